@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s-web-service/internal/agent"
+)
+
+// agentFlags registers the flags of the agent command
+func agentFlags(fs *flag.FlagSet) func(args []string) error {
+	loader := newConfigLoader(fs)
+	serverURL := fs.String("server-url", "", "URL of the k8s-web-service API to report to (overrides agent.server_url)")
+	nodeName := fs.String("node-name", os.Getenv("NODE_NAME"), "Name of the node the agent runs on (default $NODE_NAME)")
+	hostRoot := fs.String("host-root", "", "Directory the node filesystem is mounted at (overrides agent.host_root)")
+	once := fs.Bool("once", false, "Scan and report once, then exit")
+
+	return func(args []string) error {
+		cfg, err := loader.load()
+		if err != nil {
+			return err
+		}
+		if *serverURL != "" {
+			cfg.Agent.ServerURL = *serverURL
+		}
+		if *hostRoot != "" {
+			cfg.Agent.HostRoot = *hostRoot
+		}
+
+		if *nodeName == "" {
+			return fmt.Errorf("node name is required: set --node-name or NODE_NAME")
+		}
+		if cfg.Agent.ServerURL == "" {
+			return fmt.Errorf("server URL is required: set --server-url or agent.server_url")
+		}
+		if cfg.Agent.Token == "" {
+			return fmt.Errorf("agent token is required: set agent.token or AGENT_TOKEN")
+		}
+		interval, err := time.ParseDuration(cfg.Agent.Interval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid agent.interval %q", cfg.Agent.Interval)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if *once {
+			report := agent.Collect(*nodeName, cfg.Agent.HostRoot, cfg.Agent.Paths)
+			if err := agent.Send(ctx, cfg.Agent.ServerURL, cfg.Agent.Token, report); err != nil {
+				return err
+			}
+			log.Printf("Reported %d certificate files from node %s", len(report.Files), *nodeName)
+			return nil
+		}
+
+		log.Printf("Reporting certificate files under %v on node %s to %s every %s", cfg.Agent.Paths, *nodeName, cfg.Agent.ServerURL, interval)
+		agent.Run(ctx, *nodeName, cfg.Agent.HostRoot, cfg.Agent.Paths, cfg.Agent.ServerURL, cfg.Agent.Token, interval)
+		return nil
+	}
+}
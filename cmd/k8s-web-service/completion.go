@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// completionShells lists the shells completion scripts can be generated for
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionFlags registers the flags of the completion command
+func completionFlags(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s completion %s", programName, strings.Join(completionShells, "|"))
+		}
+
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(os.Stdout)
+		case "zsh":
+			writeZshCompletion(os.Stdout)
+		case "fish":
+			writeFishCompletion(os.Stdout)
+		default:
+			return fmt.Errorf("unsupported shell %q (supported: %s)", args[0], strings.Join(completionShells, ", "))
+		}
+		return nil
+	}
+}
+
+// writeBashCompletion writes a bash completion script
+func writeBashCompletion(w io.Writer) {
+	fn := "_" + strings.ReplaceAll(programName, "-", "_")
+
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+
+	fmt.Fprintf(w, "# bash completion for %s\n", programName)
+	fmt.Fprintf(w, "# Load with: source <(%s completion bash)\n", programName)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(w, "    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintf(w, "        return\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    case \"${COMP_WORDS[1]}\" in\n")
+	for _, cmd := range commands {
+		words := append([]string{}, cmd.args...)
+		for _, f := range commandFlags(cmd) {
+			words = append(words, "--"+f.Name)
+		}
+		fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", cmd.name, strings.Join(words, " "))
+	}
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -F %s %s\n", fn, programName)
+}
+
+// writeZshCompletion writes a zsh completion script
+func writeZshCompletion(w io.Writer) {
+	fn := "_" + strings.ReplaceAll(programName, "-", "_")
+
+	fmt.Fprintf(w, "#compdef %s\n", programName)
+	fmt.Fprintf(w, "# Load with: source <(%s completion zsh)\n", programName)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "    local -a commands\n")
+	fmt.Fprintf(w, "    commands=(\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "        '%s:%s'\n", cmd.name, strings.ReplaceAll(cmd.summary, "'", "'\\''"))
+	}
+	fmt.Fprintf(w, "    )\n")
+	fmt.Fprintf(w, "    if (( CURRENT == 2 )); then\n")
+	fmt.Fprintf(w, "        _describe 'command' commands\n")
+	fmt.Fprintf(w, "        return\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    shift words\n")
+	fmt.Fprintf(w, "    (( CURRENT-- ))\n")
+	fmt.Fprintf(w, "    case $words[1] in\n")
+	for _, cmd := range commands {
+		var specs []string
+		for _, f := range commandFlags(cmd) {
+			spec := fmt.Sprintf("'--%s[%s]", f.Name, zshEscape(f.Usage))
+			if !isBoolFlag(f) {
+				spec += ":value:"
+			}
+			specs = append(specs, spec+"'")
+		}
+		if len(cmd.args) > 0 {
+			specs = append(specs, fmt.Sprintf("'1:argument:(%s)'", strings.Join(cmd.args, " ")))
+		}
+		if len(specs) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "        %s) _arguments %s ;;\n", cmd.name, strings.Join(specs, " "))
+	}
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "compdef %s %s\n", fn, programName)
+}
+
+// writeFishCompletion writes a fish completion script
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintf(w, "# fish completion for %s\n", programName)
+	fmt.Fprintf(w, "# Load with: %s completion fish | source\n", programName)
+	fmt.Fprintf(w, "complete -c %s -f\n", programName)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "complete -c %s -n __fish_use_subcommand -a %s -d '%s'\n",
+			programName, cmd.name, fishEscape(cmd.summary))
+	}
+	for _, cmd := range commands {
+		condition := fmt.Sprintf("'__fish_seen_subcommand_from %s'", cmd.name)
+		if len(cmd.args) > 0 {
+			fmt.Fprintf(w, "complete -c %s -n %s -a '%s'\n", programName, condition, strings.Join(cmd.args, " "))
+		}
+		for _, f := range commandFlags(cmd) {
+			valueFlag := ""
+			if !isBoolFlag(f) {
+				valueFlag = " -r"
+			}
+			fmt.Fprintf(w, "complete -c %s -n %s -l %s%s -d '%s'\n",
+				programName, condition, f.Name, valueFlag, fishEscape(f.Usage))
+		}
+	}
+}
+
+// zshEscape makes a description safe inside a single-quoted _arguments spec
+func zshEscape(s string) string {
+	r := strings.NewReplacer("'", "'\\''", "[", "(", "]", ")", ":", "\\:")
+	return r.Replace(s)
+}
+
+// fishEscape makes a description safe inside single quotes
+func fishEscape(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "'", "\\'")
+	return r.Replace(s)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s-web-service/internal/config"
+)
+
+// configSubcommands lists the subcommands of the config command
+var configSubcommands = []string{"validate", "init"}
+
+// configFlags registers the flags of the config command
+func configFlags(fs *flag.FlagSet) func(args []string) error {
+	loader := newConfigLoader(fs)
+	strict := fs.Bool("strict", false, "Treat warnings as errors (validate)")
+	force := fs.Bool("force", false, "Overwrite an existing configuration file (init)")
+
+	return func(args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s config %s [flags]", programName, strings.Join(configSubcommands, "|"))
+		}
+
+		switch args[0] {
+		case "validate":
+			return validateConfig(loader, *strict)
+		case "init":
+			return initConfig(loader.path, *force)
+		default:
+			return fmt.Errorf("unknown config subcommand %q (supported: %s)", args[0], strings.Join(configSubcommands, ", "))
+		}
+	}
+}
+
+// validateConfig prints every configuration issue and fails if any are errors,
+// or if any are found at all in strict mode
+func validateConfig(loader *configLoader, strict bool) error {
+	cfg, err := loader.load()
+	if err != nil {
+		return err
+	}
+
+	issues, err := loader.validate(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+
+	if config.HasErrors(issues) || (strict && len(issues) > 0) {
+		return fmt.Errorf("%s: %d issue(s) found", loader.path, len(issues))
+	}
+	fmt.Printf("%s: configuration is valid\n", loader.path)
+	return nil
+}
+
+// initConfig writes the commented example configuration to path, or to
+// standard output when path is "-"
+func initConfig(path string, force bool) error {
+	if path == "-" {
+		_, err := fmt.Print(config.Example)
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, 0o600)
+	if os.IsExist(err) {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create config file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(config.Example); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/push"
+	"k8s-web-service/internal/scanner"
+)
+
+// daemonFlags registers the flags of the daemon command
+func daemonFlags(fs *flag.FlagSet) func(args []string) error {
+	loader := newConfigLoader(fs)
+	reloadInterval := fs.Duration("reload-interval", defaultReloadInterval, "How often to check the config file for changes (0 disables)")
+	once := fs.Bool("once", false, "Run a single scan, push the results, and exit (for Kubernetes CronJobs)")
+	fs.BoolVar(&loader.overrides.RememberAlerts, "remember-alerts", false, "With --once, keep delivered alerts in the <lease_name>-results ConfigMap so later runs do not deliver them again (overrides scanner.remember_alerts)")
+	failFast := fs.Bool("fail-fast", false, "Exit if the startup self-test finds the cluster unreachable or permissions missing")
+	var pushTargets []string
+	fs.Func("push-to", "Push results after a --once scan to a http(s)://, s3://bucket/prefix, or pushgateway://host:port target (repeatable)", func(target string) error {
+		pushTargets = append(pushTargets, target)
+		return nil
+	})
+
+	return func(args []string) error {
+		if len(pushTargets) > 0 && !*once {
+			return fmt.Errorf("--push-to requires --once")
+		}
+
+		cfg, err := loader.load()
+		if err != nil {
+			return err
+		}
+		issues, err := loader.validate(cfg)
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			log.Printf("%s: configuration %s: %s", issue.Severity, issue.Field, issue.Message)
+		}
+		logEffectiveConfig(cfg)
+
+		if err := startupSelfTest(cfg, *failFast); err != nil {
+			return err
+		}
+
+		var pushers []push.Pusher
+		for _, target := range pushTargets {
+			p, err := push.New(target, cfg)
+			if err != nil {
+				return err
+			}
+			pushers = append(pushers, p)
+		}
+
+		store := config.NewStore(cfg)
+		s, err := scanner.New(store, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if *once {
+			return runOnce(ctx, s, pushers, cfg.Scanner.RememberAlerts)
+		}
+
+		loader.startReloader(ctx, store, *reloadInterval)
+
+		log.Printf("Running in headless mode: HTTP server disabled")
+		s.Run(ctx)
+		return nil
+	}
+}
+
+// runOnce runs a single scan and pushes the result to every target. The
+// result is pushed even if some namespaces failed to scan; any scan or push
+// failure makes the command fail so the CronJob run is marked as failed.
+// With remember, alerts delivered by earlier runs are not delivered again;
+// failing to save the delivered alerts is logged without failing the run.
+func runOnce(ctx context.Context, s *scanner.Scanner, pushers []push.Pusher, remember bool) error {
+	scan := s.ScanOnce
+	if remember {
+		scan = s.ScanOnceRemembered
+	}
+	result, scanErr := scan(ctx)
+	if result == nil {
+		return scanErr
+	}
+
+	failed := 0
+	for _, p := range pushers {
+		if err := p.Push(ctx, result); err != nil {
+			log.Printf("Error: push to %s failed: %v", p.Target(), err)
+			failed++
+			continue
+		}
+		log.Printf("Pushed scan results to %s", p.Target())
+	}
+
+	if scanErr != nil {
+		return scanErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d pushes failed", failed, len(pushers))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/output"
+)
+
+// diffFlags registers the flags of the diff command
+func diffFlags(fs *flag.FlagSet) func(args []string) error {
+	loader := newConfigLoader(fs)
+	clusterA := fs.String("cluster-a", "", "Kubeconfig context of the first cluster (e.g. staging)")
+	clusterB := fs.String("cluster-b", "", "Kubeconfig context of the second cluster (e.g. prod)")
+	maxSkew := fs.Int("max-skew-days", 7, "Maximum difference in expiry dates before a certificate is reported")
+	format := fs.String("output", output.FormatTable, fmt.Sprintf("Output format %v", output.Formats))
+	noColor := fs.Bool("no-color", false, "Disable colors in table output")
+
+	return func(args []string) error {
+		if *clusterA == "" || *clusterB == "" {
+			return fmt.Errorf("both --cluster-a and --cluster-b are required")
+		}
+		if err := output.ValidateFormat(*format); err != nil {
+			return err
+		}
+
+		cfg, err := loader.load()
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		namespace := cfg.Kubernetes.DefaultNamespace
+
+		inventoryA, err := clusterInventory(ctx, cfg, *clusterA, namespace)
+		if err != nil {
+			return err
+		}
+		inventoryB, err := clusterInventory(ctx, cfg, *clusterB, namespace)
+		if err != nil {
+			return err
+		}
+
+		diff := k8s.DiffInventories(inventoryA, inventoryB, *maxSkew)
+		diff.ClusterA, diff.ClusterB, diff.Namespace = *clusterA, *clusterB, namespace
+
+		opts := output.Options{Color: !*noColor && output.ColorSupported(os.Stdout)}
+		return output.WriteInventoryDiff(os.Stdout, *format, diff, opts)
+	}
+}
+
+// clusterInventory collects the certificate inventory of a namespace in the
+// cluster of a kubeconfig context
+func clusterInventory(ctx context.Context, cfg *config.Config, contextName, namespace string) (map[string]*k8s.CertificateSource, error) {
+	client, err := k8s.NewClientForContext(cfg, contextName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client for %s: %w", contextName, err)
+	}
+
+	inventory, err := k8s.NamespaceInventory(ctx, client.GetClientset(), namespace)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", contextName, err)
+	}
+	return inventory, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/output"
+)
+
+// kubeconfigSubcommands lists the subcommands of the kubeconfig command
+var kubeconfigSubcommands = []string{"inspect"}
+
+// kubeconfigFlags registers the flags of the kubeconfig command
+func kubeconfigFlags(fs *flag.FlagSet) func(args []string) error {
+	loader := newConfigLoader(fs)
+	format := fs.String("output", output.FormatTable, fmt.Sprintf("Output format %v", output.Formats))
+	noColor := fs.Bool("no-color", false, "Disable color-coded status in table output")
+
+	return func(args []string) error {
+		if len(args) != 1 || args[0] != "inspect" {
+			return fmt.Errorf("usage: %s kubeconfig %s [flags]", programName, strings.Join(kubeconfigSubcommands, "|"))
+		}
+		if err := output.ValidateFormat(*format); err != nil {
+			return err
+		}
+
+		cfg, err := loader.load()
+		if err != nil {
+			return err
+		}
+
+		path, source := k8s.ResolveKubeconfigPath(cfg)
+		contexts, err := k8s.InspectKubeconfig(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Kubeconfig: %s (%s)\n\n", path, source)
+
+		opts := output.Options{Color: !*noColor && output.ColorSupported(os.Stdout)}
+		return output.WriteKubeconfigInspection(os.Stdout, *format, contexts, cfg.Scanner.WarningDays, opts)
+	}
+}
@@ -1,151 +1,207 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
-	"net/http"
+	"os"
+	"strings"
+	"time"
+	// Embeds the time zone database for server.timezone and ?tz= on hosts
+	// and images without one
+	_ "time/tzdata"
 
 	"k8s-web-service/internal/config"
-	"k8s-web-service/internal/handlers"
+	"k8s-web-service/internal/logging"
 )
 
+const (
+	programName       = "k8s-web-service"
+	defaultConfigPath = "config.yaml"
+
+	// defaultReloadInterval is how often the config file is checked for changes
+	defaultReloadInterval = 10 * time.Second
+)
+
+// command is a subcommand of the k8s-web-service binary
+type command struct {
+	name    string
+	summary string
+	// args lists the accepted positional arguments, used for shell completion
+	args []string
+	// flags registers the command's flags and returns the function that runs it
+	flags func(fs *flag.FlagSet) func(args []string) error
+}
+
+// commands is populated in init because the completion and man commands
+// generate their output from it
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "serve", summary: "Run the HTTP API server (default when no command is given)", flags: serveFlags},
+		{name: "scan", summary: "Analyze certificates in a namespace and print the results", flags: scanFlags},
+		{name: "daemon", summary: "Run the background scanner and notifiers without the HTTP server", flags: daemonFlags},
+		{name: "webhook", summary: "Run the admission webhooks that reject expired certificates and annotate Pods with their soonest expiry", flags: webhookFlags},
+		{name: "operator", summary: "Reconcile CertificateScan resources: scan, write results to their status, and emit Events", flags: operatorFlags},
+		{name: "agent", summary: "Report certificate files on this node to the API server (node agent DaemonSet)", flags: agentFlags},
+		{name: "diff", summary: "Compare certificate inventories between two clusters", flags: diffFlags},
+		{name: "kubeconfig", summary: "Inspect every kubeconfig context offline", args: kubeconfigSubcommands, flags: kubeconfigFlags},
+		{name: "config", summary: "Check the configuration file", args: configSubcommands, flags: configFlags},
+		{name: "schemas", summary: "Write the response JSON Schemas or check them against the committed contracts", args: schemasSubcommands, flags: schemasFlags},
+		{name: "completion", summary: "Print a shell completion script", args: completionShells, flags: completionFlags},
+		{name: "man", summary: "Generate man pages", flags: manFlags},
+	}
+}
+
 func main() {
-	// Load configuration
-	cfg, err := config.Load("config.yaml")
+	name := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printUsage()
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	run := cmd.flags(fs)
+	fs.Parse(args)
+
+	if err := run(fs.Args()); err != nil {
+		log.Fatalf("Error: %s: %v", cmd.name, err)
+	}
+}
+
+// findCommand looks up a subcommand by name
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// commandFlags returns the flags registered by a command without running it
+func commandFlags(cmd command) []*flag.Flag {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	cmd.flags(fs)
+
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+// isBoolFlag reports whether a flag takes no value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// printUsage lists the available subcommands
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// configLoader loads the configuration file and applies command-line
+// overrides. Precedence is flags > environment variables > config file.
+type configLoader struct {
+	fs        *flag.FlagSet
+	path      string
+	overrides config.Overrides
+}
+
+// newConfigLoader registers the configuration flags shared by all commands
+func newConfigLoader(fs *flag.FlagSet) *configLoader {
+	l := &configLoader{fs: fs}
+	fs.StringVar(&l.path, "config", defaultConfigPath, "Path to the YAML configuration file")
+	fs.StringVar(&l.overrides.DefaultNamespace, "namespace", "", "Namespace to operate on (overrides kubernetes.default_namespace)")
+	fs.StringVar(&l.overrides.Region, "region", "", "AWS region (overrides aws.region)")
+	fs.StringVar(&l.overrides.ClusterName, "cluster-name", "", "EKS cluster name (overrides kubernetes.cluster_name)")
+	fs.StringVar(&l.overrides.ClusterEndpoint, "cluster-endpoint", "", "Kubernetes API endpoint (overrides kubernetes.cluster_endpoint)")
+	fs.StringVar(&l.overrides.KubeconfigPath, "kubeconfig", "", "Path to the kubeconfig file (overrides kubernetes.kubeconfig_path)")
+	fs.StringVar(&l.overrides.FixturesDir, "fixtures", "", "Serve from YAML fixture files in this directory instead of a live cluster (overrides kubernetes.fixtures_dir)")
+	fs.IntVar(&l.overrides.WarningDays, "warning-days", 0, "Days before expiry to warn (overrides scanner.warning_days, default 30)")
+	fs.BoolVar(&l.overrides.ReadOnly, "read-only", false, "Never modify the cluster or AWS (overrides read_only)")
+	fs.StringVar(&l.overrides.LogLevel, "log-level", "", "Log level: debug, info, warn, error (overrides logging.level)")
+	fs.StringVar(&l.overrides.LogFormat, "log-format", "", "Log format: text, json (overrides logging.format)")
+	return l
+}
+
+// load reads the configuration and applies overrides and default values
+func (l *configLoader) load() (*config.Config, error) {
+	cfg, err := config.Load(l.path)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Set default values if not configured
-	if cfg.Server.Port == "" {
-		cfg.Server.Port = "8080"
+	cfg.ApplyOverrides(l.overrides)
+	cfg.SetDefaults()
+
+	if err := logging.Setup(cfg); err != nil {
+		return nil, err
 	}
-	if cfg.Server.Host == "" {
-		cfg.Server.Host = "localhost"
+	if cfg.ReadOnly {
+		log.Printf("Read-only mode: requests that modify the cluster or AWS will be refused")
 	}
-	if cfg.Kubernetes.DefaultNamespace == "" {
-		cfg.Kubernetes.DefaultNamespace = "default"
+
+	var set []string
+	l.fs.Visit(func(f *flag.Flag) {
+		set = append(set, "--"+f.Name)
+	})
+	if len(set) > 0 {
+		log.Printf("Command-line overrides: %s", strings.Join(set, " "))
 	}
 
-	log.Printf("Configuration loaded successfully")
-	log.Printf("Default namespace: %s", cfg.Kubernetes.DefaultNamespace)
-	log.Printf("AWS region for EKS: %s", cfg.AWS.Region)
-
-	// Create handlers
-	h := handlers.New(cfg)
-
-	// Setup routes
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		response := map[string]interface{}{
-			"status":  "success",
-			"message": "Kubernetes Web Service API",
-			"version": "2.0.0",
-			"server_info": map[string]interface{}{
-				"host":     cfg.Server.Host,
-				"port":     cfg.Server.Port,
-				"base_url": fmt.Sprintf("http://%s:%s", cfg.Server.Host, cfg.Server.Port),
-			},
-			"endpoints": []map[string]interface{}{
-				{
-					"path":        "/connect-k8s",
-					"method":      "GET",
-					"description": "Test Kubernetes connection",
-					"example_url": fmt.Sprintf("http://%s:%s/connect-k8s", cfg.Server.Host, cfg.Server.Port),
-				},
-				{
-					"path":        "/list-pods",
-					"method":      "GET",
-					"description": "List pods in namespace",
-					"parameters":  []string{"namespace (optional)"},
-					"example_url": fmt.Sprintf("http://%s:%s/list-pods?namespace=%s", cfg.Server.Host, cfg.Server.Port, cfg.Kubernetes.DefaultNamespace),
-				},
-				{
-					"path":        "/cluster-ca",
-					"method":      "GET",
-					"description": "Get cluster CA certificate",
-					"example_url": fmt.Sprintf("http://%s:%s/cluster-ca", cfg.Server.Host, cfg.Server.Port),
-				},
-				{
-					"path":        "/cluster-ca-expiry",
-					"method":      "GET",
-					"description": "Analyze cluster CA certificate expiry with detailed date information",
-					"parameters":  []string{"warning_days (optional, default: 30)"},
-					"example_url": fmt.Sprintf("http://%s:%s/cluster-ca-expiry?warning_days=365", cfg.Server.Host, cfg.Server.Port),
-					"response_includes": []string{
-						"formatted_dates", "time_remaining", "expiry_status", "validity_period",
-					},
-				},
-				{
-					"path":        "/pod-certificates",
-					"method":      "GET",
-					"description": "Analyze pod certificates (use ?detailed=true for expiry analysis)",
-					"parameters":  []string{"namespace (optional)", "detailed (optional)", "warning_days (optional)"},
-					"example_url": fmt.Sprintf("http://%s:%s/pod-certificates?detailed=true&warning_days=90", cfg.Server.Host, cfg.Server.Port),
-				},
-				{
-					"path":        "/pod-certificates/{pod-name}",
-					"method":      "GET",
-					"description": "Detailed certificate analysis for specific pod",
-					"parameters":  []string{"namespace (optional)", "warning_days (optional)"},
-					"example_url": fmt.Sprintf("http://%s:%s/pod-certificates/example-pod?namespace=%s&warning_days=30", cfg.Server.Host, cfg.Server.Port, cfg.Kubernetes.DefaultNamespace),
-				},
-				{
-					"path":        "/certificate-expiry",
-					"method":      "GET",
-					"description": "Certificate expiry analysis across namespace",
-					"parameters":  []string{"namespace (optional)", "warning_days (optional)"},
-					"example_url": fmt.Sprintf("http://%s:%s/certificate-expiry?namespace=%s&warning_days=60", cfg.Server.Host, cfg.Server.Port, cfg.Kubernetes.DefaultNamespace),
-				},
-				{
-					"path":        "/debug",
-					"method":      "GET",
-					"description": "Debug AWS and Kubernetes configuration",
-					"example_url": fmt.Sprintf("http://%s:%s/debug", cfg.Server.Host, cfg.Server.Port),
-				},
-				{
-					"path":        "/test-k8s-auth",
-					"method":      "GET",
-					"description": "Test Kubernetes authentication",
-					"example_url": fmt.Sprintf("http://%s:%s/test-k8s-auth", cfg.Server.Host, cfg.Server.Port),
-				},
-				{
-					"path":        "/api-docs",
-					"method":      "GET",
-					"description": "Detailed API documentation with examples",
-					"example_url": fmt.Sprintf("http://%s:%s/api-docs", cfg.Server.Host, cfg.Server.Port),
-				},
-			},
-			"postman_tips": []string{
-				"All endpoints return JSON responses",
-				"Use query parameters to customize responses",
-				"Set Content-Type: application/json in headers",
-				"Check the /api-docs endpoint for detailed examples",
-			},
-		}
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Printf("Error encoding response: %v", err)
+	return cfg, nil
+}
+
+// validate checks the configuration file for unknown keys and the merged
+// configuration for invalid values
+func (l *configLoader) validate(cfg *config.Config) ([]config.Issue, error) {
+	issues, err := config.CheckFile(l.path)
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, cfg.Validate()...), nil
+}
+
+// startReloader creates a reloader for the loaded configuration and, when
+// interval is positive, polls the configuration file for changes
+func (l *configLoader) startReloader(ctx context.Context, store *config.Store, interval time.Duration) *config.Reloader {
+	reloader := config.NewReloader(l.path, l.overrides, store)
+	store.OnChange(func(prev, next *config.Config) {
+		if prev.Logging != next.Logging {
+			if err := logging.Setup(next); err != nil {
+				log.Printf("Error: failed to apply logging settings: %v", err)
+			}
 		}
 	})
+	if interval > 0 {
+		log.Printf("Watching %s for configuration changes every %s", l.path, interval)
+		go reloader.Watch(ctx, interval)
+	}
+	return reloader
+}
 
-	http.HandleFunc("/connect-k8s", h.ConnectK8sHandler)
-	http.HandleFunc("/list-pods", h.ListPodsHandler)
-	http.HandleFunc("/cluster-ca", h.ClusterCAHandler)
-	http.HandleFunc("/cluster-ca-expiry", h.HandleClusterCACertificateExpiry)
-	http.HandleFunc("/pod-certificates/", h.HandlePodCertificateDetails)
-	http.HandleFunc("/pod-certificates", h.HandlePodCertificates)
-	http.HandleFunc("/certificate-expiry", h.HandleCertificateExpiry)
-	http.HandleFunc("/debug", h.DebugHandler)
-	http.HandleFunc("/test-k8s-auth", h.TestK8sAuthHandler)
-	http.HandleFunc("/api-docs", h.APIDocsHandler)
-
-	// Start server
-	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
-	log.Printf("Server starting on %s", addr)
-
-	if err := http.ListenAndServe(addr, nil); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+// logEffectiveConfig logs the merged configuration with credentials masked
+func logEffectiveConfig(cfg *config.Config) {
+	data, err := json.Marshal(cfg.Redacted())
+	if err != nil {
+		log.Printf("Error: failed to encode effective configuration: %v", err)
+		return
 	}
+	log.Printf("Effective configuration: %s", data)
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// manFlags registers the flags of the man command
+func manFlags(fs *flag.FlagSet) func(args []string) error {
+	dir := fs.String("dir", "", "Write one man page per command into this directory instead of printing the main page")
+
+	return func(args []string) error {
+		if *dir == "" {
+			writeMainManPage(os.Stdout)
+			return nil
+		}
+
+		if err := os.MkdirAll(*dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create man page directory: %w", err)
+		}
+
+		var buf bytes.Buffer
+		writeMainManPage(&buf)
+		if err := writeManFile(*dir, programName, buf.Bytes()); err != nil {
+			return err
+		}
+
+		for _, cmd := range commands {
+			buf.Reset()
+			writeCommandManPage(&buf, cmd)
+			if err := writeManFile(*dir, programName+"-"+cmd.name, buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// writeManFile writes a section 1 man page into dir
+func writeManFile(dir, name string, content []byte) error {
+	path := filepath.Join(dir, name+".1")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write man page %s: %w", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	return nil
+}
+
+// writeMainManPage writes the man page describing all commands and their flags
+func writeMainManPage(w io.Writer) {
+	fmt.Fprintf(w, ".TH %s 1 \"\" \"%s\" \"User Commands\"\n", strings.ToUpper(programName), programName)
+	fmt.Fprintf(w, ".SH NAME\n%s \\- Kubernetes certificate analysis service and CLI\n", roffEscape(programName))
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s\n[\\fIcommand\\fR] [\\fIflags\\fR]\n", roffEscape(programName))
+	fmt.Fprintf(w, ".SH DESCRIPTION\nAnalyzes certificate expiry across Kubernetes clusters. ")
+	fmt.Fprintf(w, "Without a command, the HTTP API server is started.\n")
+
+	fmt.Fprintf(w, ".SH COMMANDS\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roffEscape(cmd.name), roffEscape(cmd.summary))
+	}
+
+	for _, cmd := range commands {
+		flags := commandFlags(cmd)
+		if len(flags) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, ".SH \"%s FLAGS\"\n", strings.ToUpper(cmd.name))
+		writeManFlags(w, flags)
+	}
+
+	fmt.Fprintf(w, ".SH \"SEE ALSO\"\n")
+	var refs []string
+	for _, cmd := range commands {
+		refs = append(refs, fmt.Sprintf(".BR %s (1)", roffEscape(programName+"-"+cmd.name)))
+	}
+	fmt.Fprintf(w, "%s\n", strings.Join(refs, ",\n"))
+}
+
+// writeCommandManPage writes the man page of a single command
+func writeCommandManPage(w io.Writer, cmd command) {
+	name := programName + "-" + cmd.name
+	fmt.Fprintf(w, ".TH %s 1 \"\" \"%s\" \"User Commands\"\n", strings.ToUpper(name), programName)
+	fmt.Fprintf(w, ".SH NAME\n%s \\- %s\n", roffEscape(name), roffEscape(cmd.summary))
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s %s\n[\\fIflags\\fR]", roffEscape(programName), roffEscape(cmd.name))
+	if len(cmd.args) > 0 {
+		fmt.Fprintf(w, " \\fI%s\\fR", roffEscape(strings.Join(cmd.args, "|")))
+	}
+	fmt.Fprintf(w, "\n")
+
+	if flags := commandFlags(cmd); len(flags) > 0 {
+		fmt.Fprintf(w, ".SH FLAGS\n")
+		writeManFlags(w, flags)
+	}
+
+	fmt.Fprintf(w, ".SH \"SEE ALSO\"\n.BR %s (1)\n", roffEscape(programName))
+}
+
+// writeManFlags writes a tagged paragraph per flag
+func writeManFlags(w io.Writer, flags []*flag.Flag) {
+	for _, f := range flags {
+		fmt.Fprintf(w, ".TP\n.B \\-\\-%s", roffEscape(f.Name))
+		if !isBoolFlag(f) {
+			fmt.Fprintf(w, " \\fIvalue\\fR")
+		}
+		fmt.Fprintf(w, "\n%s", roffEscape(f.Usage))
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			fmt.Fprintf(w, " (default: %s)", roffEscape(f.DefValue))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+// roffEscape escapes text for use in a roff document
+func roffEscape(s string) string {
+	s = strings.NewReplacer("\\", "\\e", "-", "\\-").Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/operator"
+)
+
+// operatorName names the controller in Events and its leader election lease
+const operatorName = "k8s-web-service-operator"
+
+// operatorFlags registers the flags of the operator command
+func operatorFlags(fs *flag.FlagSet) func(args []string) error {
+	loader := newConfigLoader(fs)
+	reloadInterval := fs.Duration("reload-interval", defaultReloadInterval, "How often to check the config file for changes (0 disables)")
+	leaderElect := fs.Bool("leader-elect", false, "Elect a leader so only one of several replicas reconciles")
+	leaderElectionNamespace := fs.String("leader-election-namespace", "", "Namespace of the leader election lease (required outside the cluster)")
+	probeAddr := fs.String("health-probe-bind-address", ":8081", "Address of the /healthz and /readyz probes")
+	metricsAddr := fs.String("metrics-bind-address", "0", "Address of the controller metrics endpoint (0 disables)")
+
+	return func(args []string) error {
+		cfg, err := loader.load()
+		if err != nil {
+			return err
+		}
+		if _, err := loader.validate(cfg); err != nil {
+			return err
+		}
+		if cfg.ReadOnly {
+			return fmt.Errorf("the operator writes CertificateScan status and Events, which read_only forbids")
+		}
+		logEffectiveConfig(cfg)
+
+		scheme := runtime.NewScheme()
+		if err := clientgoscheme.AddToScheme(scheme); err != nil {
+			return fmt.Errorf("failed to register Kubernetes types: %w", err)
+		}
+		if err := operator.AddToScheme(scheme); err != nil {
+			return fmt.Errorf("failed to register CertificateScan types: %w", err)
+		}
+
+		// The manager uses the in-cluster configuration, or the kubeconfig
+		// named by KUBECONFIG; scans use the configured cluster
+		restConfig, err := ctrl.GetConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load the manager's Kubernetes configuration: %w", err)
+		}
+		mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+			Scheme:                  scheme,
+			Metrics:                 metricsserver.Options{BindAddress: *metricsAddr},
+			HealthProbeBindAddress:  *probeAddr,
+			LeaderElection:          *leaderElect,
+			LeaderElectionID:        operatorName,
+			LeaderElectionNamespace: *leaderElectionNamespace,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create controller manager: %w", err)
+		}
+		if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+			return fmt.Errorf("failed to add health check: %w", err)
+		}
+		if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
+			return fmt.Errorf("failed to add readiness check: %w", err)
+		}
+
+		store := config.NewStore(cfg)
+		reconciler := operator.NewReconciler(mgr.GetClient(), store, mgr.GetEventRecorderFor(operatorName))
+		if err := reconciler.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("failed to set up CertificateScan controller: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		loader.startReloader(ctx, store, *reloadInterval)
+
+		log.Printf("Operator reconciling CertificateScans (%s/%s)", operator.GroupVersion.Group, operator.GroupVersion.Version)
+		if err := mgr.Start(ctx); err != nil {
+			return fmt.Errorf("controller manager failed: %w", err)
+		}
+		return nil
+	}
+}
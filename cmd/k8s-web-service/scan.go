@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/output"
+)
+
+// scanFlags registers the flags of the scan command
+func scanFlags(fs *flag.FlagSet) func(args []string) error {
+	loader := newConfigLoader(fs)
+	format := fs.String("output", output.FormatTable, fmt.Sprintf("Output format %v", output.Formats))
+	noColor := fs.Bool("no-color", false, "Disable color-coded status in table output")
+	watch := fs.Bool("watch", false, "Keep running, re-scan on an interval, and print only changes")
+	interval := fs.Duration("interval", 0, "Time between scans in watch mode (default scanner.interval)")
+
+	return func(args []string) error {
+		if err := output.ValidateFormat(*format); err != nil {
+			return err
+		}
+		if *watch && *format == output.FormatYAML {
+			return fmt.Errorf("watch mode supports %s and %s output", output.FormatTable, output.FormatJSON)
+		}
+
+		cfg, err := loader.load()
+		if err != nil {
+			return err
+		}
+		if cfg.Scanner.WarningDays <= 0 {
+			return fmt.Errorf("warning days must be positive, got %d", cfg.Scanner.WarningDays)
+		}
+
+		client, err := k8s.NewClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+
+		opts := output.Options{Color: !*noColor && output.ColorSupported(os.Stdout)}
+
+		if *watch {
+			return watchScan(cfg, client, *format, *interval, opts)
+		}
+
+		report, err := k8s.AnalyzeNamespaceExpiry(context.Background(), client, cfg.Kubernetes.DefaultNamespace, cfg.Scanner.WarningDays)
+		if err != nil {
+			return err
+		}
+		return output.WriteExpiryReport(os.Stdout, *format, report, opts)
+	}
+}
+
+// watchScan prints the initial report, then re-scans on every interval and
+// prints the certificates that were added, removed, renewed, or changed
+// status, until interrupted
+func watchScan(cfg *config.Config, client *k8s.Client, format string, interval time.Duration, opts output.Options) error {
+	if interval == 0 {
+		var err error
+		if interval, err = cfg.ScanInterval(); err != nil {
+			return err
+		}
+	}
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", interval)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	namespace := cfg.Kubernetes.DefaultNamespace
+	watcher := output.NewWatcher()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for first := true; ; first = false {
+		report, err := k8s.AnalyzeNamespaceExpiry(ctx, client, namespace, cfg.Scanner.WarningDays)
+		switch {
+		case err != nil && first:
+			return err
+		case err != nil:
+			log.Printf("Error: scan of namespace %s failed: %v", namespace, err)
+		default:
+			changes := watcher.Update(report, time.Now())
+			if first && format == output.FormatTable {
+				if err := output.WriteExpiryReport(os.Stdout, format, report, opts); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "\nWatching namespace %s every %s (Ctrl-C to stop)\n", namespace, interval)
+			}
+			if err := output.WriteChanges(os.Stdout, format, changes, opts); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
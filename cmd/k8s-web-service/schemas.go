@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s-web-service/pkg/api"
+)
+
+// schemasSubcommands lists the subcommands of the schemas command
+var schemasSubcommands = []string{"write", "check"}
+
+// schemasFlags registers the flags of the schemas command
+func schemasFlags(fs *flag.FlagSet) func(args []string) error {
+	dir := fs.String("dir", "schemas", "Directory of the committed response schemas")
+
+	return func(args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s schemas %s [flags]", programName, strings.Join(schemasSubcommands, "|"))
+		}
+
+		switch args[0] {
+		case "write":
+			return writeSchemas(*dir)
+		case "check":
+			return checkSchemas(*dir)
+		default:
+			return fmt.Errorf("unknown schemas subcommand %q (supported: %s)", args[0], strings.Join(schemasSubcommands, ", "))
+		}
+	}
+}
+
+// writeSchemas writes the JSON Schema of every published response body to
+// dir, replacing the committed contracts
+func writeSchemas(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create schema directory: %w", err)
+	}
+	for _, name := range api.SchemaNames() {
+		data, err := encodeSchema(name)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// encodeSchema returns the JSON Schema of a published response body as
+// committed by writeSchemas
+func encodeSchema(name string) ([]byte, error) {
+	schema, err := api.ResponseSchema(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema %s: %w", name, err)
+	}
+	return append(data, '\n'), nil
+}
+
+// checkSchemas compares the response schemas with the contracts committed
+// in dir and fails on breaking changes, so a renamed or removed field fails
+// the build instead of the clients. A published schema without a committed
+// contract fails too, since nothing would protect its clients.
+func checkSchemas(dir string) error {
+	breaking, missing := 0, 0
+	report := func(name string, change api.SchemaChange) {
+		if change.Breaking {
+			breaking++
+		}
+		fmt.Printf("%s: %s\n", name, change)
+	}
+
+	published := make(map[string]bool)
+	for _, name := range api.SchemaNames() {
+		published[name] = true
+		schema, err := api.ResponseSchema(name)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, name+".json")
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			missing++
+			fmt.Printf("%s: no committed contract at %s\n", name, path)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read schema: %w", err)
+		}
+		var committed api.Schema
+		if err := json.Unmarshal(data, &committed); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		if committed.Properties[api.SchemaVersionField] != nil && committed.Properties[api.SchemaVersionField].Const != api.SchemaVersion {
+			fmt.Printf("%s: schema_version changed from %s to %s\n", name, committed.Properties[api.SchemaVersionField].Const, api.SchemaVersion)
+			continue
+		}
+		for _, change := range api.CompareSchemas(&committed, schema) {
+			if change.Path == "$."+api.SchemaVersionField {
+				continue
+			}
+			report(name, change)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list schemas: %w", err)
+	}
+	for _, file := range files {
+		if name := strings.TrimSuffix(filepath.Base(file), ".json"); !published[name] {
+			report(name, api.SchemaChange{Path: "$", Change: "schema no longer published", Breaking: true})
+		}
+	}
+
+	if missing > 0 {
+		return fmt.Errorf("%d response schema(s) have no committed contract; run '%s schemas write' and commit %s", missing, programName, dir)
+	}
+	if breaking > 0 {
+		return fmt.Errorf("%d breaking change(s) to the response contracts; restore the fields, or bump api.SchemaVersion to the next major version and run '%s schemas write'", breaking, programName)
+	}
+	fmt.Println("response contracts are compatible")
+	return nil
+}
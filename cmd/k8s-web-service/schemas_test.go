@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s-web-service/pkg/api"
+)
+
+// committedSchemas is the directory of the committed response contracts,
+// relative to this package
+const committedSchemas = "../../schemas"
+
+// TestCommittedSchemas regenerates every response schema from pkg/api and
+// compares it with the committed contract, so a change to a response type
+// fails until the contract is rewritten with 'schemas write' and reviewed
+func TestCommittedSchemas(t *testing.T) {
+	published := make(map[string]bool)
+	for _, name := range api.SchemaNames() {
+		published[name] = true
+		want, err := encodeSchema(name)
+		if err != nil {
+			t.Fatalf("encodeSchema(%s): %v", name, err)
+		}
+		got, err := os.ReadFile(filepath.Join(committedSchemas, name+".json"))
+		if err != nil {
+			t.Errorf("schema %s is not committed: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("committed schema %s differs from pkg/api; run 'go run ./cmd/k8s-web-service schemas write' and review the diff", name)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(committedSchemas, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if name := strings.TrimSuffix(filepath.Base(file), ".json"); !published[name] {
+			t.Errorf("committed schema %s is no longer published", name)
+		}
+	}
+}
+
+func TestCheckSchemasFailsOnMissingContract(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeSchemas(dir); err != nil {
+		t.Fatalf("writeSchemas: %v", err)
+	}
+	if err := checkSchemas(dir); err != nil {
+		t.Fatalf("checkSchemas of freshly written schemas: %v", err)
+	}
+
+	name := api.SchemaNames()[0]
+	if err := os.Remove(filepath.Join(dir, name+".json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSchemas(dir); err == nil {
+		t.Errorf("checkSchemas succeeded without the contract of %s", name)
+	}
+}
+
+func TestCheckSchemasFailsOnBreakingChange(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeSchemas(dir); err != nil {
+		t.Fatalf("writeSchemas: %v", err)
+	}
+	if err := checkSchemas(dir); err != nil {
+		t.Fatalf("checkSchemas of freshly written schemas: %v", err)
+	}
+
+	// A field the committed contract has and pkg/api no longer does was
+	// removed or renamed
+	name := api.SchemaNames()[0]
+	path := filepath.Join(dir, name+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var committed api.Schema
+	if err := json.Unmarshal(data, &committed); err != nil {
+		t.Fatal(err)
+	}
+	committed.Properties["removed_field"] = &api.Schema{Type: "string"}
+	if data, err = json.Marshal(committed); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSchemas(dir); err == nil {
+		t.Errorf("checkSchemas succeeded with a field removed from %s", name)
+	}
+}
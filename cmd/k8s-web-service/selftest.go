@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+)
+
+// selfTestTimeout bounds the startup self-test so an unreachable cluster
+// does not delay startup indefinitely
+const selfTestTimeout = 30 * time.Second
+
+// startupSelfTest runs the /test-k8s-auth checks and logs a summary. With
+// failFast, it returns an error if the cluster is unreachable or a required
+// permission is missing.
+func startupSelfTest(cfg *config.Config, failFast bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+	defer cancel()
+
+	report := k8s.RunAuthChecks(ctx, cfg)
+	failed := report.Failed()
+
+	for _, check := range failed {
+		slog.Warn("Startup self-test check failed",
+			"check", check.Name,
+			"namespace", check.Namespace,
+			"error", check.Error)
+	}
+
+	level := slog.LevelInfo
+	if len(failed) > 0 {
+		level = slog.LevelWarn
+	}
+	slog.Log(ctx, level, "Startup self-test completed",
+		"status", report.Status,
+		"passed", len(report.Checks)-len(failed),
+		"failed", len(failed))
+
+	if failFast && len(failed) > 0 {
+		return fmt.Errorf("startup self-test failed: %d check(s) failed (see /test-k8s-auth)", len(failed))
+	}
+	return nil
+}
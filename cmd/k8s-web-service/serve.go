@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/handlers"
+	"k8s-web-service/internal/lifecycle"
+	"k8s-web-service/internal/scanner"
+)
+
+// serverReadHeaderTimeout bounds reading request headers, so that slow
+// clients cannot hold connections open
+const serverReadHeaderTimeout = 10 * time.Second
+
+// serveFlags registers the flags of the serve command
+func serveFlags(fs *flag.FlagSet) func(args []string) error {
+	loader := newConfigLoader(fs)
+	fs.StringVar(&loader.overrides.Host, "host", "", "Server bind address (overrides server.host)")
+	fs.StringVar(&loader.overrides.Port, "port", "", "Server port (overrides server.port)")
+	fs.StringVar(&loader.overrides.TLSCertFile, "tls-cert-file", "", "TLS certificate to serve HTTPS (overrides server.tls.cert_file)")
+	fs.StringVar(&loader.overrides.TLSKeyFile, "tls-key-file", "", "TLS key (overrides server.tls.key_file)")
+	fs.StringVar(&loader.overrides.TLSClientCAFile, "tls-client-ca-file", "", "CA verifying client certificates (overrides server.tls.client_ca_file)")
+	reloadInterval := fs.Duration("reload-interval", defaultReloadInterval, "How often to check the config file for changes (0 disables)")
+	strict := fs.Bool("strict", false, "Refuse to start if the configuration has any warnings or errors")
+	failFast := fs.Bool("fail-fast", false, "Exit if the startup self-test finds the cluster unreachable or permissions missing")
+
+	return func(args []string) error {
+		cfg, err := loader.load()
+		if err != nil {
+			return err
+		}
+
+		issues, err := loader.validate(cfg)
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			log.Printf("%s: configuration %s: %s", issue.Severity, issue.Field, issue.Message)
+		}
+		if *strict && len(issues) > 0 {
+			return fmt.Errorf("refusing to start in strict mode: %d configuration issue(s) found", len(issues))
+		}
+
+		if err := startupSelfTest(cfg, *failFast); err != nil {
+			return err
+		}
+
+		store := config.NewStore(cfg)
+		reloader := loader.startReloader(context.Background(), store, *reloadInterval)
+		return runServer(store, reloader)
+	}
+}
+
+// runServer starts the HTTP API server and blocks until it fails or is
+// shut down by SIGTERM or SIGINT
+func runServer(store *config.Store, reloader *config.Reloader) error {
+	cfg := store.Get()
+	log.Printf("Configuration loaded successfully")
+	logEffectiveConfig(cfg)
+	log.Printf("Default namespace: %s", cfg.Kubernetes.DefaultNamespace)
+	log.Printf("AWS region for EKS: %s", cfg.AWS.Region)
+
+	// Scan jobs are tracked so the server can be drained for maintenance
+	jobs := lifecycle.NewTracker()
+
+	// Start the background scanner alongside the API when enabled
+	var s *scanner.Scanner
+	if cfg.Scanner.Enabled {
+		var err error
+		s, err = scanner.New(store, jobs)
+		if err != nil {
+			return err
+		}
+		go s.Run(context.Background())
+	}
+
+	// Create handlers
+	h := handlers.New(store, reloader, jobs, s)
+
+	// Setup routes
+	h.Register(http.DefaultServeMux)
+
+	// Start server
+	readTimeout, writeTimeout, _ := cfg.ServerTimeouts()
+	server := &http.Server{
+		Addr:              fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port),
+		ReadHeaderTimeout: serverReadHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+	}
+	scheme := "http"
+	if cfg.ServerTLSEnabled() {
+		tlsConfig, err := serverTLSConfig(cfg)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = tlsConfig
+		scheme = "https"
+	}
+	log.Printf("Server starting on %s://%s", scheme, server.Addr)
+	if cfg.ClientCertificateRequired() {
+		log.Printf("Client certificates signed by %s are required", cfg.Server.TLS.ClientCAFile)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := make(chan error, 1)
+	go func() {
+		if server.TLSConfig != nil {
+			serveErr <- server.ListenAndServeTLS("", "")
+		} else {
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server failed to start: %w", err)
+	case <-ctx.Done():
+	}
+	// A second signal exits immediately
+	stop()
+	return shutdownServer(server, store.Get(), jobs)
+}
+
+// shutdownServer drains the server: /readyz fails and new scans are refused,
+// the listener is closed, and running requests and background scans get
+// server.shutdown_timeout to finish
+func shutdownServer(server *http.Server, cfg *config.Config, jobs *lifecycle.Tracker) error {
+	_, _, timeout := cfg.ServerTimeouts()
+	log.Printf("Shutting down: waiting up to %s for running requests and scans", timeout)
+	jobs.Drain()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Warning: requests still running after %s were dropped: %v", timeout, err)
+	}
+	if active := jobs.Wait(ctx); active > 0 {
+		log.Printf("Warning: %d scan(s) still running after %s were dropped", active, timeout)
+	}
+	log.Printf("Server stopped")
+	return nil
+}
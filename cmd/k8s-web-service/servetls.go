@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"k8s-web-service/internal/config"
+)
+
+// servingCertificate holds the API's TLS certificate and loads it again
+// when the certificate file changes, so that a renewed certificate, such as
+// one cert-manager writes to a mounted secret, is served without a restart
+type servingCertificate struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// get returns the current certificate for a TLS handshake. A certificate
+// that fails to load, for example while only one of the files was updated,
+// is retried on the next handshake and the previous one served meanwhile.
+func (s *servingCertificate) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.certFile)
+	if err != nil {
+		if s.cert == nil {
+			return nil, fmt.Errorf("failed to read TLS certificate: %w", err)
+		}
+		return s.cert, nil
+	}
+	if s.cert != nil && !info.ModTime().After(s.modTime) {
+		return s.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		if s.cert == nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		log.Printf("Warning: failed to reload TLS certificate %s, serving the previous one: %v", s.certFile, err)
+		return s.cert, nil
+	}
+	if s.cert != nil {
+		log.Printf("Reloaded TLS certificate %s", s.certFile)
+	}
+	s.cert = &cert
+	s.modTime = info.ModTime()
+	return s.cert, nil
+}
+
+// serverTLSConfig returns the TLS configuration of the API server. Client
+// certificates are verified against server.tls.client_ca_file when they
+// are presented; requests without one are refused by the handlers when
+// server.tls.client_auth is require, so that probes and agent reports can
+// be let through.
+func serverTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	certificate := &servingCertificate{certFile: cfg.Server.TLS.CertFile, keyFile: cfg.Server.TLS.KeyFile}
+	if _, err := certificate.get(nil); err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certificate.get,
+	}
+
+	if caFile := cfg.Server.TLS.ClientCAFile; caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("client CA %s holds no PEM certificates", caFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
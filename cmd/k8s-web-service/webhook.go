@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"k8s-web-service/internal/admission"
+	"k8s-web-service/internal/config"
+)
+
+// webhookShutdownTimeout is how long in-flight reviews may finish on exit
+const webhookShutdownTimeout = 10 * time.Second
+
+// webhookFlags registers the flags of the webhook command
+func webhookFlags(fs *flag.FlagSet) func(args []string) error {
+	loader := newConfigLoader(fs)
+	fs.StringVar(&loader.overrides.AdmissionPort, "port", "", "HTTPS port of the webhook (overrides admission.port)")
+	fs.StringVar(&loader.overrides.AdmissionCertFile, "tls-cert-file", "", "TLS serving certificate (overrides admission.cert_file)")
+	fs.StringVar(&loader.overrides.AdmissionKeyFile, "tls-key-file", "", "TLS serving key (overrides admission.key_file)")
+	fs.StringVar(&loader.overrides.AdmissionMode, "mode", "", "deny or warn (overrides admission.mode)")
+	windowDays := fs.Int("window-days", -1, "Also flag certificates expiring within this many days (overrides admission.window_days)")
+	reloadInterval := fs.Duration("reload-interval", defaultReloadInterval, "How often to check the config file for changes (0 disables)")
+
+	return func(args []string) error {
+		if *windowDays >= 0 {
+			loader.overrides.AdmissionWindowDays = windowDays
+		}
+		cfg, err := loader.load()
+		if err != nil {
+			return err
+		}
+
+		issues, err := loader.validate(cfg)
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			if issue.Severity == config.SeverityError && strings.HasPrefix(issue.Field, "admission") {
+				return fmt.Errorf("invalid configuration: %s", issue)
+			}
+		}
+		if cfg.Admission.CertFile == "" {
+			return fmt.Errorf("a TLS certificate is required: set --tls-cert-file and --tls-key-file or admission.cert_file and admission.key_file")
+		}
+
+		store := config.NewStore(cfg)
+		server, err := admission.New(store)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		loader.startReloader(ctx, store, *reloadInterval)
+
+		httpServer := &http.Server{
+			Addr:              ":" + cfg.Admission.Port,
+			Handler:           server.Handler(),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+			defer cancel()
+			httpServer.Shutdown(shutdownCtx)
+		}()
+
+		log.Printf("Admission webhooks listening on :%s (%s: mode %s, window %d days; %s: expiry annotations)", cfg.Admission.Port, admission.ValidatePath, cfg.Admission.Mode, cfg.Admission.WindowDays, admission.MutatePath)
+		if err := httpServer.ListenAndServeTLS(cfg.Admission.CertFile, cfg.Admission.KeyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("webhook server failed: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,254 @@
+// Package admission implements the admission webhook mode: the API server
+// sends the Pods being created and the Ingresses being created or updated,
+// and the webhook rejects or warns about those that reference secrets whose certificates are
+// expired or expire within the configured window, before they are deployed.
+// A mutating webhook annotates new Pods with the soonest expiry of their
+// mounted certificates.
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+)
+
+// Paths called by the ValidatingWebhookConfiguration and the
+// MutatingWebhookConfiguration
+const (
+	ValidatePath = "/validate"
+	MutatePath   = "/mutate"
+)
+
+// maxReviewSize bounds the AdmissionReview request body
+const maxReviewSize = 3 << 20
+
+// reviewTimeout bounds the secret lookups of one review, below the 10
+// second default timeout of webhook calls
+const reviewTimeout = 8 * time.Second
+
+// Server answers AdmissionReview requests
+type Server struct {
+	store  *config.Store
+	client *k8s.Client
+}
+
+// New creates a webhook server with a Kubernetes client for reading the
+// referenced secrets
+func New(store *config.Store) (*Server, error) {
+	client, err := k8s.NewClient(store.Get())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return &Server{store: store, client: client}, nil
+}
+
+// Handler returns the routes of the webhook server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePath, s.handleReview(s.review))
+	mux.HandleFunc(MutatePath, s.handleReview(s.mutate))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}` + "\n"))
+	})
+	return mux
+}
+
+// handleReview decodes an AdmissionReview, answers its request with review,
+// and responds with the review
+func (s *Server) handleReview(review func(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxReviewSize))
+		if err != nil {
+			http.Error(w, "failed to read request", http.StatusBadRequest)
+			return
+		}
+		var admissionReview admissionv1.AdmissionReview
+		if err := json.Unmarshal(body, &admissionReview); err != nil || admissionReview.Request == nil {
+			http.Error(w, "request is not an AdmissionReview", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), reviewTimeout)
+		defer cancel()
+		admissionReview.Response = review(ctx, admissionReview.Request)
+		admissionReview.Response.UID = admissionReview.Request.UID
+		admissionReview.Request = nil
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(admissionReview)
+	}
+}
+
+// review checks the certificates of the secrets a Pod being created, or an
+// Ingress being created or updated, references. Pod updates are allowed:
+// the volumes of a pod cannot change, and denying updates would block
+// controllers from labelling pods or removing finalizers of pods being
+// deleted. Objects of other kinds, deletions, and objects that cannot be
+// decoded are allowed, so a misconfigured webhook never blocks unrelated
+// changes.
+func (s *Server) review(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	allowed := &admissionv1.AdmissionResponse{Allowed: true}
+	switch {
+	case req.Operation == admissionv1.Create:
+	case req.Operation == admissionv1.Update && req.Kind.Kind != "Pod":
+	default:
+		return allowed
+	}
+
+	secrets, err := SecretReferences(req.Kind.Kind, req.Object.Raw)
+	if err != nil {
+		log.Printf("Warning: admission: %s %s/%s: %v", req.Kind.Kind, req.Namespace, req.Name, err)
+		return allowed
+	}
+	if len(secrets) == 0 {
+		return allowed
+	}
+
+	cfg := s.store.Get()
+	problems := s.certificateProblems(ctx, req.Namespace, secrets, cfg.Admission.WindowDays)
+	if len(problems) == 0 {
+		return allowed
+	}
+
+	name := req.Name
+	if name == "" {
+		name = objectName(req.Object.Raw)
+	}
+	if cfg.Admission.Mode == config.AdmissionWarn {
+		allowed.Warnings = problems
+		log.Printf("Admission: warned on %s %s/%s: %s", req.Kind.Kind, req.Namespace, name, strings.Join(problems, "; "))
+		return allowed
+	}
+	log.Printf("Admission: denied %s %s/%s: %s", req.Kind.Kind, req.Namespace, name, strings.Join(problems, "; "))
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: fmt.Sprintf("%s references expired or expiring certificates: %s", req.Kind.Kind, strings.Join(problems, "; ")),
+		},
+	}
+}
+
+// certificateProblems describes the expired certificates, and those
+// expiring within windowDays, of the named secrets of a namespace. Secrets
+// that do not exist yet are skipped, as they may be created by the same
+// deployment; secrets that cannot be read are skipped with a warning in the
+// log.
+func (s *Server) certificateProblems(ctx context.Context, namespace string, secrets []string, windowDays int) []string {
+	var problems []string
+	for _, name := range secrets {
+		source, err := k8s.ExtractCertificatesFromSecret(ctx, s.client.GetClientset(), namespace, name)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Printf("Warning: admission: failed to read secret %s/%s: %v", namespace, name, err)
+			}
+			continue
+		}
+		for _, cert := range source.Certificates {
+			switch {
+			case cert.IsExpired:
+				problems = append(problems, fmt.Sprintf("secret %s: certificate %s expired on %s", name, cert.Subject, cert.NotAfter.Format("2006-01-02")))
+			case windowDays > 0 && cert.DaysUntilExp < windowDays:
+				problems = append(problems, fmt.Sprintf("secret %s: certificate %s expires in %d days, on %s", name, cert.Subject, cert.DaysUntilExp, cert.NotAfter.Format("2006-01-02")))
+			}
+		}
+	}
+	return problems
+}
+
+// SecretReferences returns the secrets whose certificates a Pod or Ingress
+// uses: the secret and projected secret volumes of a Pod, or the TLS
+// secrets of an Ingress. Other kinds reference none.
+func SecretReferences(kind string, raw []byte) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	switch kind {
+	case "Pod":
+		var pod corev1.Pod
+		if err := json.Unmarshal(raw, &pod); err != nil {
+			return nil, fmt.Errorf("failed to decode pod: %w", err)
+		}
+		return podSecrets(&pod), nil
+	case "Ingress":
+		var ingress networkingv1.Ingress
+		if err := json.Unmarshal(raw, &ingress); err != nil {
+			return nil, fmt.Errorf("failed to decode ingress: %w", err)
+		}
+		for _, tls := range ingress.Spec.TLS {
+			add(tls.SecretName)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// podSecrets returns the secrets of the secret and projected secret volumes
+// of a pod
+func podSecrets(pod *corev1.Pod) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, volume := range pod.Spec.Volumes {
+		var refs []string
+		if volume.Secret != nil {
+			refs = append(refs, volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, projection := range volume.Projected.Sources {
+				if projection.Secret != nil {
+					refs = append(refs, projection.Secret.Name)
+				}
+			}
+		}
+		for _, name := range refs {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// objectName returns the name or generateName of an object, for pods created
+// by controllers, whose requests carry no name
+func objectName(raw []byte) string {
+	var object metav1.PartialObjectMetadata
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return ""
+	}
+	if object.Name != "" {
+		return object.Name
+	}
+	return object.GenerateName + "*"
+}
@@ -0,0 +1,115 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"k8s-web-service/internal/config"
+)
+
+// newTestServer returns a webhook server reading the example fixtures, where
+// legacy-tls holds an expired certificate and web-tls a valid one
+func newTestServer(t *testing.T, mode string) *Server {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+	cfg.Admission.Mode = mode
+	cfg.SetDefaults()
+	server, err := New(config.NewStore(cfg))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return server
+}
+
+func podWithSecret(secret string) string {
+	return `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"api"},"spec":{"volumes":[{"name":"tls","secret":{"secretName":"` + secret + `"}}]}}`
+}
+
+func ingressWithSecret(secret string) string {
+	return `{"apiVersion":"networking.k8s.io/v1","kind":"Ingress","metadata":{"name":"api"},"spec":{"tls":[{"secretName":"` + secret + `"}]}}`
+}
+
+func TestReview(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		kind         string
+		operation    admissionv1.Operation
+		object       string
+		wantAllowed  bool
+		wantWarnings bool
+	}{
+		{"pod create with expired certificate", config.AdmissionDeny, "Pod", admissionv1.Create, podWithSecret("legacy-tls"), false, false},
+		{"pod create with valid certificate", config.AdmissionDeny, "Pod", admissionv1.Create, podWithSecret("web-tls"), true, false},
+		{"pod create with missing secret", config.AdmissionDeny, "Pod", admissionv1.Create, podWithSecret("not-created-yet"), true, false},
+		{"pod update with expired certificate", config.AdmissionDeny, "Pod", admissionv1.Update, podWithSecret("legacy-tls"), true, false},
+		{"pod delete", config.AdmissionDeny, "Pod", admissionv1.Delete, podWithSecret("legacy-tls"), true, false},
+		{"ingress create with expired certificate", config.AdmissionDeny, "Ingress", admissionv1.Create, ingressWithSecret("legacy-tls"), false, false},
+		{"ingress update with expired certificate", config.AdmissionDeny, "Ingress", admissionv1.Update, ingressWithSecret("legacy-tls"), false, false},
+		{"warn mode", config.AdmissionWarn, "Pod", admissionv1.Create, podWithSecret("legacy-tls"), true, true},
+		{"other kind", config.AdmissionDeny, "ConfigMap", admissionv1.Create, `{"kind":"ConfigMap"}`, true, false},
+		{"undecodable object", config.AdmissionDeny, "Pod", admissionv1.Create, `{"spec":"volumes"}`, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+				Request: &admissionv1.AdmissionRequest{
+					UID:       "review-1",
+					Kind:      metav1.GroupVersionKind{Kind: tt.kind},
+					Namespace: "default",
+					Operation: tt.operation,
+					Object:    runtime.RawExtension{Raw: []byte(tt.object)},
+				},
+			}
+			body, err := json.Marshal(review)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			newTestServer(t, tt.mode).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(body)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+
+			var response admissionv1.AdmissionReview
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || response.Response == nil {
+				t.Fatalf("response is not an AdmissionReview: %s", rec.Body.String())
+			}
+			if response.Response.UID != "review-1" {
+				t.Errorf("uid = %q, want the request's", response.Response.UID)
+			}
+			if response.Response.Allowed != tt.wantAllowed {
+				t.Errorf("allowed = %t, want %t: %s", response.Response.Allowed, tt.wantAllowed, rec.Body.String())
+			}
+			if got := len(response.Response.Warnings) > 0; got != tt.wantWarnings {
+				t.Errorf("warnings = %v, want warnings %t", response.Response.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestReviewRejectsInvalidRequests(t *testing.T) {
+	handler := newTestServer(t, config.AdmissionDeny).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ValidatePath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader([]byte(`{"kind":"AdmissionReview"}`))))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("review without request status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,110 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"k8s-web-service/internal/k8s"
+)
+
+// Annotations added to Pods by the mutating webhook. The expiry is an RFC
+// 3339 time, so it stays correct as the pod ages, unlike a day count.
+const (
+	AnnotationSoonestExpiry = "k8s-web-service.io/soonest-certificate-expiry"
+	AnnotationSoonestSource = "k8s-web-service.io/soonest-certificate-source"
+)
+
+// jsonPatchOp is an operation of a JSON patch (RFC 6902)
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// mutate annotates a Pod being created with the soonest expiry among the
+// certificates of its secret, projected secret, and ConfigMap volumes. Pods
+// are always admitted; one without readable certificates is left as is.
+func (s *Server) mutate(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	allowed := &admissionv1.AdmissionResponse{Allowed: true}
+	if req.Operation != admissionv1.Create || req.Kind.Kind != "Pod" {
+		return allowed
+	}
+	var pod corev1.Pod
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		log.Printf("Warning: admission: failed to decode pod %s/%s: %v", req.Namespace, objectName(req.Object.Raw), err)
+		return allowed
+	}
+
+	expiry, source := s.soonestExpiry(ctx, req.Namespace, &pod)
+	if source == "" {
+		return allowed
+	}
+	values := map[string]string{
+		AnnotationSoonestExpiry: expiry.UTC().Format(time.RFC3339),
+		AnnotationSoonestSource: source,
+	}
+
+	var patch []jsonPatchOp
+	if pod.Annotations == nil {
+		patch = append(patch, jsonPatchOp{Op: "add", Path: "/metadata/annotations", Value: values})
+	} else {
+		for _, key := range []string{AnnotationSoonestExpiry, AnnotationSoonestSource} {
+			patch = append(patch, jsonPatchOp{Op: "add", Path: "/metadata/annotations/" + escapePointer(key), Value: values[key]})
+		}
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return allowed
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	allowed.Patch = data
+	allowed.PatchType = &patchType
+	return allowed
+}
+
+// soonestExpiry returns the earliest expiry among the certificates mounted
+// by a pod and the source holding it, as secret/name or configmap/name, or
+// an empty source if the pod mounts none. Sources that do not exist yet are
+// skipped.
+func (s *Server) soonestExpiry(ctx context.Context, namespace string, pod *corev1.Pod) (time.Time, string) {
+	var soonest time.Time
+	var soonestSource string
+	consider := func(kind, name string, source *k8s.CertificateSource, err error) {
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Printf("Warning: admission: failed to read %s %s/%s: %v", kind, namespace, name, err)
+			}
+			return
+		}
+		for _, cert := range source.Certificates {
+			if soonestSource == "" || cert.NotAfter.Before(soonest) {
+				soonest, soonestSource = cert.NotAfter, kind+"/"+name
+			}
+		}
+	}
+
+	clientset := s.client.GetClientset()
+	for _, name := range podSecrets(pod) {
+		source, err := k8s.ExtractCertificatesFromSecret(ctx, clientset, namespace, name)
+		consider("secret", name, source, err)
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.ConfigMap != nil {
+			source, err := k8s.ExtractCertificatesFromConfigMap(ctx, clientset, namespace, volume.ConfigMap.Name)
+			consider("configmap", volume.ConfigMap.Name, source, err)
+		}
+	}
+	return soonest, soonestSource
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
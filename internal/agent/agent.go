@@ -0,0 +1,229 @@
+// Package agent implements the node agent mode: a DaemonSet pod on every
+// node reads certificate files from host paths that pods mount through
+// hostPath volumes, and reports them to the service API, which keeps the
+// latest report of each node.
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// ReportPath is the service endpoint agents post reports to
+const ReportPath = "/agent/report"
+
+// maxFileSize skips files too large to be certificates
+const maxFileSize = 1 << 20
+
+// certificateExtensions are the file extensions read by the agent
+var certificateExtensions = map[string]bool{
+	".crt": true, ".cer": true, ".cert": true, ".pem": true,
+}
+
+// FileCertificates is a certificate file found on a node
+type FileCertificates struct {
+	Path         string                   `json:"path"`
+	Certificates []*utils.CertificateInfo `json:"certificates,omitempty"`
+	// BundleSummary is set by the service when the file holds more
+	// certificates than the bundle cap
+	BundleSummary *utils.BundleSummary `json:"bundle_summary,omitempty"`
+	Error         string               `json:"error,omitempty"`
+}
+
+// Report is the result of a node agent scan
+type Report struct {
+	Node       string             `json:"node"`
+	Paths      []string           `json:"paths"`
+	ScannedAt  time.Time          `json:"scanned_at"`
+	Files      []FileCertificates `json:"files"`
+	Errors     []string           `json:"errors,omitempty"`
+	ReceivedAt time.Time          `json:"received_at,omitempty"`
+}
+
+// Collect reads the certificate files under paths, which are node paths
+// resolved below hostRoot, the directory the node filesystem is mounted at.
+// Paths in the report are node paths. Only files with a certificate
+// extension that contain a CERTIFICATE PEM block are reported, and only their
+// certificates; private keys in combined PEM files are never reported.
+func Collect(node, hostRoot string, paths []string) Report {
+	report := Report{Node: node, Paths: paths, ScannedAt: time.Now(), Files: []FileCertificates{}}
+	for _, root := range paths {
+		hostPath := filepath.Join(hostRoot, root)
+		err := filepath.WalkDir(hostPath, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				report.Errors = append(report.Errors, err.Error())
+				if entry != nil && entry.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			// Symlinks are skipped: absolute targets would resolve in the agent
+			// container rather than on the node, and distribution trust
+			// stores link into directories that are scanned anyway
+			if entry.IsDir() || entry.Type()&fs.ModeSymlink != 0 || !certificateExtensions[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil || info.Size() > maxFileSize {
+				return nil
+			}
+
+			nodePath := "/" + strings.TrimPrefix(strings.TrimPrefix(path, hostRoot), "/")
+			file := FileCertificates{Path: nodePath}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				file.Error = fmt.Sprintf("failed to read file: %v", err)
+			} else if !bytes.Contains(data, []byte("-----BEGIN CERTIFICATE-----")) {
+				return nil
+			} else if certs, err := utils.ParseCertificateBundle(string(data)); err != nil {
+				file.Error = err.Error()
+			} else {
+				file.Certificates = certs
+			}
+			report.Files = append(report.Files, file)
+			return nil
+		})
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to scan %s: %v", root, err))
+		}
+	}
+	return report
+}
+
+// Send posts a report to the service, authenticated with the shared agent
+// token
+func Send(ctx context.Context, serverURL, token string, report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(serverURL, "/")+ReportPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send report: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("service rejected report: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// Run scans the node and sends a report every interval until ctx is done
+func Run(ctx context.Context, node, hostRoot string, paths []string, serverURL, token string, interval time.Duration) {
+	for {
+		report := Collect(node, hostRoot, paths)
+		if err := Send(ctx, serverURL, token, report); err != nil {
+			log.Printf("Error: %v", err)
+		} else {
+			log.Printf("Reported %d certificate files from node %s", len(report.Files), node)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Registry keeps the latest report of each node
+type Registry struct {
+	mu      sync.RWMutex
+	reports map[string]Report
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{reports: make(map[string]Report)}
+}
+
+// Put stores the report of a node, replacing its previous report
+func (r *Registry) Put(report Report) {
+	report.ReceivedAt = time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports[report.Node] = report
+}
+
+// Get returns the latest report of a node
+func (r *Registry) Get(node string) (Report, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	report, ok := r.reports[node]
+	return report, ok
+}
+
+// List returns the latest report of every node, ordered by node name
+func (r *Registry) List() []Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reports := make([]Report, 0, len(r.reports))
+	for _, report := range r.reports {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Node < reports[j].Node })
+	return reports
+}
+
+// Covers reports whether a node file path is inside the host path of a
+// hostPath volume
+func Covers(hostPath, filePath string) bool {
+	hostPath = filepath.Clean(hostPath)
+	filePath = filepath.Clean(filePath)
+	return filePath == hostPath || strings.HasPrefix(filePath, strings.TrimSuffix(hostPath, "/")+"/")
+}
+
+// kubeletPKIDir is where the kubelet keeps its certificates
+const kubeletPKIDir = "/var/lib/kubelet/pki/"
+
+// KubeletCertificates returns the current kubelet client and serving
+// certificates in a report, if the agent scanned the kubelet PKI directory.
+// Rotated files are kept next to each other (kubelet-client-<timestamp>.pem
+// behind the kubelet-client-current.pem symlink), so the certificate
+// expiring last is the current one.
+func KubeletCertificates(report Report) (client, serving *utils.CertificateInfo) {
+	for _, file := range report.Files {
+		name, ok := strings.CutPrefix(file.Path, kubeletPKIDir)
+		if !ok {
+			continue
+		}
+		for _, cert := range file.Certificates {
+			switch {
+			case strings.HasPrefix(name, "kubelet-client-"):
+				if client == nil || cert.NotAfter.After(client.NotAfter) {
+					client = cert
+				}
+			case strings.HasPrefix(name, "kubelet-server-") || name == "kubelet.crt":
+				if cert.IsCA {
+					continue // the self-signed kubelet.crt bundle includes its CA
+				}
+				if serving == nil || cert.NotAfter.After(serving.NotAfter) {
+					serving = cert
+				}
+			}
+		}
+	}
+	return client, serving
+}
@@ -0,0 +1,127 @@
+// Package analyzer lets custom certificate checks run alongside the built-in
+// expiry analysis. Checks register themselves, typically from an init
+// function in a separate file, so organization-specific policies (naming,
+// allowed issuers, required SANs) need no changes to the scanning code.
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// Finding severities
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Source describes where a certificate was discovered
+type Source struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod,omitempty"`
+	// Name identifies the source within the pod, e.g. "secret/tls-cert"
+	Name string `json:"name"`
+	// Type is the kind of resource holding the certificate: secret,
+	// configmap, or cluster-ca
+	Type         string `json:"type"`
+	ResourceName string `json:"resource_name"`
+	Key          string `json:"key,omitempty"`
+}
+
+// Finding is a problem or observation reported by an analyzer
+type Finding struct {
+	Analyzer string `json:"analyzer"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Source   Source `json:"source"`
+	Subject  string `json:"subject"`
+}
+
+// Analyzer checks a single certificate and reports findings. Analyze is
+// called concurrently for different certificates and must not modify cert.
+type Analyzer interface {
+	Name() string
+	Analyze(cert *utils.CertificateInfo, source Source) []Finding
+}
+
+// Func adapts a function to the Analyzer interface
+type Func struct {
+	AnalyzerName string
+	Fn           func(cert *utils.CertificateInfo, source Source) []Finding
+}
+
+// Name returns the analyzer name
+func (f Func) Name() string { return f.AnalyzerName }
+
+// Analyze calls the wrapped function
+func (f Func) Analyze(cert *utils.CertificateInfo, source Source) []Finding {
+	return f.Fn(cert, source)
+}
+
+var (
+	mu        sync.RWMutex
+	analyzers = make(map[string]Analyzer)
+)
+
+// Register makes an analyzer available to every scan. It panics if an
+// analyzer with the same name is already registered.
+func Register(a Analyzer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := analyzers[a.Name()]; exists {
+		panic(fmt.Sprintf("analyzer: Register called twice for %q", a.Name()))
+	}
+	analyzers[a.Name()] = a
+}
+
+// Registered returns the registered analyzers sorted by name
+func Registered() []Analyzer {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]Analyzer, 0, len(analyzers))
+	for _, a := range analyzers {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// Run passes a certificate to every registered analyzer and collects their
+// findings. Findings are stamped with the analyzer name and source, and a
+// panicking analyzer is reported as a finding instead of aborting the scan.
+func Run(cert *utils.CertificateInfo, source Source) []Finding {
+	var findings []Finding
+	for _, a := range Registered() {
+		for _, f := range runOne(a, cert, source) {
+			f.Analyzer = a.Name()
+			f.Source = source
+			if f.Subject == "" {
+				f.Subject = cert.Subject
+			}
+			if f.Severity == "" {
+				f.Severity = SeverityWarning
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// runOne runs a single analyzer, converting a panic into a finding
+func runOne(a Analyzer, cert *utils.CertificateInfo, source Source) (findings []Finding) {
+	defer func() {
+		if r := recover(); r != nil {
+			findings = []Finding{{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("analyzer failed: %v", r),
+			}}
+		}
+	}()
+	return a.Analyze(cert, source)
+}
@@ -8,14 +8,22 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	smithylogging "github.com/aws/smithy-go/logging"
+	"github.com/aws/smithy-go/middleware"
 
 	appConfig "k8s-web-service/internal/config"
+	"k8s-web-service/internal/logging"
+	"k8s-web-service/internal/readonly"
+	"k8s-web-service/internal/telemetry"
 )
 
 // EKSTokenGenerator handles EKS token generation
@@ -23,38 +31,90 @@ type EKSTokenGenerator struct {
 	cfg *appConfig.Config
 }
 
+// LoadAWSConfig loads the AWS SDK configuration, using the static credentials
+// from the config file when both are set and the default credential chain
+// (env vars, shared credentials, instance profile, etc.) otherwise. SDK
+// response and retry logging is enabled at debug log level.
+func LoadAWSConfig(ctx context.Context, cfg *appConfig.Config) (aws.Config, error) {
+	opts := []func(*config.LoadOptions) error{
+		config.WithRegion(cfg.AWS.Region),
+	}
+
+	if cfg.AWS.AccessKeyID != "" && cfg.AWS.SecretAccessKey != "" {
+		opts = append(opts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			cfg.AWS.AccessKeyID,
+			cfg.AWS.SecretAccessKey,
+			"",
+		)))
+	}
+
+	opts = append(opts, config.WithAPIOptions([]func(*middleware.Stack) error{addCallMetrics}))
+	if cfg.ReadOnly {
+		opts = append(opts, config.WithAPIOptions([]func(*middleware.Stack) error{addReadOnlyGuard}))
+	}
+
+	if logging.DebugEnabled() {
+		opts = append(opts,
+			config.WithClientLogMode(aws.LogResponse|aws.LogRetries),
+			config.WithLogger(smithylogging.LoggerFunc(func(classification smithylogging.Classification, format string, v ...interface{}) {
+				logging.Debugf("aws-sdk: "+format, v...)
+			})),
+		)
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return awsCfg, nil
+}
+
+// addReadOnlyGuard adds a middleware refusing AWS operations that are not
+// allowed in read-only mode
+func addReadOnlyGuard(stack *middleware.Stack) error {
+	guard := middleware.InitializeMiddlewareFunc("ReadOnlyGuard", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		operation := awsmiddleware.GetOperationName(ctx)
+		if !readonly.AllowAWSOperation(operation) {
+			return middleware.InitializeOutput{}, middleware.Metadata{},
+				fmt.Errorf("%s %s: %w", awsmiddleware.GetServiceID(ctx), operation, readonly.ErrReadOnly)
+		}
+		return next.HandleInitialize(ctx, in)
+	})
+	return stack.Initialize.Add(guard, middleware.Before)
+}
+
+// addCallMetrics adds a middleware timing each attempt of an AWS operation
+// by service and operation. Presigned requests are never sent and are not
+// timed.
+func addCallMetrics(stack *middleware.Stack) error {
+	timer := middleware.DeserializeMiddlewareFunc("CallMetrics", func(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (middleware.DeserializeOutput, middleware.Metadata, error) {
+		done := telemetry.Time(telemetry.Call{
+			System:    telemetry.SystemAWS,
+			Service:   awsmiddleware.GetServiceID(ctx),
+			Operation: awsmiddleware.GetOperationName(ctx),
+		})
+		out, metadata, err := next.HandleDeserialize(ctx, in)
+		done(err)
+		return out, metadata, err
+	})
+	return stack.Deserialize.Add(timer, middleware.Before)
+}
+
 // NewEKSTokenGenerator creates a new EKS token generator
 func NewEKSTokenGenerator(cfg *appConfig.Config) *EKSTokenGenerator {
 	return &EKSTokenGenerator{cfg: cfg}
 }
 
 // GenerateToken generates an EKS authentication token
-func (e *EKSTokenGenerator) GenerateToken(clusterName string, roleARNToAssume string) (string, error) {
+func (e *EKSTokenGenerator) GenerateToken(clusterName string, roleARNToAssume string) (token string, err error) {
+	done := telemetry.Time(telemetry.Call{System: telemetry.SystemToken, Service: "eks", Operation: TokenFromPresign})
+	defer func() { done(err) }()
 	ctx := context.Background()
 
 	// Load AWS configuration
-	var awsCfg aws.Config
-	var err error
-
-	if e.cfg.AWS.AccessKeyID != "" && e.cfg.AWS.SecretAccessKey != "" {
-		// Use static credentials from config
-		awsCfg, err = config.LoadDefaultConfig(ctx,
-			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-				e.cfg.AWS.AccessKeyID,
-				e.cfg.AWS.SecretAccessKey,
-				"",
-			)),
-			config.WithRegion(e.cfg.AWS.Region),
-		)
-	} else {
-		// Use default credential chain (env vars, shared credentials, instance profile, etc.)
-		awsCfg, err = config.LoadDefaultConfig(ctx,
-			config.WithRegion(e.cfg.AWS.Region),
-		)
-	}
-
+	awsCfg, err := LoadAWSConfig(ctx, e.cfg)
 	if err != nil {
-		return "", fmt.Errorf("failed to load AWS config: %w", err)
+		return "", err
 	}
 
 	// If a role ARN is provided, assume the role
@@ -62,14 +122,14 @@ func (e *EKSTokenGenerator) GenerateToken(clusterName string, roleARNToAssume st
 		log.Printf("Attempting to assume role: %s", roleARNToAssume)
 		stsClient := sts.NewFromConfig(awsCfg)
 
-		assumeRoleInput := &sts.AssumeRoleInput{
-			RoleArn:         aws.String(roleARNToAssume),
-			RoleSessionName: aws.String("k8s-web-service-session"),
+		input := assumeRoleInput(e.cfg, roleARNToAssume)
+		if input.SourceIdentity != nil || len(input.Tags) > 0 {
+			log.Printf("Using source identity %q and %d session tags", aws.ToString(input.SourceIdentity), len(input.Tags))
 		}
 
-		assumeRoleOutput, err := stsClient.AssumeRole(ctx, assumeRoleInput)
+		assumeRoleOutput, err := stsClient.AssumeRole(ctx, input)
 		if err != nil {
-			log.Printf("Failed to assume role %s: %v", roleARNToAssume, err)
+			log.Printf("Error: failed to assume role %s: %v", roleARNToAssume, err)
 			return "", fmt.Errorf("failed to assume role %s: %w", roleARNToAssume, err)
 		}
 
@@ -120,8 +180,45 @@ func (e *EKSTokenGenerator) GenerateToken(clusterName string, roleARNToAssume st
 	return tokenPayload, nil
 }
 
+// HasSessionAttribution reports whether a source identity or session tags are
+// configured for AssumeRole. aws-iam-authenticator cannot set them, so
+// tokens must then be generated with GenerateToken.
+func HasSessionAttribution(cfg *appConfig.Config) bool {
+	return cfg.AWS.AssumeRole.SourceIdentity != "" || len(cfg.AWS.AssumeRole.SessionTags) > 0
+}
+
+// assumeRoleInput builds the AssumeRole request for roleARN with the
+// configured session name, source identity, and session tags
+func assumeRoleInput(cfg *appConfig.Config, roleARN string) *sts.AssumeRoleInput {
+	settings := cfg.AWS.AssumeRole
+	sessionName := settings.SessionName
+	if sessionName == "" {
+		sessionName = "k8s-web-service-session"
+	}
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(roleARN),
+		RoleSessionName: aws.String(sessionName),
+	}
+	if settings.SourceIdentity != "" {
+		input.SourceIdentity = aws.String(settings.SourceIdentity)
+	}
+
+	keys := make([]string, 0, len(settings.SessionTags))
+	for key := range settings.SessionTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		input.Tags = append(input.Tags, ststypes.Tag{Key: aws.String(key), Value: aws.String(settings.SessionTags[key])})
+	}
+	input.TransitiveTagKeys = settings.TransitiveTagKeys
+	return input
+}
+
 // GenerateTokenUsingAuthenticator generates an EKS token using aws-iam-authenticator directly
-func (e *EKSTokenGenerator) GenerateTokenUsingAuthenticator(clusterName string, roleARN string) (string, error) {
+func (e *EKSTokenGenerator) GenerateTokenUsingAuthenticator(clusterName string, roleARN string) (token string, err error) {
+	done := telemetry.Time(telemetry.Call{System: telemetry.SystemToken, Service: "eks", Operation: TokenFromAuthenticator})
+	defer func() { done(err) }()
 	// Build the command arguments
 	args := []string{"token", "-i", clusterName}
 	if roleARN != "" {
@@ -134,8 +231,11 @@ func (e *EKSTokenGenerator) GenerateTokenUsingAuthenticator(clusterName string,
 	// Inherit the current environment to use the same AWS configuration as kubectl
 	cmd.Env = os.Environ()
 
-	// Override with our specific AWS credentials if they exist
-	if e.cfg.AWS.AccessKeyID != "" && e.cfg.AWS.SecretAccessKey != "" {
+	// Override with our specific AWS credentials and the cluster's region if
+	// they exist, so tokens for clusters in other regions or partitions are
+	// signed by their own STS endpoint
+	hasCredentials := e.cfg.AWS.AccessKeyID != "" && e.cfg.AWS.SecretAccessKey != ""
+	if hasCredentials || e.cfg.AWS.Region != "" {
 		// Find and replace or add AWS environment variables
 		envMap := make(map[string]string)
 		for _, env := range cmd.Env {
@@ -145,11 +245,15 @@ func (e *EKSTokenGenerator) GenerateTokenUsingAuthenticator(clusterName string,
 			}
 		}
 
-		envMap["AWS_ACCESS_KEY_ID"] = e.cfg.AWS.AccessKeyID
-		envMap["AWS_SECRET_ACCESS_KEY"] = e.cfg.AWS.SecretAccessKey
+		if hasCredentials {
+			envMap["AWS_ACCESS_KEY_ID"] = e.cfg.AWS.AccessKeyID
+			envMap["AWS_SECRET_ACCESS_KEY"] = e.cfg.AWS.SecretAccessKey
+			delete(envMap, "AWS_SESSION_TOKEN")
+		}
 
 		if e.cfg.AWS.Region != "" {
 			envMap["AWS_REGION"] = e.cfg.AWS.Region
+			envMap["AWS_STS_REGIONAL_ENDPOINTS"] = "regional"
 		}
 
 		// Rebuild environment slice
@@ -183,26 +287,9 @@ func (e *EKSTokenGenerator) GetCallerIdentity() (*sts.GetCallerIdentityOutput, e
 	ctx := context.Background()
 
 	// Load AWS configuration
-	var awsCfg aws.Config
-	var err error
-
-	if e.cfg.AWS.AccessKeyID != "" && e.cfg.AWS.SecretAccessKey != "" {
-		awsCfg, err = config.LoadDefaultConfig(ctx,
-			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-				e.cfg.AWS.AccessKeyID,
-				e.cfg.AWS.SecretAccessKey,
-				"",
-			)),
-			config.WithRegion(e.cfg.AWS.Region),
-		)
-	} else {
-		awsCfg, err = config.LoadDefaultConfig(ctx,
-			config.WithRegion(e.cfg.AWS.Region),
-		)
-	}
-
+	awsCfg, err := LoadAWSConfig(ctx, e.cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		return nil, err
 	}
 
 	stsClient := sts.NewFromConfig(awsCfg)
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+
+	appConfig "k8s-web-service/internal/config"
+)
+
+// How an EKS token was generated
+const (
+	TokenFromAuthenticator = "aws-iam-authenticator"
+	TokenFromPresign       = "sts-presign"
+)
+
+// EKSTokenLifetime is how long EKS accepts a token after it was presigned,
+// regardless of the expiry in the presigned URL
+const EKSTokenLifetime = 15 * time.Minute
+
+// tokenPrefix is the prefix of EKS bearer tokens
+const tokenPrefix = "k8s-aws-v1."
+
+// probeTimeout bounds the IMDS and aws-iam-authenticator probes
+const probeTimeout = 2 * time.Second
+
+// TokenInfo describes an EKS token without revealing it
+type TokenInfo struct {
+	Source    string    `json:"source"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ParseTokenInfo reads when an EKS token was presigned from the X-Amz-Date
+// of its presigned GetCallerIdentity URL
+func ParseTokenInfo(token, source string) (*TokenInfo, error) {
+	encoded, ok := strings.CutPrefix(token, tokenPrefix)
+	if !ok {
+		return nil, fmt.Errorf("not an EKS token")
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode EKS token: %w", err)
+	}
+	presigned, err := url.Parse(string(decoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse presigned URL of EKS token: %w", err)
+	}
+	issued, err := time.Parse("20060102T150405Z", presigned.Query().Get("X-Amz-Date"))
+	if err != nil {
+		return nil, fmt.Errorf("EKS token has no valid X-Amz-Date: %w", err)
+	}
+	return &TokenInfo{Source: source, IssuedAt: issued, ExpiresAt: issued.Add(EKSTokenLifetime)}, nil
+}
+
+// CredentialInfo describes the AWS credentials of the default chain without
+// revealing them
+type CredentialInfo struct {
+	// Provider is the credential provider that supplied the credentials,
+	// such as EnvConfigCredentials, SharedConfigCredentials,
+	// WebIdentityCredentials (IRSA), or EC2RoleProvider
+	Provider  string     `json:"provider,omitempty"`
+	CanExpire bool       `json:"can_expire"`
+	Expires   *time.Time `json:"expires,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// DescribeCredentials retrieves the AWS credentials the service uses and
+// reports which provider supplied them
+func DescribeCredentials(ctx context.Context, cfg *appConfig.Config) CredentialInfo {
+	awsCfg, err := LoadAWSConfig(ctx, cfg)
+	if err != nil {
+		return CredentialInfo{Error: err.Error()}
+	}
+	if awsCfg.Credentials == nil {
+		return CredentialInfo{Error: "no credential provider configured"}
+	}
+	creds, err := awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return CredentialInfo{Error: fmt.Sprintf("failed to retrieve credentials: %v", err)}
+	}
+	info := CredentialInfo{Provider: creds.Source, CanExpire: creds.CanExpire}
+	if creds.CanExpire {
+		expires := creds.Expires
+		info.Expires = &expires
+	}
+	return info
+}
+
+// IMDSStatus reports whether the EC2 instance metadata service answers
+type IMDSStatus struct {
+	Available bool   `json:"available"`
+	Region    string `json:"region,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ProbeIMDS asks the instance metadata service for its region. It is not
+// available on Fargate, outside EC2, or when the hop limit blocks pods.
+func ProbeIMDS(ctx context.Context) IMDSStatus {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	output, err := imds.New(imds.Options{}).GetRegion(ctx, &imds.GetRegionInput{})
+	if err != nil {
+		return IMDSStatus{Error: err.Error()}
+	}
+	return IMDSStatus{Available: true, Region: output.Region}
+}
+
+// AuthenticatorStatus reports whether aws-iam-authenticator is installed
+type AuthenticatorStatus struct {
+	Path    string `json:"path,omitempty"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ProbeAuthenticator looks up aws-iam-authenticator on the PATH and reads its
+// version
+func ProbeAuthenticator(ctx context.Context) AuthenticatorStatus {
+	path, err := exec.LookPath("aws-iam-authenticator")
+	if err != nil {
+		return AuthenticatorStatus{Error: "not found on PATH; tokens are presigned by the service"}
+	}
+	status := AuthenticatorStatus{Path: path}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, "version").Output()
+	if err != nil {
+		status.Error = fmt.Sprintf("failed to read version: %v", err)
+		return status
+	}
+	var version struct {
+		Version string `json:"Version"`
+	}
+	if json.Unmarshal(output, &version) == nil && version.Version != "" {
+		status.Version = version.Version
+	} else {
+		status.Version = strings.TrimSpace(string(output))
+	}
+	return status
+}
@@ -0,0 +1,110 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+// EKS cluster authentication modes
+const (
+	AuthModeAPI             = "API"
+	AuthModeAPIAndConfigMap = "API_AND_CONFIG_MAP"
+	AuthModeConfigMap       = "CONFIG_MAP"
+)
+
+// AccessPolicyInfo is an EKS access policy associated with an access entry
+type AccessPolicyInfo struct {
+	PolicyARN  string   `json:"policy_arn"`
+	ScopeType  string   `json:"scope_type"`
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// AccessEntryInfo describes an EKS access entry
+type AccessEntryInfo struct {
+	PrincipalARN     string             `json:"principal_arn"`
+	Type             string             `json:"type"`
+	Username         string             `json:"username"`
+	KubernetesGroups []string           `json:"kubernetes_groups,omitempty"`
+	AccessPolicies   []AccessPolicyInfo `json:"access_policies,omitempty"`
+	Error            string             `json:"error,omitempty"`
+}
+
+// AccessEntryReport lists the access entries of a cluster
+type AccessEntryReport struct {
+	ClusterName        string            `json:"cluster_name"`
+	AuthenticationMode string            `json:"authentication_mode"`
+	Entries            []AccessEntryInfo `json:"entries"`
+}
+
+// ListAccessEntries returns the authentication mode of the cluster and its
+// access entries with their Kubernetes groups and associated access
+// policies. Clusters in CONFIG_MAP mode have no access entries.
+func (s *Session) ListAccessEntries(ctx context.Context) (*AccessEntryReport, error) {
+	client := eks.NewFromConfig(s.AWS)
+
+	cluster, err := client.DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(s.ClusterName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe EKS cluster %s: %w", s.ClusterName, err)
+	}
+	report := &AccessEntryReport{
+		ClusterName:        s.ClusterName,
+		AuthenticationMode: AuthModeConfigMap,
+		Entries:            []AccessEntryInfo{},
+	}
+	if cluster.Cluster.AccessConfig != nil && cluster.Cluster.AccessConfig.AuthenticationMode != "" {
+		report.AuthenticationMode = string(cluster.Cluster.AccessConfig.AuthenticationMode)
+	}
+	if report.AuthenticationMode == AuthModeConfigMap {
+		return report, nil
+	}
+
+	var principals []string
+	paginator := eks.NewListAccessEntriesPaginator(client, &eks.ListAccessEntriesInput{ClusterName: aws.String(s.ClusterName)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list access entries of EKS cluster %s: %w", s.ClusterName, err)
+		}
+		principals = append(principals, page.AccessEntries...)
+	}
+	sort.Strings(principals)
+
+	for _, principal := range principals {
+		info := AccessEntryInfo{PrincipalARN: principal}
+		out, err := client.DescribeAccessEntry(ctx, &eks.DescribeAccessEntryInput{
+			ClusterName:  aws.String(s.ClusterName),
+			PrincipalArn: aws.String(principal),
+		})
+		if err != nil {
+			info.Error = fmt.Sprintf("failed to describe access entry: %v", err)
+			report.Entries = append(report.Entries, info)
+			continue
+		}
+		info.Type = aws.ToString(out.AccessEntry.Type)
+		info.Username = aws.ToString(out.AccessEntry.Username)
+		info.KubernetesGroups = out.AccessEntry.KubernetesGroups
+
+		policies, err := client.ListAssociatedAccessPolicies(ctx, &eks.ListAssociatedAccessPoliciesInput{
+			ClusterName:  aws.String(s.ClusterName),
+			PrincipalArn: aws.String(principal),
+		})
+		if err != nil {
+			info.Error = fmt.Sprintf("failed to list associated access policies: %v", err)
+		} else {
+			for _, policy := range policies.AssociatedAccessPolicies {
+				policyInfo := AccessPolicyInfo{PolicyARN: aws.ToString(policy.PolicyArn)}
+				if policy.AccessScope != nil {
+					policyInfo.ScopeType = string(policy.AccessScope.Type)
+					policyInfo.Namespaces = policy.AccessScope.Namespaces
+				}
+				info.AccessPolicies = append(info.AccessPolicies, policyInfo)
+			}
+		}
+		report.Entries = append(report.Entries, info)
+	}
+	return report, nil
+}
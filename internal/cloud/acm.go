@@ -0,0 +1,114 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	acmtypes "github.com/aws/aws-sdk-go-v2/service/acm/types"
+)
+
+// acmKeyTypes are the key algorithms to list; ListCertificates only returns
+// RSA 2048 certificates unless key types are given
+var acmKeyTypes = []acmtypes.KeyAlgorithm{
+	acmtypes.KeyAlgorithmRsa1024,
+	acmtypes.KeyAlgorithmRsa2048,
+	acmtypes.KeyAlgorithmRsa3072,
+	acmtypes.KeyAlgorithmRsa4096,
+	acmtypes.KeyAlgorithmEcPrime256v1,
+	acmtypes.KeyAlgorithmEcSecp384r1,
+	acmtypes.KeyAlgorithmEcSecp521r1,
+}
+
+// ACMCertificate describes a certificate managed by AWS Certificate Manager
+type ACMCertificate struct {
+	ARN                string     `json:"arn"`
+	DomainName         string     `json:"domain_name"`
+	SANs               []string   `json:"subject_alternative_names,omitempty"`
+	Type               string     `json:"type"` // AMAZON_ISSUED, IMPORTED, or PRIVATE
+	Status             string     `json:"status"`
+	KeyAlgorithm       string     `json:"key_algorithm,omitempty"`
+	Issuer             string     `json:"issuer,omitempty"`
+	NotBefore          *time.Time `json:"not_before,omitempty"`
+	NotAfter           *time.Time `json:"not_after,omitempty"`
+	DaysUntilExp       *int       `json:"days_until_expiry,omitempty"`
+	IsExpired          bool       `json:"is_expired"`
+	RenewalEligibility string     `json:"renewal_eligibility,omitempty"`
+	RenewalStatus      string     `json:"renewal_status,omitempty"`
+	InUseBy            []string   `json:"in_use_by,omitempty"`
+	PrivateCAARN       string     `json:"private_ca_arn,omitempty"`
+	Error              string     `json:"error,omitempty"`
+}
+
+// ListACMCertificates lists the ACM certificates of the region with their
+// expiry and renewal eligibility. Imported certificates are never renewed
+// by ACM; certificates that cannot be described are reported with an error.
+func ListACMCertificates(ctx context.Context, awsCfg aws.Config) ([]ACMCertificate, error) {
+	client := acm.NewFromConfig(awsCfg)
+
+	var summaries []acmtypes.CertificateSummary
+	paginator := acm.NewListCertificatesPaginator(client, &acm.ListCertificatesInput{
+		Includes: &acmtypes.Filters{KeyTypes: acmKeyTypes},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ACM certificates: %w", err)
+		}
+		summaries = append(summaries, page.CertificateSummaryList...)
+	}
+
+	certs := make([]ACMCertificate, 0, len(summaries))
+	for _, summary := range summaries {
+		cert := describeACMCertificate(ctx, client, aws.ToString(summary.CertificateArn))
+		if cert.DomainName == "" {
+			cert.DomainName = aws.ToString(summary.DomainName)
+		}
+		certs = append(certs, cert)
+	}
+
+	sort.Slice(certs, func(i, j int) bool {
+		if certs[i].DomainName != certs[j].DomainName {
+			return certs[i].DomainName < certs[j].DomainName
+		}
+		return certs[i].ARN < certs[j].ARN
+	})
+	return certs, nil
+}
+
+// describeACMCertificate describes a single ACM certificate, reporting
+// failures in its Error field
+func describeACMCertificate(ctx context.Context, client *acm.Client, arn string) ACMCertificate {
+	cert := ACMCertificate{ARN: arn}
+	out, err := client.DescribeCertificate(ctx, &acm.DescribeCertificateInput{CertificateArn: aws.String(arn)})
+	if err != nil {
+		cert.Error = fmt.Sprintf("failed to describe certificate: %v", err)
+		return cert
+	}
+
+	detail := out.Certificate
+	cert.DomainName = aws.ToString(detail.DomainName)
+	cert.SANs = detail.SubjectAlternativeNames
+	cert.Type = string(detail.Type)
+	cert.Status = string(detail.Status)
+	cert.KeyAlgorithm = string(detail.KeyAlgorithm)
+	cert.Issuer = aws.ToString(detail.Issuer)
+	cert.NotBefore = detail.NotBefore
+	cert.NotAfter = detail.NotAfter
+	cert.RenewalEligibility = string(detail.RenewalEligibility)
+	cert.InUseBy = detail.InUseBy
+	cert.PrivateCAARN = aws.ToString(detail.CertificateAuthorityArn)
+	if detail.RenewalSummary != nil {
+		cert.RenewalStatus = string(detail.RenewalSummary.RenewalStatus)
+	}
+	if detail.NotAfter != nil {
+		now := time.Now()
+		days := int(detail.NotAfter.Sub(now).Hours() / 24)
+		cert.DaysUntilExp = &days
+		cert.IsExpired = now.After(*detail.NotAfter)
+	}
+	return cert
+}
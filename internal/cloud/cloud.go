@@ -0,0 +1,79 @@
+// Package cloud inspects the AWS resources around the monitored EKS cluster.
+// Every call is a read-only Describe, List, or Get operation.
+package cloud
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"k8s-web-service/internal/auth"
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+)
+
+// ErrNoClusterName is returned when the EKS cluster name is neither
+// configured nor found in the kubeconfig
+var ErrNoClusterName = errors.New("EKS cluster name is not configured (set kubernetes.cluster_name or K8S_CLUSTER_NAME)")
+
+// Session holds the AWS configuration for the monitored EKS cluster
+type Session struct {
+	AWS         aws.Config
+	ClusterName string
+}
+
+// LoadConfig loads the AWS configuration of the cluster in the kubeconfig
+// details, with its region and credentials from the clusters setting or its
+// kubeconfig, falling back to the aws settings. The details may be nil.
+func LoadConfig(ctx context.Context, cfg *config.Config, details *k8s.KubeConfigEKSDetails) (aws.Config, error) {
+	if details != nil {
+		cfg = k8s.ClusterConfig(cfg, details)
+	}
+	awsCfg, err := auth.LoadAWSConfig(ctx, cfg)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if awsCfg.Region == "" && details != nil {
+		awsCfg.Region = details.Region
+	}
+	return awsCfg, nil
+}
+
+// NewSession loads the AWS configuration for an EKS cluster. Without an
+// explicit cluster name, the name comes from the configuration file and then
+// from the kubeconfig details, which may be nil.
+func NewSession(ctx context.Context, cfg *config.Config, clusterName string, details *k8s.KubeConfigEKSDetails) (*Session, error) {
+	awsCfg, err := LoadConfig(ctx, cfg, details)
+	if err != nil {
+		return nil, err
+	}
+
+	if clusterName == "" {
+		clusterName = cfg.Kubernetes.ClusterName
+	}
+	if clusterName == "" && details != nil {
+		clusterName = details.ClusterName
+	}
+	clusterName = ClusterNameFromARN(clusterName)
+	if clusterName == "" {
+		return nil, ErrNoClusterName
+	}
+
+	return &Session{AWS: awsCfg, ClusterName: clusterName}, nil
+}
+
+// ClusterNameFromARN returns the cluster name of an EKS cluster ARN such as
+// arn:aws:eks:us-west-2:123456789012:cluster/prod, which kubeconfigs written
+// by aws eks update-kubeconfig use as the cluster name. Other values are
+// returned unchanged.
+func ClusterNameFromARN(name string) string {
+	if !strings.HasPrefix(name, "arn:") {
+		return name
+	}
+	if _, clusterName, ok := strings.Cut(name, ":cluster/"); ok {
+		return clusterName
+	}
+	return name
+}
@@ -0,0 +1,100 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+)
+
+// cloudFrontRegion is the region of the ACM certificates CloudFront can use
+const cloudFrontRegion = "us-east-1"
+
+// CertificateSourceCloudFront marks the default *.cloudfront.net certificate
+const CertificateSourceCloudFront = "cloudfront"
+
+// CloudFrontDistribution describes the viewer certificate of a CloudFront
+// distribution
+type CloudFrontDistribution struct {
+	ID                     string                `json:"id"`
+	DomainName             string                `json:"domain_name,omitempty"`
+	Aliases                []string              `json:"aliases,omitempty"`
+	Status                 string                `json:"status,omitempty"`
+	Enabled                bool                  `json:"enabled"`
+	CertificateSource      string                `json:"certificate_source,omitempty"`
+	MinimumProtocolVersion string                `json:"minimum_protocol_version,omitempty"`
+	SSLSupportMethod       string                `json:"ssl_support_method,omitempty"`
+	ACM                    *ACMCertificate       `json:"acm,omitempty"`
+	IAM                    *IAMServerCertificate `json:"iam,omitempty"`
+	Error                  string                `json:"error,omitempty"`
+}
+
+// DescribeCloudFrontDistributions returns the viewer certificate of each
+// distribution in ids. ACM certificates are described in us-east-1, where
+// CloudFront requires them; distributions that cannot be read are reported
+// with an error.
+func DescribeCloudFrontDistributions(ctx context.Context, awsCfg aws.Config, ids []string) []CloudFrontDistribution {
+	globalCfg := awsCfg.Copy()
+	globalCfg.Region = cloudFrontRegion
+	client := cloudfront.NewFromConfig(globalCfg)
+	acmClient := acm.NewFromConfig(globalCfg)
+
+	// IAM server certificates are only listed if a distribution uses one
+	var serverCerts []IAMServerCertificate
+	var serverCertsErr error
+	serverCertsLoaded := false
+
+	distributions := make([]CloudFrontDistribution, 0, len(ids))
+	for _, id := range ids {
+		info := CloudFrontDistribution{ID: id}
+		out, err := client.GetDistribution(ctx, &cloudfront.GetDistributionInput{Id: aws.String(id)})
+		if err != nil {
+			info.Error = fmt.Sprintf("failed to get distribution: %v", err)
+			distributions = append(distributions, info)
+			continue
+		}
+
+		distribution := out.Distribution
+		info.DomainName = aws.ToString(distribution.DomainName)
+		info.Status = aws.ToString(distribution.Status)
+		distConfig := distribution.DistributionConfig
+		info.Enabled = aws.ToBool(distConfig.Enabled)
+		if distConfig.Aliases != nil {
+			info.Aliases = distConfig.Aliases.Items
+		}
+
+		viewer := distConfig.ViewerCertificate
+		if viewer == nil {
+			distributions = append(distributions, info)
+			continue
+		}
+		info.MinimumProtocolVersion = string(viewer.MinimumProtocolVersion)
+		info.SSLSupportMethod = string(viewer.SSLSupportMethod)
+		switch {
+		case aws.ToString(viewer.ACMCertificateArn) != "":
+			info.CertificateSource = CertificateSourceACM
+			cert := describeACMCertificate(ctx, acmClient, aws.ToString(viewer.ACMCertificateArn))
+			info.ACM = &cert
+		case aws.ToString(viewer.IAMCertificateId) != "":
+			info.CertificateSource = CertificateSourceIAM
+			if !serverCertsLoaded {
+				serverCertsLoaded = true
+				serverCerts, serverCertsErr = ListServerCertificates(ctx, awsCfg)
+			}
+			for i := range serverCerts {
+				if serverCerts[i].ID == aws.ToString(viewer.IAMCertificateId) {
+					info.IAM = &serverCerts[i]
+				}
+			}
+			if info.IAM == nil && serverCertsErr != nil {
+				info.Error = serverCertsErr.Error()
+			}
+		case aws.ToBool(viewer.CloudFrontDefaultCertificate):
+			info.CertificateSource = CertificateSourceCloudFront
+		}
+		distributions = append(distributions, info)
+	}
+	return distributions
+}
@@ -0,0 +1,71 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+// ClusterInfo describes an EKS cluster found in the account
+type ClusterInfo struct {
+	Name              string     `json:"name"`
+	Region            string     `json:"region"`
+	ARN               string     `json:"arn,omitempty"`
+	Status            string     `json:"status,omitempty"`
+	KubernetesVersion string     `json:"kubernetes_version,omitempty"`
+	PlatformVersion   string     `json:"platform_version,omitempty"`
+	Endpoint          string     `json:"endpoint,omitempty"`
+	Created           *time.Time `json:"created,omitempty"`
+	Error             string     `json:"error,omitempty"`
+}
+
+// DiscoverClusters lists the EKS clusters of the account in each region.
+// Clusters that cannot be described are reported with an error; a region
+// whose clusters cannot be listed fails the whole discovery.
+func DiscoverClusters(ctx context.Context, awsCfg aws.Config, regions []string) ([]ClusterInfo, error) {
+	var clusters []ClusterInfo
+	for _, region := range regions {
+		regionCfg := awsCfg.Copy()
+		regionCfg.Region = region
+		client := eks.NewFromConfig(regionCfg)
+
+		var names []string
+		paginator := eks.NewListClustersPaginator(client, &eks.ListClustersInput{})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list EKS clusters in %s: %w", region, err)
+			}
+			names = append(names, page.Clusters...)
+		}
+
+		for _, name := range names {
+			info := ClusterInfo{Name: name, Region: region}
+			out, err := client.DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(name)})
+			if err != nil {
+				info.Error = fmt.Sprintf("failed to describe cluster: %v", err)
+			} else {
+				cluster := out.Cluster
+				info.ARN = aws.ToString(cluster.Arn)
+				info.Status = string(cluster.Status)
+				info.KubernetesVersion = aws.ToString(cluster.Version)
+				info.PlatformVersion = aws.ToString(cluster.PlatformVersion)
+				info.Endpoint = aws.ToString(cluster.Endpoint)
+				info.Created = cluster.CreatedAt
+			}
+			clusters = append(clusters, info)
+		}
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Region != clusters[j].Region {
+			return clusters[i].Region < clusters[j].Region
+		}
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters, nil
+}
@@ -0,0 +1,181 @@
+package cloud
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// AddonIssue is a health issue reported by EKS for an addon
+type AddonIssue struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// AddonInfo compares an installed EKS addon with the versions available for
+// the cluster's Kubernetes version
+type AddonInfo struct {
+	Name            string       `json:"name"`
+	CurrentVersion  string       `json:"current_version"`
+	LatestVersion   string       `json:"latest_compatible_version,omitempty"`
+	DefaultVersion  string       `json:"default_version,omitempty"`
+	UpdateAvailable bool         `json:"update_available"`
+	Status          string       `json:"status"`
+	Issues          []AddonIssue `json:"issues,omitempty"`
+	Error           string       `json:"error,omitempty"`
+}
+
+// AddonReport lists the EKS addons installed on a cluster
+type AddonReport struct {
+	ClusterName       string      `json:"cluster_name"`
+	KubernetesVersion string      `json:"kubernetes_version"`
+	Addons            []AddonInfo `json:"addons"`
+}
+
+// ListAddons lists the EKS managed addons of the cluster, such as vpc-cni,
+// coredns, and kube-proxy, with their installed version and the latest and
+// default versions compatible with the cluster's Kubernetes version. An
+// addon whose details cannot be read is reported with an error.
+func (s *Session) ListAddons(ctx context.Context) (*AddonReport, error) {
+	client := eks.NewFromConfig(s.AWS)
+
+	cluster, err := client.DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(s.ClusterName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe EKS cluster %s: %w", s.ClusterName, err)
+	}
+	report := &AddonReport{
+		ClusterName:       s.ClusterName,
+		KubernetesVersion: aws.ToString(cluster.Cluster.Version),
+		Addons:            []AddonInfo{},
+	}
+
+	var names []string
+	paginator := eks.NewListAddonsPaginator(client, &eks.ListAddonsInput{ClusterName: aws.String(s.ClusterName)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list addons of EKS cluster %s: %w", s.ClusterName, err)
+		}
+		names = append(names, page.Addons...)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		info := AddonInfo{Name: name}
+
+		addon, err := client.DescribeAddon(ctx, &eks.DescribeAddonInput{
+			ClusterName: aws.String(s.ClusterName),
+			AddonName:   aws.String(name),
+		})
+		if err != nil {
+			info.Error = fmt.Sprintf("failed to describe addon: %v", err)
+			report.Addons = append(report.Addons, info)
+			continue
+		}
+		info.CurrentVersion = aws.ToString(addon.Addon.AddonVersion)
+		info.Status = string(addon.Addon.Status)
+		if addon.Addon.Health != nil {
+			for _, issue := range addon.Addon.Health.Issues {
+				info.Issues = append(info.Issues, AddonIssue{
+					Code:    string(issue.Code),
+					Message: aws.ToString(issue.Message),
+				})
+			}
+		}
+
+		versions, err := client.DescribeAddonVersions(ctx, &eks.DescribeAddonVersionsInput{
+			AddonName:         aws.String(name),
+			KubernetesVersion: aws.String(report.KubernetesVersion),
+		})
+		if err != nil {
+			info.Error = fmt.Sprintf("failed to describe addon versions: %v", err)
+		} else {
+			info.LatestVersion, info.DefaultVersion = compatibleVersions(versions.Addons, report.KubernetesVersion)
+			info.UpdateAvailable = info.LatestVersion != "" && compareAddonVersions(info.LatestVersion, info.CurrentVersion) > 0
+		}
+		report.Addons = append(report.Addons, info)
+	}
+	return report, nil
+}
+
+// compatibleVersions returns the newest addon version and the default
+// version for a Kubernetes version
+func compatibleVersions(addons []ekstypes.AddonInfo, kubernetesVersion string) (latest, def string) {
+	for _, addon := range addons {
+		for _, version := range addon.AddonVersions {
+			name := aws.ToString(version.AddonVersion)
+			for _, compat := range version.Compatibilities {
+				if aws.ToString(compat.ClusterVersion) != kubernetesVersion {
+					continue
+				}
+				if latest == "" || compareAddonVersions(name, latest) > 0 {
+					latest = name
+				}
+				if compat.DefaultVersion {
+					def = name
+				}
+			}
+		}
+	}
+	return latest, def
+}
+
+// compareAddonVersions compares EKS addon versions such as v1.18.3-eksbuild.2
+// by their numeric parts, returning -1, 0, or 1
+func compareAddonVersions(a, b string) int {
+	pa, pb := addonVersionParts(a), addonVersionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// addonVersionParts returns the numeric parts of an addon version
+func addonVersionParts(version string) []int {
+	fields := strings.FieldsFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	parts := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, _ := strconv.Atoi(field)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// ClusterCertificateAuthority returns the PEM CA bundle EKS reports for the
+// cluster, which changes first during a CA rotation, and when the cluster
+// was created
+func (s *Session) ClusterCertificateAuthority(ctx context.Context) (string, time.Time, error) {
+	cluster, err := eks.NewFromConfig(s.AWS).DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(s.ClusterName)})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to describe EKS cluster %s: %w", s.ClusterName, err)
+	}
+	created := aws.ToTime(cluster.Cluster.CreatedAt)
+	if cluster.Cluster.CertificateAuthority == nil || aws.ToString(cluster.Cluster.CertificateAuthority.Data) == "" {
+		return "", created, fmt.Errorf("EKS cluster %s reports no certificate authority", s.ClusterName)
+	}
+	data, err := base64.StdEncoding.DecodeString(aws.ToString(cluster.Cluster.CertificateAuthority.Data))
+	if err != nil {
+		return "", created, fmt.Errorf("failed to decode certificate authority of EKS cluster %s: %w", s.ClusterName, err)
+	}
+	return string(data), created, nil
+}
@@ -0,0 +1,149 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+)
+
+// Sources of listener certificates
+const (
+	CertificateSourceACM = "acm"
+	CertificateSourceIAM = "iam"
+)
+
+// ListenerCertificate is a certificate attached to a load balancer listener
+type ListenerCertificate struct {
+	ARN       string                `json:"arn"`
+	Source    string                `json:"source"`
+	IsDefault bool                  `json:"is_default"`
+	ACM       *ACMCertificate       `json:"acm,omitempty"`
+	IAM       *IAMServerCertificate `json:"iam,omitempty"`
+}
+
+// ListenerInfo describes a TLS listener of a load balancer
+type ListenerInfo struct {
+	ARN          string                `json:"arn"`
+	Port         int32                 `json:"port"`
+	Protocol     string                `json:"protocol"`
+	SSLPolicy    string                `json:"ssl_policy,omitempty"`
+	Certificates []ListenerCertificate `json:"certificates"`
+	Error        string                `json:"error,omitempty"`
+}
+
+// LoadBalancerInfo describes an ALB or NLB and its TLS listeners
+type LoadBalancerInfo struct {
+	Name      string         `json:"name"`
+	ARN       string         `json:"arn"`
+	Type      string         `json:"type"`
+	Scheme    string         `json:"scheme"`
+	DNSName   string         `json:"dns_name"`
+	Listeners []ListenerInfo `json:"listeners"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// ListLoadBalancerCertificates returns the ALBs and NLBs whose DNS names are
+// in hostnames with their HTTPS and TLS listeners and the certificates
+// attached to them. ACM certificates are described; IAM server certificates
+// are matched to the account's server certificates when they can be listed.
+func ListLoadBalancerCertificates(ctx context.Context, awsCfg aws.Config, hostnames []string) ([]LoadBalancerInfo, error) {
+	wanted := make(map[string]bool, len(hostnames))
+	for _, host := range hostnames {
+		wanted[strings.ToLower(host)] = true
+	}
+
+	client := elbv2.NewFromConfig(awsCfg)
+	acmClient := acm.NewFromConfig(awsCfg)
+	described := make(map[string]*ACMCertificate)
+
+	// IAM server certificates are only listed if a listener uses one
+	var serverCerts map[string]*IAMServerCertificate
+	serverCertificate := func(arn string) *IAMServerCertificate {
+		if serverCerts == nil {
+			serverCerts = make(map[string]*IAMServerCertificate)
+			certs, err := ListServerCertificates(ctx, awsCfg)
+			if err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			for i := range certs {
+				serverCerts[certs[i].ARN] = &certs[i]
+			}
+		}
+		return serverCerts[arn]
+	}
+
+	var balancers []LoadBalancerInfo
+	paginator := elbv2.NewDescribeLoadBalancersPaginator(client, &elbv2.DescribeLoadBalancersInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe load balancers: %w", err)
+		}
+		for _, lb := range page.LoadBalancers {
+			if !wanted[strings.ToLower(aws.ToString(lb.DNSName))] {
+				continue
+			}
+			info := LoadBalancerInfo{
+				Name:      aws.ToString(lb.LoadBalancerName),
+				ARN:       aws.ToString(lb.LoadBalancerArn),
+				Type:      string(lb.Type),
+				Scheme:    string(lb.Scheme),
+				DNSName:   aws.ToString(lb.DNSName),
+				Listeners: []ListenerInfo{},
+			}
+
+			listeners, err := client.DescribeListeners(ctx, &elbv2.DescribeListenersInput{LoadBalancerArn: lb.LoadBalancerArn})
+			if err != nil {
+				info.Error = fmt.Sprintf("failed to describe listeners: %v", err)
+				balancers = append(balancers, info)
+				continue
+			}
+			for _, listener := range listeners.Listeners {
+				protocol := string(listener.Protocol)
+				if protocol != "HTTPS" && protocol != "TLS" {
+					continue
+				}
+				listenerInfo := ListenerInfo{
+					ARN:          aws.ToString(listener.ListenerArn),
+					Port:         aws.ToInt32(listener.Port),
+					Protocol:     protocol,
+					SSLPolicy:    aws.ToString(listener.SslPolicy),
+					Certificates: []ListenerCertificate{},
+				}
+
+				// DescribeListeners only returns the default certificate
+				certs, err := client.DescribeListenerCertificates(ctx, &elbv2.DescribeListenerCertificatesInput{ListenerArn: listener.ListenerArn})
+				if err != nil {
+					listenerInfo.Error = fmt.Sprintf("failed to describe listener certificates: %v", err)
+				} else {
+					for _, cert := range certs.Certificates {
+						arn := aws.ToString(cert.CertificateArn)
+						listenerCert := ListenerCertificate{ARN: arn, Source: CertificateSourceIAM, IsDefault: aws.ToBool(cert.IsDefault)}
+						if strings.Contains(arn, ":acm:") {
+							listenerCert.Source = CertificateSourceACM
+							if _, ok := described[arn]; !ok {
+								acmCert := describeACMCertificate(ctx, acmClient, arn)
+								described[arn] = &acmCert
+							}
+							listenerCert.ACM = described[arn]
+						} else {
+							listenerCert.IAM = serverCertificate(arn)
+						}
+						listenerInfo.Certificates = append(listenerInfo.Certificates, listenerCert)
+					}
+				}
+				info.Listeners = append(info.Listeners, listenerInfo)
+			}
+			balancers = append(balancers, info)
+		}
+	}
+
+	sort.Slice(balancers, func(i, j int) bool { return balancers[i].Name < balancers[j].Name })
+	return balancers, nil
+}
@@ -0,0 +1,60 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+)
+
+// IAMServerCertificate describes a legacy IAM server certificate. IAM never
+// renews these; an expired one breaks every listener still using it.
+type IAMServerCertificate struct {
+	Name         string     `json:"name"`
+	ARN          string     `json:"arn"`
+	ID           string     `json:"id"`
+	Path         string     `json:"path,omitempty"`
+	Uploaded     *time.Time `json:"uploaded,omitempty"`
+	NotAfter     *time.Time `json:"not_after,omitempty"`
+	DaysUntilExp *int       `json:"days_until_expiry,omitempty"`
+	IsExpired    bool       `json:"is_expired"`
+}
+
+// ListServerCertificates lists the IAM server certificates of the account
+// with their expiry. IAM is global, so the result is the same in every
+// region.
+func ListServerCertificates(ctx context.Context, awsCfg aws.Config) ([]IAMServerCertificate, error) {
+	client := iam.NewFromConfig(awsCfg)
+
+	var certs []IAMServerCertificate
+	paginator := iam.NewListServerCertificatesPaginator(client, &iam.ListServerCertificatesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list IAM server certificates: %w", err)
+		}
+		for _, metadata := range page.ServerCertificateMetadataList {
+			cert := IAMServerCertificate{
+				Name:     aws.ToString(metadata.ServerCertificateName),
+				ARN:      aws.ToString(metadata.Arn),
+				ID:       aws.ToString(metadata.ServerCertificateId),
+				Path:     aws.ToString(metadata.Path),
+				Uploaded: metadata.UploadDate,
+				NotAfter: metadata.Expiration,
+			}
+			if metadata.Expiration != nil {
+				now := time.Now()
+				days := int(metadata.Expiration.Sub(now).Hours() / 24)
+				cert.DaysUntilExp = &days
+				cert.IsExpired = now.After(*metadata.Expiration)
+			}
+			certs = append(certs, cert)
+		}
+	}
+
+	sort.Slice(certs, func(i, j int) bool { return certs[i].Name < certs[j].Name })
+	return certs, nil
+}
@@ -0,0 +1,156 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+// ScalingConfig is the size of a managed nodegroup
+type ScalingConfig struct {
+	MinSize     int32 `json:"min_size"`
+	MaxSize     int32 `json:"max_size"`
+	DesiredSize int32 `json:"desired_size"`
+}
+
+// NodegroupInfo describes an EKS managed nodegroup
+type NodegroupInfo struct {
+	Name              string         `json:"name"`
+	Status            string         `json:"status"`
+	KubernetesVersion string         `json:"kubernetes_version"`
+	AMIType           string         `json:"ami_type"`
+	ReleaseVersion    string         `json:"release_version"`
+	CapacityType      string         `json:"capacity_type"`
+	InstanceTypes     []string       `json:"instance_types"`
+	LaunchTemplate    string         `json:"launch_template,omitempty"`
+	Scaling           *ScalingConfig `json:"scaling,omitempty"`
+	Issues            []AddonIssue   `json:"issues,omitempty"`
+	Error             string         `json:"error,omitempty"`
+}
+
+// FargateSelector selects the pods that run on a Fargate profile
+type FargateSelector struct {
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// FargateProfileInfo describes an EKS Fargate profile
+type FargateProfileInfo struct {
+	Name                string            `json:"name"`
+	Status              string            `json:"status"`
+	PodExecutionRoleARN string            `json:"pod_execution_role_arn"`
+	Subnets             []string          `json:"subnets"`
+	Selectors           []FargateSelector `json:"selectors"`
+	Error               string            `json:"error,omitempty"`
+}
+
+// ComputeReport lists the managed nodegroups and Fargate profiles of a cluster
+type ComputeReport struct {
+	ClusterName     string               `json:"cluster_name"`
+	Nodegroups      []NodegroupInfo      `json:"nodegroups"`
+	FargateProfiles []FargateProfileInfo `json:"fargate_profiles"`
+}
+
+// ListCompute lists the managed nodegroups of the cluster with their AMI
+// release version and scaling configuration, and its Fargate profiles.
+// Self-managed nodes are not known to the EKS API and are not included.
+func (s *Session) ListCompute(ctx context.Context) (*ComputeReport, error) {
+	client := eks.NewFromConfig(s.AWS)
+	report := &ComputeReport{
+		ClusterName:     s.ClusterName,
+		Nodegroups:      []NodegroupInfo{},
+		FargateProfiles: []FargateProfileInfo{},
+	}
+
+	var nodegroups []string
+	nodegroupPages := eks.NewListNodegroupsPaginator(client, &eks.ListNodegroupsInput{ClusterName: aws.String(s.ClusterName)})
+	for nodegroupPages.HasMorePages() {
+		page, err := nodegroupPages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodegroups of EKS cluster %s: %w", s.ClusterName, err)
+		}
+		nodegroups = append(nodegroups, page.Nodegroups...)
+	}
+	sort.Strings(nodegroups)
+
+	for _, name := range nodegroups {
+		info := NodegroupInfo{Name: name}
+		out, err := client.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
+			ClusterName:   aws.String(s.ClusterName),
+			NodegroupName: aws.String(name),
+		})
+		if err != nil {
+			info.Error = fmt.Sprintf("failed to describe nodegroup: %v", err)
+			report.Nodegroups = append(report.Nodegroups, info)
+			continue
+		}
+
+		ng := out.Nodegroup
+		info.Status = string(ng.Status)
+		info.KubernetesVersion = aws.ToString(ng.Version)
+		info.AMIType = string(ng.AmiType)
+		info.ReleaseVersion = aws.ToString(ng.ReleaseVersion)
+		info.CapacityType = string(ng.CapacityType)
+		info.InstanceTypes = ng.InstanceTypes
+		if ng.LaunchTemplate != nil {
+			info.LaunchTemplate = fmt.Sprintf("%s (version %s)", aws.ToString(ng.LaunchTemplate.Name), aws.ToString(ng.LaunchTemplate.Version))
+		}
+		if ng.ScalingConfig != nil {
+			info.Scaling = &ScalingConfig{
+				MinSize:     aws.ToInt32(ng.ScalingConfig.MinSize),
+				MaxSize:     aws.ToInt32(ng.ScalingConfig.MaxSize),
+				DesiredSize: aws.ToInt32(ng.ScalingConfig.DesiredSize),
+			}
+		}
+		if ng.Health != nil {
+			for _, issue := range ng.Health.Issues {
+				info.Issues = append(info.Issues, AddonIssue{
+					Code:    string(issue.Code),
+					Message: aws.ToString(issue.Message),
+				})
+			}
+		}
+		report.Nodegroups = append(report.Nodegroups, info)
+	}
+
+	var profiles []string
+	profilePages := eks.NewListFargateProfilesPaginator(client, &eks.ListFargateProfilesInput{ClusterName: aws.String(s.ClusterName)})
+	for profilePages.HasMorePages() {
+		page, err := profilePages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Fargate profiles of EKS cluster %s: %w", s.ClusterName, err)
+		}
+		profiles = append(profiles, page.FargateProfileNames...)
+	}
+	sort.Strings(profiles)
+
+	for _, name := range profiles {
+		info := FargateProfileInfo{Name: name}
+		out, err := client.DescribeFargateProfile(ctx, &eks.DescribeFargateProfileInput{
+			ClusterName:        aws.String(s.ClusterName),
+			FargateProfileName: aws.String(name),
+		})
+		if err != nil {
+			info.Error = fmt.Sprintf("failed to describe Fargate profile: %v", err)
+			report.FargateProfiles = append(report.FargateProfiles, info)
+			continue
+		}
+
+		profile := out.FargateProfile
+		info.Status = string(profile.Status)
+		info.PodExecutionRoleARN = aws.ToString(profile.PodExecutionRoleArn)
+		info.Subnets = profile.Subnets
+		for _, selector := range profile.Selectors {
+			info.Selectors = append(info.Selectors, FargateSelector{
+				Namespace: aws.ToString(selector.Namespace),
+				Labels:    selector.Labels,
+			})
+		}
+		report.FargateProfiles = append(report.FargateProfiles, info)
+	}
+
+	return report, nil
+}
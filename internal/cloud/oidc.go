@@ -0,0 +1,159 @@
+package cloud
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+)
+
+// OIDC thumbprint statuses
+const (
+	ThumbprintMatch      = "match"
+	ThumbprintMismatch   = "mismatch"
+	ThumbprintNoProvider = "no_provider" // IRSA is not set up for the cluster
+	ThumbprintUnverified = "unverified"  // the issuer certificate could not be fetched
+)
+
+// oidcDialTimeout bounds the TLS handshake with the OIDC issuer
+const oidcDialTimeout = 10 * time.Second
+
+// OIDCChainCert describes a certificate served by the OIDC issuer
+type OIDCChainCert struct {
+	Subject    string    `json:"subject"`
+	Issuer     string    `json:"issuer"`
+	NotAfter   time.Time `json:"not_after"`
+	Thumbprint string    `json:"sha1_thumbprint"`
+}
+
+// OIDCThumbprintCheck compares the certificate chain of the cluster's OIDC
+// issuer with the thumbprints registered on the IAM OIDC provider
+type OIDCThumbprintCheck struct {
+	ClusterName           string          `json:"cluster_name"`
+	IssuerURL             string          `json:"issuer_url"`
+	ProviderARN           string          `json:"provider_arn,omitempty"`
+	RegisteredThumbprints []string        `json:"registered_thumbprints,omitempty"`
+	Chain                 []OIDCChainCert `json:"issuer_chain,omitempty"`
+	ExpectedThumbprint    string          `json:"expected_thumbprint,omitempty"`
+	MatchedThumbprint     string          `json:"matched_thumbprint,omitempty"`
+	Status                string          `json:"status"`
+	Message               string          `json:"message"`
+	FetchError            string          `json:"fetch_error,omitempty"`
+}
+
+// CheckOIDCThumbprint fetches the TLS certificate chain of the cluster's
+// OIDC issuer and checks that the IAM OIDC provider for the issuer lists the
+// thumbprint of one of its certificates. IAM expects the thumbprint of the
+// top certificate of the chain; a stale thumbprint makes IRSA credential
+// exchange fail without any error in the cluster.
+func (s *Session) CheckOIDCThumbprint(ctx context.Context) (*OIDCThumbprintCheck, error) {
+	cluster, err := eks.NewFromConfig(s.AWS).DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(s.ClusterName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe EKS cluster %s: %w", s.ClusterName, err)
+	}
+	check := &OIDCThumbprintCheck{ClusterName: s.ClusterName}
+	if cluster.Cluster.Identity == nil || cluster.Cluster.Identity.Oidc == nil || cluster.Cluster.Identity.Oidc.Issuer == nil {
+		return nil, fmt.Errorf("EKS cluster %s has no OIDC issuer", s.ClusterName)
+	}
+	check.IssuerURL = aws.ToString(cluster.Cluster.Identity.Oidc.Issuer)
+
+	// IAM stores the provider URL without the scheme
+	providerURL := strings.TrimPrefix(check.IssuerURL, "https://")
+	iamClient := iam.NewFromConfig(s.AWS)
+	providers, err := iamClient.ListOpenIDConnectProviders(ctx, &iam.ListOpenIDConnectProvidersInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IAM OIDC providers: %w", err)
+	}
+	for _, entry := range providers.OpenIDConnectProviderList {
+		arn := aws.ToString(entry.Arn)
+		if !strings.HasSuffix(arn, ":oidc-provider/"+providerURL) {
+			continue
+		}
+		provider, err := iamClient.GetOpenIDConnectProvider(ctx, &iam.GetOpenIDConnectProviderInput{OpenIDConnectProviderArn: aws.String(arn)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get IAM OIDC provider %s: %w", arn, err)
+		}
+		check.ProviderARN = arn
+		for _, thumbprint := range provider.ThumbprintList {
+			check.RegisteredThumbprints = append(check.RegisteredThumbprints, strings.ToLower(thumbprint))
+		}
+		break
+	}
+
+	chain, err := fetchIssuerChain(ctx, check.IssuerURL)
+	if err != nil {
+		check.FetchError = err.Error()
+	}
+	check.Chain = chain
+	if len(chain) > 0 {
+		check.ExpectedThumbprint = chain[len(chain)-1].Thumbprint
+	}
+
+	switch {
+	case check.ProviderARN == "":
+		check.Status = ThumbprintNoProvider
+		check.Message = "No IAM OIDC provider is registered for the cluster issuer; IAM roles for service accounts are not set up"
+	case len(chain) == 0:
+		check.Status = ThumbprintUnverified
+		check.Message = "The OIDC issuer certificate could not be fetched"
+	default:
+		for _, thumbprint := range check.RegisteredThumbprints {
+			for _, cert := range chain {
+				if thumbprint == cert.Thumbprint {
+					check.MatchedThumbprint = thumbprint
+				}
+			}
+		}
+		if check.MatchedThumbprint != "" {
+			check.Status = ThumbprintMatch
+			check.Message = "The IAM OIDC provider lists a thumbprint of the issuer certificate chain"
+		} else {
+			check.Status = ThumbprintMismatch
+			check.Message = fmt.Sprintf("None of the registered thumbprints match the issuer certificate chain; IRSA token exchange may fail. Add %s to the IAM OIDC provider.", check.ExpectedThumbprint)
+		}
+	}
+	return check, nil
+}
+
+// fetchIssuerChain returns the certificate chain served by the OIDC issuer
+func fetchIssuerChain(ctx context.Context, issuerURL string) ([]OIDCChainCert, error) {
+	parsed, err := url.Parse(issuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuer URL %s: %w", issuerURL, err)
+	}
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), "443")
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: oidcDialTimeout},
+		Config:    &tls.Config{ServerName: parsed.Hostname()},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to OIDC issuer %s: %w", host, err)
+	}
+	defer conn.Close()
+
+	var chain []OIDCChainCert
+	for _, cert := range conn.(*tls.Conn).ConnectionState().PeerCertificates {
+		sum := sha1.Sum(cert.Raw)
+		chain = append(chain, OIDCChainCert{
+			Subject:    cert.Subject.String(),
+			Issuer:     cert.Issuer.String(),
+			NotAfter:   cert.NotAfter,
+			Thumbprint: hex.EncodeToString(sum[:]),
+		})
+	}
+	return chain, nil
+}
@@ -0,0 +1,115 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	"github.com/aws/aws-sdk-go-v2/service/acmpca"
+)
+
+// ErrNotPrivateCertificate is returned when re-issuing a certificate that
+// was not issued by a private CA
+var ErrNotPrivateCertificate = errors.New("certificate was not issued by an ACM Private CA")
+
+// RevocationInfo is the revocation configuration of a private CA
+type RevocationInfo struct {
+	CRLEnabled        bool   `json:"crl_enabled"`
+	CRLExpirationDays int32  `json:"crl_expiration_days,omitempty"`
+	CRLBucket         string `json:"crl_s3_bucket,omitempty"`
+	CRLCustomCNAME    string `json:"crl_custom_cname,omitempty"`
+	OCSPEnabled       bool   `json:"ocsp_enabled"`
+	OCSPCustomCNAME   string `json:"ocsp_custom_cname,omitempty"`
+}
+
+// PrivateCAInfo describes an AWS Private CA
+type PrivateCAInfo struct {
+	ARN          string         `json:"arn"`
+	CommonName   string         `json:"common_name,omitempty"`
+	Type         string         `json:"type"` // ROOT or SUBORDINATE
+	Status       string         `json:"status"`
+	UsageMode    string         `json:"usage_mode,omitempty"`
+	KeyAlgorithm string         `json:"key_algorithm,omitempty"`
+	NotBefore    *time.Time     `json:"not_before,omitempty"`
+	NotAfter     *time.Time     `json:"not_after,omitempty"`
+	DaysUntilExp *int           `json:"days_until_expiry,omitempty"`
+	IsExpired    bool           `json:"is_expired"`
+	Revocation   RevocationInfo `json:"revocation"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// DescribePrivateCAs describes the private CAs with the given ARNs. A CA
+// that cannot be described, for example because it belongs to another
+// account, is reported with an error.
+func DescribePrivateCAs(ctx context.Context, awsCfg aws.Config, arns []string) []PrivateCAInfo {
+	client := acmpca.NewFromConfig(awsCfg)
+	now := time.Now()
+
+	cas := make([]PrivateCAInfo, 0, len(arns))
+	for _, arn := range arns {
+		info := PrivateCAInfo{ARN: arn}
+		out, err := client.DescribeCertificateAuthority(ctx, &acmpca.DescribeCertificateAuthorityInput{CertificateAuthorityArn: aws.String(arn)})
+		if err != nil {
+			info.Error = fmt.Sprintf("failed to describe certificate authority: %v", err)
+			cas = append(cas, info)
+			continue
+		}
+
+		ca := out.CertificateAuthority
+		info.Type = string(ca.Type)
+		info.Status = string(ca.Status)
+		info.UsageMode = string(ca.UsageMode)
+		info.NotBefore = ca.NotBefore
+		info.NotAfter = ca.NotAfter
+		if ca.NotAfter != nil {
+			days := int(ca.NotAfter.Sub(now).Hours() / 24)
+			info.DaysUntilExp = &days
+			info.IsExpired = now.After(*ca.NotAfter)
+		}
+		if caConfig := ca.CertificateAuthorityConfiguration; caConfig != nil {
+			info.KeyAlgorithm = string(caConfig.KeyAlgorithm)
+			if caConfig.Subject != nil {
+				info.CommonName = aws.ToString(caConfig.Subject.CommonName)
+			}
+		}
+		if revocation := ca.RevocationConfiguration; revocation != nil {
+			if crl := revocation.CrlConfiguration; crl != nil {
+				info.Revocation.CRLEnabled = aws.ToBool(crl.Enabled)
+				info.Revocation.CRLExpirationDays = aws.ToInt32(crl.ExpirationInDays)
+				info.Revocation.CRLBucket = aws.ToString(crl.S3BucketName)
+				info.Revocation.CRLCustomCNAME = aws.ToString(crl.CustomCname)
+			}
+			if ocsp := revocation.OcspConfiguration; ocsp != nil {
+				info.Revocation.OCSPEnabled = aws.ToBool(ocsp.Enabled)
+				info.Revocation.OCSPCustomCNAME = aws.ToString(ocsp.OcspCustomCname)
+			}
+		}
+		cas = append(cas, info)
+	}
+
+	sort.Slice(cas, func(i, j int) bool { return cas[i].ARN < cas[j].ARN })
+	return cas
+}
+
+// RenewPrivateCertificate requests ACM to re-issue a certificate issued by a
+// private CA. ACM renews the certificate in place under the same ARN, so
+// load balancers using it pick up the new certificate automatically.
+func RenewPrivateCertificate(ctx context.Context, awsCfg aws.Config, arn string) (*ACMCertificate, error) {
+	client := acm.NewFromConfig(awsCfg)
+	cert := describeACMCertificate(ctx, client, arn)
+	if cert.Error != "" {
+		return nil, errors.New(cert.Error)
+	}
+	if cert.Type != "PRIVATE" || cert.PrivateCAARN == "" {
+		return &cert, ErrNotPrivateCertificate
+	}
+
+	if _, err := client.RenewCertificate(ctx, &acm.RenewCertificateInput{CertificateArn: aws.String(arn)}); err != nil {
+		return &cert, fmt.Errorf("failed to renew certificate %s: %w", arn, err)
+	}
+	return &cert, nil
+}
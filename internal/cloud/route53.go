@@ -0,0 +1,106 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+// DNSRecord is a Route53 record pointing at a load balancer
+type DNSRecord struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Alias       bool   `json:"alias"`
+	Target      string `json:"target"`
+	Zone        string `json:"zone"`
+	ZoneID      string `json:"zone_id"`
+	PrivateZone bool   `json:"private_zone"`
+}
+
+// ListRecordsTargeting returns the A, AAAA, and CNAME records of every
+// hosted zone of the account whose alias target or value is one of
+// hostnames
+func ListRecordsTargeting(ctx context.Context, awsCfg aws.Config, hostnames []string) ([]DNSRecord, error) {
+	wanted := make(map[string]string, len(hostnames))
+	for _, host := range hostnames {
+		wanted[normalizeDNSName(host)] = host
+	}
+
+	client := route53.NewFromConfig(awsCfg)
+	var records []DNSRecord
+	zones := route53.NewListHostedZonesPaginator(client, &route53.ListHostedZonesInput{})
+	for zones.HasMorePages() {
+		page, err := zones.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list hosted zones: %w", err)
+		}
+		for _, zone := range page.HostedZones {
+			zoneID := strings.TrimPrefix(aws.ToString(zone.Id), "/hostedzone/")
+			private := zone.Config != nil && zone.Config.PrivateZone
+
+			recordPages := route53.NewListResourceRecordSetsPaginator(client, &route53.ListResourceRecordSetsInput{HostedZoneId: zone.Id})
+			for recordPages.HasMorePages() {
+				recordPage, err := recordPages.NextPage(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("failed to list records of hosted zone %s: %w", aws.ToString(zone.Name), err)
+				}
+				for _, set := range recordPage.ResourceRecordSets {
+					recordType := string(set.Type)
+					if recordType != "A" && recordType != "AAAA" && recordType != "CNAME" {
+						continue
+					}
+
+					var targets []string
+					alias := set.AliasTarget != nil
+					if alias {
+						targets = append(targets, aws.ToString(set.AliasTarget.DNSName))
+					}
+					for _, value := range set.ResourceRecords {
+						targets = append(targets, aws.ToString(value.Value))
+					}
+					for _, target := range targets {
+						host, ok := wanted[normalizeDNSName(target)]
+						if !ok {
+							continue
+						}
+						records = append(records, DNSRecord{
+							Name:        decodeDNSName(aws.ToString(set.Name)),
+							Type:        recordType,
+							Alias:       alias,
+							Target:      host,
+							Zone:        strings.TrimSuffix(aws.ToString(zone.Name), "."),
+							ZoneID:      zoneID,
+							PrivateZone: private,
+						})
+						break
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+		return records[i].Type < records[j].Type
+	})
+	return records, nil
+}
+
+// normalizeDNSName lowercases a DNS name and strips the trailing dot and the
+// dualstack. prefix Route53 adds to load balancer alias targets
+func normalizeDNSName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	return strings.TrimPrefix(name, "dualstack.")
+}
+
+// decodeDNSName strips the trailing dot of a Route53 record name and decodes
+// the \052 escape Route53 uses for wildcard records
+func decodeDNSName(name string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(name, "."), `\052`, "*")
+}
@@ -0,0 +1,163 @@
+package cloud
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// Formats of certificates stored in Secrets Manager
+const (
+	SecretFormatPEM    = "pem"
+	SecretFormatPKCS12 = "pkcs12"
+)
+
+// pkcs12PasswordKeys are the JSON keys holding the password of a PKCS#12
+// archive stored next to it in the same secret
+var pkcs12PasswordKeys = []string{"password", "passphrase", "keystore_password"}
+
+// SecretsManagerCertificate is a certificate bundle found in a Secrets
+// Manager secret. Key is the JSON key of the bundle when the secret is a
+// JSON object. Secret values are never returned.
+type SecretsManagerCertificate struct {
+	Name         string                   `json:"name"`
+	ARN          string                   `json:"arn"`
+	Key          string                   `json:"key,omitempty"`
+	Format       string                   `json:"format,omitempty"`
+	Certificates []*utils.CertificateInfo `json:"certificates,omitempty"`
+	Error        string                   `json:"error,omitempty"`
+}
+
+// ListSecretsManagerCertificates reads the secrets whose names start with one
+// of prefixes and parses the certificates they contain. A secret may hold PEM
+// text, a binary or base64 PKCS#12 archive, or a JSON object whose values are
+// either. Secrets without certificates are skipped; secrets that cannot be
+// read are reported with an error.
+func ListSecretsManagerCertificates(ctx context.Context, awsCfg aws.Config, prefixes []string) ([]SecretsManagerCertificate, error) {
+	client := secretsmanager.NewFromConfig(awsCfg)
+
+	seen := make(map[string]bool)
+	var entries []smtypes.SecretListEntry
+	for _, prefix := range prefixes {
+		paginator := secretsmanager.NewListSecretsPaginator(client, &secretsmanager.ListSecretsInput{
+			Filters: []smtypes.Filter{{Key: smtypes.FilterNameStringTypeName, Values: []string{prefix}}},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list secrets with prefix %s: %w", prefix, err)
+			}
+			for _, entry := range page.SecretList {
+				// The name filter also matches words inside the name, so
+				// check the prefix here
+				arn := aws.ToString(entry.ARN)
+				if !strings.HasPrefix(aws.ToString(entry.Name), prefix) || seen[arn] {
+					continue
+				}
+				seen[arn] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	var certs []SecretsManagerCertificate
+	for _, entry := range entries {
+		name, arn := aws.ToString(entry.Name), aws.ToString(entry.ARN)
+		value, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: entry.ARN})
+		if err != nil {
+			certs = append(certs, SecretsManagerCertificate{Name: name, ARN: arn, Error: fmt.Sprintf("failed to get secret value: %v", err)})
+			continue
+		}
+		for _, cert := range parseSecretValue(value.SecretString, value.SecretBinary) {
+			cert.Name, cert.ARN = name, arn
+			certs = append(certs, cert)
+		}
+	}
+
+	sort.SliceStable(certs, func(i, j int) bool {
+		if certs[i].Name != certs[j].Name {
+			return certs[i].Name < certs[j].Name
+		}
+		return certs[i].Key < certs[j].Key
+	})
+	return certs, nil
+}
+
+// parseSecretValue finds the certificate bundles of a secret value
+func parseSecretValue(secretString *string, secretBinary []byte) []SecretsManagerCertificate {
+	if len(secretBinary) > 0 {
+		if cert, ok := parseSecretField("", string(secretBinary), ""); ok {
+			return []SecretsManagerCertificate{cert}
+		}
+		return nil
+	}
+
+	text := aws.ToString(secretString)
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &fields); err != nil {
+		if cert, ok := parseSecretField("", text, ""); ok {
+			return []SecretsManagerCertificate{cert}
+		}
+		return nil
+	}
+
+	password := ""
+	for _, key := range pkcs12PasswordKeys {
+		if value, ok := fields[key].(string); ok {
+			password = value
+			break
+		}
+	}
+	var certs []SecretsManagerCertificate
+	for key, raw := range fields {
+		value, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		if cert, ok := parseSecretField(key, value, password); ok {
+			certs = append(certs, cert)
+		}
+	}
+	return certs
+}
+
+// parseSecretField parses a PEM bundle or a raw or base64 PKCS#12 archive.
+// It returns false when value holds neither.
+func parseSecretField(key, value, password string) (SecretsManagerCertificate, bool) {
+	cert := SecretsManagerCertificate{Key: key}
+	if strings.Contains(value, "-----BEGIN CERTIFICATE-----") {
+		cert.Format = SecretFormatPEM
+		chain, err := utils.ParseCertificateBundle(value)
+		if err != nil {
+			cert.Error = err.Error()
+		}
+		cert.Certificates = chain
+		return cert, true
+	}
+
+	data := []byte(value)
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value)); err == nil {
+		data = decoded
+	}
+	// PKCS#12 archives are DER SEQUENCEs; anything else is not a certificate
+	if len(data) == 0 || data[0] != 0x30 {
+		return cert, false
+	}
+	cert.Format = SecretFormatPKCS12
+	chain, err := utils.ParsePKCS12(data, password)
+	if err != nil {
+		// Usually a wrong or missing password
+		cert.Error = err.Error()
+	}
+	cert.Certificates = chain
+	return cert, true
+}
@@ -4,28 +4,492 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
 // Config represents the application configuration
 type Config struct {
+	// ReadOnly guarantees that no create, update, patch, or delete requests
+	// are sent to the cluster or AWS
+	ReadOnly bool `yaml:"read_only" json:"read_only"`
+
 	AWS struct {
-		AccessKeyID     string `yaml:"access_key_id"`
-		SecretAccessKey string `yaml:"secret_access_key"`
-		Region          string `yaml:"region"`
-	} `yaml:"aws"`
+		AccessKeyID     string `yaml:"access_key_id" json:"access_key_id"`
+		SecretAccessKey string `yaml:"secret_access_key" json:"secret_access_key"`
+		Region          string `yaml:"region" json:"region"`
+
+		// AssumeRole sets the session attributes of the AssumeRole call made
+		// when the kubeconfig names a role, so CloudTrail shows which scanner
+		// accessed the cluster
+		AssumeRole struct {
+			SessionName       string            `yaml:"session_name" json:"session_name"`
+			SourceIdentity    string            `yaml:"source_identity" json:"source_identity"`
+			SessionTags       map[string]string `yaml:"session_tags" json:"session_tags"`
+			TransitiveTagKeys []string          `yaml:"transitive_tag_keys" json:"transitive_tag_keys"`
+		} `yaml:"assume_role" json:"assume_role"`
+
+		SecretsManager struct {
+			// Prefixes are the secret name prefixes scanned for certificates
+			Prefixes []string `yaml:"prefixes" json:"prefixes"`
+		} `yaml:"secrets_manager" json:"secrets_manager"`
+
+		CloudFront struct {
+			// DistributionIDs are the distributions whose viewer certificates
+			// are checked
+			DistributionIDs []string `yaml:"distribution_ids" json:"distribution_ids"`
+		} `yaml:"cloudfront" json:"cloudfront"`
+	} `yaml:"aws" json:"aws"`
+
+	// Clusters overrides the AWS settings of individual clusters, keyed by
+	// kubeconfig context or EKS cluster name, for clusters in other regions
+	// or accounts than aws.region and its credentials. Endpoints select a
+	// cluster by its key with ?cluster=.
+	Clusters map[string]ClusterAWS `yaml:"clusters" json:"clusters"`
 
 	Kubernetes struct {
-		ClusterName      string `yaml:"cluster_name"`
-		ClusterEndpoint  string `yaml:"cluster_endpoint"`
-		DefaultNamespace string `yaml:"default_namespace"`
-	} `yaml:"kubernetes"`
+		ClusterName      string `yaml:"cluster_name" json:"cluster_name"`
+		ClusterEndpoint  string `yaml:"cluster_endpoint" json:"cluster_endpoint"`
+		DefaultNamespace string `yaml:"default_namespace" json:"default_namespace"`
+		KubeconfigPath   string `yaml:"kubeconfig_path" json:"kubeconfig_path"`
+		FixturesDir      string `yaml:"fixtures_dir" json:"fixtures_dir"`
+		// Auth selects how the service authenticates to the cluster: auto
+		// uses the pod's service account when running in a pod without an
+		// explicit kubeconfig, in-cluster always does, and kubeconfig never
+		Auth string `yaml:"auth" json:"auth"`
+	} `yaml:"kubernetes" json:"kubernetes"`
 
 	Server struct {
-		Port string `yaml:"port"`
-		Host string `yaml:"host"`
-	} `yaml:"server"`
+		Port string `yaml:"port" json:"port"`
+		Host string `yaml:"host" json:"host"`
+		// Timezone is the IANA time zone of human-readable dates in
+		// responses, e.g. Europe/Berlin; empty uses the server's local zone
+		Timezone string `yaml:"timezone" json:"timezone"`
+		// Durations configures how durations such as time_remaining and
+		// age are written
+		Durations DurationFormat `yaml:"durations" json:"durations"`
+		// ReadTimeout bounds reading a request, and WriteTimeout writing its
+		// response; an empty WriteTimeout leaves responses to the endpoint
+		// limits
+		ReadTimeout  string `yaml:"read_timeout" json:"read_timeout"`
+		WriteTimeout string `yaml:"write_timeout" json:"write_timeout"`
+		// ShutdownTimeout is how long running requests and scans may finish
+		// after SIGTERM or SIGINT before the server exits
+		ShutdownTimeout string `yaml:"shutdown_timeout" json:"shutdown_timeout"`
+		// TLS serves the API over HTTPS when CertFile is set
+		TLS struct {
+			CertFile string `yaml:"cert_file" json:"cert_file"`
+			KeyFile  string `yaml:"key_file" json:"key_file"`
+			// ClientCAFile verifies client certificates; ClientAuth is
+			// require to refuse requests without one, or optional to only
+			// verify those presented
+			ClientCAFile string `yaml:"client_ca_file" json:"client_ca_file"`
+			ClientAuth   string `yaml:"client_auth" json:"client_auth"`
+		} `yaml:"tls" json:"tls"`
+	} `yaml:"server" json:"server"`
+
+	Scanner struct {
+		Enabled     bool     `yaml:"enabled" json:"enabled"`
+		Interval    string   `yaml:"interval" json:"interval"`
+		Namespaces  []string `yaml:"namespaces" json:"namespaces"`
+		WarningDays int      `yaml:"warning_days" json:"warning_days"`
+		// Concurrency is the number of namespaces analyzed at once, by the
+		// background scanner and by requests that span several namespaces
+		Concurrency int `yaml:"concurrency" json:"concurrency"`
+		// RememberAlerts keeps the alerts delivered by daemon --once runs in
+		// the results ConfigMap of leader election, so that each run of a
+		// CronJob notifies about an alert once
+		RememberAlerts bool `yaml:"remember_alerts" json:"remember_alerts"`
+		// LeaderElection lets several replicas share one scanner: the
+		// replica holding a Lease scans and notifies, and the others serve
+		// the results it publishes
+		LeaderElection struct {
+			Enabled bool `yaml:"enabled" json:"enabled"`
+			// Namespace holds the Lease and the results ConfigMap; defaults
+			// to kubernetes.default_namespace
+			Namespace string `yaml:"namespace" json:"namespace"`
+			// LeaseName names the Lease; the results ConfigMap is
+			// LeaseName-results
+			LeaseName string `yaml:"lease_name" json:"lease_name"`
+			// LeaseDuration is how long replicas wait for a leader that
+			// stopped renewing the Lease before taking over
+			LeaseDuration string `yaml:"lease_duration" json:"lease_duration"`
+			// ShardNamespaces splits the scanned namespaces between the
+			// live replicas by hash; the leader merges their results
+			ShardNamespaces bool `yaml:"shard_namespaces" json:"shard_namespaces"`
+		} `yaml:"leader_election" json:"leader_election"`
+	} `yaml:"scanner" json:"scanner"`
+
+	Notifiers struct {
+		WebhookURL      string `yaml:"webhook_url" json:"webhook_url"`
+		SlackWebhookURL string `yaml:"slack_webhook_url" json:"slack_webhook_url"`
+	} `yaml:"notifiers" json:"notifiers"`
+
+	// CustomResources lists custom resource fields holding PEM certificates,
+	// analyzed with the pods of each namespace
+	CustomResources []CustomResource `yaml:"custom_resources" json:"custom_resources"`
+
+	// Policies are certificate rules per namespace, evaluated by
+	// /policy-violations
+	Policies []Policy `yaml:"policies" json:"policies"`
+
+	// Images configures the opt-in analysis of the CA bundles baked into
+	// workload images
+	Images struct {
+		// CABundleAnalysis allows pulling workload images from their
+		// registries
+		CABundleAnalysis bool `yaml:"ca_bundle_analysis" json:"ca_bundle_analysis"`
+		// MaxImages caps the images pulled per request
+		MaxImages int `yaml:"max_images" json:"max_images"`
+	} `yaml:"images" json:"images"`
+
+	// Bundles controls how certificate sources holding many certificates,
+	// such as system trust bundles, are reported
+	Bundles struct {
+		// MaxCertificates is the number of certificates above which a
+		// source lists only its expired and expiring certificates, at most
+		// this many, with aggregate statistics
+		MaxCertificates int `yaml:"max_certificates" json:"max_certificates"`
+	} `yaml:"bundles" json:"bundles"`
+
+	// Agent configures node agents, which report certificate files on host
+	// paths that pods mount through hostPath volumes
+	Agent struct {
+		// Token authenticates agent reports; the service refuses reports
+		// when it is empty
+		Token string `yaml:"token" json:"token"`
+		// ServerURL is the service URL agents report to
+		ServerURL string `yaml:"server_url" json:"server_url"`
+		// HostRoot is where the node filesystem is mounted in the agent pod
+		HostRoot string `yaml:"host_root" json:"host_root"`
+		// Paths are the node directories scanned for certificate files
+		Paths []string `yaml:"paths" json:"paths"`
+		// Interval is how often agents report
+		Interval string `yaml:"interval" json:"interval"`
+	} `yaml:"agent" json:"agent"`
+
+	// Admin configures the authentication of the admin endpoints, which are
+	// only served when endpoints.admin is true
+	Admin struct {
+		// Token authenticates admin requests presented as a bearer token.
+		// Requests with a verified client certificate are accepted too;
+		// without either, every admin request is refused.
+		Token string `yaml:"token" json:"token"`
+	} `yaml:"admin" json:"admin"`
+
+	// Admission configures the admission webhook (webhook command), which
+	// checks the certificates of the secrets that Pods reference when they
+	// are created, and Ingresses when they are created or updated
+	Admission struct {
+		Port string `yaml:"port" json:"port"`
+		// CertFile and KeyFile are the TLS serving certificate; the API
+		// server only calls webhooks over HTTPS
+		CertFile string `yaml:"cert_file" json:"cert_file"`
+		KeyFile  string `yaml:"key_file" json:"key_file"`
+		// Mode is deny to reject objects referencing expired or expiring
+		// certificates, or warn to admit them with warnings
+		Mode string `yaml:"mode" json:"mode"`
+		// WindowDays treats certificates expiring within this many days
+		// like expired ones; 0 only checks for expired certificates
+		WindowDays int `yaml:"window_days" json:"window_days"`
+	} `yaml:"admission" json:"admission"`
+
+	Logging struct {
+		Level  string `yaml:"level" json:"level"`
+		Format string `yaml:"format" json:"format"`
+	} `yaml:"logging" json:"logging"`
+
+	// Security controls certificate material in responses, for deployments
+	// whose responses flow into shared logging systems
+	Security struct {
+		// RedactPEM removes raw PEM content from responses; certificates are
+		// identified by their SHA-256 fingerprints instead
+		RedactPEM bool `yaml:"redact_pem" json:"redact_pem"`
+		// RedactSubjects removes the subject, issuer, and serial number of
+		// certificates from responses, leaving their fingerprints
+		RedactSubjects bool `yaml:"redact_subjects" json:"redact_subjects"`
+		// PrivacyMode guarantees metadata-only handling of secrets: PEM
+		// content is always redacted, including from non-JSON responses,
+		// secret key names other than certificate and key file names are
+		// hidden, and certificate subjects are removed for the
+		// SensitiveNamespaces
+		PrivacyMode bool `yaml:"privacy_mode" json:"privacy_mode"`
+		// SensitiveNamespaces are the namespaces whose certificate subjects
+		// are removed in privacy mode; all namespaces if empty
+		SensitiveNamespaces []string `yaml:"sensitive_namespaces" json:"sensitive_namespaces"`
+	} `yaml:"security" json:"security"`
+
+	// Endpoints enables or disables endpoint groups by name; groups that are
+	// not listed are enabled, except the admin group
+	Endpoints map[string]bool `yaml:"endpoints" json:"endpoints"`
+
+	// Limits sets the request timeout and result size per endpoint group.
+	// The "default" entry applies to endpoints without a group and fills in
+	// settings a group does not set.
+	Limits map[string]EndpointLimit `yaml:"limits" json:"limits"`
+}
+
+// CustomResource locates the certificates stored in a custom resource kind
+type CustomResource struct {
+	// GVK is group/version/Kind, e.g. kafka.strimzi.io/v1beta2/Kafka, or
+	// version/Kind for core kinds
+	GVK string `yaml:"gvk" json:"gvk"`
+	// JSONPath selects the fields holding PEM data, in kubectl JSONPath
+	// syntax, e.g. {.status.listeners[*].certificates[*]}
+	JSONPath string `yaml:"jsonpath" json:"jsonpath"`
+}
+
+// ParseGVK splits GVK into its group, version, and kind; the group is empty
+// for core kinds
+func (r CustomResource) ParseGVK() (group, version, kind string, err error) {
+	parts := strings.Split(r.GVK, "/")
+	switch {
+	case len(parts) == 2:
+		version, kind = parts[0], parts[1]
+	case len(parts) == 3:
+		group, version, kind = parts[0], parts[1], parts[2]
+	default:
+		return "", "", "", fmt.Errorf("%q is not group/version/Kind", r.GVK)
+	}
+	if version == "" || kind == "" || (len(parts) == 3 && group == "") {
+		return "", "", "", fmt.Errorf("%q is not group/version/Kind", r.GVK)
+	}
+	return group, version, kind, nil
+}
+
+// Kubernetes authentication modes
+const (
+	KubernetesAuthAuto       = "auto"
+	KubernetesAuthInCluster  = "in-cluster"
+	KubernetesAuthKubeconfig = "kubeconfig"
+)
+
+// Admission webhook modes
+const (
+	AdmissionDeny = "deny"
+	AdmissionWarn = "warn"
+)
+
+// Policy is a set of certificate rules for the namespaces it names. Each rule
+// that is set passes or fails on its own.
+type Policy struct {
+	Name string `yaml:"name" json:"name"`
+	// Namespaces are the namespaces the policy applies to, as names or
+	// shell patterns such as prod-*; all namespaces if empty
+	Namespaces []string `yaml:"namespaces" json:"namespaces"`
+	// MinDaysRemaining is the runway every certificate must have left
+	MinDaysRemaining int `yaml:"min_days_remaining" json:"min_days_remaining"`
+	// AllowedIssuers restricts the issuers of leaf certificates; an issuer
+	// is allowed when its distinguished name contains one of the entries,
+	// e.g. "CN=Example Internal CA"
+	AllowedIssuers []string `yaml:"allowed_issuers" json:"allowed_issuers"`
+	// MaxValidityDays caps the validity period of leaf certificates
+	MaxValidityDays int `yaml:"max_validity_days" json:"max_validity_days"`
+}
+
+// AppliesTo reports whether the policy applies to a namespace
+func (p Policy) AppliesTo(namespace string) bool {
+	if len(p.Namespaces) == 0 {
+		return true
+	}
+	for _, pattern := range p.Namespaces {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ClusterAWS overrides the AWS settings of one cluster. A cluster without a
+// region uses the region of its kubeconfig endpoint, then aws.region.
+type ClusterAWS struct {
+	// KubeconfigPath and Context locate the cluster when it is selected by
+	// name; they default to kubernetes.kubeconfig_path and to the name
+	KubeconfigPath string `yaml:"kubeconfig_path" json:"kubeconfig_path"`
+	Context        string `yaml:"context" json:"context"`
+	Region         string `yaml:"region" json:"region"`
+	// Partition is aws, aws-cn, aws-us-gov, aws-iso, or aws-iso-b; it
+	// follows from the region when empty
+	Partition       string `yaml:"partition" json:"partition"`
+	AccessKeyID     string `yaml:"access_key_id" json:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key" json:"secret_access_key"`
+	// RoleARN is assumed to authenticate to the cluster, instead of the
+	// kubeconfig's --role-arn
+	RoleARN string `yaml:"role_arn" json:"role_arn"`
+}
+
+// ClusterAWS returns the AWS overrides of a cluster, looked up by each of
+// its names in turn
+func (c *Config) ClusterAWS(names ...string) (ClusterAWS, bool) {
+	for _, name := range names {
+		if override, ok := c.Clusters[name]; ok && name != "" {
+			return override, true
+		}
+	}
+	return ClusterAWS{}, false
+}
+
+// ClusterNames returns the names of the configured clusters in order
+func (c *Config) ClusterNames() []string {
+	names := make([]string, 0, len(c.Clusters))
+	for name := range c.Clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForCluster returns a copy of the configuration for the AWS calls of a
+// cluster, with its region and, if overridden, its static credentials
+func (c *Config) ForCluster(override ClusterAWS, region string) *Config {
+	clusterCfg := *c
+	clusterCfg.AWS.Region = region
+	if override.AccessKeyID != "" && override.SecretAccessKey != "" {
+		clusterCfg.AWS.AccessKeyID = override.AccessKeyID
+		clusterCfg.AWS.SecretAccessKey = override.SecretAccessKey
+	}
+	return &clusterCfg
+}
+
+// partitionRegions maps the AWS partitions to the region used for STS when
+// a cluster's partition is known but not its region
+var partitionRegions = map[string]string{
+	"aws":        "us-east-1",
+	"aws-cn":     "cn-north-1",
+	"aws-us-gov": "us-gov-west-1",
+	"aws-iso":    "us-iso-east-1",
+	"aws-iso-b":  "us-isob-east-1",
+}
+
+// PartitionForRegion returns the AWS partition of a region, or "" if the
+// region is empty
+func PartitionForRegion(region string) string {
+	switch {
+	case region == "":
+		return ""
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	case strings.HasPrefix(region, "us-isob-"):
+		return "aws-iso-b"
+	case strings.HasPrefix(region, "us-iso-"):
+		return "aws-iso"
+	}
+	return "aws"
+}
+
+// PartitionRegion returns the default region of a partition, or "" if the
+// partition is unknown
+func PartitionRegion(partition string) string {
+	return partitionRegions[partition]
+}
+
+// Duration units, largest first
+var DurationUnits = []string{"years", "months", "weeks", "days", "hours", "minutes"}
+
+// DurationUnitIndex returns the position of a unit in DurationUnits, or -1
+// if it is not a unit
+func DurationUnitIndex(unit string) int {
+	for i, u := range DurationUnits {
+		if u == unit {
+			return i
+		}
+	}
+	return -1
+}
+
+// DurationFormat configures human-readable durations. Years and months are
+// counted on the calendar, so leap years and month lengths are exact.
+type DurationFormat struct {
+	// Units are the units durations are broken into, in any order; a
+	// remainder smaller than the smallest unit is dropped
+	Units []string `yaml:"units" json:"units"`
+	// Precision is the number of units written, from the largest non-zero one
+	Precision int `yaml:"precision" json:"precision"`
+	// Compact writes "2y 11m" instead of "2 years, 11 months"
+	Compact bool `yaml:"compact" json:"compact"`
+}
+
+// EndpointLimit bounds the cost of requests to an endpoint group
+type EndpointLimit struct {
+	// Timeout is a Go duration after which the request is aborted
+	Timeout string `yaml:"timeout" json:"timeout"`
+	// MaxResults caps the number of items in list responses; 0 is unlimited
+	MaxResults int `yaml:"max_results" json:"max_results"`
+}
+
+// Client certificate modes of server.tls.client_auth
+const (
+	ClientAuthRequire  = "require"
+	ClientAuthOptional = "optional"
+)
+
+// Limit defaults
+const (
+	DefaultLimitsKey      = "default"
+	DefaultRequestTimeout = 60 * time.Second
+)
+
+// Endpoint groups that can be disabled with the endpoints setting
+const (
+	EndpointGroupClusterCA      = "cluster_ca"
+	EndpointGroupSecretScanning = "secret_scanning"
+	EndpointGroupExecAnalysis   = "exec_analysis"
+	EndpointGroupProbes         = "probes"
+	EndpointGroupDebug          = "debug"
+	EndpointGroupAdmin          = "admin"
+	EndpointGroupAWS            = "aws"
+	EndpointGroupNodeAgent      = "node_agent"
+)
+
+// endpointGroupsOffByDefault are the groups served only when the endpoints
+// setting enables them: the admin endpoints change the server's state and
+// send notifications
+var endpointGroupsOffByDefault = map[string]bool{EndpointGroupAdmin: true}
+
+// EndpointGroups lists the endpoint groups that can be disabled
+var EndpointGroups = []string{
+	EndpointGroupClusterCA,
+	EndpointGroupSecretScanning,
+	EndpointGroupExecAnalysis,
+	EndpointGroupProbes,
+	EndpointGroupDebug,
+	EndpointGroupAdmin,
+	EndpointGroupAWS,
+	EndpointGroupNodeAgent,
+}
+
+// Overrides holds configuration values supplied on the command line.
+// Zero values are ignored so only flags that were actually set take effect.
+type Overrides struct {
+	Host             string
+	TLSCertFile      string
+	TLSKeyFile       string
+	TLSClientCAFile  string
+	Port             string
+	DefaultNamespace string
+	Region           string
+	ClusterName      string
+	ClusterEndpoint  string
+	KubeconfigPath   string
+	FixturesDir      string
+	WarningDays      int
+	RememberAlerts   bool
+	LogLevel         string
+	LogFormat        string
+	ReadOnly         bool
+	// Admission settings of the webhook command
+	AdmissionPort     string
+	AdmissionCertFile string
+	AdmissionKeyFile  string
+	AdmissionMode     string
+	// AdmissionWindowDays is nil when not set, since 0 days is a valid window
+	AdmissionWindowDays *int
 }
 
 // Load loads configuration from file and environment variables
@@ -56,6 +520,15 @@ func Load(configPath string) (*Config, error) {
 	if awsRegion := os.Getenv("AWS_REGION"); awsRegion != "" {
 		config.AWS.Region = awsRegion
 	}
+	if sourceIdentity := os.Getenv("AWS_SOURCE_IDENTITY"); sourceIdentity != "" {
+		config.AWS.AssumeRole.SourceIdentity = sourceIdentity
+	}
+	if agentToken := os.Getenv("AGENT_TOKEN"); agentToken != "" {
+		config.Agent.Token = agentToken
+	}
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
+		config.Admin.Token = adminToken
+	}
 	if k8sClusterName := os.Getenv("K8S_CLUSTER_NAME"); k8sClusterName != "" {
 		config.Kubernetes.ClusterName = k8sClusterName
 	}
@@ -72,6 +545,239 @@ func Load(configPath string) (*Config, error) {
 	return config, nil
 }
 
+// ApplyOverrides applies command-line overrides, which take precedence over
+// both the config file and environment variables
+func (c *Config) ApplyOverrides(o Overrides) {
+	if o.Host != "" {
+		c.Server.Host = o.Host
+	}
+	if o.Port != "" {
+		c.Server.Port = o.Port
+	}
+	if o.TLSCertFile != "" {
+		c.Server.TLS.CertFile = o.TLSCertFile
+	}
+	if o.TLSKeyFile != "" {
+		c.Server.TLS.KeyFile = o.TLSKeyFile
+	}
+	if o.TLSClientCAFile != "" {
+		c.Server.TLS.ClientCAFile = o.TLSClientCAFile
+	}
+	if o.DefaultNamespace != "" {
+		c.Kubernetes.DefaultNamespace = o.DefaultNamespace
+	}
+	if o.Region != "" {
+		c.AWS.Region = o.Region
+	}
+	if o.ClusterName != "" {
+		c.Kubernetes.ClusterName = o.ClusterName
+	}
+	if o.ClusterEndpoint != "" {
+		c.Kubernetes.ClusterEndpoint = o.ClusterEndpoint
+	}
+	if o.KubeconfigPath != "" {
+		c.Kubernetes.KubeconfigPath = o.KubeconfigPath
+	}
+	if o.FixturesDir != "" {
+		c.Kubernetes.FixturesDir = o.FixturesDir
+	}
+	if o.WarningDays != 0 {
+		c.Scanner.WarningDays = o.WarningDays
+	}
+	if o.RememberAlerts {
+		c.Scanner.RememberAlerts = true
+	}
+	if o.ReadOnly {
+		c.ReadOnly = true
+	}
+	if o.LogLevel != "" {
+		c.Logging.Level = o.LogLevel
+	}
+	if o.LogFormat != "" {
+		c.Logging.Format = o.LogFormat
+	}
+	if o.AdmissionPort != "" {
+		c.Admission.Port = o.AdmissionPort
+	}
+	if o.AdmissionCertFile != "" {
+		c.Admission.CertFile = o.AdmissionCertFile
+	}
+	if o.AdmissionKeyFile != "" {
+		c.Admission.KeyFile = o.AdmissionKeyFile
+	}
+	if o.AdmissionMode != "" {
+		c.Admission.Mode = o.AdmissionMode
+	}
+	if o.AdmissionWindowDays != nil {
+		c.Admission.WindowDays = *o.AdmissionWindowDays
+	}
+}
+
+// SetDefaults fills in default values for settings that are not configured
+func (c *Config) SetDefaults() {
+	if c.Server.Port == "" {
+		c.Server.Port = "8080"
+	}
+	if c.Server.Host == "" {
+		c.Server.Host = "localhost"
+	}
+	if len(c.Server.Durations.Units) == 0 {
+		c.Server.Durations.Units = []string{"years", "months", "days", "hours"}
+	}
+	if c.Server.Durations.Precision == 0 {
+		c.Server.Durations.Precision = 2
+	}
+	if c.Server.ReadTimeout == "" {
+		c.Server.ReadTimeout = "30s"
+	}
+	if c.Server.ShutdownTimeout == "" {
+		c.Server.ShutdownTimeout = "25s"
+	}
+	if c.Server.TLS.ClientCAFile != "" && c.Server.TLS.ClientAuth == "" {
+		c.Server.TLS.ClientAuth = ClientAuthRequire
+	}
+	if c.AWS.AssumeRole.SessionName == "" {
+		c.AWS.AssumeRole.SessionName = "k8s-web-service-session"
+	}
+	if c.Kubernetes.DefaultNamespace == "" {
+		c.Kubernetes.DefaultNamespace = "default"
+	}
+	if c.Kubernetes.Auth == "" {
+		c.Kubernetes.Auth = KubernetesAuthAuto
+	}
+	if c.Scanner.Interval == "" {
+		c.Scanner.Interval = "1h"
+	}
+	if c.Scanner.WarningDays == 0 {
+		c.Scanner.WarningDays = 30
+	}
+	if c.Scanner.Concurrency == 0 {
+		c.Scanner.Concurrency = 4
+	}
+	if len(c.Scanner.Namespaces) == 0 {
+		c.Scanner.Namespaces = []string{c.Kubernetes.DefaultNamespace}
+	}
+	if c.Scanner.LeaderElection.Namespace == "" {
+		c.Scanner.LeaderElection.Namespace = c.Kubernetes.DefaultNamespace
+	}
+	if c.Scanner.LeaderElection.LeaseName == "" {
+		c.Scanner.LeaderElection.LeaseName = "k8s-web-service-scanner"
+	}
+	if c.Scanner.LeaderElection.LeaseDuration == "" {
+		c.Scanner.LeaderElection.LeaseDuration = "15s"
+	}
+	if c.Images.MaxImages == 0 {
+		c.Images.MaxImages = 20
+	}
+	if c.Bundles.MaxCertificates == 0 {
+		c.Bundles.MaxCertificates = 20
+	}
+	if c.Agent.HostRoot == "" {
+		c.Agent.HostRoot = "/host"
+	}
+	if len(c.Agent.Paths) == 0 {
+		c.Agent.Paths = []string{"/etc/pki", "/etc/ssl/certs", "/etc/kubernetes/pki", "/var/lib/kubelet/pki"}
+	}
+	if c.Agent.Interval == "" {
+		c.Agent.Interval = "1h"
+	}
+	if c.Admission.Port == "" {
+		c.Admission.Port = "8443"
+	}
+	if c.Admission.Mode == "" {
+		c.Admission.Mode = AdmissionDeny
+	}
+	if c.Logging.Level == "" {
+		c.Logging.Level = "info"
+	}
+	if c.Logging.Format == "" {
+		c.Logging.Format = "text"
+	}
+}
+
+// EndpointGroupEnabled reports whether the endpoints of a group are served.
+// Endpoints without a group are always served, and groups that are not
+// listed are served unless they are off by default.
+func (c *Config) EndpointGroupEnabled(group string) bool {
+	if group == "" {
+		return true
+	}
+	enabled, listed := c.Endpoints[group]
+	if !listed {
+		return !endpointGroupsOffByDefault[group]
+	}
+	return enabled
+}
+
+// EndpointLimits returns the request timeout and maximum number of results
+// for an endpoint group, falling back to the "default" entry and then to
+// DefaultRequestTimeout with no result limit
+func (c *Config) EndpointLimits(group string) (timeout time.Duration, maxResults int) {
+	timeout = DefaultRequestTimeout
+	for _, key := range []string{DefaultLimitsKey, group} {
+		limit, ok := c.Limits[key]
+		if !ok || key == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(limit.Timeout); err == nil && d > 0 {
+			timeout = d
+		}
+		if limit.MaxResults > 0 {
+			maxResults = limit.MaxResults
+		}
+	}
+	return timeout, maxResults
+}
+
+// ServerTLSEnabled reports whether the API is served over HTTPS
+func (c *Config) ServerTLSEnabled() bool {
+	return c.Server.TLS.CertFile != ""
+}
+
+// ClientCertificateRequired reports whether requests must present a client
+// certificate signed by server.tls.client_ca_file
+func (c *Config) ClientCertificateRequired() bool {
+	return c.ServerTLSEnabled() && c.Server.TLS.ClientCAFile != "" && c.Server.TLS.ClientAuth == ClientAuthRequire
+}
+
+// ServerTimeouts returns the parsed read, write, and shutdown timeouts of
+// the HTTP server; zero means no limit
+func (c *Config) ServerTimeouts() (read, write, shutdown time.Duration) {
+	read, _ = time.ParseDuration(c.Server.ReadTimeout)
+	write, _ = time.ParseDuration(c.Server.WriteTimeout)
+	shutdown, _ = time.ParseDuration(c.Server.ShutdownTimeout)
+	return read, write, shutdown
+}
+
+// Redacted returns a copy of the configuration with credentials masked,
+// suitable for logging and the /debug endpoint
+func (c *Config) Redacted() Config {
+	redacted := *c
+	redacted.AWS.AccessKeyID = mask(c.AWS.AccessKeyID)
+	redacted.AWS.SecretAccessKey = mask(c.AWS.SecretAccessKey)
+	redacted.Notifiers.WebhookURL = mask(c.Notifiers.WebhookURL)
+	redacted.Notifiers.SlackWebhookURL = mask(c.Notifiers.SlackWebhookURL)
+	redacted.Agent.Token = mask(c.Agent.Token)
+	redacted.Admin.Token = mask(c.Admin.Token)
+	if c.Clusters != nil {
+		redacted.Clusters = make(map[string]ClusterAWS, len(c.Clusters))
+		for name, override := range c.Clusters {
+			override.AccessKeyID = mask(override.AccessKeyID)
+			override.SecretAccessKey = mask(override.SecretAccessKey)
+			redacted.Clusters[name] = override
+		}
+	}
+	return redacted
+}
+
+// mask hides a sensitive value while still showing whether it is set
+func mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "********"
+}
+
 // ValidateAWSConfig checks if required AWS credentials are present
 func (c *Config) ValidateAWSConfig() error {
 	// Allow for no explicit AWS creds if relying on EC2 instance profile, env vars, or shared credentials
@@ -97,3 +803,24 @@ func (c *Config) ValidateAWSConfig() error {
 
 	return nil
 }
+
+// AllNamespaces in scanner.namespaces scans every namespace of the cluster
+const AllNamespaces = "*"
+
+// ScansAllNamespaces reports whether the background scanner scans every
+// namespace, listed again at each scan
+func (c *Config) ScansAllNamespaces() bool {
+	return len(c.Scanner.Namespaces) == 1 && c.Scanner.Namespaces[0] == AllNamespaces
+}
+
+// ScanInterval returns the parsed background scanner interval
+func (c *Config) ScanInterval() (time.Duration, error) {
+	interval, err := time.ParseDuration(c.Scanner.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid scanner interval %q: %w", c.Scanner.Interval, err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("scanner interval must be positive, got %s", interval)
+	}
+	return interval, nil
+}
@@ -0,0 +1,288 @@
+package config
+
+// Example is a fully commented configuration file covering every setting,
+// populated with the default values
+const Example = `# k8s-web-service configuration
+#
+# Precedence is command-line flags > environment variables > this file.
+# Check this file with: k8s-web-service config validate
+
+# Guarantee that no create, update, patch, or delete requests are sent to the
+# cluster or AWS. Flag: --read-only
+read_only: false
+
+# AWS credentials used to generate EKS authentication tokens.
+# Leave the keys empty to use the default AWS credential chain
+# (environment, shared credentials file, instance role).
+aws:
+  # Env: AWS_ACCESS_KEY_ID. Must be set together with secret_access_key.
+  access_key_id: ""
+  # Env: AWS_SECRET_ACCESS_KEY
+  secret_access_key: ""
+  # Env: AWS_REGION. Flag: --region
+  region: "us-gov-west-1"
+  # Session attributes of the AssumeRole call made when the kubeconfig
+  # names a role (--role-arn). A source identity or session tags show in
+  # CloudTrail which team or instance of the scanner accessed the cluster.
+  assume_role:
+    session_name: "k8s-web-service-session"
+    # Env: AWS_SOURCE_IDENTITY (e.g. the pod name from the downward API)
+    source_identity: ""
+    session_tags: {}
+    #   team: platform
+    #   scanner-instance: prod-east
+    transitive_tag_keys: []
+  # Secrets Manager secrets scanned by /aws/secretsmanager-certificates,
+  # selected by name prefix (e.g. "prod/tls/")
+  secrets_manager:
+    prefixes: []
+  # CloudFront distributions fronting the cluster, checked by
+  # /aws/cloudfront-certificates (e.g. "E2QWRUHAPOMQZL")
+  cloudfront:
+    distribution_ids: []
+
+# AWS settings per cluster, keyed by kubeconfig context or EKS cluster name,
+# for clusters in other regions or accounts. The region defaults to the one
+# in the cluster endpoint, then aws.region; the partition (aws, aws-cn,
+# aws-us-gov, aws-iso, aws-iso-b) follows from the region. Endpoints select
+# a cluster with ?cluster=<key>, using its context (default: the key) in its
+# kubeconfig_path (default: kubernetes.kubeconfig_path).
+clusters: {}
+#   prod-eu:
+#     kubeconfig_path: "/etc/k8s-web-service/prod.kubeconfig"
+#     context: "arn:aws:eks:eu-west-1:123456789012:cluster/prod"
+#     region: "eu-west-1"
+#   gov-east:
+#     partition: "aws-us-gov"
+#     role_arn: "arn:aws-us-gov:iam::123456789012:role/cert-scanner"
+#     access_key_id: ""
+#     secret_access_key: ""
+
+# Kubernetes cluster access
+kubernetes:
+  # EKS cluster name. Env: K8S_CLUSTER_NAME. Flag: --cluster-name
+  cluster_name: ""
+  # API server URL. Env: K8S_CLUSTER_ENDPOINT. Flag: --cluster-endpoint
+  cluster_endpoint: ""
+  # Namespace used when a request does not specify one.
+  # Env: K8S_DEFAULT_NAMESPACE. Flag: --namespace
+  default_namespace: "default"
+  # Kubeconfig file. Defaults to $KUBECONFIG, then ~/.kube/config. Flag: --kubeconfig
+  kubeconfig_path: ""
+  # How to authenticate: auto uses the pod's service account when running
+  # in a pod and no kubeconfig is set (kubeconfig_path, --kubeconfig, or
+  # $KUBECONFIG), in-cluster always uses it, kubeconfig never does
+  auth: "auto"
+  # Serve from YAML fixture files in this directory instead of a live
+  # cluster (e.g. examples/fixtures). Flag: --fixtures
+  fixtures_dir: ""
+
+# HTTP API server
+server:
+  # Bind address. Flag: --host
+  host: "localhost"
+  # Listen port. Env: SERVER_PORT. Flag: --port
+  port: "8080"
+  # IANA time zone of human-readable dates in responses (e.g. UTC,
+  # Europe/Berlin). Empty uses the server's local zone. ?tz= overrides it.
+  timezone: ""
+  # Human-readable durations such as time_remaining. Years and months follow
+  # the calendar. units: any of years, months, weeks, days, hours, minutes.
+  # precision is the number of units written; compact writes "2y 11m".
+  durations:
+    units: [years, months, days, hours]
+    precision: 2
+    compact: false
+  # Time allowed to read a request, and to write its response. An empty
+  # write_timeout leaves responses to the endpoint limits; set it above the
+  # longest limits timeout. Both require a restart.
+  read_timeout: "30s"
+  write_timeout: ""
+  # On SIGTERM or SIGINT the server drains: /readyz fails, new scans are
+  # refused, and running requests and scans get this long to finish. Keep it
+  # below the pod's terminationGracePeriodSeconds.
+  shutdown_timeout: "25s"
+  # Serve HTTPS with this certificate, reloaded when the file changes. With
+  # client_ca_file, client certificates are verified; client_auth require
+  # refuses requests without one except /healthz, /readyz, and
+  # /agent/report, optional only verifies those presented. Flags:
+  # --tls-cert-file, --tls-key-file, --tls-client-ca-file
+  tls:
+    cert_file: ""
+    key_file: ""
+    client_ca_file: ""
+    client_auth: "require"
+
+# Background certificate expiry scanner. It always runs in daemon mode;
+# set enabled to also run it alongside the HTTP server.
+scanner:
+  enabled: false
+  # Time between scans, as a Go duration (e.g. 30m, 1h, 24h)
+  interval: "1h"
+  # Namespaces to scan, or ["*"] for every namespace of the cluster.
+  # Defaults to kubernetes.default_namespace.
+  # namespaces:
+  #   - "default"
+  # Days before expiry at which a certificate is reported. Flag: --warning-days
+  warning_days: 30
+  # Namespaces analyzed at once by the scanner and by requests with
+  # ?namespace=all or a list of namespaces
+  concurrency: 4
+  # Keep the alerts delivered by 'daemon --once' runs in the
+  # <lease_name>-results ConfigMap of leader_election, so a CronJob notifies
+  # about each alert once. Needs get, create, and update on configmaps in
+  # that namespace, so it cannot be combined with read_only.
+  # Flag: --remember-alerts
+  remember_alerts: false
+  # Run several replicas with one scanner: the replica holding the Lease
+  # scans and notifies, the others serve the results it stores in the
+  # <lease_name>-results ConfigMap. Needs get, create, and update on leases
+  # and configmaps in the namespace. Read at startup only.
+  leader_election:
+    enabled: false
+    # Namespace of the Lease and ConfigMap. Defaults to kubernetes.default_namespace.
+    # namespace: "default"
+    lease_name: "k8s-web-service-scanner"
+    # How long replicas wait for a leader that stopped renewing
+    lease_duration: "15s"
+    # Split the namespaces between the live replicas by hash so scans scale
+    # out; the leader merges the shards and notifies. Also needs list and
+    # delete on leases and list on configmaps.
+    shard_namespaces: false
+
+# Destinations for scanner alerts. Alerts are always written to the log.
+notifiers:
+  # Receives a JSON POST with the list of alerts
+  webhook_url: ""
+  # Slack incoming webhook URL
+  slack_webhook_url: ""
+
+# Custom resource fields holding PEM certificates, analyzed with the pods of
+# each namespace. gvk is group/version/Kind; jsonpath uses kubectl syntax and
+# may select plain or base64-encoded PEM strings. Only namespaced kinds are
+# supported. For example, the listener certificates of Strimzi Kafka clusters:
+#   - gvk: "kafka.strimzi.io/v1beta2/Kafka"
+#     jsonpath: "{.status.listeners[*].certificates[*]}"
+custom_resources: []
+
+# Certificate rules per namespace, evaluated by /policy-violations. Each rule
+# that is set passes or fails on its own. namespaces takes names or shell
+# patterns and defaults to all namespaces. For example:
+#   - name: production-runway
+#     namespaces: ["prod-*"]
+#     min_days_remaining: 30
+#     allowed_issuers: ["CN=Example Internal CA"]
+#     max_validity_days: 398
+policies: []
+
+# Analysis of the CA bundles baked into workload images (/image-ca-bundles)
+images:
+  # Pull image layers from registries, authenticating with the pods'
+  # imagePullSecrets. Off by default: it downloads layers until the CA
+  # bundle is found.
+  ca_bundle_analysis: false
+  # Maximum number of distinct images pulled per request
+  max_images: 20
+
+# Certificate sources holding more than max_certificates certificates, such
+# as system trust bundles, list only their expired and expiring certificates
+# with aggregate statistics. Pass ?full_bundles=true to list every one.
+bundles:
+  max_certificates: 20
+
+# Node agents (k8s-web-service agent, run as a DaemonSet) report
+# certificate files on host paths that pods mount through hostPath volumes
+agent:
+  # Shared token authenticating agent reports; the service refuses reports
+  # while it is empty. Env: AGENT_TOKEN
+  token: ""
+  # Service URL agents report to, e.g. http://k8s-web-service.monitoring:8080
+  server_url: ""
+  # Where the node filesystem is mounted in the agent pod
+  host_root: "/host"
+  # Node directories scanned for certificate files
+  paths:
+    - "/etc/pki"
+    - "/etc/ssl/certs"
+    - "/etc/kubernetes/pki"
+    - "/var/lib/kubelet/pki"
+  interval: "1h"
+
+# Authentication of the admin endpoints (endpoints.admin). Requests present
+# the token as Authorization: Bearer <token>, or a client certificate signed
+# by server.tls.client_ca_file; without either, they are refused.
+admin:
+  # Env: ADMIN_TOKEN
+  token: ""
+
+# Admission webhook (k8s-web-service webhook), registered with a
+# ValidatingWebhookConfiguration for Pods and Ingresses
+admission:
+  port: "8443"
+  # TLS serving certificate; the API server only calls webhooks over HTTPS
+  cert_file: ""
+  key_file: ""
+  # deny rejects Pods and Ingresses referencing secrets with expired or
+  # expiring certificates; warn admits them with a warning for kubectl
+  mode: "deny"
+  # Also flag certificates expiring within this many days; 0 only flags
+  # expired certificates
+  window_days: 0
+
+# Logging
+logging:
+  # debug, info, warn, or error. Debug includes AWS SDK and Kubernetes API
+  # request logs. Flag: --log-level
+  level: "info"
+  # text or json. Flag: --log-format
+  format: "text"
+
+# Certificate material in responses, for deployments whose responses flow
+# into shared logging systems. redact_pem replaces PEM content with SHA-256
+# fingerprints; redact_subjects drops certificate subjects, issuers, and
+# serial numbers, keeping fingerprints. Metrics, exports, pushed results,
+# and alerts are redacted the same way.
+security:
+  redact_pem: false
+  redact_subjects: false
+  # Metadata-only handling of secrets: PEM blocks never leave the service,
+  # secret key names other than certificate and key file names are hidden,
+  # and certificate subjects are removed for sensitive_namespaces (all
+  # namespaces if empty).
+  privacy_mode: false
+  sensitive_namespaces: []
+
+# Endpoint groups. Disabled endpoints return 404 and are hidden from
+# /api-docs, for deployments that need a minimal read-only surface.
+endpoints:
+  # /cluster-ca, /cluster-ca-expiry
+  cluster_ca: true
+  # Endpoints that read secrets and configmaps: /pod-certificates, /certificate-expiry
+  secret_scanning: true
+  # Endpoints that exec into pods
+  exec_analysis: true
+  # Endpoints that connect to workloads over the network
+  probes: true
+  # /debug, /test-k8s-auth
+  debug: true
+  # /admin/*; off unless set to true, and then requires admin.token or a
+  # client certificate
+  admin: false
+  # Endpoints that call AWS APIs: /eks/*, /aws/*
+  aws: true
+  # /hostpath-certificates, /agent/report
+  node_agent: true
+
+# Request timeout and maximum number of items in list responses per
+# endpoint group. "default" applies to endpoints without a group and to
+# settings a group does not set. max_results: 0 means unlimited.
+limits:
+  default:
+    timeout: "60s"
+    max_results: 0
+  # probes:
+  #   timeout: "10s"
+  # secret_scanning:
+  #   timeout: "5m"
+  #   max_results: 500
+`
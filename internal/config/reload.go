@@ -0,0 +1,220 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// restartRequired lists settings that are only read at startup
+var restartRequired = map[string]bool{
+	"server.host": true,
+	"server.port": true,
+	// The timeouts of the listening http.Server cannot be changed
+	"server.read_timeout":  true,
+	"server.write_timeout": true,
+	// The listener and its TLS configuration are set up at startup; the
+	// certificate itself is reloaded when its file changes
+	"server.tls.cert_file":      true,
+	"server.tls.key_file":       true,
+	"server.tls.client_ca_file": true,
+}
+
+// Store holds the active configuration and allows it to be replaced while
+// the service is running
+type Store struct {
+	mu          sync.RWMutex
+	cfg         *Config
+	subscribers []func(prev, next *Config)
+}
+
+// NewStore creates a store holding cfg
+func NewStore(cfg *Config) *Store {
+	return &Store{cfg: cfg}
+}
+
+// Get returns the active configuration. The returned value must not be
+// modified; callers should call Get once per operation so they see a
+// consistent configuration.
+func (s *Store) Get() *Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// Set replaces the active configuration and notifies subscribers
+func (s *Store) Set(cfg *Config) {
+	s.mu.Lock()
+	old := s.cfg
+	s.cfg = cfg
+	subscribers := append([]func(prev, next *Config){}, s.subscribers...)
+	s.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(old, cfg)
+	}
+}
+
+// OnChange registers fn to be called after every configuration change
+func (s *Store) OnChange(fn func(prev, next *Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Reloader re-reads the configuration file into a Store, applying the same
+// command-line overrides as at startup
+type Reloader struct {
+	path      string
+	overrides Overrides
+	store     *Store
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// NewReloader creates a reloader for the configuration file at path
+func NewReloader(path string, overrides Overrides, store *Store) *Reloader {
+	r := &Reloader{path: path, overrides: overrides, store: store}
+	if info, err := os.Stat(path); err == nil {
+		r.modTime = info.ModTime()
+	}
+	return r
+}
+
+// Reload loads the configuration and makes it active if it has no
+// validation errors. It returns the changed settings.
+func (r *Reloader) Reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if info, err := os.Stat(r.path); err == nil {
+		r.modTime = info.ModTime()
+	}
+
+	cfg, err := Load(r.path)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ApplyOverrides(r.overrides)
+	cfg.SetDefaults()
+
+	issues := cfg.Validate()
+	if HasErrors(issues) {
+		return nil, fmt.Errorf("new configuration is invalid: %v", issues)
+	}
+
+	changes := Diff(r.store.Get(), cfg)
+	if len(changes) == 0 {
+		log.Printf("Configuration reloaded from %s: no changes", r.path)
+		return changes, nil
+	}
+
+	r.store.Set(cfg)
+	for _, change := range changes {
+		log.Printf("Configuration changed: %s", change)
+	}
+	return changes, nil
+}
+
+// Watch polls the configuration file every interval and reloads it when its
+// modification time changes, until ctx is cancelled
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(r.path)
+		if err != nil {
+			continue
+		}
+
+		r.mu.Lock()
+		changed := !info.ModTime().Equal(r.modTime)
+		r.mu.Unlock()
+
+		if changed {
+			log.Printf("Configuration file %s changed, reloading", r.path)
+			if _, err := r.Reload(); err != nil {
+				log.Printf("Warning: configuration reload failed, keeping previous configuration: %v", err)
+			}
+		}
+	}
+}
+
+// Diff describes the settings that differ between two configurations as
+// "field: old -> new". Credentials are masked, and settings that are only
+// read at startup are flagged.
+func Diff(prev, next *Config) []string {
+	oldValues, newValues := flatten(prev), flatten(next)
+	oldRedacted, newRedacted := prev.Redacted(), next.Redacted()
+	oldMasked, newMasked := flatten(&oldRedacted), flatten(&newRedacted)
+
+	keys := make(map[string]bool)
+	for k := range oldValues {
+		keys[k] = true
+	}
+	for k := range newValues {
+		keys[k] = true
+	}
+
+	var changes []string
+	for k := range keys {
+		if oldValues[k] == newValues[k] {
+			continue
+		}
+
+		change := fmt.Sprintf("%s: %s -> %s", k, oldMasked[k], newMasked[k])
+		if oldMasked[k] == newMasked[k] {
+			change = fmt.Sprintf("%s: changed", k)
+		}
+		if restartRequired[k] {
+			change += " (requires restart)"
+		}
+		changes = append(changes, change)
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// flatten maps the dotted JSON path of every setting to its JSON-encoded value
+func flatten(cfg *Config) map[string]string {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil
+	}
+
+	var generic map[string]interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil
+	}
+
+	values := make(map[string]string)
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, child := range m {
+				if prefix != "" {
+					k = prefix + "." + k
+				}
+				walk(k, child)
+			}
+			return
+		}
+		encoded, _ := json.Marshal(v)
+		values[prefix] = string(encoded)
+	}
+	walk("", generic)
+	return values
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadKeepsOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("admission:\n  port: \"8443\"\n  mode: deny\n  window_days: 30\n")
+
+	windowDays := 0
+	overrides := Overrides{
+		Port:                "9090",
+		AdmissionPort:       "9443",
+		AdmissionCertFile:   "/tls/tls.crt",
+		AdmissionKeyFile:    "/tls/tls.key",
+		AdmissionMode:       AdmissionWarn,
+		AdmissionWindowDays: &windowDays,
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ApplyOverrides(overrides)
+	cfg.SetDefaults()
+	store := NewStore(cfg)
+
+	write("admission:\n  port: \"8443\"\n  mode: deny\n  window_days: 14\nscanner:\n  warning_days: 10\n")
+	if _, err := NewReloader(path, overrides, store).Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	got := store.Get()
+	if got.Scanner.WarningDays != 10 {
+		t.Errorf("scanner.warning_days = %d, want the reloaded 10", got.Scanner.WarningDays)
+	}
+	if got.Server.Port != "9090" {
+		t.Errorf("server.port = %q, want the override 9090", got.Server.Port)
+	}
+	admission := got.Admission
+	if admission.Port != "9443" || admission.CertFile != "/tls/tls.crt" || admission.KeyFile != "/tls/tls.key" {
+		t.Errorf("admission port and TLS files = %q, %q, %q, want the overrides", admission.Port, admission.CertFile, admission.KeyFile)
+	}
+	if admission.Mode != AdmissionWarn || admission.WindowDays != 0 {
+		t.Errorf("admission mode and window = %q, %d, want the overrides warn and 0", admission.Mode, admission.WindowDays)
+	}
+}
@@ -0,0 +1,545 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue describes a problem found while validating the configuration
+type Issue struct {
+	Severity string `json:"severity"`
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+}
+
+// String formats the issue for logs and CLI output
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Field, i.Message)
+}
+
+var (
+	dns1123LabelPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	awsRegionPattern    = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-[0-9]+$`)
+	cloudFrontIDPattern = regexp.MustCompile(`^[A-Z0-9]{13,14}$`)
+	stsNamePattern      = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+	roleARNPattern      = regexp.MustCompile(`^arn:(aws[a-z-]*):iam::[0-9]{12}:role/.+$`)
+)
+
+// maxSessionTags is the number of session tags STS accepts on AssumeRole
+const maxSessionTags = 50
+
+// IsAWSRegion reports whether s looks like an AWS region name
+func IsAWSRegion(s string) bool {
+	return awsRegionPattern.MatchString(s)
+}
+
+// IsDNS1123Label reports whether s is a valid Kubernetes namespace name
+func IsDNS1123Label(s string) bool {
+	return len(s) <= 63 && dns1123LabelPattern.MatchString(s)
+}
+
+// CheckFile reports keys in the config file that do not correspond to any
+// setting. Such keys are otherwise silently ignored, so a typo results in
+// the default value being used.
+func CheckFile(path string) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []Issue{{
+			Severity: SeverityWarning,
+			Field:    path,
+			Message:  "config file not found; using environment variables and defaults",
+		}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	var issues []Issue
+	for _, key := range unknownKeys("", raw, reflect.TypeOf(Config{})) {
+		issues = append(issues, Issue{
+			Severity: SeverityWarning,
+			Field:    key,
+			Message:  "unknown configuration key (ignored)",
+		})
+	}
+	return issues, nil
+}
+
+// unknownKeys walks a decoded YAML document alongside the struct type it is
+// decoded into and returns the dotted paths of keys without a matching field
+func unknownKeys(prefix string, node interface{}, t reflect.Type) []string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return unknownKeys(prefix, node, t.Elem())
+	case reflect.Slice:
+		items, ok := node.([]interface{})
+		if !ok {
+			return nil
+		}
+		var keys []string
+		for i, item := range items {
+			keys = append(keys, unknownKeys(fmt.Sprintf("%s[%d]", prefix, i), item, t.Elem())...)
+		}
+		return keys
+	case reflect.Struct:
+		// handled below
+	default:
+		return nil
+	}
+
+	m, ok := node.(map[interface{}]interface{})
+	if !ok {
+		return nil
+	}
+
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+
+	var keys []string
+	for k, v := range m {
+		key := fmt.Sprint(k)
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		fieldType, known := fields[key]
+		if !known {
+			keys = append(keys, path)
+			continue
+		}
+		keys = append(keys, unknownKeys(path, v, fieldType)...)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate checks the values of a loaded configuration for invalid settings
+// and missing required combinations
+func (c *Config) Validate() []Issue {
+	var issues []Issue
+	add := func(severity, field, format string, args ...interface{}) {
+		issues = append(issues, Issue{Severity: severity, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// AWS
+	if (c.AWS.AccessKeyID == "") != (c.AWS.SecretAccessKey == "") {
+		add(SeverityError, "aws", "access_key_id and secret_access_key must be set together")
+	}
+	if c.AWS.Region != "" && !awsRegionPattern.MatchString(c.AWS.Region) {
+		add(SeverityWarning, "aws.region", "%q does not look like an AWS region (e.g. us-gov-west-1)", c.AWS.Region)
+	}
+	assumeRole := c.AWS.AssumeRole
+	if assumeRole.SessionName != "" && !stsNamePattern.MatchString(assumeRole.SessionName) {
+		add(SeverityError, "aws.assume_role.session_name", "%q must be 2-64 characters of letters, digits, and +=,.@_-", assumeRole.SessionName)
+	}
+	if assumeRole.SourceIdentity != "" {
+		if !stsNamePattern.MatchString(assumeRole.SourceIdentity) {
+			add(SeverityError, "aws.assume_role.source_identity", "%q must be 2-64 characters of letters, digits, and +=,.@_-", assumeRole.SourceIdentity)
+		} else if strings.HasPrefix(strings.ToLower(assumeRole.SourceIdentity), "aws:") {
+			add(SeverityError, "aws.assume_role.source_identity", "must not start with the reserved prefix aws:")
+		}
+	}
+	if len(assumeRole.SessionTags) > maxSessionTags {
+		add(SeverityError, "aws.assume_role.session_tags", "%d tags exceed the STS limit of %d", len(assumeRole.SessionTags), maxSessionTags)
+	}
+	tagKeys := make([]string, 0, len(assumeRole.SessionTags))
+	for key := range assumeRole.SessionTags {
+		tagKeys = append(tagKeys, key)
+	}
+	sort.Strings(tagKeys)
+	for _, key := range tagKeys {
+		value := assumeRole.SessionTags[key]
+		field := fmt.Sprintf("aws.assume_role.session_tags.%s", key)
+		if key == "" || len(key) > 128 {
+			add(SeverityError, field, "tag keys must be 1-128 characters")
+		}
+		if len(value) > 256 {
+			add(SeverityError, field, "tag values must be at most 256 characters")
+		}
+	}
+	for i, key := range assumeRole.TransitiveTagKeys {
+		if _, ok := assumeRole.SessionTags[key]; !ok {
+			add(SeverityError, fmt.Sprintf("aws.assume_role.transitive_tag_keys[%d]", i), "%q is not a session tag", key)
+		}
+	}
+	for i, prefix := range c.AWS.SecretsManager.Prefixes {
+		if strings.TrimSpace(prefix) == "" {
+			add(SeverityError, fmt.Sprintf("aws.secrets_manager.prefixes[%d]", i), "prefix must not be empty; it would match every secret")
+		}
+	}
+	for i, id := range c.AWS.CloudFront.DistributionIDs {
+		if !cloudFrontIDPattern.MatchString(id) {
+			add(SeverityWarning, fmt.Sprintf("aws.cloudfront.distribution_ids[%d]", i), "%q does not look like a CloudFront distribution ID (e.g. E2QWRUHAPOMQZL)", id)
+		}
+	}
+	clusterNames := make([]string, 0, len(c.Clusters))
+	for name := range c.Clusters {
+		clusterNames = append(clusterNames, name)
+	}
+	sort.Strings(clusterNames)
+	for _, name := range clusterNames {
+		override := c.Clusters[name]
+		field := "clusters." + name
+		if (override.AccessKeyID == "") != (override.SecretAccessKey == "") {
+			add(SeverityError, field, "access_key_id and secret_access_key must be set together")
+		}
+		if override.KubeconfigPath != "" {
+			if _, err := os.Stat(override.KubeconfigPath); err != nil {
+				add(SeverityError, field+".kubeconfig_path", "%v", err)
+			}
+		}
+		if override.Region != "" && !awsRegionPattern.MatchString(override.Region) {
+			add(SeverityWarning, field+".region", "%q does not look like an AWS region (e.g. us-gov-west-1)", override.Region)
+		}
+		partition := override.Partition
+		if partition != "" {
+			if PartitionRegion(partition) == "" {
+				add(SeverityError, field+".partition", "%q is not an AWS partition (aws, aws-cn, aws-us-gov, aws-iso, aws-iso-b)", partition)
+			} else if regionPartition := PartitionForRegion(override.Region); regionPartition != "" && regionPartition != partition {
+				add(SeverityError, field+".partition", "region %s is in partition %s, not %s", override.Region, regionPartition, partition)
+			}
+		} else {
+			partition = PartitionForRegion(override.Region)
+		}
+		if override.RoleARN != "" {
+			match := roleARNPattern.FindStringSubmatch(override.RoleARN)
+			if match == nil {
+				add(SeverityError, field+".role_arn", "%q is not an IAM role ARN (arn:aws:iam::123456789012:role/name)", override.RoleARN)
+			} else if partition != "" && match[1] != partition {
+				add(SeverityError, field+".role_arn", "role is in partition %s, but the cluster is in %s", match[1], partition)
+			}
+		}
+	}
+
+	// Kubernetes
+	if !IsDNS1123Label(c.Kubernetes.DefaultNamespace) {
+		add(SeverityError, "kubernetes.default_namespace", "%q is not a valid namespace name", c.Kubernetes.DefaultNamespace)
+	}
+	if c.Kubernetes.ClusterEndpoint != "" {
+		if u, err := url.Parse(c.Kubernetes.ClusterEndpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+			add(SeverityWarning, "kubernetes.cluster_endpoint", "%q is not an https URL", c.Kubernetes.ClusterEndpoint)
+		}
+	}
+	if c.Kubernetes.KubeconfigPath != "" {
+		if _, err := os.Stat(c.Kubernetes.KubeconfigPath); err != nil {
+			add(SeverityError, "kubernetes.kubeconfig_path", "%v", err)
+		}
+	}
+	switch c.Kubernetes.Auth {
+	case KubernetesAuthAuto, KubernetesAuthInCluster, KubernetesAuthKubeconfig:
+	default:
+		add(SeverityError, "kubernetes.auth", "%q is not one of auto, in-cluster, kubeconfig", c.Kubernetes.Auth)
+	}
+	if c.Kubernetes.FixturesDir != "" {
+		if info, err := os.Stat(c.Kubernetes.FixturesDir); err != nil {
+			add(SeverityError, "kubernetes.fixtures_dir", "%v", err)
+		} else if !info.IsDir() {
+			add(SeverityError, "kubernetes.fixtures_dir", "%s is not a directory", c.Kubernetes.FixturesDir)
+		}
+	}
+
+	// Server
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		add(SeverityError, "server.port", "%q is not a valid port number", c.Server.Port)
+	}
+	if c.Server.Timezone != "" {
+		if _, err := time.LoadLocation(c.Server.Timezone); err != nil {
+			add(SeverityError, "server.timezone", "%q is not an IANA time zone (e.g. UTC, Europe/Berlin)", c.Server.Timezone)
+		}
+	}
+	for _, unit := range c.Server.Durations.Units {
+		if DurationUnitIndex(unit) < 0 {
+			add(SeverityError, "server.durations.units", "%q is not a unit; use %s", unit, strings.Join(DurationUnits, ", "))
+		}
+	}
+	if c.Server.Durations.Precision < 1 {
+		add(SeverityError, "server.durations.precision", "must be positive, got %d", c.Server.Durations.Precision)
+	}
+	for _, timeout := range []struct{ field, value string }{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	} {
+		if d, err := time.ParseDuration(timeout.value); timeout.value != "" && (err != nil || d < 0) {
+			add(SeverityError, timeout.field, "%q is not a valid duration (e.g. 30s, 5m)", timeout.value)
+		}
+	}
+	serverTLS := c.Server.TLS
+	if (serverTLS.CertFile == "") != (serverTLS.KeyFile == "") {
+		add(SeverityError, "server.tls", "cert_file and key_file must be set together")
+	}
+	for _, file := range []struct{ field, path string }{
+		{"server.tls.cert_file", serverTLS.CertFile},
+		{"server.tls.key_file", serverTLS.KeyFile},
+		{"server.tls.client_ca_file", serverTLS.ClientCAFile},
+	} {
+		if file.path != "" {
+			if _, err := os.Stat(file.path); err != nil {
+				add(SeverityError, file.field, "%v", err)
+			}
+		}
+	}
+	if serverTLS.CertFile != "" && serverTLS.KeyFile != "" {
+		if pair, err := tls.LoadX509KeyPair(serverTLS.CertFile, serverTLS.KeyFile); err != nil {
+			add(SeverityError, "server.tls.cert_file", "failed to load the key pair: %v", err)
+		} else if leaf, err := x509.ParseCertificate(pair.Certificate[0]); err == nil {
+			if remaining := time.Until(leaf.NotAfter); remaining <= 0 {
+				add(SeverityError, "server.tls.cert_file", "the certificate expired on %s", leaf.NotAfter.Format(time.RFC3339))
+			} else if days := int(remaining.Hours() / 24); days <= c.Scanner.WarningDays {
+				add(SeverityWarning, "server.tls.cert_file", "the certificate expires in %d days", days)
+			}
+		}
+	}
+	if serverTLS.ClientCAFile != "" && serverTLS.CertFile == "" {
+		add(SeverityError, "server.tls.client_ca_file", "requires cert_file and key_file; client certificates are only verified over HTTPS")
+	}
+	switch serverTLS.ClientAuth {
+	case "", ClientAuthRequire, ClientAuthOptional:
+		if serverTLS.ClientAuth != "" && serverTLS.ClientCAFile == "" {
+			add(SeverityWarning, "server.tls.client_auth", "has no effect without client_ca_file")
+		}
+	default:
+		add(SeverityError, "server.tls.client_auth", "%q is not one of require, optional", serverTLS.ClientAuth)
+	}
+	if _, write, _ := c.ServerTimeouts(); write > 0 {
+		for _, group := range append([]string{""}, EndpointGroups...) {
+			if timeout, _ := c.EndpointLimits(group); timeout > write {
+				add(SeverityWarning, "server.write_timeout", "%s is shorter than the %s timeout of some endpoints, whose responses are then cut off", write, timeout)
+				break
+			}
+		}
+	}
+
+	// Scanner
+	if interval, err := time.ParseDuration(c.Scanner.Interval); err != nil {
+		add(SeverityError, "scanner.interval", "%q is not a valid duration (e.g. 30m, 1h)", c.Scanner.Interval)
+	} else if interval < time.Minute {
+		add(SeverityWarning, "scanner.interval", "%s is very short and may overload the API server", interval)
+	}
+	if c.Scanner.WarningDays <= 0 {
+		add(SeverityError, "scanner.warning_days", "must be positive, got %d", c.Scanner.WarningDays)
+	}
+	if c.Scanner.Concurrency <= 0 {
+		add(SeverityError, "scanner.concurrency", "must be positive, got %d", c.Scanner.Concurrency)
+	}
+	for i, namespace := range c.Scanner.Namespaces {
+		if namespace == AllNamespaces {
+			if len(c.Scanner.Namespaces) > 1 {
+				add(SeverityError, fmt.Sprintf("scanner.namespaces[%d]", i), "%q scans every namespace and cannot be combined with names", namespace)
+			}
+			continue
+		}
+		if !IsDNS1123Label(namespace) {
+			add(SeverityError, fmt.Sprintf("scanner.namespaces[%d]", i), "%q is not a valid namespace name", namespace)
+		}
+	}
+	if c.Scanner.RememberAlerts && c.ReadOnly {
+		add(SeverityError, "scanner.remember_alerts", "needs to write the <lease_name>-results ConfigMap, which read_only forbids")
+	}
+	if election := c.Scanner.LeaderElection; election.ShardNamespaces && !election.Enabled {
+		add(SeverityError, "scanner.leader_election.shard_namespaces", "requires scanner.leader_election.enabled")
+	}
+	if election := c.Scanner.LeaderElection; election.Enabled {
+		if !IsDNS1123Label(election.Namespace) {
+			add(SeverityError, "scanner.leader_election.namespace", "%q is not a valid namespace name", election.Namespace)
+		}
+		if !IsDNS1123Label(election.LeaseName) {
+			add(SeverityError, "scanner.leader_election.lease_name", "%q is not a valid name", election.LeaseName)
+		}
+		if d, err := time.ParseDuration(election.LeaseDuration); err != nil {
+			add(SeverityError, "scanner.leader_election.lease_duration", "%q is not a valid duration (e.g. 15s)", election.LeaseDuration)
+		} else if d < 5*time.Second {
+			add(SeverityError, "scanner.leader_election.lease_duration", "must be at least 5s, got %s", d)
+		}
+		if c.ReadOnly {
+			add(SeverityError, "scanner.leader_election", "needs to write a Lease and a ConfigMap, which read_only forbids")
+		}
+	}
+
+	// Notifiers
+	for field, value := range map[string]string{
+		"notifiers.webhook_url":       c.Notifiers.WebhookURL,
+		"notifiers.slack_webhook_url": c.Notifiers.SlackWebhookURL,
+	} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(SeverityError, field, "not a valid http(s) URL")
+		}
+	}
+
+	// Custom resources
+	for i, resource := range c.CustomResources {
+		field := fmt.Sprintf("custom_resources[%d]", i)
+		if _, _, _, err := resource.ParseGVK(); err != nil {
+			add(SeverityError, field+".gvk", "%v", err)
+		}
+		if resource.JSONPath == "" {
+			add(SeverityError, field+".jsonpath", "must be set")
+		} else if err := jsonpath.New(field).Parse(resource.JSONPath); err != nil {
+			add(SeverityError, field+".jsonpath", "invalid JSONPath: %v", err)
+		}
+	}
+
+	policyNames := make(map[string]bool)
+	for i, policy := range c.Policies {
+		field := fmt.Sprintf("policies[%d]", i)
+		switch {
+		case policy.Name == "":
+			add(SeverityError, field+".name", "must be set")
+		case policyNames[policy.Name]:
+			add(SeverityError, field+".name", "duplicate policy name %q", policy.Name)
+		}
+		policyNames[policy.Name] = true
+		for j, pattern := range policy.Namespaces {
+			if _, err := path.Match(pattern, ""); err != nil {
+				add(SeverityError, fmt.Sprintf("%s.namespaces[%d]", field, j), "invalid pattern %q", pattern)
+			}
+		}
+		if policy.MinDaysRemaining < 0 {
+			add(SeverityError, field+".min_days_remaining", "must not be negative")
+		}
+		if policy.MaxValidityDays < 0 {
+			add(SeverityError, field+".max_validity_days", "must not be negative")
+		}
+		if policy.MinDaysRemaining == 0 && len(policy.AllowedIssuers) == 0 && policy.MaxValidityDays == 0 {
+			add(SeverityWarning, field, "sets no rules (min_days_remaining, allowed_issuers, max_validity_days)")
+		}
+	}
+
+	// Images
+	if c.Images.MaxImages < 0 {
+		add(SeverityError, "images.max_images", "must be positive, got %d", c.Images.MaxImages)
+	}
+
+	// Bundles
+	if c.Bundles.MaxCertificates < 0 {
+		add(SeverityError, "bundles.max_certificates", "must be positive, got %d", c.Bundles.MaxCertificates)
+	}
+
+	// Agent
+	if c.Agent.ServerURL != "" {
+		if u, err := url.Parse(c.Agent.ServerURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(SeverityError, "agent.server_url", "not a valid http(s) URL")
+		}
+	}
+	if _, err := time.ParseDuration(c.Agent.Interval); err != nil {
+		add(SeverityError, "agent.interval", "%q is not a valid duration (e.g. 30m, 1h)", c.Agent.Interval)
+	}
+	for i, path := range c.Agent.Paths {
+		if !strings.HasPrefix(path, "/") {
+			add(SeverityError, fmt.Sprintf("agent.paths[%d]", i), "%q must be an absolute node path", path)
+		}
+	}
+
+	// Admission
+	if c.Admission.Mode != AdmissionDeny && c.Admission.Mode != AdmissionWarn {
+		add(SeverityError, "admission.mode", "%q is not one of deny, warn", c.Admission.Mode)
+	}
+	if c.Admission.WindowDays < 0 {
+		add(SeverityError, "admission.window_days", "must not be negative")
+	}
+	if (c.Admission.CertFile == "") != (c.Admission.KeyFile == "") {
+		add(SeverityError, "admission", "cert_file and key_file must be set together")
+	}
+
+	// Logging
+	switch strings.ToLower(c.Logging.Level) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		add(SeverityError, "logging.level", "%q is not one of debug, info, warn, error", c.Logging.Level)
+	}
+	if c.Logging.Format != "text" && c.Logging.Format != "json" {
+		add(SeverityError, "logging.format", "%q is not one of text, json", c.Logging.Format)
+	}
+
+	// Security
+	for i, namespace := range c.Security.SensitiveNamespaces {
+		if !IsDNS1123Label(namespace) {
+			add(SeverityError, fmt.Sprintf("security.sensitive_namespaces[%d]", i), "%q is not a valid namespace name", namespace)
+		}
+	}
+	if len(c.Security.SensitiveNamespaces) > 0 && !c.Security.PrivacyMode {
+		add(SeverityWarning, "security.sensitive_namespaces", "has no effect unless security.privacy_mode is set")
+	}
+
+	// Endpoints
+	for group := range c.Endpoints {
+		if !knownEndpointGroup(group) {
+			add(SeverityWarning, "endpoints."+group, "unknown endpoint group (known: %s)", strings.Join(EndpointGroups, ", "))
+		}
+	}
+
+	if c.EndpointGroupEnabled(EndpointGroupAdmin) && c.Admin.Token == "" && c.Server.TLS.ClientCAFile == "" {
+		add(SeverityWarning, "endpoints.admin", "admin endpoints refuse every request without admin.token or server.tls.client_ca_file")
+	}
+
+	// Limits
+	for group, limit := range c.Limits {
+		if group != DefaultLimitsKey && !knownEndpointGroup(group) {
+			add(SeverityWarning, "limits."+group, "unknown endpoint group (known: %s, %s)", DefaultLimitsKey, strings.Join(EndpointGroups, ", "))
+		}
+		if limit.Timeout != "" {
+			if d, err := time.ParseDuration(limit.Timeout); err != nil || d <= 0 {
+				add(SeverityError, "limits."+group+".timeout", "%q is not a valid positive duration (e.g. 10s, 5m)", limit.Timeout)
+			}
+		}
+		if limit.MaxResults < 0 {
+			add(SeverityError, "limits."+group+".max_results", "must not be negative, got %d", limit.MaxResults)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Field < issues[j].Field })
+	return issues
+}
+
+// knownEndpointGroup reports whether group is one of EndpointGroups
+func knownEndpointGroup(group string) bool {
+	for _, known := range EndpointGroups {
+		if group == known {
+			return true
+		}
+	}
+	return false
+}
+
+// HasErrors reports whether any issue has error severity
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
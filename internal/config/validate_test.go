@@ -0,0 +1,16 @@
+package config
+
+import "testing"
+
+func TestValidateRememberAlertsReadOnly(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyOverrides(Overrides{RememberAlerts: true, ReadOnly: true})
+	cfg.SetDefaults()
+
+	for _, issue := range cfg.Validate() {
+		if issue.Field == "scanner.remember_alerts" && issue.Severity == SeverityError {
+			return
+		}
+	}
+	t.Errorf("Validate accepted scanner.remember_alerts with read_only")
+}
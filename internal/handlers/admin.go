@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-web-service/internal/cloud"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/notify"
+	"k8s-web-service/internal/scanner"
+	"k8s-web-service/pkg/api"
+)
+
+// ReloadHandler handles the POST /admin/reload endpoint, re-reading the
+// configuration file and applying it without a restart
+func (h *Handler) ReloadHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeProblem(w, r, http.StatusMethodNotAllowed, ProblemMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+
+	if h.reloader == nil {
+		writeProblem(w, r, http.StatusServiceUnavailable, ProblemNotConfigured, "Configuration reload is not available")
+		return
+	}
+
+	changes, err := h.reloader.Reload()
+	if err != nil {
+		writeProblem(w, r, http.StatusUnprocessableEntity, ProblemParseFailure, "Configuration reload failed, keeping previous configuration: %v", err)
+		return
+	}
+
+	if changes == nil {
+		changes = []string{}
+	}
+	json.NewEncoder(w).Encode(api.ReloadResponse{
+		Status:  api.StatusSuccess,
+		Message: fmt.Sprintf("Configuration reloaded with %d change(s)", len(changes)),
+		Changes: changes,
+	})
+}
+
+// DrainHandler handles the /admin/drain endpoint. POST starts draining: new
+// scans are refused, running scans finish, and /readyz reports not ready.
+// GET reports drain progress and DELETE resumes normal operation.
+func (h *Handler) DrainHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	message := "Drain status"
+	switch r.Method {
+	case http.MethodPost:
+		h.jobs.Drain()
+		message = "Draining: new scans are refused while running scans finish"
+	case http.MethodDelete:
+		h.jobs.Resume()
+		message = "Drain cancelled: accepting new scans"
+	case http.MethodGet:
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeProblem(w, r, http.StatusMethodNotAllowed, ProblemMethodNotAllowed, "Method not allowed, use GET, POST, or DELETE")
+		return
+	}
+
+	status := h.jobs.Status()
+	if r.Method == http.MethodPost && !status.Drained {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(api.DrainResponse{
+		Status:  api.StatusSuccess,
+		Message: message,
+		Drain:   api.DrainStatus(status),
+	})
+}
+
+// SimulateHandler handles the POST /admin/simulate endpoint. It scans a
+// namespace, pretends the selected certificates expire after expiry_in, and
+// sends the resulting alerts through the configured notifiers, so alert
+// delivery can be verified end-to-end. Alerts are marked as simulated.
+func (h *Handler) SimulateHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeProblem(w, r, http.StatusMethodNotAllowed, ProblemMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+
+	cfg := h.cfg()
+	query := r.URL.Query()
+
+	expiryIn, err := parseExpiryIn(query.Get("expiry_in"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Invalid expiry_in: %v", err)
+		return
+	}
+
+	namespace := query.Get("namespace")
+	if namespace == "" {
+		namespace = cfg.Kubernetes.DefaultNamespace
+	}
+	match := query.Get("match")
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	ctx := r.Context()
+	report, err := k8s.AnalyzeNamespaceExpiry(ctx, client, namespace, cfg.Scanner.WarningDays)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to analyze certificates: %v", err)
+		return
+	}
+
+	if k8s.SimulateExpiry(report, time.Now().Add(expiryIn), match) == 0 {
+		writeProblem(w, r, http.StatusUnprocessableEntity, ProblemNotFound, "No certificates in namespace %s match %q", namespace, match)
+		return
+	}
+
+	var alerts []notify.Alert
+	for _, alert := range scanner.AlertsFromReport(report) {
+		alert.Simulated = true
+		alerts = append(alerts, alert)
+	}
+
+	notifier := notify.FromConfig(cfg)
+	response := SimulateResponse{
+		Status:    api.StatusSuccess,
+		Message:   fmt.Sprintf("Sent %d simulated alerts to %s", len(alerts), notifier.Name()),
+		Namespace: namespace,
+		ExpiryIn:  expiryIn.String(),
+		Notifiers: notifier.Name(),
+		Alerts:    alerts,
+	}
+	if len(alerts) == 0 {
+		response.Message = fmt.Sprintf("No alerts: expiry_in is beyond the %d day warning threshold", cfg.Scanner.WarningDays)
+	} else if err := notifier.Notify(ctx, alerts); err != nil {
+		problem := newProblem(r, http.StatusBadGateway, ProblemNotificationFailed, err.Error())
+		problem.Extensions = map[string]interface{}{
+			"namespace": response.Namespace,
+			"expiry_in": response.ExpiryIn,
+			"notifiers": response.Notifiers,
+			"alerts":    response.Alerts,
+		}
+		encodeProblem(w, problem)
+		return
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseExpiryIn parses a Go duration or a number of days such as "3d".
+// A zero or negative value simulates an expired certificate.
+func parseExpiryIn(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, fmt.Errorf("required, e.g. 3d or 12h")
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number of days", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// ReissueCertificateHandler handles the POST /admin/reissue-certificate
+// endpoint, asking ACM to re-issue a certificate from its private CA
+func (h *Handler) ReissueCertificateHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeProblem(w, r, http.StatusMethodNotAllowed, ProblemMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+
+	if h.cfg().ReadOnly {
+		writeProblem(w, r, http.StatusForbidden, ProblemReadOnly, "Re-issuing certificates is disabled in read-only mode")
+		return
+	}
+
+	arn := r.URL.Query().Get("certificate_arn")
+	if arn == "" {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "certificate_arn is required")
+		return
+	}
+
+	ctx := r.Context()
+	awsCfg, err := h.awsConfig(ctx, r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	cert, err := cloud.RenewPrivateCertificate(ctx, awsCfg, arn)
+	switch {
+	case errors.Is(err, cloud.ErrNotPrivateCertificate):
+		problem := newProblem(r, http.StatusUnprocessableEntity, ProblemNotPrivateCertificate, err.Error())
+		problem.Extensions = map[string]interface{}{"certificate": cert}
+		encodeProblem(w, problem)
+		return
+	case err != nil:
+		writeErrorProblem(w, r, http.StatusBadGateway, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	log.Printf("Requested re-issue of private certificate %s (%s)", arn, cert.DomainName)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(ReissueResponse{
+		Status:      api.StatusSuccess,
+		Message:     fmt.Sprintf("Re-issue of %s requested; ACM replaces the certificate under the same ARN", cert.DomainName),
+		Certificate: cert,
+	})
+}
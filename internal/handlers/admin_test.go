@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExpiryIn(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "3d", want: 72 * time.Hour},
+		{value: "0d", want: 0},
+		{value: "12h", want: 12 * time.Hour},
+		{value: "90m", want: 90 * time.Minute},
+		{value: "", wantErr: true},
+		{value: "xd", wantErr: true},
+		{value: "3 days", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseExpiryIn(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseExpiryIn(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseExpiryIn(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
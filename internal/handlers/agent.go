@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s-web-service/internal/agent"
+	"k8s-web-service/pkg/api"
+	"k8s-web-service/pkg/utils"
+)
+
+// maxAgentReportSize bounds the body of an agent report
+const maxAgentReportSize = 10 << 20
+
+// AgentReportHandler handles POST /agent/report, storing the certificate
+// files a node agent found on its node
+func (h *Handler) AgentReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeProblem(w, r, http.StatusMethodNotAllowed, ProblemMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+
+	token := h.cfg().Agent.Token
+	if token == "" {
+		writeProblem(w, r, http.StatusServiceUnavailable, ProblemNotConfigured, "Node agent reports are disabled; set agent.token")
+		return
+	}
+	presented := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+		writeProblem(w, r, http.StatusUnauthorized, ProblemUnauthenticated, "Invalid agent token")
+		return
+	}
+
+	var report agent.Report
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAgentReportSize)).Decode(&report); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Invalid report: %v", err)
+		return
+	}
+	if report.Node == "" {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Report has no node name")
+		return
+	}
+
+	h.agents.Put(report)
+	json.NewEncoder(w).Encode(api.AgentReportResponse{
+		Status: api.StatusSuccess,
+		Node:   report.Node,
+		Files:  len(report.Files),
+	})
+}
+
+// HostPathCertificatesHandler handles the /hostpath-certificates endpoint,
+// matching the hostPath volumes of pods with the certificate files node
+// agents reported under those paths
+func (h *Handler) HostPathCertificatesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+	cfg := h.cfg()
+
+	namespace := r.URL.Query().Get("namespace") // empty covers all namespaces
+	warningDays := cfg.Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+	pods, err := client.GetClientset().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list pods: %v", err)
+		return
+	}
+
+	bundles := h.bundleCap(r)
+	entries := []HostPathPodEntry{}
+	missing := make(map[string]bool) // nodes with hostPath pods but no agent report
+	totalWarnings := 0
+	for _, pod := range pods.Items {
+		entry := HostPathPodEntry{Namespace: pod.Namespace, Pod: pod.Name, Node: pod.Spec.NodeName}
+		report, reported := h.agents.Get(pod.Spec.NodeName)
+		for _, volume := range pod.Spec.Volumes {
+			if volume.HostPath == nil {
+				continue
+			}
+			hostVolume := HostPathVolume{Volume: volume.Name, HostPath: volume.HostPath.Path, MountPaths: hostPathMounts(&pod, volume.Name)}
+			for _, file := range report.Files {
+				if agent.Covers(volume.HostPath.Path, file.Path) {
+					for _, warning := range utils.ValidateCertificateExpiry(file.Certificates, warningDays) {
+						hostVolume.Warnings = append(hostVolume.Warnings, fmt.Sprintf("%s: %s", file.Path, warning))
+					}
+					// file is a copy, so the stored report keeps every certificate
+					file.Certificates, file.BundleSummary = utils.SummarizeCertificates(file.Certificates, warningDays, bundles)
+					hostVolume.Files = append(hostVolume.Files, file)
+				}
+			}
+			totalWarnings += len(hostVolume.Warnings)
+			entry.Volumes = append(entry.Volumes, hostVolume)
+		}
+		if len(entry.Volumes) == 0 {
+			continue
+		}
+		entry.AgentReported = reported
+		if !reported && pod.Spec.NodeName != "" {
+			missing[pod.Spec.NodeName] = true
+		}
+		entries = append(entries, entry)
+	}
+
+	// Agents report every agent.interval; a report older than two intervals
+	// means the agent on that node stopped
+	interval, err := time.ParseDuration(cfg.Agent.Interval)
+	if err != nil {
+		interval = time.Hour
+	}
+	nodes := []AgentStatus{}
+	for _, report := range h.agents.List() {
+		nodes = append(nodes, AgentStatus{
+			Node:       report.Node,
+			ReceivedAt: report.ReceivedAt,
+			Files:      len(report.Files),
+			Errors:     report.Errors,
+			Stale:      time.Since(report.ReceivedAt) > 2*interval,
+		})
+	}
+
+	total := len(entries)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(entries) > limit
+	if truncated {
+		entries = entries[:limit]
+	}
+
+	response := HostPathCertificatesResponse{
+		Status:      api.StatusSuccess,
+		WarningDays: warningDays,
+		Summary: HostPathCertificatesSummary{
+			PodsWithHostPaths: total,
+			ReportingNodes:    len(nodes),
+			NodesWithoutAgent: len(missing),
+			TotalWarnings:     totalWarnings,
+		},
+		Pods:              entries,
+		Agents:            nodes,
+		NodesWithoutAgent: sortedKeys(missing),
+		Namespace:         namespace,
+	}
+	if cfg.Agent.Token == "" {
+		response.Notes = []string{"Node agent reports are disabled; set agent.token and deploy the agent DaemonSet"}
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// hostPathMounts returns where the containers of a pod mount a volume
+func hostPathMounts(pod *corev1.Pod, volume string) []string {
+	var paths []string
+	for _, container := range pod.Spec.Containers {
+		for _, mount := range container.VolumeMounts {
+			if mount.Name == volume {
+				paths = append(paths, fmt.Sprintf("%s:%s", container.Name, mount.MountPath))
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
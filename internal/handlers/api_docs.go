@@ -4,22 +4,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
+
+	"k8s-web-service/pkg/api"
 )
 
 // APIDocsHandler provides comprehensive API documentation with examples
 func (h *Handler) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	baseURL := fmt.Sprintf("http://%s:%s", h.config.Server.Host, h.config.Server.Port)
+	baseURL := h.baseURL()
+	now := time.Now().In(h.location(r))
 
-	response := map[string]interface{}{
-		"status":       "success",
-		"message":      "Kubernetes Web Service API Documentation",
-		"version":      "2.0.0",
-		"base_url":     baseURL,
-		"generated_at": time.Now().Format("January 2, 2006 at 3:04 PM MST"),
-		"endpoints": map[string]interface{}{
+	response := APIDocsResponse{
+		Status:         api.StatusSuccess,
+		Message:        "Kubernetes Web Service API Documentation",
+		Version:        "2.0.0",
+		BaseURL:        baseURL,
+		GeneratedAt:    now.Format(dateTimeLayout),
+		GeneratedAtISO: now.Format(time.RFC3339),
+		Endpoints: map[string]map[string]interface{}{
 			"connect_k8s": map[string]interface{}{
 				"url":         fmt.Sprintf("%s/connect-k8s", baseURL),
 				"method":      "GET",
@@ -31,7 +36,7 @@ func (h *Handler) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
 					"cluster_name":      "your-cluster",
 					"cluster_endpoint":  "https://...",
 					"region":            "us-gov-west-1",
-					"default_namespace": h.config.Kubernetes.DefaultNamespace,
+					"default_namespace": h.cfg().Kubernetes.DefaultNamespace,
 				},
 			},
 			"list_pods": map[string]interface{}{
@@ -39,21 +44,22 @@ func (h *Handler) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
 				"method":      "GET",
 				"description": "List all pods in a namespace with their status and details",
 				"parameters": map[string]string{
-					"namespace": "Target namespace (optional, defaults to configured namespace)",
+					"namespace": "Target namespace, a comma-separated list, or all (optional, defaults to configured namespace)",
 				},
 				"example_urls": []string{
 					fmt.Sprintf("%s/list-pods", baseURL),
-					fmt.Sprintf("%s/list-pods?namespace=%s", baseURL, h.config.Kubernetes.DefaultNamespace),
+					fmt.Sprintf("%s/list-pods?namespace=all", baseURL),
+					fmt.Sprintf("%s/list-pods?namespace=%s", baseURL, h.cfg().Kubernetes.DefaultNamespace),
 					fmt.Sprintf("%s/list-pods?namespace=default", baseURL),
 				},
 				"example_response": map[string]interface{}{
 					"status":    "success",
-					"namespace": h.config.Kubernetes.DefaultNamespace,
+					"namespace": h.cfg().Kubernetes.DefaultNamespace,
 					"count":     5,
 					"pods": []map[string]interface{}{
 						{
 							"name":      "example-pod-123",
-							"namespace": h.config.Kubernetes.DefaultNamespace,
+							"namespace": h.cfg().Kubernetes.DefaultNamespace,
 							"status":    "Running",
 							"node":      "node-1",
 							"created":   "2024-01-01T00:00:00Z",
@@ -61,17 +67,92 @@ func (h *Handler) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
 					},
 				},
 			},
+			"namespaces": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/namespaces", baseURL),
+				"method":      "GET",
+				"description": "List namespaces visible to the client with labels, age, background scan status, and links to per-namespace endpoints",
+				"parameters":  "None",
+				"scan_statuses": []string{
+					"not_scheduled - not in scanner.namespaces",
+					"pending - scheduled but not scanned yet",
+					"ok - scanned without warnings",
+					"warnings - scanned with expiring or expired certificates",
+					"failed - the last scan of the namespace failed",
+				},
+				"use_case": "Entry point for discovering which namespaces to analyze",
+			},
+			"nodes": map[string]interface{}{
+				"url":               fmt.Sprintf("%s/nodes", baseURL),
+				"method":            "GET",
+				"description":       "List nodes with kubelet version, OS, nodegroup, and whether kubelet client and serving certificate rotation has been observed in CSR activity",
+				"parameters":        "None",
+				"response_includes": []string{"kubelet_version", "os_image", "node_group", "certificate_rotation", "kubelet_versions"},
+				"use_case":          "Spot nodes that never rotate kubelet certificates or have unapproved serving CSRs",
+			},
+			"kubelet_rotation": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/nodes/kubelet-rotation", baseURL),
+				"method":      "GET",
+				"description": "Read each kubelet's /configz through the API server node proxy and report rotateCertificates and serverTLSBootstrap with the expiry of the current client and serving certificates, rated healthy, warning, critical, or unknown",
+				"parameters": map[string]string{
+					"warning_days": "Days threshold for certificate expiry (optional, default: scanner.warning_days)",
+				},
+				"response_includes": []string{"nodes", "rotate_certificates", "server_tls_bootstrap", "client_certificate", "serving_certificate", "pending_serving_csrs", "status", "issues"},
+			},
+			"services": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/services", baseURL),
+				"method":      "GET",
+				"description": "List Services with TLS-relevant ports (443, 8443, https-named ports) and AWS load balancer certificate annotations",
+				"parameters": map[string]string{
+					"namespace": "Target namespace (optional, defaults to configured namespace)",
+					"tls_only":  "Only return Services with a TLS port or load balancer certificate (optional, true/false)",
+				},
+				"example_urls": []string{
+					fmt.Sprintf("%s/services?tls_only=true", baseURL),
+					fmt.Sprintf("%s/services?namespace=%s", baseURL, h.cfg().Kubernetes.DefaultNamespace),
+				},
+				"response_includes": []string{"ports", "has_tls", "aws.certificate_arns", "aws.ssl_ports", "aws.load_balancer_hosts"},
+			},
+			"live_cert_check": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/live-cert-check", baseURL),
+				"method":      "GET",
+				"description": "Dial host:port over TLS and parse the certificate chain it presents, to verify what is actually served rather than what is stored in secrets",
+				"parameters": map[string]string{
+					"host":         "Host name or IP address to dial (required)",
+					"port":         "TCP port (optional, default: 443)",
+					"server_name":  "SNI name sent and matched against the leaf certificate (optional, default: host unless it is an IP address)",
+					"warning_days": "Days threshold for expiry warnings (optional, default: scanner.warning_days)",
+				},
+				"example_urls": []string{
+					fmt.Sprintf("%s/live-cert-check?host=example.com", baseURL),
+					fmt.Sprintf("%s/live-cert-check?host=10.0.12.7&port=8443&server_name=api.payments.svc", baseURL),
+				},
+				"response_includes": []string{"result.chain", "result.tls_version", "result.hostname_matches", "result.warnings"},
+			},
+			"live_cert_check_services": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/live-cert-check/services", baseURL),
+				"method":      "GET",
+				"description": "Dial every TLS-looking port of the Services of a namespace, at the load balancer when it terminates TLS with an ACM certificate and at the cluster IP otherwise, and parse the chains they serve",
+				"parameters": map[string]string{
+					"namespace":      "Target namespace (optional, defaults to configured namespace)",
+					"all_namespaces": "true to dial the Services of every namespace (optional)",
+					"warning_days":   "Days threshold for expiry warnings (optional, default: scanner.warning_days)",
+				},
+				"response_includes": []string{"results", "summary.unreachable", "summary.expired_leaf", "summary.hostname_mismatches"},
+				"use_case":          "Find Services still serving an old certificate after the secret was rotated",
+			},
 			"cluster_ca": map[string]interface{}{
 				"url":         fmt.Sprintf("%s/cluster-ca", baseURL),
 				"method":      "GET",
 				"description": "Retrieve the Kubernetes cluster CA certificate",
 				"parameters":  "None",
+				"redaction":   "With security.redact_pem, pem_content is omitted and redacted is true",
 				"example_response": map[string]interface{}{
 					"status":  "success",
 					"message": "Retrieved cluster CA certificate",
 					"ca_certificate": map[string]interface{}{
-						"pem_content": "-----BEGIN CERTIFICATE-----...",
-						"length":      1099,
+						"pem_content":         "-----BEGIN CERTIFICATE-----...",
+						"length":              1099,
+						"fingerprints_sha256": []string{"5f3c9a..."},
 					},
 					"cluster_info": map[string]interface{}{
 						"region":           "us-gov-west-1",
@@ -86,6 +167,7 @@ func (h *Handler) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
 				"description": "Analyze cluster CA certificate expiry with detailed date information",
 				"parameters": map[string]string{
 					"warning_days": "Number of days before expiry to warn (optional, default: 30)",
+					"tz":           "IANA time zone of dates (optional, default: server.timezone)",
 				},
 				"example_urls": []string{
 					fmt.Sprintf("%s/cluster-ca-expiry", baseURL),
@@ -93,27 +175,33 @@ func (h *Handler) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
 					fmt.Sprintf("%s/cluster-ca-expiry?warning_days=90", baseURL),
 				},
 				"response_features": []string{
-					"Formatted expiry dates (human-readable)",
-					"Time remaining in years/months/days",
+					"Formatted expiry dates (human-readable, with ISO-8601 counterparts)",
+					"Dates in server.timezone or the tz parameter",
+					"Time remaining in calendar years/months/days (server.durations)",
 					"Certificate validity period",
 					"Expiry status summary",
 					"Analysis timestamp",
 				},
 				"example_response": map[string]interface{}{
-					"status":        "success",
-					"analysis_date": "May 23, 2025 at 2:19 PM CDT",
+					"status":            "success",
+					"analysis_date":     "May 23, 2025 at 7:19 PM UTC",
+					"analysis_date_iso": "2025-05-23T19:19:00Z",
+					"timezone":          "UTC",
 					"certificate_info": map[string]interface{}{
 						"enhanced_info": map[string]interface{}{
 							"validity_period": map[string]interface{}{
 								"not_before_formatted": "May 4, 2023 at 5:37 PM UTC",
 								"not_after_formatted":  "May 1, 2033 at 5:37 PM UTC",
+								"not_before_iso":       "2023-05-04T17:37:00Z",
+								"not_after_iso":        "2033-05-01T17:37:00Z",
 								"valid_for_days":       3650,
 							},
 							"expiry_info": map[string]interface{}{
 								"days_until_expiry":  2899,
 								"expires_on":         "May 1, 2033",
 								"expires_on_weekday": "Sunday, May 1, 2033",
-								"time_remaining":     "7 years, 344 days",
+								"expires_on_iso":     "2033-05-01",
+								"time_remaining":     "7 years, 11 months",
 							},
 						},
 					},
@@ -122,14 +210,95 @@ func (h *Handler) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
 					},
 				},
 			},
+			"scan": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/scan", baseURL),
+				"method":      "GET",
+				"description": "Run the pods, secrets, ingress, webhooks, and cluster CA analyses in one request and summarize their certificates together; a failed section is reported under errors",
+				"parameters": map[string]string{
+					"namespace":    "Target namespace (optional)",
+					"include":      "Comma-separated sections: pods, secrets, ingress, webhooks, cluster-ca (optional, default: all)",
+					"warning_days": "Days threshold for expiry warnings (optional, default: scanner.warning_days)",
+					"tz":           "IANA time zone of dates (optional, default: server.timezone)",
+				},
+				"example_urls": []string{
+					fmt.Sprintf("%s/scan?namespace=%s", baseURL, h.cfg().Kubernetes.DefaultNamespace),
+					fmt.Sprintf("%s/scan?include=pods,ingress&warning_days=60", baseURL),
+				},
+				"response_includes": []string{"summary", "status", "soonest_expiry", "pods", "secrets", "ingress", "webhooks", "cluster_ca", "errors"},
+			},
+			"health_score": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/health-score", baseURL),
+				"method":      "GET",
+				"description": "Score the certificate health of namespaces from 0 to 100 for trending; each certificate loses the weights of its issues (expired, expiring within 7 days, expiring within warning_days, broken chain, weak key, critical findings). Without a namespace, the namespaces of the last background scan are scored",
+				"parameters": map[string]string{
+					"namespace":    "Namespace to analyze now (optional, default: the namespaces of the last background scan, or the default namespace)",
+					"warning_days": "Days threshold for expiring certificates (optional, default: scanner.warning_days)",
+				},
+				"example_urls": []string{
+					fmt.Sprintf("%s/health-score", baseURL),
+					fmt.Sprintf("%s/health-score?namespace=%s", baseURL, h.cfg().Kubernetes.DefaultNamespace),
+				},
+				"response_includes": []string{"score", "namespaces", "deductions", "weights", "source"},
+			},
+			"policy_violations": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/policy-violations", baseURL),
+				"method":      "GET",
+				"description": "Evaluate the policies of the configuration (runway, allowed issuers, maximum validity) against the certificates of each namespace they apply to, reporting every rule as passed or failed with the violating certificates. Returns 503 when no policies are configured",
+				"parameters": map[string]string{
+					"namespace": "Namespace to analyze now (optional, default: the namespaces of the last background scan, or the default namespace)",
+				},
+				"example_urls": []string{
+					fmt.Sprintf("%s/policy-violations", baseURL),
+					fmt.Sprintf("%s/policy-violations?namespace=%s", baseURL, h.cfg().Kubernetes.DefaultNamespace),
+				},
+				"response_includes": []string{"passed", "summary", "results", "rule", "requirement", "violations"},
+			},
+			"graphql": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/graphql", baseURL),
+				"method":      "POST",
+				"description": "Answer a GraphQL query over the certificate inventory, so a dashboard fetches the nested shape it needs in one round trip. The query type has namespaces(names, warning_days) and namespace(name, warning_days); a Namespace has pods, certificate_sources per pod, custom_resources, certificates (once each, soonest expiry first, optionally expiring_only), all_warnings, and health_score. Field names are the JSON members of the REST endpoints. Without arguments, namespaces returns the last background scan",
+				"parameters":  `JSON body {"query": "...", "variables": {...}, "operationName": "..."}`,
+				"example_urls": []string{
+					fmt.Sprintf(`curl -X POST %s/graphql -d '{"query": "{ namespaces { namespace health_score { score } certificates(expiring_only: true) { subject not_after } } }"}'`, baseURL),
+				},
+				"response_includes": []string{"data", "errors"},
+			},
+			"analyze_keystore": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/analyze/keystore", baseURL),
+				"method":      "POST",
+				"description": "Analyze the certificates of an uploaded PKCS#12 (.p12/.pfx) or JKS keystore before it is deployed, per alias, with the same expiry analysis as in-cluster certificates. The keystore and password are not stored, and private keys are not decoded",
+				"parameters":  "Multipart form with the keystore in file, its password in password, and optionally format (pkcs12 or jks, detected by default); query parameters warning_days (optional, default: scanner.warning_days) and tz (optional, IANA time zone of dates, default: server.timezone)",
+				"example_urls": []string{
+					fmt.Sprintf(`curl -X POST %s/analyze/keystore -F file=@keystore.p12 -F password=changeit`, baseURL),
+				},
+				"response_includes": []string{"format", "integrity_verified", "entries", "certificates", "warnings"},
+			},
+			"compare": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/compare", baseURL),
+				"method":      "POST",
+				"description": "Verify that a rotation landed everywhere: compare an uploaded certificate (PEM body or multipart file field) with the certificate stored in a secret and the one a Service serves, reporting whether the fingerprints match, the SANs missing or extra, and how many days later the uploaded certificate expires",
+				"parameters": map[string]string{
+					"namespace": "Namespace of the secret and Service (optional, default: kubernetes.default_namespace)",
+					"secret":    "Secret to compare with; its first non-CA certificate is used (optional)",
+					"service":   "Service whose served certificate is compared, over TLS to its cluster IP (optional, requires the probes group)",
+					"port":      "Port of the Service (optional, default: its first TLS port)",
+					"tz":        "IANA time zone of dates (optional, default: server.timezone)",
+				},
+				"example_urls": []string{
+					fmt.Sprintf(`curl -X POST "%s/compare?namespace=payments&secret=api-tls&service=api" --data-binary @tls.crt`, baseURL),
+				},
+				"response_includes": []string{"uploaded", "all_match", "comparisons", "sans_missing", "sans_extra", "expiry_delta_days"},
+			},
 			"pod_certificates": map[string]interface{}{
 				"url":         fmt.Sprintf("%s/pod-certificates", baseURL),
 				"method":      "GET",
 				"description": "Analyze certificate mounts and sources in pods",
 				"parameters": map[string]string{
-					"namespace":    "Target namespace (optional)",
+					"namespace":    "Target namespace, a comma-separated list, or all (optional)",
 					"detailed":     "Include certificate expiry analysis (true/false, optional)",
 					"warning_days": "Warning threshold in days (optional, default: 30)",
+					"format":       "csv or xlsx for a spreadsheet of every certificate, with the detailed analysis (optional, default: JSON)",
+					"tz":           "IANA time zone of dates (optional, default: server.timezone)",
 				},
 				"example_urls": []string{
 					fmt.Sprintf("%s/pod-certificates", baseURL),
@@ -146,72 +315,455 @@ func (h *Handler) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
 					"pod-name":     "Name of the pod (required in URL path)",
 					"namespace":    "Target namespace (optional)",
 					"warning_days": "Warning threshold in days (optional, default: 30)",
+					"tz":           "IANA time zone of dates (optional, default: server.timezone)",
 				},
 				"example_urls": []string{
 					fmt.Sprintf("%s/pod-certificates/example-pod", baseURL),
-					fmt.Sprintf("%s/pod-certificates/example-pod?namespace=%s", baseURL, h.config.Kubernetes.DefaultNamespace),
+					fmt.Sprintf("%s/pod-certificates/example-pod?namespace=%s", baseURL, h.cfg().Kubernetes.DefaultNamespace),
 					fmt.Sprintf("%s/pod-certificates/example-pod?warning_days=60", baseURL),
 				},
 			},
 			"certificate_expiry": map[string]interface{}{
 				"url":         fmt.Sprintf("%s/certificate-expiry", baseURL),
 				"method":      "GET",
-				"description": "Certificate expiry analysis across all pods in a namespace",
+				"description": "Certificate expiry analysis across all pods in a namespace, served from the last background scan (with last_scanned) when it covered the namespace at the same warning threshold",
 				"parameters": map[string]string{
-					"namespace":    "Target namespace (optional)",
+					"namespace":    "Target namespace, a comma-separated list, or all (optional)",
 					"warning_days": "Warning threshold in days (optional, default: 30)",
+					"refresh":      "true to analyze now instead of serving the last background scan (optional)",
+					"format":       "csv or xlsx for a spreadsheet of every certificate (optional, default: JSON)",
+					"tz":           "IANA time zone of dates (optional, default: server.timezone)",
 				},
 				"example_urls": []string{
 					fmt.Sprintf("%s/certificate-expiry", baseURL),
-					fmt.Sprintf("%s/certificate-expiry?namespace=%s&warning_days=60", baseURL, h.config.Kubernetes.DefaultNamespace),
+					fmt.Sprintf("%s/certificate-expiry?namespace=%s&warning_days=60", baseURL, h.cfg().Kubernetes.DefaultNamespace),
+					fmt.Sprintf("%s/certificate-expiry?namespace=%s&format=csv", baseURL, h.cfg().Kubernetes.DefaultNamespace),
+					fmt.Sprintf("%s/certificate-expiry?namespace=all", baseURL),
+				},
+			},
+			"workload_certificates": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/workload-certificates", baseURL),
+				"method":      "GET",
+				"description": "Certificate expiry analysis per owning workload, so a 30-replica Deployment is one entry instead of 30",
+				"parameters": map[string]string{
+					"namespace":    "Target namespace (optional)",
+					"warning_days": "Days threshold for expiry warnings (optional, default: scanner.warning_days)",
+					"tz":           "IANA time zone of dates (optional, default: server.timezone)",
+				},
+				"example_urls": []string{
+					fmt.Sprintf("%s/workload-certificates?namespace=%s", baseURL, h.cfg().Kubernetes.DefaultNamespace),
+				},
+				"response_includes": []string{"kind", "name", "replicas", "revisions", "pods", "certificate_sources", "warnings"},
+			},
+			"stale_certificates": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/stale-certificates", baseURL),
+				"method":      "GET",
+				"description": "Compare when the certificate in each secret a pod uses was rotated (certificate not_before, or the secret's last update) with the pod start time, and flag pods that may still serve the old certificate, with a rollout-restart command",
+				"parameters": map[string]string{
+					"namespace": "Target namespace (optional)",
+				},
+				"confidence": []string{
+					"stale - subPath mount or environment variable, never updated by the kubelet",
+					"possibly_stale - volume updated in place; stale unless the process reloads it",
+					"propagating - rotated within the kubelet sync window",
+				},
+				"response_includes": []string{"findings", "pod_started", "secret_rotated", "confidence", "suggested_action", "suggested_actions"},
+			},
+			"service_account_tokens": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/service-account-tokens", baseURL),
+				"method":      "GET",
+				"description": "Report the expiration_seconds and audiences of projected service account tokens, and decode legacy token secrets mounted by pods to surface their JWT expiry, flagging tokens that never expire",
+				"parameters": map[string]string{
+					"namespace":    "Target namespace (optional)",
+					"warning_days": "Days threshold for legacy token expiry warnings (optional, default: scanner.warning_days)",
+				},
+				"token_types": []string{
+					"projected - requested and refreshed by the kubelet through a projected serviceAccountToken source",
+					"legacy_secret - read from a kubernetes.io/service-account-token secret and never rotated",
+				},
+				"example_urls": []string{
+					fmt.Sprintf("%s/service-account-tokens?namespace=%s", baseURL, h.cfg().Kubernetes.DefaultNamespace),
+				},
+				"response_includes": []string{"pods", "tokens", "expiration_seconds", "audiences", "expires_at", "never_expires", "last_used", "invalid_since", "warnings"},
+			},
+			"system_certificates": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/system-certificates", baseURL),
+				"method":      "GET",
+				"description": "Curated certificate health of kube-system components: CoreDNS, kube-proxy, metrics-server, the AWS Load Balancer Controller, and the EBS/EFS CSI drivers, including webhook caBundles and the metrics-server APIService",
+				"parameters": map[string]string{
+					"warning_days": "Days threshold for expiry warnings (optional, default: scanner.warning_days)",
+				},
+				"response_includes": []string{"components", "status", "ready", "certificate_sources", "webhooks", "api_service", "warnings"},
+			},
+			"helm_certificates": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/helm-certificates", baseURL),
+				"method":      "GET",
+				"description": "Decode the Helm v3 release secrets (sh.helm.release.v1.*) of a namespace and report the expiry of PEM certificates embedded in the release values, rendered manifests, and chart defaults, including base64-encoded PEM in Secret data",
+				"parameters": map[string]string{
+					"namespace":     "Target namespace (optional)",
+					"warning_days":  "Days threshold for expiry warnings (optional, default: scanner.warning_days)",
+					"all_revisions": "true to scan every stored revision instead of the deployed one (optional)",
+				},
+				"response_includes": []string{"releases", "revision", "chart", "certificates", "source", "location", "warnings"},
+			},
+			"image_ca_bundles": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/image-ca-bundles", baseURL),
+				"method":      "GET",
+				"description": "Pull the images of a namespace's pods, authenticating with their imagePullSecrets, and report expired or expiring roots in /etc/ssl/certs/ca-certificates.crt (or /etc/pki/tls/certs/ca-bundle.crt). Opt-in with images.ca_bundle_analysis; returns 503 otherwise",
+				"parameters": map[string]string{
+					"namespace":    "Target namespace (optional)",
+					"warning_days": "Days threshold for expiring roots (optional, default: scanner.warning_days)",
+				},
+				"response_includes": []string{"images", "digest", "path", "total_roots", "expired_roots", "expiring_roots", "pods", "skipped_images"},
+			},
+			"secrets": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/secrets", baseURL),
+				"method":      "GET",
+				"description": "List secret names, types, key names, sizes, and ages. Values are never returned.",
+				"parameters": map[string]string{
+					"namespace": "Target namespace (optional, defaults to configured namespace)",
+					"type":      "Only secrets of this type (optional, e.g. kubernetes.io/tls)",
+				},
+				"example_urls": []string{
+					fmt.Sprintf("%s/secrets?namespace=%s&type=kubernetes.io/tls", baseURL, h.cfg().Kubernetes.DefaultNamespace),
+				},
+				"use_case": "See which secrets exist before requesting deep certificate analysis",
+			},
+			"secrets_certificates": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/secrets-certificates", baseURL),
+				"method":      "GET",
+				"description": "Parse the certificates of every kubernetes.io/tls secret, and of Opaque secrets whose certificate-like keys hold PEM certificates, in a namespace or cluster-wide, including secrets no pod references",
+				"parameters": map[string]string{
+					"namespace":      "Target namespace (optional, defaults to configured namespace)",
+					"all_namespaces": "true to list the secrets of every namespace (optional)",
+					"warning_days":   "Days threshold for expiry warnings (optional, default: scanner.warning_days)",
+					"tz":             "IANA time zone of dates (optional, default: server.timezone)",
+				},
+				"response_includes": []string{"secrets", "secret_type", "status", "certificates", "parse_errors", "private_key.matches_certificate", "warnings", "by_status", "key_mismatches"},
+				"use_case":          "Find expiring certificates in secrets that pod-based discovery cannot see",
+			},
+			"trust_bundles": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/configmaps/trust-bundles", baseURL),
+				"method":      "GET",
+				"description": "Find configmaps likely to contain CA bundles (kube-root-ca.crt, *-ca-bundle, PEM content) and report bundle size, soonest-expiring member, and expired roots",
+				"parameters": map[string]string{
+					"namespace": "Target namespace (optional, defaults to configured namespace)",
+				},
+				"response_includes": []string{"reason", "keys", "size_bytes", "total_certificates", "soonest_expiry", "expired_roots"},
+			},
+			"ca_rotation_status": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/ca-rotation-status", baseURL),
+				"method":      "GET",
+				"description": "Detect an in-progress or recent cluster CA rotation by comparing the kubeconfig CA, the CA EKS reports (with the aws group enabled), and each namespace's kube-root-ca.crt, and list the workloads whose pods started before the new CA reached their namespace",
+				"parameters": map[string]string{
+					"namespace":    "Comma-separated namespaces to check besides the default and scanner namespaces (optional)",
+					"cluster_name": "EKS cluster name (optional, defaults to the configured or kubeconfig cluster)",
+				},
+				"response_includes": []string{"rotation.status", "newest_ca", "sources", "has_newest_ca", "workloads", "pods_not_picked_up", "summary", "issues"},
+			},
+			"webhook_ca_bundles": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/webhook-ca-bundles", baseURL),
+				"method":      "GET",
+				"description": "Decode the caBundle of every MutatingWebhookConfiguration and ValidatingWebhookConfiguration webhook and every aggregated APIService, and report the expiry of each CA. An expired CA makes every call from the API server fail, rejecting or silently skipping admission",
+				"parameters": map[string]string{
+					"warning_days": "Days threshold for expiry warnings (optional, default: scanner.warning_days)",
+					"tz":           "IANA time zone of dates (optional, default: server.timezone)",
+				},
+				"response_includes": []string{"webhooks", "failure_policy", "ca_bundle", "api_services", "insecure_skip_tls_verify", "warnings", "summary"},
+			},
+			"trust_store_validation": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/trust-store-validation", baseURL),
+				"method":      "GET",
+				"description": "Verify that the CA bundles mounted into each pod, the service account ca.crt projected from kube-root-ca.crt and trust bundle configmaps, validate the chain the API server serves now, catching stale or truncated trust stores before TLS fails",
+				"parameters": map[string]string{
+					"namespace": "Target namespace (optional, defaults to configured namespace)",
+					"tz":        "IANA time zone of dates (optional, default: server.timezone)",
+				},
+				"response_includes": []string{"api_server", "served_chain", "invalid_pods", "trust_stores", "valid", "truncated", "mount_paths"},
+			},
+			"eks_clusters": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/eks/clusters", baseURL),
+				"method":      "GET",
+				"description": "List the EKS clusters of the account in the configured region or the given regions, marking clusters that are configured or present in the kubeconfig. Unregistered clusters include the kubeconfig command and settings needed to onboard them.",
+				"parameters": map[string]string{
+					"regions": "Comma-separated regions to search (optional, defaults to aws.region)",
+				},
+				"response_includes": []string{"name", "region", "kubernetes_version", "registered", "kubeconfig_context", "onboarding"},
+			},
+			"eks_addons": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/eks/addons", baseURL),
+				"method":      "GET",
+				"description": "List installed EKS addons (vpc-cni, coredns, kube-proxy, ...) with their version, the latest and default versions compatible with the cluster's Kubernetes version, and health issues",
+				"parameters": map[string]string{
+					"cluster_name": "EKS cluster name (optional, defaults to the configured or kubeconfig cluster)",
+				},
+				"response_includes": []string{"kubernetes_version", "current_version", "latest_compatible_version", "default_version", "update_available", "issues"},
+				"use_case":          "Spot addon drift that often accompanies certificate and authentication issues",
+			},
+			"eks_nodegroups": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/eks/nodegroups", baseURL),
+				"method":      "GET",
+				"description": "List EKS managed nodegroups with AMI type and release version, instance types, and scaling config, and Fargate profiles with their selectors",
+				"parameters": map[string]string{
+					"cluster_name": "EKS cluster name (optional, defaults to the configured or kubeconfig cluster)",
+				},
+				"response_includes": []string{"nodegroups", "release_version", "scaling", "fargate_profiles", "selectors"},
+			},
+			"eks_oidc_thumbprint": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/eks/oidc-thumbprint", baseURL),
+				"method":      "GET",
+				"description": "Fetch the TLS certificate chain of the cluster's OIDC issuer and compare its SHA-1 thumbprints with those registered on the IAM OIDC provider",
+				"parameters": map[string]string{
+					"cluster_name": "EKS cluster name (optional, defaults to the configured or kubeconfig cluster)",
+				},
+				"statuses": []string{
+					"match - the provider lists a thumbprint of the issuer chain",
+					"mismatch - no registered thumbprint matches; IRSA may fail",
+					"no_provider - no IAM OIDC provider exists for the issuer",
+					"unverified - the issuer certificate could not be fetched",
+				},
+				"use_case": "Diagnose IAM roles for service accounts failing silently after an issuer certificate change",
+			},
+			"eks_access": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/eks/access", baseURL),
+				"method":      "GET",
+				"description": "Parse the aws-auth ConfigMap and list EKS access entries with their groups and access policies, flagging mappings that cannot work (role ARNs with a path, assumed-role ARNs, missing usernames, duplicates, aws-auth ignored in API mode)",
+				"parameters": map[string]string{
+					"cluster_name": "EKS cluster name (optional, defaults to the configured or kubeconfig cluster)",
 				},
+				"response_includes": []string{"aws_auth", "authentication_mode", "access_entries", "issues"},
+				"use_case":          "Find the mapping behind authentication failures reported by /test-k8s-auth",
+			},
+			"acm_certificates": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/aws/acm-certificates", baseURL),
+				"method":      "GET",
+				"description": "List ACM certificates of the region with expiry, renewal eligibility, and renewal status, correlated to the Ingresses (alb.ingress.kubernetes.io/certificate-arn) and Services (aws-load-balancer-ssl-cert) that reference them. Legacy IAM server certificates are listed with expiry warnings, and certificates of Ingress TLS secrets are included when secret_scanning is enabled.",
+				"parameters": map[string]string{
+					"namespace":       "Only correlate references from this namespace (optional, defaults to all namespaces)",
+					"referenced_only": "Only return certificates referenced by the cluster (optional, true/false)",
+					"warning_days":    "Expiry warning threshold in days (optional, defaults to scanner.warning_days)",
+				},
+				"response_includes": []string{"acm_certificates", "referenced_by", "renewal_eligibility", "warnings", "iam_server_certificates", "unresolved_references", "in_cluster_certificates"},
+				"use_case":          "See AWS-managed and in-cluster certificates in one report",
+			},
+			"load_balancer_certificates": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/aws/load-balancer-certificates", baseURL),
+				"method":      "GET",
+				"description": "Match the load balancer hostnames of Ingresses and Services to ALBs and NLBs, and analyze the certificates attached to their HTTPS and TLS listeners, including non-default SNI certificates",
+				"parameters": map[string]string{
+					"namespace":    "Only load balancers fronting this namespace (optional, defaults to all namespaces)",
+					"warning_days": "Expiry warning threshold in days (optional, defaults to scanner.warning_days)",
+				},
+				"response_includes": []string{"load_balancers", "listeners", "certificates", "frontends", "warnings", "unmatched_hostnames"},
+				"use_case":          "Catch expired listener certificates, which are invisible from inside the cluster",
+			},
+			"route53_coverage": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/aws/route53-coverage", baseURL),
+				"method":      "GET",
+				"description": "Find Route53 A, AAAA, and CNAME records pointing at the load balancers of Ingresses and Services, and check them against the SANs of the listener certificates and Ingress TLS secrets actually served",
+				"parameters": map[string]string{
+					"namespace":    "Only load balancers fronting this namespace (optional, defaults to all namespaces)",
+					"warning_days": "Expiry warning threshold in days (optional, defaults to scanner.warning_days)",
+				},
+				"statuses": []string{
+					"covered - a served certificate covers the domain",
+					"expiring - the longest-lived covering certificate expires within warning_days",
+					"expired - every covering certificate has expired",
+					"uncovered - no served certificate covers the domain",
+				},
+			},
+			"secretsmanager_certificates": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/aws/secretsmanager-certificates", baseURL),
+				"method":      "GET",
+				"description": "Read the Secrets Manager secrets under aws.secrets_manager.prefixes and check the expiry of the PEM and PKCS#12 certificates they hold, including JSON secrets with certificate fields. Secret values are never returned.",
+				"parameters": map[string]string{
+					"prefix":       "Only secrets under this prefix, which must fall under a configured prefix (optional)",
+					"warning_days": "Expiry warning threshold in days (optional, defaults to scanner.warning_days)",
+				},
+				"response_includes": []string{"bundles", "certificates", "format", "warnings"},
+			},
+			"cloudfront_certificates": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/aws/cloudfront-certificates", baseURL),
+				"method":      "GET",
+				"description": "Check the viewer certificates (ACM in us-east-1 or IAM) of the distributions in aws.cloudfront.distribution_ids for expiry and alias coverage, and flag security policies that allow TLS versions below 1.2",
+				"parameters": map[string]string{
+					"warning_days": "Expiry warning threshold in days (optional, defaults to scanner.warning_days)",
+				},
+				"response_includes": []string{"distributions", "certificate_source", "minimum_protocol_version", "warnings"},
+			},
+			"private_ca": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/aws/private-ca", baseURL),
+				"method":      "GET",
+				"description": "Resolve the ACM Private CAs that issued ACM certificates and report each CA's status, expiry, and CRL/OCSP configuration with the certificates it issued",
+				"parameters": map[string]string{
+					"warning_days": "Expiry warning threshold in days (optional, defaults to scanner.warning_days)",
+				},
+				"response_includes": []string{"certificate_authorities", "revocation", "issued_certificates", "warnings"},
+			},
+			"hostpath_certificates": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/hostpath-certificates", baseURL),
+				"method":      "GET",
+				"description": "Match the hostPath volumes of pods with the certificate files the node agent DaemonSet reported under those paths on the pod's node, and list reporting nodes, stale reports, and nodes without an agent",
+				"parameters": map[string]string{
+					"namespace":    "Target namespace (optional, default: all namespaces)",
+					"warning_days": "Days threshold for expiry warnings (optional, default: scanner.warning_days)",
+				},
+				"response_includes": []string{"pods", "volumes", "files", "warnings", "agents", "nodes_without_agent"},
+			},
+			"agent_report": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/agent/report", baseURL),
+				"method":      "POST",
+				"description": "Receive the certificate files a node agent found on its node; requires Authorization: Bearer <agent.token>",
+				"parameters":  "JSON report sent by k8s-web-service agent",
+				"use_case":    "Called by the node agent DaemonSet, not by users",
 			},
 			"debug": map[string]interface{}{
 				"url":         fmt.Sprintf("%s/debug", baseURL),
 				"method":      "GET",
-				"description": "Debug AWS and Kubernetes configuration",
+				"description": "Debug AWS and Kubernetes configuration: the effective configuration and kubeconfig, EKS token source and expiry, AWS credential provider, IMDS availability, aws-iam-authenticator version, dependency versions, the age of cached data, and per-call latency statistics (count, errors, mean, p50, p95, max) of AWS calls, Kubernetes API requests, and EKS token generation",
 				"parameters":  "None",
 				"use_case":    "Troubleshooting connectivity issues",
 			},
+			"metrics": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/metrics", baseURL),
+				"method":      "GET",
+				"description": "Prometheus text format metrics: k8s_cert_days_until_expiry and k8s_cert_expiry_timestamp_seconds per certificate of the last background scan (labels namespace, pod, source, subject, serial), per-namespace scan gauges, the scan error counters k8s_cert_scan_errors_total and k8s_cert_scan_namespace_errors_total, and latency histograms (k8s_web_service_call_duration_seconds) and error counters (k8s_web_service_call_errors_total) of AWS calls, Kubernetes API requests, and EKS token generation",
+				"parameters":  "None",
+				"use_case":    "Alert on certificate expiry with Alertmanager rules, and attribute slow scans to AWS or to the Kubernetes API server",
+			},
 			"test_k8s_auth": map[string]interface{}{
 				"url":         fmt.Sprintf("%s/test-k8s-auth", baseURL),
 				"method":      "GET",
-				"description": "Comprehensive Kubernetes authentication testing",
+				"description": "Comprehensive Kubernetes authentication testing: AWS configuration, client creation, namespace and pod reads, and a SelfSubjectAccessReview of each permission of the certificate features (get/list pods, secrets, and configmaps; create pods/exec, optional) in the default, scanner, and requested namespaces",
+				"parameters": map[string]string{
+					"namespace": "Extra namespaces to check, comma-separated (optional)",
+				},
+				"response_includes": []string{"tests", "identity", "missing_permissions", "remediation_manifest"},
+				"use_case":          "Verify permissions and get the RBAC manifest that grants the missing ones",
+			},
+			"debug_rbac": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/debug/rbac", baseURL),
+				"method":      "GET",
+				"description": "Report what the service's identity can and cannot do: every permission the service uses, with its access (allowed, denied, restricted, unknown) cluster-wide or in each of the default, scanner, and requested namespaces, and the raw rules per namespace",
+				"parameters": map[string]string{
+					"namespace": "Extra namespaces to review, comma-separated (optional)",
+					"format":    "table for a plain-text matrix (optional, default: JSON)",
+				},
+				"response_includes": []string{"matrix", "used_by", "rules", "incomplete", "missing_permissions"},
+				"use_case":          "Onboarding a cluster without trial-and-error 403s",
+			},
+			"admin_reload": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/admin/reload", baseURL),
+				"method":      "POST",
+				"description": "Reload the configuration file without restarting and report what changed; requires Authorization: Bearer <admin.token> or a client certificate",
 				"parameters":  "None",
-				"use_case":    "Verify permissions and access levels",
+				"use_case":    "Apply new thresholds, namespaces, or notifier settings",
+			},
+			"admin_drain": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/admin/drain", baseURL),
+				"method":      "POST, GET, DELETE",
+				"description": "Stop accepting new scans and let running scans finish (POST), report drain progress (GET), or resume (DELETE); requires Authorization: Bearer <admin.token> or a client certificate",
+				"parameters":  "None",
+				"use_case":    "Take a replica out of service before maintenance or a rolling update",
+			},
+			"admin_simulate": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/admin/simulate?expiry_in=3d&namespace=%s", baseURL, h.cfg().Kubernetes.DefaultNamespace),
+				"method":      "POST",
+				"description": "Pretend matching certificates expire after expiry_in and send the alerts to the configured notifiers, marked as simulated; requires Authorization: Bearer <admin.token> or a client certificate",
+				"parameters": map[string]string{
+					"expiry_in": "Required. Go duration or days, e.g. 3d, 12h; 0 or negative simulates an expired certificate",
+					"namespace": "Optional. Namespace to scan (defaults to the configured namespace)",
+					"match":     "Optional. Only certificates whose subject or source contains this text",
+				},
+				"use_case": "Verify Slack and webhook alert delivery end-to-end",
+			},
+			"admin_reissue_certificate": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/admin/reissue-certificate?certificate_arn=...", baseURL),
+				"method":      "POST",
+				"description": "Ask ACM to re-issue a certificate issued by a private CA. The certificate keeps its ARN, so load balancers pick up the new one. Refused with 403 in read-only mode and 422 for certificates not issued by a private CA. Requires Authorization: Bearer <admin.token> or a client certificate.",
+				"parameters": map[string]string{
+					"certificate_arn": "Required. ARN of the ACM certificate",
+				},
+				"use_case": "Remediate an expiring private certificate found by /aws/private-ca",
+			},
+			"healthz": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/healthz", baseURL),
+				"method":      "GET",
+				"description": "Liveness probe, always 200 while the process is serving",
+				"parameters":  "None",
+				"use_case":    "Kubernetes livenessProbe",
+			},
+			"readyz": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/readyz", baseURL),
+				"method":      "GET",
+				"description": "Readiness probe, 503 while the server is draining",
+				"parameters":  "None",
+				"use_case":    "Kubernetes readinessProbe",
+			},
+			"schemas": map[string]interface{}{
+				"url":         fmt.Sprintf("%s/schemas/{name}.json", baseURL),
+				"method":      "GET",
+				"description": "JSON Schemas (draft 2020-12) of the typed response bodies; /schemas/ lists them with their endpoints. Every JSON response carries schema_version, whose major version changes only with breaking changes",
+				"parameters":  "None",
+				"example_urls": []string{
+					fmt.Sprintf("%s/schemas/", baseURL),
+					fmt.Sprintf("%s/schemas/pod-certificates.json", baseURL),
+				},
+				"use_case": "Validate responses in Terraform and reporting pipelines",
 			},
 		},
-		"postman_collection": map[string]interface{}{
-			"info": map[string]interface{}{
-				"name":        "Kubernetes Web Service API",
-				"description": "Collection for testing Kubernetes certificate analysis endpoints",
-				"version":     "2.0.0",
+		PostmanCollection: PostmanCollection{
+			Info: PostmanInfo{
+				Name:        "Kubernetes Web Service API",
+				Description: "Collection for testing Kubernetes certificate analysis endpoints",
+				Version:     "2.0.0",
 			},
-			"quick_start": []string{
+			QuickStart: []string{
 				"1. Import this response as a Postman collection",
 				"2. Set base_url as an environment variable",
 				"3. Start with /connect-k8s to test connectivity",
 				"4. Use /cluster-ca-expiry for certificate date analysis",
 				"5. Try /pod-certificates?detailed=true for comprehensive analysis",
 			},
-			"common_headers": map[string]string{
+			CommonHeaders: map[string]string{
 				"Content-Type": "application/json",
 				"Accept":       "application/json",
 			},
 		},
-		"configuration": map[string]interface{}{
-			"default_namespace": h.config.Kubernetes.DefaultNamespace,
-			"aws_region":        h.config.AWS.Region,
-			"cluster_name":      h.config.Kubernetes.ClusterName,
+		Configuration: DocsConfiguration{
+			DefaultNamespace: h.cfg().Kubernetes.DefaultNamespace,
+			AWSRegion:        h.cfg().AWS.Region,
+			ClusterName:      h.cfg().Kubernetes.ClusterName,
 		},
-		"notes": []string{
+		Errors: DocsErrors{
+			ContentType:  ProblemContentType,
+			ProblemTypes: problemTypes,
+		},
+		Notes: []string{
 			"All endpoints return JSON responses",
+			"Errors are RFC 7807 problem details; branch on their type URI, listed under errors",
 			"Query parameters are optional unless specified",
+			"Unknown, repeated, or invalid query parameters are rejected with 400 and listed in invalid_params",
 			"Date information includes multiple formats for convenience",
 			"Use warning_days parameter to customize expiry thresholds",
+			"Certificate lists accept sort=days_until_expiry|name|namespace|issuer and order=asc|desc",
+			"Every endpoint accepts query, a JSONPath expression such as $.pods[*].name; the response becomes the array of selected values",
+			"Certificate endpoints accept verify=true to build each leaf's chain through the presented intermediates and verify it against the cluster CA and the system trust store, reporting wrong order and missing intermediates under verification",
+			"Every endpoint accepts cluster, the name of an entry of the clusters setting listed on /, to query that cluster instead of the default kubeconfig context",
 			"The detailed=true parameter provides comprehensive certificate analysis",
+			"HEAD on /scan, /health-score, /pod-certificates, and /certificate-expiry returns X-Total-Warnings, X-Soonest-Expiry, and Last-Modified of the last background scan without running it; GET honors If-Modified-Since against the same scan",
 		},
 	}
 
+	// Hide endpoints whose group is disabled. The documented URLs carry
+	// example query strings, which are not part of the route path.
+	for name, doc := range response.Endpoints {
+		link, _ := doc["url"].(string)
+		u, err := url.Parse(link)
+		if err != nil || !h.pathEnabled(u.Path) {
+			delete(response.Endpoints, name)
+		}
+	}
+
 	json.NewEncoder(w).Encode(response)
 }
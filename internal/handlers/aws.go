@@ -0,0 +1,806 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"k8s-web-service/internal/cloud"
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+	"k8s-web-service/pkg/utils"
+)
+
+// eksDetails returns the EKS details of the cluster of a request, or nil if
+// no Kubernetes client can be created
+func (h *Handler) eksDetails(r *http.Request) *k8s.KubeConfigEKSDetails {
+	client, err := h.client(r)
+	if err != nil {
+		return nil
+	}
+	return client.GetEKSDetails()
+}
+
+// awsConfig loads the AWS configuration, falling back to the region of the
+// cluster of a request
+func (h *Handler) awsConfig(ctx context.Context, r *http.Request) (aws.Config, error) {
+	return cloud.LoadConfig(ctx, h.cfg(), h.eksDetails(r))
+}
+
+// awsSession creates an AWS session for the monitored EKS cluster. The
+// cluster_name query parameter overrides the configured cluster name.
+func (h *Handler) awsSession(ctx context.Context, r *http.Request) (*cloud.Session, error) {
+	return cloud.NewSession(ctx, h.cfg(), r.URL.Query().Get("cluster_name"), h.eksDetails(r))
+}
+
+// ACMCertificatesHandler handles the /aws/acm-certificates endpoint, listing
+// the ACM and IAM server certificates correlated with the Ingresses and
+// Services that reference them, alongside the in-cluster certificates of
+// Ingress TLS secrets
+func (h *Handler) ACMCertificatesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+	cfg := h.cfg()
+
+	namespace := r.URL.Query().Get("namespace") // empty correlates across all namespaces
+	referencedOnly := r.URL.Query().Get("referenced_only") == "true"
+	warningDays := cfg.Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	awsCfg, err := h.awsConfig(ctx, r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	certs, err := cloud.ListACMCertificates(ctx, awsCfg)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	response := ACMCertificatesResponse{
+		Status:      api.StatusSuccess,
+		Region:      awsCfg.Region,
+		WarningDays: warningDays,
+		Namespace:   namespace,
+	}
+
+	// Kubernetes references are best effort; ACM results are returned even
+	// when the cluster cannot be reached
+	var refs []k8s.CertificateReference
+	client, refsErr := h.client(r)
+	if refsErr == nil {
+		refs, refsErr = k8s.ListCertificateReferences(ctx, client.GetClientset(), namespace)
+	}
+	if refsErr != nil {
+		response.ReferencesError = refsErr.Error()
+	}
+
+	referencedBy := make(map[string][]ResourceRef)
+	for _, ref := range refs {
+		for _, arn := range ref.ARNs {
+			referencedBy[arn] = append(referencedBy[arn], ResourceRef{Kind: ref.Kind, Namespace: ref.Namespace, Name: ref.Name})
+		}
+	}
+
+	known := make(map[string]bool, len(certs))
+	expiring, unrenewable := 0, 0
+
+	// Legacy IAM server certificates are part of the inventory; failing to
+	// list them does not fail the ACM results
+	serverCerts, err := cloud.ListServerCertificates(ctx, awsCfg)
+	if err != nil {
+		response.IAMError = err.Error()
+	}
+	iamEntries := make([]IAMServerCertificateEntry, 0, len(serverCerts))
+	for _, cert := range serverCerts {
+		known[cert.ARN] = true
+		entry := IAMServerCertificateEntry{IAMServerCertificate: cert, ReferencedBy: referencedBy[cert.ARN]}
+		if entry.ReferencedBy == nil {
+			if referencedOnly {
+				continue
+			}
+			entry.ReferencedBy = []ResourceRef{}
+		}
+		entry.Warnings = iamWarnings(cert, warningDays)
+		if len(entry.Warnings) > 0 {
+			expiring++
+		}
+		iamEntries = append(iamEntries, entry)
+	}
+
+	entries := make([]ACMCertificateEntry, 0, len(certs))
+	for _, cert := range certs {
+		known[cert.ARN] = true
+		entry := ACMCertificateEntry{ACMCertificate: cert, ReferencedBy: referencedBy[cert.ARN]}
+		if entry.ReferencedBy == nil {
+			if referencedOnly {
+				continue
+			}
+			entry.ReferencedBy = []ResourceRef{}
+		}
+		entry.Warnings = acmWarnings(cert, warningDays, len(entry.ReferencedBy) > 0)
+		if cert.IsExpired || (cert.DaysUntilExp != nil && *cert.DaysUntilExp <= warningDays) {
+			expiring++
+		}
+		if cert.RenewalEligibility == "INELIGIBLE" && len(entry.ReferencedBy) > 0 {
+			unrenewable++
+		}
+		entries = append(entries, entry)
+	}
+
+	// References to certificates missing from ACM usually point to a deleted
+	// certificate or one in another region or account
+	var unresolved []UnresolvedReference
+	for _, arn := range sortedKeys(referencedBy) {
+		if !known[arn] {
+			unresolved = append(unresolved, UnresolvedReference{ARN: arn, ReferencedBy: referencedBy[arn]})
+		}
+	}
+
+	if refsErr == nil && cfg.EndpointGroupEnabled(config.EndpointGroupSecretScanning) {
+		response.InClusterCertificates = k8s.IngressTLSCertificates(ctx, client.GetClientset(), refs)
+	}
+
+	sortResults(r, entries, func(entry *ACMCertificateEntry) sortFields {
+		return sortFields{Name: entry.DomainName, Namespace: referenceNamespace(entry.ReferencedBy), Issuer: entry.Issuer, DaysUntilExpiry: entry.DaysUntilExp}
+	})
+	sortResults(r, iamEntries, func(entry *IAMServerCertificateEntry) sortFields {
+		return sortFields{Name: entry.Name, Namespace: referenceNamespace(entry.ReferencedBy), DaysUntilExpiry: entry.DaysUntilExp}
+	})
+
+	total := len(entries)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(entries) > limit
+	if truncated {
+		entries = entries[:limit]
+	}
+
+	response.Summary = ACMCertificatesSummary{
+		TotalACMCertificates:      total,
+		TotalIAMCertificates:      len(iamEntries),
+		ReferencedCertificates:    len(referencedBy) - len(unresolved),
+		ExpiringOrExpired:         expiring,
+		ReferencedNotRenewable:    unrenewable,
+		UnresolvedCertificateARNs: len(unresolved),
+	}
+	response.ACMCertificates = entries
+	response.IAMServerCertificates = iamEntries
+	response.UnresolvedReferences = unresolved
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// acmWarnings returns the problems of an ACM certificate worth acting on
+func acmWarnings(cert cloud.ACMCertificate, warningDays int, referenced bool) []string {
+	var warnings []string
+	switch {
+	case cert.IsExpired:
+		warnings = append(warnings, "Certificate has expired")
+	case cert.DaysUntilExp != nil && *cert.DaysUntilExp <= warningDays:
+		if cert.Type == "IMPORTED" {
+			warnings = append(warnings, fmt.Sprintf("Imported certificate expires in %d days and is not renewed by ACM", *cert.DaysUntilExp))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("Certificate expires in %d days", *cert.DaysUntilExp))
+		}
+	}
+	if cert.RenewalStatus == "FAILED" || cert.RenewalStatus == "PENDING_VALIDATION" {
+		warnings = append(warnings, fmt.Sprintf("Managed renewal is %s", cert.RenewalStatus))
+	}
+	if referenced && cert.Status != "" && cert.Status != "ISSUED" {
+		warnings = append(warnings, fmt.Sprintf("Referenced by the cluster but status is %s", cert.Status))
+	}
+	return warnings
+}
+
+// iamWarnings returns the expiry problems of an IAM server certificate
+func iamWarnings(cert cloud.IAMServerCertificate, warningDays int) []string {
+	switch {
+	case cert.IsExpired:
+		return []string{"IAM server certificate has expired"}
+	case cert.DaysUntilExp != nil && *cert.DaysUntilExp <= warningDays:
+		return []string{fmt.Sprintf("IAM server certificate expires in %d days and is never renewed automatically; upload a replacement or move to ACM", *cert.DaysUntilExp)}
+	}
+	return nil
+}
+
+// referenceNamespace returns the namespace an AWS certificate is sorted
+// under: that of the first resource referencing it
+func referenceNamespace(refs []ResourceRef) string {
+	if len(refs) == 0 {
+		return ""
+	}
+	return refs[0].Namespace
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LoadBalancerCertificatesHandler handles the /aws/load-balancer-certificates
+// endpoint, analyzing the listener certificates of the ALBs and NLBs that
+// front the cluster's Ingresses and Services
+func (h *Handler) LoadBalancerCertificatesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+	cfg := h.cfg()
+
+	namespace := r.URL.Query().Get("namespace") // empty covers all namespaces
+	warningDays := cfg.Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	frontends, err := k8s.ListLoadBalancerFrontends(ctx, client.GetClientset(), namespace)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+	byHost := make(map[string][]ResourceRef)
+	for _, frontend := range frontends {
+		host := strings.ToLower(frontend.Hostname)
+		byHost[host] = append(byHost[host], ResourceRef{Kind: frontend.Kind, Namespace: frontend.Namespace, Name: frontend.Name})
+	}
+
+	awsCfg, err := cloud.LoadConfig(ctx, cfg, client.GetEKSDetails())
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	balancers, err := cloud.ListLoadBalancerCertificates(ctx, awsCfg, sortedKeys(byHost))
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	matched := make(map[string]bool)
+	entries := make([]LoadBalancerEntry, 0, len(balancers))
+	totalWarnings := 0
+	for _, lb := range balancers {
+		host := strings.ToLower(lb.DNSName)
+		matched[host] = true
+		entry := LoadBalancerEntry{LoadBalancerInfo: lb, Frontends: byHost[host]}
+		for _, listener := range lb.Listeners {
+			entry.Warnings = append(entry.Warnings, listenerWarnings(listener, warningDays)...)
+		}
+		totalWarnings += len(entry.Warnings)
+		entries = append(entries, entry)
+	}
+
+	// Hostnames without an ELBv2 load balancer belong to Classic Load
+	// Balancers or load balancers in another region or account
+	unmatched := []string{}
+	for _, host := range sortedKeys(byHost) {
+		if !matched[host] {
+			unmatched = append(unmatched, host)
+		}
+	}
+
+	total := len(entries)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(entries) > limit
+	if truncated {
+		entries = entries[:limit]
+	}
+
+	response := LoadBalancerCertificatesResponse{
+		Status:      api.StatusSuccess,
+		Region:      awsCfg.Region,
+		WarningDays: warningDays,
+		Summary: LoadBalancerCertificatesSummary{
+			TotalLoadBalancers: total,
+			Frontends:          len(frontends),
+			TotalWarnings:      totalWarnings,
+		},
+		LoadBalancers:      entries,
+		UnmatchedHostnames: unmatched,
+		Namespace:          namespace,
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// listenerWarnings returns the certificate problems of a TLS listener
+func listenerWarnings(listener cloud.ListenerInfo, warningDays int) []string {
+	prefix := fmt.Sprintf("Listener %s:%d", listener.Protocol, listener.Port)
+	if listener.Error == "" && len(listener.Certificates) == 0 {
+		return []string{prefix + " has no certificate"}
+	}
+
+	var warnings []string
+	for _, cert := range listener.Certificates {
+		switch {
+		case cert.ACM != nil:
+			for _, warning := range acmWarnings(*cert.ACM, warningDays, true) {
+				warnings = append(warnings, fmt.Sprintf("%s certificate %s: %s", prefix, cert.ACM.DomainName, warning))
+			}
+		case cert.IAM != nil:
+			for _, warning := range iamWarnings(*cert.IAM, warningDays) {
+				warnings = append(warnings, fmt.Sprintf("%s certificate %s: %s", prefix, cert.IAM.Name, warning))
+			}
+		}
+	}
+	return warnings
+}
+
+// Route53 coverage statuses
+const (
+	coverageCovered   = "covered"
+	coverageExpiring  = "expiring"
+	coverageExpired   = "expired"
+	coverageUncovered = "uncovered"
+)
+
+// Route53CoverageHandler handles the /aws/route53-coverage endpoint,
+// checking that every Route53 record pointing at a cluster load balancer is
+// covered by a certificate the load balancer or Ingress actually serves
+func (h *Handler) Route53CoverageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+	cfg := h.cfg()
+
+	namespace := r.URL.Query().Get("namespace") // empty covers all namespaces
+	warningDays := cfg.Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	fail := func(err error) {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		fail(fmt.Errorf("failed to create Kubernetes client: %w", err))
+		return
+	}
+	clientset := client.GetClientset()
+
+	frontends, err := k8s.ListLoadBalancerFrontends(ctx, clientset, namespace)
+	if err != nil {
+		fail(err)
+		return
+	}
+	byHost := make(map[string][]ResourceRef)
+	for _, frontend := range frontends {
+		host := strings.ToLower(frontend.Hostname)
+		byHost[host] = append(byHost[host], ResourceRef{Kind: frontend.Kind, Namespace: frontend.Namespace, Name: frontend.Name})
+	}
+	hosts := sortedKeys(byHost)
+
+	awsCfg, err := cloud.LoadConfig(ctx, cfg, client.GetEKSDetails())
+	if err != nil {
+		fail(err)
+		return
+	}
+	records, err := cloud.ListRecordsTargeting(ctx, awsCfg, hosts)
+	if err != nil {
+		fail(err)
+		return
+	}
+	balancers, err := cloud.ListLoadBalancerCertificates(ctx, awsCfg, hosts)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// Certificates served per load balancer hostname
+	served := make(map[string][]ServedCertificate)
+	for _, lb := range balancers {
+		host := strings.ToLower(lb.DNSName)
+		for _, listener := range lb.Listeners {
+			for _, cert := range listener.Certificates {
+				if cert.ACM == nil || cert.ACM.NotAfter == nil {
+					continue
+				}
+				served[host] = append(served[host], ServedCertificate{
+					Source:       cloud.CertificateSourceACM,
+					Name:         cert.ARN,
+					Names:        append([]string{cert.ACM.DomainName}, cert.ACM.SANs...),
+					NotAfter:     cert.ACM.NotAfter,
+					DaysUntilExp: *cert.ACM.DaysUntilExp,
+					IsExpired:    cert.ACM.IsExpired,
+				})
+			}
+		}
+	}
+	if cfg.EndpointGroupEnabled(config.EndpointGroupSecretScanning) {
+		refs, err := k8s.ListCertificateReferences(ctx, clientset, namespace)
+		if err != nil {
+			fail(err)
+			return
+		}
+		ingressCerts := make(map[ResourceRef][]ServedCertificate)
+		for _, cert := range k8s.IngressTLSCertificates(ctx, clientset, refs) {
+			if cert.Cert == nil {
+				continue
+			}
+			key := ResourceRef{Kind: k8s.ReferenceIngress, Namespace: cert.Namespace, Name: cert.Ingress}
+			notAfter := cert.Cert.NotAfter
+			ingressCerts[key] = append(ingressCerts[key], ServedCertificate{
+				Source:       "secret",
+				Name:         cert.Namespace + "/" + cert.Secret,
+				Names:        cert.Cert.DNSNames,
+				NotAfter:     &notAfter,
+				DaysUntilExp: cert.Cert.DaysUntilExp,
+				IsExpired:    cert.Cert.IsExpired,
+			})
+		}
+		for host, refs := range byHost {
+			for _, ref := range refs {
+				served[host] = append(served[host], ingressCerts[ref]...)
+			}
+		}
+	}
+
+	counts := map[string]int{coverageCovered: 0, coverageExpiring: 0, coverageExpired: 0, coverageUncovered: 0}
+	coverage := make([]DomainCoverage, 0, len(records))
+	for _, record := range records {
+		entry := DomainCoverage{
+			DNSRecord: record,
+			Frontends: byHost[record.Target],
+			Status:    coverageUncovered,
+			CoveredBy: []ServedCertificate{},
+		}
+		best := -1
+		for _, cert := range served[record.Target] {
+			for _, name := range cert.Names {
+				if utils.MatchesHostname(name, record.Name) {
+					entry.CoveredBy = append(entry.CoveredBy, cert)
+					if best < 0 || cert.DaysUntilExp > entry.CoveredBy[best].DaysUntilExp {
+						best = len(entry.CoveredBy) - 1
+					}
+					break
+				}
+			}
+		}
+		if best >= 0 {
+			switch cert := entry.CoveredBy[best]; {
+			case cert.IsExpired:
+				entry.Status = coverageExpired
+			case cert.DaysUntilExp <= warningDays:
+				entry.Status = coverageExpiring
+			default:
+				entry.Status = coverageCovered
+			}
+		}
+		counts[entry.Status]++
+		coverage = append(coverage, entry)
+	}
+
+	total := len(coverage)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(coverage) > limit
+	if truncated {
+		coverage = coverage[:limit]
+	}
+
+	response := Route53CoverageResponse{
+		Status:      api.StatusSuccess,
+		WarningDays: warningDays,
+		Summary: Route53CoverageSummary{
+			TotalRecords: total,
+			ByStatus:     counts,
+		},
+		Domains:   coverage,
+		Namespace: namespace,
+	}
+	if !cfg.EndpointGroupEnabled(config.EndpointGroupSecretScanning) {
+		response.Notes = []string{"Ingress TLS secrets are not checked because the secret_scanning endpoint group is disabled"}
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// SecretsManagerCertificatesHandler handles the
+// /aws/secretsmanager-certificates endpoint, checking the expiry of
+// certificates stored in the Secrets Manager secrets under the configured
+// prefixes
+func (h *Handler) SecretsManagerCertificatesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+	cfg := h.cfg()
+
+	warningDays := cfg.Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	prefixes := cfg.AWS.SecretsManager.Prefixes
+	if len(prefixes) == 0 {
+		writeProblem(w, r, http.StatusServiceUnavailable, ProblemNotConfigured, "No Secrets Manager prefixes configured; set aws.secrets_manager.prefixes")
+		return
+	}
+	// A requested prefix must fall under a configured one, so the endpoint
+	// never reads secrets outside the configured scope
+	if prefix := r.URL.Query().Get("prefix"); prefix != "" {
+		allowed := false
+		for _, configured := range prefixes {
+			if strings.HasPrefix(prefix, configured) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "prefix %q is not under a configured prefix (%s)", prefix, strings.Join(prefixes, ", "))
+			return
+		}
+		prefixes = []string{prefix}
+	}
+
+	awsCfg, err := h.awsConfig(ctx, r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	certs, err := cloud.ListSecretsManagerCertificates(ctx, awsCfg, prefixes)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	entries := make([]SecretsManagerEntry, 0, len(certs))
+	secrets := make(map[string]bool)
+	totalCerts, totalWarnings, failed := 0, 0, 0
+	for _, cert := range certs {
+		secrets[cert.ARN] = true
+		entry := SecretsManagerEntry{SecretsManagerCertificate: cert}
+		if cert.Error != "" {
+			failed++
+		}
+		entry.Warnings = utils.ValidateCertificateExpiry(cert.Certificates, warningDays)
+		totalCerts += len(cert.Certificates)
+		totalWarnings += len(entry.Warnings)
+		entries = append(entries, entry)
+	}
+
+	sortResults(r, entries, func(entry *SecretsManagerEntry) sortFields {
+		return soonestExpiry(sortFields{Name: entry.Name}, entry.Certificates...)
+	})
+
+	total := len(entries)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(entries) > limit
+	if truncated {
+		entries = entries[:limit]
+	}
+
+	response := SecretsManagerCertificatesResponse{
+		Status:      api.StatusSuccess,
+		Region:      awsCfg.Region,
+		Prefixes:    prefixes,
+		WarningDays: warningDays,
+		Summary: SecretsManagerCertificatesSummary{
+			Secrets:           len(secrets),
+			TotalBundles:      total,
+			TotalCertificates: totalCerts,
+			TotalWarnings:     totalWarnings,
+			Errors:            failed,
+		},
+		Bundles: entries,
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// weakViewerProtocols are CloudFront security policies that allow TLS
+// versions below 1.2
+var weakViewerProtocols = map[string]bool{
+	"SSLv3":        true,
+	"TLSv1":        true,
+	"TLSv1_2016":   true,
+	"TLSv1.1_2016": true,
+}
+
+// CloudFrontCertificatesHandler handles the /aws/cloudfront-certificates
+// endpoint, checking the viewer certificates and security policies of the
+// configured CloudFront distributions
+func (h *Handler) CloudFrontCertificatesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+	cfg := h.cfg()
+
+	warningDays := cfg.Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	ids := cfg.AWS.CloudFront.DistributionIDs
+	if len(ids) == 0 {
+		writeProblem(w, r, http.StatusServiceUnavailable, ProblemNotConfigured, "No CloudFront distributions configured; set aws.cloudfront.distribution_ids")
+		return
+	}
+
+	awsCfg, err := h.awsConfig(ctx, r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	entries := []CloudFrontEntry{}
+	totalWarnings, weak := 0, 0
+	for _, distribution := range cloud.DescribeCloudFrontDistributions(ctx, awsCfg, ids) {
+		entry := CloudFrontEntry{CloudFrontDistribution: distribution}
+		entry.Warnings = cloudFrontWarnings(distribution, warningDays)
+		if weakViewerProtocols[distribution.MinimumProtocolVersion] {
+			weak++
+		}
+		totalWarnings += len(entry.Warnings)
+		entries = append(entries, entry)
+	}
+
+	json.NewEncoder(w).Encode(CloudFrontCertificatesResponse{
+		Status:      api.StatusSuccess,
+		WarningDays: warningDays,
+		Summary: CloudFrontCertificatesSummary{
+			TotalDistributions: len(entries),
+			WeakProtocolPolicy: weak,
+			TotalWarnings:      totalWarnings,
+		},
+		Distributions: entries,
+	})
+}
+
+// cloudFrontWarnings returns the viewer certificate problems of a CloudFront
+// distribution
+func cloudFrontWarnings(distribution cloud.CloudFrontDistribution, warningDays int) []string {
+	if distribution.Error != "" {
+		return nil
+	}
+
+	var warnings []string
+	switch distribution.CertificateSource {
+	case cloud.CertificateSourceACM:
+		if distribution.ACM.Error != "" {
+			break
+		}
+		warnings = append(warnings, acmWarnings(*distribution.ACM, warningDays, true)...)
+		names := append([]string{distribution.ACM.DomainName}, distribution.ACM.SANs...)
+		for _, alias := range distribution.Aliases {
+			covered := false
+			for _, name := range names {
+				if utils.MatchesHostname(name, alias) {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				warnings = append(warnings, fmt.Sprintf("Alias %s is not covered by the certificate", alias))
+			}
+		}
+	case cloud.CertificateSourceIAM:
+		if distribution.IAM == nil {
+			warnings = append(warnings, "IAM server certificate not found in the account")
+		} else {
+			warnings = append(warnings, iamWarnings(*distribution.IAM, warningDays)...)
+		}
+	case cloud.CertificateSourceCloudFront:
+		warnings = append(warnings, "Uses the default *.cloudfront.net certificate, which only supports TLSv1 as the minimum protocol")
+	}
+	if weakViewerProtocols[distribution.MinimumProtocolVersion] {
+		warnings = append(warnings, fmt.Sprintf("Security policy %s allows TLS versions below 1.2; use TLSv1.2_2021 or later", distribution.MinimumProtocolVersion))
+	}
+	return warnings
+}
+
+// PrivateCAHandler handles the /aws/private-ca endpoint, resolving the
+// private CAs that issued ACM certificates and reporting their expiry and
+// revocation configuration
+func (h *Handler) PrivateCAHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+
+	warningDays := h.cfg().Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	awsCfg, err := h.awsConfig(ctx, r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	certs, err := cloud.ListACMCertificates(ctx, awsCfg)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+	issued := make(map[string][]cloud.ACMCertificate)
+	private := 0
+	for _, cert := range certs {
+		if cert.PrivateCAARN != "" {
+			issued[cert.PrivateCAARN] = append(issued[cert.PrivateCAARN], cert)
+			private++
+		}
+	}
+
+	entries := []PrivateCAEntry{}
+	totalWarnings := 0
+	for _, ca := range cloud.DescribePrivateCAs(ctx, awsCfg, sortedKeys(issued)) {
+		entry := PrivateCAEntry{PrivateCAInfo: ca, IssuedCertificates: issued[ca.ARN]}
+		entry.Warnings = privateCAWarnings(entry, warningDays)
+		totalWarnings += len(entry.Warnings)
+		entries = append(entries, entry)
+	}
+
+	json.NewEncoder(w).Encode(PrivateCAResponse{
+		Status:      api.StatusSuccess,
+		Region:      awsCfg.Region,
+		WarningDays: warningDays,
+		Summary: PrivateCASummary{
+			PrivateCAs:          len(entries),
+			PrivateCertificates: private,
+			TotalWarnings:       totalWarnings,
+		},
+		CertificateAuthorities: entries,
+		Notes: []string{
+			"Private certificates can be re-issued with POST /admin/reissue-certificate?certificate_arn=...",
+		},
+	})
+}
+
+// privateCAWarnings returns the problems of a private CA
+func privateCAWarnings(entry PrivateCAEntry, warningDays int) []string {
+	if entry.Error != "" {
+		return nil
+	}
+
+	var warnings []string
+	if entry.Status != "ACTIVE" {
+		warnings = append(warnings, fmt.Sprintf("CA status is %s; it cannot issue or renew certificates", entry.Status))
+	}
+	switch {
+	case entry.IsExpired:
+		warnings = append(warnings, "CA certificate has expired")
+	case entry.DaysUntilExp != nil && *entry.DaysUntilExp <= warningDays:
+		warnings = append(warnings, fmt.Sprintf("CA certificate expires in %d days", *entry.DaysUntilExp))
+	}
+	if !entry.Revocation.CRLEnabled && !entry.Revocation.OCSPEnabled {
+		warnings = append(warnings, "Neither CRL nor OCSP is enabled; issued certificates cannot be revoked effectively")
+	}
+	if entry.NotAfter != nil {
+		for _, cert := range entry.IssuedCertificates {
+			if cert.NotAfter != nil && cert.NotAfter.After(*entry.NotAfter) {
+				warnings = append(warnings, fmt.Sprintf("Certificate %s outlives the CA (%s)", cert.DomainName, entry.NotAfter.Format("2006-01-02")))
+			}
+		}
+	}
+	return warnings
+}
@@ -1,13 +1,33 @@
 package handlers
 
-import "k8s-web-service/internal/config"
+import (
+	"k8s-web-service/internal/agent"
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/images"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/lifecycle"
+	"k8s-web-service/internal/scanner"
+)
 
 // Handler contains the application dependencies
 type Handler struct {
-	config *config.Config
+	store    *config.Store
+	reloader *config.Reloader
+	jobs     *lifecycle.Tracker
+	scanner  *scanner.Scanner
+	agents   *agent.Registry
+	images   *images.Analyzer
+	clients  *k8s.ClientManager
 }
 
-// New creates a new handler instance
-func New(cfg *config.Config) *Handler {
-	return &Handler{config: cfg}
+// New creates a new handler instance. The reloader and scanner are optional;
+// without them the reload endpoint reports that reloading is unavailable and
+// no background scan results are reported.
+func New(store *config.Store, reloader *config.Reloader, jobs *lifecycle.Tracker, s *scanner.Scanner) *Handler {
+	return &Handler{store: store, reloader: reloader, jobs: jobs, scanner: s, agents: agent.NewRegistry(), images: images.NewAnalyzer(), clients: k8s.NewClientManager()}
+}
+
+// cfg returns the active configuration
+func (h *Handler) cfg() *config.Config {
+	return h.store.Get()
 }
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"k8s-web-service/internal/k8s"
+)
+
+// bundleCap returns the number of certificates above which a source is
+// summarized, or 0 if the request asks for full_bundles or a spreadsheet,
+// which lists every certificate
+func (h *Handler) bundleCap(r *http.Request) int {
+	if r.URL.Query().Get("full_bundles") == "true" || exportFormat(r) != "" {
+		return 0
+	}
+	return h.cfg().Bundles.MaxCertificates
+}
+
+// summarizeBundles lists only the expired and expiring certificates of
+// sources holding more than bundles.max_certificates, with aggregate
+// statistics, unless the request asks for full_bundles
+func (h *Handler) summarizeBundles(r *http.Request, warningDays int, sources ...*k8s.CertificateSource) {
+	max := h.bundleCap(r)
+	for _, source := range sources {
+		if source != nil {
+			source.Summarize(warningDays, max)
+		}
+	}
+}
+
+// chainSource is a certificate source or live check whose chain can be
+// verified
+type chainSource interface {
+	VerifyChain(clusterCA string)
+}
+
+// chainVerifier returns a function that verifies the chains of sources
+// against the cluster CA of the request and the system roots when the
+// request asks to verify, and does nothing otherwise. The cluster CA is
+// loaded once per request.
+func (h *Handler) chainVerifier(r *http.Request) func(sources ...chainSource) {
+	if r.URL.Query().Get("verify") != "true" {
+		return func(...chainSource) {}
+	}
+	clusterCA, err := h.clusterCA(r)
+	if err != nil {
+		log.Printf("Warning: verifying chains against the system roots only: %v", err)
+	}
+	return func(sources ...chainSource) {
+		for _, source := range sources {
+			source.VerifyChain(clusterCA)
+		}
+	}
+}
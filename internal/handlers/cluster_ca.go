@@ -3,11 +3,15 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"k8s-web-service/internal/config"
 	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
 	"k8s-web-service/pkg/utils"
 )
 
@@ -15,60 +19,45 @@ import (
 func (h *Handler) ClusterCAHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Get kubeconfig path
-	kubeconfigPath := k8s.GetKubeconfigPath()
-	if kubeconfigPath == "" {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  "Could not determine kubeconfig path",
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
 	// Get cluster CA
-	clusterCA, err := k8s.GetClusterCA(kubeconfigPath)
+	clusterCA, err := h.clusterCA(r)
 	if err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("Failed to get cluster CA: %v", err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to get cluster CA: %v", err)
 		return
 	}
 
 	// Create Kubernetes client to get additional details
-	client, err := k8s.NewClient(h.config)
+	client, err := h.client(r)
 	if err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("Failed to create Kubernetes client: %v", err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
 		return
 	}
 
 	eksDetails := client.GetEKSDetails()
 
-	response := map[string]interface{}{
-		"status":      "success",
-		"message":     "Retrieved cluster CA certificate",
-		"description": "This is the CA certificate that all pods use to verify the Kubernetes API server",
-		"ca_certificate": map[string]interface{}{
-			"pem_content": clusterCA,
-			"length":      len(clusterCA),
-		},
-		"source": "kubeconfig certificate-authority-data",
-		"usage":  "Mounted at /var/run/secrets/kubernetes.io/serviceaccount/ca.crt in every pod",
-		"cluster_info": map[string]interface{}{
-			"region":           eksDetails.Region,
-			"cluster_endpoint": eksDetails.ClusterEndpoint,
-			"cluster_name":     eksDetails.ClusterName,
+	caCertificate := api.CACertificate{
+		PEMContent:   clusterCA,
+		Length:       len(clusterCA),
+		Fingerprints: utils.PEMFingerprints(clusterCA),
+	}
+	if security := h.cfg().Security; security.RedactPEM || security.PrivacyMode {
+		caCertificate.PEMContent = ""
+		caCertificate.Redacted = true
+	}
+
+	response := api.ClusterCAResponse{
+		Status:        api.StatusSuccess,
+		Message:       "Retrieved cluster CA certificate",
+		Description:   "This is the CA certificate that all pods use to verify the Kubernetes API server",
+		CACertificate: caCertificate,
+		Source:        "kubeconfig certificate-authority-data",
+		Usage:         "Mounted at /var/run/secrets/kubernetes.io/serviceaccount/ca.crt in every pod",
+		ClusterInfo: api.ClusterInfo{
+			Region:          eksDetails.Region,
+			ClusterEndpoint: eksDetails.ClusterEndpoint,
+			ClusterName:     eksDetails.ClusterName,
 		},
-		"notes": []string{
+		Notes: []string{
 			"This certificate is automatically mounted in every pod",
 			"Pods use this to verify the identity of the Kubernetes API server",
 			"This is different from client certificates used for authentication",
@@ -82,48 +71,26 @@ func (h *Handler) ClusterCAHandler(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) HandleClusterCACertificateExpiry(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Get warning days from query parameter (default 30 days)
+	// Get warning days from query parameter (default from scanner.warning_days)
 	warningDaysStr := r.URL.Query().Get("warning_days")
-	warningDays := 30
+	warningDays := h.cfg().Scanner.WarningDays
 	if warningDaysStr != "" {
 		if days, err := strconv.Atoi(warningDaysStr); err == nil && days > 0 {
 			warningDays = days
 		}
 	}
 
-	// Get kubeconfig path
-	kubeconfigPath := k8s.GetKubeconfigPath()
-	if kubeconfigPath == "" {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  "Could not determine kubeconfig path",
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
 	// Get cluster CA
-	clusterCA, err := k8s.GetClusterCA(kubeconfigPath)
+	clusterCA, err := h.clusterCA(r)
 	if err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("Failed to get cluster CA: %v", err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to get cluster CA: %v", err)
 		return
 	}
 
 	// Parse the cluster CA certificate and get expiry information
 	certSource, err := k8s.GetClusterCACertificateInfo(clusterCA)
 	if err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("Failed to parse cluster CA certificate: %v", err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		writeProblem(w, r, http.StatusInternalServerError, ProblemParseFailure, "Failed to parse cluster CA certificate: %v", err)
 		return
 	}
 
@@ -134,63 +101,73 @@ func (h *Handler) HandleClusterCACertificateExpiry(w http.ResponseWriter, r *htt
 	warnings := k8s.GetCertificateExpiryWarnings(certSources, warningDays)
 
 	// Create enhanced certificate info with formatted dates
-	var enhancedCertInfo map[string]interface{}
+	loc := h.location(r)
+	now := time.Now().In(loc)
+	h.setSourceDates(r, now, certSource)
+	var enhancedCertInfo *api.CertificateDetails
 	if len(certSource.Certificates) > 0 {
 		cert := certSource.Certificates[0]
+		notBefore, notAfter := cert.NotBefore.In(loc), cert.NotAfter.In(loc)
 
-		// Calculate time remaining in different units
-		timeUntilExpiry := cert.NotAfter.Sub(time.Now())
-		years := int(timeUntilExpiry.Hours() / (24 * 365))
-		months := int(timeUntilExpiry.Hours() / (24 * 30))
-		weeks := int(timeUntilExpiry.Hours() / (24 * 7))
+		// Calculate time remaining in different units; years and months
+		// are calendar ones, negative once the certificate has expired
+		months := calendarMonths(now, cert.NotAfter)
+		years := months / 12
+		weeks := int(cert.NotAfter.Sub(now).Hours() / (24 * 7))
 
-		enhancedCertInfo = map[string]interface{}{
-			"subject":       cert.Subject,
-			"issuer":        cert.Issuer,
-			"serial_number": cert.SerialNumber,
-			"is_ca":         cert.IsCA,
-			"is_expired":    cert.IsExpired,
-			"validity_period": map[string]interface{}{
-				"not_before":           cert.NotBefore,
-				"not_after":            cert.NotAfter,
-				"not_before_formatted": cert.NotBefore.Format("January 2, 2006 at 3:04 PM MST"),
-				"not_after_formatted":  cert.NotAfter.Format("January 2, 2006 at 3:04 PM MST"),
-				"valid_for_days":       int(cert.NotAfter.Sub(cert.NotBefore).Hours() / 24),
+		enhancedCertInfo = &api.CertificateDetails{
+			Subject:      cert.Subject,
+			Issuer:       cert.Issuer,
+			SerialNumber: cert.SerialNumber,
+			Fingerprint:  cert.Fingerprint,
+			IsCA:         cert.IsCA,
+			IsExpired:    cert.IsExpired,
+			ValidityPeriod: api.ValidityPeriod{
+				NotBefore:          cert.NotBefore,
+				NotAfter:           cert.NotAfter,
+				NotBeforeFormatted: notBefore.Format(dateTimeLayout),
+				NotAfterFormatted:  notAfter.Format(dateTimeLayout),
+				NotBeforeISO:       notBefore.Format(time.RFC3339),
+				NotAfterISO:        notAfter.Format(time.RFC3339),
+				ValidForDays:       int(cert.NotAfter.Sub(cert.NotBefore).Hours() / 24),
 			},
-			"expiry_info": map[string]interface{}{
-				"days_until_expiry":   cert.DaysUntilExp,
-				"weeks_until_expiry":  weeks,
-				"months_until_expiry": months,
-				"years_until_expiry":  years,
-				"expires_on":          cert.NotAfter.Format("January 2, 2006"),
-				"expires_on_weekday":  cert.NotAfter.Format("Monday, January 2, 2006"),
-				"time_remaining":      formatDuration(timeUntilExpiry),
+			ExpiryInfo: api.ExpiryInfo{
+				DaysUntilExpiry:   cert.DaysUntilExp,
+				WeeksUntilExpiry:  weeks,
+				MonthsUntilExpiry: months,
+				YearsUntilExpiry:  years,
+				ExpiresOn:         notAfter.Format(dateLayout),
+				ExpiresOnWeekday:  notAfter.Format(weekdayDateLayout),
+				ExpiresOnISO:      notAfter.Format(isoDateLayout),
+				TimeRemaining:     h.timeRemaining(now, cert.NotAfter),
 			},
-			"dns_names":    cert.DNSNames,
-			"ip_addresses": cert.IPAddresses,
-			"key_usage":    cert.KeyUsage,
+			DNSNames:    cert.DNSNames,
+			IPAddresses: cert.IPAddresses,
+			KeyUsage:    cert.KeyUsage,
 		}
 	}
 
 	// Create detailed response
-	response := map[string]interface{}{
-		"status":        "success",
-		"message":       "Cluster CA certificate expiry analysis",
-		"warning_days":  warningDays,
-		"analysis_date": time.Now().Format("January 2, 2006 at 3:04 PM MST"),
-		"certificate_info": map[string]interface{}{
-			"source":        certSource,
-			"warnings":      warnings,
-			"total_certs":   len(certSource.Certificates),
-			"enhanced_info": enhancedCertInfo,
+	response := api.ClusterCAExpiryResponse{
+		Status:          api.StatusSuccess,
+		Message:         "Cluster CA certificate expiry analysis",
+		WarningDays:     warningDays,
+		AnalysisDate:    now.Format(dateTimeLayout),
+		AnalysisDateISO: now.Format(time.RFC3339),
+		Timezone:        loc.String(),
+		CertificateInfo: api.ClusterCAReport{
+			Source:       apiCertificateSource(certSource),
+			Warnings:     warnings,
+			TotalCerts:   len(certSource.Certificates),
+			EnhancedInfo: enhancedCertInfo,
 		},
-		"summary": map[string]interface{}{
-			"certificates_analyzed": len(certSource.Certificates),
-			"warnings_found":        len(warnings),
-			"expires_within_days":   warningDays,
-			"status_summary":        getExpiryStatusSummary(certSource.Certificates, warningDays),
+		Summary: api.ClusterCASummary{
+			CertificatesAnalyzed: len(certSource.Certificates),
+			WarningsFound:        len(warnings),
+			ExpiresWithinDays:    warningDays,
+			StatusSummary:        getExpiryStatusSummary(certSource.Certificates, warningDays),
 		},
-		"notes": []string{
+		Notes: []string{
 			"This is the Kubernetes cluster CA certificate used to verify the API server",
 			"All pods automatically receive this certificate at /var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
 			fmt.Sprintf("Analysis performed with %d day warning threshold", warningDays),
@@ -201,39 +178,6 @@ func (h *Handler) HandleClusterCACertificateExpiry(w http.ResponseWriter, r *htt
 	json.NewEncoder(w).Encode(response)
 }
 
-// formatDuration formats a duration into a human-readable string
-func formatDuration(d time.Duration) string {
-	if d < 0 {
-		return "Expired"
-	}
-
-	days := int(d.Hours() / 24)
-	hours := int(d.Hours()) % 24
-
-	if days > 365 {
-		years := days / 365
-		remainingDays := days % 365
-		if remainingDays > 0 {
-			return fmt.Sprintf("%d years, %d days", years, remainingDays)
-		}
-		return fmt.Sprintf("%d years", years)
-	} else if days > 30 {
-		months := days / 30
-		remainingDays := days % 30
-		if remainingDays > 0 {
-			return fmt.Sprintf("%d months, %d days", months, remainingDays)
-		}
-		return fmt.Sprintf("%d months", months)
-	} else if days > 0 {
-		if hours > 0 {
-			return fmt.Sprintf("%d days, %d hours", days, hours)
-		}
-		return fmt.Sprintf("%d days", days)
-	} else {
-		return fmt.Sprintf("%d hours", hours)
-	}
-}
-
 // getExpiryStatusSummary provides a summary of certificate expiry status
 func getExpiryStatusSummary(certs []*utils.CertificateInfo, warningDays int) string {
 	if len(certs) == 0 {
@@ -259,3 +203,63 @@ func getExpiryStatusSummary(certs []*utils.CertificateInfo, warningDays int) str
 
 	return fmt.Sprintf("VALID (%d days remaining)", minDays)
 }
+
+// CARotationStatusHandler handles the /ca-rotation-status endpoint,
+// detecting an in-progress or recent cluster CA rotation by comparing the
+// kubeconfig CA, the CA EKS reports, and the kube-root-ca.crt configmap of
+// each namespace, and reporting which workloads have picked up the new CA
+func (h *Handler) CARotationStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cfg := h.cfg()
+	var extra []string
+	if param := r.URL.Query().Get("namespace"); param != "" {
+		extra = strings.Split(param, ",")
+	}
+	namespaces := k8s.RBACNamespaces(cfg.Kubernetes.DefaultNamespace, cfg.Scanner.Namespaces, extra...)
+
+	kubeconfigCA, err := h.clusterCA(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to get cluster CA: %v", err)
+		return
+	}
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	// EKS reports the new CA before it reaches the cluster; without the
+	// aws group or AWS credentials the rotation is detected in-cluster only
+	var eksCA string
+	var clusterCreated time.Time
+	var eksErr error
+	if cfg.EndpointGroupEnabled(config.EndpointGroupAWS) {
+		session, err := h.awsSession(ctx, r)
+		if err == nil {
+			eksCA, clusterCreated, err = session.ClusterCertificateAuthority(ctx)
+		}
+		eksErr = err
+	}
+
+	status, err := k8s.AnalyzeCARotation(ctx, client.GetClientset(), kubeconfigCA, eksCA, clusterCreated, namespaces)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ProblemParseFailure, "Failed to analyze cluster CA rotation: %v", err)
+		return
+	}
+	if eksErr != nil {
+		log.Printf("Warning: EKS cluster CA unavailable: %v", eksErr)
+		status.Sources = append(status.Sources, k8s.CASource{Source: k8s.CASourceEKS, Error: eksErr.Error()})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CARotationResponse{
+		Status:     api.StatusSuccess,
+		Rotation:   status,
+		Namespaces: namespaces,
+		Notes: []string{
+			"Pods read the service account ca.crt when they start; restart workloads marked not_picked_up to trust the new CA",
+			"bundle_pending means the namespace's kube-root-ca.crt has not received the new CA yet",
+			"Use ?namespace=a,b to check namespaces beyond the default and scanner namespaces",
+		},
+	})
+}
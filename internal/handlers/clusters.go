@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/scanner"
+	"k8s-web-service/pkg/api"
+)
+
+// clusterParam documents the cluster parameter, accepted by every endpoint
+const clusterParam = "cluster (optional, name of an entry of the clusters setting; default: the configured kubeconfig context)"
+
+// requestedCluster returns the cluster a request selects, or "" for the
+// default cluster
+func requestedCluster(r *http.Request) string {
+	return r.URL.Query().Get("cluster")
+}
+
+// client returns the shared Kubernetes client of the cluster a request
+// selects
+func (h *Handler) client(r *http.Request) (*k8s.Client, error) {
+	return h.clients.ClientFor(h.cfg(), requestedCluster(r))
+}
+
+// clusterCA returns the CA of the cluster a request selects, from its
+// kubeconfig entry, or for the default cluster as k8s.LoadClusterCA does
+func (h *Handler) clusterCA(r *http.Request) (string, error) {
+	if requestedCluster(r) == "" {
+		return k8s.LoadClusterCA(h.cfg())
+	}
+	client, err := h.client(r)
+	if err != nil {
+		return "", err
+	}
+	return client.GetEKSDetails().ClusterCA, nil
+}
+
+// scanResult returns the last background scan, or nil when background
+// scanning is disabled or the request selects another cluster than the
+// default one the scanner covers
+func (h *Handler) scanResult(r *http.Request) *scanner.Result {
+	if h.scanner == nil || requestedCluster(r) != "" {
+		return nil
+	}
+	return h.scanner.LastResult()
+}
+
+// requireCluster responds with 400 when the cluster parameter names a
+// cluster that is not configured
+func (h *Handler) requireCluster(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cluster := requestedCluster(r)
+		if _, ok := h.cfg().Clusters[cluster]; cluster != "" && !ok {
+			reason := "no clusters are configured"
+			if names := h.cfg().ClusterNames(); len(names) > 0 {
+				reason = "not one of " + strings.Join(names, ", ")
+			}
+			invalid := []InvalidParam{{Name: "cluster", Reason: fmt.Sprintf("%q is %s", cluster, reason)}}
+			problem := newProblem(r, http.StatusBadRequest, ProblemInvalidParameters, invalidParamsDetail(invalid))
+			problem.Extensions = map[string]interface{}{"invalid_params": invalid}
+			encodeProblem(w, problem)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// clusterList describes the clusters a request can select, for the root
+// endpoint
+func (h *Handler) clusterList() []api.ClusterEntry {
+	cfg := h.cfg()
+	clusters := make([]api.ClusterEntry, 0, len(cfg.Clusters))
+	for _, name := range cfg.ClusterNames() {
+		cluster := cfg.Clusters[name]
+		context := cluster.Context
+		if context == "" {
+			context = name
+		}
+		clusters = append(clusters, api.ClusterEntry{
+			Name:           name,
+			Context:        context,
+			ExampleURL:     fmt.Sprintf("%s/namespaces?cluster=%s", h.baseURL(), name),
+			KubeconfigPath: cluster.KubeconfigPath,
+			Region:         cluster.Region,
+		})
+	}
+	return clusters
+}
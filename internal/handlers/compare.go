@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+	"k8s-web-service/pkg/utils"
+)
+
+// maxCertificateUploadSize bounds the body of a certificate upload
+const maxCertificateUploadSize = 1 << 20
+
+// HandleCompare handles POST /compare, reporting whether an uploaded
+// certificate matches the one stored in a secret and the one served by a
+// Service, to verify that a rotation landed everywhere. The certificate is
+// the PEM request body or the multipart file field "file"; its first
+// certificate is compared.
+func (h *Handler) HandleCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeProblem(w, r, http.StatusMethodNotAllowed, ProblemMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+	ctx := r.Context()
+	cfg := h.cfg()
+
+	query := r.URL.Query()
+	namespace := query.Get("namespace")
+	if namespace == "" {
+		namespace = cfg.Kubernetes.DefaultNamespace
+	}
+	secret := query.Get("secret")
+	service := query.Get("service")
+	if secret == "" && service == "" {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Set secret, service, or both to compare against")
+		return
+	}
+	if service != "" && !cfg.EndpointGroupEnabled(config.EndpointGroupProbes) {
+		writeProblem(w, r, http.StatusServiceUnavailable, ProblemNotConfigured, "Comparing with a Service connects to it, and the probes endpoint group is disabled")
+		return
+	}
+	port, _ := strconv.Atoi(query.Get("port"))
+
+	uploaded, err := readUploadedCertificate(w, r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Invalid certificate upload: %v", err)
+		return
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+	clientset := client.GetClientset()
+
+	var comparisons []k8s.CertificateComparison
+	if secret != "" {
+		target := fmt.Sprintf("secret/%s/%s", namespace, secret)
+		if deployed, err := k8s.SecretLeafCertificate(ctx, clientset, namespace, secret); err != nil {
+			comparisons = append(comparisons, k8s.CertificateComparison{Target: target, Error: err.Error()})
+		} else {
+			comparisons = append(comparisons, k8s.CompareCertificates(target, uploaded, deployed))
+		}
+	}
+	if service != "" {
+		deployed, servedPort, err := k8s.ServedCertificate(ctx, clientset, namespace, service, int32(port))
+		target := fmt.Sprintf("service/%s/%s:%d", namespace, service, servedPort)
+		if err != nil {
+			comparisons = append(comparisons, k8s.CertificateComparison{Target: target, Error: err.Error()})
+		} else {
+			comparisons = append(comparisons, k8s.CompareCertificates(target, uploaded, deployed))
+		}
+	}
+
+	now := time.Now()
+	h.setCertificateDates(r, now, uploaded)
+	allMatch := true
+	for _, comparison := range comparisons {
+		allMatch = allMatch && comparison.Matches
+		h.setCertificateDates(r, now, comparison.Deployed)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.CompareResponse{
+		Status:      api.StatusSuccess,
+		Uploaded:    uploaded,
+		AllMatch:    allMatch,
+		Comparisons: apiComparisons(comparisons),
+		Notes: []string{
+			"Certificates match when their SHA-256 fingerprints are equal",
+			"expiry_delta_days is positive when the uploaded certificate expires after the deployed one",
+			"The served certificate is read over a TLS connection to the Service's cluster IP, which only succeeds from inside the cluster",
+		},
+	})
+}
+
+// readUploadedCertificate reads the first certificate of a PEM request body
+// or multipart file field "file"
+func readUploadedCertificate(w http.ResponseWriter, r *http.Request) (*utils.CertificateInfo, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCertificateUploadSize)
+
+	var data []byte
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxCertificateUploadSize); err != nil {
+			return nil, err
+		}
+		defer r.MultipartForm.RemoveAll()
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("missing file field: %w", err)
+		}
+		defer file.Close()
+		if data, err = io.ReadAll(file); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if data, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	certs, err := utils.ParseCertificateBundle(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/scanner"
+)
+
+// Summary headers of conditional routes, describing the last background scan
+const (
+	headerTotalWarnings = "X-Total-Warnings"
+	headerSoonestExpiry = "X-Soonest-Expiry"
+)
+
+// shapingParams are query parameters that change the representation of a
+// response beyond the background scan, so conditional GET requests with
+// them are always answered in full
+var shapingParams = []string{"namespace", "warning_days", "detailed", "query", "sort", "format"}
+
+// conditionalScan answers HEAD requests and conditional GET requests of an
+// expensive endpoint from the last background scan, without running it.
+// HEAD responds with the summary headers of the scan, or of its reports of
+// the namespaces of the namespace parameter. A GET whose If-Modified-Since
+// is not before the end of the scan gets 304 Not Modified, unless it
+// analyzes anew as scannedReport does, with refresh, verify, or cluster, or
+// one of the shapingParams asks for another response.
+func (h *Handler) conditionalScan(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			h.headScan(w, r)
+			return
+		case http.MethodGet:
+		default:
+			next(w, r)
+			return
+		}
+
+		if analyzesAnew(r) {
+			next(w, r)
+			return
+		}
+		query := r.URL.Query()
+		for _, param := range shapingParams {
+			if query.Has(param) {
+				next(w, r)
+				return
+			}
+		}
+		result := h.scanResult(r)
+		if result == nil {
+			next(w, r)
+			return
+		}
+
+		setScanHeaders(w, result, result.Reports)
+		if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !result.CompletedAt().Truncate(time.Second).After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// scannedReport returns a copy of the last background scan's report of a
+// namespace and when the scan completed, or nil when the namespace was not
+// scanned, the scan used another warning threshold or cluster, or
+// refresh=true asks for a new analysis. verify=true also analyzes anew,
+// since the scan keeps no certificate data to build chains from.
+func (h *Handler) scannedReport(r *http.Request, namespace string, warningDays int) (*k8s.NamespaceExpiryReport, time.Time) {
+	if analyzesAnew(r) {
+		return nil, time.Time{}
+	}
+	result := h.scanResult(r)
+	if result == nil {
+		return nil, time.Time{}
+	}
+	for _, report := range result.Reports {
+		if report.Namespace == namespace && report.WarningDays == warningDays {
+			return report.Clone(), result.CompletedAt()
+		}
+	}
+	return nil, time.Time{}
+}
+
+// analyzesAnew reports whether a request asks for an analysis now rather
+// than the last background scan's: refresh=true, verify=true, or a cluster,
+// which the background scan does not cover
+func analyzesAnew(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Get("refresh") == "true" || query.Get("verify") == "true" || requestedCluster(r) != ""
+}
+
+// headScan responds to a HEAD request with the summary headers of the last
+// background scan
+func (h *Handler) headScan(w http.ResponseWriter, r *http.Request) {
+	if h.scanner == nil {
+		writeProblem(w, r, http.StatusServiceUnavailable, ProblemNotConfigured, "Background scanning is disabled; HEAD summarizes the last background scan")
+		return
+	}
+	if cluster := requestedCluster(r); cluster != "" {
+		writeProblem(w, r, http.StatusNotFound, ProblemNotFound, "The background scan covers the default cluster, not cluster %s", cluster)
+		return
+	}
+	result := h.scanner.LastResult()
+	if result == nil {
+		w.Header().Set("Retry-After", "30")
+		writeProblem(w, r, http.StatusServiceUnavailable, ProblemNotConfigured, "No background scan has completed yet")
+		return
+	}
+
+	reports := result.Reports
+	if param := r.URL.Query().Get("namespace"); param != "" && param != allNamespacesValue {
+		reports = nil
+		for _, namespace := range strings.Split(param, ",") {
+			if namespace = strings.TrimSpace(namespace); namespace == "" {
+				continue
+			}
+			found := false
+			for _, report := range result.Reports {
+				if report.Namespace == namespace {
+					reports = append(reports, report)
+					found = true
+				}
+			}
+			if !found {
+				writeProblem(w, r, http.StatusNotFound, ProblemNotFound, "Namespace %s is not in the last background scan", namespace)
+				return
+			}
+		}
+	}
+
+	setScanHeaders(w, result, reports)
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !result.CompletedAt().Truncate(time.Second).After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// setScanHeaders sets Last-Modified to the end of a background scan, and the
+// summary headers to the total warnings and soonest certificate expiry of
+// its reports. X-Soonest-Expiry is left out when they have no certificates.
+func setScanHeaders(w http.ResponseWriter, result *scanner.Result, reports []*k8s.NamespaceExpiryReport) {
+	warnings := 0
+	var soonest time.Time
+	for _, report := range reports {
+		warnings += report.TotalWarnings
+		sources := append([]*k8s.CertificateSource{}, report.CustomResources...)
+		for _, pod := range report.Pods {
+			for _, source := range pod.CertSources {
+				sources = append(sources, source)
+			}
+		}
+		for _, source := range sources {
+			if source == nil {
+				continue
+			}
+			for _, cert := range source.Certificates {
+				if soonest.IsZero() || cert.NotAfter.Before(soonest) {
+					soonest = cert.NotAfter
+				}
+			}
+		}
+	}
+
+	w.Header().Set("Last-Modified", result.CompletedAt().UTC().Format(http.TimeFormat))
+	w.Header().Set(headerTotalWarnings, strconv.Itoa(warnings))
+	if !soonest.IsZero() {
+		w.Header().Set(headerSoonestExpiry, soonest.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/lifecycle"
+	"k8s-web-service/internal/scanner"
+)
+
+func TestConditionalScan(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+	cfg.SetDefaults()
+	store := config.NewStore(cfg)
+	s, err := scanner.New(store, nil)
+	if err != nil {
+		t.Fatalf("scanner.New: %v", err)
+	}
+	if _, err := s.ScanOnce(context.Background()); err != nil {
+		t.Fatalf("ScanOnce: %v", err)
+	}
+	h := New(store, nil, lifecycle.NewTracker(), s)
+	handler := h.conditionalScan(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	since := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"unchanged scan", "", http.StatusNotModified},
+		{"refresh", "?refresh=true", http.StatusOK},
+		{"verify", "?verify=true", http.StatusOK},
+		{"cluster", "?cluster=staging", http.StatusOK},
+		{"namespace", "?namespace=default", http.StatusOK},
+		{"warning_days", "?warning_days=7", http.StatusOK},
+		{"detailed", "?detailed=true", http.StatusOK},
+		{"query", "?query=namespaces", http.StatusOK},
+		{"sort", "?sort=expiry", http.StatusOK},
+		{"format", "?format=csv", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/certificate-expiry"+tt.query, nil)
+			req.Header.Set("If-Modified-Since", since)
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/certificate-expiry", nil)
+	req.Header.Set("If-Modified-Since", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status after a newer scan = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
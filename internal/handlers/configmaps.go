@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// TrustBundlesHandler handles the /configmaps/trust-bundles endpoint,
+// reporting configmaps that likely hold CA bundles with their size, soonest
+// expiring member, and expired roots
+func (h *Handler) TrustBundlesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	namespace := h.cfg().Kubernetes.DefaultNamespace
+	if ns := r.URL.Query().Get("namespace"); ns != "" {
+		namespace = ns
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	bundles, err := k8s.FindTrustBundles(r.Context(), client.GetClientset(), namespace)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "%v", err)
+		return
+	}
+
+	totalCertificates := 0
+	var withExpiredRoots []string
+	for _, bundle := range bundles {
+		totalCertificates += bundle.TotalCertificates
+		if len(bundle.ExpiredRoots) > 0 {
+			withExpiredRoots = append(withExpiredRoots, bundle.Name)
+		}
+	}
+
+	total := len(bundles)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(bundles) > limit
+	if truncated {
+		bundles = bundles[:limit]
+	}
+
+	response := TrustBundlesResponse{
+		Status:    api.StatusSuccess,
+		Namespace: namespace,
+		Summary: TrustBundlesSummary{
+			TrustBundles:            total,
+			TotalCertificates:       totalCertificates,
+			BundlesWithExpiredRoots: withExpiredRoots,
+		},
+		TrustBundles: bundles,
+		Notes: []string{
+			"Configmaps are detected by well-known name (kube-root-ca.crt), name pattern (*-ca-bundle, *-ca), or PEM content holding a CA or several certificates",
+			"Expired roots are usually harmless only if no chain still depends on them; remove them to avoid confusing validation",
+		},
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// TrustStoreValidationHandler handles the /trust-store-validation endpoint,
+// checking that the CA bundles mounted into each pod of a namespace, the
+// service account ca.crt and trust bundle configmaps, validate the chain the
+// API server serves now
+func (h *Handler) TrustStoreValidationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	namespace := h.cfg().Kubernetes.DefaultNamespace
+	if ns := r.URL.Query().Get("namespace"); ns != "" {
+		namespace = ns
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	chain, endpoint, err := client.APIServerChain(ctx)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusBadGateway, err, ProblemClusterUnreachable, "Failed to read the API server certificate chain: %v", err)
+		return
+	}
+	report, err := k8s.ValidatePodTrustStores(ctx, client.GetClientset(), namespace, chain)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "%v", err)
+		return
+	}
+	report.APIServer = endpoint
+	now := time.Now()
+	h.setCertificateDates(r, now, report.ServedChain...)
+
+	limit := maxResults(r)
+	truncated := limit > 0 && len(report.Pods) > limit
+	if truncated {
+		report.Pods = report.Pods[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := TrustStoreValidationResponse{
+		Status:     api.StatusSuccess,
+		Namespace:  namespace,
+		TrustStore: report,
+		Notes: []string{
+			"The service account ca.crt is projected from the kube-root-ca.crt configmap; trust bundle configmaps are detected as in /configmaps/trust-bundles",
+			"A bundle is valid when it verifies the API server's serving chain for server authentication; hostnames are not checked",
+			"truncated marks bundles with a cut-off or unparseable PEM block, a common cause of partial trust stores",
+		},
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"k8s-web-service/internal/analyzer"
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/telemetry"
+	"k8s-web-service/pkg/api"
+)
+
+// The functions of this file convert the results of the internal packages
+// to the response types of pkg/api, which clients import without the
+// internal packages. Nil pointers, slices, and maps stay nil, so responses
+// encode the same null values.
+
+// apiCertificateSource converts a certificate source
+func apiCertificateSource(source *k8s.CertificateSource) *api.CertificateSource {
+	if source == nil {
+		return nil
+	}
+	return &api.CertificateSource{
+		Type:          source.Type,
+		Name:          source.Name,
+		Namespace:     source.Namespace,
+		Key:           source.Key,
+		Certificates:  source.Certificates,
+		BundleSummary: source.BundleSummary,
+		ParseErrors:   source.ParseErrors,
+		PrivateKey:    source.PrivateKey,
+		Verification:  source.Verification,
+		Error:         source.Error,
+	}
+}
+
+// apiCertificateSources converts a list of certificate sources
+func apiCertificateSources(sources []*k8s.CertificateSource) []*api.CertificateSource {
+	if sources == nil {
+		return nil
+	}
+	converted := make([]*api.CertificateSource, len(sources))
+	for i, source := range sources {
+		converted[i] = apiCertificateSource(source)
+	}
+	return converted
+}
+
+// apiCertificateSourceMap converts certificate sources by name
+func apiCertificateSourceMap(sources map[string]*k8s.CertificateSource) map[string]*api.CertificateSource {
+	if sources == nil {
+		return nil
+	}
+	converted := make(map[string]*api.CertificateSource, len(sources))
+	for name, source := range sources {
+		converted[name] = apiCertificateSource(source)
+	}
+	return converted
+}
+
+// apiFindings converts analyzer findings
+func apiFindings(findings []analyzer.Finding) []api.Finding {
+	if findings == nil {
+		return nil
+	}
+	converted := make([]api.Finding, len(findings))
+	for i, finding := range findings {
+		converted[i] = api.Finding{
+			Analyzer: finding.Analyzer,
+			Severity: finding.Severity,
+			Message:  finding.Message,
+			Source:   api.FindingSource(finding.Source),
+			Subject:  finding.Subject,
+		}
+	}
+	return converted
+}
+
+// apiComputeInfo converts where a pod runs
+func apiComputeInfo(compute *k8s.ComputeInfo) *api.ComputeInfo {
+	if compute == nil {
+		return nil
+	}
+	converted := api.ComputeInfo(*compute)
+	return &converted
+}
+
+// apiPodRuntime converts the runtime status of a pod
+func apiPodRuntime(runtime *k8s.PodRuntime) *api.PodRuntime {
+	if runtime == nil {
+		return nil
+	}
+	converted := &api.PodRuntime{
+		Phase:        runtime.Phase,
+		Ready:        runtime.Ready,
+		Restarts:     runtime.Restarts,
+		CrashLooping: runtime.CrashLooping,
+	}
+	if runtime.Containers != nil {
+		converted.Containers = make([]api.ContainerRuntime, len(runtime.Containers))
+		for i, container := range runtime.Containers {
+			converted.Containers[i] = api.ContainerRuntime(container)
+		}
+	}
+	return converted
+}
+
+// apiPodOrigin converts the workload and deployment tool owning a pod
+func apiPodOrigin(origin *k8s.PodOrigin) *api.PodOrigin {
+	if origin == nil {
+		return nil
+	}
+	return &api.PodOrigin{
+		Workload:  api.WorkloadRef(origin.Workload),
+		ManagedBy: origin.ManagedBy,
+		Helm:      (*api.HelmOrigin)(origin.Helm),
+		ArgoCD:    (*api.ArgoCDOrigin)(origin.ArgoCD),
+	}
+}
+
+// apiExpiryReport converts the expiry report of a namespace
+func apiExpiryReport(report *k8s.NamespaceExpiryReport) *api.NamespaceExpiryReport {
+	if report == nil {
+		return nil
+	}
+	return &api.NamespaceExpiryReport{
+		Namespace:         report.Namespace,
+		WarningDays:       report.WarningDays,
+		TotalPods:         report.TotalPods,
+		TotalCertificates: report.TotalCertificates,
+		TotalWarnings:     report.TotalWarnings,
+		Pods:              apiPodExpiryInfo(report.Pods),
+		CustomResources:   apiCertificateSources(report.CustomResources),
+		Warnings:          report.Warnings,
+		Findings:          apiFindings(report.Findings),
+	}
+}
+
+// apiPodExpiryInfo converts the pods of an expiry report
+func apiPodExpiryInfo(pods []k8s.PodExpiryInfo) []api.PodExpiryInfo {
+	if pods == nil {
+		return nil
+	}
+	converted := make([]api.PodExpiryInfo, len(pods))
+	for i, pod := range pods {
+		converted[i] = api.PodExpiryInfo{
+			PodName:      pod.PodName,
+			Compute:      apiComputeInfo(pod.Compute),
+			Runtime:      apiPodRuntime(pod.Runtime),
+			Origin:       apiPodOrigin(pod.Origin),
+			CertSources:  apiCertificateSourceMap(pod.CertSources),
+			Warnings:     pod.Warnings,
+			WarningCount: pod.WarningCount,
+			CertCount:    pod.CertCount,
+			Findings:     apiFindings(pod.Findings),
+		}
+	}
+	return converted
+}
+
+// apiScanReport converts a consolidated scan report
+func apiScanReport(report *k8s.ScanReport) *api.ScanReport {
+	if report == nil {
+		return nil
+	}
+	converted := &api.ScanReport{
+		Namespace:   report.Namespace,
+		WarningDays: report.WarningDays,
+		Summary: api.ScanSummary{
+			Status:            report.Summary.Status,
+			Sections:          report.Summary.Sections,
+			TotalCertificates: report.Summary.TotalCertificates,
+			BySection:         report.Summary.BySection,
+			Expired:           report.Summary.Expired,
+			ExpiringSoon:      report.Summary.ExpiringSoon,
+			FailedSections:    report.Summary.FailedSections,
+			SoonestExpiry:     (*api.ScanExpiry)(report.Summary.SoonestExpiry),
+		},
+		Pods:      apiExpiryReport(report.Pods),
+		Secrets:   apiCertificateSources(report.Secrets),
+		ClusterCA: apiCertificateSource(report.ClusterCA),
+		Errors:    report.Errors,
+	}
+	if report.Ingress != nil {
+		converted.Ingress = make([]api.InClusterCertificate, len(report.Ingress))
+		for i, ingress := range report.Ingress {
+			converted.Ingress[i] = api.InClusterCertificate(ingress)
+		}
+	}
+	if report.Webhooks != nil {
+		converted.Webhooks = make([]api.WebhookCABundle, len(report.Webhooks))
+		for i, webhook := range report.Webhooks {
+			converted.Webhooks[i] = api.WebhookCABundle(webhook)
+		}
+	}
+	return converted
+}
+
+// apiComparisons converts certificate comparisons
+func apiComparisons(comparisons []k8s.CertificateComparison) []api.CertificateComparison {
+	if comparisons == nil {
+		return nil
+	}
+	converted := make([]api.CertificateComparison, len(comparisons))
+	for i, comparison := range comparisons {
+		converted[i] = api.CertificateComparison(comparison)
+	}
+	return converted
+}
+
+// apiAuthChecks converts the self-test checks by name
+func apiAuthChecks(checks map[string]k8s.AuthCheck) map[string]api.AuthCheck {
+	if checks == nil {
+		return nil
+	}
+	converted := make(map[string]api.AuthCheck, len(checks))
+	for name, check := range checks {
+		converted[name] = api.AuthCheck{
+			Status:     check.Status,
+			Namespace:  check.Namespace,
+			Permission: check.Permission,
+			Optional:   check.Optional,
+			Count:      check.Count,
+			Error:      check.Error,
+		}
+	}
+	return converted
+}
+
+// apiMissingPermissions converts the permissions the self-test found missing
+func apiMissingPermissions(missing []k8s.MissingPermission) []api.MissingPermission {
+	if missing == nil {
+		return nil
+	}
+	converted := make([]api.MissingPermission, len(missing))
+	for i, permission := range missing {
+		converted[i] = api.MissingPermission{
+			Namespace:      permission.Namespace,
+			RBACPermission: api.RBACPermission(permission.RBACPermission),
+			Optional:       permission.Optional,
+		}
+	}
+	return converted
+}
+
+// apiRBACReport converts the effective access of the service
+func apiRBACReport(report *k8s.RBACReport) *api.RBACReport {
+	if report == nil {
+		return nil
+	}
+	converted := &api.RBACReport{
+		Namespaces: report.Namespaces,
+		Incomplete: report.Incomplete,
+		Errors:     report.Errors,
+	}
+	if report.Matrix != nil {
+		converted.Matrix = make([]api.RBACMatrixRow, len(report.Matrix))
+		for i, row := range report.Matrix {
+			converted.Matrix[i] = api.RBACMatrixRow{
+				RBACPermission: api.RBACPermission(row.RBACPermission),
+				ClusterAccess:  row.ClusterAccess,
+				Namespaces:     row.Namespaces,
+			}
+		}
+	}
+	if report.Rules != nil {
+		converted.Rules = make(map[string][]api.RBACRule, len(report.Rules))
+		for namespace, rules := range report.Rules {
+			var convertedRules []api.RBACRule
+			if rules != nil {
+				convertedRules = make([]api.RBACRule, len(rules))
+				for i, rule := range rules {
+					convertedRules[i] = api.RBACRule(rule)
+				}
+			}
+			converted.Rules[namespace] = convertedRules
+		}
+	}
+	return converted
+}
+
+// apiCallStats converts the latency and errors of timed operations
+func apiCallStats(stats []telemetry.CallStats) []api.CallStats {
+	if stats == nil {
+		return nil
+	}
+	converted := make([]api.CallStats, len(stats))
+	for i, stat := range stats {
+		converted[i] = api.CallStats{
+			Call:         api.Call(stat.Call),
+			Count:        stat.Count,
+			Errors:       stat.Errors,
+			TotalSeconds: stat.TotalSeconds,
+			MeanSeconds:  stat.MeanSeconds,
+			P50Seconds:   stat.P50Seconds,
+			P95Seconds:   stat.P95Seconds,
+			MaxSeconds:   stat.MaxSeconds,
+		}
+	}
+	return converted
+}
+
+// apiEffectiveConfig converts the configuration, whose credentials the
+// caller has masked
+func apiEffectiveConfig(cfg config.Config) api.EffectiveConfig {
+	converted := api.EffectiveConfig{
+		ReadOnly:   cfg.ReadOnly,
+		AWS:        api.AWSConfig(cfg.AWS),
+		Kubernetes: api.KubernetesConfig(cfg.Kubernetes),
+		Server: api.ServerConfig{
+			Port:            cfg.Server.Port,
+			Host:            cfg.Server.Host,
+			Timezone:        cfg.Server.Timezone,
+			Durations:       api.DurationFormat(cfg.Server.Durations),
+			ReadTimeout:     cfg.Server.ReadTimeout,
+			WriteTimeout:    cfg.Server.WriteTimeout,
+			ShutdownTimeout: cfg.Server.ShutdownTimeout,
+			TLS:             api.TLSConfig(cfg.Server.TLS),
+		},
+		Scanner:   api.ScannerConfig(cfg.Scanner),
+		Notifiers: api.NotifiersConfig(cfg.Notifiers),
+		Images:    api.ImagesConfig(cfg.Images),
+		Bundles:   api.BundlesConfig(cfg.Bundles),
+		Agent:     api.AgentConfig(cfg.Agent),
+		Admin:     api.AdminConfig(cfg.Admin),
+		Admission: api.AdmissionConfig(cfg.Admission),
+		Logging:   api.LoggingConfig(cfg.Logging),
+		Security:  api.SecurityConfig(cfg.Security),
+		Endpoints: cfg.Endpoints,
+	}
+	if cfg.Clusters != nil {
+		converted.Clusters = make(map[string]api.ClusterAWS, len(cfg.Clusters))
+		for name, cluster := range cfg.Clusters {
+			converted.Clusters[name] = api.ClusterAWS(cluster)
+		}
+	}
+	if cfg.CustomResources != nil {
+		converted.CustomResources = make([]api.CustomResource, len(cfg.CustomResources))
+		for i, resource := range cfg.CustomResources {
+			converted.CustomResources[i] = api.CustomResource(resource)
+		}
+	}
+	if cfg.Policies != nil {
+		converted.Policies = make([]api.Policy, len(cfg.Policies))
+		for i, policy := range cfg.Policies {
+			converted.Policies[i] = api.Policy(policy)
+		}
+	}
+	if cfg.Limits != nil {
+		converted.Limits = make(map[string]api.EndpointLimit, len(cfg.Limits))
+		for group, limit := range cfg.Limits {
+			converted.Limits[group] = api.EndpointLimit(limit)
+		}
+	}
+	return converted
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/lifecycle"
+	"k8s-web-service/internal/telemetry"
+)
+
+// TestConversionsEncodeLikeInternalTypes checks that the pkg/api copies of
+// the internal types encode the same JSON, so responses did not change when
+// pkg/api stopped importing the internal packages
+func TestConversionsEncodeLikeInternalTypes(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+	cfg.AWS.AccessKeyID = "AKIAEXAMPLE"
+	cfg.Clusters = map[string]config.ClusterAWS{"prod": {Region: "eu-west-1", RoleARN: "arn:aws:iam::123456789012:role/scanner"}}
+	cfg.Policies = []config.Policy{{Name: "prod", Namespaces: []string{"prod-*"}, MinDaysRemaining: 30}}
+	cfg.CustomResources = []config.CustomResource{{GVK: "kafka.strimzi.io/v1beta2/Kafka", JSONPath: "{.status.listeners[*].certificates[*]}"}}
+	h := New(config.NewStore(cfg), nil, lifecycle.NewTracker(), nil)
+
+	client, err := h.client(httptest.NewRequest(http.MethodGet, "/scan", nil))
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	report := k8s.RunScan(context.Background(), client, "default", k8s.ScanSections, 30)
+	if report.Pods == nil || len(report.Pods.Pods) == 0 || len(report.Secrets) == 0 {
+		t.Fatalf("scan of the fixtures found no pods or secrets: %+v", report)
+	}
+
+	count := 3
+	rbac := &k8s.RBACReport{
+		Namespaces: []string{"default"},
+		Matrix: []k8s.RBACMatrixRow{{
+			RBACPermission: k8s.RBACPermission{Resource: "secrets", Verb: "list", UsedBy: "/scan"},
+			Namespaces:     map[string]string{"default": k8s.AccessAllowed},
+		}},
+		Rules: map[string][]k8s.RBACRule{"default": {{Verbs: []string{"get"}, Resources: []string{"pods"}}}, "empty": nil},
+	}
+	checks := map[string]k8s.AuthCheck{"list_pods": {Name: "list_pods", Status: "passed", Namespace: "default", Count: &count}}
+	missing := []k8s.MissingPermission{{Namespace: "default", RBACPermission: k8s.RBACPermission{Resource: "secrets", Verb: "get"}, Optional: true}}
+	calls := []telemetry.CallStats{{Call: telemetry.Call{System: "aws", Service: "sts", Operation: "GetCallerIdentity"}, Count: 2, MeanSeconds: 0.5}}
+
+	tests := []struct {
+		name      string
+		internal  interface{}
+		converted interface{}
+	}{
+		{"scan report", report, apiScanReport(report)},
+		{"expiry report", report.Pods, apiExpiryReport(report.Pods)},
+		{"certificate sources", report.Secrets, apiCertificateSources(report.Secrets)},
+		{"effective config", cfg.Redacted(), apiEffectiveConfig(cfg.Redacted())},
+		{"rbac report", rbac, apiRBACReport(rbac)},
+		{"auth checks", checks, apiAuthChecks(checks)},
+		{"missing permissions", missing, apiMissingPermissions(missing)},
+		{"call stats", calls, apiCallStats(calls)},
+		{"nil expiry report", (*k8s.NamespaceExpiryReport)(nil), apiExpiryReport(nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := json.Marshal(tt.internal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(tt.converted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("converted JSON differs:\n got: %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
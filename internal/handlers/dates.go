@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/utils"
+)
+
+// tzParam documents the tz parameter of the endpoints formatting dates
+const tzParam = "tz (optional, IANA time zone of dates, default: server.timezone)"
+
+// Layouts of human-readable dates in responses
+const (
+	dateTimeLayout    = "January 2, 2006 at 3:04 PM MST"
+	dateLayout        = "January 2, 2006"
+	weekdayDateLayout = "Monday, January 2, 2006"
+	isoDateLayout     = "2006-01-02"
+)
+
+// location returns the time zone of formatted dates: the tz query parameter,
+// then server.timezone, then the server's local zone. Both are validated
+// beforehand, so a zone that fails to load falls back to the local zone.
+func (h *Handler) location(r *http.Request) *time.Location {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		name = h.cfg().Server.Timezone
+	}
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// durationUnitNames are the singular, plural, and compact names of the
+// config.DurationUnits
+var durationUnitNames = map[string][3]string{
+	"years":   {"year", "years", "y"},
+	"months":  {"month", "months", "m"},
+	"weeks":   {"week", "weeks", "w"},
+	"days":    {"day", "days", "d"},
+	"hours":   {"hour", "hours", "h"},
+	"minutes": {"minute", "minutes", "min"},
+}
+
+// durationUnitSizes are the lengths of the units that are not counted on
+// the calendar
+var durationUnitSizes = map[string]time.Duration{
+	"weeks":   7 * 24 * time.Hour,
+	"days":    24 * time.Hour,
+	"hours":   time.Hour,
+	"minutes": time.Minute,
+}
+
+// formatDuration writes the time between start and end in the configured
+// duration format. The order of start and end does not matter; callers say
+// whether the time is past.
+func (h *Handler) formatDuration(start, end time.Time) string {
+	return formatSpan(start, end, h.cfg().Server.Durations)
+}
+
+// timeRemaining writes the time left until expiry, or how long ago it
+// expired
+func (h *Handler) timeRemaining(now, expiry time.Time) string {
+	if expiry.Before(now) {
+		return "Expired " + h.formatDuration(expiry, now) + " ago"
+	}
+	return h.formatDuration(now, expiry)
+}
+
+// setCertificateDates moves the validity dates of certificates into the
+// time zone of the request and fills in their time remaining
+func (h *Handler) setCertificateDates(r *http.Request, now time.Time, certs ...*utils.CertificateInfo) {
+	loc := h.location(r)
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+		cert.NotBefore = cert.NotBefore.In(loc)
+		cert.NotAfter = cert.NotAfter.In(loc)
+		cert.TimeRemaining = h.timeRemaining(now, cert.NotAfter)
+	}
+}
+
+// setSourceDates sets the certificate dates of certificate sources
+func (h *Handler) setSourceDates(r *http.Request, now time.Time, sources ...*k8s.CertificateSource) {
+	for _, source := range sources {
+		if source != nil {
+			h.setCertificateDates(r, now, source.Certificates...)
+		}
+	}
+}
+
+// formatSpan breaks the time between start and end into units. Years and
+// months are counted on the calendar in UTC: a month after January 31 is
+// the last day of February, and a year after February 29 is February 28.
+func formatSpan(start, end time.Time, format config.DurationFormat) string {
+	if end.Before(start) {
+		start, end = end, start
+	}
+	start, end = start.UTC(), end.UTC()
+
+	var units []string
+	seen := make(map[string]bool)
+	for _, unit := range format.Units {
+		if config.DurationUnitIndex(unit) >= 0 && !seen[unit] {
+			seen[unit] = true
+			units = append(units, unit)
+		}
+	}
+	if len(units) == 0 {
+		units = []string{"days"}
+	}
+	sort.Slice(units, func(i, j int) bool {
+		return config.DurationUnitIndex(units[i]) < config.DurationUnitIndex(units[j])
+	})
+
+	counts := make([]int, len(units))
+	totalMonths := monthsBetween(start, end)
+	offset := 0
+	cursor := start
+	for i, unit := range units {
+		switch unit {
+		case "years":
+			counts[i] = (totalMonths - offset) / 12
+			offset += counts[i] * 12
+			cursor = addMonths(start, offset)
+		case "months":
+			counts[i] = totalMonths - offset
+			offset = totalMonths
+			cursor = addMonths(start, offset)
+		default:
+			size := durationUnitSizes[unit]
+			n := end.Sub(cursor) / size
+			counts[i] = int(n)
+			cursor = cursor.Add(n * size)
+		}
+	}
+
+	precision := format.Precision
+	if precision < 1 {
+		precision = 1
+	}
+	var parts []string
+	first := -1
+	for i, count := range counts {
+		if first < 0 && count > 0 {
+			first = i
+		}
+		if first < 0 || i >= first+precision {
+			continue
+		}
+		if count > 0 {
+			parts = append(parts, formatUnit(count, units[i], format.Compact))
+		}
+	}
+	if len(parts) == 0 {
+		return formatUnit(0, units[len(units)-1], format.Compact)
+	}
+	if format.Compact {
+		return strings.Join(parts, " ")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatUnit writes a count of a unit, such as "1 day", "3 days", or "3d"
+func formatUnit(count int, unit string, compact bool) string {
+	names := durationUnitNames[unit]
+	switch {
+	case compact:
+		return fmt.Sprintf("%d%s", count, names[2])
+	case count == 1:
+		return fmt.Sprintf("%d %s", count, names[0])
+	default:
+		return fmt.Sprintf("%d %s", count, names[1])
+	}
+}
+
+// monthsBetween counts the whole calendar months from start to end, which
+// must not be before start
+func monthsBetween(start, end time.Time) int {
+	months := (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())
+	if months > 0 && addMonths(start, months).After(end) {
+		months--
+	}
+	return months
+}
+
+// addMonths adds months to t, keeping the day of the month where the target
+// month is long enough and using its last day otherwise
+func addMonths(t time.Time, months int) time.Time {
+	month := int(t.Month()) - 1 + months
+	year := t.Year() + month/12
+	month = month%12 + 1
+	day := t.Day()
+	if last := time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, t.Location()).Day(); day > last {
+		day = last
+	}
+	return time.Date(year, time.Month(month), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// calendarMonths counts the whole calendar months from now until t, negative
+// when t is past
+func calendarMonths(now, t time.Time) int {
+	if t.Before(now) {
+		return -monthsBetween(t.UTC(), now.UTC())
+	}
+	return monthsBetween(now.UTC(), t.UTC())
+}
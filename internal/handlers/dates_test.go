@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/lifecycle"
+)
+
+func TestFormatSpan(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+	monthsDays := config.DurationFormat{Units: []string{"months", "days"}, Precision: 2}
+	tests := []struct {
+		name       string
+		start, end time.Time
+		format     config.DurationFormat
+		months     int
+		want       string
+	}{
+		{
+			name:   "one month after January 31",
+			start:  date(2026, time.January, 31),
+			end:    date(2026, time.February, 28),
+			format: monthsDays,
+			months: 1,
+			want:   "1 month",
+		},
+		{
+			name:   "one year after February 29",
+			start:  date(2024, time.February, 29),
+			end:    date(2025, time.February, 28),
+			format: config.DurationFormat{Units: []string{"years", "months", "days"}, Precision: 3},
+			months: 12,
+			want:   "1 year",
+		},
+		{
+			name:   "February 29 to March 28",
+			start:  date(2024, time.February, 29),
+			end:    date(2024, time.March, 28),
+			format: monthsDays,
+			months: 0,
+			want:   "28 days",
+		},
+		{
+			name:   "across a month end",
+			start:  date(2026, time.January, 30),
+			end:    date(2026, time.March, 2),
+			format: monthsDays,
+			months: 1,
+			want:   "1 month, 2 days",
+		},
+		{
+			name:   "compact across a year end",
+			start:  date(2025, time.November, 15),
+			end:    date(2027, time.January, 20),
+			format: config.DurationFormat{Units: []string{"years", "months", "days"}, Precision: 2, Compact: true},
+			months: 14,
+			want:   "1y 2m",
+		},
+		{
+			name:   "already expired",
+			start:  date(2026, time.March, 2),
+			end:    date(2026, time.January, 30),
+			format: monthsDays,
+			months: -1,
+			want:   "1 month, 2 days",
+		},
+		{
+			name:   "under the smallest unit",
+			start:  date(2026, time.January, 30),
+			end:    date(2026, time.January, 30).Add(time.Hour),
+			format: monthsDays,
+			months: 0,
+			want:   "0 days",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSpan(tt.start, tt.end, tt.format); got != tt.want {
+				t.Errorf("formatSpan = %q, want %q", got, tt.want)
+			}
+			if got := calendarMonths(tt.start, tt.end); got != tt.months {
+				t.Errorf("calendarMonths = %d, want %d", got, tt.months)
+			}
+		})
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		start  time.Time
+		months int
+		want   time.Time
+	}{
+		{time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC), 1, time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC), 1, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), 12, time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), 48, time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC), 4, time.Date(2027, time.February, 28, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := addMonths(tt.start, tt.months); !got.Equal(tt.want) {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.start.Format(isoDateLayout), tt.months, got.Format(isoDateLayout), tt.want.Format(isoDateLayout))
+		}
+	}
+}
+
+func TestCertificateDatesTimezone(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+	cfg.Server.Timezone = "UTC"
+	handler := newTestHandler(cfg)
+	paths := []string{
+		"/certificate-expiry?namespace=default",
+		"/pod-certificates?namespace=default&detailed=true",
+		"/secrets-certificates?namespace=default",
+		"/scan?namespace=default",
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			for tz, offset := range map[string]string{"": `Z"`, "Asia/Kolkata": `+05:30"`} {
+				target := path
+				if tz != "" {
+					target += "&tz=" + tz
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("GET %s = %d: %s", target, rec.Code, rec.Body)
+				}
+				if body := rec.Body.String(); !strings.Contains(body, `"not_after":"`) || !strings.Contains(body, offset) {
+					t.Errorf("GET %s: no not_after ending in %s: %s", target, offset, body)
+				}
+			}
+		})
+	}
+}
+
+func TestFormattedDatesTimezone(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+	cfg.Server.Timezone = "UTC"
+	cfg.SetDefaults()
+	mux := http.NewServeMux()
+	New(config.NewStore(cfg), nil, lifecycle.NewTracker(), nil).Register(mux)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/cluster-ca-expiry", []string{`"timezone":"UTC"`, ` UTC"`, `Z"`}},
+		{"/cluster-ca-expiry?tz=Asia/Kolkata", []string{`"timezone":"Asia/Kolkata"`, ` IST"`, `+05:30"`}},
+		{"/api-docs?tz=Asia/Kolkata", []string{` IST"`, `+05:30"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d: %s", tt.path, rec.Code, rec.Body)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(rec.Body.String(), s) {
+					t.Errorf("GET %s lacks %s: %s", tt.path, s, rec.Body)
+				}
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cluster-ca-expiry?tz=Mars/Olympus", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GET with an unknown time zone = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -1,214 +1,246 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"runtime"
+	runtimedebug "runtime/debug"
 	"strings"
+	"text/tabwriter"
+	"time"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
+	"k8s-web-service/internal/auth"
 	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/telemetry"
+	"k8s-web-service/pkg/api"
 )
 
-// DebugHandler handles the /debug endpoint
+// debugModules are the dependencies whose versions /debug reports
+var debugModules = []string{
+	"k8s.io/client-go",
+	"k8s.io/apimachinery",
+	"github.com/aws/aws-sdk-go-v2",
+	"github.com/aws/aws-sdk-go-v2/config",
+	"github.com/aws/aws-sdk-go-v2/service/sts",
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds",
+	"sigs.k8s.io/controller-runtime",
+}
+
+// DebugHandler handles the /debug endpoint, reporting what would otherwise
+// be gathered inside the pod: the configuration and kubeconfig in use, the
+// EKS token and how it was generated, the AWS credential provider, IMDS and
+// aws-iam-authenticator availability, dependency versions, and the age of
+// cached data, and the latency of the AWS and Kubernetes calls made so far
 func (h *Handler) DebugHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-
-	debugInfo := map[string]interface{}{
-		"status": "success",
-	}
-
-	// AWS Configuration Status
-	awsConfigStatus := map[string]interface{}{
-		"has_access_key":    h.config.AWS.AccessKeyID != "",
-		"has_secret_key":    h.config.AWS.SecretAccessKey != "",
-		"region":            h.config.AWS.Region,
-		"validation_result": "unknown",
-	}
-
-	if err := h.config.ValidateAWSConfig(); err != nil {
-		awsConfigStatus["validation_result"] = fmt.Sprintf("failed: %v", err)
-	} else {
-		awsConfigStatus["validation_result"] = "passed"
+	cfg := h.cfg()
+
+	response := api.DebugResponse{
+		Status: api.StatusSuccess,
+		AWSConfig: api.AWSConfigStatus{
+			HasAccessKey:     cfg.AWS.AccessKeyID != "",
+			HasSecretKey:     cfg.AWS.SecretAccessKey != "",
+			Region:           cfg.AWS.Region,
+			ValidationResult: "passed",
+		},
+		// Effective configuration after merging file, environment, and flags
+		EffectiveConfig: apiEffectiveConfig(cfg.Redacted()),
+	}
+	if err := cfg.ValidateAWSConfig(); err != nil {
+		response.AWSConfig.ValidationResult = fmt.Sprintf("failed: %v", err)
+	}
+
+	// Kubeconfig file actually used and how it was selected
+	response.Kubeconfig.Path, response.Kubeconfig.Source = k8s.ResolveKubeconfigPath(cfg)
+	cluster := requestedCluster(r)
+	switch {
+	case cluster != "" && cfg.Clusters[cluster].KubeconfigPath != "":
+		response.Kubeconfig.Path, response.Kubeconfig.Source = cfg.Clusters[cluster].KubeconfigPath, "clusters."+cluster+".kubeconfig_path"
+	case cluster == "" && k8s.InCluster(cfg):
+		response.Kubeconfig.Path, response.Kubeconfig.Source = "", "in-cluster service account"
 	}
 
-	debugInfo["aws_config"] = awsConfigStatus
-
-	// Try to get AWS caller identity
-	client, err := k8s.NewClient(h.config)
+	// The shared client of the cluster, so the token described is the one
+	// requests use
+	client, err := h.client(r)
 	if err != nil {
-		debugInfo["aws_identity"] = map[string]interface{}{
-			"error": fmt.Sprintf("Failed to create client: %v", err),
-		}
+		response.AWSIdentity = &api.ErrorStatus{Error: fmt.Sprintf("Failed to create client: %v", err)}
 	} else {
-		eksDetails := client.GetEKSDetails()
-		debugInfo["kubeconfig_details"] = eksDetails
+		response.KubeconfigDetails = (*api.KubeConfigEKSDetails)(client.GetEKSDetails())
+		response.EKSToken = tokenStatus(client)
 	}
 
-	json.NewEncoder(w).Encode(debugInfo)
-}
+	ctx := r.Context()
+	response.AWSCredentials = api.CredentialInfo(auth.DescribeCredentials(ctx, cfg))
+	response.IMDS = api.IMDSStatus(auth.ProbeIMDS(ctx))
+	response.Authenticator = api.AuthenticatorStatus(auth.ProbeAuthenticator(ctx))
+	response.Build = buildInfo()
+	response.Caches = h.cacheStatus()
+	response.Calls = apiCallStats(telemetry.Snapshot())
 
-// TestK8sAuthHandler handles the /test-k8s-auth endpoint
-func (h *Handler) TestK8sAuthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
 
-	results := map[string]interface{}{
-		"status": "running_tests",
-		"tests":  map[string]interface{}{},
+// tokenStatus describes the EKS token of a client, or returns nil for
+// fixture and in-cluster clients
+func tokenStatus(client *k8s.Client) *api.TokenStatus {
+	info, err := client.TokenInfo()
+	if err != nil {
+		return &api.TokenStatus{Error: err.Error()}
 	}
+	if info == nil {
+		return nil
+	}
+	return &api.TokenStatus{TokenInfo: (*api.TokenInfo)(info), ExpiresInSeconds: int(time.Until(info.ExpiresAt).Seconds())}
+}
 
-	// Test 1: AWS Configuration
-	if err := h.config.ValidateAWSConfig(); err != nil {
-		results["tests"].(map[string]interface{})["aws_config"] = map[string]interface{}{
-			"status": "failed",
-			"error":  err.Error(),
+// buildInfo reads the Go version and dependency versions from the binary
+func buildInfo() api.BuildInfo {
+	build := api.BuildInfo{GoVersion: runtime.Version(), Modules: make(map[string]string)}
+	info, ok := runtimedebug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+	build.Version = info.Main.Version
+	for _, module := range info.Deps {
+		for _, path := range debugModules {
+			if module.Path != path {
+				continue
+			}
+			version := module.Version
+			if module.Replace != nil {
+				version = module.Replace.Path + " " + module.Replace.Version
+			}
+			build.Modules[path] = version
 		}
-		results["status"] = "failed"
-		json.NewEncoder(w).Encode(results)
-		return
-	}
-	results["tests"].(map[string]interface{})["aws_config"] = map[string]interface{}{
-		"status": "passed",
 	}
+	return build
+}
 
-	// Test 2: Create Kubernetes client
-	client, err := k8s.NewClient(h.config)
-	if err != nil {
-		results["tests"].(map[string]interface{})["k8s_client_creation"] = map[string]interface{}{
-			"status": "failed",
-			"error":  err.Error(),
+// cacheStatus reports the age of the background scan result and node agent
+// reports, and the size of the image CA bundle cache
+func (h *Handler) cacheStatus() api.CacheStatus {
+	status := api.CacheStatus{
+		Informers:    "none: every request reads the Kubernetes API server",
+		ImageDigests: h.images.Len(),
+	}
+	if h.scanner != nil {
+		if result := h.scanner.LastResult(); result != nil {
+			status.Scanner = cacheAge(result.StartedAt, len(result.Reports))
 		}
-		results["status"] = "failed"
-		json.NewEncoder(w).Encode(results)
-		return
 	}
-	results["tests"].(map[string]interface{})["k8s_client_creation"] = map[string]interface{}{
-		"status": "passed",
+	if reports := h.agents.List(); len(reports) > 0 {
+		oldest := reports[0].ReceivedAt
+		for _, report := range reports {
+			if report.ReceivedAt.Before(oldest) {
+				oldest = report.ReceivedAt
+			}
+		}
+		status.NodeAgents = cacheAge(oldest, len(reports))
 	}
+	return status
+}
 
-	ctx := context.Background()
+// cacheAge describes data cached at updated
+func cacheAge(updated time.Time, entries int) *api.CacheAge {
+	return &api.CacheAge{UpdatedAt: updated, AgeSeconds: int(time.Since(updated).Seconds()), Entries: entries}
+}
 
-	// Test 3: List namespaces (basic cluster access)
-	namespaces, err := client.GetClientset().CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		results["tests"].(map[string]interface{})["list_namespaces"] = map[string]interface{}{
-			"status": "failed",
-			"error":  err.Error(),
-		}
-	} else {
-		results["tests"].(map[string]interface{})["list_namespaces"] = map[string]interface{}{
-			"status": "passed",
-			"count":  len(namespaces.Items),
-		}
-	}
+// TestK8sAuthHandler handles the /test-k8s-auth endpoint
+func (h *Handler) TestK8sAuthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	// Test 4: Get specific namespace
-	targetNamespace := h.config.Kubernetes.DefaultNamespace
-	_, err = client.GetClientset().CoreV1().Namespaces().Get(ctx, targetNamespace, metav1.GetOptions{})
-	if err != nil {
-		results["tests"].(map[string]interface{})["get_target_namespace"] = map[string]interface{}{
-			"status":    "failed",
-			"namespace": targetNamespace,
-			"error":     err.Error(),
-		}
-	} else {
-		results["tests"].(map[string]interface{})["get_target_namespace"] = map[string]interface{}{
-			"status":    "passed",
-			"namespace": targetNamespace,
-		}
+	var extra []string
+	if param := r.URL.Query().Get("namespace"); param != "" {
+		extra = strings.Split(param, ",")
+	}
+	report := k8s.RunAuthChecks(r.Context(), h.cfg(), extra...)
+	json.NewEncoder(w).Encode(api.AuthTestResponse{
+		Status:              report.Status,
+		Tests:               apiAuthChecks(report.Tests()),
+		Identity:            report.Identity,
+		MissingPermissions:  apiMissingPermissions(report.Missing),
+		RemediationManifest: k8s.RemediationManifest(report.Missing, report.Identity),
+	})
+}
+
+// DebugRBACHandler handles the /debug/rbac endpoint, reporting the
+// permissions of the service's identity as a matrix of the permissions it
+// uses by namespace
+func (h *Handler) DebugRBACHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := h.cfg()
+	var extra []string
+	if param := r.URL.Query().Get("namespace"); param != "" {
+		extra = strings.Split(param, ",")
 	}
+	namespaces := k8s.RBACNamespaces(cfg.Kubernetes.DefaultNamespace, cfg.Scanner.Namespaces, extra...)
 
-	// Test 5: List pods in target namespace
-	pods, err := client.GetClientset().CoreV1().Pods(targetNamespace).List(ctx, metav1.ListOptions{})
+	client, err := h.client(r)
 	if err != nil {
-		results["tests"].(map[string]interface{})["list_pods_target_namespace"] = map[string]interface{}{
-			"status":    "failed",
-			"namespace": targetNamespace,
-			"error":     err.Error(),
-		}
-	} else {
-		results["tests"].(map[string]interface{})["list_pods_target_namespace"] = map[string]interface{}{
-			"status":    "passed",
-			"namespace": targetNamespace,
-			"count":     len(pods.Items),
-		}
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
 	}
+	report := k8s.AnalyzeRBAC(r.Context(), client.GetClientset(), namespaces)
 
-	// Test 6: List pods in default namespace
-	defaultPods, err := client.GetClientset().CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		results["tests"].(map[string]interface{})["list_pods_default_namespace"] = map[string]interface{}{
-			"status":    "failed",
-			"namespace": "default",
-			"error":     err.Error(),
-		}
-	} else {
-		results["tests"].(map[string]interface{})["list_pods_default_namespace"] = map[string]interface{}{
-			"status":    "passed",
-			"namespace": "default",
-			"count":     len(defaultPods.Items),
-		}
+	if r.URL.Query().Get("format") == "table" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writeRBACTable(w, report)
+		return
 	}
 
-	// Determine overall status
-	allPassed := true
-	for _, test := range results["tests"].(map[string]interface{}) {
-		if testMap, ok := test.(map[string]interface{}); ok {
-			if status, exists := testMap["status"]; exists && status != "passed" {
-				allPassed = false
-				break
+	missing := 0
+	for _, row := range report.Matrix {
+		if row.ClusterAccess != "" && row.ClusterAccess != k8s.AccessAllowed {
+			missing++
+		}
+		for _, access := range row.Namespaces {
+			if access != k8s.AccessAllowed {
+				missing++
 			}
 		}
 	}
 
-	if allPassed {
-		results["status"] = "all_tests_passed"
-	} else {
-		results["status"] = "some_tests_failed"
-	}
-
-	json.NewEncoder(w).Encode(results)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.RBACResponse{
+		Status:             api.StatusSuccess,
+		MissingPermissions: missing,
+		RBAC:               apiRBACReport(report),
+		Notes: []string{
+			"Namespaced permissions come from a SelfSubjectRulesReview per namespace; cluster-scoped ones from SelfSubjectAccessReviews",
+			"restricted: granted only for specific resource names",
+			"Use ?namespace=a,b to review more namespaces and ?format=table for a text matrix",
+		},
+	})
 }
 
-// Helper functions
-
-func isCertificateMount(mountPath string) bool {
-	certPaths := []string{
-		"/var/run/secrets/kubernetes.io/serviceaccount",
-		"/etc/ssl",
-		"/etc/certs",
-		"/etc/pki",
-		"/usr/share/ca-certificates",
-		"/etc/ca-certificates",
-	}
-
-	for _, certPath := range certPaths {
-		if strings.HasPrefix(mountPath, certPath) {
-			return true
+// writeRBACTable renders an RBAC report as a text matrix with one row per
+// permission and one column per namespace
+func writeRBACTable(w io.Writer, report *k8s.RBACReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "PERMISSION\tCLUSTER\t%s\tUSED BY\n", strings.Join(report.Namespaces, "\t"))
+	for _, row := range report.Matrix {
+		cells := make([]string, 0, len(report.Namespaces)+1)
+		if row.ClusterScoped {
+			cells = append(cells, row.ClusterAccess)
+			for range report.Namespaces {
+				cells = append(cells, "-")
+			}
+		} else {
+			cells = append(cells, "-")
+			for _, namespace := range report.Namespaces {
+				cells = append(cells, row.Namespaces[namespace])
+			}
 		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", row.RBACPermission, strings.Join(cells, "\t"), row.UsedBy)
 	}
+	tw.Flush()
 
-	// Check for common certificate file extensions in the path
-	certExtensions := []string{".crt", ".pem", ".key", ".p12", ".jks", ".truststore"}
-	for _, ext := range certExtensions {
-		if strings.Contains(mountPath, ext) {
-			return true
-		}
+	for namespace, reason := range report.Incomplete {
+		fmt.Fprintf(w, "\nRules review for %s was incomplete (%s); denied cells were checked with access reviews\n", namespace, reason)
 	}
-
-	return false
-}
-
-func getVolumeType(volume interface{}) string {
-	// This is a simplified version - in reality you'd check all volume types
-	v := volume.(interface{})
-	switch {
-	case v != nil:
-		// Check various volume types
-		return "unknown"
-	default:
-		return "unknown"
+	for _, message := range report.Errors {
+		fmt.Fprintf(w, "\nError: %s\n", message)
 	}
 }
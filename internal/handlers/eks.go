@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"k8s-web-service/internal/auth"
+	"k8s-web-service/internal/cloud"
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// EKSAddonsHandler handles the /eks/addons endpoint, comparing the installed
+// EKS addons with the latest versions compatible with the cluster
+func (h *Handler) EKSAddonsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+
+	session, err := h.awsSession(ctx, r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "Failed to create AWS session: %v", err)
+		return
+	}
+
+	report, err := session.ListAddons(ctx)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	updates := 0
+	degraded := 0
+	for _, addon := range report.Addons {
+		if addon.UpdateAvailable {
+			updates++
+		}
+		if len(addon.Issues) > 0 {
+			degraded++
+		}
+	}
+
+	json.NewEncoder(w).Encode(EKSAddonsResponse{
+		Status:            api.StatusSuccess,
+		ClusterName:       report.ClusterName,
+		KubernetesVersion: report.KubernetesVersion,
+		Summary: EKSAddonsSummary{
+			TotalAddons:      len(report.Addons),
+			UpdatesAvailable: updates,
+			AddonsWithIssues: degraded,
+		},
+		Addons: report.Addons,
+	})
+}
+
+// EKSNodegroupsHandler handles the /eks/nodegroups endpoint, listing the
+// managed nodegroups and Fargate profiles of the cluster
+func (h *Handler) EKSNodegroupsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+
+	session, err := h.awsSession(ctx, r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "Failed to create AWS session: %v", err)
+		return
+	}
+
+	report, err := session.ListCompute(ctx)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	releaseVersions := make(map[string]int)
+	for _, ng := range report.Nodegroups {
+		if ng.ReleaseVersion != "" {
+			releaseVersions[ng.ReleaseVersion]++
+		}
+	}
+
+	json.NewEncoder(w).Encode(EKSNodegroupsResponse{
+		Status:      api.StatusSuccess,
+		ClusterName: report.ClusterName,
+		Summary: EKSNodegroupsSummary{
+			TotalNodegroups:      len(report.Nodegroups),
+			TotalFargateProfiles: len(report.FargateProfiles),
+			AMIReleaseVersions:   releaseVersions,
+		},
+		Nodegroups:      report.Nodegroups,
+		FargateProfiles: report.FargateProfiles,
+		Notes: []string{
+			"Self-managed nodes are not known to the EKS API; see /nodes for every node of the cluster",
+		},
+	})
+}
+
+// EKSClustersHandler handles the /eks/clusters endpoint, listing the EKS
+// clusters of the account and marking those this service is set up for
+func (h *Handler) EKSClustersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+	cfg := h.cfg()
+
+	awsCfg, err := auth.LoadAWSConfig(ctx, cfg)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	var regions []string
+	for _, region := range strings.Split(r.URL.Query().Get("regions"), ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	if len(regions) == 0 && awsCfg.Region != "" {
+		// Also list the regions of configured clusters that share the
+		// credentials and partition
+		seen := map[string]bool{awsCfg.Region: true}
+		regions = []string{awsCfg.Region}
+		for _, name := range sortedKeys(cfg.Clusters) {
+			region := cfg.Clusters[name].Region
+			if region != "" && !seen[region] && cfg.Clusters[name].AccessKeyID == "" && config.PartitionForRegion(region) == config.PartitionForRegion(awsCfg.Region) {
+				seen[region] = true
+				regions = append(regions, region)
+			}
+		}
+	}
+	if len(regions) == 0 {
+		writeProblem(w, r, http.StatusBadRequest, ProblemNotConfigured, "No AWS region configured; set aws.region or pass ?regions=")
+		return
+	}
+
+	clusters, err := cloud.DiscoverClusters(ctx, awsCfg, regions)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+
+	// Clusters are registered when configured or reachable through a
+	// kubeconfig context, matched by name, ARN, or endpoint
+	contexts, _ := k8s.InspectKubeconfig(k8s.GetKubeconfigPath(cfg))
+	configured := cloud.ClusterNameFromARN(cfg.Kubernetes.ClusterName)
+
+	registered := 0
+	discovered := make([]DiscoveredCluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		entry := DiscoveredCluster{ClusterInfo: cluster}
+		for _, kubeContext := range contexts {
+			if cloud.ClusterNameFromARN(kubeContext.Cluster) == cluster.Name || kubeContext.Cluster == cluster.ARN ||
+				(cluster.Endpoint != "" && kubeContext.Server == cluster.Endpoint) {
+				entry.KubeconfigContext = kubeContext.Name
+				break
+			}
+		}
+		entry.Registered = cluster.Name == configured || entry.KubeconfigContext != ""
+
+		if entry.Registered {
+			registered++
+		} else {
+			entry.Onboarding = &ClusterOnboarding{
+				KubeconfigCommand: fmt.Sprintf("aws eks update-kubeconfig --name %s --region %s", cluster.Name, cluster.Region),
+				Config: map[string]string{
+					"kubernetes.cluster_name": cluster.Name,
+					"aws.region":              cluster.Region,
+				},
+			}
+		}
+		discovered = append(discovered, entry)
+	}
+
+	total := len(discovered)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(discovered) > limit
+	if truncated {
+		discovered = discovered[:limit]
+	}
+
+	response := EKSClustersResponse{
+		Status:  api.StatusSuccess,
+		Regions: regions,
+		Summary: EKSClustersSummary{
+			TotalClusters:        total,
+			RegisteredClusters:   registered,
+			UnregisteredClusters: total - registered,
+		},
+		Clusters: discovered,
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// EKSOIDCHandler handles the /eks/oidc-thumbprint endpoint, checking that the
+// IAM OIDC provider trusts the certificate of the cluster's OIDC issuer
+func (h *Handler) EKSOIDCHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+
+	session, err := h.awsSession(ctx, r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "Failed to create AWS session: %v", err)
+		return
+	}
+
+	check, err := session.CheckOIDCThumbprint(ctx)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemAWSError, "%v", err)
+		return
+	}
+	if check.Status == cloud.ThumbprintMismatch {
+		log.Printf("Warning: OIDC provider thumbprints of cluster %s do not match the issuer certificate (expected %s)", check.ClusterName, check.ExpectedThumbprint)
+	}
+
+	json.NewEncoder(w).Encode(EKSOIDCResponse{Status: api.StatusSuccess, OIDC: check})
+}
+
+// EKSAccessHandler handles the /eks/access endpoint, reporting the aws-auth
+// ConfigMap mappings and EKS access entries that grant IAM principals access
+// to the cluster, and flagging mappings that cannot work
+func (h *Handler) EKSAccessHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ctx := r.Context()
+
+	response := EKSAccessResponse{Status: api.StatusSuccess}
+	var issues []k8s.MappingIssue
+
+	var awsAuth *k8s.AWSAuthReport
+	client, err := h.client(r)
+	if err == nil {
+		awsAuth, err = k8s.AnalyzeAWSAuth(ctx, client.GetClientset())
+	}
+	if err != nil {
+		response.AWSAuthError = err.Error()
+	} else {
+		response.AWSAuth = awsAuth
+	}
+
+	var entries *cloud.AccessEntryReport
+	session, err := h.awsSession(ctx, r)
+	if err == nil {
+		entries, err = session.ListAccessEntries(ctx)
+	}
+	if err != nil {
+		response.AccessEntriesError = err.Error()
+	} else {
+		response.EKSAccessEntries = &EKSAccessEntries{
+			ClusterName:        entries.ClusterName,
+			AuthenticationMode: entries.AuthenticationMode,
+			AccessEntries:      entries.Entries,
+		}
+	}
+
+	if awsAuth == nil && entries == nil {
+		problem := newProblem(r, http.StatusInternalServerError, classifyError(err, ProblemAWSError), "Neither the aws-auth ConfigMap nor the EKS access entries could be read")
+		problem.Extensions = map[string]interface{}{
+			"aws_auth_error":       response.AWSAuthError,
+			"access_entries_error": response.AccessEntriesError,
+		}
+		encodeProblem(w, problem)
+		return
+	}
+
+	broken := 0
+	if awsAuth != nil {
+		for _, mapping := range awsAuth.Mappings {
+			for _, issue := range mapping.Issues {
+				if issue.Severity == k8s.MappingBroken {
+					broken++
+					break
+				}
+			}
+		}
+		issues = append(issues, awsAuth.Issues...)
+	}
+
+	if entries != nil {
+		mapped := make(map[string]bool)
+		if awsAuth != nil {
+			for _, mapping := range awsAuth.Mappings {
+				mapped[mapping.ARN] = true
+			}
+			if entries.AuthenticationMode == cloud.AuthModeAPI && len(awsAuth.Mappings) > 0 {
+				issues = append(issues, k8s.MappingIssue{
+					Severity: k8s.MappingBroken,
+					Message:  "The cluster authentication mode is API, so the aws-auth ConfigMap is ignored; migrate its mappings to access entries",
+				})
+			}
+		}
+		for _, entry := range entries.Entries {
+			if entry.Type == "STANDARD" && len(entry.KubernetesGroups) == 0 && len(entry.AccessPolicies) == 0 && entry.Error == "" {
+				issues = append(issues, k8s.MappingIssue{
+					Severity: k8s.MappingWarning,
+					Message:  fmt.Sprintf("Access entry %s has no Kubernetes groups or access policies and grants no permissions", entry.PrincipalARN),
+				})
+			}
+			if mapped[entry.PrincipalARN] {
+				issues = append(issues, k8s.MappingIssue{
+					Severity: k8s.MappingInfo,
+					Message:  fmt.Sprintf("%s is in both aws-auth and an access entry; the access entry takes precedence", entry.PrincipalARN),
+				})
+			}
+		}
+	}
+
+	response.Summary = EKSAccessSummary{BrokenMappings: broken}
+	if awsAuth != nil {
+		mappings := len(awsAuth.Mappings)
+		response.Summary.AWSAuthMappings = &mappings
+	}
+	if entries != nil {
+		count := len(entries.Entries)
+		response.Summary.AccessEntries = &count
+	}
+	response.Issues = issues
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/utils"
+)
+
+// maxGraphQLRequestSize bounds the body of a GraphQL request
+const maxGraphQLRequestSize = 1 << 20
+
+// GraphQLRequest is the body of a /graphql request
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// graphqlRoot is the root value of a GraphQL request. Namespaces are
+// analyzed with the shared Kubernetes client, fetched when the first
+// namespace is analyzed.
+type graphqlRoot struct {
+	h       *Handler
+	cluster string // the cluster parameter of the request
+	mu      sync.Mutex
+	client  *k8s.Client
+}
+
+// rootValueKey holds the graphqlRoot in the root object of a request
+const rootValueKey = "root"
+
+// graphqlRootOf returns the graphqlRoot of a request
+func graphqlRootOf(p graphql.ResolveParams) *graphqlRoot {
+	values, _ := p.Info.RootValue.(map[string]interface{})
+	root, _ := values[rootValueKey].(*graphqlRoot)
+	return root
+}
+
+// reports returns the expiry reports of namespaces, as /health-score does:
+// without names or warning_days the namespaces of the most recent
+// background scan, and otherwise the named namespaces, or the default
+// namespace, analyzed now
+func (root *graphqlRoot) reports(p graphql.ResolveParams, names []string, warningDays int) ([]*k8s.NamespaceExpiryReport, error) {
+	cfg := root.h.cfg()
+	if len(names) == 0 && warningDays == 0 && root.h.scanner != nil && root.cluster == "" {
+		if result := root.h.scanner.LastResult(); result != nil {
+			return result.Reports, nil
+		}
+	}
+	if len(names) == 0 {
+		names = []string{cfg.Kubernetes.DefaultNamespace}
+	}
+	if warningDays <= 0 {
+		warningDays = cfg.Scanner.WarningDays
+	}
+
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if root.client == nil {
+		client, err := root.h.clients.ClientFor(cfg, root.cluster)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		root.client = client
+	}
+	reports := make([]*k8s.NamespaceExpiryReport, 0, len(names))
+	for _, name := range names {
+		if reason := validateNamespace(name); reason != "" {
+			return nil, fmt.Errorf("%s", reason)
+		}
+		report, err := k8s.AnalyzeNamespaceExpiry(p.Context, root.client, name, warningDays)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze namespace %s: %w", name, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// namespaced is a pod, certificate source, or certificate resolved with the
+// namespace it was found in, so that field resolvers redact it as security
+// configures for that namespace. Its other fields resolve from the json
+// tags of the value.
+type namespaced struct {
+	value     interface{}
+	namespace string
+}
+
+// Resolve resolves a field of the value from its json tag
+func (n namespaced) Resolve(p graphql.ResolveParams) (interface{}, error) {
+	p.Source = n.value
+	return graphql.DefaultResolveFn(p)
+}
+
+// namespacedSources returns sources found in namespace, for sources that
+// do not name their own
+func namespacedSources(sources []*k8s.CertificateSource, namespace string) []namespaced {
+	values := make([]namespaced, 0, len(sources))
+	for _, source := range sources {
+		ns := namespace
+		if source.Namespace != "" {
+			ns = source.Namespace
+		}
+		values = append(values, namespaced{value: source, namespace: ns})
+	}
+	return values
+}
+
+// namespacedCertificates returns certificates found in namespace
+func namespacedCertificates(certs []*utils.CertificateInfo, namespace string) []namespaced {
+	values := make([]namespaced, 0, len(certs))
+	for _, cert := range certs {
+		values = append(values, namespaced{value: cert, namespace: namespace})
+	}
+	return values
+}
+
+// resolveIdentity resolves a subject, issuer, or serial number of a
+// certificate, or null where security redacts certificate identities
+func resolveIdentity(field func(cert *utils.CertificateInfo) string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		n, _ := p.Source.(namespaced)
+		cert, ok := n.value.(*utils.CertificateInfo)
+		if !ok || graphqlRootOf(p).h.redaction().SubjectsOf(n.namespace) {
+			return nil, nil
+		}
+		return field(cert), nil
+	}
+}
+
+// graphqlCertificate is the GraphQL type of a certificate. Field names of
+// the GraphQL types are the JSON members of the REST responses, so objects
+// are resolved from their json tags. The identity fields are redacted by
+// their resolvers, whatever else the query selects.
+var graphqlCertificate = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Certificate",
+	Description: "An X.509 certificate",
+	Fields: graphql.Fields{
+		"subject": &graphql.Field{
+			Type:    graphql.String,
+			Resolve: resolveIdentity(func(cert *utils.CertificateInfo) string { return cert.Subject }),
+		},
+		"issuer": &graphql.Field{
+			Type:    graphql.String,
+			Resolve: resolveIdentity(func(cert *utils.CertificateInfo) string { return cert.Issuer }),
+		},
+		"serial_number": &graphql.Field{
+			Type:    graphql.String,
+			Resolve: resolveIdentity(func(cert *utils.CertificateInfo) string { return cert.SerialNumber }),
+		},
+		"redacted": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Whether subject, issuer, and serial_number are redacted",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				n, _ := p.Source.(namespaced)
+				return graphqlRootOf(p).h.redaction().SubjectsOf(n.namespace), nil
+			},
+		},
+		"not_before":          &graphql.Field{Type: graphql.DateTime},
+		"not_after":           &graphql.Field{Type: graphql.DateTime},
+		"is_expired":          &graphql.Field{Type: graphql.Boolean},
+		"days_until_expiry":   &graphql.Field{Type: graphql.Int},
+		"dns_names":           &graphql.Field{Type: graphql.NewList(graphql.String)},
+		"ip_addresses":        &graphql.Field{Type: graphql.NewList(graphql.String)},
+		"key_usage":           &graphql.Field{Type: graphql.NewList(graphql.String)},
+		"is_ca":               &graphql.Field{Type: graphql.Boolean},
+		"key_algorithm":       &graphql.Field{Type: graphql.String},
+		"key_size":            &graphql.Field{Type: graphql.Int},
+		"signature_algorithm": &graphql.Field{Type: graphql.String},
+		"fingerprint_sha256":  &graphql.Field{Type: graphql.String},
+	},
+})
+
+// graphqlSource is the GraphQL type of a certificate source
+var graphqlSource = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "CertificateSource",
+	Description: "A secret, ConfigMap, projected volume, or custom resource holding certificates",
+	Fields: graphql.Fields{
+		"type":      &graphql.Field{Type: graphql.String},
+		"name":      &graphql.Field{Type: graphql.String},
+		"namespace": &graphql.Field{Type: graphql.String},
+		"key": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				n, _ := p.Source.(namespaced)
+				source, _ := n.value.(*k8s.CertificateSource)
+				return graphqlRootOf(p).h.redaction().KeyName(source.Key), nil
+			},
+		},
+		"error": &graphql.Field{Type: graphql.String},
+		"certificates": &graphql.Field{
+			Type: graphql.NewList(graphqlCertificate),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				n, _ := p.Source.(namespaced)
+				source, _ := n.value.(*k8s.CertificateSource)
+				return namespacedCertificates(source.Certificates, n.namespace), nil
+			},
+		},
+	},
+})
+
+// graphqlPod is the GraphQL type of a pod
+var graphqlPod = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Pod",
+	Description: "A pod with certificates or warnings",
+	Fields: graphql.Fields{
+		"pod_name": &graphql.Field{Type: graphql.String},
+		"warnings": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				n, _ := p.Source.(namespaced)
+				pod, _ := n.value.(k8s.PodExpiryInfo)
+				var certs []*utils.CertificateInfo
+				for _, source := range pod.CertSources {
+					certs = append(certs, source.Certificates...)
+				}
+				return graphqlRootOf(p).h.redaction().Strings(n.namespace, pod.Warnings, certs), nil
+			},
+		},
+		"warning_count":     &graphql.Field{Type: graphql.Int},
+		"certificate_count": &graphql.Field{Type: graphql.Int},
+		"certificate_sources": &graphql.Field{
+			Type:        graphql.NewList(graphqlSource),
+			Description: "The certificate sources mounted by the pod, by volume",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				n, _ := p.Source.(namespaced)
+				pod, _ := n.value.(k8s.PodExpiryInfo)
+				names := make([]string, 0, len(pod.CertSources))
+				for name := range pod.CertSources {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				sources := make([]*k8s.CertificateSource, len(names))
+				for i, name := range names {
+					sources[i] = pod.CertSources[name]
+				}
+				return namespacedSources(sources, n.namespace), nil
+			},
+		},
+	},
+})
+
+// graphqlHealthScore is the GraphQL type of a namespace health score
+var graphqlHealthScore = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "HealthScore",
+	Description: "The certificate health of a namespace from 0 to 100, as reported by /health-score",
+	Fields: graphql.Fields{
+		"score":             &graphql.Field{Type: graphql.Int},
+		"certificates":      &graphql.Field{Type: graphql.Int},
+		"expired":           &graphql.Field{Type: graphql.Int},
+		"critical":          &graphql.Field{Type: graphql.Int},
+		"expiring":          &graphql.Field{Type: graphql.Int},
+		"broken_chains":     &graphql.Field{Type: graphql.Int},
+		"weak_keys":         &graphql.Field{Type: graphql.Int},
+		"critical_findings": &graphql.Field{Type: graphql.Int},
+	},
+})
+
+// graphqlNamespace is the GraphQL type of a namespace expiry report
+var graphqlNamespace = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Namespace",
+	Description: "The certificate expiry analysis of a namespace",
+	Fields: graphql.Fields{
+		"namespace":           &graphql.Field{Type: graphql.String},
+		"warning_days":        &graphql.Field{Type: graphql.Int},
+		"total_pods_analyzed": &graphql.Field{Type: graphql.Int},
+		"total_certificates":  &graphql.Field{Type: graphql.Int},
+		"total_warnings":      &graphql.Field{Type: graphql.Int},
+		"all_warnings": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				report, _ := p.Source.(*k8s.NamespaceExpiryReport)
+				certs := namespaceCertificates(report, false)
+				return graphqlRootOf(p).h.redaction().Strings(report.Namespace, report.Warnings, certs), nil
+			},
+		},
+		"custom_resources": &graphql.Field{
+			Type: graphql.NewList(graphqlSource),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				report, _ := p.Source.(*k8s.NamespaceExpiryReport)
+				return namespacedSources(report.CustomResources, report.Namespace), nil
+			},
+		},
+		"pods": &graphql.Field{
+			Type: graphql.NewList(graphqlPod),
+			Args: graphql.FieldConfigArgument{
+				"name": &graphql.ArgumentConfig{Type: graphql.String, Description: "Only the pod with this name"},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				report, _ := p.Source.(*k8s.NamespaceExpiryReport)
+				name, _ := p.Args["name"].(string)
+				pods := make([]namespaced, 0, len(report.Pods))
+				for _, pod := range report.Pods {
+					if name == "" || pod.PodName == name {
+						pods = append(pods, namespaced{value: pod, namespace: report.Namespace})
+					}
+				}
+				return pods, nil
+			},
+		},
+		"certificates": &graphql.Field{
+			Type:        graphql.NewList(graphqlCertificate),
+			Description: "Every certificate of the namespace once, soonest expiry first",
+			Args: graphql.FieldConfigArgument{
+				"expiring_only": &graphql.ArgumentConfig{Type: graphql.Boolean, Description: "Only expired certificates and those expiring within warning_days"},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				report, _ := p.Source.(*k8s.NamespaceExpiryReport)
+				expiringOnly, _ := p.Args["expiring_only"].(bool)
+				return namespacedCertificates(namespaceCertificates(report, expiringOnly), report.Namespace), nil
+			},
+		},
+		"health_score": &graphql.Field{
+			Type: graphqlHealthScore,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				report, _ := p.Source.(*k8s.NamespaceExpiryReport)
+				return k8s.NamespaceHealthScore(report), nil
+			},
+		},
+	},
+})
+
+// graphqlQuery is the root query type
+var graphqlQuery = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"namespaces": &graphql.Field{
+			Type:        graphql.NewList(graphqlNamespace),
+			Description: "Without arguments the namespaces of the most recent background scan, or the default namespace; otherwise the named namespaces analyzed now",
+			Args: graphql.FieldConfigArgument{
+				"names":        &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+				"warning_days": &graphql.ArgumentConfig{Type: graphql.Int},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				var names []string
+				if list, ok := p.Args["names"].([]interface{}); ok {
+					for _, name := range list {
+						if s, ok := name.(string); ok {
+							names = append(names, s)
+						}
+					}
+				}
+				warningDays, _ := p.Args["warning_days"].(int)
+				return graphqlRootOf(p).reports(p, names, warningDays)
+			},
+		},
+		"namespace": &graphql.Field{
+			Type:        graphqlNamespace,
+			Description: "A namespace analyzed now",
+			Args: graphql.FieldConfigArgument{
+				"name":         &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"warning_days": &graphql.ArgumentConfig{Type: graphql.Int},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				name, _ := p.Args["name"].(string)
+				warningDays, _ := p.Args["warning_days"].(int)
+				reports, err := graphqlRootOf(p).reports(p, []string{name}, warningDays)
+				if err != nil {
+					return nil, err
+				}
+				return reports[0], nil
+			},
+		},
+	},
+})
+
+// graphqlSchema is the schema of /graphql
+var graphqlSchema, graphqlSchemaErr = graphql.NewSchema(graphql.SchemaConfig{Query: graphqlQuery})
+
+// namespaceCertificates returns the certificates of a namespace report,
+// including custom resources, once per fingerprint and soonest expiry first
+func namespaceCertificates(report *k8s.NamespaceExpiryReport, expiringOnly bool) []*utils.CertificateInfo {
+	var sources []*k8s.CertificateSource
+	for _, pod := range report.Pods {
+		for _, source := range pod.CertSources {
+			sources = append(sources, source)
+		}
+	}
+	sources = append(sources, report.CustomResources...)
+
+	seen := make(map[string]bool)
+	certs := []*utils.CertificateInfo{}
+	for _, source := range sources {
+		for _, cert := range source.Certificates {
+			if cert == nil || seen[cert.Fingerprint] {
+				continue
+			}
+			if expiringOnly && !cert.IsExpired && cert.DaysUntilExp > report.WarningDays {
+				continue
+			}
+			seen[cert.Fingerprint] = true
+			certs = append(certs, cert)
+		}
+	}
+	sort.Slice(certs, func(i, j int) bool { return certs[i].NotAfter.Before(certs[j].NotAfter) })
+	return certs
+}
+
+// HandleGraphQL handles the /graphql endpoint, answering GraphQL queries
+// over the certificate inventory: namespaces, their pods, certificate
+// sources, certificates, warnings, and health scores. Errors of the query
+// are reported in the errors member of a 200 response, as GraphQL clients
+// expect.
+func (h *Handler) HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeProblem(w, r, http.StatusMethodNotAllowed, ProblemMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+	if graphqlSchemaErr != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ProblemInternal, "Invalid GraphQL schema: %v", graphqlSchemaErr)
+		return
+	}
+
+	var request GraphQLRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLRequestSize)).Decode(&request); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Invalid GraphQL request: %v", err)
+		return
+	}
+	if request.Query == "" {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "GraphQL request has no query")
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         graphqlSchema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		RootObject:     map[string]interface{}{rootValueKey: &graphqlRoot{h: h, cluster: requestedCluster(r)}},
+		Context:        r.Context(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
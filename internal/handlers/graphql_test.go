@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/lifecycle"
+)
+
+func TestGraphQLRedactsIdentityFields(t *testing.T) {
+	// Only identity fields and warnings are selected, so nothing identifies
+	// the objects as certificates to the response redaction
+	const query = `{"query": "{ namespace(name: \"default\") { all_warnings certificates { subject } pods { warnings certificate_sources { certificates { issuer serial_number redacted } } } } }"}`
+	tests := []struct {
+		name     string
+		security func(cfg *config.Config)
+		redacted bool
+	}{
+		{"no redaction", func(cfg *config.Config) {}, false},
+		{"redact_subjects", func(cfg *config.Config) { cfg.Security.RedactSubjects = true }, true},
+		{"privacy mode", func(cfg *config.Config) { cfg.Security.PrivacyMode = true }, true},
+		{"other sensitive namespace", func(cfg *config.Config) {
+			cfg.Security.PrivacyMode = true
+			cfg.Security.SensitiveNamespaces = []string{"payments"}
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.SetDefaults()
+			cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+			tt.security(cfg)
+			h := New(config.NewStore(cfg), nil, lifecycle.NewTracker(), nil)
+
+			rec := httptest.NewRecorder()
+			h.HandleGraphQL(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(query)))
+
+			body := rec.Body.String()
+			if strings.Contains(body, `"errors"`) {
+				t.Fatalf("query failed: %s", body)
+			}
+			if got := strings.Contains(body, "example.com"); got == tt.redacted {
+				t.Errorf("subject in response = %t, want %t: %s", got, !tt.redacted, body)
+			}
+			if got := strings.Contains(body, `"redacted":true`); got != tt.redacted {
+				t.Errorf("redacted = %t, want %t: %s", got, tt.redacted, body)
+			}
+		})
+	}
+}
+
+func TestGraphQLNamespace(t *testing.T) {
+	const query = `{"query": "{ namespace(name: \"default\") { namespace total_certificates certificates { subject days_until_expiry } pods(name: \"web-7d4b9c6f5-abcde\") { pod_name certificate_count } health_score { score certificates } } }"}`
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+	h := New(config.NewStore(cfg), nil, lifecycle.NewTracker(), nil)
+
+	rec := httptest.NewRecorder()
+	h.HandleGraphQL(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(query)))
+
+	var response struct {
+		Data struct {
+			Namespace struct {
+				Namespace         string `json:"namespace"`
+				TotalCertificates int    `json:"total_certificates"`
+				Certificates      []struct {
+					Subject string `json:"subject"`
+				} `json:"certificates"`
+				Pods []struct {
+					PodName string `json:"pod_name"`
+				} `json:"pods"`
+				HealthScore struct {
+					Certificates int `json:"certificates"`
+				} `json:"health_score"`
+			} `json:"namespace"`
+		} `json:"data"`
+		Errors []interface{} `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || len(response.Errors) > 0 {
+		t.Fatalf("query failed: %s", rec.Body)
+	}
+	namespace := response.Data.Namespace
+	if namespace.Namespace != "default" || namespace.TotalCertificates == 0 || len(namespace.Certificates) == 0 {
+		t.Errorf("namespace default has no certificates: %s", rec.Body)
+	}
+	if len(namespace.Pods) != 1 || namespace.Pods[0].PodName != "web-7d4b9c6f5-abcde" {
+		t.Errorf("pods(name) = %+v, want only web-7d4b9c6f5-abcde", namespace.Pods)
+	}
+	if namespace.HealthScore.Certificates == 0 {
+		t.Errorf("health_score counts no certificates: %s", rec.Body)
+	}
+}
+
+func TestGraphQLRejectsInvalidQueries(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+	h := New(config.NewStore(cfg), nil, lifecycle.NewTracker(), nil)
+
+	for _, query := range []string{`{"query": "{ namespace { namespace } }"}`, `{"query": "{ secrets { name } }"}`} {
+		rec := httptest.NewRecorder()
+		h.HandleGraphQL(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(query)))
+		if !strings.Contains(rec.Body.String(), `"errors"`) {
+			t.Errorf("query %s succeeded: %s", query, rec.Body)
+		}
+	}
+}
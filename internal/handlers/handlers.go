@@ -3,9 +3,48 @@ package handlers
 // This file serves as the main package file for handlers.
 // Individual handler functions are organized in separate files:
 // - base.go: Handler struct and constructor
-// - types.go: Type definitions
+// - routes.go: Route registry, endpoint groups, and root handler
+// - limits.go: Per-group request timeouts and result limits
+// - problems.go: RFC 7807 problem details error responses
+// - params.go: Query parameter validation
+// - dates.go: Time zone and layouts of formatted dates
+// - bundles.go: Summaries of large certificate sources
+// - redact.go: Redaction of certificate material in responses
+// - sort.go: Sorting of certificate lists
+// - query.go: JSONPath response shaping
+// - render.go: JSON and spreadsheet (CSV, XLSX) responses
+// - clusters.go: Selection of a configured cluster with the cluster parameter
+// - multinamespace.go: Requests spanning several namespaces with namespace=all or a list
+// - conditional.go: HEAD and conditional GET of expensive endpoints from the background scan
+// - types.go: Types of AWS and discovery responses; shared response bodies are in pkg/api
 // - kubernetes.go: Basic Kubernetes operations
-// - cluster_ca.go: Cluster CA operations
+// - namespaces.go: Namespace listing with scan status
+// - nodes.go: Node inventory and kubelet certificate rotation
+// - livecheck.go: Live TLS certificate checks
+// - services.go: Service TLS discovery
+// - secrets.go: Secret metadata inventory
+// - secret_certificates.go: Certificates of every TLS and certificate-bearing secret
+// - configmaps.go: Trust bundle inventory and pod trust store validation
+// - cluster_ca.go: Cluster CA operations and CA rotation status
+// - webhooks.go: caBundles of admission webhooks and aggregated APIServices
 // - pod_certificates.go: Pod certificate analysis
+// - scan.go: Consolidated certificate report across sources
+// - health_score.go: Namespace certificate health score
+// - policies.go: Certificate policy evaluation
+// - graphql.go: GraphQL queries over the certificate inventory
+// - keystore.go: Analysis of uploaded PKCS#12 and JKS keystores
+// - compare.go: Comparison of an uploaded certificate with deployed ones
+// - workloads.go: Workload-level certificate aggregation
+// - tokens.go: Projected and legacy service account token analysis
+// - system.go: kube-system component certificate health
+// - helm.go: Certificates embedded in Helm releases
+// - images.go: CA bundles baked into workload images
+// - agent.go: Node agent reports and hostPath certificate analysis
+// - eks.go: EKS cluster inspection through the AWS APIs
+// - aws.go: AWS session helpers and AWS certificate inventories
 // - debug.go: Debug and utility functions
 // - api_docs.go: API documentation handler
+// - schemas.go: schema_version of responses and published JSON Schemas
+// - admin.go: Administrative endpoints (configuration reload, drain, alert simulation)
+// - health.go: Liveness and readiness probes
+// - metrics.go: Prometheus metrics
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s-web-service/pkg/api"
+)
+
+// HealthzHandler handles the /healthz liveness endpoint
+func (h *Handler) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.HealthResponse{Status: "ok"})
+}
+
+// ReadyzHandler handles the /readyz readiness endpoint. The server reports
+// not ready while draining so it is removed from load balancing.
+func (h *Handler) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !h.jobs.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		drain := api.DrainStatus(h.jobs.Status())
+		json.NewEncoder(w).Encode(api.HealthResponse{Status: "draining", Drain: &drain})
+		return
+	}
+
+	json.NewEncoder(w).Encode(api.HealthResponse{Status: "ready"})
+}
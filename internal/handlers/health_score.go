@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// HandleHealthScore handles the /health-score endpoint, scoring the
+// certificate health of namespaces from 0 to 100. With a namespace the
+// namespace is analyzed now; without one the namespaces of the most recent
+// background scan are scored, or the default namespace if there is none.
+func (h *Handler) HandleHealthScore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	response := HealthScoreResponse{Status: api.StatusSuccess}
+	reports, warningDays, ok := h.expiryReports(ctx, w, r, &response.ReportSource)
+	if !ok {
+		return
+	}
+
+	scores := make([]*k8s.HealthScore, 0, len(reports))
+	var weighted float64
+	certificates := 0
+	for _, report := range reports {
+		score := k8s.NamespaceHealthScore(report)
+		scores = append(scores, score)
+		weighted += float64(score.Score * score.Certificates)
+		certificates += score.Certificates
+	}
+	overall := 100
+	if certificates > 0 {
+		overall = int(math.Round(weighted / float64(certificates)))
+	}
+
+	response.WarningDays = warningDays
+	response.Score = overall
+	response.Certificates = certificates
+	response.Namespaces = scores
+	response.Weights = map[string]float64{
+		"expired":          k8s.HealthWeightExpired,
+		"critical":         k8s.HealthWeightCritical,
+		"expiring":         k8s.HealthWeightExpiring,
+		"broken_chain":     k8s.HealthWeightBrokenChain,
+		"weak_key":         k8s.HealthWeightWeakKey,
+		"critical_finding": k8s.HealthWeightCriticalFinding,
+	}
+	response.Notes = []string{
+		"Each certificate loses the weights of its issues, up to the whole certificate; the score is the share of certificates left, from 0 to 100",
+		"critical certificates expire within 7 days, expiring ones within warning_days",
+		"score is the average of the namespace scores weighted by their certificates",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// expiryReports returns the namespace expiry reports a request is about: the
+// namespace parameter analyzed now, or else the namespaces of the most
+// recent background scan, or else the default namespace analyzed now. A
+// warning_days parameter also forces a new analysis. The origin of the
+// reports is set in source; on failure the error is written and ok is false.
+func (h *Handler) expiryReports(ctx context.Context, w http.ResponseWriter, r *http.Request, source *ReportSource) (reports []*k8s.NamespaceExpiryReport, warningDays int, ok bool) {
+	namespace := r.URL.Query().Get("namespace")
+	warningDays = h.cfg().Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	if namespace == "" && r.URL.Query().Get("warning_days") == "" {
+		if result := h.scanResult(r); result != nil {
+			source.Source = "scanner"
+			source.ScannedAt = &result.StartedAt
+			return result.Reports, result.WarningDays, true
+		}
+	}
+
+	if namespace == "" {
+		namespace = h.cfg().Kubernetes.DefaultNamespace
+	}
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return nil, 0, false
+	}
+	report, err := k8s.AnalyzeNamespaceExpiry(ctx, client, namespace, warningDays)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to analyze namespace %s: %v", namespace, err)
+		return nil, 0, false
+	}
+	source.Source = "live"
+	return []*k8s.NamespaceExpiryReport{report}, warningDays, true
+}
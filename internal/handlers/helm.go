@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// HandleHelmCertificates handles the /helm-certificates endpoint, reporting
+// the certificates templated into Helm releases at install time
+func (h *Handler) HandleHelmCertificates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = h.cfg().Kubernetes.DefaultNamespace
+	}
+	warningDays := h.cfg().Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+	allRevisions := r.URL.Query().Get("all_revisions") == "true"
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	releases, err := k8s.ScanHelmReleases(ctx, client.GetClientset(), namespace, allRevisions, warningDays)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to scan Helm releases: %v", err)
+		return
+	}
+
+	withCertificates, totalCertificates, totalWarnings, failed := 0, 0, 0, 0
+	for _, release := range releases {
+		if len(release.Certificates) > 0 {
+			withCertificates++
+		}
+		for _, found := range release.Certificates {
+			totalCertificates += len(found.Certificates)
+		}
+		totalWarnings += len(release.Warnings)
+		if release.Error != "" {
+			failed++
+		}
+	}
+
+	sortResults(r, releases, func(release *k8s.HelmReleaseCertificates) sortFields {
+		fields := sortFields{Name: release.Release, Namespace: release.Namespace}
+		for _, found := range release.Certificates {
+			fields = soonestExpiry(fields, found.Certificates...)
+		}
+		return fields
+	})
+
+	total := len(releases)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(releases) > limit
+	if truncated {
+		releases = releases[:limit]
+	}
+
+	response := HelmCertificatesResponse{
+		Status:       api.StatusSuccess,
+		Namespace:    namespace,
+		WarningDays:  warningDays,
+		AllRevisions: allRevisions,
+		Summary: HelmCertificatesSummary{
+			TotalReleases:            total,
+			ReleasesWithCertificates: withCertificates,
+			TotalCertificates:        totalCertificates,
+			TotalWarnings:            totalWarnings,
+			UndecodableReleases:      failed,
+		},
+		Releases: releases,
+		Notes: []string{
+			"Certificates templated into a release are only replaced by upgrading the release with new values",
+			"Each certificate is reported once per release, at the first of values, manifest, and chart_values it appears in",
+		},
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s-web-service/pkg/api"
+)
+
+// ImageCABundlesHandler handles the /image-ca-bundles endpoint, pulling the
+// images of a namespace's pods and reporting expired or expiring roots in
+// their system CA bundles
+func (h *Handler) ImageCABundlesHandler(w http.ResponseWriter, r *http.Request) {
+	// Pulling layers can take long; the request context stops it when the
+	// request times out
+	ctx := r.Context()
+	cfg := h.cfg()
+
+	if !cfg.Images.CABundleAnalysis {
+		writeProblem(w, r, http.StatusServiceUnavailable, ProblemNotConfigured, "Image CA bundle analysis is disabled; set images.ca_bundle_analysis to pull workload images")
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = cfg.Kubernetes.DefaultNamespace
+	}
+	warningDays := cfg.Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+	clientset := client.GetClientset()
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list pods: %v", err)
+		return
+	}
+
+	// Each image is pulled once, with the pull secrets of every pod running it
+	pullSecrets := make(map[string]map[string]bool) // image -> secret names
+	imagePods := make(map[string]map[string]bool)   // image -> pod names
+	for _, pod := range pods.Items {
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, container := range containers {
+			if pullSecrets[container.Image] == nil {
+				pullSecrets[container.Image] = make(map[string]bool)
+				imagePods[container.Image] = make(map[string]bool)
+			}
+			for _, secret := range pod.Spec.ImagePullSecrets {
+				pullSecrets[container.Image][secret.Name] = true
+			}
+			imagePods[container.Image][pod.Name] = true
+		}
+	}
+	imageNames := sortedKeys(pullSecrets)
+	var skipped []string
+	if max := cfg.Images.MaxImages; len(imageNames) > max {
+		imageNames, skipped = imageNames[:max], imageNames[max:]
+	}
+
+	secrets := make(map[string]*corev1.Secret)
+	var bundles []ImageCABundleEntry
+	totalExpired, totalExpiring, failed := 0, 0, 0
+	for _, image := range imageNames {
+		var imageSecrets []*corev1.Secret
+		for _, secretName := range sortedKeys(pullSecrets[image]) {
+			secret, ok := secrets[secretName]
+			if !ok {
+				secret, err = clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+				if err != nil {
+					secret = nil
+				}
+				secrets[secretName] = secret
+			}
+			if secret != nil {
+				imageSecrets = append(imageSecrets, secret)
+			}
+		}
+
+		bundle := h.images.Analyze(ctx, image, imageSecrets, warningDays)
+		totalExpired += len(bundle.ExpiredRoots)
+		totalExpiring += len(bundle.ExpiringRoots)
+		if bundle.Error != "" {
+			failed++
+		}
+		bundles = append(bundles, ImageCABundleEntry{CABundle: bundle, Pods: sortedKeys(imagePods[image])})
+	}
+
+	total := len(bundles)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(bundles) > limit
+	if truncated {
+		bundles = bundles[:limit]
+	}
+	if bundles == nil {
+		bundles = []ImageCABundleEntry{}
+	}
+
+	response := ImageCABundlesResponse{
+		Status:      api.StatusSuccess,
+		Namespace:   namespace,
+		WarningDays: warningDays,
+		Summary: ImageCABundlesSummary{
+			ImagesAnalyzed:     total,
+			ImagesSkipped:      len(skipped),
+			ImagesFailed:       failed,
+			TotalExpiredRoots:  totalExpired,
+			TotalExpiringRoots: totalExpiring,
+		},
+		Images: bundles,
+		Notes: []string{
+			"Roots baked into an image are only refreshed by rebuilding it on an updated base image",
+			"Multi-platform images are analyzed for linux/amd64",
+		},
+		SkippedImages: skipped,
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
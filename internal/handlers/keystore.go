@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s-web-service/pkg/api"
+	"k8s-web-service/pkg/utils"
+)
+
+// maxKeystoreSize bounds the body of a keystore upload
+const maxKeystoreSize = 10 << 20
+
+// HandleKeystoreAnalysis handles POST /analyze/keystore, analyzing the
+// certificates of an uploaded PKCS#12 or JKS keystore before it is
+// deployed. The keystore is the multipart file field "file", with its
+// password in the "password" field and optionally its format, pkcs12 or
+// jks, in "format". Nothing is stored, and private keys are not decoded.
+func (h *Handler) HandleKeystoreAnalysis(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeProblem(w, r, http.StatusMethodNotAllowed, ProblemMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+
+	warningDays := h.cfg().Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxKeystoreSize)
+	if err := r.ParseMultipartForm(maxKeystoreSize); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Invalid multipart upload: %v", err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Missing keystore file field: %v", err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Failed to read keystore: %v", err)
+		return
+	}
+
+	format := r.FormValue("format")
+	switch format {
+	case "", utils.KeystorePKCS12, utils.KeystoreJKS:
+	default:
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Unsupported keystore format %q, use pkcs12 or jks", format)
+		return
+	}
+	keystore, err := utils.ParseKeystore(data, r.FormValue("password"), format)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Failed to parse keystore %s: %v", header.Filename, err)
+		return
+	}
+
+	now := time.Now()
+	certs := keystore.Certificates()
+	h.setCertificateDates(r, now, certs...)
+	warnings := utils.ValidateCertificateExpiry(certs, warningDays)
+	if warnings == nil {
+		warnings = []string{}
+	}
+	notes := []string{"Private keys are not decoded, and the keystore is not stored"}
+	if !keystore.IntegrityVerified {
+		notes = append(notes, "The JKS integrity digest was not checked because no password was given")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.KeystoreAnalysisResponse{
+		Status:            api.StatusSuccess,
+		Filename:          header.Filename,
+		Format:            keystore.Format,
+		IntegrityVerified: keystore.IntegrityVerified,
+		WarningDays:       warningDays,
+		TotalCertificates: len(certs),
+		Entries:           keystore.Entries,
+		Warnings:          warnings,
+		Notes:             notes,
+	})
+}
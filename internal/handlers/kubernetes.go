@@ -3,12 +3,12 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
 )
 
 // ConnectK8sHandler handles the /connect-k8s endpoint
@@ -16,107 +16,152 @@ func (h *Handler) ConnectK8sHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// Validate AWS configuration
-	if err := h.config.ValidateAWSConfig(); err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("AWS configuration validation failed: %v", err),
-		}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response)
+	if err := h.cfg().ValidateAWSConfig(); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ProblemNotConfigured, "AWS configuration validation failed: %v", err)
 		return
 	}
 
 	// Create Kubernetes client
-	client, err := k8s.NewClient(h.config)
+	client, err := h.client(r)
 	if err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("Failed to create Kubernetes client: %v", err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
 		return
 	}
 
 	// Test connection
-	ctx := context.Background()
+	ctx := r.Context()
 	if err := client.TestConnection(ctx); err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("Failed to connect to Kubernetes cluster: %v", err),
-		}
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(response)
+		writeErrorProblem(w, r, http.StatusUnauthorized, err, ProblemClusterUnreachable, "Failed to connect to Kubernetes cluster: %v", err)
 		return
 	}
 
 	// Get EKS details
 	eksDetails := client.GetEKSDetails()
 
-	response := map[string]interface{}{
-		"status":            "success",
-		"message":           "Successfully connected to Kubernetes cluster",
-		"cluster_name":      eksDetails.ClusterName,
-		"cluster_endpoint":  eksDetails.ClusterEndpoint,
-		"region":            eksDetails.Region,
-		"default_namespace": h.config.Kubernetes.DefaultNamespace,
+	response := api.ConnectResponse{
+		Status:           api.StatusSuccess,
+		Message:          "Successfully connected to Kubernetes cluster",
+		ClusterName:      eksDetails.ClusterName,
+		ClusterEndpoint:  eksDetails.ClusterEndpoint,
+		Region:           eksDetails.Region,
+		DefaultNamespace: h.cfg().Kubernetes.DefaultNamespace,
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
 
+// listPods lists up to limit pods of a namespace in the form of the
+// /list-pods response, and reports whether the namespace has more
+func listPods(ctx context.Context, client *k8s.Client, namespace string, limit int) ([]api.PodSummary, bool, error) {
+	pods, err := client.GetClientset().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{Limit: int64(limit)})
+	if err != nil {
+		return nil, false, err
+	}
+	truncated := truncatePods(pods, limit)
+
+	// Format pod information
+	var podList []api.PodSummary
+	for _, pod := range pods.Items {
+		podList = append(podList, api.PodSummary{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			Status:    string(pod.Status.Phase),
+			Node:      pod.Spec.NodeName,
+			Created:   pod.CreationTimestamp.Time,
+		})
+	}
+	return podList, truncated, nil
+}
+
 // ListPodsHandler handles the /list-pods endpoint
 func (h *Handler) ListPodsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	namespace := h.config.Kubernetes.DefaultNamespace
+	namespace := h.cfg().Kubernetes.DefaultNamespace
 	if ns := r.URL.Query().Get("namespace"); ns != "" {
 		namespace = ns
 	}
 
 	// Create Kubernetes client
-	client, err := k8s.NewClient(h.config)
+	client, err := h.client(r)
 	if err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("Failed to create Kubernetes client: %v", err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	if multipleNamespaces(r) {
+		h.listNamespacesPods(w, r, client)
 		return
 	}
 
 	// List pods
-	ctx := context.Background()
-	pods, err := client.GetClientset().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	ctx := r.Context()
+	limit := maxResults(r)
+	podList, truncated, err := listPods(ctx, client, namespace, limit)
 	if err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("Failed to list pods in namespace %s: %v", namespace, err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list pods in namespace %s: %v", namespace, err)
 		return
 	}
 
-	// Format pod information
-	var podList []map[string]interface{}
-	for _, pod := range pods.Items {
-		podInfo := map[string]interface{}{
-			"name":      pod.Name,
-			"namespace": pod.Namespace,
-			"status":    string(pod.Status.Phase),
-			"node":      pod.Spec.NodeName,
-			"created":   pod.CreationTimestamp.Time,
-		}
-		podList = append(podList, podInfo)
-	}
-
-	response := map[string]interface{}{
-		"status":    "success",
-		"namespace": namespace,
-		"count":     len(podList),
-		"pods":      podList,
+	response := api.ListPodsResponse{
+		Status:    api.StatusSuccess,
+		Namespace: namespace,
+		Count:     len(podList),
+		Pods:      podList,
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// listNamespacesPods answers /list-pods for several namespaces, listed
+// scanner.concurrency at a time. max_results applies to every namespace.
+func (h *Handler) listNamespacesPods(w http.ResponseWriter, r *http.Request, client *k8s.Client) {
+	namespaces, err := requestedNamespaces(r.Context(), r, client)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list namespaces: %v", err)
+		return
+	}
+
+	// namespacePods are the pods listed in one namespace
+	type namespacePods struct {
+		namespace string
+		pods      []api.PodSummary
+		truncated bool
+	}
+	limit := maxResults(r)
+	results, failures, err := analyzeNamespaces(r.Context(), h.cfg().Scanner.Concurrency, namespaces, func(ctx context.Context, namespace string) (namespacePods, error) {
+		pods, truncated, err := listPods(ctx, client, namespace, limit)
+		return namespacePods{namespace: namespace, pods: pods, truncated: truncated}, err
+	})
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list pods: %v", err)
+		return
+	}
+
+	podList := []api.PodSummary{}
+	byNamespace := make(map[string]int)
+	truncated := false
+	for _, result := range results {
+		podList = append(podList, result.pods...)
+		byNamespace[result.namespace] = len(result.pods)
+		truncated = truncated || result.truncated
+	}
+
+	response := api.NamespacesListPodsResponse{
+		Status:           api.StatusSuccess,
+		Namespaces:       namespaces,
+		Count:            len(podList),
+		ByNamespace:      byNamespace,
+		Pods:             podList,
+		FailedNamespaces: failures,
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
 	}
 
 	json.NewEncoder(w).Encode(response)
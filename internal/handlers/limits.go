@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxResultsKey is the request context key holding the result limit
+type maxResultsKey struct{}
+
+// timeoutWriter holds the response of a handler running under its group
+// timeout back, so that a 504 response can be sent instead when the timeout
+// passes first. It has its own header map since the handler runs in its own
+// goroutine, and discards writes once the request timed out.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	timedOut bool
+}
+
+// Header returns the headers of the held back response
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader records the status of the held back response
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.timedOut && tw.status == 0 {
+		tw.status = status
+	}
+}
+
+// Write buffers the response body, or fails with http.ErrHandlerTimeout
+// once the request timed out
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(data)
+}
+
+// applyLimits enforces the timeout of the endpoint group and makes its
+// result limit available to the handler through maxResults. The request
+// context carries the deadline, so Kubernetes and AWS calls made with
+// r.Context() are canceled when it passes, and the client receives a 504
+// problem response instead of waiting for the handler to give up.
+func (h *Handler) applyLimits(group string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		timeout, limit := h.cfg().EndpointLimits(group)
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(context.WithValue(ctx, maxResultsKey{}, limit))
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next(tw, r)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			for key, values := range tw.header {
+				w.Header()[key] = values
+			}
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.body.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			tw.mu.Unlock()
+			if ctx.Err() != context.DeadlineExceeded {
+				// The client went away, so there is no one to respond to
+				return
+			}
+			writeProblem(w, r, http.StatusGatewayTimeout, ProblemTimeout, "Request exceeded the %s timeout of its endpoint group", timeout)
+		}
+	}
+}
+
+// maxResults returns the maximum number of items a list response may
+// contain, or 0 if it is unlimited
+func maxResults(r *http.Request) int {
+	limit, _ := r.Context().Value(maxResultsKey{}).(int)
+	return limit
+}
+
+// truncatePods limits pods to max items, reporting whether any were dropped.
+// The API server honours ListOptions.Limit, but offline fixtures do not.
+func truncatePods(pods *corev1.PodList, max int) bool {
+	truncated := pods.Continue != ""
+	if max > 0 && len(pods.Items) > max {
+		pods.Items = pods.Items[:max]
+		truncated = true
+	}
+	return truncated
+}
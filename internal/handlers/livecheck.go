@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// liveCheckNotes explain how the live certificate checks read a chain
+var liveCheckNotes = []string{
+	"The chain is read from a TLS handshake with the endpoint and is not verified, so expired and untrusted chains are still reported",
+	"hostname_matches reports whether the leaf certificate covers server_name",
+}
+
+// liveWarningDays returns the warning_days parameter, or the scanner's
+// default
+func (h *Handler) liveWarningDays(r *http.Request) int {
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		return days
+	}
+	return h.cfg().Scanner.WarningDays
+}
+
+// HandleLiveCertCheck handles the /live-cert-check endpoint, dialing a host
+// and reporting the certificate chain it actually serves
+func (h *Handler) HandleLiveCertCheck(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	port := 443
+	if p, err := strconv.Atoi(query.Get("port")); err == nil {
+		port = p
+	}
+	warningDays := h.liveWarningDays(r)
+
+	check := k8s.CheckLiveCertificate(r.Context(), query.Get("host"), port, query.Get("server_name"), warningDays)
+	if check.Error != "" {
+		writeProblem(w, r, http.StatusBadGateway, ProblemEndpointUnreachable, "Failed to read the certificate of %s: %s", check.Target, check.Error)
+		return
+	}
+	h.chainVerifier(r)(&check)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LiveCertCheckResponse{
+		Status:      api.StatusSuccess,
+		WarningDays: warningDays,
+		Result:      check,
+		Notes:       liveCheckNotes,
+	})
+}
+
+// HandleLiveServiceCertCheck handles the /live-cert-check/services endpoint,
+// dialing every TLS port of the Services of a namespace
+func (h *Handler) HandleLiveServiceCertCheck(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = h.cfg().Kubernetes.DefaultNamespace
+	}
+	allNamespaces := r.URL.Query().Get("all_namespaces") == "true"
+	if allNamespaces {
+		namespace = ""
+	}
+	warningDays := h.liveWarningDays(r)
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	// The request context stops the dials once the probes timeout is reached
+	checks, err := k8s.CheckServiceCertificates(r.Context(), client.GetClientset(), namespace, warningDays)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to check Service certificates: %v", err)
+		return
+	}
+
+	failed, expired, expiring, mismatched := 0, 0, 0, 0
+	verify := h.chainVerifier(r)
+	for i := range checks {
+		check := &checks[i]
+		verify(check)
+		if check.Error != "" {
+			failed++
+			continue
+		}
+		if !check.HostnameMatches && check.ServerName != "" {
+			mismatched++
+		}
+		if leaf := check.Chain[0]; leaf.IsExpired {
+			expired++
+		} else if leaf.DaysUntilExp <= warningDays {
+			expiring++
+		}
+	}
+
+	sortResults(r, checks, func(check *k8s.LiveCertificateCheck) sortFields {
+		return soonestExpiry(sortFields{Name: check.Target, Namespace: check.Namespace}, check.Chain...)
+	})
+
+	total := len(checks)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(checks) > limit
+	if truncated {
+		checks = checks[:limit]
+	}
+
+	response := LiveServiceCertCheckResponse{
+		Status:        api.StatusSuccess,
+		Namespace:     namespace,
+		AllNamespaces: allNamespaces,
+		WarningDays:   warningDays,
+		Summary: LiveServiceCertCheckSummary{
+			TotalTargets:       total,
+			Reachable:          total - failed,
+			Unreachable:        failed,
+			ExpiredLeaf:        expired,
+			ExpiringLeaf:       expiring,
+			HostnameMismatches: mismatched,
+		},
+		Results: checks,
+		Notes: append([]string{
+			"Every Service port that looks like TLS is dialed: at the load balancer when it terminates TLS with an ACM certificate, otherwise at the cluster IP, which only succeeds from inside the cluster",
+		}, liveCheckNotes...),
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
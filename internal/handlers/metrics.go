@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"k8s-web-service/internal/push"
+	"k8s-web-service/internal/telemetry"
+)
+
+// MetricsHandler handles the /metrics endpoint in the Prometheus text
+// format: the expiry gauges of the certificates found by the last
+// background scan, the scan failure counters, and the latency histograms and
+// error counts of AWS calls, Kubernetes API requests, and EKS token
+// generation. Certificate labels are redacted as JSON responses are. The
+// registry is built per scrape from the last scan, so series of
+// certificates that are gone are not reported again.
+func (h *Handler) MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	registry := prometheus.NewRegistry()
+	if h.scanner != nil {
+		if result := h.scanner.LastResult(); result != nil {
+			registry.MustRegister(push.NewResultCollector(result, h.redaction()))
+		}
+		registry.MustRegister(push.NewScanErrorsCollector(h.scanner.Errors()))
+	}
+	registry.MustRegister(telemetry.Collector())
+
+	// The response goes through the redaction and query wrappers of every
+	// route, which need it uncompressed
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorHandling:      promhttp.ContinueOnError,
+		DisableCompression: true,
+	}).ServeHTTP(w, r)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/lifecycle"
+	"k8s-web-service/internal/scanner"
+	"k8s-web-service/internal/telemetry"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	for _, service := range []string{"secrets", "pods"} {
+		telemetry.Observe(telemetry.Call{System: telemetry.SystemKubernetes, Service: service, Operation: "list"}, 20*time.Millisecond, nil)
+	}
+	handler := newTestHandler(&config.Config{})
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("GET /metrics = %d %s: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	metrics := rec.Body.String()
+	if n := strings.Count(metrics, "# TYPE k8s_web_service_call_duration_seconds histogram\n"); n != 1 {
+		t.Errorf("TYPE lines of the call histogram = %d, want 1:\n%s", n, metrics)
+	}
+	for _, service := range []string{"secrets", "pods"} {
+		series := `k8s_web_service_call_duration_seconds_count{operation="list",service="` + service + `",system="kubernetes"} `
+		if !strings.Contains(metrics, series) {
+			t.Errorf("metrics lack %s:\n%s", series, metrics)
+		}
+	}
+}
+
+func TestMetricsHandlerScanResult(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+	cfg.SetDefaults()
+	store := config.NewStore(cfg)
+	s, err := scanner.New(store, nil)
+	if err != nil {
+		t.Fatalf("scanner.New: %v", err)
+	}
+	if _, err := s.ScanOnce(context.Background()); err != nil {
+		t.Fatalf("ScanOnce: %v", err)
+	}
+	mux := http.NewServeMux()
+	New(store, nil, lifecycle.NewTracker(), s).Register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d: %s", rec.Code, rec.Body)
+	}
+	metrics := rec.Body.String()
+	for _, s := range []string{
+		`k8s_cert_expiry_timestamp_seconds{namespace="default",`,
+		`k8s_cert_days_until_expiry{namespace="default",`,
+		`k8s_cert_scan_certificates{namespace="default"} `,
+		"k8s_cert_scan_errors_total 0\n",
+	} {
+		if !strings.Contains(metrics, s) {
+			t.Errorf("metrics lack %s:\n%s", s, metrics)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// allNamespacesValue of the namespace parameter selects every namespace of
+// the cluster
+const allNamespacesValue = "all"
+
+// namespacesParam documents the namespace parameter of the endpoints that
+// span several namespaces
+const namespacesParam = "namespace (optional, a namespace, a comma-separated list of namespaces, or all for every namespace; default: kubernetes.default_namespace)"
+
+// multipleNamespaces reports whether the namespace parameter selects
+// several namespaces, with all or a comma-separated list. Those requests
+// are answered with one result per namespace.
+func multipleNamespaces(r *http.Request) bool {
+	namespace := r.URL.Query().Get("namespace")
+	return namespace == allNamespacesValue || strings.Contains(namespace, ",")
+}
+
+// namespacesLabel names the namespaces of a request that spans several in
+// the file names of exports
+func namespacesLabel(r *http.Request) string {
+	if r.URL.Query().Get("namespace") == allNamespacesValue {
+		return allNamespacesValue
+	}
+	return "namespaces"
+}
+
+// requestedNamespaces returns the namespaces the namespace parameter
+// selects, in the order given without duplicates, or every namespace of the
+// cluster for all
+func requestedNamespaces(ctx context.Context, r *http.Request, client *k8s.Client) ([]string, error) {
+	param := r.URL.Query().Get("namespace")
+	if param == allNamespacesValue {
+		return k8s.ListNamespaceNames(ctx, client.GetClientset())
+	}
+	var namespaces []string
+	seen := make(map[string]bool)
+	for _, namespace := range strings.Split(param, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" && !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces, nil
+}
+
+// analyzeNamespaces calls analyze for every namespace, scanner.concurrency
+// of them at once, and returns the results of the namespaces that succeeded
+// in order with the failures of the others. err is the first failure when
+// every namespace failed.
+func analyzeNamespaces[T any](ctx context.Context, concurrency int, namespaces []string, analyze func(ctx context.Context, namespace string) (T, error)) (results []T, failures []api.NamespaceFailure, err error) {
+	all, errs := k8s.MapNamespaces(ctx, namespaces, concurrency, analyze)
+	failures = []api.NamespaceFailure{}
+	for i, namespace := range namespaces {
+		if errs[i] != nil {
+			failures = append(failures, api.NamespaceFailure{Namespace: namespace, Error: errs[i].Error()})
+			if err == nil {
+				err = errs[i]
+			}
+			continue
+		}
+		results = append(results, all[i])
+	}
+	if len(results) > 0 {
+		err = nil
+	}
+	return results, failures, err
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// Namespace scan statuses
+const (
+	scanStatusNotScheduled = "not_scheduled"
+	scanStatusPending      = "pending"
+	scanStatusFailed       = "failed"
+	scanStatusWarnings     = "warnings"
+	scanStatusOK           = "ok"
+)
+
+// NamespacesHandler handles the /namespaces endpoint, listing the namespaces
+// visible to the client with their background scan status and links to the
+// per-namespace endpoints
+func (h *Handler) NamespacesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	cfg := h.cfg()
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	ctx := r.Context()
+	limit := maxResults(r)
+	namespaces, err := client.GetClientset().CoreV1().Namespaces().List(ctx, metav1.ListOptions{Limit: int64(limit)})
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list namespaces: %v", err)
+		return
+	}
+	truncated := namespaces.Continue != ""
+	if limit > 0 && len(namespaces.Items) > limit {
+		namespaces.Items = namespaces.Items[:limit]
+		truncated = true
+	}
+
+	scheduled := make(map[string]bool)
+	for _, namespace := range cfg.Scanner.Namespaces {
+		scheduled[namespace] = true
+	}
+	if cfg.ScansAllNamespaces() {
+		for _, namespace := range namespaces.Items {
+			scheduled[namespace.Name] = true
+		}
+	}
+
+	// Summaries from the most recent background scan, if any
+	reports := make(map[string]*k8s.NamespaceExpiryReport)
+	failed := make(map[string]bool)
+	// The scanner covers the default cluster only
+	scanned := h.scanner != nil && requestedCluster(r) == ""
+	scannerInfo := api.ScannerStatus{Enabled: scanned}
+	if scanned {
+		scannerInfo.Role = h.scanner.Role()
+		if result := h.scanner.LastResult(); result != nil {
+			for _, report := range result.Reports {
+				reports[report.Namespace] = report
+			}
+			for _, namespace := range result.FailedNamespaces {
+				failed[namespace] = true
+			}
+			scannerInfo.LastScan = &result.StartedAt
+		}
+		if notified, err := h.scanner.LastNotification(); err != nil {
+			scannerInfo.NotificationError = err.Error()
+		} else if !notified.IsZero() {
+			scannerInfo.LastNotification = &notified
+		}
+	}
+
+	baseURL := h.baseURL()
+	now := time.Now()
+	var namespaceList []api.NamespaceInfo
+	for _, ns := range namespaces.Items {
+		scan := api.NamespaceScan{
+			Scheduled: scheduled[ns.Name],
+			Status:    scanStatusNotScheduled,
+		}
+		switch report, scanned := reports[ns.Name]; {
+		case failed[ns.Name]:
+			scan.Status = scanStatusFailed
+		case scanned:
+			scan.Status = scanStatusOK
+			if report.TotalWarnings > 0 {
+				scan.Status = scanStatusWarnings
+			}
+			scan.TotalCertificates = &report.TotalCertificates
+			scan.TotalWarnings = &report.TotalWarnings
+		case scheduled[ns.Name] && scanned:
+			scan.Status = scanStatusPending
+		}
+
+		query := url.Values{"namespace": {ns.Name}}.Encode()
+		age := now.Sub(ns.CreationTimestamp.Time)
+		namespaceList = append(namespaceList, api.NamespaceInfo{
+			Name:    ns.Name,
+			Phase:   string(ns.Status.Phase),
+			Labels:  ns.Labels,
+			Created: ns.CreationTimestamp.Time,
+			Age:     h.formatDuration(ns.CreationTimestamp.Time, now),
+			AgeDays: int(age.Hours() / 24),
+			Scan:    scan,
+			Links: api.NamespaceLinks{
+				CertificateExpiry: fmt.Sprintf("%s/certificate-expiry?%s", baseURL, query),
+				PodCertificates:   fmt.Sprintf("%s/pod-certificates?%s", baseURL, query),
+				Pods:              fmt.Sprintf("%s/list-pods?%s", baseURL, query),
+			},
+		})
+	}
+
+	response := api.NamespacesResponse{
+		Status:     api.StatusSuccess,
+		Count:      len(namespaceList),
+		Namespaces: namespaceList,
+		Scanner:    scannerInfo,
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"k8s-web-service/internal/agent"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// NodesHandler handles the /nodes endpoint, listing nodes with their kubelet
+// version, OS, and whether kubelet certificate rotation appears enabled
+func (h *Handler) NodesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	inventory, err := k8s.ListNodeInventory(r.Context(), client.GetClientset())
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "%v", err)
+		return
+	}
+
+	notReady, clientRotation, servingRotation := 0, 0, 0
+	for _, node := range inventory.Nodes {
+		if !node.Ready {
+			notReady++
+		}
+		if node.Rotation.ClientRotation == k8s.RotationObserved {
+			clientRotation++
+		}
+		if node.Rotation.ServingRotation == k8s.RotationObserved {
+			servingRotation++
+		}
+	}
+
+	total := len(inventory.Nodes)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(inventory.Nodes) > limit
+	if truncated {
+		inventory.Nodes = inventory.Nodes[:limit]
+	}
+
+	response := NodesResponse{
+		Status: api.StatusSuccess,
+		Summary: NodesSummary{
+			TotalNodes:                   total,
+			NotReady:                     notReady,
+			KubeletVersions:              inventory.KubeletVersions,
+			ClientRotationObservedNodes:  clientRotation,
+			ServingRotationObservedNodes: servingRotation,
+			KubeletVersionSkew:           len(inventory.KubeletVersions) > 1,
+		},
+		Nodes:    inventory.Nodes,
+		CSRError: inventory.CSRError,
+		Notes: []string{
+			"Rotation status is inferred from kubelet certificate signing requests (signers kube-apiserver-client-kubelet and kubelet-serving)",
+			"Issued CSRs are garbage collected after about an hour, so not_observed does not prove rotation is disabled",
+			"Pending CSRs for kubelet-serving usually mean serverTLSBootstrap is enabled but nothing approves the requests",
+		},
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// KubeletRotationHandler handles the /nodes/kubelet-rotation endpoint,
+// reporting each kubelet's rotateCertificates and serverTLSBootstrap settings
+// from /configz with the expiry of its current certificates
+func (h *Handler) KubeletRotationHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	warningDays := h.cfg().Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	// Node agents that scan /var/lib/kubelet/pki report the certificates the
+	// kubelets actually use
+	onNode := make(map[string]k8s.NodeKubeletCertificates)
+	for _, report := range h.agents.List() {
+		clientCert, servingCert := agent.KubeletCertificates(report)
+		onNode[report.Node] = k8s.NodeKubeletCertificates{Client: clientCert, Serving: servingCert}
+	}
+
+	nodes, err := k8s.AnalyzeKubeletRotation(r.Context(), client.GetClientset(), warningDays, onNode)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "%v", err)
+		return
+	}
+
+	statuses := make(map[string]int)
+	rotationDisabled, bootstrapDisabled := 0, 0
+	for _, node := range nodes {
+		statuses[node.Status]++
+		if node.RotateCertificates != nil && !*node.RotateCertificates {
+			rotationDisabled++
+		}
+		if node.ServerTLSBootstrap != nil && !*node.ServerTLSBootstrap {
+			bootstrapDisabled++
+		}
+	}
+
+	total := len(nodes)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(nodes) > limit
+	if truncated {
+		nodes = nodes[:limit]
+	}
+	if nodes == nil {
+		nodes = []k8s.NodeKubeletRotation{}
+	}
+
+	response := KubeletRotationResponse{
+		Status:      api.StatusSuccess,
+		WarningDays: warningDays,
+		Summary: KubeletRotationSummary{
+			TotalNodes:                 total,
+			ByStatus:                   statuses,
+			RotateCertificatesDisabled: rotationDisabled,
+			ServerTLSBootstrapDisabled: bootstrapDisabled,
+		},
+		Nodes: nodes,
+		Notes: []string{
+			"Settings are read from each kubelet's /configz through the API server node proxy, which needs get on nodes/proxy",
+			"Certificates come from the node agent when it scans /var/lib/kubelet/pki, else from the node's latest issued CSR, which is garbage collected about an hour after issuance",
+			"Without serverTLSBootstrap the kubelet serves a self-signed certificate that is not rotated",
+		},
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/output"
+)
+
+// maxWarningDays bounds the warning_days parameter at ten years, beyond the
+// validity of most CA certificates
+const maxWarningDays = 3650
+
+// InvalidParam is a query parameter rejected by validateParams
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// paramValidators check the value of query parameters by name; they return
+// the reason a value is invalid, or "" if it is valid. Parameters without a
+// validator accept any value.
+var paramValidators = map[string]func(value string) string{
+	"warning_days":    validateWarningDays,
+	"namespace":       validateNamespace,
+	"secret":          validateResourceName,
+	"service":         validateServiceName,
+	"port":            validatePort,
+	"host":            validateHost,
+	"server_name":     validateHost,
+	"detailed":        oneOf("true", "false"),
+	"tls_only":        oneOf("true", "false"),
+	"full_bundles":    oneOf("true", "false"),
+	"refresh":         oneOf("true", "false"),
+	"verify":          oneOf("true", "false"),
+	"all_revisions":   oneOf("true", "false"),
+	"all_namespaces":  oneOf("true", "false"),
+	"referenced_only": oneOf("true", "false"),
+	"format":          oneOf("json", "table", output.FormatCSV, output.FormatXLSX),
+	"regions":         validateRegions,
+	"certificate_arn": validateARN,
+	"tz":              validateTimezone,
+	"sort":            oneOf("days_until_expiry", "name", "namespace", "issuer"),
+	"order":           oneOf("asc", "desc"),
+	"query":           validateQuery,
+	"include":         oneOf(k8s.ScanSections...),
+}
+
+// paramName returns the name of a documented route parameter such as
+// "namespace (optional)"
+func paramName(param string) string {
+	name, _, _ := strings.Cut(param, " ")
+	return name
+}
+
+// paramList reports whether a documented route parameter takes a
+// comma-separated list
+func paramList(param string) bool {
+	return strings.Contains(param, "comma-separated")
+}
+
+// validateParams responds with 400 when the query has parameters the route
+// does not document (every route accepts query, see shapeResponse, and
+// cluster, see requireCluster), repeats a parameter, lacks a required one, or has a
+// value its validator rejects. Every problem is listed in invalid_params.
+func validateParams(route Route, next http.HandlerFunc) http.HandlerFunc {
+	documented := map[string]string{"query": queryParam, "cluster": clusterParam}
+	for _, param := range route.Parameters {
+		documented[paramName(param)] = param
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var invalid []InvalidParam
+		reject := func(name, format string, args ...interface{}) {
+			invalid = append(invalid, InvalidParam{Name: name, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		names := make([]string, 0, len(query))
+		for name := range query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			param, ok := documented[name]
+			if !ok {
+				reject(name, "unknown parameter")
+				continue
+			}
+			values := query[name]
+			if len(values) > 1 {
+				reject(name, "given %d times", len(values))
+				continue
+			}
+			// An empty value is the same as leaving the parameter out
+			validate := paramValidators[name]
+			if validate == nil || values[0] == "" {
+				continue
+			}
+			items := []string{values[0]}
+			if paramList(param) {
+				items = strings.Split(values[0], ",")
+			}
+			for _, item := range items {
+				if item = strings.TrimSpace(item); item == "" {
+					continue
+				}
+				if reason := validate(item); reason != "" {
+					reject(name, "%s", reason)
+					break
+				}
+			}
+		}
+		for _, param := range route.Parameters {
+			if strings.Contains(param, "(required") && query.Get(paramName(param)) == "" {
+				reject(paramName(param), "required")
+			}
+		}
+
+		if len(invalid) > 0 {
+			problem := newProblem(r, http.StatusBadRequest, ProblemInvalidParameters, invalidParamsDetail(invalid))
+			problem.Extensions = map[string]interface{}{"invalid_params": invalid}
+			encodeProblem(w, problem)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// invalidParamsDetail summarizes rejected parameters in one sentence
+func invalidParamsDetail(invalid []InvalidParam) string {
+	reasons := make([]string, len(invalid))
+	for i, param := range invalid {
+		reasons[i] = param.Name + ": " + param.Reason
+	}
+	return "Invalid query parameters: " + strings.Join(reasons, "; ")
+}
+
+// validateWarningDays accepts a whole number of days up to maxWarningDays
+func validateWarningDays(value string) string {
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 || days > maxWarningDays {
+		return fmt.Sprintf("%q is not a whole number of days between 1 and %d", value, maxWarningDays)
+	}
+	return ""
+}
+
+// validateNamespace accepts a DNS-1123 label, the format of namespace names
+func validateNamespace(value string) string {
+	if !config.IsDNS1123Label(value) {
+		return fmt.Sprintf("%q is not a valid namespace name (lowercase letters, digits, and '-', at most 63 characters)", value)
+	}
+	return ""
+}
+
+// validateResourceName accepts a DNS-1123 subdomain, the format of most
+// resource names such as secrets
+func validateResourceName(value string) string {
+	valid := len(value) <= 253
+	for _, label := range strings.Split(value, ".") {
+		valid = valid && config.IsDNS1123Label(label)
+	}
+	if !valid {
+		return fmt.Sprintf("%q is not a valid resource name (lowercase letters, digits, '-', and '.', at most 253 characters)", value)
+	}
+	return ""
+}
+
+// validateServiceName accepts a DNS-1123 label, the format of Service names
+func validateServiceName(value string) string {
+	if !config.IsDNS1123Label(value) {
+		return fmt.Sprintf("%q is not a valid Service name (lowercase letters, digits, and '-', at most 63 characters)", value)
+	}
+	return ""
+}
+
+// validatePort accepts a TCP port number
+func validatePort(value string) string {
+	if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+		return fmt.Sprintf("%q is not a port number between 1 and 65535", value)
+	}
+	return ""
+}
+
+// validateHost accepts an IP address or a DNS name
+func validateHost(value string) string {
+	if net.ParseIP(value) != nil {
+		return ""
+	}
+	valid := len(value) <= 253
+	for _, label := range strings.Split(strings.ToLower(strings.TrimSuffix(value, ".")), ".") {
+		valid = valid && config.IsDNS1123Label(label)
+	}
+	if !valid {
+		return fmt.Sprintf("%q is not an IP address or DNS name", value)
+	}
+	return ""
+}
+
+// validateRegions accepts AWS region names
+func validateRegions(value string) string {
+	if !config.IsAWSRegion(value) {
+		return fmt.Sprintf("%q is not an AWS region (e.g. us-gov-west-1)", value)
+	}
+	return ""
+}
+
+// validateTimezone accepts IANA time zone names
+func validateTimezone(value string) string {
+	if _, err := time.LoadLocation(value); err != nil {
+		return fmt.Sprintf("%q is not an IANA time zone (e.g. UTC, Europe/Berlin)", value)
+	}
+	return ""
+}
+
+// validateARN accepts Amazon Resource Names
+func validateARN(value string) string {
+	if parts := strings.SplitN(value, ":", 6); len(parts) != 6 || parts[0] != "arn" {
+		return fmt.Sprintf("%q is not an ARN (arn:partition:service:region:account:resource)", value)
+	}
+	return ""
+}
+
+// oneOf returns a validator accepting the given values
+func oneOf(allowed ...string) func(string) string {
+	return func(value string) string {
+		for _, a := range allowed {
+			if value == a {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+}
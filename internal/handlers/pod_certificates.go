@@ -7,166 +7,79 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/output"
+	"k8s-web-service/pkg/api"
 )
 
-// PodCertificatesHandler handles the /pod-certificates endpoint
-func (h *Handler) PodCertificatesHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	namespace := h.config.Kubernetes.DefaultNamespace
-	if ns := r.URL.Query().Get("namespace"); ns != "" {
-		namespace = ns
-	}
-
-	// Create Kubernetes client
-	client, err := k8s.NewClient(h.config)
-	if err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("Failed to create Kubernetes client: %v", err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	// List pods
-	ctx := context.Background()
-	pods, err := client.GetClientset().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		response := map[string]interface{}{
-			"status": "error",
-			"error":  fmt.Sprintf("Failed to list pods in namespace %s: %v", namespace, err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	// Get cluster CA
-	eksDetails := client.GetEKSDetails()
-
-	// Analyze pods for certificate information
-	var podCertificates []map[string]interface{}
-	for _, pod := range pods.Items {
-		podInfo := map[string]interface{}{
-			"name":      pod.Name,
-			"namespace": pod.Namespace,
-		}
-
-		// Analyze volume mounts for certificates
-		var volumeMounts []map[string]interface{}
-		var volumes []map[string]interface{}
-
-		for _, container := range pod.Spec.Containers {
-			for _, mount := range container.VolumeMounts {
-				if isCertificateMount(mount.MountPath) {
-					volumeMounts = append(volumeMounts, map[string]interface{}{
-						"container":   container.Name,
-						"mount_path":  mount.MountPath,
-						"volume_name": mount.Name,
-						"read_only":   mount.ReadOnly,
-					})
-				}
-			}
-		}
-
-		// Analyze volumes for certificate sources
-		for _, volume := range pod.Spec.Volumes {
-			volumeInfo := map[string]interface{}{
-				"name": volume.Name,
-				"type": getVolumeType(volume),
-			}
-
-			if volume.Secret != nil {
-				volumeInfo["secret_name"] = volume.Secret.SecretName
-			}
-			if volume.ConfigMap != nil {
-				volumeInfo["configmap_name"] = volume.ConfigMap.Name
-			}
-
-			volumes = append(volumes, volumeInfo)
-		}
-
-		podInfo["volume_mounts"] = volumeMounts
-		podInfo["volumes"] = volumes
-		podCertificates = append(podCertificates, podInfo)
-	}
-
-	response := map[string]interface{}{
-		"status":           "success",
-		"message":          fmt.Sprintf("Retrieved certificate information for %d pods in namespace '%s'", len(podCertificates), namespace),
-		"target_namespace": namespace,
-		"cluster_ca_info": map[string]interface{}{
-			"description": "The cluster CA certificate used by your kubeconfig",
-			"length":      len(eksDetails.ClusterCA),
-			"source":      "kubeconfig certificate-authority-data",
-		},
-		"pods": podCertificates,
-		"notes": []string{
-			"All pods automatically receive the Kubernetes cluster CA at /var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
-			"Additional certificates may be mounted via secrets, configmaps, or projected volumes",
-			"To extract actual certificate content, you need to exec into the pod or read the secret/configmap directly",
-		},
-	}
-
-	json.NewEncoder(w).Encode(response)
+// podCertificates is the pod certificate analysis of one namespace
+type podCertificates struct {
+	namespace string
+	pods      []podCertInfo
+	byCompute map[string]int
+	warnings  []string
+	// listed is the number of pods listed, and truncated reports that the
+	// namespace has more
+	listed    int
+	truncated bool
 }
 
-// HandlePodCertificates handles requests for pod certificate information with expiry analysis
-func (h *Handler) HandlePodCertificates(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+// podCertInfo is a pod of a /pod-certificates response with the certificate
+// sources that are verified and summarized before the response is written
+type podCertInfo struct {
+	api.PodCertInfo
+	sources map[string]*k8s.CertificateSource
+}
 
-	// Get namespace from query parameter or use default
-	namespace := r.URL.Query().Get("namespace")
-	if namespace == "" {
-		namespace = h.config.Kubernetes.DefaultNamespace
+// podCertInfos returns the pods of a /pod-certificates response
+func podCertInfos(pods []podCertInfo) []api.PodCertInfo {
+	if pods == nil {
+		return nil
 	}
-
-	// Get warning days from query parameter (default 30 days)
-	warningDaysStr := r.URL.Query().Get("warning_days")
-	warningDays := 30
-	if warningDaysStr != "" {
-		if days, err := strconv.Atoi(warningDaysStr); err == nil && days > 0 {
-			warningDays = days
-		}
+	converted := make([]api.PodCertInfo, len(pods))
+	for i, pod := range pods {
+		converted[i] = pod.PodCertInfo
+		converted[i].CertificateSources = apiCertificateSourceMap(pod.sources)
 	}
+	return converted
+}
 
-	// Get detailed analysis flag
-	detailed := r.URL.Query().Get("detailed") == "true"
-
-	// Create Kubernetes client
-	client, err := k8s.NewClient(h.config)
+// analyzePodCertificates lists up to limit pods of a namespace with their
+// volumes and, if detailed, analyzes the certificates they mount
+func (h *Handler) analyzePodCertificates(ctx context.Context, client *k8s.Client, namespace string, warningDays int, detailed bool, limit int) (*podCertificates, error) {
+	pods, err := client.GetClientset().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{Limit: int64(limit)})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to create Kubernetes client: %v", err), http.StatusInternalServerError)
-		return
+		return nil, err
 	}
-
-	pods, err := client.GetClientset().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to list pods: %v", err), http.StatusInternalServerError)
-		return
+	truncated := truncatePods(pods, limit)
+	result := &podCertificates{
+		namespace: namespace,
+		byCompute: make(map[string]int),
+		listed:    len(pods.Items),
+		truncated: truncated,
 	}
-
-	eksDetails := client.GetEKSDetails()
-
-	var podCertInfos []PodCertInfo
-	var allExpiryWarnings []string
-
+	compute := k8s.NewComputeResolver(client.GetClientset())
+	origins := k8s.NewOriginResolver(client.GetClientset(), namespace)
 	for _, pod := range pods.Items {
-		podInfo := PodCertInfo{
+		podCompute := compute.Resolve(ctx, &pod)
+		podInfo := podCertInfo{PodCertInfo: api.PodCertInfo{
 			Name:      pod.Name,
 			Namespace: pod.Namespace,
-		}
+			Compute:   apiComputeInfo(podCompute),
+			Runtime:   apiPodRuntime(k8s.PodRuntimeStatus(&pod)),
+			Origin:    apiPodOrigin(origins.Resolve(ctx, &pod)),
+		}}
+		result.byCompute[podCompute.String()]++
 
 		// Get volume mounts and volumes (existing logic)
 		for _, container := range pod.Spec.Containers {
 			for _, mount := range container.VolumeMounts {
-				podInfo.VolumeMounts = append(podInfo.VolumeMounts, VolumeMount{
+				podInfo.VolumeMounts = append(podInfo.VolumeMounts, api.VolumeMount{
 					Name:      mount.Name,
 					MountPath: mount.MountPath,
 					ReadOnly:  mount.ReadOnly,
@@ -176,9 +89,9 @@ func (h *Handler) HandlePodCertificates(w http.ResponseWriter, r *http.Request)
 		}
 
 		for _, volume := range pod.Spec.Volumes {
-			volumeInfo := Volume{
+			volumeInfo := api.Volume{
 				Name: volume.Name,
-				Type: getVolumeType(volume),
+				Type: volumeType(&volume),
 			}
 
 			if volume.Secret != nil {
@@ -198,61 +111,216 @@ func (h *Handler) HandlePodCertificates(w http.ResponseWriter, r *http.Request)
 		if detailed {
 			certSources, err := k8s.AnalyzePodCertificates(ctx, client, namespace, pod.Name)
 			if err == nil {
-				podInfo.CertificateSources = certSources
+				podInfo.sources = certSources
 
 				// Get expiry warnings for this pod
 				warnings := k8s.GetCertificateExpiryWarnings(certSources, warningDays)
 				if len(warnings) > 0 {
 					podInfo.ExpiryWarnings = warnings
 					for _, warning := range warnings {
-						allExpiryWarnings = append(allExpiryWarnings, fmt.Sprintf("Pod %s: %s", pod.Name, warning))
+						result.warnings = append(result.warnings, fmt.Sprintf("Pod %s: %s", pod.Name, warning))
 					}
 				}
 			}
 		}
 
-		podCertInfos = append(podCertInfos, podInfo)
+		result.pods = append(result.pods, podInfo)
+	}
+	return result, nil
+}
+
+// finishPodCertificates sorts the pods of a namespace as the request asks,
+// sets the dates of their certificates, and verifies and summarizes their
+// certificate sources
+func (h *Handler) finishPodCertificates(r *http.Request, warningDays int, verify func(...chainSource), pods []podCertInfo) {
+	// The API server applies the limit, so sorting orders the returned page
+	sortResults(r, pods, func(pod *podCertInfo) sortFields {
+		return sourcesExpiry(sortFields{Name: pod.Name, Namespace: pod.Namespace}, pod.sources)
+	})
+	now := time.Now()
+	for _, pod := range pods {
+		for _, source := range pod.sources {
+			h.setSourceDates(r, now, source)
+			verify(source)
+			h.summarizeBundles(r, warningDays, source)
+		}
+	}
+}
+
+// podCertificatesNotes returns the notes of the /pod-certificates responses
+func podCertificatesNotes() []string {
+	return []string{
+		"All pods automatically receive the Kubernetes cluster CA at /var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+		"Additional certificates may be mounted via secrets, configmaps, or projected volumes",
+		"To extract actual certificate content, you need to exec into the pod or read the secret/configmap directly",
+	}
+}
+
+// detailedNotes returns the notes of /pod-certificates about the detailed
+// analysis
+func detailedNotes(detailed bool, warningDays int) []string {
+	if !detailed {
+		return []string{"Use ?detailed=true to include certificate expiry analysis"}
+	}
+	return []string{
+		fmt.Sprintf("Certificate expiry analysis performed with %d day warning threshold", warningDays),
+		"Use ?detailed=true&warning_days=N to customize the warning threshold",
+	}
+}
+
+// HandlePodCertificates handles requests for pod certificate information with expiry analysis
+func (h *Handler) HandlePodCertificates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get namespace from query parameter or use default
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = h.cfg().Kubernetes.DefaultNamespace
+	}
+
+	// Get warning days from query parameter (default from scanner.warning_days)
+	warningDaysStr := r.URL.Query().Get("warning_days")
+	warningDays := h.cfg().Scanner.WarningDays
+	if warningDaysStr != "" {
+		if days, err := strconv.Atoi(warningDaysStr); err == nil && days > 0 {
+			warningDays = days
+		}
+	}
+
+	// Get detailed analysis flag; spreadsheets list the certificates found
+	// by the detailed analysis
+	detailed := r.URL.Query().Get("detailed") == "true" || exportFormat(r) != ""
+
+	// Create Kubernetes client
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	if multipleNamespaces(r) {
+		h.handleNamespacesPodCertificates(w, r, client, warningDays, detailed)
+		return
 	}
 
-	response := PodCertificatesResponse{
-		Status:          "success",
-		Message:         fmt.Sprintf("Retrieved certificate information for %d pods in namespace '%s'", len(pods.Items), namespace),
+	limit := maxResults(r)
+	result, err := h.analyzePodCertificates(ctx, client, namespace, warningDays, detailed, limit)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list pods: %v", err)
+		return
+	}
+	h.finishPodCertificates(r, warningDays, h.chainVerifier(r), result.pods)
+
+	eksDetails := client.GetEKSDetails()
+	response := api.PodCertificatesResponse{
+		Status:          api.StatusSuccess,
+		Message:         fmt.Sprintf("Retrieved certificate information for %d pods in namespace '%s'", result.listed, namespace),
 		TargetNamespace: namespace,
-		ClusterCAInfo: ClusterCAInfo{
+		ClusterCAInfo: api.ClusterCAInfo{
 			Description: "The cluster CA certificate used by your kubeconfig",
 			Length:      len(eksDetails.ClusterCA),
 			Source:      "kubeconfig certificate-authority-data",
 		},
-		Pods:           podCertInfos,
-		ExpiryWarnings: allExpiryWarnings,
-		Notes: []string{
-			"All pods automatically receive the Kubernetes cluster CA at /var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
-			"Additional certificates may be mounted via secrets, configmaps, or projected volumes",
-			"To extract actual certificate content, you need to exec into the pod or read the secret/configmap directly",
-		},
+		Pods:           podCertInfos(result.pods),
+		ByCompute:      result.byCompute,
+		ExpiryWarnings: result.warnings,
+		Truncated:      result.truncated,
+		Notes:          podCertificatesNotes(),
 	}
 
-	if detailed {
-		response.Notes = append(response.Notes,
-			fmt.Sprintf("Certificate expiry analysis performed with %d day warning threshold", warningDays),
-			"Use ?detailed=true&warning_days=N to customize the warning threshold",
-		)
-	} else {
-		response.Notes = append(response.Notes, "Use ?detailed=true to include certificate expiry analysis")
+	if result.truncated {
+		response.Notes = append(response.Notes, fmt.Sprintf("Results limited to %d pods by the max_results limit", limit))
 	}
+	response.Notes = append(response.Notes, detailedNotes(detailed, warningDays)...)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	sheet := h.certificateSheet(r, "pod-certificates-"+namespace, warningDays)
+	if sheet != nil {
+		for _, pod := range result.pods {
+			sheet.AddSources(pod.Namespace, pod.Name, pod.sources)
+		}
+	}
+	render(w, r, response, sheet)
+}
+
+// handleNamespacesPodCertificates answers /pod-certificates for several
+// namespaces, analyzed scanner.concurrency at a time, with the pods of each
+// namespace in its own result. max_results applies to every namespace.
+func (h *Handler) handleNamespacesPodCertificates(w http.ResponseWriter, r *http.Request, client *k8s.Client, warningDays int, detailed bool) {
+	namespaces, err := requestedNamespaces(r.Context(), r, client)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list namespaces: %v", err)
+		return
+	}
+
+	limit := maxResults(r)
+	results, failures, err := analyzeNamespaces(r.Context(), h.cfg().Scanner.Concurrency, namespaces, func(ctx context.Context, namespace string) (*podCertificates, error) {
+		return h.analyzePodCertificates(ctx, client, namespace, warningDays, detailed, limit)
+	})
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list pods: %v", err)
+		return
+	}
+
+	eksDetails := client.GetEKSDetails()
+	response := api.NamespacesPodCertificatesResponse{
+		Status:     api.StatusSuccess,
+		Namespaces: namespaces,
+		ClusterCAInfo: api.ClusterCAInfo{
+			Description: "The cluster CA certificate used by your kubeconfig",
+			Length:      len(eksDetails.ClusterCA),
+			Source:      "kubeconfig certificate-authority-data",
+		},
+		Results:          []api.NamespacePodCertificates{},
+		ByCompute:        make(map[string]int),
+		FailedNamespaces: failures,
+		Notes:            podCertificatesNotes(),
+	}
+	sheet := h.certificateSheet(r, "pod-certificates-"+namespacesLabel(r), warningDays)
+	verify := h.chainVerifier(r)
+	total := 0
+	for _, result := range results {
+		h.finishPodCertificates(r, warningDays, verify, result.pods)
+		entry := api.NamespacePodCertificates{
+			Namespace:      result.namespace,
+			Pods:           podCertInfos(result.pods),
+			ExpiryWarnings: result.warnings,
+			Truncated:      result.truncated,
+		}
+		if entry.Pods == nil {
+			entry.Pods = []api.PodCertInfo{}
+		}
+		response.Results = append(response.Results, entry)
+		response.Truncated = response.Truncated || result.truncated
+		for compute, count := range result.byCompute {
+			response.ByCompute[compute] += count
+		}
+		total += result.listed
+		if sheet != nil {
+			for _, pod := range result.pods {
+				sheet.AddSources(pod.Namespace, pod.Name, pod.sources)
+			}
+		}
+	}
+	response.Message = fmt.Sprintf("Retrieved certificate information for %d pods in %d namespaces", total, len(results))
+
+	if response.Truncated {
+		response.Notes = append(response.Notes, fmt.Sprintf("Results limited to %d pods per namespace by the max_results limit", limit))
+	}
+	if len(failures) > 0 {
+		response.Notes = append(response.Notes, "Namespaces that could not be analyzed are listed in failed_namespaces")
+	}
+	response.Notes = append(response.Notes, detailedNotes(detailed, warningDays)...)
+	render(w, r, response, sheet)
 }
 
 // HandlePodCertificateDetails handles requests for detailed certificate analysis of a specific pod
 func (h *Handler) HandlePodCertificateDetails(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 
 	// Get pod name from URL path
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 3 || pathParts[2] == "" {
-		http.Error(w, "Pod name is required in URL path: /pod-certificates/{pod-name}", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, ProblemParseFailure, "Pod name is required in URL path: /pod-certificates/{pod-name}")
 		return
 	}
 	podName := pathParts[2]
@@ -260,12 +328,12 @@ func (h *Handler) HandlePodCertificateDetails(w http.ResponseWriter, r *http.Req
 	// Get namespace from query parameter or use default
 	namespace := r.URL.Query().Get("namespace")
 	if namespace == "" {
-		namespace = h.config.Kubernetes.DefaultNamespace
+		namespace = h.cfg().Kubernetes.DefaultNamespace
 	}
 
-	// Get warning days from query parameter (default 30 days)
+	// Get warning days from query parameter (default from scanner.warning_days)
 	warningDaysStr := r.URL.Query().Get("warning_days")
-	warningDays := 30
+	warningDays := h.cfg().Scanner.WarningDays
 	if warningDaysStr != "" {
 		if days, err := strconv.Atoi(warningDaysStr); err == nil && days > 0 {
 			warningDays = days
@@ -273,36 +341,49 @@ func (h *Handler) HandlePodCertificateDetails(w http.ResponseWriter, r *http.Req
 	}
 
 	// Create Kubernetes client
-	client, err := k8s.NewClient(h.config)
+	client, err := h.client(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to create Kubernetes client: %v", err), http.StatusInternalServerError)
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
 		return
 	}
 
 	// Analyze certificates for the specific pod
 	certSources, err := k8s.AnalyzePodCertificates(ctx, client, namespace, podName)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to analyze certificates for pod %s: %v", podName, err), http.StatusInternalServerError)
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to analyze certificates for pod %s: %v", podName, err)
 		return
 	}
 
 	// Get expiry warnings
 	warnings := k8s.GetCertificateExpiryWarnings(certSources, warningDays)
+	now := time.Now()
+	verify := h.chainVerifier(r)
+	for _, source := range certSources {
+		h.setSourceDates(r, now, source)
+		verify(source)
+		h.summarizeBundles(r, warningDays, source)
+	}
 
-	response := map[string]interface{}{
-		"status":              "success",
-		"message":             fmt.Sprintf("Certificate analysis for pod '%s' in namespace '%s'", podName, namespace),
-		"pod_name":            podName,
-		"namespace":           namespace,
-		"warning_days":        warningDays,
-		"certificate_sources": certSources,
-		"expiry_warnings":     warnings,
-		"summary": map[string]interface{}{
-			"total_sources":      len(certSources),
-			"total_certificates": getTotalCertificateCount(certSources),
-			"warnings_count":     len(warnings),
+	response := api.PodCertificateDetailsResponse{
+		Status:             api.StatusSuccess,
+		Message:            fmt.Sprintf("Certificate analysis for pod '%s' in namespace '%s'", podName, namespace),
+		PodName:            podName,
+		Namespace:          namespace,
+		WarningDays:        warningDays,
+		CertificateSources: apiCertificateSourceMap(certSources),
+		ExpiryWarnings:     warnings,
+		Findings:           apiFindings(k8s.RunAnalyzers(namespace, podName, certSources)),
+		Summary: api.PodCertificateSummary{
+			TotalSources:      len(certSources),
+			TotalCertificates: k8s.CountCertificates(certSources),
+			WarningsCount:     len(warnings),
 		},
 	}
+	if pod, err := client.GetClientset().CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{}); err == nil {
+		response.Compute = apiComputeInfo(k8s.NewComputeResolver(client.GetClientset()).Resolve(ctx, pod))
+		response.Runtime = apiPodRuntime(k8s.PodRuntimeStatus(pod))
+		response.Origin = apiPodOrigin(k8s.NewOriginResolver(client.GetClientset(), namespace).Resolve(ctx, pod))
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -310,107 +391,242 @@ func (h *Handler) HandlePodCertificateDetails(w http.ResponseWriter, r *http.Req
 
 // HandleCertificateExpiry handles requests for certificate expiry analysis across the namespace
 func (h *Handler) HandleCertificateExpiry(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 
 	// Get namespace from query parameter or use default
 	namespace := r.URL.Query().Get("namespace")
 	if namespace == "" {
-		namespace = h.config.Kubernetes.DefaultNamespace
+		namespace = h.cfg().Kubernetes.DefaultNamespace
 	}
 
-	// Get warning days from query parameter (default 30 days)
+	// Get warning days from query parameter (default from scanner.warning_days)
 	warningDaysStr := r.URL.Query().Get("warning_days")
-	warningDays := 30
+	warningDays := h.cfg().Scanner.WarningDays
 	if warningDaysStr != "" {
 		if days, err := strconv.Atoi(warningDaysStr); err == nil && days > 0 {
 			warningDays = days
 		}
 	}
 
-	// Create Kubernetes client
-	client, err := k8s.NewClient(h.config)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to create Kubernetes client: %v", err), http.StatusInternalServerError)
+	if multipleNamespaces(r) {
+		h.handleNamespacesExpiry(w, r, warningDays)
 		return
 	}
 
-	// Get pods in the namespace
-	pods, err := client.GetClientset().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to list pods: %v", err), http.StatusInternalServerError)
-		return
-	}
+	// Serve the last background scan of the namespace when it used the same
+	// threshold, instead of analyzing every pod again
+	report, scanned := h.scannedReport(r, namespace, warningDays)
+	if report == nil {
+		client, err := h.client(r)
+		if err != nil {
+			writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+			return
+		}
 
-	type PodExpiryInfo struct {
-		PodName      string                            `json:"pod_name"`
-		CertSources  map[string]*k8s.CertificateSource `json:"certificate_sources"`
-		Warnings     []string                          `json:"warnings"`
-		WarningCount int                               `json:"warning_count"`
-		CertCount    int                               `json:"certificate_count"`
+		// Analyze every pod in the namespace
+		report, err = k8s.AnalyzeNamespaceExpiry(ctx, client, namespace, warningDays)
+		if err != nil {
+			writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to analyze certificates: %v", err)
+			return
+		}
 	}
 
-	var podExpiryInfos []PodExpiryInfo
-	var allWarnings []string
-	totalCerts := 0
-	totalWarnings := 0
+	sortResults(r, report.Pods, func(pod *k8s.PodExpiryInfo) sortFields {
+		return sourcesExpiry(sortFields{Name: pod.PodName, Namespace: namespace}, pod.CertSources)
+	})
 
-	for _, pod := range pods.Items {
-		certSources, err := k8s.AnalyzePodCertificates(ctx, client, namespace, pod.Name)
-		if err != nil {
-			continue // Skip pods with errors
-		}
+	// Only the pods with certificates are returned, so the limit applies to those
+	limit := maxResults(r)
+	truncated := limit > 0 && len(report.Pods) > limit
+	if truncated {
+		report.Pods = report.Pods[:limit]
+	}
 
-		warnings := k8s.GetCertificateExpiryWarnings(certSources, warningDays)
-		certCount := getTotalCertificateCount(certSources)
+	h.finishExpiryReport(r, warningDays, h.chainVerifier(r), report)
+
+	response := api.CertificateExpiryResponse{
+		Status:      api.StatusSuccess,
+		Message:     fmt.Sprintf("Certificate expiry analysis for namespace '%s'", namespace),
+		Namespace:   namespace,
+		WarningDays: warningDays,
+		Summary: api.CertificateExpirySummary{
+			TotalPodsAnalyzed:    report.TotalPods,
+			PodsWithCertificates: len(report.Pods),
+			TotalCertificates:    report.TotalCertificates,
+			TotalWarnings:        report.TotalWarnings,
+		},
+		PodExpiryInfo:   apiPodExpiryInfo(report.Pods),
+		CustomResources: apiCertificateSources(report.CustomResources),
+		AllWarnings:     report.Warnings,
+		Findings:        apiFindings(report.Findings),
+		Notes: []string{
+			fmt.Sprintf("Analysis performed with %d day warning threshold", warningDays),
+			"Use ?warning_days=N to customize the warning threshold",
+			"Only pods with certificates or warnings are included in the results",
+		},
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	if !scanned.IsZero() {
+		response.LastScanned = &scanned
+		response.Notes = append(response.Notes, "Served from the last background scan; use ?refresh=true to analyze now")
+	}
 
-		if len(warnings) > 0 || certCount > 0 {
-			podInfo := PodExpiryInfo{
-				PodName:      pod.Name,
-				CertSources:  certSources,
-				Warnings:     warnings,
-				WarningCount: len(warnings),
-				CertCount:    certCount,
-			}
-			podExpiryInfos = append(podExpiryInfos, podInfo)
+	sheet := h.certificateSheet(r, "certificate-expiry-"+namespace, warningDays)
+	addExpiryReport(sheet, report)
+	render(w, r, response, sheet)
+}
 
-			for _, warning := range warnings {
-				allWarnings = append(allWarnings, fmt.Sprintf("Pod %s: %s", pod.Name, warning))
-			}
+// finishExpiryReport sets the dates of the certificates of a namespace
+// expiry report, and verifies and summarizes its sources
+func (h *Handler) finishExpiryReport(r *http.Request, warningDays int, verify func(...chainSource), report *k8s.NamespaceExpiryReport) {
+	h.setExpiryReportDates(r, time.Now(), report)
+	for _, pod := range report.Pods {
+		for _, source := range pod.CertSources {
+			verify(source)
+			h.summarizeBundles(r, warningDays, source)
 		}
+	}
+	h.summarizeBundles(r, warningDays, report.CustomResources...)
+}
 
-		totalCerts += certCount
-		totalWarnings += len(warnings)
+// setExpiryReportDates sets the dates of the certificates of a namespace
+// expiry report
+func (h *Handler) setExpiryReportDates(r *http.Request, now time.Time, report *k8s.NamespaceExpiryReport) {
+	for _, pod := range report.Pods {
+		for _, source := range pod.CertSources {
+			h.setSourceDates(r, now, source)
+		}
 	}
+	h.setSourceDates(r, now, report.CustomResources...)
+}
 
-	response := map[string]interface{}{
-		"status":       "success",
-		"message":      fmt.Sprintf("Certificate expiry analysis for namespace '%s'", namespace),
-		"namespace":    namespace,
-		"warning_days": warningDays,
-		"summary": map[string]interface{}{
-			"total_pods_analyzed":    len(pods.Items),
-			"pods_with_certificates": len(podExpiryInfos),
-			"total_certificates":     totalCerts,
-			"total_warnings":         totalWarnings,
-		},
-		"pod_expiry_info": podExpiryInfos,
-		"all_warnings":    allWarnings,
-		"notes": []string{
+// addExpiryReport adds the certificates of a namespace expiry report to a
+// sheet, if not nil
+func addExpiryReport(sheet *output.CertificateSheet, report *k8s.NamespaceExpiryReport) {
+	if sheet == nil {
+		return
+	}
+	for _, pod := range report.Pods {
+		sheet.AddSources(report.Namespace, pod.PodName, pod.CertSources)
+	}
+	for _, source := range report.CustomResources {
+		sheet.AddSource(report.Namespace, "", source)
+	}
+}
+
+// handleNamespacesExpiry answers /certificate-expiry for several namespaces
+// with the report of each. Namespaces the last background scan covered at
+// the same threshold are served from it and the others analyzed,
+// scanner.concurrency at a time. max_results applies to the pods of all
+// namespaces together, in the order of the namespaces.
+func (h *Handler) handleNamespacesExpiry(w http.ResponseWriter, r *http.Request, warningDays int) {
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+	namespaces, err := requestedNamespaces(r.Context(), r, client)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list namespaces: %v", err)
+		return
+	}
+
+	response := api.NamespacesExpiryResponse{
+		Status:      api.StatusSuccess,
+		Namespaces:  namespaces,
+		WarningDays: warningDays,
+		Reports:     []*api.NamespaceExpiryReport{},
+		Notes: []string{
 			fmt.Sprintf("Analysis performed with %d day warning threshold", warningDays),
 			"Use ?warning_days=N to customize the warning threshold",
 			"Only pods with certificates or warnings are included in the results",
 		},
 	}
+	scanned := make(map[string]*k8s.NamespaceExpiryReport)
+	for _, namespace := range namespaces {
+		if report, at := h.scannedReport(r, namespace, warningDays); report != nil {
+			scanned[namespace] = report
+			response.ScannedNamespaces = append(response.ScannedNamespaces, namespace)
+			response.LastScanned = &at
+		}
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	reports, failures, err := analyzeNamespaces(r.Context(), h.cfg().Scanner.Concurrency, namespaces, func(ctx context.Context, namespace string) (*k8s.NamespaceExpiryReport, error) {
+		if report := scanned[namespace]; report != nil {
+			return report, nil
+		}
+		return k8s.AnalyzeNamespaceExpiry(ctx, client, namespace, warningDays)
+	})
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to analyze certificates: %v", err)
+		return
+	}
+	response.FailedNamespaces = failures
+
+	limit := maxResults(r)
+	remaining := limit
+	sheet := h.certificateSheet(r, "certificate-expiry-"+namespacesLabel(r), warningDays)
+	verify := h.chainVerifier(r)
+	for _, report := range reports {
+		sortResults(r, report.Pods, func(pod *k8s.PodExpiryInfo) sortFields {
+			return sourcesExpiry(sortFields{Name: pod.PodName, Namespace: report.Namespace}, pod.CertSources)
+		})
+		if limit > 0 {
+			if len(report.Pods) > remaining {
+				report.Pods = report.Pods[:remaining]
+				response.Truncated = true
+			}
+			remaining -= len(report.Pods)
+		}
+		h.finishExpiryReport(r, warningDays, verify, report)
+		addExpiryReport(sheet, report)
+
+		response.Reports = append(response.Reports, apiExpiryReport(report))
+		response.Summary.TotalPodsAnalyzed += report.TotalPods
+		response.Summary.PodsWithCertificates += len(report.Pods)
+		response.Summary.TotalCertificates += report.TotalCertificates
+		response.Summary.TotalWarnings += report.TotalWarnings
+	}
+	response.Summary.NamespacesAnalyzed = len(reports)
+	response.Summary.FailedNamespaces = len(failures)
+	response.Message = fmt.Sprintf("Certificate expiry analysis for %d namespaces", len(reports))
+
+	if response.Truncated {
+		response.MaxResults = limit
+	}
+	if len(failures) > 0 {
+		response.Notes = append(response.Notes, "Namespaces that could not be analyzed are listed in failed_namespaces")
+	}
+	if len(response.ScannedNamespaces) > 0 {
+		response.Notes = append(response.Notes, "scanned_namespaces were served from the last background scan; use ?refresh=true to analyze them now")
+	}
+	render(w, r, response, sheet)
 }
 
-// getTotalCertificateCount counts total certificates across all sources
-func getTotalCertificateCount(certSources map[string]*k8s.CertificateSource) int {
-	total := 0
-	for _, source := range certSources {
-		total += len(source.Certificates)
+// volumeType returns the kind of source of a pod volume, such as secret or
+// configMap
+func volumeType(volume *corev1.Volume) string {
+	switch {
+	case volume.Secret != nil:
+		return "secret"
+	case volume.ConfigMap != nil:
+		return "configMap"
+	case volume.Projected != nil:
+		return "projected"
+	case volume.EmptyDir != nil:
+		return "emptyDir"
+	case volume.HostPath != nil:
+		return "hostPath"
+	case volume.CSI != nil:
+		return "csi"
+	case volume.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim"
+	case volume.DownwardAPI != nil:
+		return "downwardAPI"
+	default:
+		return "unknown"
 	}
-	return total
 }
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// HandlePolicyViolations handles the /policy-violations endpoint, evaluating
+// the configured policies against the certificates of namespaces, chosen as
+// for /health-score. Every rule of a policy that applies to a namespace is
+// reported as passed or failed with the certificates that break it.
+func (h *Handler) HandlePolicyViolations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	policies := h.cfg().Policies
+	if len(policies) == 0 {
+		writeProblem(w, r, http.StatusServiceUnavailable, ProblemNotConfigured, "No policies are configured; add them under policies in the configuration")
+		return
+	}
+
+	response := PolicyViolationsResponse{Status: api.StatusSuccess}
+	reports, _, ok := h.expiryReports(ctx, w, r, &response.ReportSource)
+	if !ok {
+		return
+	}
+
+	results := []k8s.PolicyResult{}
+	failed := 0
+	violations := 0
+	for _, report := range reports {
+		for _, result := range k8s.EvaluatePolicies(policies, report) {
+			if !result.Passed {
+				failed++
+				violations += len(result.Violations)
+			}
+			results = append(results, result)
+		}
+	}
+
+	response.Passed = failed == 0
+	response.Summary = PolicyViolationsSummary{
+		RulesEvaluated: len(results),
+		RulesPassed:    len(results) - failed,
+		RulesFailed:    failed,
+		Violations:     violations,
+	}
+	response.Results = results
+	response.Notes = []string{
+		"allowed_issuers and max_validity_days apply to leaf certificates; min_days_remaining applies to every certificate",
+		"Without a namespace, the namespaces of the last background scan are evaluated",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
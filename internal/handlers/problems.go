@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/smithy-go"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"k8s-web-service/internal/readonly"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// Problem types of error responses. The URIs are stable, so clients can
+// branch on the type instead of matching messages.
+const (
+	ProblemClusterUnreachable    = "/problems/cluster-unreachable"
+	ProblemRBACDenied            = "/problems/rbac-denied"
+	ProblemUnauthenticated       = "/problems/unauthenticated"
+	ProblemParseFailure          = "/problems/parse-failure"
+	ProblemInvalidParameters     = "/problems/invalid-parameters"
+	ProblemNotFound              = "/problems/not-found"
+	ProblemMethodNotAllowed      = "/problems/method-not-allowed"
+	ProblemNotConfigured         = "/problems/not-configured"
+	ProblemReadOnly              = "/problems/read-only"
+	ProblemDraining              = "/problems/draining"
+	ProblemTimeout               = "/problems/timeout"
+	ProblemKubernetesError       = "/problems/kubernetes-error"
+	ProblemAWSError              = "/problems/aws-error"
+	ProblemNotPrivateCertificate = "/problems/not-private-certificate"
+	ProblemNotificationFailed    = "/problems/notification-failed"
+	ProblemEndpointUnreachable   = "/problems/endpoint-unreachable"
+	ProblemInternal              = "/problems/internal"
+)
+
+// problemInfo is the title and description of a problem type
+type problemInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// problemTypes describes each problem type
+var problemTypes = map[string]problemInfo{
+	ProblemClusterUnreachable:    {"Cluster unreachable", "The Kubernetes client could not be created from the kubeconfig and EKS token, or the API server did not respond"},
+	ProblemRBACDenied:            {"Permission denied", "Kubernetes RBAC or AWS IAM denied a request of the service's identity; see /debug/rbac"},
+	ProblemUnauthenticated:       {"Unauthenticated", "The credentials of the caller, or of the service towards Kubernetes or AWS, were missing, invalid, or expired"},
+	ProblemParseFailure:          {"Parse failure", "A parameter, request body, or certificate could not be parsed"},
+	ProblemInvalidParameters:     {"Invalid parameters", "Query parameters are unknown, repeated, missing, or out of range; each is listed in invalid_params"},
+	ProblemNotFound:              {"Not found", "The endpoint or a resource it names does not exist"},
+	ProblemMethodNotAllowed:      {"Method not allowed", "The endpoint does not accept the request method; see the Allow header"},
+	ProblemNotConfigured:         {"Not configured", "The feature is disabled or lacks configuration"},
+	ProblemReadOnly:              {"Refused in read-only mode", "The request would modify the cluster or AWS while read_only is set"},
+	ProblemDraining:              {"Server draining", "The server is draining and does not accept new scans; retry after the Retry-After delay"},
+	ProblemTimeout:               {"Request timeout", "The request exceeded the timeout of its endpoint group and its calls were canceled"},
+	ProblemKubernetesError:       {"Kubernetes request failed", "The Kubernetes API server returned an error"},
+	ProblemAWSError:              {"AWS request failed", "An AWS API returned an error"},
+	ProblemNotPrivateCertificate: {"Not a private certificate", "Only certificates issued by AWS Private CA can be re-issued; the certificate is included"},
+	ProblemNotificationFailed:    {"Notification failed", "A notifier refused the alerts; the alerts are included"},
+	ProblemEndpointUnreachable:   {"Endpoint unreachable", "A TLS endpoint the request names could not be dialed or did not complete the handshake"},
+	ProblemInternal:              {"Internal error", "The request failed for another reason"},
+}
+
+// Problem is an RFC 7807 problem details object. Error repeats the detail
+// for clients of the former {"status": "error", "error": ...} body.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Extensions are additional members, such as the certificate a failed
+	// re-issue was requested for
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON adds the extension members to the problem
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	data, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	members := make(map[string]interface{})
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	for key, value := range p.Extensions {
+		if _, ok := members[key]; !ok {
+			members[key] = value
+		}
+	}
+	return json.Marshal(members)
+}
+
+// newProblem creates the problem of a request
+func newProblem(r *http.Request, status int, problemType, detail string) Problem {
+	return Problem{
+		Type:     problemType,
+		Title:    problemTypes[problemType].Title,
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Error:    detail,
+	}
+}
+
+// encodeProblem writes a problem as the response
+func encodeProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// writeProblem writes an error response of a problem type
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, problemType, format string, args ...interface{}) {
+	encodeProblem(w, newProblem(r, status, problemType, fmt.Sprintf(format, args...)))
+}
+
+// writeErrorProblem writes an error response for err, typed by err when it
+// is recognized and by problemType otherwise. Kubernetes API errors for
+// missing resources, denied requests, and rejected credentials replace
+// status with 404, 403, and 401, and their status is kept under upstream.
+// Calls cut off by the request deadline replace it with 504.
+func writeErrorProblem(w http.ResponseWriter, r *http.Request, status int, err error, problemType, format string, args ...interface{}) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+	var apiStatus apierrors.APIStatus
+	if errors.As(err, &apiStatus) {
+		switch code := int(apiStatus.Status().Code); code {
+		case http.StatusNotFound, http.StatusForbidden, http.StatusUnauthorized:
+			status = code
+		}
+	}
+	problem := newProblem(r, status, classifyError(err, problemType), fmt.Sprintf(format, args...))
+	if apiStatus != nil {
+		upstream := apiStatus.Status()
+		problem.Extensions = map[string]interface{}{
+			"upstream": map[string]interface{}{
+				"code":    upstream.Code,
+				"reason":  upstream.Reason,
+				"message": upstream.Message,
+			},
+		}
+	}
+	encodeProblem(w, problem)
+}
+
+// classifyError returns the problem type of an error from Kubernetes, AWS,
+// or the network, or fallback if it is not recognized
+func classifyError(err error, fallback string) string {
+	var apiErr smithy.APIError
+	var urlErr *url.Error
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return fallback
+	case errors.Is(err, readonly.ErrReadOnly):
+		return ProblemReadOnly
+	case errors.Is(err, context.DeadlineExceeded):
+		return ProblemTimeout
+	case apierrors.IsForbidden(err):
+		return ProblemRBACDenied
+	case apierrors.IsUnauthorized(err):
+		return ProblemUnauthenticated
+	case apierrors.IsNotFound(err):
+		return ProblemNotFound
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), apierrors.IsServiceUnavailable(err):
+		return ProblemClusterUnreachable
+	case errors.As(err, &apiErr):
+		switch apiErr.ErrorCode() {
+		case "AccessDenied", "AccessDeniedException", "UnauthorizedOperation":
+			return ProblemRBACDenied
+		case "ExpiredToken", "ExpiredTokenException", "InvalidClientTokenId", "UnrecognizedClientException":
+			return ProblemUnauthenticated
+		}
+		return ProblemAWSError
+	case fallback != ProblemAWSError && (errors.As(err, &urlErr) || errors.As(err, &netErr)):
+		return ProblemClusterUnreachable
+	}
+	return fallback
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// queryParam documents the query parameter, accepted by every endpoint
+const queryParam = "query (optional, JSONPath applied to the JSON response, e.g. $.pods[?(@.expiry_warnings)].name)"
+
+// parseQuery parses a JSONPath response query. The surrounding braces of
+// kubectl templates are optional, so $.pods[*].name and {.pods[*].name}
+// are the same.
+func parseQuery(query string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(strings.TrimSpace(query), "{") {
+		query = "{" + query + "}"
+	}
+	parser := jsonpath.New("query").AllowMissingKeys(true)
+	if err := parser.Parse(query); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+// validateQuery accepts JSONPath expressions
+func validateQuery(value string) string {
+	if _, err := parseQuery(value); err != nil {
+		return fmt.Sprintf("%q is not a valid JSONPath expression: %v", value, err)
+	}
+	return ""
+}
+
+// shapeResponse applies the query parameter to successful JSON responses,
+// replacing the body with the array of values the JSONPath selects, so
+// lightweight consumers get only what they need. Error responses are sent
+// unchanged.
+func shapeResponse(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		if query == "" {
+			next(w, r)
+			return
+		}
+		// validateParams has parsed the query already
+		parser, err := parseQuery(query)
+		if err != nil {
+			next(w, r)
+			return
+		}
+
+		buffered := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+		next(buffered, r)
+
+		body := buffered.body.Bytes()
+		if buffered.status < 300 && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			// Numbers decode as float64, so filters can compare them
+			var value interface{}
+			if err := json.Unmarshal(body, &value); err == nil {
+				results, err := parser.FindResults(value)
+				if err != nil {
+					writeProblem(w, r, http.StatusBadRequest, ProblemInvalidParameters, "Failed to evaluate query %q: %v", query, err)
+					return
+				}
+				selected := []interface{}{}
+				for _, values := range results {
+					for _, v := range values {
+						selected = append(selected, v.Interface())
+					}
+				}
+				if data, err := json.Marshal(selected); err == nil {
+					body = append(data, '\n')
+				}
+			}
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(buffered.status)
+		w.Write(body)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"k8s-web-service/internal/redact"
+)
+
+// bufferedWriter holds a response back so it can be rewritten before it is
+// sent, to redact certificate material or apply a query
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status until the response is sent
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// Write buffers the response body
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+// redaction returns what security configures to be removed from responses
+func (h *Handler) redaction() redact.Policy {
+	return redact.NewPolicy(h.cfg())
+}
+
+// redactResponse removes PEM content, certificate subjects, and secret key
+// names from responses as configured under security. JSON responses are
+// redacted member by member; the namespace of the request applies to
+// certificates outside any object with a namespace. Other responses, such
+// as CSV exports, have their PEM blocks removed and redact subjects as they
+// are written.
+func (h *Handler) redactResponse(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policy := h.redaction()
+		if !policy.Enabled() {
+			next(w, r)
+			return
+		}
+
+		buffered := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+		next(buffered, r)
+
+		namespace := r.URL.Query().Get("namespace")
+		if namespace == "" {
+			namespace = h.cfg().Kubernetes.DefaultNamespace
+		}
+		body := buffered.body.Bytes()
+		if strings.Contains(w.Header().Get("Content-Type"), "json") {
+			body = policy.JSON(body, namespace)
+		} else {
+			body = policy.Text(body)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(buffered.status)
+		w.Write(body)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/lifecycle"
+)
+
+func TestRedactResponsePrivacyMode(t *testing.T) {
+	const pem = "-----BEGIN CERTIFICATE-----\\nMIIBszCCAVmgAwIBAgIU\\n-----END CERTIFICATE-----"
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"json", "application/json", `{"certificates":[{"subject":"CN=payments.internal","serial_number":"4f:1a:9c","fingerprint_sha256":"ab12","pem":"` + pem + `"}]}`},
+		{"json without fingerprint", "application/json", `{"alerts":[{"namespace":"payments","subject":"CN=payments.internal","serial_number":"4f:1a:9c"}]}`},
+		{"csv", "text/csv", "subject,pem\n[REDACTED],\"" + strings.ReplaceAll(pem, `\n`, "\n") + "\"\n"},
+		{"plain text", "text/plain", "CA bundle:\n" + strings.ReplaceAll(pem, `\n`, "\n") + "\n"},
+	}
+
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.Security.PrivacyMode = true
+	h := New(config.NewStore(cfg), nil, lifecycle.NewTracker(), nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := h.redactResponse(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.body))
+			})
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, "/certificates?namespace=payments", nil))
+
+			body := rec.Body.String()
+			for _, s := range []string{"-----BEGIN ", "MIIB", "CN=payments.internal", "4f:1a:9c"} {
+				if strings.Contains(body, s) {
+					t.Errorf("response contains %q: %s", s, body)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactResponseFixtures(t *testing.T) {
+	// Warnings and chain verifications name certificates by subject outside
+	// the certificate objects
+	paths := []string{
+		"/certificate-expiry?namespace=default&verify=true",
+		"/pod-certificates?namespace=default&verify=true",
+		"/secrets-certificates?namespace=default",
+	}
+	tests := []struct {
+		name     string
+		security func(cfg *config.Config)
+	}{
+		{"redact_subjects", func(cfg *config.Config) { cfg.Security.RedactSubjects = true }},
+		{"privacy mode", func(cfg *config.Config) { cfg.Security.PrivacyMode = true }},
+		{"sensitive namespace", func(cfg *config.Config) {
+			cfg.Security.PrivacyMode = true
+			cfg.Security.SensitiveNamespaces = []string{"default"}
+		}},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+		tt.security(cfg)
+		handler := newTestHandler(cfg)
+		for _, path := range paths {
+			t.Run(tt.name+" "+path, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("GET %s = %d: %s", path, rec.Code, rec.Body)
+				}
+				if body := rec.Body.String(); strings.Contains(body, "CN=") {
+					t.Errorf("response contains a subject: %s", body)
+				}
+			})
+		}
+	}
+}
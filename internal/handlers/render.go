@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"k8s-web-service/internal/output"
+)
+
+// formatParam documents the format parameter of the endpoints that export
+// their certificates as a spreadsheet
+const formatParam = "format (optional, csv or xlsx for a spreadsheet of every certificate, default: json)"
+
+// exportFormat returns the spreadsheet format the format parameter asks
+// for, or "" for a JSON response
+func exportFormat(r *http.Request) string {
+	switch format := r.URL.Query().Get("format"); format {
+	case output.FormatCSV, output.FormatXLSX:
+		return format
+	}
+	return ""
+}
+
+// certificateSheet returns an empty certificate sheet for the export of a
+// request, or nil when the request asks for JSON. Certificate identities
+// are redacted in the namespaces security redacts them from JSON responses.
+func (h *Handler) certificateSheet(r *http.Request, name string, warningDays int) *output.CertificateSheet {
+	if exportFormat(r) == "" {
+		return nil
+	}
+	return output.NewCertificateSheet(name, warningDays, h.redaction())
+}
+
+// render writes response as JSON, or sheet in the spreadsheet format the
+// request asks for, as an attachment named after the sheet. A nil sheet
+// always renders JSON.
+func render(w http.ResponseWriter, r *http.Request, response interface{}, sheet *output.CertificateSheet) {
+	format := exportFormat(r)
+	if format == "" || sheet == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	var body bytes.Buffer
+	if err := output.WriteSheet(&body, format, &sheet.Sheet); err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ProblemInternal, "Failed to render %s: %v", format, err)
+		return
+	}
+	w.Header().Set("Content-Type", output.SheetContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", sheet.Name+"."+format))
+	w.Write(body.Bytes())
+}
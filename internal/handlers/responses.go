@@ -0,0 +1,686 @@
+package handlers
+
+import (
+	"time"
+
+	"k8s-web-service/internal/cloud"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/notify"
+)
+
+// The types of this file are the response bodies of the endpoints that
+// return results of the internal packages, which pkg/api cannot import.
+// Responses made of plain values are defined in pkg/api.
+
+// ACMCertificatesResponse is the response of /aws/acm-certificates. The
+// references and IAM errors are set when those lookups failed, which does
+// not fail the ACM results.
+type ACMCertificatesResponse struct {
+	Status                string                      `json:"status"`
+	Region                string                      `json:"region"`
+	WarningDays           int                         `json:"warning_days"`
+	ReferencesError       string                      `json:"references_error,omitempty"`
+	IAMError              string                      `json:"iam_error,omitempty"`
+	Summary               ACMCertificatesSummary      `json:"summary"`
+	ACMCertificates       []ACMCertificateEntry       `json:"acm_certificates"`
+	IAMServerCertificates []IAMServerCertificateEntry `json:"iam_server_certificates"`
+	UnresolvedReferences  []UnresolvedReference       `json:"unresolved_references"`
+	InClusterCertificates []k8s.InClusterCertificate  `json:"in_cluster_certificates,omitempty"`
+	Namespace             string                      `json:"namespace,omitempty"`
+	Truncated             bool                        `json:"truncated,omitempty"`
+	MaxResults            int                         `json:"max_results,omitempty"`
+}
+
+// ACMCertificatesSummary totals an ACMCertificatesResponse
+type ACMCertificatesSummary struct {
+	TotalACMCertificates      int `json:"total_acm_certificates"`
+	TotalIAMCertificates      int `json:"total_iam_certificates"`
+	ReferencedCertificates    int `json:"referenced_certificates"`
+	ExpiringOrExpired         int `json:"expiring_or_expired"`
+	ReferencedNotRenewable    int `json:"referenced_not_renewable"`
+	UnresolvedCertificateARNs int `json:"unresolved_certificate_arns"`
+}
+
+// UnresolvedReference is a certificate ARN referenced by Kubernetes
+// resources but missing from ACM and IAM
+type UnresolvedReference struct {
+	ARN          string        `json:"arn"`
+	ReferencedBy []ResourceRef `json:"referenced_by"`
+}
+
+// LoadBalancerCertificatesResponse is the response of
+// /aws/load-balancer-certificates
+type LoadBalancerCertificatesResponse struct {
+	Status             string                          `json:"status"`
+	Region             string                          `json:"region"`
+	WarningDays        int                             `json:"warning_days"`
+	Summary            LoadBalancerCertificatesSummary `json:"summary"`
+	LoadBalancers      []LoadBalancerEntry             `json:"load_balancers"`
+	UnmatchedHostnames []string                        `json:"unmatched_hostnames"`
+	Namespace          string                          `json:"namespace,omitempty"`
+	Truncated          bool                            `json:"truncated,omitempty"`
+	MaxResults         int                             `json:"max_results,omitempty"`
+}
+
+// LoadBalancerCertificatesSummary totals a LoadBalancerCertificatesResponse
+type LoadBalancerCertificatesSummary struct {
+	TotalLoadBalancers int `json:"total_load_balancers"`
+	Frontends          int `json:"frontends"`
+	TotalWarnings      int `json:"total_warnings"`
+}
+
+// Route53CoverageResponse is the response of /aws/route53-coverage
+type Route53CoverageResponse struct {
+	Status      string                 `json:"status"`
+	WarningDays int                    `json:"warning_days"`
+	Summary     Route53CoverageSummary `json:"summary"`
+	Domains     []DomainCoverage       `json:"domains"`
+	Notes       []string               `json:"notes,omitempty"`
+	Namespace   string                 `json:"namespace,omitempty"`
+	Truncated   bool                   `json:"truncated,omitempty"`
+	MaxResults  int                    `json:"max_results,omitempty"`
+}
+
+// Route53CoverageSummary counts the records of a Route53CoverageResponse by
+// coverage status
+type Route53CoverageSummary struct {
+	TotalRecords int            `json:"total_records"`
+	ByStatus     map[string]int `json:"by_status"`
+}
+
+// SecretsManagerCertificatesResponse is the response of
+// /aws/secretsmanager-certificates
+type SecretsManagerCertificatesResponse struct {
+	Status      string                            `json:"status"`
+	Region      string                            `json:"region"`
+	Prefixes    []string                          `json:"prefixes"`
+	WarningDays int                               `json:"warning_days"`
+	Summary     SecretsManagerCertificatesSummary `json:"summary"`
+	Bundles     []SecretsManagerEntry             `json:"bundles"`
+	Truncated   bool                              `json:"truncated,omitempty"`
+	MaxResults  int                               `json:"max_results,omitempty"`
+}
+
+// SecretsManagerCertificatesSummary totals a
+// SecretsManagerCertificatesResponse
+type SecretsManagerCertificatesSummary struct {
+	Secrets           int `json:"secrets"`
+	TotalBundles      int `json:"total_bundles"`
+	TotalCertificates int `json:"total_certificates"`
+	TotalWarnings     int `json:"total_warnings"`
+	Errors            int `json:"errors"`
+}
+
+// CloudFrontCertificatesResponse is the response of
+// /aws/cloudfront-certificates
+type CloudFrontCertificatesResponse struct {
+	Status        string                        `json:"status"`
+	WarningDays   int                           `json:"warning_days"`
+	Summary       CloudFrontCertificatesSummary `json:"summary"`
+	Distributions []CloudFrontEntry             `json:"distributions"`
+}
+
+// CloudFrontCertificatesSummary totals a CloudFrontCertificatesResponse
+type CloudFrontCertificatesSummary struct {
+	TotalDistributions int `json:"total_distributions"`
+	WeakProtocolPolicy int `json:"weak_protocol_policy"`
+	TotalWarnings      int `json:"total_warnings"`
+}
+
+// PrivateCAResponse is the response of /aws/private-ca
+type PrivateCAResponse struct {
+	Status                 string           `json:"status"`
+	Region                 string           `json:"region"`
+	WarningDays            int              `json:"warning_days"`
+	Summary                PrivateCASummary `json:"summary"`
+	CertificateAuthorities []PrivateCAEntry `json:"certificate_authorities"`
+	Notes                  []string         `json:"notes"`
+}
+
+// PrivateCASummary totals a PrivateCAResponse
+type PrivateCASummary struct {
+	PrivateCAs          int `json:"private_cas"`
+	PrivateCertificates int `json:"private_certificates"`
+	TotalWarnings       int `json:"total_warnings"`
+}
+
+// EKSAddonsResponse is the response of /eks/addons
+type EKSAddonsResponse struct {
+	Status            string            `json:"status"`
+	ClusterName       string            `json:"cluster_name"`
+	KubernetesVersion string            `json:"kubernetes_version"`
+	Summary           EKSAddonsSummary  `json:"summary"`
+	Addons            []cloud.AddonInfo `json:"addons"`
+}
+
+// EKSAddonsSummary totals an EKSAddonsResponse
+type EKSAddonsSummary struct {
+	TotalAddons      int `json:"total_addons"`
+	UpdatesAvailable int `json:"updates_available"`
+	AddonsWithIssues int `json:"addons_with_issues"`
+}
+
+// EKSNodegroupsResponse is the response of /eks/nodegroups
+type EKSNodegroupsResponse struct {
+	Status          string                     `json:"status"`
+	ClusterName     string                     `json:"cluster_name"`
+	Summary         EKSNodegroupsSummary       `json:"summary"`
+	Nodegroups      []cloud.NodegroupInfo      `json:"nodegroups"`
+	FargateProfiles []cloud.FargateProfileInfo `json:"fargate_profiles"`
+	Notes           []string                   `json:"notes"`
+}
+
+// EKSNodegroupsSummary totals an EKSNodegroupsResponse
+type EKSNodegroupsSummary struct {
+	TotalNodegroups      int            `json:"total_nodegroups"`
+	TotalFargateProfiles int            `json:"total_fargate_profiles"`
+	AMIReleaseVersions   map[string]int `json:"ami_release_versions"`
+}
+
+// EKSClustersResponse is the response of /eks/clusters
+type EKSClustersResponse struct {
+	Status     string              `json:"status"`
+	Regions    []string            `json:"regions"`
+	Summary    EKSClustersSummary  `json:"summary"`
+	Clusters   []DiscoveredCluster `json:"clusters"`
+	Truncated  bool                `json:"truncated,omitempty"`
+	MaxResults int                 `json:"max_results,omitempty"`
+}
+
+// EKSClustersSummary totals an EKSClustersResponse
+type EKSClustersSummary struct {
+	TotalClusters        int `json:"total_clusters"`
+	RegisteredClusters   int `json:"registered_clusters"`
+	UnregisteredClusters int `json:"unregistered_clusters"`
+}
+
+// EKSOIDCResponse is the response of /eks/oidc-thumbprint
+type EKSOIDCResponse struct {
+	Status string                     `json:"status"`
+	OIDC   *cloud.OIDCThumbprintCheck `json:"oidc"`
+}
+
+// EKSAccessResponse is the response of /eks/access. Each source is replaced
+// by its error when it cannot be read.
+type EKSAccessResponse struct {
+	Status             string             `json:"status"`
+	AWSAuthError       string             `json:"aws_auth_error,omitempty"`
+	AWSAuth            *k8s.AWSAuthReport `json:"aws_auth,omitempty"`
+	AccessEntriesError string             `json:"access_entries_error,omitempty"`
+	*EKSAccessEntries
+	Summary EKSAccessSummary   `json:"summary"`
+	Issues  []k8s.MappingIssue `json:"issues"`
+}
+
+// EKSAccessEntries are the access entries of an EKSAccessResponse
+type EKSAccessEntries struct {
+	ClusterName        string                  `json:"cluster_name"`
+	AuthenticationMode string                  `json:"authentication_mode"`
+	AccessEntries      []cloud.AccessEntryInfo `json:"access_entries"`
+}
+
+// EKSAccessSummary totals an EKSAccessResponse; the counts of a source that
+// could not be read are omitted
+type EKSAccessSummary struct {
+	BrokenMappings  int  `json:"broken_mappings"`
+	AWSAuthMappings *int `json:"aws_auth_mappings,omitempty"`
+	AccessEntries   *int `json:"access_entries,omitempty"`
+}
+
+// CARotationResponse is the response of /ca-rotation-status
+type CARotationResponse struct {
+	Status     string                `json:"status"`
+	Rotation   *k8s.CARotationStatus `json:"rotation"`
+	Namespaces []string              `json:"namespaces"`
+	Notes      []string              `json:"notes"`
+}
+
+// ReportSource tells whether the reports of a response come from the last
+// background scan, which started at ScannedAt, or were analyzed for the
+// request
+type ReportSource struct {
+	Source    string     `json:"source"` // scanner or live
+	ScannedAt *time.Time `json:"scanned_at,omitempty"`
+}
+
+// HealthScoreResponse is the response of /health-score
+type HealthScoreResponse struct {
+	Status string `json:"status"`
+	ReportSource
+	WarningDays  int                `json:"warning_days"`
+	Score        int                `json:"score"`
+	Certificates int                `json:"certificates"`
+	Namespaces   []*k8s.HealthScore `json:"namespaces"`
+	Weights      map[string]float64 `json:"weights"`
+	Notes        []string           `json:"notes"`
+}
+
+// PolicyViolationsResponse is the response of /policy-violations
+type PolicyViolationsResponse struct {
+	Status string `json:"status"`
+	ReportSource
+	Passed  bool                    `json:"passed"`
+	Summary PolicyViolationsSummary `json:"summary"`
+	Results []k8s.PolicyResult      `json:"results"`
+	Notes   []string                `json:"notes"`
+}
+
+// PolicyViolationsSummary totals a PolicyViolationsResponse
+type PolicyViolationsSummary struct {
+	RulesEvaluated int `json:"rules_evaluated"`
+	RulesPassed    int `json:"rules_passed"`
+	RulesFailed    int `json:"rules_failed"`
+	Violations     int `json:"violations"`
+}
+
+// HelmCertificatesResponse is the response of /helm-certificates
+type HelmCertificatesResponse struct {
+	Status       string                        `json:"status"`
+	Namespace    string                        `json:"namespace"`
+	WarningDays  int                           `json:"warning_days"`
+	AllRevisions bool                          `json:"all_revisions"`
+	Summary      HelmCertificatesSummary       `json:"summary"`
+	Releases     []k8s.HelmReleaseCertificates `json:"releases"`
+	Notes        []string                      `json:"notes"`
+	Truncated    bool                          `json:"truncated,omitempty"`
+	MaxResults   int                           `json:"max_results,omitempty"`
+}
+
+// HelmCertificatesSummary totals a HelmCertificatesResponse
+type HelmCertificatesSummary struct {
+	TotalReleases            int `json:"total_releases"`
+	ReleasesWithCertificates int `json:"releases_with_certificates"`
+	TotalCertificates        int `json:"total_certificates"`
+	TotalWarnings            int `json:"total_warnings"`
+	UndecodableReleases      int `json:"undecodable_releases"`
+}
+
+// ImageCABundlesResponse is the response of /image-ca-bundles. The images
+// beyond images.max_images are listed in SkippedImages.
+type ImageCABundlesResponse struct {
+	Status        string                `json:"status"`
+	Namespace     string                `json:"namespace"`
+	WarningDays   int                   `json:"warning_days"`
+	Summary       ImageCABundlesSummary `json:"summary"`
+	Images        []ImageCABundleEntry  `json:"images"`
+	Notes         []string              `json:"notes"`
+	SkippedImages []string              `json:"skipped_images,omitempty"`
+	Truncated     bool                  `json:"truncated,omitempty"`
+	MaxResults    int                   `json:"max_results,omitempty"`
+}
+
+// ImageCABundlesSummary totals an ImageCABundlesResponse
+type ImageCABundlesSummary struct {
+	ImagesAnalyzed     int `json:"images_analyzed"`
+	ImagesSkipped      int `json:"images_skipped"`
+	ImagesFailed       int `json:"images_failed"`
+	TotalExpiredRoots  int `json:"total_expired_roots"`
+	TotalExpiringRoots int `json:"total_expiring_roots"`
+}
+
+// SimulateResponse is the response of POST /admin/simulate
+type SimulateResponse struct {
+	Status    string         `json:"status"`
+	Message   string         `json:"message"`
+	Namespace string         `json:"namespace"`
+	ExpiryIn  string         `json:"expiry_in"`
+	Notifiers string         `json:"notifiers"`
+	Alerts    []notify.Alert `json:"alerts"`
+}
+
+// ReissueResponse is the response of POST /admin/reissue-certificate
+type ReissueResponse struct {
+	Status      string                `json:"status"`
+	Message     string                `json:"message"`
+	Certificate *cloud.ACMCertificate `json:"certificate"`
+}
+
+// HostPathCertificatesResponse is the response of /hostpath-certificates
+type HostPathCertificatesResponse struct {
+	Status            string                      `json:"status"`
+	WarningDays       int                         `json:"warning_days"`
+	Summary           HostPathCertificatesSummary `json:"summary"`
+	Pods              []HostPathPodEntry          `json:"pods"`
+	Agents            []AgentStatus               `json:"agents"`
+	NodesWithoutAgent []string                    `json:"nodes_without_agent"`
+	Namespace         string                      `json:"namespace,omitempty"`
+	Notes             []string                    `json:"notes,omitempty"`
+	Truncated         bool                        `json:"truncated,omitempty"`
+	MaxResults        int                         `json:"max_results,omitempty"`
+}
+
+// HostPathCertificatesSummary totals a HostPathCertificatesResponse
+type HostPathCertificatesSummary struct {
+	PodsWithHostPaths int `json:"pods_with_host_paths"`
+	ReportingNodes    int `json:"reporting_nodes"`
+	NodesWithoutAgent int `json:"nodes_without_agent"`
+	TotalWarnings     int `json:"total_warnings"`
+}
+
+// AgentStatus is the last report of a node agent. Stale marks agents that
+// did not report for two agent.interval.
+type AgentStatus struct {
+	Node       string    `json:"node"`
+	ReceivedAt time.Time `json:"received_at"`
+	Files      int       `json:"files"`
+	Errors     []string  `json:"errors"`
+	Stale      bool      `json:"stale"`
+}
+
+// LiveCertCheckResponse is the response of /live-cert-check
+type LiveCertCheckResponse struct {
+	Status      string                   `json:"status"`
+	WarningDays int                      `json:"warning_days"`
+	Result      k8s.LiveCertificateCheck `json:"result"`
+	Notes       []string                 `json:"notes"`
+}
+
+// LiveServiceCertCheckResponse is the response of /live-cert-check/services
+type LiveServiceCertCheckResponse struct {
+	Status        string                      `json:"status"`
+	Namespace     string                      `json:"namespace"`
+	AllNamespaces bool                        `json:"all_namespaces"`
+	WarningDays   int                         `json:"warning_days"`
+	Summary       LiveServiceCertCheckSummary `json:"summary"`
+	Results       []k8s.LiveCertificateCheck  `json:"results"`
+	Notes         []string                    `json:"notes"`
+	Truncated     bool                        `json:"truncated,omitempty"`
+	MaxResults    int                         `json:"max_results,omitempty"`
+}
+
+// LiveServiceCertCheckSummary totals a LiveServiceCertCheckResponse
+type LiveServiceCertCheckSummary struct {
+	TotalTargets       int `json:"total_targets"`
+	Reachable          int `json:"reachable"`
+	Unreachable        int `json:"unreachable"`
+	ExpiredLeaf        int `json:"expired_leaf"`
+	ExpiringLeaf       int `json:"expiring_leaf"`
+	HostnameMismatches int `json:"hostname_mismatches"`
+}
+
+// NodesResponse is the response of /nodes. CSRError is set when CSRs could
+// not be listed, in which case the rotation status of every node is
+// unknown.
+type NodesResponse struct {
+	Status     string         `json:"status"`
+	Summary    NodesSummary   `json:"summary"`
+	Nodes      []k8s.NodeInfo `json:"nodes"`
+	Notes      []string       `json:"notes"`
+	CSRError   string         `json:"csr_error,omitempty"`
+	Truncated  bool           `json:"truncated,omitempty"`
+	MaxResults int            `json:"max_results,omitempty"`
+}
+
+// NodesSummary totals a NodesResponse
+type NodesSummary struct {
+	TotalNodes                   int            `json:"total_nodes"`
+	NotReady                     int            `json:"not_ready"`
+	KubeletVersions              map[string]int `json:"kubelet_versions"`
+	ClientRotationObservedNodes  int            `json:"client_rotation_observed_nodes"`
+	ServingRotationObservedNodes int            `json:"serving_rotation_observed_nodes"`
+	KubeletVersionSkew           bool           `json:"kubelet_version_skew"`
+}
+
+// KubeletRotationResponse is the response of /nodes/kubelet-rotation
+type KubeletRotationResponse struct {
+	Status      string                    `json:"status"`
+	WarningDays int                       `json:"warning_days"`
+	Summary     KubeletRotationSummary    `json:"summary"`
+	Nodes       []k8s.NodeKubeletRotation `json:"nodes"`
+	Notes       []string                  `json:"notes"`
+	Truncated   bool                      `json:"truncated,omitempty"`
+	MaxResults  int                       `json:"max_results,omitempty"`
+}
+
+// KubeletRotationSummary totals a KubeletRotationResponse
+type KubeletRotationSummary struct {
+	TotalNodes                 int            `json:"total_nodes"`
+	ByStatus                   map[string]int `json:"by_status"`
+	RotateCertificatesDisabled int            `json:"rotate_certificates_disabled"`
+	ServerTLSBootstrapDisabled int            `json:"server_tls_bootstrap_disabled"`
+}
+
+// TrustBundlesResponse is the response of /configmaps/trust-bundles
+type TrustBundlesResponse struct {
+	Status       string              `json:"status"`
+	Namespace    string              `json:"namespace"`
+	Summary      TrustBundlesSummary `json:"summary"`
+	TrustBundles []k8s.TrustBundle   `json:"trust_bundles"`
+	Notes        []string            `json:"notes"`
+	Truncated    bool                `json:"truncated,omitempty"`
+	MaxResults   int                 `json:"max_results,omitempty"`
+}
+
+// TrustBundlesSummary totals a TrustBundlesResponse
+type TrustBundlesSummary struct {
+	TrustBundles            int      `json:"trust_bundles"`
+	TotalCertificates       int      `json:"total_certificates"`
+	BundlesWithExpiredRoots []string `json:"bundles_with_expired_roots"`
+}
+
+// TrustStoreValidationResponse is the response of /trust-store-validation
+type TrustStoreValidationResponse struct {
+	Status     string                `json:"status"`
+	Namespace  string                `json:"namespace"`
+	TrustStore *k8s.TrustStoreReport `json:"trust_store"`
+	Notes      []string              `json:"notes"`
+	Truncated  bool                  `json:"truncated,omitempty"`
+	MaxResults int                   `json:"max_results,omitempty"`
+}
+
+// SecretCertificatesResponse is the response of /secrets-certificates
+type SecretCertificatesResponse struct {
+	Status        string                    `json:"status"`
+	Namespace     string                    `json:"namespace"`
+	AllNamespaces bool                      `json:"all_namespaces"`
+	WarningDays   int                       `json:"warning_days"`
+	Summary       SecretCertificatesSummary `json:"summary"`
+	Secrets       []k8s.SecretCertificates  `json:"secrets"`
+	Notes         []string                  `json:"notes"`
+	Truncated     bool                      `json:"truncated,omitempty"`
+	MaxResults    int                       `json:"max_results,omitempty"`
+}
+
+// SecretCertificatesSummary totals a SecretCertificatesResponse
+type SecretCertificatesSummary struct {
+	TotalSecrets      int            `json:"total_secrets"`
+	ByType            map[string]int `json:"by_type"`
+	ByStatus          map[string]int `json:"by_status"`
+	TotalCertificates int            `json:"total_certificates"`
+	TotalWarnings     int            `json:"total_warnings"`
+	KeyMismatches     int            `json:"key_mismatches"`
+}
+
+// SecretsResponse is the response of /secrets. Type is the secret type the
+// request filtered on.
+type SecretsResponse struct {
+	Status     string               `json:"status"`
+	Namespace  string               `json:"namespace"`
+	Summary    SecretsSummary       `json:"summary"`
+	Secrets    []k8s.SecretMetadata `json:"secrets"`
+	Notes      []string             `json:"notes"`
+	Type       string               `json:"type,omitempty"`
+	Truncated  bool                 `json:"truncated,omitempty"`
+	MaxResults int                  `json:"max_results,omitempty"`
+}
+
+// SecretsSummary totals a SecretsResponse
+type SecretsSummary struct {
+	TotalSecrets int            `json:"total_secrets"`
+	ByType       map[string]int `json:"by_type"`
+}
+
+// ServicesResponse is the response of /services. Links are set when
+// Services reference ACM certificates and the aws endpoint group is enabled.
+type ServicesResponse struct {
+	Status     string               `json:"status"`
+	Namespace  string               `json:"namespace"`
+	TLSOnly    bool                 `json:"tls_only"`
+	Summary    ServicesSummary      `json:"summary"`
+	Services   []k8s.ServiceTLSInfo `json:"services"`
+	Links      *ServicesLinks       `json:"links,omitempty"`
+	Truncated  bool                 `json:"truncated,omitempty"`
+	MaxResults int                  `json:"max_results,omitempty"`
+}
+
+// ServicesSummary totals a ServicesResponse
+type ServicesSummary struct {
+	TotalServices               int      `json:"total_services"`
+	ServicesWithTLS             int      `json:"services_with_tls"`
+	LoadBalancerCertificateARNs []string `json:"load_balancer_certificate_arns"`
+}
+
+// ServicesLinks are the endpoints describing the certificates of a
+// ServicesResponse
+type ServicesLinks struct {
+	ACMCertificates string `json:"acm_certificates"`
+}
+
+// SystemCertificatesResponse is the response of /system-certificates
+type SystemCertificatesResponse struct {
+	Status      string                      `json:"status"`
+	Namespace   string                      `json:"namespace"`
+	WarningDays int                         `json:"warning_days"`
+	Summary     SystemCertificatesSummary   `json:"summary"`
+	Components  []k8s.SystemComponentReport `json:"components"`
+	Notes       []string                    `json:"notes"`
+}
+
+// SystemCertificatesSummary totals a SystemCertificatesResponse
+type SystemCertificatesSummary struct {
+	Components    int            `json:"components"`
+	ByStatus      map[string]int `json:"by_status"`
+	TotalWarnings int            `json:"total_warnings"`
+}
+
+// ServiceAccountTokensResponse is the response of /service-account-tokens
+type ServiceAccountTokensResponse struct {
+	Status      string                        `json:"status"`
+	Namespace   string                        `json:"namespace"`
+	WarningDays int                           `json:"warning_days"`
+	Summary     ServiceAccountTokensSummary   `json:"summary"`
+	Pods        []k8s.PodServiceAccountTokens `json:"pods"`
+	Notes       []string                      `json:"notes"`
+	Truncated   bool                          `json:"truncated,omitempty"`
+	MaxResults  int                           `json:"max_results,omitempty"`
+}
+
+// ServiceAccountTokensSummary totals a ServiceAccountTokensResponse
+type ServiceAccountTokensSummary struct {
+	PodsWithTokens     int `json:"pods_with_tokens"`
+	ProjectedTokens    int `json:"projected_tokens"`
+	LongLivedProjected int `json:"long_lived_projected"`
+	LegacyTokens       int `json:"legacy_tokens"`
+	LegacyNeverExpire  int `json:"legacy_never_expire"`
+	LegacyExpiring     int `json:"legacy_expiring"`
+	LegacyExpired      int `json:"legacy_expired"`
+	LegacyInvalidated  int `json:"legacy_invalidated"`
+	TotalWarnings      int `json:"total_warnings"`
+}
+
+// WebhookCABundlesResponse is the response of /webhook-ca-bundles.
+// APIServiceError is set when the APIServices could not be listed; the
+// webhooks are still reported.
+type WebhookCABundlesResponse struct {
+	Status          string                  `json:"status"`
+	WarningDays     int                     `json:"warning_days"`
+	Summary         WebhookCABundlesSummary `json:"summary"`
+	Webhooks        []k8s.WebhookCABundle   `json:"webhooks"`
+	APIServices     []k8s.APIServiceCA      `json:"api_services"`
+	Warnings        []string                `json:"warnings"`
+	Notes           []string                `json:"notes"`
+	APIServiceError string                  `json:"api_service_error,omitempty"`
+}
+
+// WebhookCABundlesSummary totals a WebhookCABundlesResponse
+type WebhookCABundlesSummary struct {
+	Webhooks             int `json:"webhooks"`
+	APIServices          int `json:"api_services"`
+	ExpiredCertificates  int `json:"expired_certificates"`
+	ExpiringCertificates int `json:"expiring_certificates"`
+	TotalWarnings        int `json:"total_warnings"`
+}
+
+// WorkloadCertificatesResponse is the response of /workload-certificates
+type WorkloadCertificatesResponse struct {
+	Status      string                      `json:"status"`
+	Message     string                      `json:"message"`
+	Namespace   string                      `json:"namespace"`
+	WarningDays int                         `json:"warning_days"`
+	Summary     WorkloadCertificatesSummary `json:"summary"`
+	Workloads   []k8s.WorkloadExpiryInfo    `json:"workloads"`
+	Notes       []string                    `json:"notes"`
+	Truncated   bool                        `json:"truncated,omitempty"`
+	MaxResults  int                         `json:"max_results,omitempty"`
+}
+
+// WorkloadCertificatesSummary totals a WorkloadCertificatesResponse
+type WorkloadCertificatesSummary struct {
+	TotalPodsAnalyzed int `json:"total_pods_analyzed"`
+	Workloads         int `json:"workloads"`
+	TotalCertificates int `json:"total_certificates"`
+	TotalWarnings     int `json:"total_warnings"`
+}
+
+// StaleCertificatesResponse is the response of /stale-certificates
+type StaleCertificatesResponse struct {
+	Status           string                   `json:"status"`
+	Namespace        string                   `json:"namespace"`
+	Summary          StaleCertificatesSummary `json:"summary"`
+	Findings         []k8s.StaleMountFinding  `json:"findings"`
+	SuggestedActions []string                 `json:"suggested_actions"`
+	Notes            []string                 `json:"notes"`
+	Truncated        bool                     `json:"truncated,omitempty"`
+	MaxResults       int                      `json:"max_results,omitempty"`
+}
+
+// StaleCertificatesSummary counts the findings of a
+// StaleCertificatesResponse by confidence
+type StaleCertificatesSummary struct {
+	TotalFindings int            `json:"total_findings"`
+	ByConfidence  map[string]int `json:"by_confidence"`
+}
+
+// APIDocsResponse is the response of /api-docs. The documentation of each
+// endpoint is free-form: its members and example responses differ per
+// endpoint.
+type APIDocsResponse struct {
+	Status            string                            `json:"status"`
+	Message           string                            `json:"message"`
+	Version           string                            `json:"version"`
+	BaseURL           string                            `json:"base_url"`
+	GeneratedAt       string                            `json:"generated_at"`
+	GeneratedAtISO    string                            `json:"generated_at_iso"`
+	Endpoints         map[string]map[string]interface{} `json:"endpoints"`
+	PostmanCollection PostmanCollection                 `json:"postman_collection"`
+	Configuration     DocsConfiguration                 `json:"configuration"`
+	Errors            DocsErrors                        `json:"errors"`
+	Notes             []string                          `json:"notes"`
+}
+
+// PostmanCollection describes how to use /api-docs as a Postman collection
+type PostmanCollection struct {
+	Info          PostmanInfo       `json:"info"`
+	QuickStart    []string          `json:"quick_start"`
+	CommonHeaders map[string]string `json:"common_headers"`
+}
+
+// PostmanInfo names a PostmanCollection
+type PostmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// DocsConfiguration is the configuration the examples of /api-docs use
+type DocsConfiguration struct {
+	DefaultNamespace string `json:"default_namespace"`
+	AWSRegion        string `json:"aws_region"`
+	ClusterName      string `json:"cluster_name"`
+}
+
+// DocsErrors describes the problem details of error responses
+type DocsErrors struct {
+	ContentType  string                 `json:"content_type"`
+	ProblemTypes map[string]problemInfo `json:"problem_types"`
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/pkg/api"
+)
+
+// TestResponsesDecodeIntoAPITypes checks that the pkg/api types hold every
+// member of the responses written from them, so clients decoding into them
+// lose nothing
+func TestResponsesDecodeIntoAPITypes(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Kubernetes.FixturesDir = "../../examples/fixtures"
+	cfg.Clusters = map[string]config.ClusterAWS{"prod": {Region: "eu-west-1"}}
+	handler := newTestHandler(cfg)
+
+	tests := []struct {
+		path string
+		body interface{}
+	}{
+		{"/", &api.RootResponse{}},
+		{"/healthz", &api.HealthResponse{}},
+		{"/readyz", &api.HealthResponse{}},
+		{"/list-pods?namespace=default", &api.ListPodsResponse{}},
+		{"/list-pods?namespace=default,kube-system", &api.NamespacesListPodsResponse{}},
+		{"/namespaces", &api.NamespacesResponse{}},
+		{"/schemas/", &api.SchemasResponse{}},
+		{"/pod-certificates?namespace=default", &api.PodCertificatesResponse{}},
+		{"/pod-certificates?namespace=default&detailed=true", &api.PodCertificatesResponse{}},
+		{"/certificate-expiry?namespace=default", &api.CertificateExpiryResponse{}},
+		{"/cluster-ca", &api.ClusterCAResponse{}},
+		{"/cluster-ca-expiry", &api.ClusterCAExpiryResponse{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d: %s", tt.path, rec.Code, rec.Body)
+			}
+
+			var want map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &want); err != nil {
+				t.Fatal(err)
+			}
+			delete(want, api.SchemaVersionField)
+			if err := json.Unmarshal(rec.Body.Bytes(), tt.body); err != nil {
+				t.Fatal(err)
+			}
+			encoded, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(encoded, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%T lost members of the response:\n got: %s\nwant: %s", tt.body, encoded, rec.Body)
+			}
+		})
+	}
+}
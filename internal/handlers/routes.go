@@ -0,0 +1,729 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"k8s-web-service/internal/agent"
+	"k8s-web-service/internal/config"
+	"k8s-web-service/pkg/api"
+)
+
+// Route describes an API endpoint and the group that enables it
+type Route struct {
+	Path   string
+	Method string
+	Group  string // empty for endpoints that cannot be disabled
+	// Job marks endpoints that run scans; they are refused while draining
+	Job bool
+	// Conditional marks expensive endpoints whose HEAD and conditional GET
+	// requests are answered from the last background scan
+	Conditional bool
+	Description string
+	// PathParam names the rest of the path of routes ending in /, such as
+	// {pod-name}
+	PathParam  string
+	Parameters []string
+	// Example is the path and query of an example request; {namespace} is
+	// replaced with the default namespace
+	Example          string
+	ResponseIncludes []string
+	Handler          http.HandlerFunc
+}
+
+// Routes returns every API endpoint in the order they are documented
+func (h *Handler) Routes() []Route {
+	return []Route{
+		{
+			Path:        "/connect-k8s",
+			Method:      "GET",
+			Description: "Test Kubernetes connection",
+			Example:     "/connect-k8s",
+			Handler:     h.ConnectK8sHandler,
+		},
+		{
+			Path:        "/list-pods",
+			Method:      "GET",
+			Description: "List pods in namespace",
+			Parameters:  []string{namespacesParam},
+			Example:     "/list-pods?namespace={namespace}",
+			Handler:     h.ListPodsHandler,
+		},
+		{
+			Path:        "/namespaces",
+			Method:      "GET",
+			Description: "List visible namespaces with labels, age, background scan status, and links",
+			Example:     "/namespaces",
+			Handler:     h.NamespacesHandler,
+		},
+		{
+			Path:        "/nodes",
+			Method:      "GET",
+			Description: "List nodes with kubelet version, OS, and kubelet certificate rotation status",
+			Example:     "/nodes",
+			Handler:     h.NodesHandler,
+		},
+		{
+			Path:        "/nodes/kubelet-rotation",
+			Method:      "GET",
+			Job:         true,
+			Description: "Per-node kubelet rotateCertificates/serverTLSBootstrap settings from /configz with current certificate expiry",
+			Parameters:  []string{"warning_days (optional)"},
+			Example:     "/nodes/kubelet-rotation",
+			Handler:     h.KubeletRotationHandler,
+		},
+		{
+			Path:        "/services",
+			Method:      "GET",
+			Description: "List Services with TLS ports and AWS load balancer certificate annotations",
+			Parameters:  []string{"namespace (optional)", "tls_only (optional)"},
+			Example:     "/services?namespace={namespace}&tls_only=true",
+			Handler:     h.ServicesHandler,
+		},
+		{
+			Path:        "/live-cert-check",
+			Method:      "GET",
+			Group:       config.EndpointGroupProbes,
+			Description: "Dial a TLS endpoint and parse the certificate chain it actually serves",
+			Parameters:  []string{"host (required)", "port (optional, default: 443)", "server_name (optional, default: host)", "warning_days (optional)", "verify (optional)"},
+			Example:     "/live-cert-check?host=example.com&port=443",
+			Handler:     h.HandleLiveCertCheck,
+		},
+		{
+			Path:        "/live-cert-check/services",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupProbes,
+			Description: "Dial every TLS port of the Services of a namespace and parse the chains they serve",
+			Parameters:  append([]string{"namespace (optional)", "all_namespaces (optional)", "warning_days (optional)", "verify (optional)"}, sortParams...),
+			Example:     "/live-cert-check/services?namespace={namespace}",
+			Handler:     h.HandleLiveServiceCertCheck,
+		},
+		{
+			Path:        "/cluster-ca",
+			Method:      "GET",
+			Group:       config.EndpointGroupClusterCA,
+			Description: "Get cluster CA certificate",
+			Example:     "/cluster-ca",
+			Handler:     h.ClusterCAHandler,
+		},
+		{
+			Path:        "/cluster-ca-expiry",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupClusterCA,
+			Description: "Analyze cluster CA certificate expiry with detailed date information",
+			Parameters:  []string{"warning_days (optional, default: 30)", tzParam},
+			Example:     "/cluster-ca-expiry?warning_days=365",
+			ResponseIncludes: []string{
+				"formatted_dates", "time_remaining", "expiry_status", "validity_period",
+			},
+			Handler: h.HandleClusterCACertificateExpiry,
+		},
+		{
+			Path:        "/ca-rotation-status",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupClusterCA,
+			Description: "Detect an in-progress or recent cluster CA rotation and list which workloads have picked up the new CA",
+			Parameters:  []string{"namespace (optional, comma-separated extra namespaces)", "cluster_name (optional)"},
+			Example:     "/ca-rotation-status?namespace={namespace}",
+			Handler:     h.CARotationStatusHandler,
+		},
+		{
+			Path:        "/webhook-ca-bundles",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupClusterCA,
+			Description: "Expiry of the caBundles of every mutating and validating admission webhook and aggregated APIService",
+			Parameters:  []string{"warning_days (optional)", tzParam},
+			Example:     "/webhook-ca-bundles?warning_days=60",
+			Handler:     h.HandleWebhookCABundles,
+		},
+		{
+			Path:        "/scan",
+			Method:      "GET",
+			Job:         true,
+			Conditional: true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "One consolidated certificate report of a namespace across pods, secrets, ingress, webhooks, and the cluster CA",
+			Parameters:  []string{"namespace (optional)", "include (optional, comma-separated: pods, secrets, ingress, webhooks, cluster-ca; default: all)", "warning_days (optional)", tzParam},
+			Example:     "/scan?namespace={namespace}&include=pods,secrets,ingress",
+			Handler:     h.HandleScan,
+		},
+		{
+			Path:        "/health-score",
+			Method:      "GET",
+			Job:         true,
+			Conditional: true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Certificate health score from 0 to 100 per namespace, weighted by expired, critical, weak-key, and broken-chain certificates",
+			Parameters:  []string{"namespace (optional; default: the namespaces of the last background scan)", "warning_days (optional)"},
+			Example:     "/health-score?namespace={namespace}",
+			Handler:     h.HandleHealthScore,
+		},
+		{
+			Path:        "/policy-violations",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Evaluate the configured certificate policies per namespace, with pass or fail per rule",
+			Parameters:  []string{"namespace (optional; default: the namespaces of the last background scan)"},
+			Example:     "/policy-violations?namespace={namespace}",
+			Handler:     h.HandlePolicyViolations,
+		},
+		{
+			Path:        "/graphql",
+			Method:      "POST",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "GraphQL queries over namespaces, pods, certificate sources, certificates, warnings, and health scores",
+			Example:     "/graphql",
+			Handler:     h.HandleGraphQL,
+		},
+		{
+			Path:        "/analyze/keystore",
+			Method:      "POST",
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Analyze the certificates of an uploaded PKCS#12 or JKS keystore before deployment",
+			Parameters:  []string{"warning_days (optional)", tzParam},
+			Example:     "/analyze/keystore",
+			Handler:     h.HandleKeystoreAnalysis,
+		},
+		{
+			Path:        "/compare",
+			Method:      "POST",
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Compare an uploaded certificate with the one stored in a secret and served by a Service",
+			Parameters:  []string{"namespace (optional)", "secret (optional)", "service (optional; requires the probes group)", "port (optional; default: the first TLS port of service)", tzParam},
+			Example:     "/compare?namespace={namespace}&secret=example-tls&service=example",
+			Handler:     h.HandleCompare,
+		},
+		{
+			Path:        "/pod-certificates",
+			Method:      "GET",
+			Job:         true,
+			Conditional: true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Analyze pod certificates (use ?detailed=true for expiry analysis)",
+			Parameters:  append([]string{namespacesParam, "detailed (optional)", "warning_days (optional)", "full_bundles (optional)", "verify (optional)", tzParam, formatParam}, sortParams...),
+			Example:     "/pod-certificates?detailed=true&warning_days=90",
+			Handler:     h.HandlePodCertificates,
+		},
+		{
+			Path:        "/pod-certificates/",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Detailed certificate analysis for specific pod",
+			PathParam:   "{pod-name}",
+			Parameters:  []string{"namespace (optional)", "warning_days (optional)", "full_bundles (optional)", "verify (optional)", tzParam},
+			Example:     "/pod-certificates/example-pod?namespace={namespace}&warning_days=30",
+			Handler:     h.HandlePodCertificateDetails,
+		},
+		{
+			Path:        "/certificate-expiry",
+			Method:      "GET",
+			Job:         true,
+			Conditional: true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Certificate expiry analysis across namespace",
+			Parameters:  append([]string{namespacesParam, "warning_days (optional)", "full_bundles (optional)", "verify (optional)", "refresh (optional, true to analyze now instead of serving the last background scan)", tzParam, formatParam}, sortParams...),
+			Example:     "/certificate-expiry?namespace={namespace}&warning_days=60",
+			Handler:     h.HandleCertificateExpiry,
+		},
+		{
+			Path:        "/workload-certificates",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Certificate expiry analysis rolled up to Deployments, StatefulSets, DaemonSets, and CronJobs",
+			Parameters:  append([]string{"namespace (optional)", "warning_days (optional)", "full_bundles (optional)", "verify (optional)", tzParam}, sortParams...),
+			Example:     "/workload-certificates?namespace={namespace}&warning_days=30",
+			Handler:     h.HandleWorkloadCertificates,
+		},
+		{
+			Path:        "/stale-certificates",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Pods still using a certificate that was rotated after they started, with a suggested restart command",
+			Parameters:  []string{"namespace (optional)"},
+			Example:     "/stale-certificates?namespace={namespace}",
+			Handler:     h.HandleStaleCertificates,
+		},
+		{
+			Path:        "/service-account-tokens",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Projected service account tokens with their expiration and audiences, and legacy token secrets with the expiry of their JWT",
+			Parameters:  []string{"namespace (optional)", "warning_days (optional)"},
+			Example:     "/service-account-tokens?namespace={namespace}",
+			Handler:     h.HandleServiceAccountTokens,
+		},
+		{
+			Path:        "/system-certificates",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Certificate health of CoreDNS, kube-proxy, metrics-server, the AWS Load Balancer Controller, and the EBS/EFS CSI drivers",
+			Parameters:  []string{"warning_days (optional)", "full_bundles (optional)", "verify (optional)"},
+			Example:     "/system-certificates",
+			Handler:     h.HandleSystemCertificates,
+		},
+		{
+			Path:        "/helm-certificates",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Certificates templated into Helm release values and manifests at install time",
+			Parameters:  append([]string{"namespace (optional)", "warning_days (optional)", "all_revisions (optional, true to scan every stored revision)"}, sortParams...),
+			Example:     "/helm-certificates?namespace={namespace}",
+			Handler:     h.HandleHelmCertificates,
+		},
+		{
+			Path:        "/image-ca-bundles",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Expired or expiring roots in the system CA bundles baked into workload images (opt-in: images.ca_bundle_analysis)",
+			Parameters:  []string{"namespace (optional)", "warning_days (optional)"},
+			Example:     "/image-ca-bundles?namespace={namespace}",
+			Handler:     h.ImageCABundlesHandler,
+		},
+		{
+			Path:        "/secrets",
+			Method:      "GET",
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "List secret metadata (names, types, key names, sizes, ages) without values",
+			Parameters:  []string{"namespace (optional)", "type (optional, e.g. kubernetes.io/tls)"},
+			Example:     "/secrets?namespace={namespace}&type=kubernetes.io/tls",
+			Handler:     h.SecretsHandler,
+		},
+		{
+			Path:        "/secrets-certificates",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Certificates of every TLS secret, and of Opaque secrets holding certificates, whether or not a pod references them",
+			Parameters:  append([]string{"namespace (optional)", "all_namespaces (optional, true to list every namespace)", "warning_days (optional)", "full_bundles (optional)", "verify (optional)", tzParam}, sortParams...),
+			Example:     "/secrets-certificates?namespace={namespace}&sort=days_until_expiry",
+			Handler:     h.HandleSecretCertificates,
+		},
+		{
+			Path:        "/configmaps/trust-bundles",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Find configmaps holding CA bundles with size, soonest-expiring member, and expired roots",
+			Parameters:  []string{"namespace (optional)"},
+			Example:     "/configmaps/trust-bundles?namespace={namespace}",
+			Handler:     h.TrustBundlesHandler,
+		},
+		{
+			Path:        "/trust-store-validation",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupSecretScanning,
+			Description: "Check that each pod's service account ca.crt and trust bundle configmaps validate the API server's current serving chain",
+			Parameters:  []string{"namespace (optional)", tzParam},
+			Example:     "/trust-store-validation?namespace={namespace}",
+			Handler:     h.TrustStoreValidationHandler,
+		},
+		{
+			Path:        "/eks/clusters",
+			Method:      "GET",
+			Group:       config.EndpointGroupAWS,
+			Description: "EKS clusters in the account, marking those this service is set up to monitor",
+			Parameters:  []string{"regions (optional, comma-separated)"},
+			Example:     "/eks/clusters?regions=us-east-1,us-west-2",
+			Handler:     h.EKSClustersHandler,
+		},
+		{
+			Path:        "/eks/addons",
+			Method:      "GET",
+			Group:       config.EndpointGroupAWS,
+			Description: "Installed EKS addons with current vs. latest compatible versions",
+			Parameters:  []string{"cluster_name (optional)"},
+			Example:     "/eks/addons",
+			Handler:     h.EKSAddonsHandler,
+		},
+		{
+			Path:        "/eks/nodegroups",
+			Method:      "GET",
+			Group:       config.EndpointGroupAWS,
+			Description: "EKS managed nodegroups and Fargate profiles with AMI release versions and scaling config",
+			Parameters:  []string{"cluster_name (optional)"},
+			Example:     "/eks/nodegroups",
+			Handler:     h.EKSNodegroupsHandler,
+		},
+		{
+			Path:        "/eks/oidc-thumbprint",
+			Method:      "GET",
+			Group:       config.EndpointGroupAWS,
+			Description: "Compare the OIDC issuer certificate thumbprint with the IAM OIDC provider used for IRSA",
+			Parameters:  []string{"cluster_name (optional)"},
+			Example:     "/eks/oidc-thumbprint",
+			Handler:     h.EKSOIDCHandler,
+		},
+		{
+			Path:        "/eks/access",
+			Method:      "GET",
+			Group:       config.EndpointGroupAWS,
+			Description: "aws-auth ConfigMap mappings and EKS access entries with broken mappings flagged",
+			Parameters:  []string{"cluster_name (optional)"},
+			Example:     "/eks/access",
+			Handler:     h.EKSAccessHandler,
+		},
+		{
+			Path:        "/aws/acm-certificates",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupAWS,
+			Description: "ACM and IAM server certificates with expiry and renewal eligibility, correlated to the Ingresses and Services referencing them",
+			Parameters:  append([]string{"namespace (optional, default all namespaces)", "referenced_only (optional)", "warning_days (optional)"}, sortParams...),
+			Example:     "/aws/acm-certificates?referenced_only=true",
+			Handler:     h.ACMCertificatesHandler,
+		},
+		{
+			Path:        "/aws/load-balancer-certificates",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupAWS,
+			Description: "Certificates attached to the ALB/NLB listeners fronting the cluster's Ingresses and Services",
+			Parameters:  []string{"namespace (optional, default all namespaces)", "warning_days (optional)"},
+			Example:     "/aws/load-balancer-certificates",
+			Handler:     h.LoadBalancerCertificatesHandler,
+		},
+		{
+			Path:        "/aws/route53-coverage",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupAWS,
+			Description: "Route53 records pointing at cluster load balancers and whether a served certificate covers them",
+			Parameters:  []string{"namespace (optional, default all namespaces)", "warning_days (optional)"},
+			Example:     "/aws/route53-coverage",
+			Handler:     h.Route53CoverageHandler,
+		},
+		{
+			Path:        "/aws/secretsmanager-certificates",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupAWS,
+			Description: "Expiry of PEM and PKCS#12 certificates stored in Secrets Manager under the configured prefixes",
+			Parameters:  append([]string{"prefix (optional, must be under a configured prefix)", "warning_days (optional)"}, sortParams...),
+			Example:     "/aws/secretsmanager-certificates",
+			Handler:     h.SecretsManagerCertificatesHandler,
+		},
+		{
+			Path:        "/aws/cloudfront-certificates",
+			Method:      "GET",
+			Group:       config.EndpointGroupAWS,
+			Description: "Viewer certificate expiry and minimum TLS protocol of the configured CloudFront distributions",
+			Parameters:  []string{"warning_days (optional)"},
+			Example:     "/aws/cloudfront-certificates",
+			Handler:     h.CloudFrontCertificatesHandler,
+		},
+		{
+			Path:        "/aws/private-ca",
+			Method:      "GET",
+			Group:       config.EndpointGroupAWS,
+			Description: "ACM Private CAs that issued ACM certificates, with CA expiry and revocation configuration",
+			Parameters:  []string{"warning_days (optional)"},
+			Example:     "/aws/private-ca",
+			Handler:     h.PrivateCAHandler,
+		},
+		{
+			Path:        "/hostpath-certificates",
+			Method:      "GET",
+			Job:         true,
+			Group:       config.EndpointGroupNodeAgent,
+			Description: "Certificate files under the hostPath volumes of pods, as reported by the node agent DaemonSet",
+			Parameters:  []string{"namespace (optional, default: all namespaces)", "warning_days (optional)", "full_bundles (optional)"},
+			Example:     "/hostpath-certificates?namespace={namespace}",
+			Handler:     h.HostPathCertificatesHandler,
+		},
+		{
+			Path:        "/agent/report",
+			Method:      "POST",
+			Group:       config.EndpointGroupNodeAgent,
+			Description: "Receive a node agent report (bearer agent.token)",
+			Example:     "/agent/report",
+			Handler:     h.AgentReportHandler,
+		},
+		{
+			Path:        "/debug",
+			Method:      "GET",
+			Group:       config.EndpointGroupDebug,
+			Description: "Debug AWS and Kubernetes configuration",
+			Example:     "/debug",
+			Handler:     h.DebugHandler,
+		},
+		{
+			Path:        "/test-k8s-auth",
+			Method:      "GET",
+			Group:       config.EndpointGroupDebug,
+			Description: "Test Kubernetes authentication and the permissions of the certificate features per namespace",
+			Parameters:  []string{"namespace (optional, comma-separated extra namespaces)"},
+			Example:     "/test-k8s-auth?namespace=payments",
+			Handler:     h.TestK8sAuthHandler,
+		},
+		{
+			Path:        "/debug/rbac",
+			Method:      "GET",
+			Group:       config.EndpointGroupDebug,
+			Description: "Effective RBAC of the service's identity as a permission by namespace matrix",
+			Parameters:  []string{"namespace (optional, comma-separated extra namespaces)", "format (optional, table for text)"},
+			Example:     "/debug/rbac?format=table",
+			Handler:     h.DebugRBACHandler,
+		},
+		{
+			Path:        "/admin/reload",
+			Method:      "POST",
+			Group:       config.EndpointGroupAdmin,
+			Description: "Reload the configuration file without restarting",
+			Example:     "/admin/reload",
+			Handler:     h.ReloadHandler,
+		},
+		{
+			Path:        "/admin/drain",
+			Method:      "POST",
+			Group:       config.EndpointGroupAdmin,
+			Description: "Stop accepting new scans, let running scans finish, and report progress (GET for status, DELETE to resume)",
+			Example:     "/admin/drain",
+			Handler:     h.DrainHandler,
+		},
+		{
+			Path:        "/admin/simulate",
+			Method:      "POST",
+			Job:         true,
+			Group:       config.EndpointGroupAdmin,
+			Description: "Send alerts for certificates with a simulated expiry date to test notifier wiring",
+			Parameters:  []string{"expiry_in (required, e.g. 3d or 12h)", "namespace (optional)", "match (optional, subject or source substring)"},
+			Example:     "/admin/simulate?expiry_in=3d&namespace={namespace}",
+			Handler:     h.SimulateHandler,
+		},
+		{
+			Path:        "/admin/reissue-certificate",
+			Method:      "POST",
+			Group:       config.EndpointGroupAdmin,
+			Description: "Ask ACM to re-issue a certificate from its private CA (refused in read-only mode)",
+			Parameters:  []string{"certificate_arn (required)"},
+			Example:     "/admin/reissue-certificate?certificate_arn=arn:aws:acm:...",
+			Handler:     h.ReissueCertificateHandler,
+		},
+		{
+			Path:        "/healthz",
+			Method:      "GET",
+			Description: "Liveness probe",
+			Example:     "/healthz",
+			Handler:     h.HealthzHandler,
+		},
+		{
+			Path:        "/readyz",
+			Method:      "GET",
+			Description: "Readiness probe; not ready while draining",
+			Example:     "/readyz",
+			Handler:     h.ReadyzHandler,
+		},
+		{
+			Path:        "/metrics",
+			Method:      "GET",
+			Description: "Prometheus certificate expiry gauges, scan error counters, and latency of AWS calls, Kubernetes API requests, and EKS token generation",
+			Example:     "/metrics",
+			Handler:     h.MetricsHandler,
+		},
+		{
+			Path:        "/schemas/",
+			Method:      "GET",
+			Description: "JSON Schemas of the typed response bodies, listed at /schemas/ and served at /schemas/{name}.json",
+			PathParam:   "{name}.json",
+			Example:     "/schemas/pod-certificates.json",
+			Handler:     h.SchemasHandler,
+		},
+		{
+			Path:        "/api-docs",
+			Method:      "GET",
+			Description: "Detailed API documentation with examples",
+			Parameters:  []string{tzParam},
+			Example:     "/api-docs",
+			Handler:     h.APIDocsHandler,
+		},
+	}
+}
+
+// Register adds the root handler and every route to mux. The group of a
+// route is checked on each request so configuration reloads take effect
+// without re-registering.
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/", versionResponse(h.requireClientCert("/", h.RootHandler)))
+	for _, route := range h.Routes() {
+		handler := route.Handler
+		if route.Job {
+			handler = h.trackJob(route.Path, handler)
+		}
+		handler = h.redactResponse(handler)
+		handler = shapeResponse(handler)
+		if route.Conditional {
+			handler = h.conditionalScan(handler)
+		}
+		handler = h.requireCluster(handler)
+		handler = validateParams(route, handler)
+		handler = h.applyLimits(route.Group, handler)
+		if route.Group == config.EndpointGroupAdmin {
+			handler = h.requireAdmin(handler)
+		}
+		mux.HandleFunc(route.Path, versionResponse(h.requireClientCert(route.Path, h.requireGroup(route.Group, handler))))
+	}
+}
+
+// trackJob registers the request as a running scan job and refuses it with
+// 503 while the server is draining
+func (h *Handler) trackJob(kind string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		end, ok := h.jobs.Begin(kind)
+		if !ok {
+			w.Header().Set("Retry-After", "30")
+			writeProblem(w, r, http.StatusServiceUnavailable, ProblemDraining, "Server is draining and not accepting new scans")
+			return
+		}
+		defer end()
+		next(w, r)
+	}
+}
+
+// requireGroup responds with 404 while the endpoint group is disabled
+func (h *Handler) requireGroup(group string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.cfg().EndpointGroupEnabled(group) {
+			h.notFound(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// clientCertExempt are the paths served without a client certificate while
+// one is required: kubelet probes present none, and agent reports
+// authenticate with agent.token
+var clientCertExempt = map[string]bool{"/healthz": true, "/readyz": true, agent.ReportPath: true}
+
+// requireClientCert responds with 401 to HTTPS requests without a verified
+// client certificate while server.tls.client_auth is require
+func (h *Handler) requireClientCert(path string, next http.HandlerFunc) http.HandlerFunc {
+	if clientCertExempt[path] {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) == 0 && h.cfg().ClientCertificateRequired() {
+			writeProblem(w, r, http.StatusUnauthorized, ProblemUnauthenticated, "A client certificate signed by server.tls.client_ca_file is required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireAdmin responds with 401 to requests that present neither a verified
+// client certificate nor admin.token as a bearer token
+func (h *Handler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			next(w, r)
+			return
+		}
+		token := h.cfg().Admin.Token
+		presented, bearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !bearer || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeProblem(w, r, http.StatusUnauthorized, ProblemUnauthenticated, "Admin endpoints require Authorization: Bearer <admin.token> or a client certificate signed by server.tls.client_ca_file")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// notFound writes a 404 problem response
+func (h *Handler) notFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, ProblemNotFound, "Endpoint %s not found", r.URL.Path)
+}
+
+// enabledRoutes returns the routes whose group is enabled
+func (h *Handler) enabledRoutes() []Route {
+	cfg := h.cfg()
+	var routes []Route
+	for _, route := range h.Routes() {
+		if cfg.EndpointGroupEnabled(route.Group) {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// pathEnabled reports whether the endpoint serving path is enabled. Paths
+// that do not belong to a route are considered enabled.
+func (h *Handler) pathEnabled(path string) bool {
+	cfg := h.cfg()
+	for _, route := range h.Routes() {
+		if route.Path == path || (strings.HasSuffix(route.Path, "/") && strings.HasPrefix(path, route.Path)) {
+			return cfg.EndpointGroupEnabled(route.Group)
+		}
+	}
+	return true
+}
+
+// baseURL returns the URL clients use to reach the server
+func (h *Handler) baseURL() string {
+	scheme := "http"
+	if h.cfg().ServerTLSEnabled() {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%s", scheme, h.cfg().Server.Host, h.cfg().Server.Port)
+}
+
+// RootHandler handles the / endpoint with a service overview listing the
+// enabled endpoints
+func (h *Handler) RootHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := h.cfg()
+	baseURL := h.baseURL()
+
+	var endpoints []api.EndpointInfo
+	for _, route := range h.enabledRoutes() {
+		endpoints = append(endpoints, api.EndpointInfo{
+			Path:             route.Path + route.PathParam,
+			Method:           route.Method,
+			Description:      route.Description,
+			ExampleURL:       baseURL + strings.ReplaceAll(route.Example, "{namespace}", cfg.Kubernetes.DefaultNamespace),
+			Parameters:       route.Parameters,
+			ResponseIncludes: route.ResponseIncludes,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := api.RootResponse{
+		Status:  api.StatusSuccess,
+		Message: "Kubernetes Web Service API",
+		Version: "2.0.0",
+		ServerInfo: api.ServerInfo{
+			Host:    cfg.Server.Host,
+			Port:    cfg.Server.Port,
+			BaseURL: baseURL,
+		},
+		Endpoints: endpoints,
+		Clusters:  h.clusterList(),
+		PostmanTips: []string{
+			"All endpoints return JSON responses",
+			"Use query parameters to customize responses",
+			"Set Content-Type: application/json in headers",
+			"Check the /api-docs endpoint for detailed examples",
+		},
+	}
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error: failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/lifecycle"
+)
+
+// newTestHandler returns the routes of a handler serving cfg, completed with
+// the defaults
+func newTestHandler(cfg *config.Config) http.Handler {
+	cfg.SetDefaults()
+	mux := http.NewServeMux()
+	New(config.NewStore(cfg), nil, lifecycle.NewTracker(), nil).Register(mux)
+	return mux
+}
+
+func TestAdminEndpointsAuthentication(t *testing.T) {
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{}}}
+	tests := []struct {
+		name      string
+		endpoints map[string]bool
+		token     string
+		header    string
+		tls       *tls.ConnectionState
+		want      int
+	}{
+		{name: "disabled by default", token: "secret", header: "Bearer secret", want: http.StatusNotFound},
+		{name: "disabled", endpoints: map[string]bool{"admin": false}, token: "secret", header: "Bearer secret", want: http.StatusNotFound},
+		{name: "valid token", endpoints: map[string]bool{"admin": true}, token: "secret", header: "Bearer secret", want: http.StatusOK},
+		{name: "no credentials", endpoints: map[string]bool{"admin": true}, token: "secret", want: http.StatusUnauthorized},
+		{name: "wrong token", endpoints: map[string]bool{"admin": true}, token: "secret", header: "Bearer wrong", want: http.StatusUnauthorized},
+		{name: "token without bearer scheme", endpoints: map[string]bool{"admin": true}, token: "secret", header: "secret", want: http.StatusUnauthorized},
+		{name: "no token configured", endpoints: map[string]bool{"admin": true}, header: "Bearer ", want: http.StatusUnauthorized},
+		{name: "verified client certificate", endpoints: map[string]bool{"admin": true}, tls: verified, want: http.StatusOK},
+		{name: "unverified client certificate", endpoints: map[string]bool{"admin": true}, token: "secret", tls: &tls.ConnectionState{}, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Endpoints: tt.endpoints}
+			cfg.Admin.Token = tt.token
+			handler := newTestHandler(cfg)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/drain", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			req.TLS = tt.tls
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET /admin/drain = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestAPIDocsHideDisabledEndpoints(t *testing.T) {
+	// The admin_simulate and admin_reissue_certificate URLs carry example
+	// query strings
+	admin := []string{"admin_reload", "admin_drain", "admin_simulate", "admin_reissue_certificate"}
+	tests := []struct {
+		name      string
+		endpoints map[string]bool
+		listed    bool
+	}{
+		{"admin disabled by default", nil, false},
+		{"admin enabled", map[string]bool{"admin": true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(&config.Config{Endpoints: tt.endpoints})
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api-docs", nil))
+
+			var docs APIDocsResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &docs); err != nil {
+				t.Fatalf("GET /api-docs = %d: %v", rec.Code, err)
+			}
+			for _, name := range admin {
+				if _, ok := docs.Endpoints[name]; ok != tt.listed {
+					t.Errorf("%s listed = %t, want %t", name, ok, tt.listed)
+				}
+			}
+		})
+	}
+}
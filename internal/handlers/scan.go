@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// HandleScan handles the /scan endpoint, running the analyses of the pods,
+// secrets, ingress, webhooks, and cluster CA sections in one request and
+// summarizing them together. A section that fails is reported under errors
+// instead of failing the scan.
+func (h *Handler) HandleScan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = h.cfg().Kubernetes.DefaultNamespace
+	}
+	warningDays := h.cfg().Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	report := k8s.RunScan(ctx, client, namespace, scanSections(r.URL.Query().Get("include")), warningDays)
+	h.setScanDates(r, time.Now(), report)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.ScanResponse{
+		Status:     api.StatusSuccess,
+		ScanReport: apiScanReport(report),
+		Notes: []string{
+			"summary.status is critical when a certificate has expired, and warning when one expires within warning_days or a section failed",
+			"webhooks are the admission webhooks calling services in the namespace; cluster-ca is the CA of the kubeconfig",
+		},
+	})
+}
+
+// scanSections returns the sections named by the include parameter, in the
+// order they run, or every section if it is empty
+func scanSections(include string) []string {
+	if include == "" {
+		return k8s.ScanSections
+	}
+	selected := make(map[string]bool)
+	for _, section := range strings.Split(include, ",") {
+		selected[strings.TrimSpace(section)] = true
+	}
+	var sections []string
+	for _, section := range k8s.ScanSections {
+		if selected[section] {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// setScanDates sets the dates of the certificates of every section of a
+// scan report
+func (h *Handler) setScanDates(r *http.Request, now time.Time, report *k8s.ScanReport) {
+	if report.Pods != nil {
+		h.setExpiryReportDates(r, now, report.Pods)
+	}
+	h.setSourceDates(r, now, report.Secrets...)
+	for _, ingress := range report.Ingress {
+		h.setCertificateDates(r, now, ingress.Cert)
+		h.setCertificateDates(r, now, ingress.Chain...)
+	}
+	for _, webhook := range report.Webhooks {
+		h.setCertificateDates(r, now, webhook.Certificates...)
+	}
+	h.setSourceDates(r, now, report.ClusterCA)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"k8s-web-service/pkg/api"
+)
+
+// SchemaContentType is the media type of published JSON Schemas
+const SchemaContentType = "application/schema+json"
+
+// versionResponse adds schema_version to JSON object responses, including
+// problem responses, so clients can tell which contract a response follows.
+// The member is inserted first, without decoding the body.
+func versionResponse(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		buffered := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+		next(buffered, r)
+
+		body := buffered.body.Bytes()
+		contentType := w.Header().Get("Content-Type")
+		if strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, ProblemContentType) {
+			body = withSchemaVersion(body)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(buffered.status)
+		w.Write(body)
+	}
+}
+
+// withSchemaVersion inserts schema_version into a JSON object, unless it is
+// not an object or already has the member
+func withSchemaVersion(body []byte) []byte {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '{' || bytes.Contains(body, []byte(`"`+api.SchemaVersionField+`"`)) {
+		return body
+	}
+	rest := trimmed[1:]
+	member := `"` + api.SchemaVersionField + `":"` + api.SchemaVersion + `"`
+	if !bytes.HasPrefix(bytes.TrimLeft(rest, " \t\r\n"), []byte("}")) {
+		member += ","
+	}
+	versioned := make([]byte, 0, len(trimmed)+len(member))
+	versioned = append(versioned, '{')
+	versioned = append(versioned, member...)
+	return append(versioned, rest...)
+}
+
+// SchemasHandler handles /schemas/, listing the published JSON Schemas of
+// response bodies, and /schemas/{name}.json, serving one of them
+func (h *Handler) SchemasHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/schemas/")
+	if name == "" {
+		var schemas []api.SchemaEntry
+		for _, name := range api.SchemaNames() {
+			schemas = append(schemas, api.SchemaEntry{
+				Name:     name,
+				Endpoint: api.Schemas[name].Endpoint,
+				URL:      h.baseURL() + "/schemas/" + name + ".json",
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.SchemasResponse{
+			Status:  api.StatusSuccess,
+			Schemas: schemas,
+			Notes: []string{
+				"Every JSON response carries schema_version; its major version changes when a field is removed, renamed, or changes type",
+				"Error responses are RFC 7807 problem details with type, title, status, and detail",
+			},
+		})
+		return
+	}
+
+	schema, err := api.ResponseSchema(strings.TrimSuffix(name, ".json"))
+	if err != nil || !strings.HasSuffix(name, ".json") {
+		writeProblem(w, r, http.StatusNotFound, ProblemNotFound, "Schema %s not found; see /schemas/", name)
+		return
+	}
+	w.Header().Set("Content-Type", SchemaContentType)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(schema)
+}
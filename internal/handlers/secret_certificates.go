@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// HandleSecretCertificates handles the /secrets-certificates endpoint,
+// reporting the certificates of every TLS secret, and of Opaque secrets
+// holding certificates, whether or not a pod references them
+func (h *Handler) HandleSecretCertificates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = h.cfg().Kubernetes.DefaultNamespace
+	}
+	allNamespaces := r.URL.Query().Get("all_namespaces") == "true"
+	if allNamespaces {
+		namespace = ""
+	}
+	warningDays := h.cfg().Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	secrets, err := k8s.ListSecretCertificates(ctx, client.GetClientset(), namespace, warningDays)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to list secret certificates: %v", err)
+		return
+	}
+
+	byType := make(map[string]int)
+	byStatus := make(map[string]int)
+	totalCertificates, totalWarnings, keyMismatches := 0, 0, 0
+	now := time.Now()
+	loc := h.location(r)
+	verify := h.chainVerifier(r)
+	for i := range secrets {
+		secret := &secrets[i]
+		secret.Created = secret.Created.In(loc)
+		h.setSourceDates(r, now, &secret.CertificateSource)
+		byType[secret.SecretType]++
+		byStatus[secret.Status]++
+		if secret.PrivateKey != nil && secret.PrivateKey.Error == "" && !secret.PrivateKey.MatchesCertificate {
+			keyMismatches++
+		}
+		totalCertificates += len(secret.Certificates)
+		totalWarnings += len(secret.Warnings)
+		verify(&secret.CertificateSource)
+		h.summarizeBundles(r, warningDays, &secret.CertificateSource)
+	}
+
+	sortResults(r, secrets, func(secret *k8s.SecretCertificates) sortFields {
+		return soonestExpiry(sortFields{Name: secret.Name, Namespace: secret.Namespace}, secret.Certificates...)
+	})
+
+	total := len(secrets)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(secrets) > limit
+	if truncated {
+		secrets = secrets[:limit]
+	}
+
+	response := SecretCertificatesResponse{
+		Status:        api.StatusSuccess,
+		Namespace:     namespace,
+		AllNamespaces: allNamespaces,
+		WarningDays:   warningDays,
+		Summary: SecretCertificatesSummary{
+			TotalSecrets:      total,
+			ByType:            byType,
+			ByStatus:          byStatus,
+			TotalCertificates: totalCertificates,
+			TotalWarnings:     totalWarnings,
+			KeyMismatches:     keyMismatches,
+		},
+		Secrets: secrets,
+		Notes: []string{
+			"Lists secrets whether or not a pod references them; private keys and other values are never returned",
+			"private_key compares tls.key with the certificate in tls.crt; only its algorithm and size are reported",
+			"Opaque secrets are listed when a certificate-like key holds a PEM certificate",
+		},
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// SecretsHandler handles the /secrets endpoint, listing secret metadata
+// (names, types, key names, sizes, and ages). Secret values are never
+// included in the response.
+func (h *Handler) SecretsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	namespace := h.cfg().Kubernetes.DefaultNamespace
+	if ns := r.URL.Query().Get("namespace"); ns != "" {
+		namespace = ns
+	}
+	secretType := r.URL.Query().Get("type")
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	secrets, err := k8s.ListSecretMetadata(r.Context(), client.GetClientset(), namespace, secretType)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "%v", err)
+		return
+	}
+
+	byType := make(map[string]int)
+	for _, secret := range secrets {
+		byType[secret.Type]++
+	}
+
+	total := len(secrets)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(secrets) > limit
+	if truncated {
+		secrets = secrets[:limit]
+	}
+
+	response := SecretsResponse{
+		Status:    api.StatusSuccess,
+		Namespace: namespace,
+		Summary: SecretsSummary{
+			TotalSecrets: total,
+			ByType:       byType,
+		},
+		Secrets: secrets,
+		Notes: []string{
+			"Metadata only: secret values are never returned",
+			"Use /pod-certificates?detailed=true or /certificate-expiry for certificate analysis",
+		},
+		Type: secretType,
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// ServicesHandler handles the /services endpoint, listing Services with
+// their TLS ports and AWS load balancer certificate annotations
+func (h *Handler) ServicesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	namespace := h.cfg().Kubernetes.DefaultNamespace
+	if ns := r.URL.Query().Get("namespace"); ns != "" {
+		namespace = ns
+	}
+	tlsOnly := r.URL.Query().Get("tls_only") == "true"
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	services, err := k8s.ListServiceTLS(r.Context(), client.GetClientset(), namespace, tlsOnly)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "%v", err)
+		return
+	}
+
+	withTLS := 0
+	arnSet := make(map[string]bool)
+	for _, svc := range services {
+		if svc.HasTLS {
+			withTLS++
+		}
+		if svc.AWS != nil {
+			for _, arn := range svc.AWS.CertificateARNs {
+				arnSet[arn] = true
+			}
+		}
+	}
+	arns := make([]string, 0, len(arnSet))
+	for arn := range arnSet {
+		arns = append(arns, arn)
+	}
+	sort.Strings(arns)
+
+	total := len(services)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(services) > limit
+	if truncated {
+		services = services[:limit]
+	}
+
+	response := ServicesResponse{
+		Status:    api.StatusSuccess,
+		Namespace: namespace,
+		TLSOnly:   tlsOnly,
+		Summary: ServicesSummary{
+			TotalServices:               total,
+			ServicesWithTLS:             withTLS,
+			LoadBalancerCertificateARNs: arns,
+		},
+		Services: services,
+	}
+	if len(arns) > 0 && h.cfg().EndpointGroupEnabled(config.EndpointGroupAWS) {
+		query := url.Values{"namespace": {namespace}, "referenced_only": {"true"}}.Encode()
+		response.Links = &ServicesLinks{
+			ACMCertificates: fmt.Sprintf("%s/aws/acm-certificates?%s", h.baseURL(), query),
+		}
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/utils"
+)
+
+// sortParams document the sort and order parameters of certificate list
+// endpoints
+var sortParams = []string{
+	"sort (optional, days_until_expiry, name, namespace, or issuer)",
+	"order (optional, asc or desc, default: asc)",
+}
+
+// sortFields are the values a list item is sorted by. DaysUntilExpiry and
+// Issuer are those of the item's certificate that expires first; items
+// without certificates have no expiry and sort last in either order.
+type sortFields struct {
+	Name            string
+	Namespace       string
+	Issuer          string
+	DaysUntilExpiry *int
+}
+
+// sortResults orders list items by the sort and order query parameters,
+// leaving them as they are without sort. Ties are broken by namespace and
+// name, so the order is stable across requests. Call it before truncating
+// to max_results, so the limit keeps the first items of the order.
+func sortResults[T any](r *http.Request, items []T, fields func(*T) sortFields) {
+	key := r.URL.Query().Get("sort")
+	if key == "" {
+		return
+	}
+	desc := r.URL.Query().Get("order") == "desc"
+
+	values := make([]sortFields, len(items))
+	for i := range items {
+		values[i] = fields(&items[i])
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := values[order[i]], values[order[j]]
+		if key == "days_until_expiry" && (a.DaysUntilExpiry == nil) != (b.DaysUntilExpiry == nil) {
+			return b.DaysUntilExpiry == nil
+		}
+		cmp := compareSortFields(a, b, key)
+		if cmp == 0 {
+			if cmp = compareSortFields(a, b, "namespace"); cmp == 0 {
+				cmp = compareSortFields(a, b, "name")
+			}
+			return cmp < 0
+		}
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	sorted := make([]T, len(items))
+	for i, index := range order {
+		sorted[i] = items[index]
+	}
+	copy(items, sorted)
+}
+
+// compareSortFields compares two items by a sort key, returning -1, 0, or 1
+func compareSortFields(a, b sortFields, key string) int {
+	switch key {
+	case "days_until_expiry":
+		if a.DaysUntilExpiry == nil || b.DaysUntilExpiry == nil {
+			return 0
+		}
+		switch {
+		case *a.DaysUntilExpiry < *b.DaysUntilExpiry:
+			return -1
+		case *a.DaysUntilExpiry > *b.DaysUntilExpiry:
+			return 1
+		}
+		return 0
+	case "namespace":
+		return strings.Compare(a.Namespace, b.Namespace)
+	case "issuer":
+		return strings.Compare(a.Issuer, b.Issuer)
+	default:
+		return strings.Compare(a.Name, b.Name)
+	}
+}
+
+// soonestExpiry returns the sort fields of the certificate that expires
+// first among certs, preferring the first issuer in order on ties so the
+// result does not depend on the order of certs
+func soonestExpiry(fields sortFields, certs ...*utils.CertificateInfo) sortFields {
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+		if fields.DaysUntilExpiry == nil || cert.DaysUntilExp < *fields.DaysUntilExpiry ||
+			(cert.DaysUntilExp == *fields.DaysUntilExpiry && cert.Issuer < fields.Issuer) {
+			days := cert.DaysUntilExp
+			fields.DaysUntilExpiry = &days
+			fields.Issuer = cert.Issuer
+		}
+	}
+	return fields
+}
+
+// sourcesExpiry returns the sort fields of the certificate that expires
+// first across certificate sources
+func sourcesExpiry(fields sortFields, sources map[string]*k8s.CertificateSource) sortFields {
+	for _, source := range sources {
+		if source != nil {
+			fields = soonestExpiry(fields, source.Certificates...)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// HandleSystemCertificates handles the /system-certificates endpoint,
+// reporting the certificate health of the curated kube-system components in
+// a single view
+func (h *Handler) HandleSystemCertificates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	warningDays := h.cfg().Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	report, err := k8s.AnalyzeSystemCertificates(ctx, client, warningDays)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to analyze system certificates: %v", err)
+		return
+	}
+
+	statuses := make(map[string]int)
+	verify := h.chainVerifier(r)
+	for _, component := range report.Components {
+		statuses[component.Status]++
+		for _, source := range component.CertSources {
+			verify(source)
+			h.summarizeBundles(r, warningDays, source)
+		}
+	}
+
+	response := SystemCertificatesResponse{
+		Status:      api.StatusSuccess,
+		Namespace:   report.Namespace,
+		WarningDays: warningDays,
+		Summary: SystemCertificatesSummary{
+			Components:    len(report.Components),
+			ByStatus:      statuses,
+			TotalWarnings: report.TotalWarnings,
+		},
+		Components: report.Components,
+		Notes: []string{
+			"One pod per component is analyzed; the cluster CA is reported by /cluster-ca-expiry",
+			"Webhooks are matched to components by the name of the kube-system service they call",
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// HandleServiceAccountTokens handles the /service-account-tokens endpoint,
+// reporting the projected and legacy service account tokens of the pods of
+// a namespace
+func (h *Handler) HandleServiceAccountTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = h.cfg().Kubernetes.DefaultNamespace
+	}
+	warningDays := h.cfg().Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	pods, err := k8s.AnalyzeServiceAccountTokens(ctx, client.GetClientset(), namespace, warningDays)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to analyze service account tokens: %v", err)
+		return
+	}
+
+	projected, legacy, longLived, neverExpires, expiring, expired, invalidated, warnings := 0, 0, 0, 0, 0, 0, 0, 0
+	for _, pod := range pods {
+		for _, token := range pod.Tokens {
+			warnings += len(token.Warnings)
+			switch token.Type {
+			case k8s.TokenProjected:
+				projected++
+				if token.ExpirationSeconds > k8s.MaxRotatedTokenSeconds {
+					longLived++
+				}
+			case k8s.TokenLegacy:
+				legacy++
+				switch {
+				case token.NeverExpires:
+					neverExpires++
+				case token.IsExpired:
+					expired++
+				case token.DaysUntilExpiry != nil && *token.DaysUntilExpiry <= warningDays:
+					expiring++
+				}
+				if token.InvalidSince != "" {
+					invalidated++
+				}
+			}
+		}
+	}
+
+	total := len(pods)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(pods) > limit
+	if truncated {
+		pods = pods[:limit]
+	}
+	if pods == nil {
+		pods = []k8s.PodServiceAccountTokens{}
+	}
+
+	response := ServiceAccountTokensResponse{
+		Status:      api.StatusSuccess,
+		Namespace:   namespace,
+		WarningDays: warningDays,
+		Summary: ServiceAccountTokensSummary{
+			PodsWithTokens:     total,
+			ProjectedTokens:    projected,
+			LongLivedProjected: longLived,
+			LegacyTokens:       legacy,
+			LegacyNeverExpire:  neverExpires,
+			LegacyExpiring:     expiring,
+			LegacyExpired:      expired,
+			LegacyInvalidated:  invalidated,
+			TotalWarnings:      warnings,
+		},
+		Pods: pods,
+		Notes: []string{
+			"Projected tokens are requested by the kubelet and refreshed at 80% of expiration_seconds or after 24 hours; their actual expiry is only visible inside the pod",
+			"Unless the API server runs with --service-account-extend-token-expiration=false, kube-api-access tokens are issued for a year and only the kubelet refresh bounds their use",
+			"Legacy tokens are read from kubernetes.io/service-account-token secrets and their claims decoded without verifying the signature; tokens without exp never expire",
+			"An empty audiences list on a projected token means the API server's own audiences",
+		},
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -1,46 +1,120 @@
 package handlers
 
-import "k8s-web-service/internal/k8s"
+import (
+	"time"
 
-// VolumeMount represents a volume mount in a pod
-type VolumeMount struct {
+	"k8s-web-service/internal/agent"
+	"k8s-web-service/internal/cloud"
+	"k8s-web-service/internal/images"
+)
+
+// DiscoveredCluster is an EKS cluster of the account and whether this
+// service is already set up to monitor it
+type DiscoveredCluster struct {
+	cloud.ClusterInfo
+	Registered        bool               `json:"registered"`
+	KubeconfigContext string             `json:"kubeconfig_context,omitempty"`
+	Onboarding        *ClusterOnboarding `json:"onboarding,omitempty"`
+}
+
+// ClusterOnboarding holds the steps to start monitoring a discovered cluster
+type ClusterOnboarding struct {
+	KubeconfigCommand string            `json:"kubeconfig_command"`
+	Config            map[string]string `json:"config"`
+}
+
+// ResourceRef identifies a Kubernetes resource
+type ResourceRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
 	Name      string `json:"name"`
-	MountPath string `json:"mount_path"`
-	ReadOnly  bool   `json:"read_only"`
-	Container string `json:"container"`
-}
-
-// Volume represents a volume in a pod
-type Volume struct {
-	Name   string `json:"name"`
-	Type   string `json:"type"`
-	Source string `json:"source,omitempty"`
-}
-
-// ClusterCAInfo represents cluster CA certificate information
-type ClusterCAInfo struct {
-	Description string `json:"description"`
-	Length      int    `json:"length"`
-	Source      string `json:"source"`
-}
-
-// PodCertificatesResponse represents the response for pod certificates with expiry info
-type PodCertificatesResponse struct {
-	Status          string        `json:"status"`
-	Message         string        `json:"message"`
-	TargetNamespace string        `json:"target_namespace"`
-	ClusterCAInfo   ClusterCAInfo `json:"cluster_ca_info"`
-	Pods            []PodCertInfo `json:"pods"`
-	ExpiryWarnings  []string      `json:"expiry_warnings,omitempty"`
-	Notes           []string      `json:"notes"`
-}
-
-// PodCertInfo represents certificate information for a pod with expiry details
-type PodCertInfo struct {
-	Name               string                            `json:"name"`
-	Namespace          string                            `json:"namespace"`
-	VolumeMounts       []VolumeMount                     `json:"volume_mounts"`
-	Volumes            []Volume                          `json:"volumes"`
-	CertificateSources map[string]*k8s.CertificateSource `json:"certificate_sources,omitempty"`
-	ExpiryWarnings     []string                          `json:"expiry_warnings,omitempty"`
+}
+
+// ACMCertificateEntry is an ACM certificate with the Kubernetes resources
+// that reference it
+type ACMCertificateEntry struct {
+	cloud.ACMCertificate
+	ReferencedBy []ResourceRef `json:"referenced_by"`
+	Warnings     []string      `json:"warnings,omitempty"`
+}
+
+// IAMServerCertificateEntry is an IAM server certificate with the Kubernetes
+// resources that reference it
+type IAMServerCertificateEntry struct {
+	cloud.IAMServerCertificate
+	ReferencedBy []ResourceRef `json:"referenced_by"`
+	Warnings     []string      `json:"warnings,omitempty"`
+}
+
+// LoadBalancerEntry is a load balancer with the Kubernetes resources it
+// fronts
+type LoadBalancerEntry struct {
+	cloud.LoadBalancerInfo
+	Frontends []ResourceRef `json:"frontends"`
+	Warnings  []string      `json:"warnings,omitempty"`
+}
+
+// ServedCertificate is a certificate served for a load balancer hostname,
+// from an ACM listener certificate or an Ingress TLS secret
+type ServedCertificate struct {
+	Source       string     `json:"source"` // acm or secret
+	Name         string     `json:"name"`   // ARN or namespace/secret
+	Names        []string   `json:"names"`
+	NotAfter     *time.Time `json:"not_after,omitempty"`
+	DaysUntilExp int        `json:"days_until_expiry"`
+	IsExpired    bool       `json:"is_expired"`
+}
+
+// DomainCoverage is the certificate coverage of a DNS record pointing at a
+// cluster load balancer
+type DomainCoverage struct {
+	cloud.DNSRecord
+	Frontends []ResourceRef       `json:"frontends"`
+	Status    string              `json:"status"`
+	CoveredBy []ServedCertificate `json:"covered_by"`
+}
+
+// CloudFrontEntry is a CloudFront distribution and its viewer certificate
+type CloudFrontEntry struct {
+	cloud.CloudFrontDistribution
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// SecretsManagerEntry is a certificate bundle stored in Secrets Manager
+type SecretsManagerEntry struct {
+	cloud.SecretsManagerCertificate
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// PrivateCAEntry is a private CA with the ACM certificates it issued
+type PrivateCAEntry struct {
+	cloud.PrivateCAInfo
+	IssuedCertificates []cloud.ACMCertificate `json:"issued_certificates"`
+	Warnings           []string               `json:"warnings,omitempty"`
+}
+
+// HostPathPodEntry is a pod with hostPath volumes and the certificate files
+// its node agent reported under them
+type HostPathPodEntry struct {
+	Namespace     string           `json:"namespace"`
+	Pod           string           `json:"pod"`
+	Node          string           `json:"node"`
+	AgentReported bool             `json:"agent_reported"`
+	Volumes       []HostPathVolume `json:"volumes"`
+}
+
+// HostPathVolume is a hostPath volume of a pod
+type HostPathVolume struct {
+	Volume     string                   `json:"volume"`
+	HostPath   string                   `json:"host_path"`
+	MountPaths []string                 `json:"mount_paths,omitempty"`
+	Files      []agent.FileCertificates `json:"files,omitempty"`
+	Warnings   []string                 `json:"warnings,omitempty"`
+}
+
+// ImageCABundleEntry is the system CA bundle of an image and the pods
+// running it
+type ImageCABundleEntry struct {
+	images.CABundle
+	Pods []string `json:"pods"`
 }
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+	"k8s-web-service/pkg/utils"
+)
+
+// HandleWebhookCABundles handles the /webhook-ca-bundles endpoint,
+// reporting the expiry of the caBundles of every admission webhook and
+// aggregated APIService
+func (h *Handler) HandleWebhookCABundles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	warningDays := h.cfg().Scanner.WarningDays
+	if days, err := strconv.Atoi(r.URL.Query().Get("warning_days")); err == nil && days > 0 {
+		warningDays = days
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	report, err := k8s.AnalyzeWebhookCABundles(ctx, client.GetClientset(), warningDays)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to analyze webhook caBundles: %v", err)
+		return
+	}
+
+	now := time.Now()
+	expired, expiring := 0, 0
+	count := func(certs []*utils.CertificateInfo) {
+		h.setCertificateDates(r, now, certs...)
+		for _, cert := range certs {
+			if cert.IsExpired {
+				expired++
+			} else if cert.DaysUntilExp <= warningDays {
+				expiring++
+			}
+		}
+	}
+	for _, webhook := range report.Webhooks {
+		count(webhook.Certificates)
+	}
+	for _, service := range report.APIServices {
+		count(service.Certificates)
+	}
+
+	response := WebhookCABundlesResponse{
+		Status:      api.StatusSuccess,
+		WarningDays: warningDays,
+		Summary: WebhookCABundlesSummary{
+			Webhooks:             len(report.Webhooks),
+			APIServices:          len(report.APIServices),
+			ExpiredCertificates:  expired,
+			ExpiringCertificates: expiring,
+			TotalWarnings:        report.TotalWarnings,
+		},
+		Webhooks:        report.Webhooks,
+		APIServices:     report.APIServices,
+		Warnings:        report.Warnings,
+		APIServiceError: report.APIServiceError,
+		Notes: []string{
+			"The API server verifies webhooks and aggregated APIs with these caBundles; once the CA expires every call fails",
+			"Webhooks with failurePolicy Fail then reject admission requests, and those with Ignore are silently skipped",
+			"APIServices served by the API server itself have no caBundle and are not listed",
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
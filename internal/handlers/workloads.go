@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/api"
+)
+
+// HandleWorkloadCertificates handles the /workload-certificates endpoint,
+// reporting certificates per Deployment, StatefulSet, DaemonSet, or CronJob
+// instead of per pod
+func (h *Handler) HandleWorkloadCertificates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get namespace from query parameter or use default
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = h.cfg().Kubernetes.DefaultNamespace
+	}
+
+	// Get warning days from query parameter (default from scanner.warning_days)
+	warningDaysStr := r.URL.Query().Get("warning_days")
+	warningDays := h.cfg().Scanner.WarningDays
+	if warningDaysStr != "" {
+		if days, err := strconv.Atoi(warningDaysStr); err == nil && days > 0 {
+			warningDays = days
+		}
+	}
+
+	// Create Kubernetes client
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	report, err := k8s.AnalyzeWorkloadExpiry(ctx, client, namespace, warningDays)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to analyze certificates: %v", err)
+		return
+	}
+
+	sortResults(r, report.Workloads, func(workload *k8s.WorkloadExpiryInfo) sortFields {
+		return sourcesExpiry(sortFields{Name: workload.Name, Namespace: namespace}, workload.CertSources)
+	})
+
+	limit := maxResults(r)
+	truncated := limit > 0 && len(report.Workloads) > limit
+	if truncated {
+		report.Workloads = report.Workloads[:limit]
+	}
+	now := time.Now()
+	verify := h.chainVerifier(r)
+	for _, workload := range report.Workloads {
+		for _, source := range workload.CertSources {
+			h.setSourceDates(r, now, source)
+			verify(source)
+			h.summarizeBundles(r, warningDays, source)
+		}
+	}
+
+	response := WorkloadCertificatesResponse{
+		Status:      api.StatusSuccess,
+		Message:     fmt.Sprintf("Certificate expiry analysis for %d workloads in namespace '%s'", len(report.Workloads), namespace),
+		Namespace:   namespace,
+		WarningDays: warningDays,
+		Summary: WorkloadCertificatesSummary{
+			TotalPodsAnalyzed: report.TotalPods,
+			Workloads:         len(report.Workloads),
+			TotalCertificates: report.TotalCertificates,
+			TotalWarnings:     report.TotalWarnings,
+		},
+		Workloads: report.Workloads,
+		Notes: []string{
+			"Pods are grouped by owning Deployment, StatefulSet, DaemonSet, or CronJob via owner references",
+			"One pod per template revision is analyzed; workloads mid-rollout include the sources of every revision",
+			"Pods without a controller are reported with kind Pod",
+		},
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleStaleCertificates handles the /stale-certificates endpoint, flagging
+// pods that started before a certificate they use was rotated
+func (h *Handler) HandleStaleCertificates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = h.cfg().Kubernetes.DefaultNamespace
+	}
+
+	client, err := h.client(r)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemClusterUnreachable, "Failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	findings, err := k8s.DetectStaleMounts(ctx, client.GetClientset(), namespace)
+	if err != nil {
+		writeErrorProblem(w, r, http.StatusInternalServerError, err, ProblemKubernetesError, "Failed to detect stale certificates: %v", err)
+		return
+	}
+
+	byConfidence := make(map[string]int)
+	actions := make(map[string]bool)
+	var suggested []string
+	for _, finding := range findings {
+		byConfidence[finding.Confidence]++
+		if finding.Confidence != k8s.StalePropagating && !actions[finding.SuggestedAction] {
+			actions[finding.SuggestedAction] = true
+			suggested = append(suggested, finding.SuggestedAction)
+		}
+	}
+
+	total := len(findings)
+	limit := maxResults(r)
+	truncated := limit > 0 && len(findings) > limit
+	if truncated {
+		findings = findings[:limit]
+	}
+	if findings == nil {
+		findings = []k8s.StaleMountFinding{}
+	}
+
+	response := StaleCertificatesResponse{
+		Status:    api.StatusSuccess,
+		Namespace: namespace,
+		Summary: StaleCertificatesSummary{
+			TotalFindings: total,
+			ByConfidence:  byConfidence,
+		},
+		Findings:         findings,
+		SuggestedActions: suggested,
+		Notes: []string{
+			"stale: the secret is mounted with subPath or used as an environment variable, which the kubelet never updates",
+			"possibly_stale: the kubelet updated the mounted file, but the process only sees it if it reloads certificates",
+			"propagating: the secret was rotated within the kubelet sync window and the volume may not be updated yet",
+		},
+	}
+	if truncated {
+		response.Truncated = true
+		response.MaxResults = limit
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,279 @@
+// Package images inspects the CA bundles baked into container images. Base
+// images ship a snapshot of the system trust store, which is only refreshed
+// when the image is rebuilt, so long-lived images keep expired roots and
+// miss new ones.
+package images
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// bundlePaths are the system CA bundle locations of common base images, in
+// the order they are tried: Debian, Ubuntu, and Alpine, then RHEL-based
+// images
+var bundlePaths = []string{
+	"etc/ssl/certs/ca-certificates.crt",
+	"etc/pki/tls/certs/ca-bundle.crt",
+}
+
+// maxSymlinks bounds the symlinks followed to reach a bundle
+const maxSymlinks = 5
+
+// errNotFound reports that an image has no file at a path
+var errNotFound = errors.New("file not found in image")
+
+// CABundle is the system CA bundle of an image
+type CABundle struct {
+	Image         string                   `json:"image"`
+	Digest        string                   `json:"digest,omitempty"`
+	Path          string                   `json:"path,omitempty"`
+	TotalRoots    int                      `json:"total_roots"`
+	ExpiredRoots  []*utils.CertificateInfo `json:"expired_roots,omitempty"`
+	ExpiringRoots []*utils.CertificateInfo `json:"expiring_roots,omitempty"`
+	Authenticated bool                     `json:"authenticated"`
+	Error         string                   `json:"error,omitempty"`
+}
+
+// Analyzer pulls images and inspects their CA bundles. Results are cached by
+// image digest, since the content of a digest never changes.
+type Analyzer struct {
+	mu    sync.Mutex
+	cache map[string][]*utils.CertificateInfo // digest -> roots
+	paths map[string]string                   // digest -> bundle path
+}
+
+// NewAnalyzer creates an analyzer with an empty cache
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{cache: make(map[string][]*utils.CertificateInfo), paths: make(map[string]string)}
+}
+
+// Len returns the number of image digests in the cache
+func (a *Analyzer) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.cache)
+}
+
+// Analyze pulls the manifest of an image, reads its CA bundle from the
+// layers, newest first, and reports roots that are expired or expire within
+// warningDays. pullSecrets are the image pull secrets of the pod running
+// the image; the image is pulled anonymously if none matches its registry.
+func (a *Analyzer) Analyze(ctx context.Context, image string, pullSecrets []*corev1.Secret, warningDays int) CABundle {
+	result := CABundle{Image: image}
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid image reference: %v", err)
+		return result
+	}
+
+	auth := authn.Anonymous
+	if credentials, ok := registryCredentials(ref.Context().RegistryStr(), pullSecrets); ok {
+		auth = authn.FromConfig(credentials)
+		result.Authenticated = true
+	}
+
+	img, err := remote.Image(ref, remote.WithAuth(auth), remote.WithContext(ctx))
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to get image manifest: %v", err)
+		return result
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to get image digest: %v", err)
+		return result
+	}
+	result.Digest = digest.String()
+
+	roots, bundlePath, err := a.roots(img, result.Digest)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Path = "/" + bundlePath
+	result.TotalRoots = len(roots)
+	for _, root := range roots {
+		if root.IsExpired {
+			result.ExpiredRoots = append(result.ExpiredRoots, root)
+		} else if root.DaysUntilExp <= warningDays {
+			result.ExpiringRoots = append(result.ExpiringRoots, root)
+		}
+	}
+	return result
+}
+
+// roots returns the certificates of the CA bundle of an image, from the
+// cache if the digest was analyzed before
+func (a *Analyzer) roots(img v1.Image, digest string) ([]*utils.CertificateInfo, string, error) {
+	a.mu.Lock()
+	roots, ok := a.cache[digest]
+	bundlePath := a.paths[digest]
+	a.mu.Unlock()
+	if ok {
+		return roots, bundlePath, nil
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list image layers: %w", err)
+	}
+
+	var data []byte
+	for _, candidate := range bundlePaths {
+		data, bundlePath, err = readFile(layers, candidate)
+		if !errors.Is(err, errNotFound) {
+			break
+		}
+	}
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return nil, "", fmt.Errorf("no system CA bundle found at /%s", strings.Join(bundlePaths, " or /"))
+		}
+		return nil, "", err
+	}
+
+	roots, err = utils.ParseCertificateBundle(string(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse CA bundle /%s: %w", bundlePath, err)
+	}
+
+	a.mu.Lock()
+	a.cache[digest] = roots
+	a.paths[digest] = bundlePath
+	a.mu.Unlock()
+	return roots, bundlePath, nil
+}
+
+// readFile reads a file from image layers, newest layer first, following
+// symlinks. It returns the content and the path of the regular file read.
+func readFile(layers []v1.Layer, filePath string) ([]byte, string, error) {
+	for hops := 0; hops <= maxSymlinks; hops++ {
+		header, data, err := findFile(layers, filePath)
+		if err != nil {
+			return nil, "", err
+		}
+		if header.Typeflag != tar.TypeSymlink {
+			return data, filePath, nil
+		}
+		target := header.Linkname
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(filePath), target)
+		}
+		filePath = strings.TrimPrefix(path.Clean(target), "/")
+	}
+	return nil, "", fmt.Errorf("too many symlinks resolving /%s", filePath)
+}
+
+// findFile returns the tar entry of filePath in the newest layer that
+// contains it, honouring whiteouts that delete it in a newer layer
+func findFile(layers []v1.Layer, filePath string) (*tar.Header, []byte, error) {
+	dir, base := path.Split(filePath)
+	whiteout := dir + ".wh." + base
+	for i := len(layers) - 1; i >= 0; i-- {
+		header, data, deleted, err := scanLayer(layers[i], filePath, whiteout, dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		if deleted {
+			return nil, nil, errNotFound
+		}
+		if header != nil {
+			return header, data, nil
+		}
+	}
+	return nil, nil, errNotFound
+}
+
+// scanLayer looks for filePath in a layer, reporting whether the layer
+// deletes it through a whiteout file or an opaque directory
+func scanLayer(layer v1.Layer, filePath, whiteout, dir string) (*tar.Header, []byte, bool, error) {
+	reader, err := layer.Uncompressed()
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to read image layer: %w", err)
+	}
+	defer reader.Close()
+
+	deleted := false
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil, nil, deleted, nil
+		}
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("failed to read image layer: %w", err)
+		}
+		entry := strings.TrimPrefix(path.Clean(header.Name), "/")
+		switch entry {
+		case filePath:
+			if header.Typeflag == tar.TypeSymlink {
+				return header, nil, false, nil
+			}
+			data, err := io.ReadAll(archive)
+			if err != nil {
+				return nil, nil, false, fmt.Errorf("failed to read /%s: %w", filePath, err)
+			}
+			return header, data, false, nil
+		case whiteout, dir + ".wh..wh..opq":
+			deleted = true
+		}
+	}
+}
+
+// dockerConfig is the content of a kubernetes.io/dockerconfigjson secret
+type dockerConfig struct {
+	Auths map[string]authn.AuthConfig `json:"auths"`
+}
+
+// registryCredentials returns the credentials for a registry from image pull
+// secrets of type kubernetes.io/dockerconfigjson or kubernetes.io/dockercfg
+func registryCredentials(registry string, pullSecrets []*corev1.Secret) (authn.AuthConfig, bool) {
+	for _, secret := range pullSecrets {
+		var auths map[string]authn.AuthConfig
+		switch secret.Type {
+		case corev1.SecretTypeDockerConfigJson:
+			var dockerCfg dockerConfig
+			if json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &dockerCfg) != nil {
+				continue
+			}
+			auths = dockerCfg.Auths
+		case corev1.SecretTypeDockercfg:
+			if json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths) != nil {
+				continue
+			}
+		}
+		for server, credentials := range auths {
+			if registryHost(server) == registry {
+				return credentials, true
+			}
+		}
+	}
+	return authn.AuthConfig{}, false
+}
+
+// registryHost normalizes a docker config server key, which may be a URL
+// such as https://index.docker.io/v1/, to the registry host used in image
+// references
+func registryHost(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	server = strings.SplitN(server, "/", 2)[0]
+	if server == "docker.io" || server == "registry-1.docker.io" {
+		return name.DefaultRegistry
+	}
+	return server
+}
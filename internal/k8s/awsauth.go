@@ -0,0 +1,174 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Location of the aws-auth ConfigMap
+const (
+	AWSAuthNamespace = "kube-system"
+	AWSAuthName      = "aws-auth"
+)
+
+// nodeUsername is the username EKS expects in node role mappings
+const nodeUsername = "system:node:{{EC2PrivateDNSName}}"
+
+// Severities of aws-auth mapping issues
+const (
+	MappingBroken  = "broken"
+	MappingWarning = "warning"
+	MappingInfo    = "info"
+)
+
+var (
+	iamRoleARN     = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/.+$`)
+	iamRolePathARN = regexp.MustCompile(`:role/.+/`)
+	iamUserARN     = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:user/.+$`)
+)
+
+// MappingIssue is a problem with an aws-auth or access entry mapping
+type MappingIssue struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// AWSAuthMapping is a role or user mapping of the aws-auth ConfigMap
+type AWSAuthMapping struct {
+	Kind     string         `json:"kind"` // role or user
+	ARN      string         `json:"arn"`
+	Username string         `json:"username"`
+	Groups   []string       `json:"groups"`
+	Issues   []MappingIssue `json:"issues,omitempty"`
+}
+
+// AWSAuthReport is the parsed aws-auth ConfigMap
+type AWSAuthReport struct {
+	Found    bool             `json:"found"`
+	Mappings []AWSAuthMapping `json:"mappings"`
+	Accounts []string         `json:"accounts,omitempty"`
+	Issues   []MappingIssue   `json:"issues,omitempty"`
+}
+
+// awsAuthEntry is an entry of mapRoles or mapUsers
+type awsAuthEntry struct {
+	RoleARN  string   `yaml:"rolearn"`
+	UserARN  string   `yaml:"userarn"`
+	Username string   `yaml:"username"`
+	Groups   []string `yaml:"groups"`
+}
+
+// AnalyzeAWSAuth parses the mapRoles, mapUsers, and mapAccounts of the
+// aws-auth ConfigMap and flags mappings that cannot work, such as role ARNs
+// with a path or of an assumed-role session, missing usernames, and
+// duplicates. A missing ConfigMap is reported with Found false.
+func AnalyzeAWSAuth(ctx context.Context, clientset kubernetes.Interface) (*AWSAuthReport, error) {
+	report := &AWSAuthReport{Mappings: []AWSAuthMapping{}}
+
+	configMap, err := clientset.CoreV1().ConfigMaps(AWSAuthNamespace).Get(ctx, AWSAuthName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configmap %s/%s: %w", AWSAuthNamespace, AWSAuthName, err)
+	}
+	report.Found = true
+
+	for _, section := range []struct{ key, kind string }{{"mapRoles", "role"}, {"mapUsers", "user"}} {
+		data := configMap.Data[section.key]
+		if strings.TrimSpace(data) == "" {
+			continue
+		}
+		var entries []awsAuthEntry
+		if err := yaml.Unmarshal([]byte(data), &entries); err != nil {
+			report.Issues = append(report.Issues, MappingIssue{
+				Severity: MappingBroken,
+				Message:  fmt.Sprintf("%s is not valid YAML, so none of its mappings apply: %v", section.key, err),
+			})
+			continue
+		}
+		for _, entry := range entries {
+			mapping := AWSAuthMapping{Kind: section.kind, ARN: entry.RoleARN, Username: entry.Username, Groups: entry.Groups}
+			if section.kind == "user" {
+				mapping.ARN = entry.UserARN
+			}
+			mapping.Issues = mappingIssues(mapping)
+			report.Mappings = append(report.Mappings, mapping)
+		}
+	}
+
+	if accounts := configMap.Data["mapAccounts"]; strings.TrimSpace(accounts) != "" {
+		if err := yaml.Unmarshal([]byte(accounts), &report.Accounts); err != nil {
+			report.Issues = append(report.Issues, MappingIssue{
+				Severity: MappingBroken,
+				Message:  fmt.Sprintf("mapAccounts is not valid YAML: %v", err),
+			})
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := range report.Mappings {
+		mapping := &report.Mappings[i]
+		if mapping.ARN == "" {
+			continue
+		}
+		if seen[mapping.ARN] {
+			mapping.Issues = append(mapping.Issues, MappingIssue{
+				Severity: MappingWarning,
+				Message:  "ARN is mapped more than once; only one mapping takes effect",
+			})
+		}
+		seen[mapping.ARN] = true
+	}
+
+	return report, nil
+}
+
+// mappingIssues flags problems with a single aws-auth mapping
+func mappingIssues(mapping AWSAuthMapping) []MappingIssue {
+	var issues []MappingIssue
+	add := func(severity, format string, args ...interface{}) {
+		issues = append(issues, MappingIssue{Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case mapping.ARN == "":
+		add(MappingBroken, "%sarn is missing", mapping.Kind)
+	case strings.Contains(mapping.ARN, ":assumed-role/"):
+		add(MappingBroken, "assumed-role session ARNs never match; map the IAM role ARN instead")
+	case mapping.Kind == "role" && !iamRoleARN.MatchString(mapping.ARN):
+		add(MappingBroken, "not a valid IAM role ARN")
+	case mapping.Kind == "user" && !iamUserARN.MatchString(mapping.ARN):
+		add(MappingBroken, "not a valid IAM user ARN")
+	case mapping.Kind == "role" && iamRolePathARN.MatchString(mapping.ARN):
+		add(MappingBroken, "role ARNs with a path never match; remove the path from the ARN")
+	}
+
+	if strings.TrimSpace(mapping.Username) == "" {
+		add(MappingBroken, "username is missing")
+	}
+
+	isNode := false
+	for _, group := range mapping.Groups {
+		switch group {
+		case "system:nodes":
+			isNode = true
+		case "system:masters":
+			add(MappingInfo, "grants cluster-admin through system:masters")
+		}
+	}
+	if isNode && mapping.Username != nodeUsername {
+		add(MappingWarning, "node role mapping should use username %s", nodeUsername)
+	}
+	if len(mapping.Groups) == 0 {
+		add(MappingWarning, "no groups; the identity authenticates but is only authorized by RBAC bindings to its username")
+	}
+	return issues
+}
@@ -0,0 +1,260 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// Cluster CA rotation states
+const (
+	CARotationNone       = "none"
+	CARotationInProgress = "in_progress" // the sources disagree or carry more than one CA
+	CARotationRecent     = "recent"      // one CA, issued within recentRotationWindow and after the cluster was created
+)
+
+// Whether a workload uses the newest cluster CA
+const (
+	CAPickedUp      = "picked_up"      // every pod started after the new CA reached its namespace
+	CANotPickedUp   = "not_picked_up"  // a pod started before the new CA reached its namespace
+	CABundlePending = "bundle_pending" // the namespace's kube-root-ca.crt lacks the new CA
+)
+
+// Sources of the cluster CA
+const (
+	CASourceKubeconfig = "kubeconfig"
+	CASourceEKS        = "eks"
+	CASourceRootCA     = "kube-root-ca.crt"
+)
+
+// recentRotationWindow is how long after a CA was issued a rotation to it
+// is reported as recent
+const recentRotationWindow = 30 * 24 * time.Hour
+
+// CASource is the cluster CA bundle found at one source
+type CASource struct {
+	Source       string     `json:"source"`
+	Namespace    string     `json:"namespace,omitempty"`
+	Fingerprints []string   `json:"fingerprints_sha256"`
+	HasNewestCA  bool       `json:"has_newest_ca"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// WorkloadCAStatus is whether the pods of a workload use the newest cluster
+// CA. Pods load the service account ca.crt when they start, so a pod that
+// started before the CA reached its namespace keeps trusting the old one.
+type WorkloadCAStatus struct {
+	Namespace string `json:"namespace"`
+	WorkloadRef
+	Status          string   `json:"status"`
+	Pods            int      `json:"pods"`
+	PodsNotPickedUp []string `json:"pods_not_picked_up,omitempty"`
+}
+
+// CARotationStatus summarizes an in-progress or recent cluster CA rotation
+type CARotationStatus struct {
+	Status    string                   `json:"status"`
+	NewestCA  *utils.CertificateInfo   `json:"newest_ca,omitempty"`
+	CAs       []*utils.CertificateInfo `json:"cas"`
+	Sources   []CASource               `json:"sources"`
+	Workloads []WorkloadCAStatus       `json:"workloads"`
+	Summary   map[string]int           `json:"summary"`
+	Issues    []string                 `json:"issues,omitempty"`
+}
+
+// AnalyzeCARotation compares the cluster CA of the kubeconfig, of EKS when
+// eksCA is set, and of the kube-root-ca.crt configmap of each namespace to
+// detect a CA rotation, and reports which workloads of the namespaces have
+// picked up the newest CA. clusterCreated is zero when unknown.
+func AnalyzeCARotation(ctx context.Context, clientset kubernetes.Interface, kubeconfigCA, eksCA string, clusterCreated time.Time, namespaces []string) (*CARotationStatus, error) {
+	status := &CARotationStatus{Status: CARotationNone, Workloads: []WorkloadCAStatus{}, Summary: map[string]int{}}
+	cas := make(map[string]*utils.CertificateInfo)
+	addBundle := func(source CASource, bundle string) CASource {
+		certs, err := utils.ParseCertificateBundle(bundle)
+		if err != nil {
+			source.Error = err.Error()
+			return source
+		}
+		for _, cert := range certs {
+			source.Fingerprints = append(source.Fingerprints, cert.Fingerprint)
+			cas[cert.Fingerprint] = cert
+		}
+		return source
+	}
+
+	status.Sources = append(status.Sources, addBundle(CASource{Source: CASourceKubeconfig}, kubeconfigCA))
+	if eksCA != "" {
+		status.Sources = append(status.Sources, addBundle(CASource{Source: CASourceEKS}, eksCA))
+	}
+	rootCAUpdated := make(map[string]time.Time)
+	rewritten := false // a kube-root-ca.crt configmap changed well after it was created
+	for _, namespace := range namespaces {
+		source := CASource{Source: CASourceRootCA, Namespace: namespace}
+		configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, rootCAConfigMap, metav1.GetOptions{})
+		if err != nil {
+			source.Error = fmt.Sprintf("failed to get configmap: %v", err)
+			status.Sources = append(status.Sources, source)
+			continue
+		}
+		updated := configMapUpdated(configMap)
+		rootCAUpdated[namespace] = updated
+		source.UpdatedAt = &updated
+		rewritten = rewritten || updated.Sub(configMap.CreationTimestamp.Time) > 24*time.Hour
+		status.Sources = append(status.Sources, addBundle(source, configMap.Data["ca.crt"]))
+	}
+	if len(cas) == 0 {
+		return nil, fmt.Errorf("no cluster CA certificate could be read from any source")
+	}
+
+	for _, cert := range cas {
+		status.CAs = append(status.CAs, cert)
+		if status.NewestCA == nil || cert.NotBefore.After(status.NewestCA.NotBefore) {
+			status.NewestCA = cert
+		}
+	}
+	sort.Slice(status.CAs, func(i, j int) bool { return status.CAs[i].NotBefore.Before(status.CAs[j].NotBefore) })
+
+	for i := range status.Sources {
+		source := &status.Sources[i]
+		for _, fingerprint := range source.Fingerprints {
+			source.HasNewestCA = source.HasNewestCA || fingerprint == status.NewestCA.Fingerprint
+		}
+		switch {
+		case source.Error != "":
+		case !source.HasNewestCA:
+			status.Issues = append(status.Issues, fmt.Sprintf("%s lacks the newest CA %s", sourceName(*source), status.NewestCA.Subject))
+		case len(source.Fingerprints) > 1:
+			status.Issues = append(status.Issues, fmt.Sprintf("%s holds %d CAs; the old CA is still trusted", sourceName(*source), len(source.Fingerprints)))
+		}
+	}
+
+	newest := status.NewestCA.NotBefore
+	switch {
+	case len(status.CAs) > 1:
+		status.Status = CARotationInProgress
+	case time.Since(newest) >= recentRotationWindow:
+	case !clusterCreated.IsZero() && newest.After(clusterCreated.Add(24*time.Hour)):
+		status.Status = CARotationRecent
+	case clusterCreated.IsZero() && rewritten:
+		status.Status = CARotationRecent
+	}
+	if status.Status == CARotationNone {
+		return status, nil
+	}
+
+	for _, namespace := range namespaces {
+		updated, ok := rootCAUpdated[namespace]
+		if !ok {
+			continue
+		}
+		hasNewest := false
+		for _, source := range status.Sources {
+			if source.Source == CASourceRootCA && source.Namespace == namespace {
+				hasNewest = source.HasNewestCA
+			}
+		}
+		workloads, err := workloadCAStatus(ctx, clientset, namespace, hasNewest, updated)
+		if err != nil {
+			status.Issues = append(status.Issues, err.Error())
+			continue
+		}
+		for _, workload := range workloads {
+			status.Summary[workload.Status]++
+		}
+		status.Workloads = append(status.Workloads, workloads...)
+	}
+	return status, nil
+}
+
+// workloadCAStatus reports whether the workloads of a namespace whose pods
+// mount the service account CA started after it was last updated
+func workloadCAStatus(ctx context.Context, clientset kubernetes.Interface, namespace string, hasNewest bool, updated time.Time) ([]WorkloadCAStatus, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %s: %w", namespace, err)
+	}
+
+	resolver := newWorkloadResolver(clientset, namespace)
+	byWorkload := make(map[WorkloadRef]*WorkloadCAStatus)
+	var order []WorkloadRef
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.StartTime == nil || pod.Status.Phase != corev1.PodRunning || !mountsRootCA(pod) {
+			continue
+		}
+		ref := resolver.resolve(ctx, pod)
+		workload, ok := byWorkload[ref]
+		if !ok {
+			workload = &WorkloadCAStatus{Namespace: namespace, WorkloadRef: ref, Status: CAPickedUp}
+			byWorkload[ref] = workload
+			order = append(order, ref)
+		}
+		workload.Pods++
+		switch {
+		case !hasNewest:
+			workload.Status = CABundlePending
+		case pod.Status.StartTime.Time.Before(updated):
+			workload.Status = CANotPickedUp
+			workload.PodsNotPickedUp = append(workload.PodsNotPickedUp, pod.Name)
+		}
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].Kind != order[j].Kind {
+			return order[i].Kind < order[j].Kind
+		}
+		return order[i].Name < order[j].Name
+	})
+	workloads := make([]WorkloadCAStatus, 0, len(order))
+	for _, ref := range order {
+		workloads = append(workloads, *byWorkload[ref])
+	}
+	return workloads, nil
+}
+
+// mountsRootCA reports whether a pod mounts the kube-root-ca.crt configmap,
+// as every pod with an automounted service account token does
+func mountsRootCA(pod *corev1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.ConfigMap != nil && volume.ConfigMap.Name == rootCAConfigMap {
+			return true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil && source.ConfigMap.Name == rootCAConfigMap {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// configMapUpdated returns when a configmap was last written, from its
+// managed fields, or its creation time
+func configMapUpdated(configMap *corev1.ConfigMap) time.Time {
+	updated := configMap.CreationTimestamp.Time
+	for _, entry := range configMap.ManagedFields {
+		if entry.Time != nil && entry.Time.After(updated) {
+			updated = entry.Time.Time
+		}
+	}
+	return updated
+}
+
+// sourceName describes a CA source in issues
+func sourceName(source CASource) string {
+	if source.Namespace != "" {
+		return strings.Join([]string{source.Source, "in namespace", source.Namespace}, " ")
+	}
+	return source.Source
+}
@@ -3,8 +3,10 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
@@ -18,11 +20,73 @@ type CertificateSource struct {
 	Namespace    string                   `json:"namespace"`     // resource namespace
 	Key          string                   `json:"key,omitempty"` // key within the resource
 	Certificates []*utils.CertificateInfo `json:"certificates"`
+	// BundleSummary is set when the source holds more certificates than
+	// the bundle cap and Certificates lists only the expired and expiring
+	// ones
+	BundleSummary *utils.BundleSummary `json:"bundle_summary,omitempty"`
+	// ParseErrors locate the blocks of the source's keys that did not yield
+	// a certificate
+	ParseErrors []*utils.PEMError `json:"parse_errors,omitempty"`
+	// PrivateKey describes tls.key of a secret that also holds tls.crt
+	PrivateKey *utils.PrivateKeyInfo `json:"private_key,omitempty"`
+	// Verification is the chain of the source's leaf certificate, set by
+	// VerifyChain
+	Verification *utils.ChainVerification `json:"verification,omitempty"`
 	Error        string                   `json:"error,omitempty"`
+
+	// pem holds the PEM data of the parsed keys for VerifyChain
+	pem string
+}
+
+// addKey parses the certificates stored under a key of the source, noting
+// the key in their subjects and in the errors of blocks that do not parse
+func (s *CertificateSource) addKey(key, data string) {
+	certs, errs := utils.ParseCertificateBundleDiagnostics(data)
+	for _, cert := range certs {
+		cert.Subject = fmt.Sprintf("%s (from %s)", cert.Subject, key)
+		s.Certificates = append(s.Certificates, cert)
+	}
+	for _, err := range errs {
+		err.Key = key
+		s.ParseErrors = append(s.ParseErrors, err)
+	}
+	if len(certs) > 0 {
+		s.pem += data + "\n"
+	}
+}
+
+// VerifyChain builds the chain of the source's leaf certificate through the
+// other certificates of the source and verifies it against clusterCA and
+// the system roots. Sources holding only CA certificates are trust bundles
+// and are left without a verification.
+func (s *CertificateSource) VerifyChain(clusterCA string) {
+	if s != nil && s.pem != "" {
+		s.Verification = utils.ChainVerify(s.pem, clusterCA)
+	}
+}
+
+// KeyWarning returns a warning when the private key of the source does not
+// match its certificate or cannot be read, or "" otherwise
+func (s *CertificateSource) KeyWarning() string {
+	switch {
+	case s.PrivateKey == nil:
+		return ""
+	case s.PrivateKey.Error != "":
+		return fmt.Sprintf("tls.key cannot be checked against tls.crt: %s", s.PrivateKey.Error)
+	case !s.PrivateKey.MatchesCertificate:
+		return "tls.key does not match the certificate in tls.crt; the certificate was probably rotated without its key"
+	}
+	return ""
+}
+
+// Summarize replaces the certificates of a source holding more than max
+// with its expired and expiring ones and a summary of all of them
+func (s *CertificateSource) Summarize(warningDays, max int) {
+	s.Certificates, s.BundleSummary = utils.SummarizeCertificates(s.Certificates, warningDays, max)
 }
 
 // ExtractCertificatesFromSecret extracts certificates from a Kubernetes secret
-func ExtractCertificatesFromSecret(ctx context.Context, clientset *kubernetes.Clientset, namespace, secretName string) (*CertificateSource, error) {
+func ExtractCertificatesFromSecret(ctx context.Context, clientset kubernetes.Interface, namespace, secretName string) (*CertificateSource, error) {
 	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
 	if err != nil {
 		return &CertificateSource{
@@ -33,48 +97,56 @@ func ExtractCertificatesFromSecret(ctx context.Context, clientset *kubernetes.Cl
 		}, err
 	}
 
-	source := &CertificateSource{
-		Type:      "secret",
-		Name:      secretName,
-		Namespace: namespace,
-	}
+	return certificatesFromSecret(secret), nil
+}
 
-	// Common certificate keys to check
-	certKeys := []string{
-		"tls.crt", "tls.cert", "cert.pem", "certificate.pem", "ca.crt", "ca.pem",
-		"client.crt", "server.crt", "cert", "certificate", "ca-bundle.crt",
-		"ca-bundle.pem", "root-ca.pem", "intermediate-ca.pem",
+// ScanSecretCertificates parses the certificates of every secret in a
+// namespace, returning the secrets that hold any
+func ScanSecretCertificates(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]*CertificateSource, error) {
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets in namespace %s: %w", namespace, err)
 	}
+	var sources []*CertificateSource
+	for i := range secrets.Items {
+		if source := certificatesFromSecret(&secrets.Items[i]); len(source.Certificates) > 0 {
+			sources = append(sources, source)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	return sources, nil
+}
 
-	var allCerts []*utils.CertificateInfo
-
-	for _, key := range certKeys {
-		if certData, exists := secret.Data[key]; exists {
-			certString := string(certData)
+// secretCertKeys are the common secret keys holding certificates
+var secretCertKeys = []string{
+	"tls.crt", "tls.cert", "cert.pem", "certificate.pem", "ca.crt", "ca.pem",
+	"client.crt", "server.crt", "cert", "certificate", "ca-bundle.crt",
+	"ca-bundle.pem", "root-ca.pem", "intermediate-ca.pem",
+}
 
-			// Try to parse as a single certificate first
-			if cert, err := utils.ParseCertificate(certString); err == nil {
-				cert.Subject = fmt.Sprintf("%s (from %s)", cert.Subject, key)
-				allCerts = append(allCerts, cert)
-				continue
-			}
+// certificatesFromSecret parses the certificates stored under well-known keys of a secret
+func certificatesFromSecret(secret *corev1.Secret) *CertificateSource {
+	source := &CertificateSource{
+		Type:      "secret",
+		Name:      secret.Name,
+		Namespace: secret.Namespace,
+	}
 
-			// Try to parse as a certificate bundle
-			if certs, err := utils.ParseCertificateBundle(certString); err == nil {
-				for _, cert := range certs {
-					cert.Subject = fmt.Sprintf("%s (from %s)", cert.Subject, key)
-					allCerts = append(allCerts, cert)
-				}
-			}
+	for _, key := range secretCertKeys {
+		if certData, exists := secret.Data[key]; exists {
+			source.addKey(key, string(certData))
 		}
 	}
+	certData, hasCert := secret.Data[corev1.TLSCertKey]
+	if keyData, hasKey := secret.Data[corev1.TLSPrivateKeyKey]; hasCert && hasKey {
+		source.PrivateKey = utils.CheckPrivateKey(string(keyData), string(certData))
+	}
 
-	source.Certificates = allCerts
-	return source, nil
+	return source
 }
 
 // ExtractCertificatesFromConfigMap extracts certificates from a Kubernetes configmap
-func ExtractCertificatesFromConfigMap(ctx context.Context, clientset *kubernetes.Clientset, namespace, configMapName string) (*CertificateSource, error) {
+func ExtractCertificatesFromConfigMap(ctx context.Context, clientset kubernetes.Interface, namespace, configMapName string) (*CertificateSource, error) {
 	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, configMapName, metav1.GetOptions{})
 	if err != nil {
 		return &CertificateSource{
@@ -85,10 +157,15 @@ func ExtractCertificatesFromConfigMap(ctx context.Context, clientset *kubernetes
 		}, err
 	}
 
+	return certificatesFromConfigMap(configMap), nil
+}
+
+// certificatesFromConfigMap parses the certificates stored under well-known keys of a configmap
+func certificatesFromConfigMap(configMap *corev1.ConfigMap) *CertificateSource {
 	source := &CertificateSource{
 		Type:      "configmap",
-		Name:      configMapName,
-		Namespace: namespace,
+		Name:      configMap.Name,
+		Namespace: configMap.Namespace,
 	}
 
 	// Common certificate keys to check
@@ -98,8 +175,6 @@ func ExtractCertificatesFromConfigMap(ctx context.Context, clientset *kubernetes
 		"client.crt", "server.crt", "cert", "certificate",
 	}
 
-	var allCerts []*utils.CertificateInfo
-
 	// Check both Data and BinaryData
 	for _, key := range certKeys {
 		var certString string
@@ -113,24 +188,10 @@ func ExtractCertificatesFromConfigMap(ctx context.Context, clientset *kubernetes
 			continue
 		}
 
-		// Try to parse as a single certificate first
-		if cert, err := utils.ParseCertificate(certString); err == nil {
-			cert.Subject = fmt.Sprintf("%s (from %s)", cert.Subject, key)
-			allCerts = append(allCerts, cert)
-			continue
-		}
-
-		// Try to parse as a certificate bundle
-		if certs, err := utils.ParseCertificateBundle(certString); err == nil {
-			for _, cert := range certs {
-				cert.Subject = fmt.Sprintf("%s (from %s)", cert.Subject, key)
-				allCerts = append(allCerts, cert)
-			}
-		}
+		source.addKey(key, certString)
 	}
 
-	source.Certificates = allCerts
-	return source, nil
+	return source
 }
 
 // GetClusterCACertificateInfo parses the cluster CA certificate and returns its info
@@ -145,24 +206,20 @@ func GetClusterCACertificateInfo(clusterCA string) (*CertificateSource, error) {
 		return source, fmt.Errorf("no cluster CA certificate")
 	}
 
-	// Try to parse as a single certificate first
-	if cert, err := utils.ParseCertificate(clusterCA); err == nil {
-		cert.Subject = fmt.Sprintf("%s (Kubernetes Cluster CA)", cert.Subject)
-		source.Certificates = []*utils.CertificateInfo{cert}
-		return source, nil
+	certs, errs := utils.ParseCertificateBundleDiagnostics(clusterCA)
+	for _, err := range errs {
+		err.Key = "certificate-authority-data"
 	}
-
-	// Try to parse as a certificate bundle
-	if certs, err := utils.ParseCertificateBundle(clusterCA); err == nil {
-		for _, cert := range certs {
-			cert.Subject = fmt.Sprintf("%s (Kubernetes Cluster CA)", cert.Subject)
-		}
-		source.Certificates = certs
-		return source, nil
+	source.ParseErrors = errs
+	if len(certs) == 0 {
+		source.Error = fmt.Sprintf("Failed to parse cluster CA certificate: %v", errs[0])
+		return source, errs[0]
 	}
-
-	source.Error = "Failed to parse cluster CA certificate"
-	return source, fmt.Errorf("failed to parse cluster CA certificate")
+	for _, cert := range certs {
+		cert.Subject = fmt.Sprintf("%s (Kubernetes Cluster CA)", cert.Subject)
+	}
+	source.Certificates = certs
+	return source, nil
 }
 
 // AnalyzePodCertificates analyzes all certificates in a pod and returns detailed information
@@ -232,6 +289,9 @@ func GetCertificateExpiryWarnings(certSources map[string]*CertificateSource, war
 				allWarnings = append(allWarnings, fmt.Sprintf("[%s] %s", sourceName, warning))
 			}
 		}
+		if warning := source.KeyWarning(); warning != "" {
+			allWarnings = append(allWarnings, fmt.Sprintf("[%s] %s", sourceName, warning))
+		}
 	}
 
 	return allWarnings
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -19,54 +22,127 @@ import (
 
 // KubeConfigEKSDetails contains EKS-specific details from kubeconfig
 type KubeConfigEKSDetails struct {
+	// Cluster is the name of the clusters entry the client was created
+	// for, if any
+	Cluster         string `json:"cluster,omitempty"`
+	Context         string `json:"context,omitempty"`
 	ClusterName     string `json:"cluster_name"`
 	ClusterEndpoint string `json:"cluster_endpoint"`
 	ClusterCA       string `json:"cluster_ca"`
 	Region          string `json:"region"`
+	Partition       string `json:"partition,omitempty"`
 	RoleARN         string `json:"role_arn,omitempty"`
 }
 
+// tokenRefreshMargin is how long before its EKS token expires a client
+// generates a new one
+const tokenRefreshMargin = 2 * time.Minute
+
 // Client wraps the Kubernetes client with additional functionality
 type Client struct {
-	clientset  *kubernetes.Clientset
-	config     *rest.Config
-	appConfig  *config.Config
-	tokenGen   *auth.EKSTokenGenerator
-	eksDetails *KubeConfigEKSDetails
+	clientset      kubernetes.Interface
+	config         *rest.Config
+	appConfig      *config.Config
+	clusterConfig  *config.Config // appConfig with the cluster's AWS overrides
+	tokenGen       *auth.EKSTokenGenerator
+	eksDetails     *KubeConfigEKSDetails
+	kubeconfigPath string
+
+	tokenMu     sync.Mutex
+	tokenSource string
+	token       string
+	tokenExpiry time.Time
 }
 
-// NewClient creates a new Kubernetes client
+// NewClient creates a new Kubernetes client for the pod's service account
+// when running in a cluster (see InCluster), else for the current kubeconfig
+// context
 func NewClient(cfg *config.Config) (*Client, error) {
-	// Get kubeconfig path
-	kubeconfigPath := getKubeconfigPath()
+	return NewClientForContext(cfg, "")
+}
+
+// NewClientForContext creates a Kubernetes client for a named kubeconfig
+// context, or the current context if contextName is empty
+func NewClientForContext(cfg *config.Config, contextName string) (*Client, error) {
+	// Serve from fixture files instead of a live cluster in offline mode
+	if cfg.Kubernetes.FixturesDir != "" {
+		if contextName != "" {
+			return nil, fmt.Errorf("kubeconfig contexts are not available in fixture mode")
+		}
+		return newFixtureClient(cfg)
+	}
+	if contextName == "" && InCluster(cfg) {
+		return newInClusterClient(cfg)
+	}
+	return newKubeconfigClient(cfg, GetKubeconfigPath(cfg), contextName, "")
+}
 
+// NewClientForCluster creates a Kubernetes client for an entry of the
+// clusters setting, by name, from its kubeconfig and context, or the
+// client of NewClient if name is empty
+func NewClientForCluster(cfg *config.Config, name string) (*Client, error) {
+	if name == "" {
+		return NewClient(cfg)
+	}
+	cluster, ok := cfg.Clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("cluster %s is not configured under clusters", name)
+	}
+	if cfg.Kubernetes.FixturesDir != "" {
+		return nil, fmt.Errorf("clusters are not available in fixture mode")
+	}
+	kubeconfigPath := cluster.KubeconfigPath
+	if kubeconfigPath == "" {
+		kubeconfigPath = GetKubeconfigPath(cfg)
+	}
+	contextName := cluster.Context
+	if contextName == "" {
+		contextName = name
+	}
+	return newKubeconfigClient(cfg, kubeconfigPath, contextName, name)
+}
+
+// newKubeconfigClient creates a client for a context of a kubeconfig file,
+// or its current context if contextName is empty, authenticating with an
+// EKS token. clusterName names the clusters entry of the context, if any.
+func newKubeconfigClient(cfg *config.Config, kubeconfigPath, contextName, clusterName string) (*Client, error) {
 	// Parse kubeconfig for EKS details
-	eksDetails, err := parseKubeConfigForEKS(kubeconfigPath)
+	eksDetails, err := parseKubeConfigForEKS(kubeconfigPath, contextName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse kubeconfig for EKS details: %w", err)
 	}
+	eksDetails.Cluster = clusterName
+
+	// Apply the cluster's AWS overrides, so its token is signed in its own
+	// region and with its own credentials
+	ResolveClusterAWS(cfg, eksDetails)
+	clusterCfg := ClusterConfig(cfg, eksDetails)
 
 	// Create token generator
-	tokenGen := auth.NewEKSTokenGenerator(cfg)
+	tokenGen := auth.NewEKSTokenGenerator(clusterCfg)
 
-	// Generate EKS token - try aws-iam-authenticator first for better compatibility
-	token, err := tokenGen.GenerateTokenUsingAuthenticator(eksDetails.ClusterName, eksDetails.RoleARN)
-	if err != nil {
-		log.Printf("Failed to generate token using aws-iam-authenticator, falling back to custom method: %v", err)
-		// Fallback to custom token generation
-		token, err = tokenGen.GenerateToken(eksDetails.ClusterName, eksDetails.RoleARN)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate EKS token: %w", err)
-		}
+	client := &Client{
+		appConfig:      cfg,
+		clusterConfig:  clusterCfg,
+		tokenGen:       tokenGen,
+		eksDetails:     eksDetails,
+		kubeconfigPath: kubeconfigPath,
+	}
+	if _, err := client.bearerToken(); err != nil {
+		return nil, err
 	}
 
-	// Create Kubernetes config
+	// Create Kubernetes config. The token is set on each request, so that it
+	// can be refreshed without replacing the clientset and its connections.
+	wrap := transportWrapper(cfg.ReadOnly)
 	restConfig := &rest.Config{
-		Host:        eksDetails.ClusterEndpoint,
-		BearerToken: token,
+		Host: eksDetails.ClusterEndpoint,
 		TLSClientConfig: rest.TLSClientConfig{
 			CAData: []byte(eksDetails.ClusterCA),
 		},
+		WrapTransport: func(rt http.RoundTripper) http.RoundTripper {
+			return &bearerRoundTripper{client: client, next: wrap(rt)}
+		},
 	}
 
 	// Create clientset
@@ -75,17 +151,114 @@ func NewClient(cfg *config.Config) (*Client, error) {
 		return nil, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
 	}
 
-	return &Client{
-		clientset:  clientset,
-		config:     restConfig,
-		appConfig:  cfg,
-		tokenGen:   tokenGen,
-		eksDetails: eksDetails,
-	}, nil
+	client.clientset = clientset
+	client.config = restConfig
+	return client, nil
+}
+
+// bearerToken returns the EKS token of the client, generating a new one
+// when it expires within tokenRefreshMargin. A token that has not expired
+// yet is kept when a new one cannot be generated.
+func (c *Client) bearerToken() (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.token != "" && time.Until(c.tokenExpiry) > tokenRefreshMargin {
+		return c.token, nil
+	}
+	token, source, err := generateToken(c.tokenGen, c.eksDetails, c.clusterConfig)
+	if err != nil {
+		if c.token != "" && time.Now().Before(c.tokenExpiry) {
+			log.Printf("Warning: failed to refresh EKS token, using the current one until it expires: %v", err)
+			return c.token, nil
+		}
+		return "", err
+	}
+
+	c.token, c.tokenSource = token, source
+	c.tokenExpiry = time.Now().Add(auth.EKSTokenLifetime)
+	if info, err := auth.ParseTokenInfo(token, source); err == nil {
+		c.tokenExpiry = info.ExpiresAt
+	}
+	return token, nil
+}
+
+// generateToken generates an EKS token for a cluster - trying
+// aws-iam-authenticator first for better compatibility, unless the role must
+// be assumed with a source identity or session tags, which
+// aws-iam-authenticator cannot set - and returns it with its source
+func generateToken(tokenGen *auth.EKSTokenGenerator, eksDetails *KubeConfigEKSDetails, clusterCfg *config.Config) (string, string, error) {
+	if eksDetails.RoleARN != "" && auth.HasSessionAttribution(clusterCfg) {
+		token, err := tokenGen.GenerateToken(eksDetails.ClusterName, eksDetails.RoleARN)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate EKS token: %w", err)
+		}
+		return token, auth.TokenFromPresign, nil
+	}
+
+	token, err := tokenGen.GenerateTokenUsingAuthenticator(eksDetails.ClusterName, eksDetails.RoleARN)
+	if err == nil {
+		return token, auth.TokenFromAuthenticator, nil
+	}
+	log.Printf("Warning: failed to generate token using aws-iam-authenticator, falling back to custom method: %v", err)
+	// Fallback to custom token generation
+	token, err = tokenGen.GenerateToken(eksDetails.ClusterName, eksDetails.RoleARN)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate EKS token: %w", err)
+	}
+	return token, auth.TokenFromPresign, nil
+}
+
+// ResolveClusterAWS applies the cluster's entry in the clusters setting,
+// found by entry, context, or cluster name, to its EKS details. The region is the
+// entry's, then the one found in the kubeconfig, then aws.region, then the
+// default region of the entry's partition; the partition follows from the
+// region unless set.
+func ResolveClusterAWS(cfg *config.Config, details *KubeConfigEKSDetails) {
+	override, _ := cfg.ClusterAWS(details.Cluster, details.Context, details.ClusterName)
+	if override.Region != "" {
+		details.Region = override.Region
+	}
+	if details.Region == "" {
+		details.Region = cfg.AWS.Region
+	}
+	if details.Region == "" {
+		details.Region = config.PartitionRegion(override.Partition)
+	}
+	details.Partition = override.Partition
+	if details.Partition == "" {
+		details.Partition = config.PartitionForRegion(details.Region)
+	}
+	if override.RoleARN != "" {
+		details.RoleARN = override.RoleARN
+	}
+}
+
+// ClusterConfig returns the configuration for the AWS calls of a cluster
+// whose details were resolved with ResolveClusterAWS: its region, and its
+// credentials if overridden
+func ClusterConfig(cfg *config.Config, details *KubeConfigEKSDetails) *config.Config {
+	override, _ := cfg.ClusterAWS(details.Cluster, details.Context, details.ClusterName)
+	region := details.Region
+	if region == "" {
+		region = cfg.AWS.Region
+	}
+	return cfg.ForCluster(override, region)
+}
+
+// TokenInfo describes the EKS token of the client, or returns nil for
+// fixture and in-cluster clients, which have none
+func (c *Client) TokenInfo() (*auth.TokenInfo, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token == "" {
+		return nil, nil
+	}
+	return auth.ParseTokenInfo(c.token, c.tokenSource)
 }
 
 // GetClientset returns the Kubernetes clientset
-func (c *Client) GetClientset() *kubernetes.Clientset {
+func (c *Client) GetClientset() kubernetes.Interface {
 	return c.clientset
 }
 
@@ -100,28 +273,41 @@ func (c *Client) TestConnection(ctx context.Context) error {
 	return err
 }
 
-// GetKubeconfigPath returns the path to the kubeconfig file (public function)
-func GetKubeconfigPath() string {
-	return getKubeconfigPath()
+// GetKubeconfigPath returns the path to the kubeconfig file
+func GetKubeconfigPath(cfg *config.Config) string {
+	path, _ := ResolveKubeconfigPath(cfg)
+	return path
 }
 
-// getKubeconfigPath returns the path to the kubeconfig file
-func getKubeconfigPath() string {
+// ResolveKubeconfigPath returns the path to the kubeconfig file and where it
+// came from. An explicitly configured path (config file or --kubeconfig)
+// takes precedence over $KUBECONFIG, which takes precedence over ~/.kube/config.
+func ResolveKubeconfigPath(cfg *config.Config) (path string, source string) {
+	if cfg.Kubernetes.KubeconfigPath != "" {
+		return cfg.Kubernetes.KubeconfigPath, "kubernetes.kubeconfig_path"
+	}
+
 	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
-		return kubeconfig
+		return kubeconfig, "KUBECONFIG environment variable"
 	}
 
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		log.Printf("Warning: Could not get user home directory: %v", err)
-		return ""
+		return "", ""
 	}
 
-	return filepath.Join(homeDir, ".kube", "config")
+	return filepath.Join(homeDir, ".kube", "config"), "default (~/.kube/config)"
+}
+
+// KubeconfigPath returns the kubeconfig file the client was created from
+func (c *Client) KubeconfigPath() string {
+	return c.kubeconfigPath
 }
 
 // parseKubeConfigForEKS parses kubeconfig and extracts EKS-specific details
-func parseKubeConfigForEKS(kubeconfigPath string) (*KubeConfigEKSDetails, error) {
+// for the named context, or the current context if contextName is empty
+func parseKubeConfigForEKS(kubeconfigPath, contextName string) (*KubeConfigEKSDetails, error) {
 	if kubeconfigPath == "" {
 		return nil, fmt.Errorf("kubeconfig path is empty")
 	}
@@ -132,15 +318,17 @@ func parseKubeConfigForEKS(kubeconfigPath string) (*KubeConfigEKSDetails, error)
 		return nil, fmt.Errorf("failed to load kubeconfig from %s: %w", kubeconfigPath, err)
 	}
 
-	// Get current context
-	currentContext := config.CurrentContext
-	if currentContext == "" {
-		return nil, fmt.Errorf("no current context set in kubeconfig")
+	// Get the requested context, falling back to the current context
+	if contextName == "" {
+		contextName = config.CurrentContext
+		if contextName == "" {
+			return nil, fmt.Errorf("no current context set in kubeconfig")
+		}
 	}
 
-	context, exists := config.Contexts[currentContext]
+	context, exists := config.Contexts[contextName]
 	if !exists {
-		return nil, fmt.Errorf("current context %s not found in kubeconfig", currentContext)
+		return nil, fmt.Errorf("context %s not found in kubeconfig", contextName)
 	}
 
 	// Get cluster info
@@ -179,22 +367,36 @@ func parseKubeConfigForEKS(kubeconfigPath string) (*KubeConfigEKSDetails, error)
 			}
 		}
 	}
+	// Clusters added by aws eks update-kubeconfig are named by their ARN,
+	// arn:<partition>:eks:<region>:<account>:cluster/<name>
+	if arnParts := strings.Split(context.Cluster, ":"); region == "" && len(arnParts) >= 6 && arnParts[0] == "arn" && arnParts[2] == "eks" {
+		region = arnParts[3]
+	}
 
 	// Check for role ARN in user config - handle both long and short form
 	roleARN := ""
 	if user, exists := config.AuthInfos[context.AuthInfo]; exists {
 		if user.Exec != nil {
 			for i, arg := range user.Exec.Args {
+				if i+1 >= len(user.Exec.Args) {
+					break
+				}
+				switch arg {
 				// Check for both --role-arn and -r
-				if (arg == "--role-arn" || arg == "-r") && i+1 < len(user.Exec.Args) {
+				case "--role-arn", "-r":
 					roleARN = user.Exec.Args[i+1]
-					break
+				// aws eks get-token --region
+				case "--region":
+					if region == "" {
+						region = user.Exec.Args[i+1]
+					}
 				}
 			}
 		}
 	}
 
 	return &KubeConfigEKSDetails{
+		Context:         contextName,
 		ClusterName:     clusterName,
 		ClusterEndpoint: cluster.Server,
 		ClusterCA:       clusterCA,
@@ -203,9 +405,27 @@ func parseKubeConfigForEKS(kubeconfigPath string) (*KubeConfigEKSDetails, error)
 	}, nil
 }
 
+// LoadClusterCA returns the cluster CA certificate for the configured
+// cluster, reading it from the fixtures directory in offline mode and from
+// the service account in a cluster
+func LoadClusterCA(cfg *config.Config) (string, error) {
+	if cfg.Kubernetes.FixturesDir != "" {
+		return loadFixtureClusterCA(cfg.Kubernetes.FixturesDir)
+	}
+	if InCluster(cfg) {
+		return loadInClusterCA()
+	}
+
+	kubeconfigPath := GetKubeconfigPath(cfg)
+	if kubeconfigPath == "" {
+		return "", fmt.Errorf("could not determine kubeconfig path")
+	}
+	return GetClusterCA(kubeconfigPath)
+}
+
 // GetClusterCA returns the cluster CA certificate
 func GetClusterCA(kubeconfigPath string) (string, error) {
-	eksDetails, err := parseKubeConfigForEKS(kubeconfigPath)
+	eksDetails, err := parseKubeConfigForEKS(kubeconfigPath, "")
 	if err != nil {
 		return "", err
 	}
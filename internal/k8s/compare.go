@@ -0,0 +1,130 @@
+package k8s
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// serviceDialTimeout bounds the TLS handshake with a Service
+const serviceDialTimeout = 5 * time.Second
+
+// CertificateComparison compares an expected certificate, such as the one a
+// rotation should have deployed, with the certificate found at a target
+type CertificateComparison struct {
+	// Target is secret/{namespace}/{name} or service/{namespace}/{name}:{port}
+	Target   string                 `json:"target"`
+	Matches  bool                   `json:"matches"`
+	Deployed *utils.CertificateInfo `json:"deployed,omitempty"`
+	// SANsMissing are the names of the expected certificate the deployed one
+	// lacks, and SANsExtra the names only the deployed one has
+	SANsMissing []string `json:"sans_missing,omitempty"`
+	SANsExtra   []string `json:"sans_extra,omitempty"`
+	// ExpiryDeltaDays is how many days later the expected certificate
+	// expires than the deployed one; negative if it expires earlier
+	ExpiryDeltaDays int    `json:"expiry_delta_days"`
+	Error           string `json:"error,omitempty"`
+}
+
+// CompareCertificates compares the fingerprints, subject alternative names,
+// and expiry of an expected and a deployed certificate
+func CompareCertificates(target string, expected, deployed *utils.CertificateInfo) CertificateComparison {
+	comparison := CertificateComparison{
+		Target:          target,
+		Matches:         expected.Fingerprint == deployed.Fingerprint,
+		Deployed:        deployed,
+		ExpiryDeltaDays: int(expected.NotAfter.Sub(deployed.NotAfter).Hours() / 24),
+	}
+	expectedSANs := certificateSANs(expected)
+	deployedSANs := certificateSANs(deployed)
+	for _, name := range sortedKeys(expectedSANs) {
+		if !deployedSANs[name] {
+			comparison.SANsMissing = append(comparison.SANsMissing, name)
+		}
+	}
+	for _, name := range sortedKeys(deployedSANs) {
+		if !expectedSANs[name] {
+			comparison.SANsExtra = append(comparison.SANsExtra, name)
+		}
+	}
+	return comparison
+}
+
+// certificateSANs returns the DNS names and IP addresses of a certificate
+func certificateSANs(cert *utils.CertificateInfo) map[string]bool {
+	sans := make(map[string]bool)
+	for _, name := range append(append([]string{}, cert.DNSNames...), cert.IPAddresses...) {
+		sans[name] = true
+	}
+	return sans
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SecretLeafCertificate returns the first certificate stored in a secret
+// that is not a CA, or the first certificate if all are CAs
+func SecretLeafCertificate(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (*utils.CertificateInfo, error) {
+	source, err := ExtractCertificatesFromSecret(ctx, clientset, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(source.Certificates) == 0 {
+		return nil, fmt.Errorf("secret %s/%s holds no certificates under well-known keys", namespace, name)
+	}
+	for _, cert := range source.Certificates {
+		if !cert.IsCA {
+			return cert, nil
+		}
+	}
+	return source.Certificates[0], nil
+}
+
+// ServedCertificate connects to a Service's cluster IP and returns the leaf
+// certificate it presents with the port it was read from. Without a port,
+// the first TLS port of the Service is used. The chain is not verified, and
+// the connection only succeeds from inside the cluster network.
+func ServedCertificate(ctx context.Context, clientset kubernetes.Interface, namespace, name string, port int32) (*utils.CertificateInfo, int32, error) {
+	svc, err := clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, port, fmt.Errorf("failed to get service %s/%s: %w", namespace, name, err)
+	}
+	if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == "None" {
+		return nil, port, fmt.Errorf("service %s/%s has no cluster IP", namespace, name)
+	}
+	if port == 0 {
+		for _, p := range serviceTLSInfo(svc).Ports {
+			if p.TLS && p.Protocol == "TCP" {
+				port = p.Port
+				break
+			}
+		}
+		if port == 0 {
+			return nil, port, fmt.Errorf("service %s/%s has no TLS port; set port", namespace, name)
+		}
+	}
+
+	address := net.JoinHostPort(svc.Spec.ClusterIP, strconv.Itoa(int(port)))
+	peers, _, err := dialCertificateChain(ctx, address, fmt.Sprintf("%s.%s.svc", name, namespace))
+	if err != nil {
+		return nil, port, fmt.Errorf("service %s/%s: %w", namespace, name, err)
+	}
+	cert, err := utils.ParseCertificate(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: peers[0].Raw})))
+	return cert, port, err
+}
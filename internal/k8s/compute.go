@@ -0,0 +1,130 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Compute types a pod can run on
+const (
+	ComputeEC2         = "ec2"
+	ComputeFargate     = "fargate"
+	ComputeUnscheduled = "unscheduled"
+)
+
+// How the EC2 node of a pod is managed
+const (
+	NodeManaged     = "managed"      // EKS managed nodegroup
+	NodeSelfManaged = "self-managed" // Auto Scaling group or standalone instance run by the cluster owner
+	NodeKarpenter   = "karpenter"
+	NodeAutoMode    = "auto-mode" // EKS Auto Mode
+)
+
+// Labels identifying the compute of pods and nodes
+const (
+	labelFargateProfile       = "eks.amazonaws.com/fargate-profile"
+	labelComputeType          = "eks.amazonaws.com/compute-type"
+	labelManagedNodegroup     = "eks.amazonaws.com/nodegroup"
+	labelKarpenterNodePool    = "karpenter.sh/nodepool"
+	labelKarpenterProvisioner = "karpenter.sh/provisioner-name"
+)
+
+// ComputeInfo describes where a pod runs. Remediation differs by compute:
+// Fargate pods have no node access, managed nodes are replaced through their
+// nodegroup, and self-managed nodes are maintained by the cluster owner.
+type ComputeInfo struct {
+	Type string `json:"type"`
+	// Management is how the EC2 node is managed; empty for Fargate pods or
+	// when nodes cannot be listed
+	Management string `json:"management,omitempty"`
+	Node       string `json:"node,omitempty"`
+	// NodeGroup is the managed nodegroup or Karpenter node pool of an EC2
+	// node, or the Fargate profile of a Fargate pod
+	NodeGroup string `json:"node_group,omitempty"`
+}
+
+// String returns the compute type with the node management of EC2 pods,
+// e.g. ec2/managed or fargate
+func (c *ComputeInfo) String() string {
+	if c.Management == "" {
+		return c.Type
+	}
+	return c.Type + "/" + c.Management
+}
+
+// ComputeResolver determines the compute type of pods, listing the nodes of
+// the cluster once
+type ComputeResolver struct {
+	clientset kubernetes.Interface
+	nodes     map[string]*corev1.Node
+	loaded    bool
+}
+
+// NewComputeResolver creates a resolver for the pods of a cluster
+func NewComputeResolver(clientset kubernetes.Interface) *ComputeResolver {
+	return &ComputeResolver{clientset: clientset}
+}
+
+// Resolve returns the compute type of pod. Fargate pods are recognized by the
+// label EKS sets on them or the node they run on; if nodes cannot be listed,
+// the management and nodegroup of EC2 pods are left empty.
+func (r *ComputeResolver) Resolve(ctx context.Context, pod *corev1.Pod) *ComputeInfo {
+	info := &ComputeInfo{Type: ComputeEC2, Node: pod.Spec.NodeName}
+	if profile, ok := pod.Labels[labelFargateProfile]; ok {
+		info.Type = ComputeFargate
+		info.NodeGroup = profile
+		return info
+	}
+	if pod.Spec.NodeName == "" {
+		info.Type = ComputeUnscheduled
+		return info
+	}
+	if strings.HasPrefix(pod.Spec.NodeName, "fargate-") {
+		info.Type = ComputeFargate
+		return info
+	}
+
+	if !r.loaded {
+		r.loaded = true
+		r.nodes = make(map[string]*corev1.Node)
+		if nodes, err := r.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err == nil {
+			for i := range nodes.Items {
+				r.nodes[nodes.Items[i].Name] = &nodes.Items[i]
+			}
+		}
+	}
+	if node, ok := r.nodes[pod.Spec.NodeName]; ok {
+		if node.Labels[labelComputeType] == ComputeFargate {
+			info.Type = ComputeFargate
+		} else {
+			info.Management = nodeManagement(node)
+		}
+		for _, label := range nodeGroupLabels {
+			if group, ok := node.Labels[label]; ok {
+				info.NodeGroup = group
+				break
+			}
+		}
+	}
+	return info
+}
+
+// nodeManagement returns how an EC2 node is managed, from the labels EKS,
+// Karpenter, and EKS Auto Mode set on the nodes they launch
+func nodeManagement(node *corev1.Node) string {
+	labels := node.Labels
+	switch {
+	case labels[labelComputeType] == "auto":
+		return NodeAutoMode
+	case labels[labelManagedNodegroup] != "":
+		return NodeManaged
+	case labels[labelKarpenterNodePool] != "" || labels[labelKarpenterProvisioner] != "":
+		return NodeKarpenter
+	default:
+		return NodeSelfManaged
+	}
+}
@@ -0,0 +1,137 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/jsonpath"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/pkg/utils"
+)
+
+// CertificateSourceCustomResource is the source type of certificates found
+// in custom resources
+const CertificateSourceCustomResource = "custom-resource"
+
+// ScanCustomResources extracts the certificates selected by each configured
+// custom resource JSONPath from the objects of a namespace. Every object
+// with certificates gets a source named Kind/name, keyed by the JSONPath; a
+// kind that cannot be listed, such as one whose CRD is not installed, gets a
+// single source with an error.
+func ScanCustomResources(ctx context.Context, clientset kubernetes.Interface, namespace string, resources []config.CustomResource) []*CertificateSource {
+	var sources []*CertificateSource
+	for _, resource := range resources {
+		sources = append(sources, scanCustomResource(ctx, clientset, namespace, resource)...)
+	}
+	return sources
+}
+
+// scanCustomResource lists the objects of one custom resource kind and
+// evaluates its JSONPath on each
+func scanCustomResource(ctx context.Context, clientset kubernetes.Interface, namespace string, resource config.CustomResource) []*CertificateSource {
+	failed := func(format string, args ...interface{}) []*CertificateSource {
+		return []*CertificateSource{{
+			Type:      CertificateSourceCustomResource,
+			Name:      resource.GVK,
+			Namespace: namespace,
+			Key:       resource.JSONPath,
+			Error:     fmt.Sprintf(format, args...),
+		}}
+	}
+
+	group, version, kind, err := resource.ParseGVK()
+	if err != nil {
+		return failed("%v", err)
+	}
+	parser := jsonpath.New(resource.GVK).AllowMissingKeys(true)
+	if err := parser.Parse(resource.JSONPath); err != nil {
+		return failed("invalid JSONPath: %v", err)
+	}
+
+	// Discovery maps the kind to the resource name used in the API path
+	groupVersion := path.Join(group, version)
+	apiResources, err := clientset.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return failed("failed to discover %s: %v", groupVersion, err)
+	}
+	resourceName := ""
+	for _, apiResource := range apiResources.APIResources {
+		if apiResource.Kind == kind && !strings.Contains(apiResource.Name, "/") {
+			if !apiResource.Namespaced {
+				return failed("%s is cluster-scoped; only namespaced kinds are supported", kind)
+			}
+			resourceName = apiResource.Name
+		}
+	}
+	if resourceName == "" {
+		return failed("kind %s is not served by %s", kind, groupVersion)
+	}
+
+	restClient := clientset.Discovery().RESTClient()
+	if restClient == nil {
+		return failed("custom resources cannot be read with this client")
+	}
+	prefix := "/apis"
+	if group == "" {
+		prefix = "/api"
+	}
+	raw, err := restClient.Get().AbsPath(prefix, groupVersion, "namespaces", namespace, resourceName).DoRaw(ctx)
+	if err != nil {
+		return failed("failed to list %s: %v", resourceName, err)
+	}
+	var list struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return failed("failed to decode %s: %v", resourceName, err)
+	}
+
+	var sources []*CertificateSource
+	for _, item := range list.Items {
+		name := ""
+		if metadata, ok := item["metadata"].(map[string]interface{}); ok {
+			name, _ = metadata["name"].(string)
+		}
+		source := &CertificateSource{
+			Type:      CertificateSourceCustomResource,
+			Name:      kind + "/" + name,
+			Namespace: namespace,
+			Key:       resource.JSONPath,
+		}
+
+		results, err := parser.FindResults(item)
+		if err != nil {
+			source.Error = fmt.Sprintf("failed to evaluate JSONPath: %v", err)
+			sources = append(sources, source)
+			continue
+		}
+		for _, values := range results {
+			for _, value := range values {
+				if text, ok := value.Interface().(string); ok {
+					source.Certificates = append(source.Certificates, embeddedCertificates(text)...)
+				}
+			}
+		}
+		if len(source.Certificates) > 0 {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// customResourceWarnings returns the expiry warnings of custom resource
+// sources, prefixed with the object they came from
+func customResourceWarnings(sources []*CertificateSource, warningDays int) []string {
+	var warnings []string
+	for _, source := range sources {
+		for _, warning := range utils.ValidateCertificateExpiry(source.Certificates, warningDays) {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", source.Name, warning))
+		}
+	}
+	return warnings
+}
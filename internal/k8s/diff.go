@@ -0,0 +1,147 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Kinds of difference reported by DiffInventories
+const (
+	DiffCount      = "certificate_count"
+	DiffIssuer     = "issuer"
+	DiffExpirySkew = "expiry_skew"
+)
+
+// CertificateDifference describes a single difference between the same
+// certificate source in two clusters
+type CertificateDifference struct {
+	Source   string `json:"source"`
+	Kind     string `json:"kind"`
+	Subject  string `json:"subject,omitempty"`
+	A        string `json:"a"`
+	B        string `json:"b"`
+	SkewDays int    `json:"skew_days,omitempty"`
+}
+
+// InventoryDiff is the result of comparing the certificate inventories of a
+// namespace in two clusters
+type InventoryDiff struct {
+	ClusterA    string                  `json:"cluster_a"`
+	ClusterB    string                  `json:"cluster_b"`
+	Namespace   string                  `json:"namespace"`
+	MaxSkewDays int                     `json:"max_skew_days"`
+	OnlyInA     []string                `json:"only_in_a"`
+	OnlyInB     []string                `json:"only_in_b"`
+	Matching    int                     `json:"matching"`
+	Differences []CertificateDifference `json:"differences"`
+}
+
+// NamespaceInventory returns every secret and configmap in a namespace that
+// contains at least one certificate, keyed like AnalyzePodCertificates
+// ("secret-<name>", "configmap-<name>")
+func NamespaceInventory(ctx context.Context, clientset kubernetes.Interface, namespace string) (map[string]*CertificateSource, error) {
+	inventory := make(map[string]*CertificateSource)
+
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets in namespace %s: %w", namespace, err)
+	}
+	for i := range secrets.Items {
+		if source := certificatesFromSecret(&secrets.Items[i]); len(source.Certificates) > 0 {
+			inventory["secret-"+source.Name] = source
+		}
+	}
+
+	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps in namespace %s: %w", namespace, err)
+	}
+	for i := range configMaps.Items {
+		if source := certificatesFromConfigMap(&configMaps.Items[i]); len(source.Certificates) > 0 {
+			inventory["configmap-"+source.Name] = source
+		}
+	}
+
+	return inventory, nil
+}
+
+// DiffInventories compares two certificate inventories. Sources are matched
+// by name and certificates by position within the source, since subjects
+// usually differ between environments. Issuers must match and expiry dates
+// may differ by at most maxSkewDays.
+func DiffInventories(a, b map[string]*CertificateSource, maxSkewDays int) *InventoryDiff {
+	diff := &InventoryDiff{
+		MaxSkewDays: maxSkewDays,
+		OnlyInA:     []string{},
+		OnlyInB:     []string{},
+		Differences: []CertificateDifference{},
+	}
+
+	var names []string
+	for name := range a {
+		if _, ok := b[name]; ok {
+			names = append(names, name)
+		} else {
+			diff.OnlyInA = append(diff.OnlyInA, name)
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			diff.OnlyInB = append(diff.OnlyInB, name)
+		}
+	}
+	sort.Strings(names)
+	sort.Strings(diff.OnlyInA)
+	sort.Strings(diff.OnlyInB)
+
+	for _, name := range names {
+		certsA, certsB := a[name].Certificates, b[name].Certificates
+		before := len(diff.Differences)
+
+		if len(certsA) != len(certsB) {
+			diff.Differences = append(diff.Differences, CertificateDifference{
+				Source: name,
+				Kind:   DiffCount,
+				A:      fmt.Sprint(len(certsA)),
+				B:      fmt.Sprint(len(certsB)),
+			})
+		}
+
+		for i := 0; i < len(certsA) && i < len(certsB); i++ {
+			certA, certB := certsA[i], certsB[i]
+
+			if certA.Issuer != certB.Issuer {
+				diff.Differences = append(diff.Differences, CertificateDifference{
+					Source:  name,
+					Kind:    DiffIssuer,
+					Subject: certA.Subject,
+					A:       certA.Issuer,
+					B:       certB.Issuer,
+				})
+			}
+
+			skew := int(math.Abs(certA.NotAfter.Sub(certB.NotAfter).Hours()) / 24)
+			if skew > maxSkewDays {
+				diff.Differences = append(diff.Differences, CertificateDifference{
+					Source:   name,
+					Kind:     DiffExpirySkew,
+					Subject:  certA.Subject,
+					A:        certA.NotAfter.Format("2006-01-02"),
+					B:        certB.NotAfter.Format("2006-01-02"),
+					SkewDays: skew,
+				})
+			}
+		}
+
+		if len(diff.Differences) == before {
+			diff.Matching++
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,175 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s-web-service/internal/analyzer"
+	"k8s-web-service/pkg/utils"
+)
+
+// PodExpiryInfo summarizes the certificates found in a single pod
+type PodExpiryInfo struct {
+	PodName      string                        `json:"pod_name"`
+	Compute      *ComputeInfo                  `json:"compute,omitempty"`
+	Runtime      *PodRuntime                   `json:"runtime"`
+	Origin       *PodOrigin                    `json:"origin"`
+	CertSources  map[string]*CertificateSource `json:"certificate_sources"`
+	Warnings     []string                      `json:"warnings"`
+	WarningCount int                           `json:"warning_count"`
+	CertCount    int                           `json:"certificate_count"`
+	Findings     []analyzer.Finding            `json:"findings,omitempty"`
+}
+
+// NamespaceExpiryReport is the result of a certificate expiry analysis across a namespace
+type NamespaceExpiryReport struct {
+	Namespace         string          `json:"namespace"`
+	WarningDays       int             `json:"warning_days"`
+	TotalPods         int             `json:"total_pods_analyzed"`
+	TotalCertificates int             `json:"total_certificates"`
+	TotalWarnings     int             `json:"total_warnings"`
+	Pods              []PodExpiryInfo `json:"pod_expiry_info"`
+	// CustomResources holds the certificates of the configured custom
+	// resource fields
+	CustomResources []*CertificateSource `json:"custom_resources,omitempty"`
+	Warnings        []string             `json:"all_warnings"`
+	// Findings of the registered custom analyzers
+	Findings []analyzer.Finding `json:"findings,omitempty"`
+}
+
+// Clone copies a report deeply enough that its pods can be reordered and its
+// certificate sources summarized without changing the original, as needed
+// for the reports the background scanner shares between requests
+func (r *NamespaceExpiryReport) Clone() *NamespaceExpiryReport {
+	clone := *r
+	clone.Pods = make([]PodExpiryInfo, len(r.Pods))
+	for i, pod := range r.Pods {
+		pod.CertSources = make(map[string]*CertificateSource, len(r.Pods[i].CertSources))
+		for name, source := range r.Pods[i].CertSources {
+			pod.CertSources[name] = source.clone()
+		}
+		clone.Pods[i] = pod
+	}
+	if r.CustomResources != nil {
+		clone.CustomResources = make([]*CertificateSource, len(r.CustomResources))
+		for i, source := range r.CustomResources {
+			clone.CustomResources[i] = source.clone()
+		}
+	}
+	return &clone
+}
+
+// clone copies a certificate source and its certificates
+func (s *CertificateSource) clone() *CertificateSource {
+	if s == nil {
+		return nil
+	}
+	clone := *s
+	clone.Certificates = make([]*utils.CertificateInfo, len(s.Certificates))
+	for i, cert := range s.Certificates {
+		info := *cert
+		clone.Certificates[i] = &info
+	}
+	return &clone
+}
+
+// AnalyzeNamespaceExpiry analyzes the certificates of every pod in a namespace.
+// Only pods with certificates or warnings are included in the report.
+func AnalyzeNamespaceExpiry(ctx context.Context, client *Client, namespace string, warningDays int) (*NamespaceExpiryReport, error) {
+	pods, err := client.GetClientset().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %s: %w", namespace, err)
+	}
+
+	report := &NamespaceExpiryReport{
+		Namespace:   namespace,
+		WarningDays: warningDays,
+		TotalPods:   len(pods.Items),
+	}
+
+	compute := NewComputeResolver(client.GetClientset())
+	origins := NewOriginResolver(client.GetClientset(), namespace)
+	for _, pod := range pods.Items {
+		certSources, err := AnalyzePodCertificates(ctx, client, namespace, pod.Name)
+		if err != nil {
+			continue // Skip pods with errors
+		}
+
+		warnings := GetCertificateExpiryWarnings(certSources, warningDays)
+		certCount := CountCertificates(certSources)
+		findings := RunAnalyzers(namespace, pod.Name, certSources)
+
+		if len(warnings) > 0 || certCount > 0 {
+			report.Pods = append(report.Pods, PodExpiryInfo{
+				PodName:      pod.Name,
+				Compute:      compute.Resolve(ctx, &pod),
+				Runtime:      PodRuntimeStatus(&pod),
+				Origin:       origins.Resolve(ctx, &pod),
+				CertSources:  certSources,
+				Warnings:     warnings,
+				WarningCount: len(warnings),
+				CertCount:    certCount,
+				Findings:     findings,
+			})
+			report.Findings = append(report.Findings, findings...)
+
+			for _, warning := range warnings {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("Pod %s: %s", pod.Name, warning))
+			}
+		}
+
+		report.TotalCertificates += certCount
+		report.TotalWarnings += len(warnings)
+	}
+
+	if resources := client.appConfig.CustomResources; len(resources) > 0 {
+		report.CustomResources = ScanCustomResources(ctx, client.GetClientset(), namespace, resources)
+		for _, source := range report.CustomResources {
+			report.TotalCertificates += len(source.Certificates)
+		}
+		warnings := customResourceWarnings(report.CustomResources, warningDays)
+		report.Warnings = append(report.Warnings, warnings...)
+		report.TotalWarnings += len(warnings)
+	}
+
+	return report, nil
+}
+
+// RunAnalyzers passes every certificate of a pod to the registered custom
+// analyzers and returns their findings
+func RunAnalyzers(namespace, podName string, certSources map[string]*CertificateSource) []analyzer.Finding {
+	var names []string
+	for name := range certSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var findings []analyzer.Finding
+	for _, name := range names {
+		source := certSources[name]
+		src := analyzer.Source{
+			Namespace:    namespace,
+			Pod:          podName,
+			Name:         name,
+			Type:         source.Type,
+			ResourceName: source.Name,
+			Key:          source.Key,
+		}
+		for _, cert := range source.Certificates {
+			findings = append(findings, analyzer.Run(cert, src)...)
+		}
+	}
+	return findings
+}
+
+// CountCertificates counts total certificates across all sources
+func CountCertificates(certSources map[string]*CertificateSource) int {
+	total := 0
+	for _, source := range certSources {
+		total += len(source.Certificates)
+	}
+	return total
+}
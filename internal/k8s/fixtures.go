@@ -0,0 +1,137 @@
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/readonly"
+)
+
+// fixtureCAFiles are the file names checked for the cluster CA in a fixtures directory
+var fixtureCAFiles = []string{"cluster-ca.crt", "ca.crt"}
+
+// newFixtureClient creates a client backed by the client-go fake clientset,
+// populated from the YAML/JSON manifests in the fixtures directory. No
+// cluster or AWS access is required.
+func newFixtureClient(cfg *config.Config) (*Client, error) {
+	dir := cfg.Kubernetes.FixturesDir
+
+	objects, err := loadFixtureObjects(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterCA, err := loadFixtureClusterCA(dir)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	clientset := fake.NewSimpleClientset(objects...)
+	if cfg.ReadOnly {
+		clientset.PrependReactor("*", "*", readOnlyReactor)
+	}
+
+	return &Client{
+		clientset: clientset,
+		appConfig: cfg,
+		eksDetails: &KubeConfigEKSDetails{
+			ClusterName:     "fixtures",
+			ClusterEndpoint: "fixtures://" + dir,
+			ClusterCA:       clusterCA,
+			Region:          cfg.AWS.Region,
+		},
+	}, nil
+}
+
+// readOnlyReactor refuses fake clientset actions that are not allowed in read-only mode
+func readOnlyReactor(action k8stesting.Action) (bool, runtime.Object, error) {
+	resource := action.GetResource()
+	if readonly.AllowKubernetesVerb(action.GetVerb(), resource.Group, resource.Resource, action.GetSubresource()) {
+		return false, nil, nil
+	}
+	return true, nil, fmt.Errorf("%s %s: %w", action.GetVerb(), resource.Resource, readonly.ErrReadOnly)
+}
+
+// loadFixtureObjects decodes every Kubernetes object found in the manifest
+// files of dir. Multi-document YAML and v1 List objects are supported.
+func loadFixtureObjects(dir string) ([]runtime.Object, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures directory %s: %w", dir, err)
+	}
+
+	decoder := scheme.Codecs.UniversalDeserializer()
+	var objects []runtime.Object
+
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+		}
+
+		for i, doc := range splitYAMLDocuments(data) {
+			obj, _, err := decoder.Decode(doc, nil, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode document %d of fixture %s: %w", i, path, err)
+			}
+
+			list, ok := obj.(*corev1.List)
+			if !ok {
+				objects = append(objects, obj)
+				continue
+			}
+
+			for j, item := range list.Items {
+				itemObj, _, err := decoder.Decode(item.Raw, nil, nil)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode list item %d in fixture %s: %w", j, path, err)
+				}
+				objects = append(objects, itemObj)
+			}
+		}
+	}
+
+	log.Printf("Loaded %d fixture objects from %s", len(objects), dir)
+	return objects, nil
+}
+
+// splitYAMLDocuments splits a multi-document YAML file on "---" separators,
+// dropping empty documents
+func splitYAMLDocuments(data []byte) [][]byte {
+	var docs [][]byte
+	for _, doc := range bytes.Split(data, []byte("\n---")) {
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// loadFixtureClusterCA reads the cluster CA certificate from the fixtures directory
+func loadFixtureClusterCA(dir string) (string, error) {
+	for _, name := range fixtureCAFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(data), nil
+		}
+	}
+	return "", fmt.Errorf("no cluster CA found in fixtures directory %s (looked for %v)", dir, fixtureCAFiles)
+}
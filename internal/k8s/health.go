@@ -0,0 +1,172 @@
+package k8s
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"k8s-web-service/internal/analyzer"
+	"k8s-web-service/pkg/utils"
+)
+
+// Weights of the issues of a certificate in the health score. The issues of
+// one certificate add up to at most a whole certificate.
+const (
+	HealthWeightExpired         = 1.0
+	HealthWeightCritical        = 0.5 // expires within HealthCriticalDays
+	HealthWeightExpiring        = 0.2 // expires within warning_days
+	HealthWeightBrokenChain     = 0.4
+	HealthWeightWeakKey         = 0.3
+	HealthWeightCriticalFinding = 0.3 // per critical analyzer finding
+)
+
+// HealthCriticalDays is the number of days before expiry from which a
+// certificate counts as critical rather than expiring
+const HealthCriticalDays = 7
+
+// Minimum public key sizes in bits; smaller keys, DSA keys, and MD5 or SHA-1
+// signatures are weak
+const (
+	minRSAKeySize   = 2048
+	minECDSAKeySize = 256
+)
+
+// HealthScore is the certificate health of a namespace from 0 to 100: the
+// share of its certificates without issues, where each issue deducts its
+// weight from the certificate it affects. A namespace without certificates
+// scores 100.
+type HealthScore struct {
+	Namespace        string            `json:"namespace"`
+	Score            int               `json:"score"`
+	Certificates     int               `json:"certificates"`
+	Expired          int               `json:"expired"`
+	Critical         int               `json:"critical"`
+	Expiring         int               `json:"expiring"`
+	BrokenChains     int               `json:"broken_chains"`
+	WeakKeys         int               `json:"weak_keys"`
+	CriticalFindings int               `json:"critical_findings"`
+	Deductions       []HealthDeduction `json:"deductions,omitempty"`
+}
+
+// HealthDeduction is the score lost to one kind of issue
+type HealthDeduction struct {
+	Issue  string  `json:"issue"`
+	Count  int     `json:"count"`
+	Points float64 `json:"points"`
+}
+
+// NamespaceHealthScore scores the certificates of a namespace expiry report,
+// including custom resources. A certificate mounted by several pods counts
+// once.
+func NamespaceHealthScore(report *NamespaceExpiryReport) *HealthScore {
+	score := &HealthScore{Namespace: report.Namespace}
+	var sources []*CertificateSource
+	for _, pod := range report.Pods {
+		for _, source := range pod.CertSources {
+			sources = append(sources, source)
+		}
+	}
+	sources = append(sources, report.CustomResources...)
+
+	points := make(map[string]float64)
+	seen := make(map[string]bool)
+	var total float64
+	for _, source := range sources {
+		broken := brokenChain(source.Certificates)
+		for _, cert := range source.Certificates {
+			if cert == nil || seen[cert.Fingerprint] {
+				continue
+			}
+			seen[cert.Fingerprint] = true
+			score.Certificates++
+
+			var penalty float64
+			deduct := func(issue string, weight float64) {
+				points[issue] += weight
+				penalty += weight
+			}
+			switch {
+			case cert.IsExpired:
+				score.Expired++
+				deduct("expired", HealthWeightExpired)
+			case cert.DaysUntilExp <= HealthCriticalDays:
+				score.Critical++
+				deduct("critical", HealthWeightCritical)
+			case cert.DaysUntilExp <= report.WarningDays:
+				score.Expiring++
+				deduct("expiring", HealthWeightExpiring)
+			}
+			if broken {
+				score.BrokenChains++
+				deduct("broken_chain", HealthWeightBrokenChain)
+			}
+			if WeakKey(cert) {
+				score.WeakKeys++
+				deduct("weak_key", HealthWeightWeakKey)
+			}
+			total += math.Min(penalty, 1)
+		}
+	}
+	for _, finding := range report.Findings {
+		if finding.Severity == analyzer.SeverityCritical {
+			score.CriticalFindings++
+			points["critical_finding"] += HealthWeightCriticalFinding
+			total += HealthWeightCriticalFinding
+		}
+	}
+
+	score.Score = 100
+	if score.Certificates > 0 {
+		score.Score = int(math.Round(100 * math.Max(0, 1-total/float64(score.Certificates))))
+	}
+	counts := map[string]int{
+		"expired":          score.Expired,
+		"critical":         score.Critical,
+		"expiring":         score.Expiring,
+		"broken_chain":     score.BrokenChains,
+		"weak_key":         score.WeakKeys,
+		"critical_finding": score.CriticalFindings,
+	}
+	for issue, lost := range points {
+		share := 100 * lost / float64(max(score.Certificates, 1))
+		score.Deductions = append(score.Deductions, HealthDeduction{Issue: issue, Count: counts[issue], Points: math.Round(share*10) / 10})
+	}
+	sort.Slice(score.Deductions, func(i, j int) bool { return score.Deductions[i].Points > score.Deductions[j].Points })
+	return score
+}
+
+// WeakKey reports whether a certificate has an RSA key under 2048 bits, an
+// ECDSA key under 256 bits, a DSA key, or an MD5 or SHA-1 signature. The
+// signature of self-signed roots is not checked, as clients ignore it.
+func WeakKey(cert *utils.CertificateInfo) bool {
+	switch cert.KeyAlgorithm {
+	case "RSA":
+		if cert.KeySize < minRSAKeySize {
+			return true
+		}
+	case "ECDSA":
+		if cert.KeySize < minECDSAKeySize {
+			return true
+		}
+	case "DSA":
+		return true
+	}
+	signature := strings.ToUpper(cert.SignatureAlgorithm)
+	weakSignature := strings.Contains(signature, "MD5") || strings.Contains(signature, "SHA1")
+	return weakSignature && cert.Subject != cert.Issuer
+}
+
+// brokenChain reports whether a chain served by a leaf is out of order: each
+// certificate after the leaf must have issued the one before it. Bundles
+// starting with a CA certificate are trust bundles and are not chains.
+func brokenChain(certs []*utils.CertificateInfo) bool {
+	if len(certs) < 2 || certs[0] == nil || certs[0].IsCA {
+		return false
+	}
+	for i := 0; i+1 < len(certs); i++ {
+		if certs[i] == nil || certs[i+1] == nil || certs[i].Issuer != certs[i+1].Subject {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,321 @@
+package k8s
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// Helm v3 release storage
+const (
+	HelmReleaseSecretType = "helm.sh/release.v1"
+	helmReleasePrefix     = "sh.helm.release.v1."
+	helmStatusDeployed    = "deployed"
+)
+
+// Where a certificate was found in a Helm release
+const (
+	HelmSourceValues      = "values"       // values supplied at install or upgrade
+	HelmSourceChartValues = "chart_values" // defaults in the chart's values.yaml
+	HelmSourceManifest    = "manifest"     // rendered manifest
+)
+
+// base64PEMPattern matches a base64-encoded PEM certificate, as rendered by
+// b64enc into the data of a Secret
+var base64PEMPattern = regexp.MustCompile(`LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0t[A-Za-z0-9+/=]*`)
+
+// gzipMagic starts gzip-compressed release data
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// HelmCertificate is a set of certificates found at one place in a release
+type HelmCertificate struct {
+	Source       string                   `json:"source"`   // values, chart_values, or manifest
+	Location     string                   `json:"location"` // values path, or manifest template
+	Certificates []*utils.CertificateInfo `json:"certificates"`
+}
+
+// HelmReleaseCertificates is a Helm release with the certificates that were
+// templated into it
+type HelmReleaseCertificates struct {
+	Release      string            `json:"release"`
+	Namespace    string            `json:"namespace"`
+	Revision     int               `json:"revision"`
+	Status       string            `json:"status,omitempty"`
+	Chart        string            `json:"chart,omitempty"`
+	ChartVersion string            `json:"chart_version,omitempty"`
+	LastDeployed *time.Time        `json:"last_deployed,omitempty"`
+	Secret       string            `json:"secret"`
+	Certificates []HelmCertificate `json:"certificates"`
+	Warnings     []string          `json:"warnings,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// helmRelease holds the fields of a Helm release record used by the scanner
+type helmRelease struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+	Info    struct {
+		Status       string    `json:"status"`
+		LastDeployed time.Time `json:"last_deployed"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"metadata"`
+		Values map[string]interface{} `json:"values"`
+	} `json:"chart"`
+	Config   map[string]interface{} `json:"config"`
+	Manifest string                 `json:"manifest"`
+}
+
+// ScanHelmReleases decodes the Helm v3 release secrets of a namespace and
+// reports the certificates embedded in their values and rendered manifests.
+// Certificates templated in at install time are never renewed by the
+// cluster, so they expire unless the release is upgraded with new values.
+// Only the deployed revision of each release is scanned (or the newest, if
+// none is deployed), unless allRevisions is set.
+func ScanHelmReleases(ctx context.Context, clientset kubernetes.Interface, namespace string, allRevisions bool, warningDays int) ([]HelmReleaseCertificates, error) {
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{FieldSelector: "type=" + HelmReleaseSecretType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Helm release secrets in namespace %s: %w", namespace, err)
+	}
+
+	var selected []*corev1.Secret
+	current := make(map[string]*corev1.Secret) // release name -> revision to scan
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if secret.Type != HelmReleaseSecretType || !strings.HasPrefix(secret.Name, helmReleasePrefix) {
+			continue
+		}
+		if allRevisions {
+			selected = append(selected, secret)
+			continue
+		}
+		name := helmReleaseName(secret)
+		if previous, ok := current[name]; !ok || preferRevision(secret, previous) {
+			current[name] = secret
+		}
+	}
+	for _, secret := range current {
+		selected = append(selected, secret)
+	}
+
+	releases := make([]HelmReleaseCertificates, 0, len(selected))
+	for _, secret := range selected {
+		releases = append(releases, scanHelmReleaseSecret(secret, warningDays))
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		if releases[i].Release != releases[j].Release {
+			return releases[i].Release < releases[j].Release
+		}
+		return releases[i].Revision > releases[j].Revision
+	})
+	return releases, nil
+}
+
+// helmReleaseName returns the release name of a release secret from its
+// labels, or from the secret name if the labels were removed
+func helmReleaseName(secret *corev1.Secret) string {
+	if name := secret.Labels["name"]; name != "" {
+		return name
+	}
+	name := strings.TrimPrefix(secret.Name, helmReleasePrefix)
+	if i := strings.LastIndex(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// helmRevision returns the revision of a release secret from its labels
+func helmRevision(secret *corev1.Secret) int {
+	revision, _ := strconv.Atoi(secret.Labels["version"])
+	return revision
+}
+
+// preferRevision reports whether candidate should be scanned instead of
+// current: the deployed revision wins, then the newest one
+func preferRevision(candidate, current *corev1.Secret) bool {
+	candidateDeployed := candidate.Labels["status"] == helmStatusDeployed
+	currentDeployed := current.Labels["status"] == helmStatusDeployed
+	if candidateDeployed != currentDeployed {
+		return candidateDeployed
+	}
+	return helmRevision(candidate) > helmRevision(current)
+}
+
+// scanHelmReleaseSecret decodes a release secret and extracts its
+// certificates
+func scanHelmReleaseSecret(secret *corev1.Secret, warningDays int) HelmReleaseCertificates {
+	result := HelmReleaseCertificates{
+		Release:      helmReleaseName(secret),
+		Namespace:    secret.Namespace,
+		Revision:     helmRevision(secret),
+		Status:       secret.Labels["status"],
+		Secret:       secret.Name,
+		Certificates: []HelmCertificate{},
+	}
+
+	release, err := decodeHelmRelease(secret.Data["release"])
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if release.Name != "" {
+		result.Release = release.Name
+	}
+	if release.Version > 0 {
+		result.Revision = release.Version
+	}
+	if release.Info.Status != "" {
+		result.Status = release.Info.Status
+	}
+	result.Chart = release.Chart.Metadata.Name
+	result.ChartVersion = release.Chart.Metadata.Version
+	if !release.Info.LastDeployed.IsZero() {
+		deployed := release.Info.LastDeployed
+		result.LastDeployed = &deployed
+	}
+
+	// The same certificate usually appears both in the values and in the
+	// manifest rendered from them; each is reported once, at its first
+	// location
+	seen := make(map[string]bool)
+	add := func(source, location string, certs []*utils.CertificateInfo) {
+		var unique []*utils.CertificateInfo
+		for _, cert := range certs {
+			key := cert.Issuer + "/" + cert.SerialNumber
+			if !seen[key] {
+				seen[key] = true
+				unique = append(unique, cert)
+			}
+		}
+		if len(unique) > 0 {
+			result.Certificates = append(result.Certificates, HelmCertificate{Source: source, Location: location, Certificates: unique})
+		}
+	}
+
+	walkHelmValues(release.Config, "", func(path, value string) {
+		add(HelmSourceValues, path, embeddedCertificates(value))
+	})
+	for _, document := range strings.Split(release.Manifest, "\n---") {
+		add(HelmSourceManifest, manifestTemplate(document), embeddedCertificates(document))
+	}
+	walkHelmValues(release.Chart.Values, "", func(path, value string) {
+		add(HelmSourceChartValues, path, embeddedCertificates(value))
+	})
+
+	for _, found := range result.Certificates {
+		for _, warning := range utils.ValidateCertificateExpiry(found.Certificates, warningDays) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s %s: %s", found.Source, found.Location, warning))
+		}
+	}
+	return result
+}
+
+// decodeHelmRelease decodes the release field of a release secret: Helm
+// stores the release JSON gzip-compressed and base64-encoded, on top of the
+// secret's own encoding
+func decodeHelmRelease(data []byte) (*helmRelease, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("secret has no release data")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode release data: %w", err)
+	}
+	if bytes.HasPrefix(decoded, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(decoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress release data: %w", err)
+		}
+		defer reader.Close()
+		if decoded, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("failed to decompress release data: %w", err)
+		}
+	}
+
+	var release helmRelease
+	if err := json.Unmarshal(decoded, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	return &release, nil
+}
+
+// walkHelmValues calls fn with the dotted path of every string in a values
+// tree, visiting map keys in sorted order
+func walkHelmValues(value interface{}, path string, fn func(path, value string)) {
+	switch v := value.(type) {
+	case string:
+		fn(path, v)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			walkHelmValues(v[key], child, fn)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walkHelmValues(item, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	}
+}
+
+// embeddedCertificates returns the certificates in text, either as PEM
+// blocks (possibly indented, as in a YAML block scalar) or base64-encoded
+// PEM
+func embeddedCertificates(text string) []*utils.CertificateInfo {
+	var certs []*utils.CertificateInfo
+	if strings.Contains(text, "-----BEGIN CERTIFICATE-----") {
+		lines := strings.Split(text, "\n")
+		for i := range lines {
+			lines[i] = strings.TrimSpace(lines[i])
+		}
+		if parsed, err := utils.ParseCertificateBundle(strings.Join(lines, "\n")); err == nil {
+			certs = append(certs, parsed...)
+		}
+	}
+	for _, encoded := range base64PEMPattern.FindAllString(text, -1) {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		if parsed, err := utils.ParseCertificateBundle(string(decoded)); err == nil {
+			certs = append(certs, parsed...)
+		}
+	}
+	return certs
+}
+
+// manifestTemplate names a rendered manifest document by the template it was
+// rendered from, which Helm records in a "# Source:" comment
+func manifestTemplate(document string) string {
+	for _, line := range strings.Split(document, "\n") {
+		if source, ok := strings.CutPrefix(strings.TrimSpace(line), "# Source: "); ok {
+			return source
+		}
+	}
+	return "manifest"
+}
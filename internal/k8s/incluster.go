@@ -0,0 +1,83 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"k8s-web-service/internal/config"
+)
+
+// Files of the service account mounted into every pod
+const (
+	serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceAccountCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// InCluster reports whether clients authenticate with the service account
+// of the pod the service runs in. In auto mode this is the case when the
+// pod's service account is mounted and no kubeconfig was set explicitly
+// with kubernetes.kubeconfig_path, --kubeconfig, or $KUBECONFIG.
+func InCluster(cfg *config.Config) bool {
+	switch cfg.Kubernetes.Auth {
+	case config.KubernetesAuthInCluster:
+		return true
+	case config.KubernetesAuthKubeconfig:
+		return false
+	}
+	if cfg.Kubernetes.KubeconfigPath != "" || os.Getenv("KUBECONFIG") != "" {
+		return false
+	}
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" || os.Getenv("KUBERNETES_SERVICE_PORT") == "" {
+		return false
+	}
+	_, err := os.Stat(serviceAccountTokenFile)
+	return err == nil
+}
+
+// newInClusterClient creates a client that authenticates with the pod's
+// service account token, which client-go reads again as the kubelet
+// rotates it. No EKS token is generated; the cluster name is taken from
+// kubernetes.cluster_name and the region from aws.region, for the AWS
+// endpoints.
+func newInClusterClient(cfg *config.Config) (*Client, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load in-cluster configuration: %w", err)
+	}
+	restConfig.WrapTransport = transportWrapper(cfg.ReadOnly)
+
+	clusterCA, err := os.ReadFile(restConfig.TLSClientConfig.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA %s: %w", restConfig.TLSClientConfig.CAFile, err)
+	}
+	eksDetails := &KubeConfigEKSDetails{
+		ClusterName:     cfg.Kubernetes.ClusterName,
+		ClusterEndpoint: restConfig.Host,
+		ClusterCA:       string(clusterCA),
+	}
+	ResolveClusterAWS(cfg, eksDetails)
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
+	}
+	return &Client{
+		clientset:     clientset,
+		config:        restConfig,
+		appConfig:     cfg,
+		clusterConfig: ClusterConfig(cfg, eksDetails),
+		eksDetails:    eksDetails,
+	}, nil
+}
+
+// loadInClusterCA returns the cluster CA of the pod's service account
+func loadInClusterCA() (string, error) {
+	data, err := os.ReadFile(serviceAccountCAFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account CA %s: %w", serviceAccountCAFile, err)
+	}
+	return string(data), nil
+}
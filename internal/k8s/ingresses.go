@@ -0,0 +1,200 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// AnnotationALBCertificateARN lists the ACM certificates of an AWS Load
+// Balancer Controller Ingress
+const AnnotationALBCertificateARN = "alb.ingress.kubernetes.io/certificate-arn"
+
+// Kinds of resources referencing certificates
+const (
+	ReferenceIngress = "Ingress"
+	ReferenceService = "Service"
+)
+
+// CertificateReference is an Ingress or Service referencing certificates,
+// by ACM ARN annotation or, for Ingresses, by TLS secret
+type CertificateReference struct {
+	Kind       string   `json:"kind"`
+	Namespace  string   `json:"namespace"`
+	Name       string   `json:"name"`
+	ARNs       []string `json:"certificate_arns,omitempty"`
+	TLSSecrets []string `json:"tls_secrets,omitempty"`
+	Hosts      []string `json:"hosts,omitempty"`
+}
+
+// InClusterCertificate is a certificate served from a TLS secret referenced
+// by an Ingress
+type InClusterCertificate struct {
+	Namespace string                   `json:"namespace"`
+	Secret    string                   `json:"secret"`
+	Ingress   string                   `json:"ingress"`
+	Hosts     []string                 `json:"hosts,omitempty"`
+	Cert      *utils.CertificateInfo   `json:"certificate,omitempty"`
+	Chain     []*utils.CertificateInfo `json:"chain,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// LoadBalancerFrontend is an Ingress or Service exposed through a cloud load
+// balancer
+type LoadBalancerFrontend struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Hostname  string `json:"hostname"`
+}
+
+// ListLoadBalancerFrontends lists the load balancer hostnames of the
+// Ingresses and LoadBalancer Services of a namespace, or of all namespaces
+// when namespace is empty
+func ListLoadBalancerFrontends(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]LoadBalancerFrontend, error) {
+	var frontends []LoadBalancerFrontend
+
+	ingresses, err := clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+	for _, ingress := range ingresses.Items {
+		for _, lb := range ingress.Status.LoadBalancer.Ingress {
+			if lb.Hostname != "" {
+				frontends = append(frontends, LoadBalancerFrontend{Kind: ReferenceIngress, Namespace: ingress.Namespace, Name: ingress.Name, Hostname: lb.Hostname})
+			}
+		}
+	}
+
+	services, err := clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	for _, svc := range services.Items {
+		for _, lb := range svc.Status.LoadBalancer.Ingress {
+			if lb.Hostname != "" {
+				frontends = append(frontends, LoadBalancerFrontend{Kind: ReferenceService, Namespace: svc.Namespace, Name: svc.Name, Hostname: lb.Hostname})
+			}
+		}
+	}
+	return frontends, nil
+}
+
+// ListCertificateReferences lists the Ingresses and Services of a namespace,
+// or of all namespaces when namespace is empty, that reference ACM
+// certificates through annotations or TLS secrets through spec.tls
+func ListCertificateReferences(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]CertificateReference, error) {
+	var refs []CertificateReference
+
+	ingresses, err := clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+	for _, ingress := range ingresses.Items {
+		ref := CertificateReference{
+			Kind:      ReferenceIngress,
+			Namespace: ingress.Namespace,
+			Name:      ingress.Name,
+			ARNs:      splitARNs(ingress.Annotations[AnnotationALBCertificateARN]),
+		}
+		for _, tls := range ingress.Spec.TLS {
+			if tls.SecretName != "" {
+				ref.TLSSecrets = append(ref.TLSSecrets, tls.SecretName)
+			}
+			ref.Hosts = append(ref.Hosts, tls.Hosts...)
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host != "" && !containsString(ref.Hosts, rule.Host) {
+				ref.Hosts = append(ref.Hosts, rule.Host)
+			}
+		}
+		if len(ref.ARNs) > 0 || len(ref.TLSSecrets) > 0 {
+			refs = append(refs, ref)
+		}
+	}
+
+	services, err := clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	for i := range services.Items {
+		info := serviceTLSInfo(&services.Items[i])
+		if info.AWS == nil || len(info.AWS.CertificateARNs) == 0 {
+			continue
+		}
+		refs = append(refs, CertificateReference{
+			Kind:      ReferenceService,
+			Namespace: info.Namespace,
+			Name:      info.Name,
+			ARNs:      info.AWS.CertificateARNs,
+			Hosts:     info.AWS.LoadBalancerHosts,
+		})
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		a, b := refs[i], refs[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return refs, nil
+}
+
+// IngressTLSCertificates reads the TLS secrets referenced by Ingresses and
+// parses their certificates. Secrets that cannot be read are reported with
+// an error.
+func IngressTLSCertificates(ctx context.Context, clientset kubernetes.Interface, refs []CertificateReference) []InClusterCertificate {
+	var certs []InClusterCertificate
+	for _, ref := range refs {
+		for _, secretName := range ref.TLSSecrets {
+			cert := InClusterCertificate{Namespace: ref.Namespace, Secret: secretName, Ingress: ref.Name, Hosts: ref.Hosts}
+			secret, err := clientset.CoreV1().Secrets(ref.Namespace).Get(ctx, secretName, metav1.GetOptions{})
+			if err != nil {
+				cert.Error = fmt.Sprintf("failed to get secret: %v", err)
+				certs = append(certs, cert)
+				continue
+			}
+			chain, err := utils.ParseCertificateBundle(string(secret.Data[corev1.TLSCertKey]))
+			if err != nil {
+				cert.Error = fmt.Sprintf("failed to parse %s: %v", corev1.TLSCertKey, err)
+			} else {
+				cert.Cert = chain[0]
+				cert.Chain = chain[1:]
+			}
+			certs = append(certs, cert)
+		}
+	}
+	return certs
+}
+
+// splitARNs splits a comma-separated certificate ARN annotation
+func splitARNs(annotation string) []string {
+	var arns []string
+	for _, arn := range strings.Split(annotation, ",") {
+		if arn = strings.TrimSpace(arn); arn != "" {
+			arns = append(arns, arn)
+		}
+	}
+	return arns
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// ExecPluginInfo describes the credential plugin configured for a kubeconfig user.
+// Only environment variable names are reported since values may hold secrets.
+type ExecPluginInfo struct {
+	Command    string   `json:"command"`
+	Args       []string `json:"args,omitempty"`
+	APIVersion string   `json:"api_version,omitempty"`
+	EnvNames   []string `json:"env_names,omitempty"`
+}
+
+// ContextInspection is the offline analysis of a single kubeconfig context
+type ContextInspection struct {
+	Name                   string                   `json:"name"`
+	Current                bool                     `json:"current"`
+	Cluster                string                   `json:"cluster"`
+	Server                 string                   `json:"server"`
+	User                   string                   `json:"user"`
+	ClusterCA              []*utils.CertificateInfo `json:"cluster_ca,omitempty"`
+	ClusterCAError         string                   `json:"cluster_ca_error,omitempty"`
+	ClientCertificate      []*utils.CertificateInfo `json:"client_certificate,omitempty"`
+	ClientCertificateError string                   `json:"client_certificate_error,omitempty"`
+	Exec                   *ExecPluginInfo          `json:"exec,omitempty"`
+	AuthMethod             string                   `json:"auth_method"`
+}
+
+// InspectKubeconfig analyzes every context of a kubeconfig file without
+// contacting any cluster: the cluster CA, the user's client certificate (if
+// any), and the authentication method, including the exec plugin
+func InspectKubeconfig(kubeconfigPath string) ([]ContextInspection, error) {
+	if kubeconfigPath == "" {
+		return nil, fmt.Errorf("kubeconfig path is empty")
+	}
+
+	kubeconfig, err := clientcmd.LoadFromFile(kubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig from %s: %w", kubeconfigPath, err)
+	}
+
+	var names []string
+	for name := range kubeconfig.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var inspections []ContextInspection
+	for _, name := range names {
+		context := kubeconfig.Contexts[name]
+		inspection := ContextInspection{
+			Name:    name,
+			Current: name == kubeconfig.CurrentContext,
+			Cluster: context.Cluster,
+			User:    context.AuthInfo,
+		}
+
+		if cluster, ok := kubeconfig.Clusters[context.Cluster]; ok {
+			inspection.Server = cluster.Server
+			inspection.ClusterCA, inspection.ClusterCAError = inspectCertificates(cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+			if len(inspection.ClusterCA) == 0 && inspection.ClusterCAError == "" {
+				inspection.ClusterCAError = "no certificate authority configured"
+			}
+		} else {
+			inspection.ClusterCAError = fmt.Sprintf("cluster %s not found in kubeconfig", context.Cluster)
+		}
+
+		if user, ok := kubeconfig.AuthInfos[context.AuthInfo]; ok {
+			inspection.ClientCertificate, inspection.ClientCertificateError = inspectCertificates(user.ClientCertificateData, user.ClientCertificate)
+			inspection.Exec = execPluginInfo(user)
+			inspection.AuthMethod = authMethod(user)
+		} else {
+			inspection.AuthMethod = "none"
+		}
+
+		inspections = append(inspections, inspection)
+	}
+
+	return inspections, nil
+}
+
+// inspectCertificates parses inline PEM data, or the referenced file when no
+// data is inlined. It returns no certificates and no error if neither is set.
+func inspectCertificates(data []byte, file string) ([]*utils.CertificateInfo, string) {
+	if len(data) == 0 && file == "" {
+		return nil, ""
+	}
+
+	if len(data) == 0 {
+		var err error
+		if data, err = os.ReadFile(file); err != nil {
+			return nil, fmt.Sprintf("failed to read %s: %v", file, err)
+		}
+	}
+
+	certs, err := utils.ParseCertificateBundle(string(data))
+	if err != nil {
+		return nil, fmt.Sprintf("failed to parse certificate: %v", err)
+	}
+	return certs, ""
+}
+
+// execPluginInfo summarizes the exec credential plugin of a user, if any
+func execPluginInfo(user *clientcmdapi.AuthInfo) *ExecPluginInfo {
+	if user.Exec == nil {
+		return nil
+	}
+
+	info := &ExecPluginInfo{
+		Command:    user.Exec.Command,
+		Args:       user.Exec.Args,
+		APIVersion: user.Exec.APIVersion,
+	}
+	for _, env := range user.Exec.Env {
+		info.EnvNames = append(info.EnvNames, env.Name)
+	}
+	return info
+}
+
+// authMethod names how a kubeconfig user authenticates
+func authMethod(user *clientcmdapi.AuthInfo) string {
+	switch {
+	case user.Exec != nil:
+		return "exec"
+	case len(user.ClientCertificateData) > 0 || user.ClientCertificate != "":
+		return "client-certificate"
+	case user.Token != "" || user.TokenFile != "":
+		return "token"
+	case user.AuthProvider != nil:
+		return "auth-provider:" + user.AuthProvider.Name
+	case user.Username != "":
+		return "basic"
+	default:
+		return "none"
+	}
+}
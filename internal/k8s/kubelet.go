@@ -0,0 +1,211 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// Kubelet rotation health
+const (
+	KubeletHealthy  = "healthy"
+	KubeletWarning  = "warning"
+	KubeletCritical = "critical"
+	KubeletUnknown  = "unknown"
+)
+
+// Where a kubelet certificate was read
+const (
+	KubeletCertFromCSR   = "csr"        // the certificate issued for the node's latest CSR
+	KubeletCertFromAgent = "node_agent" // the file on the node, reported by the node agent
+)
+
+// KubeletConfigz holds the certificate settings of a kubelet's running
+// configuration
+type KubeletConfigz struct {
+	RotateCertificates *bool  `json:"rotateCertificates"`
+	ServerTLSBootstrap *bool  `json:"serverTLSBootstrap"`
+	TLSCertFile        string `json:"tlsCertFile"`
+}
+
+// KubeletCertificate is the current client or serving certificate of a
+// kubelet
+type KubeletCertificate struct {
+	Source      string                 `json:"source"` // csr or node_agent
+	Certificate *utils.CertificateInfo `json:"certificate"`
+}
+
+// NodeKubeletCertificates are the kubelet certificates read on a node
+type NodeKubeletCertificates struct {
+	Client  *utils.CertificateInfo
+	Serving *utils.CertificateInfo
+}
+
+// NodeKubeletRotation is the certificate rotation health of a kubelet
+type NodeKubeletRotation struct {
+	Node               string              `json:"node"`
+	Ready              bool                `json:"ready"`
+	KubeletVersion     string              `json:"kubelet_version"`
+	RotateCertificates *bool               `json:"rotate_certificates"`
+	ServerTLSBootstrap *bool               `json:"server_tls_bootstrap"`
+	ConfigzError       string              `json:"configz_error,omitempty"`
+	ClientCertificate  *KubeletCertificate `json:"client_certificate,omitempty"`
+	ServingCertificate *KubeletCertificate `json:"serving_certificate,omitempty"`
+	PendingServingCSRs int                 `json:"pending_serving_csrs"`
+	Status             string              `json:"status"`
+	Issues             []string            `json:"issues,omitempty"`
+}
+
+// AnalyzeKubeletRotation reads the running configuration of every kubelet
+// from /configz, proxied through the API server, and reports whether client
+// and serving certificate rotation is enabled with the expiry of the current
+// certificates. The certificates are taken from onNode, read on the node by
+// the node agent, or else from the node's latest issued CSR, which is only
+// retained for about an hour after issuance.
+func AnalyzeKubeletRotation(ctx context.Context, clientset kubernetes.Interface, warningDays int, onNode map[string]NodeKubeletCertificates) ([]NodeKubeletRotation, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	csrsByNode := make(map[string][]certificatesv1.CertificateSigningRequest)
+	csrs, csrErr := clientset.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+	if csrErr == nil {
+		for _, csr := range csrs.Items {
+			if node, ok := strings.CutPrefix(csr.Spec.Username, "system:node:"); ok {
+				csrsByNode[node] = append(csrsByNode[node], csr)
+			}
+		}
+	}
+
+	var results []NodeKubeletRotation
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		result := NodeKubeletRotation{
+			Node:           node.Name,
+			Ready:          nodeReady(node),
+			KubeletVersion: node.Status.NodeInfo.KubeletVersion,
+		}
+
+		configz, err := getKubeletConfigz(ctx, clientset, node.Name)
+		if err != nil {
+			result.ConfigzError = err.Error()
+		} else {
+			result.RotateCertificates = configz.RotateCertificates
+			result.ServerTLSBootstrap = configz.ServerTLSBootstrap
+		}
+
+		certs := onNode[node.Name]
+		if certs.Client != nil {
+			result.ClientCertificate = &KubeletCertificate{Source: KubeletCertFromAgent, Certificate: certs.Client}
+		} else if cert := latestIssuedCertificate(csrsByNode[node.Name], signerKubeletClient); cert != nil {
+			result.ClientCertificate = &KubeletCertificate{Source: KubeletCertFromCSR, Certificate: cert}
+		}
+		if certs.Serving != nil {
+			result.ServingCertificate = &KubeletCertificate{Source: KubeletCertFromAgent, Certificate: certs.Serving}
+		} else if cert := latestIssuedCertificate(csrsByNode[node.Name], signerKubeletServing); cert != nil {
+			result.ServingCertificate = &KubeletCertificate{Source: KubeletCertFromCSR, Certificate: cert}
+		}
+		for j := range csrsByNode[node.Name] {
+			csr := &csrsByNode[node.Name][j]
+			if csr.Spec.SignerName == signerKubeletServing && csrCondition(csr) == "Pending" {
+				result.PendingServingCSRs++
+			}
+		}
+
+		result.Status, result.Issues = kubeletRotationHealth(&result, warningDays)
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Node < results[j].Node })
+	return results, nil
+}
+
+// getKubeletConfigz reads the running configuration of a kubelet through
+// the API server's node proxy, which requires get on nodes/proxy
+func getKubeletConfigz(ctx context.Context, clientset kubernetes.Interface, node string) (*KubeletConfigz, error) {
+	restClient := clientset.CoreV1().RESTClient()
+	if restClient == nil {
+		return nil, fmt.Errorf("kubelet configuration cannot be read with this client")
+	}
+	raw, err := restClient.Get().AbsPath("/api/v1/nodes", node, "proxy", "configz").DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubelet configz: %w", err)
+	}
+
+	var configz struct {
+		KubeletConfig KubeletConfigz `json:"kubeletconfig"`
+	}
+	if err := json.Unmarshal(raw, &configz); err != nil {
+		return nil, fmt.Errorf("failed to decode kubelet configz: %w", err)
+	}
+	return &configz.KubeletConfig, nil
+}
+
+// latestIssuedCertificate returns the certificate issued for the newest CSR
+// of a signer
+func latestIssuedCertificate(csrs []certificatesv1.CertificateSigningRequest, signer string) *utils.CertificateInfo {
+	var latest *certificatesv1.CertificateSigningRequest
+	for i := range csrs {
+		csr := &csrs[i]
+		if csr.Spec.SignerName != signer || len(csr.Status.Certificate) == 0 {
+			continue
+		}
+		if latest == nil || csr.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = csr
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+	certs, err := utils.ParseCertificateBundle(string(latest.Status.Certificate))
+	if err != nil || len(certs) == 0 {
+		return nil
+	}
+	return certs[0]
+}
+
+// kubeletRotationHealth rates the rotation settings and certificates of a
+// kubelet
+func kubeletRotationHealth(result *NodeKubeletRotation, warningDays int) (string, []string) {
+	status := KubeletHealthy
+	var issues []string
+	raise := func(level, format string, args ...interface{}) {
+		if level == KubeletCritical || status == KubeletHealthy {
+			status = level
+		}
+		issues = append(issues, fmt.Sprintf(format, args...))
+	}
+
+	if client := result.ClientCertificate; client != nil {
+		cert := client.Certificate
+		switch {
+		case cert.IsExpired:
+			raise(KubeletCritical, "Client certificate expired on %s; the kubelet cannot reach the API server", cert.NotAfter.Format("2006-01-02"))
+		case cert.DaysUntilExp <= warningDays && result.RotateCertificates != nil && !*result.RotateCertificates:
+			raise(KubeletCritical, "Client certificate expires in %d days and rotateCertificates is disabled", cert.DaysUntilExp)
+		}
+	}
+	if result.RotateCertificates != nil && !*result.RotateCertificates {
+		raise(KubeletWarning, "rotateCertificates is disabled; the client certificate must be renewed by hand before it expires")
+	}
+	if serving := result.ServingCertificate; serving != nil && serving.Certificate.IsExpired {
+		raise(KubeletCritical, "Serving certificate expired on %s; kubectl logs and exec fail", serving.Certificate.NotAfter.Format("2006-01-02"))
+	}
+	if result.ServerTLSBootstrap != nil && *result.ServerTLSBootstrap && result.PendingServingCSRs > 0 {
+		raise(KubeletWarning, "%d kubelet-serving CSRs are pending; nothing approves serving certificate requests", result.PendingServingCSRs)
+	}
+
+	if result.ConfigzError != "" && status == KubeletHealthy {
+		status = KubeletUnknown
+	}
+	return status, issues
+}
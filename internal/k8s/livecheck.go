@@ -0,0 +1,168 @@
+package k8s
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// LiveCertificateCheck is the certificate chain a TLS endpoint presents
+// when dialed, as opposed to the one stored in a secret
+type LiveCertificateCheck struct {
+	// Target is host:port, or service/{namespace}/{name}:{port} for Services
+	Target    string `json:"target"`
+	Namespace string `json:"namespace,omitempty"`
+	// Address is the host:port dialed, which for a Service is its cluster IP
+	// or, when its load balancer terminates TLS, the load balancer
+	Address    string `json:"address"`
+	ServerName string `json:"server_name,omitempty"`
+	TLSVersion string `json:"tls_version,omitempty"`
+	// Chain is the presented chain in the order it was sent, leaf first
+	Chain []*utils.CertificateInfo `json:"chain"`
+	// HostnameMatches reports whether the leaf certificate covers ServerName
+	HostnameMatches bool `json:"hostname_matches"`
+	// Verification is the chain built from the presented certificates, set
+	// by VerifyChain
+	Verification *utils.ChainVerification `json:"verification,omitempty"`
+	Warnings     []string                 `json:"warnings,omitempty"`
+	Error        string                   `json:"error,omitempty"`
+
+	// pem holds the presented chain for VerifyChain
+	pem string
+}
+
+// VerifyChain builds the presented chain and verifies it against clusterCA
+// and the system roots
+func (c *LiveCertificateCheck) VerifyChain(clusterCA string) {
+	if c.pem != "" {
+		c.Verification = utils.ChainVerify(c.pem, clusterCA)
+	}
+}
+
+// dialCertificateChain performs a TLS handshake with address and returns
+// the chain presented for serverName with the negotiated TLS version. The
+// chain is not verified, so that expired and untrusted chains are still
+// returned.
+func dialCertificateChain(ctx context.Context, address, serverName string) ([]*x509.Certificate, string, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: serviceDialTimeout},
+		Config: &tls.Config{
+			ServerName: serverName,
+			// The presented chain is reported, not trusted
+			InsecureSkipVerify: true,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, "", fmt.Errorf("%s presented no certificate", address)
+	}
+	return state.PeerCertificates, tls.VersionName(state.Version), nil
+}
+
+// CheckLiveCertificate dials host:port and parses the certificate chain it
+// presents with utils.ParseCertificateBundle. The SNI server name defaults
+// to host unless host is an IP address. A failed connection is reported in
+// Error rather than returned.
+func CheckLiveCertificate(ctx context.Context, host string, port int, serverName string, warningDays int) LiveCertificateCheck {
+	if serverName == "" && net.ParseIP(host) == nil {
+		serverName = host
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	check := checkAddress(ctx, address, serverName, warningDays)
+	check.Target = address
+	return check
+}
+
+// checkAddress dials address for serverName and fills in the presented
+// chain, hostname match, and expiry warnings of a LiveCertificateCheck
+func checkAddress(ctx context.Context, address, serverName string, warningDays int) LiveCertificateCheck {
+	check := LiveCertificateCheck{Address: address, ServerName: serverName, Chain: []*utils.CertificateInfo{}}
+	peers, version, err := dialCertificateChain(ctx, address, serverName)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	check.TLSVersion = version
+
+	var bundle strings.Builder
+	for _, peer := range peers {
+		bundle.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: peer.Raw}))
+	}
+	chain, err := utils.ParseCertificateBundle(bundle.String())
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to parse presented chain: %v", err)
+		return check
+	}
+	check.Chain = chain
+	check.pem = bundle.String()
+
+	if serverName != "" {
+		check.HostnameMatches = peers[0].VerifyHostname(serverName) == nil
+		if !check.HostnameMatches {
+			check.Warnings = append(check.Warnings, fmt.Sprintf("the certificate presented does not cover %s", serverName))
+		}
+	}
+	check.Warnings = append(check.Warnings, utils.ValidateCertificateExpiry(chain, warningDays)...)
+	return check
+}
+
+// CheckServiceCertificates dials every TLS port of the Services of a
+// namespace, or of all namespaces if namespace is "", and reports the
+// chains they present. Ports behind a load balancer with an ACM
+// certificate are dialed at the load balancer, where TLS terminates; the
+// others at the cluster IP with the SNI name {name}.{namespace}.svc, which
+// only succeeds from inside the cluster network. Services are dialed one at
+// a time and the dials stop when ctx is done.
+func CheckServiceCertificates(ctx context.Context, clientset kubernetes.Interface, namespace string, warningDays int) ([]LiveCertificateCheck, error) {
+	services, err := ListServiceTLS(ctx, clientset, namespace, true)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := []LiveCertificateCheck{}
+	for _, svc := range services {
+		for _, port := range svc.Ports {
+			if !port.TLS || port.Protocol != "TCP" {
+				continue
+			}
+			target := fmt.Sprintf("service/%s/%s:%d", svc.Namespace, svc.Name, port.Port)
+			host, serverName := svc.ClusterIP, fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace)
+			if svc.AWS != nil && len(svc.AWS.CertificateARNs) > 0 && len(svc.AWS.LoadBalancerHosts) > 0 {
+				// The load balancer's DNS name is not among the names of the
+				// certificate, so no name is sent or matched
+				host, serverName = svc.AWS.LoadBalancerHosts[0], ""
+			}
+
+			var check LiveCertificateCheck
+			switch {
+			case ctx.Err() != nil:
+				check = LiveCertificateCheck{Chain: []*utils.CertificateInfo{}, Error: "not dialed, the request was canceled"}
+			case host == "" || host == "None":
+				check = LiveCertificateCheck{Chain: []*utils.CertificateInfo{}, Error: "the service has no cluster IP"}
+			default:
+				check = checkAddress(ctx, net.JoinHostPort(host, strconv.Itoa(int(port.Port))), serverName, warningDays)
+			}
+			check.Target = target
+			check.Namespace = svc.Namespace
+			checks = append(checks, check)
+		}
+	}
+	sort.SliceStable(checks, func(i, j int) bool { return checks[i].Namespace < checks[j].Namespace })
+	return checks, nil
+}
@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"sync"
+
+	"k8s-web-service/internal/config"
+)
+
+// ClientManager shares one Kubernetes client per cluster between requests,
+// so that they reuse its connection pool instead of parsing the kubeconfig
+// and generating an EKS token each time. A client refreshes its token when
+// it nears expiry, and every client is replaced when the configuration is
+// reloaded.
+type ClientManager struct {
+	mu      sync.Mutex
+	cfg     *config.Config     // the configuration the clients were created with
+	clients map[string]*Client // by clusters entry, "" for the default cluster
+}
+
+// NewClientManager creates a manager without a client; the first request
+// for each cluster creates its client
+func NewClientManager() *ClientManager {
+	return &ClientManager{clients: make(map[string]*Client)}
+}
+
+// Client returns the shared client for the current kubeconfig context,
+// creating it on first use and when cfg is not the configuration it was
+// created with. Failures are not cached, so the next request tries again.
+func (m *ClientManager) Client(cfg *config.Config) (*Client, error) {
+	return m.ClientFor(cfg, "")
+}
+
+// ClientFor returns the shared client of an entry of the clusters setting,
+// or of the current kubeconfig context if cluster is empty, like Client
+func (m *ClientManager) ClientFor(cfg *config.Config, cluster string) (*Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cfg != cfg {
+		m.cfg, m.clients = cfg, make(map[string]*Client)
+	}
+	if client, ok := m.clients[cluster]; ok {
+		return client, nil
+	}
+	client, err := NewClientForCluster(cfg, cluster)
+	if err != nil {
+		return nil, err
+	}
+	m.clients[cluster] = client
+	return client, nil
+}
@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ListNamespaceNames returns the names of every namespace of the cluster,
+// sorted
+func ListNamespaceNames(ctx context.Context, clientset kubernetes.Interface) ([]string, error) {
+	list, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	namespaces := make([]string, 0, len(list.Items))
+	for _, namespace := range list.Items {
+		namespaces = append(namespaces, namespace.Name)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// MapNamespaces calls analyze for every namespace, at most concurrency of
+// them at once, and returns the results and errors in the order of
+// namespaces. Namespaces not yet started when ctx is done fail with its
+// error.
+func MapNamespaces[T any](ctx context.Context, namespaces []string, concurrency int, analyze func(ctx context.Context, namespace string) (T, error)) ([]T, []error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]T, len(namespaces))
+	errs := make([]error, len(namespaces))
+
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, namespace := range namespaces {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, namespace string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			results[i], errs[i] = analyze(ctx, namespace)
+		}(i, namespace)
+	}
+	wg.Wait()
+	return results, errs
+}
@@ -0,0 +1,192 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Kubelet certificate signers
+const (
+	signerKubeletClient  = "kubernetes.io/kube-apiserver-client-kubelet"
+	signerKubeletServing = "kubernetes.io/kubelet-serving"
+)
+
+// Rotation statuses derived from CSR activity
+const (
+	RotationObserved    = "observed"     // the node has requested a certificate through a CSR
+	RotationNotObserved = "not_observed" // no CSR from the node is retained
+	RotationUnknown     = "unknown"      // CSRs could not be listed
+)
+
+// nodeGroupLabels identify the EKS managed nodegroup or Karpenter node pool
+// of a node
+var nodeGroupLabels = []string{"eks.amazonaws.com/nodegroup", "karpenter.sh/nodepool", "alpha.eksctl.io/nodegroup-name"}
+
+// KubeletCSRInfo summarizes the most recent certificate signing request of a
+// kubelet for one signer
+type KubeletCSRInfo struct {
+	Name      string    `json:"name"`
+	Created   time.Time `json:"created"`
+	Condition string    `json:"condition"` // Approved, Denied, Failed, or Pending
+	Issued    bool      `json:"issued"`
+}
+
+// NodeRotationInfo describes whether kubelet certificate rotation appears to
+// be enabled, based on the CSRs the node has submitted
+type NodeRotationInfo struct {
+	ClientRotation  string          `json:"client_rotation"`
+	ServingRotation string          `json:"serving_rotation"`
+	LastClientCSR   *KubeletCSRInfo `json:"last_client_csr,omitempty"`
+	LastServingCSR  *KubeletCSRInfo `json:"last_serving_csr,omitempty"`
+	PendingCSRs     int             `json:"pending_csrs"`
+}
+
+// NodeInfo describes a node and its kubelet
+type NodeInfo struct {
+	Name             string           `json:"name"`
+	Ready            bool             `json:"ready"`
+	KubeletVersion   string           `json:"kubelet_version"`
+	OSImage          string           `json:"os_image"`
+	OperatingSystem  string           `json:"operating_system"`
+	Architecture     string           `json:"architecture"`
+	KernelVersion    string           `json:"kernel_version"`
+	ContainerRuntime string           `json:"container_runtime"`
+	NodeGroup        string           `json:"node_group,omitempty"`
+	InstanceType     string           `json:"instance_type,omitempty"`
+	Created          time.Time        `json:"created"`
+	Rotation         NodeRotationInfo `json:"certificate_rotation"`
+}
+
+// NodeInventory lists the nodes of the cluster with their kubelet details
+type NodeInventory struct {
+	Nodes           []NodeInfo     `json:"nodes"`
+	KubeletVersions map[string]int `json:"kubelet_versions"`
+	// CSRError is set when CSRs could not be listed, in which case the
+	// rotation status of every node is unknown
+	CSRError string `json:"csr_error,omitempty"`
+}
+
+// ListNodeInventory lists nodes with their kubelet version and OS, and
+// infers whether kubelet client and serving certificate rotation is enabled
+// from the certificate signing requests each node has submitted. CSRs are
+// garbage collected after about an hour once issued, so a node that rotated
+// long ago may show not_observed even though rotation is enabled.
+func ListNodeInventory(ctx context.Context, clientset kubernetes.Interface) (*NodeInventory, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	inventory := &NodeInventory{KubeletVersions: make(map[string]int)}
+
+	csrsByNode := make(map[string][]certificatesv1.CertificateSigningRequest)
+	csrs, err := clientset.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		inventory.CSRError = fmt.Sprintf("failed to list certificate signing requests: %v", err)
+	} else {
+		for _, csr := range csrs.Items {
+			if node, ok := strings.CutPrefix(csr.Spec.Username, "system:node:"); ok {
+				csrsByNode[node] = append(csrsByNode[node], csr)
+			}
+		}
+	}
+
+	for _, node := range nodes.Items {
+		info := NodeInfo{
+			Name:             node.Name,
+			Ready:            nodeReady(&node),
+			KubeletVersion:   node.Status.NodeInfo.KubeletVersion,
+			OSImage:          node.Status.NodeInfo.OSImage,
+			OperatingSystem:  node.Status.NodeInfo.OperatingSystem,
+			Architecture:     node.Status.NodeInfo.Architecture,
+			KernelVersion:    node.Status.NodeInfo.KernelVersion,
+			ContainerRuntime: node.Status.NodeInfo.ContainerRuntimeVersion,
+			InstanceType:     node.Labels["node.kubernetes.io/instance-type"],
+			Created:          node.CreationTimestamp.Time,
+		}
+		for _, label := range nodeGroupLabels {
+			if group, ok := node.Labels[label]; ok {
+				info.NodeGroup = group
+				break
+			}
+		}
+
+		if inventory.CSRError != "" {
+			info.Rotation = NodeRotationInfo{ClientRotation: RotationUnknown, ServingRotation: RotationUnknown}
+		} else {
+			info.Rotation = rotationFromCSRs(csrsByNode[node.Name])
+		}
+
+		inventory.KubeletVersions[info.KubeletVersion]++
+		inventory.Nodes = append(inventory.Nodes, info)
+	}
+	sort.Slice(inventory.Nodes, func(i, j int) bool { return inventory.Nodes[i].Name < inventory.Nodes[j].Name })
+
+	return inventory, nil
+}
+
+// rotationFromCSRs summarizes the kubelet CSRs of a single node
+func rotationFromCSRs(csrs []certificatesv1.CertificateSigningRequest) NodeRotationInfo {
+	rotation := NodeRotationInfo{ClientRotation: RotationNotObserved, ServingRotation: RotationNotObserved}
+
+	for i := range csrs {
+		csr := &csrs[i]
+		info := &KubeletCSRInfo{
+			Name:      csr.Name,
+			Created:   csr.CreationTimestamp.Time,
+			Condition: csrCondition(csr),
+			Issued:    len(csr.Status.Certificate) > 0,
+		}
+		if info.Condition == "Pending" {
+			rotation.PendingCSRs++
+		}
+
+		switch csr.Spec.SignerName {
+		case signerKubeletClient:
+			rotation.ClientRotation = RotationObserved
+			if rotation.LastClientCSR == nil || info.Created.After(rotation.LastClientCSR.Created) {
+				rotation.LastClientCSR = info
+			}
+		case signerKubeletServing:
+			rotation.ServingRotation = RotationObserved
+			if rotation.LastServingCSR == nil || info.Created.After(rotation.LastServingCSR.Created) {
+				rotation.LastServingCSR = info
+			}
+		}
+	}
+	return rotation
+}
+
+// csrCondition returns the decisive condition of a CSR
+func csrCondition(csr *certificatesv1.CertificateSigningRequest) string {
+	for _, condition := range csr.Status.Conditions {
+		switch condition.Type {
+		case certificatesv1.CertificateDenied, certificatesv1.CertificateFailed:
+			return string(condition.Type)
+		}
+	}
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == certificatesv1.CertificateApproved {
+			return string(condition.Type)
+		}
+	}
+	return "Pending"
+}
+
+// nodeReady reports whether the node's Ready condition is true
+func nodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
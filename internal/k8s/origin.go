@@ -0,0 +1,152 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Labels and annotations identifying the tool that deployed a workload
+const (
+	labelManagedBy          = "app.kubernetes.io/managed-by"
+	labelInstance           = "app.kubernetes.io/instance"
+	labelHelmChart          = "helm.sh/chart"
+	annotationHelmRelease   = "meta.helm.sh/release-name"
+	annotationHelmNamespace = "meta.helm.sh/release-namespace"
+	annotationArgoTracking  = "argocd.argoproj.io/tracking-id"
+	labelArgoInstance       = "argocd.argoproj.io/instance"
+)
+
+// PodOrigin identifies the workload owning a pod and the Helm release or
+// Argo CD application that deployed it, so findings can be routed to the
+// repository that must be fixed
+type PodOrigin struct {
+	Workload WorkloadRef `json:"workload"`
+	// ManagedBy is the app.kubernetes.io/managed-by label, e.g. Helm
+	ManagedBy string        `json:"managed_by,omitempty"`
+	Helm      *HelmOrigin   `json:"helm,omitempty"`
+	ArgoCD    *ArgoCDOrigin `json:"argocd,omitempty"`
+}
+
+// HelmOrigin is the Helm release a workload belongs to
+type HelmOrigin struct {
+	Release   string `json:"release"`
+	Namespace string `json:"namespace,omitempty"`
+	Chart     string `json:"chart,omitempty"`
+}
+
+// ArgoCDOrigin is the Argo CD application that syncs a workload
+type ArgoCDOrigin struct {
+	Application string `json:"application"`
+	// Namespace is the namespace of the application when it is outside the
+	// Argo CD control plane namespace
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// OriginResolver resolves the origin of the pods of a namespace, reading
+// each top-level workload once
+type OriginResolver struct {
+	workloads *workloadResolver
+	meta      map[WorkloadRef]*metav1.ObjectMeta
+}
+
+// NewOriginResolver creates an origin resolver for the pods of a namespace
+func NewOriginResolver(clientset kubernetes.Interface, namespace string) *OriginResolver {
+	return &OriginResolver{
+		workloads: newWorkloadResolver(clientset, namespace),
+		meta:      make(map[WorkloadRef]*metav1.ObjectMeta),
+	}
+}
+
+// Resolve follows the owner references of a pod up to its Deployment,
+// StatefulSet, DaemonSet, or CronJob and reads the Helm and Argo CD labels
+// and annotations of that workload. When the workload cannot be read, the
+// labels of the pod, which Helm charts usually copy, are used instead.
+func (o *OriginResolver) Resolve(ctx context.Context, pod *corev1.Pod) *PodOrigin {
+	ref := o.workloads.resolve(ctx, pod)
+	origin := &PodOrigin{Workload: ref}
+
+	meta := &pod.ObjectMeta
+	if ref.Kind != WorkloadPod {
+		if workload := o.workloadMeta(ctx, ref); workload != nil {
+			meta = workload
+		}
+	}
+	labels, annotations := meta.Labels, meta.Annotations
+
+	origin.ManagedBy = labels[labelManagedBy]
+	if release := annotations[annotationHelmRelease]; release != "" {
+		origin.Helm = &HelmOrigin{Release: release, Namespace: annotations[annotationHelmNamespace]}
+	} else if strings.EqualFold(origin.ManagedBy, "Helm") && labels[labelInstance] != "" {
+		origin.Helm = &HelmOrigin{Release: labels[labelInstance]}
+	}
+	if origin.Helm != nil {
+		origin.Helm.Chart = labels[labelHelmChart]
+		if origin.Helm.Chart == "" {
+			origin.Helm.Chart = pod.Labels[labelHelmChart]
+		}
+	}
+
+	if tracking := annotations[annotationArgoTracking]; tracking != "" {
+		origin.ArgoCD = argoCDTrackingOrigin(tracking)
+	} else if app := labels[labelArgoInstance]; app != "" {
+		origin.ArgoCD = &ArgoCDOrigin{Application: app}
+	}
+	return origin
+}
+
+// workloadMeta reads the metadata of a top-level workload, or returns nil if
+// it cannot be read
+func (o *OriginResolver) workloadMeta(ctx context.Context, ref WorkloadRef) *metav1.ObjectMeta {
+	if meta, ok := o.meta[ref]; ok {
+		return meta
+	}
+
+	clientset, namespace := o.workloads.clientset, o.workloads.namespace
+	var meta *metav1.ObjectMeta
+	switch ref.Kind {
+	case "Deployment":
+		if workload, err := clientset.AppsV1().Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+			meta = &workload.ObjectMeta
+		}
+	case "StatefulSet":
+		if workload, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+			meta = &workload.ObjectMeta
+		}
+	case "DaemonSet":
+		if workload, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+			meta = &workload.ObjectMeta
+		}
+	case WorkloadReplicaSet:
+		if workload, err := clientset.AppsV1().ReplicaSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+			meta = &workload.ObjectMeta
+		}
+	case "CronJob":
+		if workload, err := clientset.BatchV1().CronJobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+			meta = &workload.ObjectMeta
+		}
+	case WorkloadJob:
+		if workload, err := clientset.BatchV1().Jobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+			meta = &workload.ObjectMeta
+		}
+	}
+	o.meta[ref] = meta
+	return meta
+}
+
+// argoCDTrackingOrigin parses an Argo CD tracking annotation,
+// <application>:<group>/<kind>:<namespace>/<name>, where the application is
+// prefixed with <namespace>_ when it is outside the control plane namespace
+func argoCDTrackingOrigin(tracking string) *ArgoCDOrigin {
+	app, _, _ := strings.Cut(tracking, ":")
+	if app == "" {
+		return nil
+	}
+	if namespace, name, ok := strings.Cut(app, "_"); ok {
+		return &ArgoCDOrigin{Application: name, Namespace: namespace}
+	}
+	return &ArgoCDOrigin{Application: app}
+}
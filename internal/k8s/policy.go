@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/pkg/utils"
+)
+
+// Policy rules
+const (
+	RuleMinDaysRemaining = "min_days_remaining"
+	RuleAllowedIssuers   = "allowed_issuers"
+	RuleMaxValidityDays  = "max_validity_days"
+)
+
+// PolicyResult is the outcome of one rule of a policy in one namespace
+type PolicyResult struct {
+	Policy      string            `json:"policy"`
+	Rule        string            `json:"rule"`
+	Namespace   string            `json:"namespace"`
+	Requirement string            `json:"requirement"`
+	Passed      bool              `json:"passed"`
+	Checked     int               `json:"certificates_checked"`
+	Violations  []PolicyViolation `json:"violations,omitempty"`
+}
+
+// PolicyViolation is a certificate that breaks a rule
+type PolicyViolation struct {
+	Pod         string `json:"pod,omitempty"`
+	Source      string `json:"source"`
+	Subject     string `json:"subject"`
+	Fingerprint string `json:"fingerprint_sha256"`
+	Reason      string `json:"reason"`
+}
+
+// policyCertificate is a certificate of a namespace with where it was found
+type policyCertificate struct {
+	pod, source string
+	cert        *utils.CertificateInfo
+}
+
+// EvaluatePolicies checks the certificates of a namespace expiry report,
+// including custom resources, against the rules of the policies that apply
+// to the namespace. The issuer and validity rules only apply to leaf
+// certificates, so mounted CA bundles do not break them.
+func EvaluatePolicies(policies []config.Policy, report *NamespaceExpiryReport) []PolicyResult {
+	var certs []policyCertificate
+	for _, pod := range report.Pods {
+		var names []string
+		for name := range pod.CertSources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, cert := range pod.CertSources[name].Certificates {
+				certs = append(certs, policyCertificate{pod: pod.PodName, source: name, cert: cert})
+			}
+		}
+	}
+	for _, source := range report.CustomResources {
+		for _, cert := range source.Certificates {
+			certs = append(certs, policyCertificate{source: source.Name, cert: cert})
+		}
+	}
+
+	var results []PolicyResult
+	for _, policy := range policies {
+		if !policy.AppliesTo(report.Namespace) {
+			continue
+		}
+		evaluate := func(rule, requirement string, leavesOnly bool, check func(cert *utils.CertificateInfo) string) {
+			result := PolicyResult{Policy: policy.Name, Rule: rule, Namespace: report.Namespace, Requirement: requirement}
+			for _, c := range certs {
+				if c.cert == nil || (leavesOnly && c.cert.IsCA) {
+					continue
+				}
+				result.Checked++
+				if reason := check(c.cert); reason != "" {
+					result.Violations = append(result.Violations, PolicyViolation{
+						Pod:         c.pod,
+						Source:      c.source,
+						Subject:     c.cert.Subject,
+						Fingerprint: c.cert.Fingerprint,
+						Reason:      reason,
+					})
+				}
+			}
+			result.Passed = len(result.Violations) == 0
+			results = append(results, result)
+		}
+
+		if policy.MinDaysRemaining > 0 {
+			evaluate(RuleMinDaysRemaining, fmt.Sprintf("at least %d days until expiry", policy.MinDaysRemaining), false, func(cert *utils.CertificateInfo) string {
+				if cert.IsExpired {
+					return fmt.Sprintf("expired on %s", cert.NotAfter.Format("2006-01-02"))
+				}
+				if cert.DaysUntilExp < policy.MinDaysRemaining {
+					return fmt.Sprintf("expires in %d days", cert.DaysUntilExp)
+				}
+				return ""
+			})
+		}
+		if len(policy.AllowedIssuers) > 0 {
+			evaluate(RuleAllowedIssuers, "issued by "+strings.Join(policy.AllowedIssuers, " or "), true, func(cert *utils.CertificateInfo) string {
+				for _, issuer := range policy.AllowedIssuers {
+					if strings.Contains(cert.Issuer, issuer) {
+						return ""
+					}
+				}
+				return "issued by " + cert.Issuer
+			})
+		}
+		if policy.MaxValidityDays > 0 {
+			evaluate(RuleMaxValidityDays, fmt.Sprintf("valid for at most %d days", policy.MaxValidityDays), true, func(cert *utils.CertificateInfo) string {
+				if days := int(cert.NotAfter.Sub(cert.NotBefore).Hours() / 24); days > policy.MaxValidityDays {
+					return fmt.Sprintf("valid for %d days", days)
+				}
+				return ""
+			})
+		}
+	}
+	return results
+}
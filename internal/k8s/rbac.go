@@ -0,0 +1,248 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/internal/config"
+)
+
+// Access of the service to a permission
+const (
+	AccessAllowed    = "allowed"
+	AccessDenied     = "denied"
+	AccessRestricted = "restricted" // only for specific resource names
+	AccessUnknown    = "unknown"
+)
+
+// RBACPermission is a Kubernetes permission the service uses
+type RBACPermission struct {
+	Group    string `json:"group,omitempty"`
+	Resource string `json:"resource"`
+	Verb     string `json:"verb"`
+	// ClusterScoped marks cluster-scoped resources, and permissions needed
+	// across all namespaces
+	ClusterScoped bool   `json:"cluster_scoped"`
+	UsedBy        string `json:"used_by"`
+}
+
+// String formats the permission as verb resource.group
+func (p RBACPermission) String() string {
+	if p.Group == "" {
+		return p.Verb + " " + p.Resource
+	}
+	return p.Verb + " " + p.Resource + "." + p.Group
+}
+
+// servicePermissions lists every permission the service uses, in the order
+// they are reported
+var servicePermissions = []RBACPermission{
+	{Resource: "pods", Verb: "list", UsedBy: "/list-pods, /scan, /graphql, /trust-store-validation, /ca-rotation-status, /pod-certificates, /certificate-expiry, /workload-certificates, /stale-certificates, /service-account-tokens, scanner"},
+	{Resource: "pods", Verb: "get", UsedBy: "/pod-certificates/{pod-name}, /certificate-expiry"},
+	{Resource: "secrets", Verb: "get", UsedBy: "/pod-certificates, /graphql, /certificate-expiry, /stale-certificates, /service-account-tokens, /image-ca-bundles, /compare"},
+	{Resource: "secrets", Verb: "list", UsedBy: "/secrets, /secrets-certificates, /helm-certificates, /scan"},
+	{Resource: "configmaps", Verb: "get", UsedBy: "/pod-certificates, /certificate-expiry, /ca-rotation-status, scanner leader election results, daemon --once delivered alerts"},
+	{Resource: "configmaps", Verb: "list", UsedBy: "/configmaps/trust-bundles, /trust-store-validation"},
+	{Resource: "services", Verb: "list", UsedBy: "/services, /live-cert-check/services, /aws/*"},
+	{Resource: "services", Verb: "get", UsedBy: "/compare"},
+	{Resource: "configmaps", Verb: "create", UsedBy: "scanner leader election results, daemon --once delivered alerts"},
+	{Resource: "configmaps", Verb: "update", UsedBy: "scanner leader election results, daemon --once delivered alerts"},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "get", UsedBy: "scanner leader election"},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "create", UsedBy: "scanner leader election"},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "update", UsedBy: "scanner leader election"},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "list", UsedBy: "scanner namespace sharding"},
+	{Group: "coordination.k8s.io", Resource: "leases", Verb: "delete", UsedBy: "scanner namespace sharding"},
+	{Group: "networking.k8s.io", Resource: "ingresses", Verb: "list", UsedBy: "/aws/acm-certificates, /aws/load-balancer-certificates, /scan"},
+	{Group: "apps", Resource: "replicasets", Verb: "get", UsedBy: "/workload-certificates, /stale-certificates, /ca-rotation-status"},
+	{Group: "batch", Resource: "jobs", Verb: "get", UsedBy: "/workload-certificates, /stale-certificates, /ca-rotation-status"},
+	{Group: "apps", Resource: "deployments", Verb: "get", UsedBy: "/pod-certificates, /certificate-expiry, /workload-certificates origin"},
+	{Group: "apps", Resource: "statefulsets", Verb: "get", UsedBy: "/pod-certificates, /certificate-expiry, /workload-certificates origin"},
+	{Group: "apps", Resource: "daemonsets", Verb: "get", UsedBy: "/pod-certificates, /certificate-expiry, /workload-certificates origin"},
+	{Group: "batch", Resource: "cronjobs", Verb: "get", UsedBy: "/pod-certificates, /certificate-expiry, /workload-certificates origin"},
+	{Resource: "namespaces", Verb: "list", ClusterScoped: true, UsedBy: "/namespaces, /test-k8s-auth, scanner of every namespace, namespace=all"},
+	{Resource: "nodes", Verb: "list", ClusterScoped: true, UsedBy: "/nodes, /pod-certificates compute type"},
+	{Resource: "nodes/proxy", Verb: "get", ClusterScoped: true, UsedBy: "/nodes/kubelet-rotation"},
+	{Group: "certificates.k8s.io", Resource: "certificatesigningrequests", Verb: "list", ClusterScoped: true, UsedBy: "/nodes, /nodes/kubelet-rotation"},
+	{Group: "admissionregistration.k8s.io", Resource: "mutatingwebhookconfigurations", Verb: "list", ClusterScoped: true, UsedBy: "/system-certificates, /webhook-ca-bundles, /scan"},
+	{Group: "admissionregistration.k8s.io", Resource: "validatingwebhookconfigurations", Verb: "list", ClusterScoped: true, UsedBy: "/system-certificates, /webhook-ca-bundles, /scan"},
+	{Group: "apiregistration.k8s.io", Resource: "apiservices", Verb: "get", ClusterScoped: true, UsedBy: "/system-certificates"},
+	{Group: "apiregistration.k8s.io", Resource: "apiservices", Verb: "list", ClusterScoped: true, UsedBy: "/webhook-ca-bundles"},
+	{Resource: "pods", Verb: "list", ClusterScoped: true, UsedBy: "/hostpath-certificates without a namespace"},
+}
+
+// RBACMatrixRow is the access of the service to one permission, cluster-wide
+// or per namespace
+type RBACMatrixRow struct {
+	RBACPermission
+	ClusterAccess string            `json:"cluster,omitempty"`
+	Namespaces    map[string]string `json:"namespaces,omitempty"`
+}
+
+// RBACRule is a rule granted to the service in a namespace
+type RBACRule struct {
+	Verbs         []string `json:"verbs"`
+	APIGroups     []string `json:"api_groups,omitempty"`
+	Resources     []string `json:"resources,omitempty"`
+	ResourceNames []string `json:"resource_names,omitempty"`
+}
+
+// RBACReport is the effective access of the service's identity
+type RBACReport struct {
+	Namespaces []string              `json:"namespaces"`
+	Matrix     []RBACMatrixRow       `json:"matrix"`
+	Rules      map[string][]RBACRule `json:"rules"`
+	// Incomplete lists the namespaces whose rules review was incomplete,
+	// with the reason; their denied cells were confirmed with access reviews
+	Incomplete map[string]string `json:"incomplete,omitempty"`
+	Errors     []string          `json:"errors,omitempty"`
+}
+
+// AnalyzeRBAC reports what the service's identity may do in each namespace,
+// from a SelfSubjectRulesReview per namespace, and cluster-wide, from a
+// SelfSubjectAccessReview per cluster-scoped permission. Rules reviews are
+// incomplete when an authorizer other than RBAC takes part, such as the
+// EKS access entry webhook; denied cells in those namespaces are then
+// checked with access reviews.
+func AnalyzeRBAC(ctx context.Context, clientset kubernetes.Interface, namespaces []string) *RBACReport {
+	report := &RBACReport{Namespaces: namespaces, Rules: make(map[string][]RBACRule), Incomplete: make(map[string]string)}
+
+	rules := make(map[string][]authorizationv1.ResourceRule)
+	reviewed := make(map[string]bool)
+	for _, namespace := range namespaces {
+		review, err := clientset.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+			Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("namespace %s: failed to review rules: %v", namespace, err))
+			continue
+		}
+		reviewed[namespace] = true
+		rules[namespace] = review.Status.ResourceRules
+		if review.Status.Incomplete {
+			report.Incomplete[namespace] = review.Status.EvaluationError
+		}
+		for _, rule := range review.Status.ResourceRules {
+			report.Rules[namespace] = append(report.Rules[namespace], RBACRule{
+				Verbs:         rule.Verbs,
+				APIGroups:     rule.APIGroups,
+				Resources:     rule.Resources,
+				ResourceNames: rule.ResourceNames,
+			})
+		}
+	}
+
+	for _, permission := range servicePermissions {
+		row := RBACMatrixRow{RBACPermission: permission}
+		if permission.ClusterScoped {
+			row.ClusterAccess = accessReview(ctx, clientset, permission, "")
+			report.Matrix = append(report.Matrix, row)
+			continue
+		}
+
+		row.Namespaces = make(map[string]string)
+		for _, namespace := range namespaces {
+			if !reviewed[namespace] {
+				row.Namespaces[namespace] = AccessUnknown
+				continue
+			}
+			access := ruleAccess(rules[namespace], permission)
+			if _, incomplete := report.Incomplete[namespace]; incomplete && access != AccessAllowed {
+				access = accessReview(ctx, clientset, permission, namespace)
+			}
+			row.Namespaces[namespace] = access
+		}
+		report.Matrix = append(report.Matrix, row)
+	}
+
+	if len(report.Incomplete) == 0 {
+		report.Incomplete = nil
+	}
+	return report
+}
+
+// accessReview checks a permission with a SelfSubjectAccessReview, in a
+// namespace or cluster-wide if namespace is empty
+func accessReview(ctx context.Context, clientset kubernetes.Interface, permission RBACPermission, namespace string) string {
+	resource, subresource, _ := strings.Cut(permission.Resource, "/")
+	review, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        permission.Verb,
+				Group:       permission.Group,
+				Resource:    resource,
+				Subresource: subresource,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return AccessUnknown
+	}
+	if review.Status.Allowed {
+		return AccessAllowed
+	}
+	return AccessDenied
+}
+
+// ruleAccess evaluates a permission against the rules of a rules review
+func ruleAccess(rules []authorizationv1.ResourceRule, permission RBACPermission) string {
+	access := AccessDenied
+	for _, rule := range rules {
+		if !matchesAny(rule.Verbs, permission.Verb) || !matchesAny(rule.APIGroups, permission.Group) || !matchesResource(rule.Resources, permission.Resource) {
+			continue
+		}
+		if len(rule.ResourceNames) == 0 {
+			return AccessAllowed
+		}
+		access = AccessRestricted
+	}
+	return access
+}
+
+// matchesAny reports whether values contain value or the * wildcard
+func matchesAny(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesResource reports whether rule resources cover a resource, which may
+// include a subresource, honouring the *, resource/*, and */subresource
+// wildcards
+func matchesResource(resources []string, resource string) bool {
+	base, subresource, hasSubresource := strings.Cut(resource, "/")
+	for _, r := range resources {
+		switch {
+		case r == resource || r == "*":
+			return true
+		case hasSubresource && (r == base+"/*" || r == "*/"+subresource):
+			return true
+		}
+	}
+	return false
+}
+
+// RBACNamespaces returns the namespaces to review: the default namespace,
+// the scanner namespaces, and any extra ones, without duplicates. The "*"
+// of a scanner scanning every namespace is left out.
+func RBACNamespaces(defaultNamespace string, scanner []string, extra ...string) []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, namespace := range append(append([]string{defaultNamespace}, scanner...), extra...) {
+		if namespace != "" && namespace != config.AllNamespaces && !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
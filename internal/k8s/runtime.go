@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Container states
+const (
+	ContainerRunning    = "running"
+	ContainerWaiting    = "waiting"
+	ContainerTerminated = "terminated"
+	ContainerUnknown    = "unknown"
+)
+
+// reasonCrashLoop is the waiting reason of a container restarting after
+// repeated failures
+const reasonCrashLoop = "CrashLoopBackOff"
+
+// PodRuntime is the runtime status of a pod. A crash-looping pod with an
+// expired certificate is usually failing because of it, while a Running pod
+// has not yet reloaded or reconnected.
+type PodRuntime struct {
+	Phase        string             `json:"phase"`
+	Ready        bool               `json:"ready"`
+	Restarts     int32              `json:"restarts"`
+	CrashLooping bool               `json:"crash_looping"`
+	Containers   []ContainerRuntime `json:"containers"`
+}
+
+// ContainerRuntime is the runtime status of a container of a pod
+type ContainerRuntime struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// ImageID is the digest of the image that runs, which differs from
+	// Image when a tag was moved
+	ImageID  string `json:"image_id,omitempty"`
+	Init     bool   `json:"init,omitempty"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	State    string `json:"state"`
+	// Reason explains a waiting or terminated state, e.g. CrashLoopBackOff
+	Reason string `json:"reason,omitempty"`
+	// LastTermination is the reason the previous instance of a restarted
+	// container stopped, e.g. Error or OOMKilled
+	LastTermination string `json:"last_termination,omitempty"`
+}
+
+// PodRuntimeStatus reads the phase, readiness, restarts, and container images
+// of a pod. Containers without a status yet are reported with the image of
+// their spec.
+func PodRuntimeStatus(pod *corev1.Pod) *PodRuntime {
+	runtime := &PodRuntime{Phase: string(pod.Status.Phase)}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			runtime.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	add := func(containers []corev1.Container, statuses []corev1.ContainerStatus, init bool) {
+		byName := make(map[string]*corev1.ContainerStatus)
+		for i := range statuses {
+			byName[statuses[i].Name] = &statuses[i]
+		}
+		for _, container := range containers {
+			info := ContainerRuntime{Name: container.Name, Image: container.Image, Init: init, State: ContainerUnknown}
+			if status := byName[container.Name]; status != nil {
+				info.ImageID = status.ImageID
+				info.Ready = status.Ready
+				info.Restarts = status.RestartCount
+				switch {
+				case status.State.Running != nil:
+					info.State = ContainerRunning
+				case status.State.Waiting != nil:
+					info.State = ContainerWaiting
+					info.Reason = status.State.Waiting.Reason
+				case status.State.Terminated != nil:
+					info.State = ContainerTerminated
+					info.Reason = status.State.Terminated.Reason
+				}
+				if last := status.LastTerminationState.Terminated; last != nil {
+					info.LastTermination = last.Reason
+				}
+			}
+			runtime.Restarts += info.Restarts
+			if info.Reason == reasonCrashLoop {
+				runtime.CrashLooping = true
+			}
+			runtime.Containers = append(runtime.Containers, info)
+		}
+	}
+	add(pod.Spec.InitContainers, pod.Status.InitContainerStatuses, true)
+	add(pod.Spec.Containers, pod.Status.ContainerStatuses, false)
+	return runtime
+}
@@ -0,0 +1,180 @@
+package k8s
+
+import (
+	"context"
+	"time"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// Sections of a consolidated scan
+const (
+	ScanPods      = "pods"
+	ScanSecrets   = "secrets"
+	ScanIngress   = "ingress"
+	ScanWebhooks  = "webhooks"
+	ScanClusterCA = "cluster-ca"
+)
+
+// ScanSections lists every section of a consolidated scan, in the order
+// they run
+var ScanSections = []string{ScanPods, ScanSecrets, ScanIngress, ScanWebhooks, ScanClusterCA}
+
+// Overall status of a consolidated scan
+const (
+	ScanHealthy  = "healthy"
+	ScanWarning  = "warning"
+	ScanCritical = "critical"
+)
+
+// ScanExpiry locates the certificate that expires first in a scan
+type ScanExpiry struct {
+	Section         string    `json:"section"`
+	Source          string    `json:"source"`
+	Subject         string    `json:"subject"`
+	Fingerprint     string    `json:"fingerprint_sha256"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+}
+
+// ScanSummary rolls up every section of a consolidated scan
+type ScanSummary struct {
+	Status            string         `json:"status"`
+	Sections          []string       `json:"sections"`
+	TotalCertificates int            `json:"total_certificates"`
+	BySection         map[string]int `json:"certificates_by_section"`
+	Expired           int            `json:"expired"`
+	ExpiringSoon      int            `json:"expiring_soon"`
+	FailedSections    int            `json:"failed_sections"`
+	SoonestExpiry     *ScanExpiry    `json:"soonest_expiry,omitempty"`
+}
+
+// ScanReport is the consolidated certificate report of a namespace. Only
+// the included sections are set; a section that fails is reported under
+// Errors without failing the scan.
+type ScanReport struct {
+	Namespace   string                 `json:"namespace"`
+	WarningDays int                    `json:"warning_days"`
+	Summary     ScanSummary            `json:"summary"`
+	Pods        *NamespaceExpiryReport `json:"pods,omitempty"`
+	Secrets     []*CertificateSource   `json:"secrets,omitempty"`
+	Ingress     []InClusterCertificate `json:"ingress,omitempty"`
+	Webhooks    []WebhookCABundle      `json:"webhooks,omitempty"`
+	ClusterCA   *CertificateSource     `json:"cluster_ca,omitempty"`
+	Errors      map[string]string      `json:"errors,omitempty"`
+}
+
+// RunScan runs the included sections against a namespace and rolls their
+// certificates up into one summary. Webhooks are those calling services in
+// the namespace; the cluster CA is the one of the kubeconfig.
+func RunScan(ctx context.Context, client *Client, namespace string, include []string, warningDays int) *ScanReport {
+	report := &ScanReport{
+		Namespace:   namespace,
+		WarningDays: warningDays,
+		Summary:     ScanSummary{Sections: include, BySection: make(map[string]int)},
+		Errors:      make(map[string]string),
+	}
+	clientset := client.GetClientset()
+	count := func(section, source string, certs ...*utils.CertificateInfo) {
+		for _, cert := range certs {
+			if cert == nil {
+				continue
+			}
+			report.Summary.TotalCertificates++
+			report.Summary.BySection[section]++
+			switch {
+			case cert.IsExpired:
+				report.Summary.Expired++
+			case cert.DaysUntilExp <= warningDays:
+				report.Summary.ExpiringSoon++
+			}
+			if soonest := report.Summary.SoonestExpiry; soonest == nil || cert.NotAfter.Before(soonest.NotAfter) {
+				report.Summary.SoonestExpiry = &ScanExpiry{
+					Section:         section,
+					Source:          source,
+					Subject:         cert.Subject,
+					Fingerprint:     cert.Fingerprint,
+					NotAfter:        cert.NotAfter,
+					DaysUntilExpiry: cert.DaysUntilExp,
+				}
+			}
+		}
+	}
+
+	for _, section := range include {
+		switch section {
+		case ScanPods:
+			pods, err := AnalyzeNamespaceExpiry(ctx, client, namespace, warningDays)
+			if err != nil {
+				report.Errors[section] = err.Error()
+				continue
+			}
+			report.Pods = pods
+			for _, pod := range pods.Pods {
+				for name, source := range pod.CertSources {
+					count(section, pod.PodName+"/"+name, source.Certificates...)
+				}
+			}
+			for _, source := range pods.CustomResources {
+				count(section, source.Name, source.Certificates...)
+			}
+		case ScanSecrets:
+			secrets, err := ScanSecretCertificates(ctx, clientset, namespace)
+			if err != nil {
+				report.Errors[section] = err.Error()
+				continue
+			}
+			report.Secrets = secrets
+			for _, source := range secrets {
+				count(section, source.Name, source.Certificates...)
+			}
+		case ScanIngress:
+			refs, err := ListCertificateReferences(ctx, clientset, namespace)
+			if err != nil {
+				report.Errors[section] = err.Error()
+				continue
+			}
+			report.Ingress = IngressTLSCertificates(ctx, clientset, refs)
+			for _, cert := range report.Ingress {
+				count(section, cert.Ingress+"/"+cert.Secret, cert.Cert)
+			}
+		case ScanWebhooks:
+			webhooks, err := ListWebhookCABundles(ctx, clientset, namespace)
+			if err != nil {
+				report.Errors[section] = err.Error()
+				continue
+			}
+			report.Webhooks = webhooks
+			for _, webhook := range webhooks {
+				count(section, webhook.Configuration+"/"+webhook.Webhook, webhook.Certificates...)
+			}
+		case ScanClusterCA:
+			clusterCA, err := LoadClusterCA(client.appConfig)
+			if err != nil {
+				report.Errors[section] = err.Error()
+				continue
+			}
+			source, err := GetClusterCACertificateInfo(clusterCA)
+			if err != nil {
+				report.Errors[section] = err.Error()
+				continue
+			}
+			report.ClusterCA = source
+			count(section, source.Name, source.Certificates...)
+		}
+	}
+
+	report.Summary.FailedSections = len(report.Errors)
+	switch {
+	case report.Summary.Expired > 0:
+		report.Summary.Status = ScanCritical
+	case report.Summary.ExpiringSoon > 0 || report.Summary.FailedSections > 0:
+		report.Summary.Status = ScanWarning
+	default:
+		report.Summary.Status = ScanHealthy
+	}
+	if len(report.Errors) == 0 {
+		report.Errors = nil
+	}
+	return report
+}
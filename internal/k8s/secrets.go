@@ -0,0 +1,192 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// SecretKeyInfo describes a key of a secret without its value
+type SecretKeyInfo struct {
+	Name string `json:"name"`
+	Size int    `json:"size_bytes"`
+}
+
+// SecretMetadata describes a secret without any of its values
+type SecretMetadata struct {
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace"`
+	Type      string          `json:"type"`
+	Keys      []SecretKeyInfo `json:"keys"`
+	TotalSize int             `json:"total_size_bytes"`
+	Created   time.Time       `json:"created"`
+	AgeDays   int             `json:"age_days"`
+	Immutable bool            `json:"immutable"`
+	// HasCertificateKeys is true if a key name suggests certificate data
+	HasCertificateKeys bool `json:"has_certificate_keys"`
+}
+
+// ListSecretMetadata lists the secrets of a namespace, optionally only those
+// of secretType, with key names and sizes. Secret values are never returned.
+func ListSecretMetadata(ctx context.Context, clientset kubernetes.Interface, namespace, secretType string) ([]SecretMetadata, error) {
+	opts := metav1.ListOptions{}
+	if secretType != "" {
+		opts.FieldSelector = fields.OneTermEqualSelector("type", secretType).String()
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets in namespace %s: %w", namespace, err)
+	}
+
+	now := time.Now()
+	var result []SecretMetadata
+	for _, secret := range secrets.Items {
+		// Offline fixtures do not apply field selectors
+		if secretType != "" && string(secret.Type) != secretType {
+			continue
+		}
+
+		info := SecretMetadata{
+			Name:      secret.Name,
+			Namespace: secret.Namespace,
+			Type:      string(secret.Type),
+			Keys:      []SecretKeyInfo{},
+			Created:   secret.CreationTimestamp.Time,
+			AgeDays:   int(now.Sub(secret.CreationTimestamp.Time).Hours() / 24),
+			Immutable: secret.Immutable != nil && *secret.Immutable,
+		}
+		for key, value := range secret.Data {
+			info.Keys = append(info.Keys, SecretKeyInfo{Name: key, Size: len(value)})
+			info.TotalSize += len(value)
+			info.HasCertificateKeys = info.HasCertificateKeys || isCertificateKey(key)
+		}
+		sort.Slice(info.Keys, func(i, j int) bool { return info.Keys[i].Name < info.Keys[j].Name })
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Expiry statuses of the certificates of a secret
+const (
+	SecretCertificatesExpired    = "expired"
+	SecretCertificatesExpiring   = "expiring"
+	SecretCertificatesOK         = "ok"
+	SecretCertificatesUnparsable = "unparsable" // no certificate parsed
+)
+
+// SecretCertificates are the certificates stored in a secret, whether or
+// not a pod references it
+type SecretCertificates struct {
+	CertificateSource
+	SecretType string    `json:"secret_type"`
+	Created    time.Time `json:"created"`
+	// Status is the status of the certificate that expires first
+	Status   string   `json:"status"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ListSecretCertificates parses the certificates of the kubernetes.io/tls
+// secrets of a namespace, or of every namespace if namespace is empty, and
+// of the Opaque secrets with keys that look like certificates. Besides the
+// well-known keys read for pods, any certificate-like key holding a PEM
+// certificate is parsed. TLS secrets are listed even if nothing parses.
+func ListSecretCertificates(ctx context.Context, clientset kubernetes.Interface, namespace string, warningDays int) ([]SecretCertificates, error) {
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if namespace == "" {
+			return nil, fmt.Errorf("failed to list secrets in all namespaces: %w", err)
+		}
+		return nil, fmt.Errorf("failed to list secrets in namespace %s: %w", namespace, err)
+	}
+
+	var result []SecretCertificates
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if secret.Type != corev1.SecretTypeTLS && secret.Type != corev1.SecretTypeOpaque {
+			continue
+		}
+		source := certificatesFromSecret(secret)
+		addCertificateLikeKeys(source, secret)
+		if secret.Type == corev1.SecretTypeOpaque && len(source.Certificates) == 0 && len(source.ParseErrors) == 0 {
+			continue
+		}
+
+		found := SecretCertificates{
+			CertificateSource: *source,
+			SecretType:        string(secret.Type),
+			Created:           secret.CreationTimestamp.Time,
+			Status:            secretCertificatesStatus(source.Certificates, warningDays),
+		}
+		if len(source.Certificates) > 0 {
+			found.Warnings = utils.ValidateCertificateExpiry(source.Certificates, warningDays)
+		}
+		if warning := source.KeyWarning(); warning != "" {
+			found.Warnings = append(found.Warnings, warning)
+		}
+		result = append(result, found)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// addCertificateLikeKeys parses the keys of a secret beyond the well-known
+// ones whose name suggests a certificate and whose value holds a PEM
+// certificate, so that private keys and unrelated values are not reported
+// as parse errors
+func addCertificateLikeKeys(source *CertificateSource, secret *corev1.Secret) {
+	wellKnown := make(map[string]bool, len(secretCertKeys))
+	for _, key := range secretCertKeys {
+		wellKnown[key] = true
+	}
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := secret.Data[key]
+		if wellKnown[key] || !isCertificateKey(key) || !bytes.Contains(value, []byte("-----BEGIN CERTIFICATE-----")) {
+			continue
+		}
+		source.addKey(key, string(value))
+	}
+}
+
+// secretCertificatesStatus returns the status of the certificate that
+// expires first
+func secretCertificatesStatus(certs []*utils.CertificateInfo, warningDays int) string {
+	if len(certs) == 0 {
+		return SecretCertificatesUnparsable
+	}
+	soonest := certs[0]
+	for _, cert := range certs[1:] {
+		if cert.NotAfter.Before(soonest.NotAfter) {
+			soonest = cert
+		}
+	}
+	switch {
+	case soonest.IsExpired:
+		return SecretCertificatesExpired
+	case soonest.DaysUntilExp <= warningDays:
+		return SecretCertificatesExpiring
+	default:
+		return SecretCertificatesOK
+	}
+}
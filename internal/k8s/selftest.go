@@ -0,0 +1,260 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/internal/config"
+)
+
+// Self-test statuses
+const (
+	SelfTestPassed     = "all_tests_passed"
+	SelfTestSomeFailed = "some_tests_failed"
+	SelfTestFailed     = "failed"
+)
+
+// AuthCheck is the result of a single authentication or permission check
+type AuthCheck struct {
+	Name       string `json:"-"`
+	Status     string `json:"status"`
+	Namespace  string `json:"namespace,omitempty"`
+	Permission string `json:"permission,omitempty"`
+	// Optional checks do not fail the self-test
+	Optional bool   `json:"optional,omitempty"`
+	Count    *int   `json:"count,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Capability is a permission the certificate features need in each target
+// namespace
+type Capability struct {
+	Name       string
+	Permission RBACPermission
+	Optional   bool
+}
+
+// namespaceCapabilities are checked in every target namespace with a
+// SelfSubjectAccessReview
+var namespaceCapabilities = []Capability{
+	{Name: "list_pods", Permission: RBACPermission{Resource: "pods", Verb: "list", UsedBy: "every pod certificate analysis"}},
+	{Name: "get_pods", Permission: RBACPermission{Resource: "pods", Verb: "get", UsedBy: "/pod-certificates/{pod-name}, /certificate-expiry"}},
+	{Name: "get_secrets", Permission: RBACPermission{Resource: "secrets", Verb: "get", UsedBy: "certificates of secret and projected volumes"}},
+	{Name: "list_secrets", Permission: RBACPermission{Resource: "secrets", Verb: "list", UsedBy: "/secrets, /scan, /helm-certificates"}},
+	{Name: "get_configmaps", Permission: RBACPermission{Resource: "configmaps", Verb: "get", UsedBy: "certificates of ConfigMap volumes"}},
+	{Name: "list_configmaps", Permission: RBACPermission{Resource: "configmaps", Verb: "list", UsedBy: "/configmaps/trust-bundles"}},
+	{Name: "exec_pods", Optional: true, Permission: RBACPermission{Resource: "pods/exec", Verb: "create", UsedBy: "inspecting certificate files inside containers by hand; the service does not exec"}},
+}
+
+// MissingPermission is a permission a capability check found missing in a
+// namespace
+type MissingPermission struct {
+	Namespace string `json:"namespace"`
+	RBACPermission
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Passed reports whether the check succeeded
+func (c AuthCheck) Passed() bool {
+	return c.Status == "passed"
+}
+
+// AuthReport is the result of the authentication and RBAC self-test
+type AuthReport struct {
+	Status string
+	Checks []AuthCheck
+	// Identity is the user the cluster authenticated the service as, if the
+	// API server supports SelfSubjectReview
+	Identity string
+	Missing  []MissingPermission
+}
+
+// Tests returns the checks keyed by name
+func (r *AuthReport) Tests() map[string]AuthCheck {
+	tests := make(map[string]AuthCheck, len(r.Checks))
+	for _, check := range r.Checks {
+		tests[check.Name] = check
+	}
+	return tests
+}
+
+// Failed returns the checks that did not pass, except optional ones
+func (r *AuthReport) Failed() []AuthCheck {
+	var failed []AuthCheck
+	for _, check := range r.Checks {
+		if !check.Passed() && !check.Optional {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// RunAuthChecks verifies the AWS configuration, that a client can be
+// created, that the namespaces and pods the service reads are accessible,
+// and that each capability of the certificate features is granted in the
+// default, scanner, and extra namespaces. It stops early if the
+// configuration or client is unusable.
+func RunAuthChecks(ctx context.Context, cfg *config.Config, extra ...string) *AuthReport {
+	report := &AuthReport{}
+	fail := func(name, namespace string, err error) {
+		report.Checks = append(report.Checks, AuthCheck{Name: name, Status: "failed", Namespace: namespace, Error: err.Error()})
+	}
+	pass := func(name, namespace string, count *int) {
+		report.Checks = append(report.Checks, AuthCheck{Name: name, Status: "passed", Namespace: namespace, Count: count})
+	}
+
+	// AWS configuration
+	if err := cfg.ValidateAWSConfig(); err != nil {
+		fail("aws_config", "", err)
+		report.Status = SelfTestFailed
+		return report
+	}
+	pass("aws_config", "", nil)
+
+	// Kubernetes client
+	client, err := NewClient(cfg)
+	if err != nil {
+		fail("k8s_client_creation", "", err)
+		report.Status = SelfTestFailed
+		return report
+	}
+	pass("k8s_client_creation", "", nil)
+	clientset := client.GetClientset()
+
+	// Basic cluster access
+	if namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{}); err != nil {
+		fail("list_namespaces", "", err)
+	} else {
+		count := len(namespaces.Items)
+		pass("list_namespaces", "", &count)
+	}
+
+	targetNamespace := cfg.Kubernetes.DefaultNamespace
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, targetNamespace, metav1.GetOptions{}); err != nil {
+		fail("get_target_namespace", targetNamespace, err)
+	} else {
+		pass("get_target_namespace", targetNamespace, nil)
+	}
+
+	for _, podCheck := range []struct{ name, namespace string }{
+		{"list_pods_target_namespace", targetNamespace},
+		{"list_pods_default_namespace", "default"},
+	} {
+		if pods, err := clientset.CoreV1().Pods(podCheck.namespace).List(ctx, metav1.ListOptions{}); err != nil {
+			fail(podCheck.name, podCheck.namespace, err)
+		} else {
+			count := len(pods.Items)
+			pass(podCheck.name, podCheck.namespace, &count)
+		}
+	}
+
+	if identity, err := reviewIdentity(ctx, clientset); err == nil {
+		report.Identity = identity
+	}
+	for _, namespace := range RBACNamespaces(targetNamespace, cfg.Scanner.Namespaces, extra...) {
+		for _, capability := range namespaceCapabilities {
+			check := AuthCheck{
+				Name:       capability.Name + ":" + namespace,
+				Status:     "passed",
+				Namespace:  namespace,
+				Permission: capability.Permission.String(),
+				Optional:   capability.Optional,
+			}
+			if access := accessReview(ctx, clientset, capability.Permission, namespace); access != AccessAllowed {
+				check.Status = "failed"
+				check.Error = fmt.Sprintf("%s is %s (needed for %s)", capability.Permission, access, capability.Permission.UsedBy)
+				report.Missing = append(report.Missing, MissingPermission{Namespace: namespace, RBACPermission: capability.Permission, Optional: capability.Optional})
+			}
+			report.Checks = append(report.Checks, check)
+		}
+	}
+
+	report.Status = SelfTestPassed
+	if len(report.Failed()) > 0 {
+		report.Status = SelfTestSomeFailed
+	}
+	return report
+}
+
+// reviewIdentity asks the API server who the service is authenticated as
+func reviewIdentity(ctx context.Context, clientset kubernetes.Interface) (string, error) {
+	review, err := clientset.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to review identity: %w", err)
+	}
+	return review.Status.UserInfo.Username, nil
+}
+
+// remediationRoleName names the Roles and RoleBindings of
+// RemediationManifest
+const remediationRoleName = "k8s-web-service-certificates"
+
+// RemediationManifest returns a Role and RoleBinding per namespace granting
+// the missing permissions to user. Optional permissions are included but
+// marked, so they can be removed before applying.
+func RemediationManifest(missing []MissingPermission, user string) string {
+	if len(missing) == 0 {
+		return ""
+	}
+	if user == "" {
+		user = "REPLACE-WITH-SERVICE-USER"
+	}
+
+	byNamespace := make(map[string][]MissingPermission)
+	for _, permission := range missing {
+		byNamespace[permission.Namespace] = append(byNamespace[permission.Namespace], permission)
+	}
+	namespaces := make([]string, 0, len(byNamespace))
+	for namespace := range byNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Grants the permissions /test-k8s-auth found missing to %s.\n", user)
+	b.WriteString("# On EKS the user is the username of the IAM role in aws-auth or its access entry.\n")
+	for _, namespace := range namespaces {
+		fmt.Fprintf(&b, "---\napiVersion: rbac.authorization.k8s.io/v1\nkind: Role\nmetadata:\n  name: %s\n  namespace: %s\nrules:\n", remediationRoleName, namespace)
+		for _, permission := range mergeVerbs(byNamespace[namespace]) {
+			if permission.Optional {
+				b.WriteString("  # optional\n")
+			}
+			fmt.Fprintf(&b, "  - apiGroups: [%q]\n    resources: [%q]\n    verbs: [%s]\n", permission.Group, permission.Resource, quoteList(strings.Split(permission.Verb, ",")))
+		}
+		fmt.Fprintf(&b, "---\napiVersion: rbac.authorization.k8s.io/v1\nkind: RoleBinding\nmetadata:\n  name: %s\n  namespace: %s\nroleRef:\n  apiGroup: rbac.authorization.k8s.io\n  kind: Role\n  name: %s\nsubjects:\n  - apiGroup: rbac.authorization.k8s.io\n    kind: User\n    name: %q\n", remediationRoleName, namespace, remediationRoleName, user)
+	}
+	return b.String()
+}
+
+// mergeVerbs combines the missing verbs of each resource into one rule,
+// with the verbs comma-separated in Verb, required rules first
+func mergeVerbs(missing []MissingPermission) []MissingPermission {
+	var rules []MissingPermission
+	index := make(map[string]int)
+	for _, permission := range missing {
+		key := fmt.Sprintf("%s/%s/%t", permission.Group, permission.Resource, permission.Optional)
+		if i, ok := index[key]; ok {
+			rules[i].Verb += "," + permission.Verb
+			continue
+		}
+		index[key] = len(rules)
+		rules = append(rules, permission)
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return !rules[i].Optional && rules[j].Optional })
+	return rules
+}
+
+// quoteList formats values as a YAML flow sequence of quoted strings
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return strings.Join(quoted, ", ")
+}
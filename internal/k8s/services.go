@@ -0,0 +1,151 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// AWS load balancer annotations relevant to TLS
+const (
+	AnnotationAWSSSLCert          = "service.beta.kubernetes.io/aws-load-balancer-ssl-cert"
+	AnnotationAWSSSLPorts         = "service.beta.kubernetes.io/aws-load-balancer-ssl-ports"
+	AnnotationAWSSSLPolicy        = "service.beta.kubernetes.io/aws-load-balancer-ssl-negotiation-policy"
+	AnnotationAWSBackendProtocol  = "service.beta.kubernetes.io/aws-load-balancer-backend-protocol"
+	AnnotationAWSLoadBalancerType = "service.beta.kubernetes.io/aws-load-balancer-type"
+)
+
+// tlsPorts are port numbers conventionally used for TLS
+var tlsPorts = map[int32]bool{443: true, 8443: true, 6443: true, 9443: true}
+
+// ServicePortInfo describes a single port of a Service
+type ServicePortInfo struct {
+	Name       string `json:"name,omitempty"`
+	Port       int32  `json:"port"`
+	TargetPort string `json:"target_port"`
+	Protocol   string `json:"protocol"`
+	TLS        bool   `json:"tls"`
+}
+
+// ServiceAWSInfo holds the AWS load balancer TLS settings of a Service
+type ServiceAWSInfo struct {
+	CertificateARNs   []string `json:"certificate_arns,omitempty"`
+	SSLPorts          string   `json:"ssl_ports,omitempty"`
+	SSLPolicy         string   `json:"ssl_negotiation_policy,omitempty"`
+	BackendProtocol   string   `json:"backend_protocol,omitempty"`
+	LoadBalancerType  string   `json:"load_balancer_type,omitempty"`
+	LoadBalancerHosts []string `json:"load_balancer_hosts,omitempty"`
+}
+
+// ServiceTLSInfo describes the TLS-relevant configuration of a Service
+type ServiceTLSInfo struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Type      string            `json:"type"`
+	ClusterIP string            `json:"cluster_ip,omitempty"`
+	Ports     []ServicePortInfo `json:"ports"`
+	HasTLS    bool              `json:"has_tls"`
+	AWS       *ServiceAWSInfo   `json:"aws,omitempty"`
+}
+
+// ListServiceTLS lists the Services of a namespace with their TLS-relevant
+// ports and AWS load balancer certificate annotations. With tlsOnly, only
+// Services with a TLS port or a load balancer certificate are returned.
+func ListServiceTLS(ctx context.Context, clientset kubernetes.Interface, namespace string, tlsOnly bool) ([]ServiceTLSInfo, error) {
+	services, err := clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services in namespace %s: %w", namespace, err)
+	}
+
+	var result []ServiceTLSInfo
+	for i := range services.Items {
+		info := serviceTLSInfo(&services.Items[i])
+		if tlsOnly && !info.HasTLS {
+			continue
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// serviceTLSInfo extracts the TLS-relevant configuration of a Service
+func serviceTLSInfo(svc *corev1.Service) ServiceTLSInfo {
+	info := ServiceTLSInfo{
+		Name:      svc.Name,
+		Namespace: svc.Namespace,
+		Type:      string(svc.Spec.Type),
+		ClusterIP: svc.Spec.ClusterIP,
+	}
+
+	annotations := svc.Annotations
+	if arns := annotations[AnnotationAWSSSLCert]; arns != "" || svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		aws := &ServiceAWSInfo{
+			SSLPorts:         annotations[AnnotationAWSSSLPorts],
+			SSLPolicy:        annotations[AnnotationAWSSSLPolicy],
+			BackendProtocol:  annotations[AnnotationAWSBackendProtocol],
+			LoadBalancerType: annotations[AnnotationAWSLoadBalancerType],
+		}
+		aws.CertificateARNs = splitARNs(arns)
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			host := ingress.Hostname
+			if host == "" {
+				host = ingress.IP
+			}
+			aws.LoadBalancerHosts = append(aws.LoadBalancerHosts, host)
+		}
+		info.AWS = aws
+		info.HasTLS = len(aws.CertificateARNs) > 0
+	}
+
+	for _, port := range svc.Spec.Ports {
+		portInfo := ServicePortInfo{
+			Name:       port.Name,
+			Port:       port.Port,
+			TargetPort: port.TargetPort.String(),
+			Protocol:   string(port.Protocol),
+			TLS:        isTLSPort(port, info.AWS),
+		}
+		info.HasTLS = info.HasTLS || portInfo.TLS
+		info.Ports = append(info.Ports, portInfo)
+	}
+	return info
+}
+
+// isTLSPort reports whether a Service port likely carries TLS, based on its
+// number, name, app protocol, and the load balancer SSL ports annotation
+func isTLSPort(port corev1.ServicePort, aws *ServiceAWSInfo) bool {
+	if tlsPorts[port.Port] {
+		return true
+	}
+
+	name := strings.ToLower(port.Name)
+	for _, hint := range []string{"https", "tls", "grpcs", "ssl"} {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	if port.AppProtocol != nil && strings.EqualFold(*port.AppProtocol, "https") {
+		return true
+	}
+
+	if aws != nil && len(aws.CertificateARNs) > 0 {
+		// Without the annotation, every port of the load balancer uses TLS
+		if aws.SSLPorts == "" || aws.SSLPorts == "*" {
+			return true
+		}
+		for _, p := range strings.Split(aws.SSLPorts, ",") {
+			p = strings.TrimSpace(p)
+			if p == port.Name || p == strconv.Itoa(int(port.Port)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SimulateExpiry rewrites the expiry of the certificates in report whose
+// subject or source name contains match, or of all certificates if match is
+// empty, so they expire at expiresAt. Warnings are recomputed and the number
+// of rewritten certificates is returned. Used to exercise the alert pipeline
+// without waiting for a real certificate to near expiry.
+func SimulateExpiry(report *NamespaceExpiryReport, expiresAt time.Time, match string) int {
+	now := time.Now()
+	simulated := 0
+
+	report.Warnings = nil
+	report.TotalWarnings = 0
+	for i := range report.Pods {
+		pod := &report.Pods[i]
+		for name, source := range pod.CertSources {
+			for _, cert := range source.Certificates {
+				if match != "" && !strings.Contains(cert.Subject, match) && !strings.Contains(name, match) {
+					continue
+				}
+				cert.NotAfter = expiresAt
+				cert.DaysUntilExp = int(expiresAt.Sub(now).Hours() / 24)
+				cert.IsExpired = now.After(expiresAt)
+				simulated++
+			}
+		}
+
+		pod.Warnings = GetCertificateExpiryWarnings(pod.CertSources, report.WarningDays)
+		pod.WarningCount = len(pod.Warnings)
+		for _, warning := range pod.Warnings {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("Pod %s: %s", pod.PodName, warning))
+		}
+		report.TotalWarnings += pod.WarningCount
+	}
+	return simulated
+}
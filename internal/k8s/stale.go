@@ -0,0 +1,240 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// How a secret reaches a container
+const (
+	MountVolume  = "volume"
+	MountSubPath = "subPath"
+	MountEnv     = "env"
+)
+
+// Confidence of a stale certificate finding
+const (
+	StaleConfirmed   = "stale"          // the container cannot see the new certificate
+	StalePossible    = "possibly_stale" // the file was updated, but the process may not reload it
+	StalePropagating = "propagating"    // the kubelet has not synced the volume yet
+)
+
+// kubeletSyncWindow approximates how long the kubelet takes to project an
+// updated secret into volumes: its sync period plus the secret cache TTL
+const kubeletSyncWindow = 2 * time.Minute
+
+// StaleMountFinding is a pod that started before the certificate in a secret
+// it mounts was rotated
+type StaleMountFinding struct {
+	Namespace       string      `json:"namespace"`
+	Pod             string      `json:"pod"`
+	Workload        WorkloadRef `json:"workload"`
+	Container       string      `json:"container"`
+	Secret          string      `json:"secret"`
+	Mount           string      `json:"mount"` // volume, subPath, or env
+	MountPath       string      `json:"mount_path,omitempty"`
+	PodStarted      time.Time   `json:"pod_started"`
+	SecretRotated   time.Time   `json:"secret_rotated"`
+	RotationSource  string      `json:"rotation_source"` // certificate not_before or secret update time
+	Confidence      string      `json:"confidence"`
+	Message         string      `json:"message"`
+	SuggestedAction string      `json:"suggested_action"`
+}
+
+// secretRotation is when the certificate of a secret was last replaced
+type secretRotation struct {
+	at     time.Time
+	source string
+}
+
+// DetectStaleMounts flags pods of a namespace that started before the
+// certificate of a secret they use was rotated. The rotation time is the
+// newest not_before of the secret's certificates, or else the last update of
+// the secret from its managed fields. subPath mounts and environment
+// variables are never updated by the kubelet, so those pods are stale for
+// certain; regular volumes are updated in place, and the pod is only stale
+// if the process does not reload the file.
+func DetectStaleMounts(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]StaleMountFinding, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %s: %w", namespace, err)
+	}
+
+	now := time.Now()
+	rotations := make(map[string]*secretRotation) // nil when the secret holds no certificate
+	rotation := func(name string) *secretRotation {
+		if cached, ok := rotations[name]; ok {
+			return cached
+		}
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			rotations[name] = nil
+			return nil
+		}
+		rotations[name] = certificateRotation(secret)
+		return rotations[name]
+	}
+
+	resolver := newWorkloadResolver(clientset, namespace)
+	var findings []StaleMountFinding
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.StartTime == nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		started := pod.Status.StartTime.Time
+		var workload *WorkloadRef
+
+		for _, use := range podSecretUses(pod) {
+			rotated := rotation(use.secret)
+			if rotated == nil || !rotated.at.After(started) {
+				continue
+			}
+			if workload == nil {
+				ref := resolver.resolve(ctx, pod)
+				workload = &ref
+			}
+			finding := StaleMountFinding{
+				Namespace:      pod.Namespace,
+				Pod:            pod.Name,
+				Workload:       *workload,
+				Container:      use.container,
+				Secret:         use.secret,
+				Mount:          use.mount,
+				MountPath:      use.mountPath,
+				PodStarted:     started,
+				SecretRotated:  rotated.at,
+				RotationSource: rotated.source,
+			}
+			switch {
+			case use.mount != MountVolume:
+				finding.Confidence = StaleConfirmed
+				finding.Message = fmt.Sprintf("Secret %s was rotated after the pod started, and the kubelet never updates %s mounts; the container still has the old certificate", use.secret, use.mount)
+			case now.Sub(rotated.at) < kubeletSyncWindow:
+				finding.Confidence = StalePropagating
+				finding.Message = fmt.Sprintf("Secret %s was rotated %s ago; the kubelet updates the volume within about %s", use.secret, now.Sub(rotated.at).Round(time.Second), kubeletSyncWindow)
+			default:
+				finding.Confidence = StalePossible
+				finding.Message = fmt.Sprintf("Secret %s was rotated after the pod started; the file is updated, but the process keeps serving the old certificate unless it reloads it", use.secret)
+			}
+			finding.SuggestedAction = restartCommand(pod, *workload)
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Pod != findings[j].Pod {
+			return findings[i].Pod < findings[j].Pod
+		}
+		return findings[i].Secret < findings[j].Secret
+	})
+	return findings, nil
+}
+
+// secretUse is a secret used by a container
+type secretUse struct {
+	container string
+	secret    string
+	mount     string
+	mountPath string
+}
+
+// podSecretUses lists how the containers of a pod use secrets, through
+// volume mounts, subPath mounts, or environment variables
+func podSecretUses(pod *corev1.Pod) []secretUse {
+	volumeSecrets := make(map[string]string) // volume name -> secret name
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Secret != nil {
+			volumeSecrets[volume.Name] = volume.Secret.SecretName
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					volumeSecrets[volume.Name] = source.Secret.Name
+				}
+			}
+		}
+	}
+
+	var uses []secretUse
+	seen := make(map[string]bool)
+	add := func(use secretUse) {
+		key := use.container + "/" + use.secret + "/" + use.mount
+		if !seen[key] {
+			seen[key] = true
+			uses = append(uses, use)
+		}
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, mount := range container.VolumeMounts {
+			secret, ok := volumeSecrets[mount.Name]
+			if !ok {
+				continue
+			}
+			use := secretUse{container: container.Name, secret: secret, mount: MountVolume, mountPath: mount.MountPath}
+			if mount.SubPath != "" || mount.SubPathExpr != "" {
+				use.mount = MountSubPath
+			}
+			add(use)
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				add(secretUse{container: container.Name, secret: env.ValueFrom.SecretKeyRef.Name, mount: MountEnv})
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				add(secretUse{container: container.Name, secret: envFrom.SecretRef.Name, mount: MountEnv})
+			}
+		}
+	}
+	return uses
+}
+
+// certificateRotation returns when the certificate of a secret was last
+// replaced, or nil if the secret holds no certificate
+func certificateRotation(secret *corev1.Secret) *secretRotation {
+	source := certificatesFromSecret(secret)
+	if len(source.Certificates) == 0 {
+		return nil
+	}
+
+	var rotated *secretRotation
+	for _, cert := range source.Certificates {
+		if cert.IsCA {
+			continue
+		}
+		if rotated == nil || cert.NotBefore.After(rotated.at) {
+			rotated = &secretRotation{at: cert.NotBefore, source: "certificate not_before"}
+		}
+	}
+	if rotated != nil {
+		return rotated
+	}
+
+	rotated = &secretRotation{at: secret.CreationTimestamp.Time, source: "secret creation time"}
+	for _, entry := range secret.ManagedFields {
+		if entry.Time != nil && entry.Time.After(rotated.at) {
+			rotated = &secretRotation{at: entry.Time.Time, source: "secret update time"}
+		}
+	}
+	return rotated
+}
+
+// restartCommand suggests how to restart a pod so it picks up the rotated
+// certificate
+func restartCommand(pod *corev1.Pod, workload WorkloadRef) string {
+	switch workload.Kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+		return fmt.Sprintf("kubectl rollout restart %s/%s -n %s", strings.ToLower(workload.Kind), workload.Name, pod.Namespace)
+	default:
+		return fmt.Sprintf("kubectl delete pod %s -n %s", pod.Name, pod.Namespace)
+	}
+}
@@ -0,0 +1,374 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// SystemNamespace is the namespace of the components checked by
+// AnalyzeSystemCertificates
+const SystemNamespace = "kube-system"
+
+// Statuses of a system component
+const (
+	SystemHealthy      = "healthy"
+	SystemWarning      = "warning"
+	SystemCritical     = "critical"
+	SystemNotInstalled = "not_installed"
+)
+
+// systemComponent is a kube-system component with the services its
+// admission webhooks or APIService call
+type systemComponent struct {
+	name            string
+	kind            string // Deployment or DaemonSet
+	workload        string
+	servicePrefixes []string
+	apiServiceName  string
+}
+
+// systemComponents is the curated list of control-plane-adjacent components
+var systemComponents = []systemComponent{
+	{name: "coredns", kind: "Deployment", workload: "coredns"},
+	{name: "kube-proxy", kind: "DaemonSet", workload: "kube-proxy"},
+	{name: "metrics-server", kind: "Deployment", workload: "metrics-server", servicePrefixes: []string{"metrics-server"}, apiServiceName: "v1beta1.metrics.k8s.io"},
+	{name: "aws-load-balancer-controller", kind: "Deployment", workload: "aws-load-balancer-controller", servicePrefixes: []string{"aws-load-balancer-webhook"}},
+	{name: "aws-ebs-csi-driver", kind: "Deployment", workload: "ebs-csi-controller", servicePrefixes: []string{"ebs-csi", "snapshot-validation"}},
+	{name: "aws-efs-csi-driver", kind: "Deployment", workload: "efs-csi-controller", servicePrefixes: []string{"efs-csi"}},
+}
+
+// WebhookCABundle is the caBundle of an admission webhook calling a system
+// component
+type WebhookCABundle struct {
+	Kind          string                   `json:"kind"` // mutating or validating
+	Configuration string                   `json:"configuration"`
+	Webhook       string                   `json:"webhook"`
+	Service       string                   `json:"service"`
+	URL           string                   `json:"url,omitempty"` // set for webhooks called by URL
+	FailurePolicy string                   `json:"failure_policy,omitempty"`
+	Certificates  []*utils.CertificateInfo `json:"ca_bundle,omitempty"`
+	Error         string                   `json:"error,omitempty"`
+}
+
+// APIServiceCA is the TLS configuration of an aggregated API served by a
+// system component
+type APIServiceCA struct {
+	Name                  string                   `json:"name"`
+	Service               string                   `json:"service,omitempty"` // namespace/name of the serving service
+	InsecureSkipTLSVerify bool                     `json:"insecure_skip_tls_verify"`
+	Available             string                   `json:"available,omitempty"`
+	Message               string                   `json:"message,omitempty"`
+	Certificates          []*utils.CertificateInfo `json:"ca_bundle,omitempty"`
+	Error                 string                   `json:"error,omitempty"`
+}
+
+// SystemComponentReport is the certificate health of one system component
+type SystemComponentReport struct {
+	Name        string                        `json:"name"`
+	Kind        string                        `json:"kind"`
+	Workload    string                        `json:"workload"`
+	Status      string                        `json:"status"`
+	Ready       string                        `json:"ready,omitempty"`
+	Pod         string                        `json:"analyzed_pod,omitempty"`
+	CertSources map[string]*CertificateSource `json:"certificate_sources,omitempty"`
+	Webhooks    []WebhookCABundle             `json:"webhooks,omitempty"`
+	APIService  *APIServiceCA                 `json:"api_service,omitempty"`
+	Warnings    []string                      `json:"warnings,omitempty"`
+	Notes       []string                      `json:"notes,omitempty"`
+	Error       string                        `json:"error,omitempty"`
+}
+
+// SystemCertificateReport is the certificate health of the curated
+// kube-system components
+type SystemCertificateReport struct {
+	Namespace     string                  `json:"namespace"`
+	WarningDays   int                     `json:"warning_days"`
+	Components    []SystemComponentReport `json:"components"`
+	TotalWarnings int                     `json:"total_warnings"`
+}
+
+// AnalyzeSystemCertificates checks the certificates of CoreDNS, kube-proxy,
+// metrics-server, the AWS Load Balancer Controller, and the EBS and EFS CSI
+// drivers: the secrets and configmaps mounted by one pod of each, the
+// caBundles of the admission webhooks calling them, and the metrics-server
+// APIService. Components that are not installed are reported as such.
+func AnalyzeSystemCertificates(ctx context.Context, client *Client, warningDays int) (*SystemCertificateReport, error) {
+	clientset := client.GetClientset()
+	report := &SystemCertificateReport{Namespace: SystemNamespace, WarningDays: warningDays}
+
+	webhooks, err := listSystemWebhooks(ctx, clientset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, component := range systemComponents {
+		result := analyzeSystemComponent(ctx, client, component, webhooks, warningDays)
+		report.TotalWarnings += len(result.Warnings)
+		report.Components = append(report.Components, result)
+	}
+	return report, nil
+}
+
+// analyzeSystemComponent checks the certificates of a single component
+func analyzeSystemComponent(ctx context.Context, client *Client, component systemComponent, webhooks []WebhookCABundle, warningDays int) SystemComponentReport {
+	clientset := client.GetClientset()
+	result := SystemComponentReport{Name: component.name, Kind: component.kind, Workload: component.workload}
+
+	var selector *metav1.LabelSelector
+	var err error
+	switch component.kind {
+	case "DaemonSet":
+		daemonSet, getErr := clientset.AppsV1().DaemonSets(SystemNamespace).Get(ctx, component.workload, metav1.GetOptions{})
+		err = getErr
+		if err == nil {
+			selector = daemonSet.Spec.Selector
+			result.Ready = fmt.Sprintf("%d/%d", daemonSet.Status.NumberReady, daemonSet.Status.DesiredNumberScheduled)
+			if daemonSet.Status.DesiredNumberScheduled > 0 && daemonSet.Status.NumberReady == 0 {
+				result.Warnings = append(result.Warnings, "No pods are ready")
+			}
+		}
+	default:
+		deployment, getErr := clientset.AppsV1().Deployments(SystemNamespace).Get(ctx, component.workload, metav1.GetOptions{})
+		err = getErr
+		if err == nil {
+			selector = deployment.Spec.Selector
+			result.Ready = fmt.Sprintf("%d/%d", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+			if deployment.Status.Replicas > 0 && deployment.Status.ReadyReplicas == 0 {
+				result.Warnings = append(result.Warnings, "No pods are ready")
+			}
+		}
+	}
+	if apierrors.IsNotFound(err) {
+		result.Status = SystemNotInstalled
+		return result
+	}
+	if err != nil {
+		result.Status = SystemCritical
+		result.Error = fmt.Sprintf("failed to get %s %s: %v", component.kind, component.workload, err)
+		return result
+	}
+
+	// Pods of a workload mount the same sources, so one pod is analyzed
+	if pod := systemComponentPod(ctx, clientset, selector); pod != "" {
+		result.Pod = pod
+		if sources, err := AnalyzePodCertificates(ctx, client, SystemNamespace, pod); err == nil {
+			// The cluster CA is reported by /cluster-ca-expiry
+			delete(sources, "cluster-ca")
+			if len(sources) > 0 {
+				result.CertSources = sources
+			}
+		}
+	}
+	result.Warnings = append(result.Warnings, GetCertificateExpiryWarnings(result.CertSources, warningDays)...)
+
+	for _, webhook := range webhooks {
+		if !hasAnyPrefix(webhook.Service, component.servicePrefixes) {
+			continue
+		}
+		result.Webhooks = append(result.Webhooks, webhook)
+		name := fmt.Sprintf("%s webhook %s", webhook.Kind, webhook.Webhook)
+		switch {
+		case webhook.Error != "":
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", name, webhook.Error))
+		case len(webhook.Certificates) == 0:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s has no caBundle; the API server cannot verify the webhook and admission requests fail", name))
+		default:
+			for _, warning := range utils.ValidateCertificateExpiry(webhook.Certificates, warningDays) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s caBundle: %s", name, warning))
+			}
+			if !caBundleIssuesServing(webhook.Certificates, result.CertSources) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s caBundle did not issue the serving certificate mounted by the controller", name))
+			}
+		}
+	}
+
+	if component.apiServiceName != "" {
+		apiService := getAPIServiceCA(ctx, clientset, component.apiServiceName)
+		result.APIService = apiService
+		switch {
+		case apiService.Error != "":
+			result.Notes = append(result.Notes, apiService.Error)
+		case apiService.Available != "" && apiService.Available != "True":
+			result.Warnings = append(result.Warnings, fmt.Sprintf("APIService %s is not available: %s", apiService.Name, apiService.Message))
+		}
+		if apiService.InsecureSkipTLSVerify {
+			result.Notes = append(result.Notes, fmt.Sprintf("APIService %s skips TLS verification (insecureSkipTLSVerify), so its serving certificate is not checked by the API server", apiService.Name))
+		}
+		for _, warning := range utils.ValidateCertificateExpiry(apiService.Certificates, warningDays) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("APIService %s caBundle: %s", apiService.Name, warning))
+		}
+	}
+
+	sort.Strings(result.Warnings)
+	result.Status = systemComponentStatus(result)
+	return result
+}
+
+// systemComponentStatus is critical when a certificate has expired, a
+// webhook has no caBundle, or no pod is ready; warning when there are other
+// warnings; and healthy otherwise
+func systemComponentStatus(result SystemComponentReport) string {
+	if len(result.Warnings) == 0 {
+		return SystemHealthy
+	}
+	for _, warning := range result.Warnings {
+		if strings.Contains(warning, "EXPIRED") || strings.Contains(warning, "no caBundle") || warning == "No pods are ready" {
+			return SystemCritical
+		}
+	}
+	return SystemWarning
+}
+
+// systemComponentPod returns the name of a running pod matching selector,
+// or of any matching pod if none is running
+func systemComponentPod(ctx context.Context, clientset kubernetes.Interface, selector *metav1.LabelSelector) string {
+	if selector == nil {
+		return ""
+	}
+	pods, err := clientset.CoreV1().Pods(SystemNamespace).List(ctx, metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(selector)})
+	if err != nil || len(pods.Items) == 0 {
+		return ""
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			return pod.Name
+		}
+	}
+	return pods.Items[0].Name
+}
+
+// listSystemWebhooks returns the admission webhooks that call services in
+// kube-system, with their parsed caBundles
+func listSystemWebhooks(ctx context.Context, clientset kubernetes.Interface) ([]WebhookCABundle, error) {
+	return ListWebhookCABundles(ctx, clientset, SystemNamespace)
+}
+
+// ListWebhookCABundles returns the admission webhooks that call services in
+// a namespace, with their parsed caBundles. With an empty namespace, every
+// webhook is returned, including those called by URL.
+func ListWebhookCABundles(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]WebhookCABundle, error) {
+	var webhooks []WebhookCABundle
+	add := func(kind, configuration, webhook, service string, clientConfig admissionregistrationv1.WebhookClientConfig, failurePolicy *admissionregistrationv1.FailurePolicyType) {
+		entry := WebhookCABundle{Kind: kind, Configuration: configuration, Webhook: webhook, Service: service}
+		if clientConfig.URL != nil {
+			entry.URL = *clientConfig.URL
+		}
+		if failurePolicy != nil {
+			entry.FailurePolicy = string(*failurePolicy)
+		}
+		caBundle := clientConfig.CABundle
+		if len(caBundle) > 0 {
+			certs, err := utils.ParseCertificateBundle(string(caBundle))
+			if err != nil {
+				entry.Error = fmt.Sprintf("failed to parse caBundle: %v", err)
+			}
+			entry.Certificates = certs
+		}
+		webhooks = append(webhooks, entry)
+	}
+
+	mutating, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mutating webhook configurations: %w", err)
+	}
+	for _, configuration := range mutating.Items {
+		for _, webhook := range configuration.Webhooks {
+			if service, ok := webhookService(webhook.ClientConfig.Service, namespace); ok {
+				add("mutating", configuration.Name, webhook.Name, service, webhook.ClientConfig, webhook.FailurePolicy)
+			}
+		}
+	}
+
+	validating, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list validating webhook configurations: %w", err)
+	}
+	for _, configuration := range validating.Items {
+		for _, webhook := range configuration.Webhooks {
+			if service, ok := webhookService(webhook.ClientConfig.Service, namespace); ok {
+				add("validating", configuration.Name, webhook.Name, service, webhook.ClientConfig, webhook.FailurePolicy)
+			}
+		}
+	}
+	return webhooks, nil
+}
+
+// webhookService returns the name of the service a webhook calls, and
+// whether the webhook is in scope for namespace; webhooks called by URL
+// have no service and are only in scope for all namespaces
+func webhookService(service *admissionregistrationv1.ServiceReference, namespace string) (string, bool) {
+	if service == nil {
+		return "", namespace == ""
+	}
+	if namespace == "" {
+		return service.Namespace + "/" + service.Name, true
+	}
+	return service.Name, service.Namespace == namespace
+}
+
+// getAPIServiceCA reads an APIService through the raw REST client, since
+// the aggregator clientset is not a dependency
+func getAPIServiceCA(ctx context.Context, clientset kubernetes.Interface, name string) *APIServiceCA {
+	result := &APIServiceCA{Name: name}
+	restClient := clientset.Discovery().RESTClient()
+	if restClient == nil {
+		result.Error = "APIServices cannot be read with this client"
+		return result
+	}
+	raw, err := restClient.Get().AbsPath("/apis/apiregistration.k8s.io/v1/apiservices", name).DoRaw(ctx)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to get APIService %s: %v", name, err)
+		return result
+	}
+
+	var service apiService
+	if err := json.Unmarshal(raw, &service); err != nil {
+		result.Error = fmt.Sprintf("failed to decode APIService %s: %v", name, err)
+		return result
+	}
+	return service.caInfo()
+}
+
+// caBundleIssuesServing reports whether a caBundle contains the issuer of the
+// leaf certificates mounted by the component. It is true when the component
+// mounts no leaf certificate, since there is nothing to compare.
+func caBundleIssuesServing(caBundle []*utils.CertificateInfo, sources map[string]*CertificateSource) bool {
+	subjects := make(map[string]bool, len(caBundle))
+	for _, cert := range caBundle {
+		subjects[cert.Subject] = true
+	}
+	leaves := 0
+	for _, source := range sources {
+		for _, cert := range source.Certificates {
+			if cert.IsCA {
+				continue
+			}
+			leaves++
+			if subjects[cert.Issuer] {
+				return true
+			}
+		}
+	}
+	return leaves == 0
+}
+
+// hasAnyPrefix reports whether s starts with one of prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
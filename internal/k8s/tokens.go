@@ -0,0 +1,333 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// How a pod receives a service account token
+const (
+	TokenProjected = "projected"     // requested and rotated by the kubelet through a projected volume
+	TokenLegacy    = "legacy_secret" // read from a kubernetes.io/service-account-token secret
+)
+
+// Labels the API server sets on legacy token secrets (Kubernetes 1.28 and
+// later)
+const (
+	labelLegacyTokenLastUsed     = "kubernetes.io/legacy-token-last-used"
+	labelLegacyTokenInvalidSince = "kubernetes.io/legacy-token-invalid-since"
+)
+
+// defaultTokenExpirationSeconds is the lifetime of a projected token whose
+// volume does not set expirationSeconds
+const defaultTokenExpirationSeconds = 3600
+
+// MaxRotatedTokenSeconds is the lifetime beyond which a projected token
+// outlives the kubelet's 24-hour refresh, and so stays usable long after a
+// leak
+const MaxRotatedTokenSeconds = 24 * 60 * 60
+
+// PodServiceAccountTokens are the service account tokens the containers of
+// a pod receive
+type PodServiceAccountTokens struct {
+	Namespace      string                `json:"namespace"`
+	Pod            string                `json:"pod"`
+	ServiceAccount string                `json:"service_account"`
+	Tokens         []ServiceAccountToken `json:"tokens"`
+}
+
+// ServiceAccountToken is a token from a projected serviceAccountToken
+// source, or from a legacy token secret mounted or used as an environment
+// variable. Projected tokens are issued by the TokenRequest API inside the
+// kubelet, so only their requested lifetime is known; legacy tokens are
+// read from the secret and their JWT claims decoded, without verifying the
+// signature.
+type ServiceAccountToken struct {
+	Type       string   `json:"type"`
+	Volume     string   `json:"volume,omitempty"`
+	Path       string   `json:"path,omitempty"`
+	Secret     string   `json:"secret,omitempty"`
+	Containers []string `json:"containers,omitempty"`
+	// Mounts lists how the containers use a legacy token secret: volume,
+	// subPath, or env
+	Mounts []string `json:"mounts,omitempty"`
+	// ServiceAccount is the service account of a legacy token secret, which
+	// need not be the one the pod runs as
+	ServiceAccount    string `json:"service_account,omitempty"`
+	ExpirationSeconds int64  `json:"expiration_seconds,omitempty"`
+	// Audiences is empty for a projected token issued for the API server's
+	// own audiences
+	Audiences       []string   `json:"audiences,omitempty"`
+	Issuer          string     `json:"issuer,omitempty"`
+	Subject         string     `json:"subject,omitempty"`
+	IssuedAt        *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	DaysUntilExpiry *int       `json:"days_until_expiry,omitempty"`
+	IsExpired       bool       `json:"is_expired,omitempty"`
+	NeverExpires    bool       `json:"never_expires,omitempty"`
+	LastUsed        string     `json:"last_used,omitempty"`
+	InvalidSince    string     `json:"invalid_since,omitempty"`
+	Warnings        []string   `json:"warnings,omitempty"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// jwtClaims are the registered claims of a service account token read from
+// its payload
+type jwtClaims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	IssuedAt  *int64          `json:"iat"`
+}
+
+// AnalyzeServiceAccountTokens lists the service account tokens of the pods
+// of a namespace: the serviceAccountToken sources of projected volumes,
+// including the kube-api-access volume the API server adds to every pod,
+// and the legacy token secrets the containers mount or read as environment
+// variables. Pods without any token are left out.
+func AnalyzeServiceAccountTokens(ctx context.Context, clientset kubernetes.Interface, namespace string, warningDays int) ([]PodServiceAccountTokens, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %s: %w", namespace, err)
+	}
+
+	secrets := make(map[string]*corev1.Secret) // nil when unreadable
+	secret := func(name string) *corev1.Secret {
+		if cached, ok := secrets[name]; ok {
+			return cached
+		}
+		s, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			s = nil
+		}
+		secrets[name] = s
+		return s
+	}
+
+	now := time.Now()
+	var results []PodServiceAccountTokens
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		tokens := projectedTokens(pod)
+		tokens = append(tokens, legacyTokens(pod, secret, warningDays, now)...)
+		if len(tokens) == 0 {
+			continue
+		}
+		results = append(results, PodServiceAccountTokens{
+			Namespace:      pod.Namespace,
+			Pod:            pod.Name,
+			ServiceAccount: podServiceAccount(pod),
+			Tokens:         tokens,
+		})
+	}
+	return results, nil
+}
+
+// podServiceAccount returns the service account a pod runs as
+func podServiceAccount(pod *corev1.Pod) string {
+	if pod.Spec.ServiceAccountName != "" {
+		return pod.Spec.ServiceAccountName
+	}
+	return "default"
+}
+
+// volumeContainers returns the containers of a pod mounting a volume,
+// sorted
+func volumeContainers(pod *corev1.Pod, volume string) []string {
+	var containers []string
+	for _, container := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		for _, mount := range container.VolumeMounts {
+			if mount.Name == volume {
+				containers = append(containers, container.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(containers)
+	return containers
+}
+
+// projectedTokens returns the serviceAccountToken sources of the projected
+// volumes of a pod
+func projectedTokens(pod *corev1.Pod) []ServiceAccountToken {
+	var tokens []ServiceAccountToken
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			projection := source.ServiceAccountToken
+			if projection == nil {
+				continue
+			}
+			token := ServiceAccountToken{
+				Type:              TokenProjected,
+				Volume:            volume.Name,
+				Path:              projection.Path,
+				Containers:        volumeContainers(pod, volume.Name),
+				ExpirationSeconds: defaultTokenExpirationSeconds,
+			}
+			if projection.ExpirationSeconds != nil {
+				token.ExpirationSeconds = *projection.ExpirationSeconds
+			}
+			if projection.Audience != "" {
+				token.Audiences = []string{projection.Audience}
+			}
+			if token.ExpirationSeconds > MaxRotatedTokenSeconds {
+				token.Warnings = append(token.Warnings, fmt.Sprintf("Token is valid for %s; the kubelet refreshes it after 24 hours, but a leaked copy stays usable until it expires", time.Duration(token.ExpirationSeconds)*time.Second))
+			}
+			if len(token.Containers) == 0 {
+				token.Warnings = append(token.Warnings, "No container mounts this volume")
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// legacyTokens returns the legacy token secrets the containers of a pod
+// mount or read as environment variables, with the claims of their JWT
+func legacyTokens(pod *corev1.Pod, secret func(name string) *corev1.Secret, warningDays int, now time.Time) []ServiceAccountToken {
+	volumes := make(map[string]string) // secret name -> volume name
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Secret != nil {
+			volumes[volume.Secret.SecretName] = volume.Name
+		}
+	}
+
+	var names []string
+	bySecret := make(map[string]*ServiceAccountToken)
+	for _, use := range podSecretUses(pod) {
+		token, ok := bySecret[use.secret]
+		if !ok {
+			s := secret(use.secret)
+			if s == nil || s.Type != corev1.SecretTypeServiceAccountToken {
+				bySecret[use.secret] = nil
+				continue
+			}
+			token = legacyToken(s, warningDays, now)
+			token.Volume = volumes[use.secret]
+			bySecret[use.secret] = token
+			names = append(names, use.secret)
+		}
+		if token == nil {
+			continue
+		}
+		token.Containers = appendUnique(token.Containers, use.container)
+		token.Mounts = appendUnique(token.Mounts, use.mount)
+	}
+
+	sort.Strings(names)
+	tokens := make([]ServiceAccountToken, 0, len(names))
+	for _, name := range names {
+		tokens = append(tokens, *bySecret[name])
+	}
+	return tokens
+}
+
+// appendUnique appends value to values unless it is already there
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// legacyToken decodes the token of a kubernetes.io/service-account-token
+// secret. Tokens created by the token controller carry no exp claim and
+// stay valid until the secret is deleted or the API server invalidates
+// them for being unused.
+func legacyToken(secret *corev1.Secret, warningDays int, now time.Time) *ServiceAccountToken {
+	token := &ServiceAccountToken{
+		Type:           TokenLegacy,
+		Secret:         secret.Name,
+		ServiceAccount: secret.Annotations[corev1.ServiceAccountNameKey],
+		LastUsed:       secret.Labels[labelLegacyTokenLastUsed],
+		InvalidSince:   secret.Labels[labelLegacyTokenInvalidSince],
+	}
+	if token.InvalidSince != "" {
+		token.Warnings = append(token.Warnings, fmt.Sprintf("The API server has rejected this token since %s because it went unused; pods still relying on it fail to authenticate", token.InvalidSince))
+	}
+
+	raw := secret.Data[corev1.ServiceAccountTokenKey]
+	if len(raw) == 0 {
+		token.Error = "secret has no token yet; the token controller populates it once the service account exists"
+		return token
+	}
+	claims, err := parseJWTClaims(string(raw))
+	if err != nil {
+		token.Error = err.Error()
+		return token
+	}
+
+	token.Issuer, token.Subject = claims.Issuer, claims.Subject
+	token.Audiences = claims.audiences()
+	if claims.IssuedAt != nil {
+		issued := time.Unix(*claims.IssuedAt, 0).UTC()
+		token.IssuedAt = &issued
+	}
+	if claims.ExpiresAt == nil {
+		token.NeverExpires = true
+		token.Warnings = append(token.Warnings, "Long-lived token without expiry; a leaked copy is valid until the secret is deleted. Use a projected serviceAccountToken volume instead")
+		return token
+	}
+
+	expires := time.Unix(*claims.ExpiresAt, 0).UTC()
+	days := int(expires.Sub(now).Hours() / 24)
+	token.ExpiresAt, token.DaysUntilExpiry = &expires, &days
+	switch {
+	case !expires.After(now):
+		token.IsExpired = true
+		token.Warnings = append(token.Warnings, fmt.Sprintf("Token expired on %s; the API server rejects it", expires.Format(time.RFC3339)))
+	case days <= warningDays:
+		token.Warnings = append(token.Warnings, fmt.Sprintf("Token expires in %d days and is not rotated, since it is stored in a secret", days))
+	}
+	return token
+}
+
+// parseJWTClaims decodes the payload of a JWT. The signature is not
+// checked: the claims are only reported, never trusted.
+func parseJWTClaims(token string) (*jwtClaims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT: expected 3 segments, found %d", len(parts))
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token payload: %w", err)
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("failed to parse token claims: %w", err)
+	}
+	return &claims, nil
+}
+
+// audiences returns the aud claim, which is a string or a list of strings
+func (c *jwtClaims) audiences() []string {
+	if len(c.Audience) == 0 {
+		return nil
+	}
+	var audience string
+	if err := json.Unmarshal(c.Audience, &audience); err == nil {
+		if audience == "" {
+			return nil
+		}
+		return []string{audience}
+	}
+	var audiences []string
+	json.Unmarshal(c.Audience, &audiences)
+	return audiences
+}
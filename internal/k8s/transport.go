@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s-web-service/internal/logging"
+	"k8s-web-service/internal/readonly"
+	"k8s-web-service/internal/telemetry"
+)
+
+// debugRoundTripper logs every Kubernetes API request at debug level
+type debugRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (d *debugRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := d.next.RoundTrip(req)
+	if err != nil {
+		logging.Debugf("client-go: %s %s failed after %s: %v", req.Method, req.URL, time.Since(start), err)
+		return nil, err
+	}
+	logging.Debugf("client-go: %s %s %d in %s", req.Method, req.URL, resp.StatusCode, time.Since(start))
+	return resp, nil
+}
+
+// wrapDebugTransport adds request logging when debug logging is enabled
+func wrapDebugTransport(rt http.RoundTripper) http.RoundTripper {
+	if !logging.DebugEnabled() {
+		return rt
+	}
+	return &debugRoundTripper{next: rt}
+}
+
+// metricsRoundTripper times every Kubernetes API request by resource and
+// verb. Transport failures and error responses other than 404 count as
+// errors, since looking up objects that may not exist is routine.
+type metricsRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (m *metricsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource, verb := requestVerb(req)
+	done := telemetry.Time(telemetry.Call{System: telemetry.SystemKubernetes, Service: resource, Operation: verb})
+	resp, err := m.next.RoundTrip(req)
+	switch {
+	case err != nil:
+		done(err)
+	case resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound:
+		done(fmt.Errorf("%s", resp.Status))
+	default:
+		done(nil)
+	}
+	return resp, err
+}
+
+// requestVerb returns the resource, with its subresource, and the
+// Kubernetes verb of an API request. Requests outside /api and /apis, such
+// as /version, are reported by path and method.
+func requestVerb(req *http.Request) (string, string) {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		segments = segments[2:]
+	case len(segments) >= 3 && segments[0] == "apis":
+		segments = segments[3:]
+	default:
+		return req.URL.Path, strings.ToLower(req.Method)
+	}
+	if len(segments) >= 3 && segments[0] == "namespaces" {
+		segments = segments[2:]
+	}
+	if len(segments) == 0 {
+		return "discovery", strings.ToLower(req.Method)
+	}
+
+	resource := segments[0]
+	named := len(segments) >= 2
+	if len(segments) >= 3 {
+		resource += "/" + segments[2]
+	}
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("watch") == "true" {
+			return resource, "watch"
+		}
+		if named {
+			return resource, "get"
+		}
+		return resource, "list"
+	case http.MethodPost:
+		return resource, "create"
+	case http.MethodPut:
+		return resource, "update"
+	case http.MethodPatch:
+		return resource, "patch"
+	case http.MethodDelete:
+		if named {
+			return resource, "delete"
+		}
+		return resource, "deletecollection"
+	}
+	return resource, strings.ToLower(req.Method)
+}
+
+// bearerRoundTripper sets the current EKS token of a client on every
+// Kubernetes API request, refreshing it when it nears expiry
+type bearerRoundTripper struct {
+	client *Client
+	next   http.RoundTripper
+}
+
+func (b *bearerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := b.client.bearerToken()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return b.next.RoundTrip(req)
+}
+
+// readOnlyRoundTripper refuses Kubernetes API requests that could modify the cluster
+type readOnlyRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (r *readOnlyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !readonly.AllowKubernetesRequest(req.Method, req.URL.Path) {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, readonly.ErrReadOnly)
+	}
+	return r.next.RoundTrip(req)
+}
+
+// transportWrapper returns the wrapper applied to every Kubernetes API
+// request: debug logging, timing, and the read-only guard when enabled
+func transportWrapper(readOnly bool) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		rt = wrapDebugTransport(rt)
+		rt = &metricsRoundTripper{next: rt}
+		if readOnly {
+			rt = &readOnlyRoundTripper{next: rt}
+		}
+		return rt
+	}
+}
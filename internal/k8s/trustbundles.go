@@ -0,0 +1,172 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// Reasons a configmap is considered a trust bundle
+const (
+	BundleReasonWellKnown = "well_known_name"
+	BundleReasonName      = "name_pattern"
+	BundleReasonContent   = "content"
+)
+
+// pemCertificateMarker starts every PEM-encoded certificate
+const pemCertificateMarker = "-----BEGIN CERTIFICATE-----"
+
+// wellKnownBundles are configmaps that always hold CA bundles
+var wellKnownBundles = map[string]bool{
+	"kube-root-ca.crt":                   true,
+	"extension-apiserver-authentication": true,
+}
+
+// bundleNamePatterns are name fragments that suggest a CA bundle
+var bundleNamePatterns = []string{"ca-bundle", "trust-bundle", "cabundle", "trusted-ca", "-ca-certs", "root-ca"}
+
+// TrustBundleMember describes one certificate of a trust bundle
+type TrustBundleMember struct {
+	Key          string    `json:"key"`
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	NotAfter     time.Time `json:"not_after"`
+	DaysUntilExp int       `json:"days_until_expiry"`
+	IsExpired    bool      `json:"is_expired"`
+	IsRoot       bool      `json:"is_root"`
+}
+
+// TrustBundleKey describes one key of a trust bundle configmap
+type TrustBundleKey struct {
+	Key          string `json:"key"`
+	SizeBytes    int    `json:"size_bytes"`
+	Certificates int    `json:"certificates"`
+}
+
+// TrustBundle summarizes a configmap that likely holds a CA bundle
+type TrustBundle struct {
+	Name              string              `json:"name"`
+	Namespace         string              `json:"namespace"`
+	Reason            string              `json:"reason"`
+	Keys              []TrustBundleKey    `json:"keys"`
+	SizeBytes         int                 `json:"size_bytes"`
+	TotalCertificates int                 `json:"total_certificates"`
+	SoonestExpiry     *TrustBundleMember  `json:"soonest_expiry,omitempty"`
+	ExpiredCount      int                 `json:"expired_count"`
+	ExpiredRoots      []TrustBundleMember `json:"expired_roots,omitempty"`
+}
+
+// FindTrustBundles finds the configmaps of a namespace that likely contain
+// CA bundles, by well-known name, name pattern, or PEM content holding a CA
+// or several certificates, and summarizes each bundle
+func FindTrustBundles(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]TrustBundle, error) {
+	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps in namespace %s: %w", namespace, err)
+	}
+
+	var bundles []TrustBundle
+	for i := range configMaps.Items {
+		if bundle, ok := trustBundle(&configMaps.Items[i]); ok {
+			bundles = append(bundles, bundle)
+		}
+	}
+	sort.Slice(bundles, func(i, j int) bool { return bundles[i].Name < bundles[j].Name })
+	return bundles, nil
+}
+
+// trustBundle summarizes a configmap, reporting false if it does not look
+// like a trust bundle
+func trustBundle(configMap *corev1.ConfigMap) (TrustBundle, bool) {
+	bundle := TrustBundle{
+		Name:      configMap.Name,
+		Namespace: configMap.Namespace,
+		Keys:      []TrustBundleKey{},
+	}
+
+	values := make(map[string]string, len(configMap.Data)+len(configMap.BinaryData))
+	for key, value := range configMap.Data {
+		values[key] = value
+	}
+	for key, value := range configMap.BinaryData {
+		values[key] = string(value)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hasCA := false
+	var members []TrustBundleMember
+	for _, key := range keys {
+		value := values[key]
+		bundle.SizeBytes += len(value)
+		if !strings.Contains(value, pemCertificateMarker) {
+			continue
+		}
+
+		certs, _ := utils.ParseCertificateBundle(value)
+		bundle.Keys = append(bundle.Keys, TrustBundleKey{Key: key, SizeBytes: len(value), Certificates: len(certs)})
+		for _, cert := range certs {
+			hasCA = hasCA || cert.IsCA
+			members = append(members, TrustBundleMember{
+				Key:          key,
+				Subject:      cert.Subject,
+				Issuer:       cert.Issuer,
+				NotAfter:     cert.NotAfter,
+				DaysUntilExp: cert.DaysUntilExp,
+				IsExpired:    cert.IsExpired,
+				IsRoot:       cert.IsCA && cert.Subject == cert.Issuer,
+			})
+		}
+	}
+
+	switch {
+	case wellKnownBundles[configMap.Name]:
+		bundle.Reason = BundleReasonWellKnown
+	case matchesBundleName(configMap.Name):
+		bundle.Reason = BundleReasonName
+	case hasCA || len(members) > 1:
+		bundle.Reason = BundleReasonContent
+	default:
+		return bundle, false
+	}
+
+	bundle.TotalCertificates = len(members)
+	for i := range members {
+		member := members[i]
+		if bundle.SoonestExpiry == nil || member.NotAfter.Before(bundle.SoonestExpiry.NotAfter) {
+			bundle.SoonestExpiry = &member
+		}
+		if member.IsExpired {
+			bundle.ExpiredCount++
+			if member.IsRoot {
+				bundle.ExpiredRoots = append(bundle.ExpiredRoots, member)
+			}
+		}
+	}
+	return bundle, true
+}
+
+// matchesBundleName reports whether a configmap name suggests a CA bundle
+func matchesBundleName(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, "-ca") || strings.HasSuffix(name, "-ca.crt") {
+		return true
+	}
+	for _, pattern := range bundleNamePatterns {
+		if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,285 @@
+package k8s
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// Sources of the CA bundles mounted into pods
+const (
+	TrustStoreServiceAccount = "serviceaccount" // kube-root-ca.crt, projected as ca.crt
+	TrustStoreConfigMap      = "configmap"      // a trust bundle configmap
+)
+
+// rootCAConfigMap is published into every namespace by the API server and
+// projected into pods as the service account ca.crt
+const rootCAConfigMap = "kube-root-ca.crt"
+
+// apiServerDialTimeout bounds the TLS handshake with the API server
+const apiServerDialTimeout = 10 * time.Second
+
+// TrustStoreCheck is whether a CA bundle mounted into a pod validates the
+// API server's serving chain
+type TrustStoreCheck struct {
+	Source    string   `json:"source"`
+	ConfigMap string   `json:"configmap"`
+	Keys      []string `json:"keys"`
+	// MountPaths are where the containers of the pod mount the bundle
+	MountPaths   []string `json:"mount_paths,omitempty"`
+	Certificates int      `json:"certificates"`
+	Valid        bool     `json:"valid"`
+	// Truncated is true when a PEM block of the bundle is cut off or does
+	// not parse
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PodTrustStores are the checks of the CA bundles mounted into a pod
+type PodTrustStores struct {
+	Pod         string            `json:"pod"`
+	Namespace   string            `json:"namespace"`
+	Valid       bool              `json:"valid"`
+	TrustStores []TrustStoreCheck `json:"trust_stores"`
+}
+
+// TrustStoreReport validates the CA bundles of the pods of a namespace
+// against the chain the API server currently serves
+type TrustStoreReport struct {
+	APIServer   string                   `json:"api_server"`
+	ServedChain []*utils.CertificateInfo `json:"served_chain"`
+	TotalPods   int                      `json:"total_pods"`
+	InvalidPods int                      `json:"invalid_pods"`
+	Pods        []PodTrustStores         `json:"pods"`
+}
+
+// APIServerChain connects to the API server of the kubeconfig and returns
+// the certificate chain it serves, leaf first. The chain is not verified, so
+// that a chain the kubeconfig CA no longer validates is still returned.
+func (c *Client) APIServerChain(ctx context.Context) ([]*x509.Certificate, string, error) {
+	endpoint := c.GetEKSDetails().ClusterEndpoint
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Hostname() == "" {
+		return nil, endpoint, fmt.Errorf("invalid API server endpoint %q", endpoint)
+	}
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), "443")
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: apiServerDialTimeout},
+		Config: &tls.Config{
+			ServerName: parsed.Hostname(),
+			// The chain is validated against each trust store instead
+			InsecureSkipVerify: true,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, endpoint, fmt.Errorf("failed to connect to API server %s: %w", host, err)
+	}
+	defer conn.Close()
+
+	chain := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, endpoint, fmt.Errorf("API server %s presented no certificate", host)
+	}
+	return chain, endpoint, nil
+}
+
+// ValidatePodTrustStores checks that the service account ca.crt and the
+// trust bundle configmaps mounted into each pod of a namespace validate the
+// API server's serving chain, leaf first. Pods without a mounted CA bundle
+// are left out. Each configmap is validated once, however many pods mount
+// it.
+func ValidatePodTrustStores(ctx context.Context, clientset kubernetes.Interface, namespace string, chain []*x509.Certificate) (*TrustStoreReport, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("the API server chain is empty")
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %s: %w", namespace, err)
+	}
+	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps in namespace %s: %w", namespace, err)
+	}
+	byName := make(map[string]*corev1.ConfigMap, len(configMaps.Items))
+	for i := range configMaps.Items {
+		byName[configMaps.Items[i].Name] = &configMaps.Items[i]
+	}
+
+	report := &TrustStoreReport{Pods: []PodTrustStores{}}
+	for _, cert := range chain {
+		info, err := utils.ParseCertificate(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+		if err == nil {
+			report.ServedChain = append(report.ServedChain, info)
+		}
+	}
+
+	validated := make(map[string]TrustStoreCheck)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		mounts := podTrustStoreMounts(pod, byName)
+		if len(mounts) == 0 {
+			continue
+		}
+
+		result := PodTrustStores{Pod: pod.Name, Namespace: pod.Namespace, Valid: true}
+		for _, mount := range mounts {
+			key := mount.ConfigMap + "/" + strings.Join(mount.Keys, ",")
+			check, ok := validated[key]
+			if !ok {
+				check = validateTrustStore(byName[mount.ConfigMap], mount.Keys, chain)
+				validated[key] = check
+			}
+			check.Source = mount.Source
+			check.ConfigMap = mount.ConfigMap
+			check.Keys = mount.Keys
+			check.MountPaths = mount.MountPaths
+			result.Valid = result.Valid && check.Valid
+			result.TrustStores = append(result.TrustStores, check)
+		}
+		report.Pods = append(report.Pods, result)
+		if !result.Valid {
+			report.InvalidPods++
+		}
+	}
+	report.TotalPods = len(report.Pods)
+	sort.Slice(report.Pods, func(i, j int) bool { return report.Pods[i].Pod < report.Pods[j].Pod })
+	return report, nil
+}
+
+// podTrustStoreMounts returns the CA bundles a pod mounts: the projected
+// kube-root-ca.crt and configmaps that look like trust bundles, with the
+// keys and paths they are mounted at
+func podTrustStoreMounts(pod *corev1.Pod, configMaps map[string]*corev1.ConfigMap) []TrustStoreCheck {
+	mountPaths := make(map[string][]string) // volume name -> mount paths
+	for _, container := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		for _, mount := range container.VolumeMounts {
+			mountPaths[mount.Name] = append(mountPaths[mount.Name], container.Name+":"+mount.MountPath)
+		}
+	}
+
+	var mounts []TrustStoreCheck
+	add := func(volume string, name string, items []corev1.KeyToPath) {
+		source := TrustStoreConfigMap
+		if name == rootCAConfigMap {
+			source = TrustStoreServiceAccount
+		} else if configMap, ok := configMaps[name]; !ok {
+			return
+		} else if _, ok := trustBundle(configMap); !ok {
+			return
+		}
+		var keys []string
+		for _, item := range items {
+			keys = append(keys, item.Key)
+		}
+		sort.Strings(keys)
+		mounts = append(mounts, TrustStoreCheck{Source: source, ConfigMap: name, Keys: keys, MountPaths: mountPaths[volume]})
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.ConfigMap != nil {
+			add(volume.Name, volume.ConfigMap.Name, volume.ConfigMap.Items)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					add(volume.Name, source.ConfigMap.Name, source.ConfigMap.Items)
+				}
+			}
+		}
+	}
+	return mounts
+}
+
+// validateTrustStore verifies the API server chain with the certificates of
+// the given keys of a configmap, or all of its keys if none are given
+func validateTrustStore(configMap *corev1.ConfigMap, keys []string, chain []*x509.Certificate) TrustStoreCheck {
+	var check TrustStoreCheck
+	if configMap == nil {
+		check.Error = "configmap not found; the pod cannot start until it exists"
+		return check
+	}
+	if len(keys) == 0 {
+		for key := range configMap.Data {
+			keys = append(keys, key)
+		}
+		for key := range configMap.BinaryData {
+			keys = append(keys, key)
+		}
+	}
+
+	roots := x509.NewCertPool()
+	for _, key := range keys {
+		value, ok := configMap.Data[key]
+		if !ok {
+			value = string(configMap.BinaryData[key])
+		}
+		certs, truncated := parseTrustBundle(value)
+		check.Truncated = check.Truncated || truncated
+		for _, cert := range certs {
+			roots.AddCert(cert)
+		}
+		check.Certificates += len(certs)
+	}
+	if check.Certificates == 0 {
+		check.Error = "the bundle holds no certificates"
+		return check
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		check.Error = fmt.Sprintf("does not validate the API server chain: %v", err)
+		return check
+	}
+	check.Valid = true
+	return check
+}
+
+// parseTrustBundle parses the PEM certificates of a bundle, reporting it
+// truncated when a block is cut off or does not parse
+func parseTrustBundle(bundle string) ([]*x509.Certificate, bool) {
+	var certs []*x509.Certificate
+	truncated := false
+	rest := []byte(bundle)
+	for {
+		block, remaining := pem.Decode(rest)
+		if block == nil {
+			truncated = truncated || strings.Contains(string(rest), pemCertificateMarker)
+			return certs, truncated
+		}
+		rest = remaining
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			truncated = true
+			continue
+		}
+		certs = append(certs, cert)
+	}
+}
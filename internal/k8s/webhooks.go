@@ -0,0 +1,160 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/pkg/utils"
+)
+
+// apiService holds the fields of an APIService read by the service
+type apiService struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		// Service is nil for the APIs served by the API server itself
+		Service *struct {
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+		} `json:"service"`
+		CABundle              []byte `json:"caBundle"`
+		InsecureSkipTLSVerify bool   `json:"insecureSkipTLSVerify"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// caInfo returns the TLS configuration of an APIService with its parsed
+// caBundle
+func (a *apiService) caInfo() *APIServiceCA {
+	result := &APIServiceCA{Name: a.Metadata.Name, InsecureSkipTLSVerify: a.Spec.InsecureSkipTLSVerify}
+	if a.Spec.Service != nil {
+		result.Service = a.Spec.Service.Namespace + "/" + a.Spec.Service.Name
+	}
+	for _, condition := range a.Status.Conditions {
+		if condition.Type == "Available" {
+			result.Available = condition.Status
+			result.Message = condition.Message
+		}
+	}
+	if len(a.Spec.CABundle) > 0 {
+		certs, err := utils.ParseCertificateBundle(string(a.Spec.CABundle))
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to parse caBundle: %v", err)
+		}
+		result.Certificates = certs
+	}
+	return result
+}
+
+// ListAPIServiceCAs returns the aggregated APIServices, those served by a
+// service rather than the API server itself, with their parsed caBundles.
+// APIServices are read through the raw REST client, since the aggregator
+// clientset is not a dependency.
+func ListAPIServiceCAs(ctx context.Context, clientset kubernetes.Interface) ([]APIServiceCA, error) {
+	restClient := clientset.Discovery().RESTClient()
+	if restClient == nil {
+		return nil, fmt.Errorf("APIServices cannot be read with this client")
+	}
+	raw, err := restClient.Get().AbsPath("/apis/apiregistration.k8s.io/v1/apiservices").DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list APIServices: %w", err)
+	}
+	var list struct {
+		Items []apiService `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode APIServices: %w", err)
+	}
+
+	var services []APIServiceCA
+	for i := range list.Items {
+		if list.Items[i].Spec.Service != nil {
+			services = append(services, *list.Items[i].caInfo())
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services, nil
+}
+
+// WebhookCAReport is the expiry of the caBundles the API server uses to
+// call admission webhooks and aggregated APIs
+type WebhookCAReport struct {
+	WarningDays int               `json:"warning_days"`
+	Webhooks    []WebhookCABundle `json:"webhooks"`
+	APIServices []APIServiceCA    `json:"api_services"`
+	// APIServiceError is set when the APIServices could not be listed; the
+	// webhooks are still reported
+	APIServiceError string   `json:"api_service_error,omitempty"`
+	Warnings        []string `json:"warnings,omitempty"`
+	TotalWarnings   int      `json:"total_warnings"`
+}
+
+// AnalyzeWebhookCABundles checks the caBundle of every mutating and
+// validating admission webhook and aggregated APIService. An expired CA
+// fails every call the API server makes to the webhook or API: admission
+// requests are rejected under failurePolicy Fail and silently skip the
+// webhook under Ignore.
+func AnalyzeWebhookCABundles(ctx context.Context, clientset kubernetes.Interface, warningDays int) (*WebhookCAReport, error) {
+	webhooks, err := ListWebhookCABundles(ctx, clientset, "")
+	if err != nil {
+		return nil, err
+	}
+	report := &WebhookCAReport{WarningDays: warningDays, Webhooks: webhooks, APIServices: []APIServiceCA{}}
+	if report.Webhooks == nil {
+		report.Webhooks = []WebhookCABundle{}
+	}
+	sort.Slice(report.Webhooks, func(i, j int) bool {
+		a, b := report.Webhooks[i], report.Webhooks[j]
+		if a.Configuration != b.Configuration {
+			return a.Configuration < b.Configuration
+		}
+		return a.Webhook < b.Webhook
+	})
+
+	for _, webhook := range report.Webhooks {
+		name := fmt.Sprintf("%s webhook %s/%s", webhook.Kind, webhook.Configuration, webhook.Webhook)
+		switch {
+		case webhook.Error != "":
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %s", name, webhook.Error))
+		case len(webhook.Certificates) == 0 && webhook.URL == "":
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s has no caBundle; the API server cannot verify the webhook and its calls fail", name))
+		}
+		for _, warning := range utils.ValidateCertificateExpiry(webhook.Certificates, warningDays) {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s caBundle (failurePolicy %s): %s", name, webhook.FailurePolicy, warning))
+		}
+	}
+
+	services, err := ListAPIServiceCAs(ctx, clientset)
+	if err != nil {
+		report.APIServiceError = err.Error()
+	} else {
+		report.APIServices = services
+	}
+	for _, service := range report.APIServices {
+		switch {
+		case service.Error != "":
+			report.Warnings = append(report.Warnings, fmt.Sprintf("APIService %s: %s", service.Name, service.Error))
+		case service.Available != "" && service.Available != "True":
+			report.Warnings = append(report.Warnings, fmt.Sprintf("APIService %s is not available: %s", service.Name, service.Message))
+		}
+		if service.InsecureSkipTLSVerify {
+			continue
+		}
+		for _, warning := range utils.ValidateCertificateExpiry(service.Certificates, warningDays) {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("APIService %s caBundle: %s", service.Name, warning))
+		}
+	}
+	report.TotalWarnings = len(report.Warnings)
+	return report, nil
+}
@@ -0,0 +1,199 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Owner kinds that are resolved further, and the kind reported for pods
+// without a controller
+const (
+	WorkloadReplicaSet = "ReplicaSet"
+	WorkloadJob        = "Job"
+	WorkloadPod        = "Pod"
+)
+
+// revisionLabels identify the pod template revision a pod was created from
+var revisionLabels = []string{"pod-template-hash", "controller-revision-hash"}
+
+// WorkloadRef identifies the top-level controller owning a pod
+type WorkloadRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// WorkloadExpiryInfo rolls up the certificates of every pod of a workload
+type WorkloadExpiryInfo struct {
+	WorkloadRef
+	ManagedBy    string                        `json:"managed_by,omitempty"`
+	Helm         *HelmOrigin                   `json:"helm,omitempty"`
+	ArgoCD       *ArgoCDOrigin                 `json:"argocd,omitempty"`
+	Pods         []string                      `json:"pods"`
+	Replicas     int                           `json:"replicas"`
+	Revisions    int                           `json:"revisions"`
+	CertSources  map[string]*CertificateSource `json:"certificate_sources"`
+	Warnings     []string                      `json:"warnings"`
+	WarningCount int                           `json:"warning_count"`
+	CertCount    int                           `json:"certificate_count"`
+}
+
+// WorkloadExpiryReport is the result of a certificate expiry analysis across
+// the workloads of a namespace
+type WorkloadExpiryReport struct {
+	Namespace         string               `json:"namespace"`
+	WarningDays       int                  `json:"warning_days"`
+	TotalPods         int                  `json:"total_pods_analyzed"`
+	TotalCertificates int                  `json:"total_certificates"`
+	TotalWarnings     int                  `json:"total_warnings"`
+	Workloads         []WorkloadExpiryInfo `json:"workloads"`
+}
+
+// AnalyzeWorkloadExpiry analyzes the certificates of a namespace per owning
+// workload. Pods created from the same template revision mount the same
+// sources, so only one pod per revision is analyzed; a workload in the
+// middle of a rollout reports the sources of all its revisions.
+func AnalyzeWorkloadExpiry(ctx context.Context, client *Client, namespace string, warningDays int) (*WorkloadExpiryReport, error) {
+	clientset := client.GetClientset()
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %s: %w", namespace, err)
+	}
+
+	report := &WorkloadExpiryReport{
+		Namespace:   namespace,
+		WarningDays: warningDays,
+		TotalPods:   len(pods.Items),
+	}
+
+	origins := NewOriginResolver(clientset, namespace)
+	workloads := make(map[WorkloadRef]*WorkloadExpiryInfo)
+	analyzed := make(map[string]bool) // workload revisions already analyzed
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		origin := origins.Resolve(ctx, pod)
+		ref := origin.Workload
+
+		workload, ok := workloads[ref]
+		if !ok {
+			workload = &WorkloadExpiryInfo{
+				WorkloadRef: ref,
+				ManagedBy:   origin.ManagedBy,
+				Helm:        origin.Helm,
+				ArgoCD:      origin.ArgoCD,
+				CertSources: make(map[string]*CertificateSource),
+			}
+			workloads[ref] = workload
+		}
+		workload.Pods = append(workload.Pods, pod.Name)
+		workload.Replicas++
+
+		revision := fmt.Sprintf("%s/%s/%s", ref.Kind, ref.Name, podRevision(pod))
+		if analyzed[revision] {
+			continue
+		}
+		analyzed[revision] = true
+		workload.Revisions++
+
+		certSources, err := AnalyzePodCertificates(ctx, client, namespace, pod.Name)
+		if err != nil {
+			continue // Skip pods with errors
+		}
+		for name, source := range certSources {
+			workload.CertSources[name] = source
+		}
+	}
+
+	for _, workload := range workloads {
+		workload.Warnings = GetCertificateExpiryWarnings(workload.CertSources, warningDays)
+		sort.Strings(workload.Warnings)
+		workload.WarningCount = len(workload.Warnings)
+		workload.CertCount = CountCertificates(workload.CertSources)
+
+		report.TotalCertificates += workload.CertCount
+		report.TotalWarnings += workload.WarningCount
+		report.Workloads = append(report.Workloads, *workload)
+	}
+	sort.Slice(report.Workloads, func(i, j int) bool {
+		a, b := report.Workloads[i], report.Workloads[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	return report, nil
+}
+
+// podRevision returns the template revision of a pod, or its name for pods
+// without one so they are analyzed individually
+func podRevision(pod *corev1.Pod) string {
+	for _, label := range revisionLabels {
+		if revision, ok := pod.Labels[label]; ok {
+			return revision
+		}
+	}
+	return pod.Name
+}
+
+// workloadResolver follows owner references from pods to their top-level
+// controller, caching the intermediate ReplicaSets and Jobs
+type workloadResolver struct {
+	clientset kubernetes.Interface
+	namespace string
+	owners    map[string]WorkloadRef // "kind/name" of a ReplicaSet or Job -> its owner
+}
+
+func newWorkloadResolver(clientset kubernetes.Interface, namespace string) *workloadResolver {
+	return &workloadResolver{
+		clientset: clientset,
+		namespace: namespace,
+		owners:    make(map[string]WorkloadRef),
+	}
+}
+
+// resolve returns the workload owning pod. ReplicaSets are resolved to their
+// Deployment and Jobs to their CronJob; if an intermediate owner cannot be
+// read it is reported itself. Pods without a controller are their own workload.
+func (r *workloadResolver) resolve(ctx context.Context, pod *corev1.Pod) WorkloadRef {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return WorkloadRef{Kind: WorkloadPod, Name: pod.Name}
+	}
+
+	ref := WorkloadRef{Kind: owner.Kind, Name: owner.Name}
+	if owner.Kind != WorkloadReplicaSet && owner.Kind != WorkloadJob {
+		return ref
+	}
+
+	key := ref.Kind + "/" + ref.Name
+	if cached, ok := r.owners[key]; ok {
+		return cached
+	}
+
+	var meta metav1.Object
+	switch owner.Kind {
+	case WorkloadReplicaSet:
+		if rs, err := r.clientset.AppsV1().ReplicaSets(r.namespace).Get(ctx, owner.Name, metav1.GetOptions{}); err == nil {
+			meta = rs
+		}
+	case WorkloadJob:
+		if job, err := r.clientset.BatchV1().Jobs(r.namespace).Get(ctx, owner.Name, metav1.GetOptions{}); err == nil {
+			meta = job
+		}
+	}
+
+	resolved := ref
+	if meta != nil {
+		if parent := metav1.GetControllerOf(meta); parent != nil {
+			resolved = WorkloadRef{Kind: parent.Kind, Name: parent.Name}
+		}
+	}
+	r.owners[key] = resolved
+	return resolved
+}
@@ -0,0 +1,132 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DrainStatus reports the progress of a drain
+type DrainStatus struct {
+	Draining            bool           `json:"draining"`
+	Drained             bool           `json:"drained"`
+	StartedAt           *time.Time     `json:"started_at,omitempty"`
+	ElapsedSeconds      float64        `json:"elapsed_seconds,omitempty"`
+	ActiveJobs          int            `json:"active_jobs"`
+	ActiveByKind        map[string]int `json:"active_by_kind"`
+	CompletedSinceDrain int            `json:"completed_since_drain"`
+}
+
+// Tracker counts running scan jobs and stops admitting new ones while the
+// server is draining for maintenance
+type Tracker struct {
+	mu                  sync.Mutex
+	draining            bool
+	drainStarted        time.Time
+	active              map[string]int
+	completedSinceDrain int
+}
+
+// NewTracker creates a tracker that admits jobs
+func NewTracker() *Tracker {
+	return &Tracker{active: make(map[string]int)}
+}
+
+// Begin admits a job of the given kind. It returns false while draining;
+// otherwise the returned function must be called when the job ends. A nil
+// tracker admits every job.
+func (t *Tracker) Begin(kind string) (end func(), ok bool) {
+	if t == nil {
+		return func() {}, true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.draining {
+		return nil, false
+	}
+	t.active[kind]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.active[kind]--
+			if t.active[kind] == 0 {
+				delete(t.active, kind)
+			}
+			if t.draining {
+				t.completedSinceDrain++
+			}
+		})
+	}, true
+}
+
+// Drain stops admitting new jobs. Running jobs are allowed to finish.
+func (t *Tracker) Drain() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.draining {
+		t.draining = true
+		t.drainStarted = time.Now()
+		t.completedSinceDrain = 0
+	}
+}
+
+// Resume admits new jobs again after a drain
+func (t *Tracker) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.draining = false
+}
+
+// Ready reports whether the server should receive traffic
+func (t *Tracker) Ready() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.draining
+}
+
+// Status returns the drain state and the number of running jobs
+func (t *Tracker) Status() DrainStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := DrainStatus{
+		Draining:            t.draining,
+		ActiveByKind:        make(map[string]int, len(t.active)),
+		CompletedSinceDrain: t.completedSinceDrain,
+	}
+	for kind, n := range t.active {
+		status.ActiveByKind[kind] = n
+		status.ActiveJobs += n
+	}
+	if t.draining {
+		started := t.drainStarted
+		status.StartedAt = &started
+		status.ElapsedSeconds = time.Since(started).Seconds()
+		status.Drained = status.ActiveJobs == 0
+	}
+	return status
+}
+
+// Wait blocks until no jobs are running or ctx is done, and returns the
+// number of jobs still running
+func (t *Tracker) Wait(ctx context.Context) int {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		active := t.Status().ActiveJobs
+		if active == 0 {
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return active
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"k8s-web-service/internal/config"
+)
+
+// Supported log levels and formats
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// level is shared by every handler so level changes apply immediately
+var level slog.LevelVar
+
+// prefixes maps conventional message prefixes of stdlib log calls to levels
+var prefixes = []struct {
+	prefix string
+	level  slog.Level
+}{
+	{"debug:", slog.LevelDebug},
+	{"info:", slog.LevelInfo},
+	{"warning:", slog.LevelWarn},
+	{"warn:", slog.LevelWarn},
+	{"error:", slog.LevelError},
+}
+
+// Setup configures the process-wide logger from the logging settings.
+// Messages written with the standard log package are routed through it;
+// their level is taken from a "Debug:", "Info:", "Warning:", or "Error:"
+// prefix and defaults to info.
+func Setup(cfg *config.Config) error {
+	return setup(os.Stderr, cfg.Logging.Level, cfg.Logging.Format)
+}
+
+func setup(w io.Writer, levelName, format string) error {
+	lvl, err := ParseLevel(levelName)
+	if err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: &level}
+	var handler slog.Handler
+	switch format {
+	case FormatText, "":
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unsupported log format %q (supported: %s, %s)", format, FormatText, FormatJSON)
+	}
+
+	level.Set(lvl)
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+
+	// Replace the bridge installed by slog.SetDefault, which logs everything at info
+	log.SetFlags(0)
+	log.SetOutput(&bridge{logger: logger})
+	return nil
+}
+
+// ParseLevel converts a level name to a slog level. An empty name means info.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case LevelDebug:
+		return slog.LevelDebug, nil
+	case LevelInfo, "":
+		return slog.LevelInfo, nil
+	case LevelWarn, "warning":
+		return slog.LevelWarn, nil
+	case LevelError:
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unsupported log level %q (supported: %s, %s, %s, %s)", name, LevelDebug, LevelInfo, LevelWarn, LevelError)
+	}
+}
+
+// DebugEnabled reports whether debug logging is on. Debug logging includes
+// AWS SDK and Kubernetes API request logs.
+func DebugEnabled() bool {
+	return level.Level() <= slog.LevelDebug
+}
+
+// Debugf logs a formatted message at debug level
+func Debugf(format string, args ...interface{}) {
+	if DebugEnabled() {
+		slog.Debug(fmt.Sprintf(format, args...))
+	}
+}
+
+// bridge is the output of the standard log package. It assigns each message
+// a level from its prefix before passing it to the slog handler.
+type bridge struct {
+	logger *slog.Logger
+}
+
+func (b *bridge) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	lvl := slog.LevelInfo
+
+	lower := strings.ToLower(msg)
+	for _, p := range prefixes {
+		if strings.HasPrefix(lower, p.prefix) {
+			lvl = p.level
+			msg = strings.TrimSpace(msg[len(p.prefix):])
+			break
+		}
+	}
+
+	b.logger.Log(context.Background(), lvl, msg)
+	return len(p), nil
+}
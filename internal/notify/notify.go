@@ -0,0 +1,200 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/redact"
+)
+
+// Alert describes a certificate that is expired or expiring soon
+type Alert struct {
+	Namespace       string    `json:"namespace"`
+	Pod             string    `json:"pod"`
+	Source          string    `json:"source"`
+	Subject         string    `json:"subject"`
+	SerialNumber    string    `json:"serial_number"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+	Expired         bool      `json:"expired"`
+	// Simulated marks alerts produced by /admin/simulate rather than a real
+	// expiring certificate
+	Simulated bool `json:"simulated,omitempty"`
+}
+
+// Message returns a human-readable one-line description of the alert
+func (a Alert) Message() string {
+	prefix := ""
+	if a.Simulated {
+		prefix = "[SIMULATED] "
+	}
+	if a.Expired {
+		return fmt.Sprintf("%s[%s/%s] Certificate '%s' from %s has EXPIRED on %s",
+			prefix, a.Namespace, a.Pod, a.Subject, a.Source, a.NotAfter.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("%s[%s/%s] Certificate '%s' from %s expires in %d days (%s)",
+		prefix, a.Namespace, a.Pod, a.Subject, a.Source, a.DaysUntilExpiry, a.NotAfter.Format("2006-01-02"))
+}
+
+// Notifier delivers certificate alerts to an external system
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alerts []Alert) error
+}
+
+// FromConfig builds the notifier pipeline described by the configuration.
+// Alerts are always written to the log; webhook and Slack delivery are optional.
+// Certificate subjects and serial numbers are redacted as security
+// configures for responses.
+func FromConfig(cfg *config.Config) Notifier {
+	notifiers := Multi{LogNotifier{}}
+	if cfg.Notifiers.WebhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(cfg.Notifiers.WebhookURL))
+	}
+	if cfg.Notifiers.SlackWebhookURL != "" {
+		notifiers = append(notifiers, NewSlackNotifier(cfg.Notifiers.SlackWebhookURL))
+	}
+	if policy := redact.NewPolicy(cfg); policy.Enabled() {
+		return redacting{Notifier: notifiers, policy: policy}
+	}
+	return notifiers
+}
+
+// redacting removes certificate identities from alerts before another
+// notifier delivers them
+type redacting struct {
+	Notifier
+	policy redact.Policy
+}
+
+// Notify delivers copies of the alerts with their subjects and serial
+// numbers redacted
+func (n redacting) Notify(ctx context.Context, alerts []Alert) error {
+	redacted := make([]Alert, len(alerts))
+	for i, alert := range alerts {
+		alert.Subject, _, alert.SerialNumber = n.policy.Identity(alert.Namespace, alert.Subject, "", alert.SerialNumber)
+		redacted[i] = alert
+	}
+	return n.Notifier.Notify(ctx, redacted)
+}
+
+// Multi fans alerts out to several notifiers
+type Multi []Notifier
+
+// Name returns the names of all wrapped notifiers
+func (m Multi) Name() string {
+	var names []string
+	for _, n := range m {
+		names = append(names, n.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Notify sends alerts to every notifier, continuing past individual failures
+func (m Multi) Notify(ctx context.Context, alerts []Alert) error {
+	var failed []string
+	for _, n := range m {
+		if err := n.Notify(ctx, alerts); err != nil {
+			log.Printf("Error: notifier %s failed: %v", n.Name(), err)
+			failed = append(failed, n.Name())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("notifiers failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// LogNotifier writes alerts to the application log
+type LogNotifier struct{}
+
+// Name returns the notifier name
+func (LogNotifier) Name() string { return "log" }
+
+// Notify logs each alert
+func (LogNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	for _, alert := range alerts {
+		log.Printf("Warning: ALERT %s", alert.Message())
+	}
+	return nil
+}
+
+// WebhookNotifier posts alerts as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to the given URL
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name returns the notifier name
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+// Notify posts all alerts in a single request
+func (n *WebhookNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	return postJSON(ctx, n.client, n.url, map[string]interface{}{
+		"source": "k8s-web-service",
+		"count":  len(alerts),
+		"alerts": alerts,
+	})
+}
+
+// SlackNotifier posts alerts to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackNotifier creates a notifier posting to a Slack incoming webhook
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{webhookURL: webhookURL, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name returns the notifier name
+func (n *SlackNotifier) Name() string { return "slack" }
+
+// Notify posts all alerts as a single Slack message
+func (n *SlackNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	lines := []string{fmt.Sprintf(":warning: %d certificate alert(s)", len(alerts))}
+	for _, alert := range alerts {
+		lines = append(lines, "• "+alert.Message())
+	}
+	return postJSON(ctx, n.client, n.webhookURL, map[string]string{
+		"text": strings.Join(lines, "\n"),
+	})
+}
+
+// postJSON sends payload to url and treats any non-2xx status as an error
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
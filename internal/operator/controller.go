@@ -0,0 +1,290 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/notify"
+	"k8s-web-service/internal/scanner"
+)
+
+// DefaultInterval is the time between scans of a CertificateScan without
+// an interval
+const DefaultInterval = time.Hour
+
+// Reasons of the Events emitted for a CertificateScan
+const (
+	ReasonScanCompleted        = "ScanCompleted"
+	ReasonScanFailed           = "ScanFailed"
+	ReasonCertificatesExpiring = "CertificatesExpiring"
+	ReasonNotificationFailed   = "NotificationFailed"
+)
+
+// Reconciler scans the namespaces of CertificateScans when they are due
+type Reconciler struct {
+	client   client.Client
+	store    *config.Store
+	recorder record.EventRecorder
+	// clients shares one Kubernetes client across reconciles, rebuilt when
+	// the configuration changes
+	clients *k8s.ClientManager
+
+	mu sync.Mutex
+	// notified holds the alerts already delivered per CertificateScan, keyed
+	// by scanner.AlertKey
+	notified map[types.NamespacedName]map[string]bool
+}
+
+// NewReconciler creates a reconciler reading and updating CertificateScans
+// with c, and scanning with a client built from the active configuration
+func NewReconciler(c client.Client, store *config.Store, recorder record.EventRecorder) *Reconciler {
+	return &Reconciler{
+		client:   c,
+		store:    store,
+		recorder: recorder,
+		clients:  k8s.NewClientManager(),
+		notified: make(map[types.NamespacedName]map[string]bool),
+	}
+}
+
+// SetupWithManager registers the reconciler with a manager. Status updates
+// do not change the generation, so they do not trigger a reconcile.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&CertificateScan{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// Reconcile scans the namespaces of a CertificateScan if it is due, writes
+// the result to its status, emits Events, and notifies about new alerts. A
+// changed spec is scanned immediately.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var scan CertificateScan
+	if err := r.client.Get(ctx, req.NamespacedName, &scan); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get CertificateScan %s: %w", req.NamespacedName, err)
+	}
+	if scan.Spec.Suspend {
+		return ctrl.Result{}, nil
+	}
+
+	interval := DefaultInterval
+	if scan.Spec.Interval != nil && scan.Spec.Interval.Duration > 0 {
+		interval = scan.Spec.Interval.Duration
+	}
+	now := time.Now()
+	if last := scan.Status.LastScanTime; last != nil && scan.Status.ObservedGeneration == scan.ObjectMeta.Generation {
+		if due := last.Add(interval); now.Before(due) {
+			return ctrl.Result{RequeueAfter: due.Sub(now)}, nil
+		}
+	}
+
+	cfg := r.store.Get()
+	namespaces := scan.Spec.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{cfg.Kubernetes.DefaultNamespace}
+	}
+	warningDays := scan.Spec.WarningDays
+	if warningDays <= 0 {
+		warningDays = cfg.Scanner.WarningDays
+	}
+
+	status := CertificateScanStatus{
+		ObservedGeneration: scan.ObjectMeta.Generation,
+		LastScanTime:       &metav1.Time{Time: now},
+		NextScanTime:       &metav1.Time{Time: now.Add(interval)},
+		Conditions:         scan.Status.Conditions,
+	}
+	var alerts []notify.Alert
+	var failed []string
+	k8sClient, err := r.clients.Client(cfg)
+	if err != nil {
+		failed = namespaces
+		for _, namespace := range namespaces {
+			status.Namespaces = append(status.Namespaces, NamespaceScanStatus{Namespace: namespace, Error: err.Error()})
+		}
+	} else {
+		for _, namespace := range namespaces {
+			report, err := k8s.AnalyzeNamespaceExpiry(ctx, k8sClient, namespace, warningDays)
+			if err != nil {
+				log.Printf("Error: CertificateScan %s: scan of namespace %s failed: %v", req.NamespacedName, namespace, err)
+				failed = append(failed, namespace)
+				status.Namespaces = append(status.Namespaces, NamespaceScanStatus{Namespace: namespace, Error: err.Error()})
+				continue
+			}
+			status.Namespaces = append(status.Namespaces, namespaceStatus(report))
+			status.TotalCertificates += report.TotalCertificates
+			status.TotalWarnings += report.TotalWarnings
+			alerts = append(alerts, scanner.AlertsFromReport(report)...)
+		}
+	}
+
+	setConditions(&status, scan.ObjectMeta.Generation, namespaces, failed, len(alerts))
+	if len(failed) > 0 {
+		r.recorder.Eventf(&scan, corev1.EventTypeWarning, ReasonScanFailed, "Scan failed for namespaces: %s", strings.Join(failed, ", "))
+	}
+	if len(alerts) > 0 {
+		r.recorder.Eventf(&scan, corev1.EventTypeWarning, ReasonCertificatesExpiring, "%d certificates are expired or expire within %d days", len(alerts), warningDays)
+	}
+	r.recorder.Eventf(&scan, corev1.EventTypeNormal, ReasonScanCompleted, "Scanned %d namespaces: %d certificates, %d warnings", len(namespaces)-len(failed), status.TotalCertificates, status.TotalWarnings)
+
+	if err := r.notify(ctx, &scan, alerts, failed); err != nil {
+		log.Printf("Error: CertificateScan %s: %v", req.NamespacedName, err)
+		r.recorder.Eventf(&scan, corev1.EventTypeWarning, ReasonNotificationFailed, "%v", err)
+	}
+
+	scan.Status = status
+	if err := r.client.Status().Update(ctx, &scan); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status of CertificateScan %s: %w", req.NamespacedName, err)
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// notify delivers the alerts not delivered by a previous scan of the same
+// CertificateScan. Undelivered alerts are retried on the next scan; alerts
+// of namespaces that failed to scan are not delivered again once they return.
+func (r *Reconciler) notify(ctx context.Context, scan *CertificateScan, alerts []notify.Alert, failed []string) error {
+	key := client.ObjectKeyFromObject(scan)
+	r.mu.Lock()
+	previous := r.notified[key]
+	r.mu.Unlock()
+
+	current := make(map[string]bool, len(alerts))
+	var newAlerts []notify.Alert
+	for _, alert := range alerts {
+		alertKey := scanner.AlertKey(alert)
+		current[alertKey] = true
+		if !previous[alertKey] {
+			newAlerts = append(newAlerts, alert)
+		}
+	}
+	for alertKey := range previous {
+		for _, namespace := range failed {
+			if strings.HasPrefix(alertKey, namespace+"/") {
+				current[alertKey] = true
+			}
+		}
+	}
+
+	if len(newAlerts) > 0 {
+		notifier, err := r.notifier(ctx, scan)
+		if err != nil {
+			return err
+		}
+		if err := notifier.Notify(ctx, newAlerts); err != nil {
+			return fmt.Errorf("failed to deliver %d alerts: %w", len(newAlerts), err)
+		}
+	}
+
+	r.mu.Lock()
+	r.notified[key] = current
+	r.mu.Unlock()
+	return nil
+}
+
+// notifier builds the notifiers of a CertificateScan. Alerts are always
+// written to the log.
+func (r *Reconciler) notifier(ctx context.Context, scan *CertificateScan) (notify.Notifier, error) {
+	notifiers := notify.Multi{notify.LogNotifier{}}
+	if url := scan.Spec.Notifiers.WebhookURL; url != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(url))
+	}
+	if ref := scan.Spec.Notifiers.SlackWebhookURLSecretRef; ref != nil {
+		var secret corev1.Secret
+		if err := r.client.Get(ctx, types.NamespacedName{Namespace: scan.ObjectMeta.Namespace, Name: ref.Name}, &secret); err != nil {
+			return nil, fmt.Errorf("failed to get Slack webhook secret %s: %w", ref.Name, err)
+		}
+		url := strings.TrimSpace(string(secret.Data[ref.Key]))
+		if url == "" {
+			return nil, fmt.Errorf("secret %s has no key %s", ref.Name, ref.Key)
+		}
+		notifiers = append(notifiers, notify.NewSlackNotifier(url))
+	}
+	return notifiers, nil
+}
+
+// forget drops the delivered alerts of a deleted CertificateScan
+func (r *Reconciler) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.notified, key)
+}
+
+// namespaceStatus summarizes the expiry report of a namespace
+func namespaceStatus(report *k8s.NamespaceExpiryReport) NamespaceScanStatus {
+	score := k8s.NamespaceHealthScore(report)
+	status := NamespaceScanStatus{
+		Namespace:    report.Namespace,
+		Certificates: report.TotalCertificates,
+		Warnings:     report.TotalWarnings,
+		Expired:      score.Expired,
+		Expiring:     score.Critical + score.Expiring,
+		HealthScore:  score.Score,
+	}
+	var sources []*k8s.CertificateSource
+	for _, pod := range report.Pods {
+		for _, source := range pod.CertSources {
+			sources = append(sources, source)
+		}
+	}
+	for _, source := range append(sources, report.CustomResources...) {
+		for _, cert := range source.Certificates {
+			if cert != nil && (status.SoonestExpiry == nil || cert.NotAfter.Before(status.SoonestExpiry.Time)) {
+				status.SoonestExpiry = &metav1.Time{Time: cert.NotAfter}
+			}
+		}
+	}
+	return status
+}
+
+// setConditions sets the Scanned and Healthy conditions from the outcome of
+// a scan
+func setConditions(status *CertificateScanStatus, generation int64, namespaces, failed []string, alerts int) {
+	scanned := metav1.Condition{
+		Type:               ConditionScanned,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "ScanSucceeded",
+		Message:            fmt.Sprintf("Scanned %d namespaces", len(namespaces)),
+	}
+	if len(failed) > 0 {
+		scanned.Status = metav1.ConditionFalse
+		scanned.Reason = ReasonScanFailed
+		scanned.Message = fmt.Sprintf("Scan failed for namespaces: %s", strings.Join(failed, ", "))
+	}
+	meta.SetStatusCondition(&status.Conditions, scanned)
+
+	healthy := metav1.Condition{
+		Type:               ConditionHealthy,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "NoExpiringCertificates",
+		Message:            "No certificates are expired or expiring",
+	}
+	if alerts > 0 {
+		healthy.Status = metav1.ConditionFalse
+		healthy.Reason = ReasonCertificatesExpiring
+		healthy.Message = fmt.Sprintf("%d certificates are expired or expiring", alerts)
+	}
+	meta.SetStatusCondition(&status.Conditions, healthy)
+}
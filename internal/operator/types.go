@@ -0,0 +1,188 @@
+// Package operator implements the operator mode: a controller-runtime
+// manager reconciles CertificateScan custom resources, each declaring the
+// namespaces to scan, the warning threshold, the interval, and the
+// notifiers. Results are written to the status of the resource and reported
+// as Events, so scans are managed with the rest of a GitOps repository.
+package operator
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+// GroupVersion is the API group and version of CertificateScan
+var GroupVersion = schema.GroupVersion{Group: "k8s-web-service.io", Version: "v1alpha1"}
+
+// SchemeBuilder registers the CertificateScan types with a scheme
+var SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+// AddToScheme adds the CertificateScan types to a scheme
+var AddToScheme = SchemeBuilder.AddToScheme
+
+func init() {
+	SchemeBuilder.Register(&CertificateScan{}, &CertificateScanList{})
+}
+
+// Condition types of a CertificateScan
+const (
+	// ConditionScanned is true when every namespace of the last scan was
+	// scanned
+	ConditionScanned = "Scanned"
+	// ConditionHealthy is true when the last scan found no expired or
+	// expiring certificates
+	ConditionHealthy = "Healthy"
+)
+
+// CertificateScan declares a recurring certificate scan
+type CertificateScan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CertificateScanSpec   `json:"spec,omitempty"`
+	Status CertificateScanStatus `json:"status,omitempty"`
+}
+
+// CertificateScanSpec is the desired scan
+type CertificateScanSpec struct {
+	// Namespaces are scanned in turn; kubernetes.default_namespace if empty
+	Namespaces []string `json:"namespaces,omitempty"`
+	// WarningDays is the expiry threshold; scanner.warning_days if 0
+	WarningDays int `json:"warningDays,omitempty"`
+	// Interval between scans; one hour if not set
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Suspend stops scheduling scans
+	Suspend   bool                     `json:"suspend,omitempty"`
+	Notifiers CertificateScanNotifiers `json:"notifiers,omitempty"`
+}
+
+// CertificateScanNotifiers are the destinations of the alerts of a scan, in
+// addition to the log and Events. Alerts are delivered once per
+// certificate, until it is renewed.
+type CertificateScanNotifiers struct {
+	// WebhookURL receives a JSON POST with the new alerts
+	WebhookURL string `json:"webhookURL,omitempty"`
+	// SlackWebhookURLSecretRef selects the key of a secret, in the namespace
+	// of the CertificateScan, holding a Slack incoming webhook URL
+	SlackWebhookURLSecretRef *corev1.SecretKeySelector `json:"slackWebhookURLSecretRef,omitempty"`
+}
+
+// CertificateScanStatus is the result of the last scan
+type CertificateScanStatus struct {
+	ObservedGeneration int64                 `json:"observedGeneration,omitempty"`
+	LastScanTime       *metav1.Time          `json:"lastScanTime,omitempty"`
+	NextScanTime       *metav1.Time          `json:"nextScanTime,omitempty"`
+	TotalCertificates  int                   `json:"totalCertificates"`
+	TotalWarnings      int                   `json:"totalWarnings"`
+	Namespaces         []NamespaceScanStatus `json:"namespaces,omitempty"`
+	Conditions         []metav1.Condition    `json:"conditions,omitempty"`
+}
+
+// NamespaceScanStatus is the result of the last scan of a namespace
+type NamespaceScanStatus struct {
+	Namespace     string       `json:"namespace"`
+	Certificates  int          `json:"certificates"`
+	Warnings      int          `json:"warnings"`
+	Expired       int          `json:"expired"`
+	Expiring      int          `json:"expiring"`
+	HealthScore   int          `json:"healthScore"`
+	SoonestExpiry *metav1.Time `json:"soonestExpiry,omitempty"`
+	Error         string       `json:"error,omitempty"`
+}
+
+// CertificateScanList is a list of CertificateScans
+type CertificateScanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertificateScan `json:"items"`
+}
+
+// DeepCopyInto copies the scan into out
+func (in *CertificateScan) DeepCopyInto(out *CertificateScan) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy copies the scan
+func (in *CertificateScan) DeepCopy() *CertificateScan {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateScan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject copies the scan as a runtime.Object
+func (in *CertificateScan) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopyInto copies the spec into out
+func (in *CertificateScanSpec) DeepCopyInto(out *CertificateScanSpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		out.Namespaces = append([]string(nil), in.Namespaces...)
+	}
+	if in.Interval != nil {
+		interval := *in.Interval
+		out.Interval = &interval
+	}
+	if in.Notifiers.SlackWebhookURLSecretRef != nil {
+		out.Notifiers.SlackWebhookURLSecretRef = in.Notifiers.SlackWebhookURLSecretRef.DeepCopy()
+	}
+}
+
+// DeepCopyInto copies the status into out
+func (in *CertificateScanStatus) DeepCopyInto(out *CertificateScanStatus) {
+	*out = *in
+	if in.LastScanTime != nil {
+		out.LastScanTime = in.LastScanTime.DeepCopy()
+	}
+	if in.NextScanTime != nil {
+		out.NextScanTime = in.NextScanTime.DeepCopy()
+	}
+	if in.Namespaces != nil {
+		out.Namespaces = make([]NamespaceScanStatus, len(in.Namespaces))
+		for i := range in.Namespaces {
+			out.Namespaces[i] = in.Namespaces[i]
+			if in.Namespaces[i].SoonestExpiry != nil {
+				out.Namespaces[i].SoonestExpiry = in.Namespaces[i].SoonestExpiry.DeepCopy()
+			}
+		}
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopyInto copies the list into out
+func (in *CertificateScanList) DeepCopyInto(out *CertificateScanList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]CertificateScan, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopyObject copies the list as a runtime.Object
+func (in *CertificateScanList) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateScanList)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,74 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"k8s-web-service/internal/k8s"
+)
+
+// WriteInventoryDiff renders a cluster-to-cluster inventory comparison in the
+// requested format
+func WriteInventoryDiff(w io.Writer, format string, diff *k8s.InventoryDiff, opts Options) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	case FormatYAML:
+		return writeYAML(w, diff)
+	case FormatTable:
+		return writeDiffTable(w, diff, opts)
+	default:
+		return ValidateFormat(format)
+	}
+}
+
+// writeDiffTable lists sources present in only one cluster, followed by a
+// table of differences between sources present in both
+func writeDiffTable(w io.Writer, diff *k8s.InventoryDiff, opts Options) error {
+	for _, name := range diff.OnlyInA {
+		fmt.Fprintf(w, "%s only in %s\n", colorizeDiff("-", opts), diff.ClusterA)
+		fmt.Fprintf(w, "    %s\n", name)
+	}
+	for _, name := range diff.OnlyInB {
+		fmt.Fprintf(w, "%s only in %s\n", colorizeDiff("+", opts), diff.ClusterB)
+		fmt.Fprintf(w, "    %s\n", name)
+	}
+
+	if len(diff.Differences) > 0 {
+		if len(diff.OnlyInA)+len(diff.OnlyInB) > 0 {
+			fmt.Fprintln(w)
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "SOURCE\tDIFFERENCE\tSUBJECT\t%s\t%s\n", diff.ClusterA, diff.ClusterB)
+		for _, d := range diff.Differences {
+			kind := d.Kind
+			if d.Kind == k8s.DiffExpirySkew {
+				kind = fmt.Sprintf("%s (%dd)", d.Kind, d.SkewDays)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", d.Source, kind, d.Subject, d.A, d.B)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\nNamespace %s: %d matching, %d only in %s, %d only in %s, %d differences (max expiry skew %d days)\n",
+		diff.Namespace, diff.Matching, len(diff.OnlyInA), diff.ClusterA, len(diff.OnlyInB), diff.ClusterB, len(diff.Differences), diff.MaxSkewDays)
+	return err
+}
+
+// colorizeDiff colors a diff marker red for removals and green for additions
+func colorizeDiff(marker string, opts Options) string {
+	if !opts.Color {
+		return marker
+	}
+	if marker == "-" {
+		return colorRed + marker + colorReset
+	}
+	return colorGreen + marker + colorReset
+}
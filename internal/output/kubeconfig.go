@@ -0,0 +1,96 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/utils"
+)
+
+// statusRank orders certificate statuses from best to worst
+var statusRank = map[string]int{"OK": 0, "WARNING": 1, "EXPIRED": 2}
+
+// WriteKubeconfigInspection renders the inspection of every kubeconfig
+// context in the requested format
+func WriteKubeconfigInspection(w io.Writer, format string, contexts []k8s.ContextInspection, warningDays int, opts Options) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(contexts)
+	case FormatYAML:
+		return writeYAML(w, contexts)
+	case FormatTable:
+		return writeKubeconfigTable(w, contexts, warningDays, opts)
+	default:
+		return ValidateFormat(format)
+	}
+}
+
+// writeKubeconfigTable renders one row per context. The current context is
+// marked with "*" and STATUS reflects the worst of both certificates.
+func writeKubeconfigTable(w io.Writer, contexts []k8s.ContextInspection, warningDays int, opts Options) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CURRENT\tCONTEXT\tSERVER\tAUTH\tCA EXPIRES\tCLIENT CERT EXPIRES\tSTATUS")
+
+	for _, c := range contexts {
+		current := ""
+		if c.Current {
+			current = "*"
+		}
+
+		auth := c.AuthMethod
+		if c.Exec != nil {
+			auth = "exec:" + c.Exec.Command
+		}
+
+		caExpiry, caStatus := earliestExpiry(c.ClusterCA, c.ClusterCAError, warningDays)
+		clientExpiry, clientStatus := earliestExpiry(c.ClientCertificate, c.ClientCertificateError, warningDays)
+
+		status := caStatus
+		if statusRank[clientStatus] > statusRank[status] {
+			status = clientStatus
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			current, c.Name, c.Server, auth, caExpiry, clientExpiry, colorize(status, opts))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, c := range contexts {
+		if c.ClusterCAError != "" {
+			fmt.Fprintf(w, "%s: cluster CA: %s\n", c.Name, c.ClusterCAError)
+		}
+		if c.ClientCertificateError != "" {
+			fmt.Fprintf(w, "%s: client certificate: %s\n", c.Name, c.ClientCertificateError)
+		}
+	}
+	return nil
+}
+
+// earliestExpiry formats the expiry date of the certificate in certs that
+// expires first, along with its status. "-" is returned when there are no
+// certificates and "error" when they could not be read.
+func earliestExpiry(certs []*utils.CertificateInfo, certErr string, warningDays int) (string, string) {
+	if certErr != "" {
+		return "error", "OK"
+	}
+	if len(certs) == 0 {
+		return "-", "OK"
+	}
+
+	earliest := certs[0]
+	for _, cert := range certs[1:] {
+		if cert.NotAfter.Before(earliest.NotAfter) {
+			earliest = cert
+		}
+	}
+	return fmt.Sprintf("%s (%dd)", earliest.NotAfter.Format("2006-01-02"), earliest.DaysUntilExp),
+		CertificateStatus(earliest, warningDays)
+}
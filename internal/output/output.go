@@ -0,0 +1,175 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/utils"
+)
+
+// Supported output formats
+const (
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatTable = "table"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatTable, FormatJSON, FormatYAML}
+
+// ANSI escape sequences used to color-code certificate status
+const (
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorGreen  = "\033[32m"
+	colorReset  = "\033[0m"
+)
+
+// Options controls how reports are rendered
+type Options struct {
+	// Color enables ANSI color-coded status in table output
+	Color bool
+}
+
+// ColorSupported reports whether f looks like a terminal that can display
+// colors. Color is disabled when the NO_COLOR environment variable is set.
+func ColorSupported(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// ValidateFormat returns an error if format is not a supported output format
+func ValidateFormat(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q (supported: %v)", format, Formats)
+}
+
+// WriteExpiryReport renders a namespace expiry report in the requested format
+func WriteExpiryReport(w io.Writer, format string, report *k8s.NamespaceExpiryReport, opts Options) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatYAML:
+		return writeYAML(w, report)
+	case FormatTable:
+		return writeExpiryTable(w, report, opts)
+	default:
+		return ValidateFormat(format)
+	}
+}
+
+// writeYAML renders v as YAML using the same field names as the JSON responses
+func writeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		return fmt.Errorf("failed to encode report as YAML: %w", err)
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+// writeExpiryTable renders one row per certificate followed by a short summary.
+// STATUS is the last column so color codes do not affect column alignment.
+func writeExpiryTable(w io.Writer, report *k8s.NamespaceExpiryReport, opts Options) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "POD\tSOURCE\tSUBJECT\tEXPIRES\tDAYS\tSTATUS")
+
+	for _, pod := range report.Pods {
+		// Sort source names so the output is stable between runs
+		var sourceNames []string
+		for name := range pod.CertSources {
+			sourceNames = append(sourceNames, name)
+		}
+		sort.Strings(sourceNames)
+
+		for _, name := range sourceNames {
+			for _, cert := range pod.CertSources[name].Certificates {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
+					pod.PodName,
+					name,
+					cert.Subject,
+					cert.NotAfter.Format("2006-01-02"),
+					cert.DaysUntilExp,
+					colorize(CertificateStatus(cert, report.WarningDays), opts),
+				)
+			}
+		}
+	}
+	for _, source := range report.CustomResources {
+		for _, cert := range source.Certificates {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
+				source.Name,
+				source.Key,
+				cert.Subject,
+				cert.NotAfter.Format("2006-01-02"),
+				cert.DaysUntilExp,
+				colorize(CertificateStatus(cert, report.WarningDays), opts),
+			)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\nNamespace %s: %d pods analyzed, %d with certificates, %d certificates, %d warnings (threshold %d days)\n",
+		report.Namespace, report.TotalPods, len(report.Pods), report.TotalCertificates, report.TotalWarnings, report.WarningDays)
+	return err
+}
+
+// CertificateStatus classifies a certificate as EXPIRED, WARNING, or OK
+func CertificateStatus(cert *utils.CertificateInfo, warningDays int) string {
+	if cert.IsExpired {
+		return "EXPIRED"
+	}
+	if cert.DaysUntilExp <= warningDays {
+		return "WARNING"
+	}
+	return "OK"
+}
+
+// colorize wraps a status in the matching ANSI color when color is enabled
+func colorize(status string, opts Options) string {
+	if !opts.Color {
+		return status
+	}
+
+	color := colorGreen
+	switch status {
+	case "EXPIRED":
+		color = colorRed
+	case "WARNING":
+		color = colorYellow
+	}
+	return color + status + colorReset
+}
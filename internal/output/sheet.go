@@ -0,0 +1,272 @@
+package output
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/redact"
+)
+
+// Spreadsheet formats of the HTTP exports
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// SheetFormats lists the spreadsheet formats
+var SheetFormats = []string{FormatCSV, FormatXLSX}
+
+// sheetContentTypes are the media types of the spreadsheet formats
+var sheetContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Sheet is a table exported as a spreadsheet: a header row followed by rows
+// of cells, each a string, int, or bool
+type Sheet struct {
+	// Name is the file name of the export without extension, and the name
+	// of its worksheet
+	Name   string
+	Header []string
+	Rows   [][]interface{}
+}
+
+// SheetContentType returns the media type of a spreadsheet format
+func SheetContentType(format string) string {
+	return sheetContentTypes[format]
+}
+
+// WriteSheet renders a sheet in a spreadsheet format
+func WriteSheet(w io.Writer, format string, sheet *Sheet) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, sheet)
+	case FormatXLSX:
+		return writeXLSX(w, sheet)
+	default:
+		return fmt.Errorf("unsupported spreadsheet format %q (supported: %v)", format, SheetFormats)
+	}
+}
+
+// CertificateSheet lists certificates one per row, with the namespace, pod,
+// and source they were found in
+type CertificateSheet struct {
+	Sheet
+	warningDays int
+	// policy redacts certificate identities and secret key names
+	policy redact.Policy
+}
+
+// NewCertificateSheet creates an empty certificate sheet. STATUS is
+// computed with warningDays, and certificate identities and secret key
+// names are replaced with [REDACTED] as policy redacts them.
+func NewCertificateSheet(name string, warningDays int, policy redact.Policy) *CertificateSheet {
+	return &CertificateSheet{
+		Sheet: Sheet{
+			Name: name,
+			Header: []string{
+				"namespace", "pod", "source_type", "source_name", "key",
+				"subject", "issuer", "serial_number", "not_before", "not_after",
+				"days_until_expiry", "is_expired", "status", "fingerprint_sha256",
+			},
+			Rows: [][]interface{}{},
+		},
+		warningDays: warningDays,
+		policy:      policy,
+	}
+}
+
+// AddSources adds the certificates of a pod's sources, ordered by source
+// name so exports are stable between runs
+func (s *CertificateSheet) AddSources(namespace, pod string, sources map[string]*k8s.CertificateSource) {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.AddSource(namespace, pod, sources[name])
+	}
+}
+
+// AddSource adds the certificates of a source found in a pod, or of a
+// source outside pods, such as a custom resource, when pod is ""
+func (s *CertificateSheet) AddSource(namespace, pod string, source *k8s.CertificateSource) {
+	if source == nil {
+		return
+	}
+	if source.Namespace != "" {
+		namespace = source.Namespace
+	}
+	for _, cert := range source.Certificates {
+		subject, issuer, serial := s.policy.Identity(namespace, cert.Subject, cert.Issuer, cert.SerialNumber)
+		s.Rows = append(s.Rows, []interface{}{
+			namespace, pod, source.Type, source.Name, s.policy.KeyName(source.Key),
+			subject, issuer, serial,
+			cert.NotBefore.UTC().Format(time.RFC3339), cert.NotAfter.UTC().Format(time.RFC3339),
+			cert.DaysUntilExp, cert.IsExpired, CertificateStatus(cert, s.warningDays), cert.Fingerprint,
+		})
+	}
+}
+
+// cellText returns the text of a cell
+func cellText(cell interface{}) string {
+	switch v := cell.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeCSV renders a sheet as RFC 4180 CSV with a header row
+func writeCSV(w io.Writer, sheet *Sheet) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(sheet.Header); err != nil {
+		return err
+	}
+	record := make([]string, len(sheet.Header))
+	for _, row := range sheet.Rows {
+		record = record[:0]
+		for _, cell := range row {
+			text := cellText(cell)
+			// Text starting like a formula is prefixed with a quote, so that
+			// a certificate subject such as "=HYPERLINK(...)" is shown rather
+			// than evaluated when the file is opened in a spreadsheet
+			if _, ok := cell.(string); ok && text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+				text = "'" + text
+			}
+			record = append(record, text)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// xlsxParts are the fixed parts of a workbook with a single worksheet, by
+// path in the package. The workbook's sheet name is filled in by writeXLSX.
+var xlsxParts = []struct{ path, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+}
+
+// writeXLSX renders a sheet as an Office Open XML workbook with one
+// worksheet. Text is stored as inline strings, which are never evaluated
+// as formulas, and numbers and booleans as typed cells, so no shared string
+// table or styles are needed.
+func writeXLSX(w io.Writer, sheet *Sheet) error {
+	archive := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		content := part.content
+		if part.path == "xl/workbook.xml" {
+			content = fmt.Sprintf(content, xmlEscape(xlsxSheetName(sheet.Name)))
+		}
+		f, err := archive.Create(part.path)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", part.path, err)
+		}
+		if _, err := io.WriteString(f, content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", part.path, err)
+		}
+	}
+
+	f, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return fmt.Errorf("failed to write worksheet: %w", err)
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	header := make([]interface{}, len(sheet.Header))
+	for i, name := range sheet.Header {
+		header[i] = name
+	}
+	writeXLSXRow(&b, 1, header)
+	for i, row := range sheet.Rows {
+		writeXLSXRow(&b, i+2, row)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	if _, err := io.WriteString(f, b.String()); err != nil {
+		return fmt.Errorf("failed to write worksheet: %w", err)
+	}
+	return archive.Close()
+}
+
+// writeXLSXRow appends a worksheet row, numbered from 1
+func writeXLSXRow(b *strings.Builder, number int, cells []interface{}) {
+	fmt.Fprintf(b, `<row r="%d">`, number)
+	for i, cell := range cells {
+		ref := xlsxColumn(i) + strconv.Itoa(number)
+		switch v := cell.(type) {
+		case int:
+			fmt.Fprintf(b, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case bool:
+			value := 0
+			if v {
+				value = 1
+			}
+			fmt.Fprintf(b, `<c r="%s" t="b"><v>%d</v></c>`, ref, value)
+		default:
+			fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(cellText(v)))
+		}
+	}
+	b.WriteString(`</row>`)
+}
+
+// xlsxColumn returns the letters of a zero-based column index: A to Z, then
+// AA and so on
+func xlsxColumn(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxSheetName returns name as a valid worksheet name: at most 31
+// characters, none of which are []:*?/\
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		name = "Sheet1"
+	}
+	return name
+}
+
+// xmlEscape escapes text for XML character data and attribute values,
+// replacing characters XML cannot represent
+func xmlEscape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
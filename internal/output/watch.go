@@ -0,0 +1,164 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/pkg/utils"
+)
+
+// Change kinds reported in watch mode
+const (
+	ChangeAdded   = "ADDED"
+	ChangeRemoved = "REMOVED"
+	ChangeRenewed = "RENEWED"
+	ChangeStatus  = "STATUS"
+)
+
+// Change describes how a certificate differs from the previous scan
+type Change struct {
+	Time            time.Time `json:"time"`
+	Kind            string    `json:"kind"`
+	Namespace       string    `json:"namespace"`
+	Pod             string    `json:"pod"`
+	Source          string    `json:"source"`
+	Subject         string    `json:"subject"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+	Status          string    `json:"status"`
+	PreviousStatus  string    `json:"previous_status,omitempty"`
+}
+
+// watchedCert is the state of a certificate remembered between scans
+type watchedCert struct {
+	pod    string
+	source string
+	cert   *utils.CertificateInfo
+	status string
+}
+
+// Watcher tracks certificates across successive scans of a namespace and
+// reports what changed. Certificates are identified by source and subject
+// rather than pod, so pod restarts and rollouts are not reported.
+type Watcher struct {
+	previous map[string]watchedCert
+}
+
+// NewWatcher creates a watcher with no previous scan
+func NewWatcher() *Watcher {
+	return &Watcher{}
+}
+
+// Update records a new scan and returns the changes since the previous one.
+// The first call establishes the baseline and returns no changes.
+func (w *Watcher) Update(report *k8s.NamespaceExpiryReport, now time.Time) []Change {
+	current := make(map[string]watchedCert)
+	for _, pod := range report.Pods {
+		for name, source := range pod.CertSources {
+			for _, cert := range source.Certificates {
+				key := fmt.Sprintf("%s/%s/%s", report.Namespace, name, cert.Subject)
+				if _, seen := current[key]; seen {
+					continue
+				}
+				current[key] = watchedCert{
+					pod:    pod.PodName,
+					source: name,
+					cert:   cert,
+					status: CertificateStatus(cert, report.WarningDays),
+				}
+			}
+		}
+	}
+	for _, source := range report.CustomResources {
+		for _, cert := range source.Certificates {
+			key := fmt.Sprintf("%s/%s/%s", report.Namespace, source.Name, cert.Subject)
+			current[key] = watchedCert{
+				pod:    source.Name,
+				source: source.Key,
+				cert:   cert,
+				status: CertificateStatus(cert, report.WarningDays),
+			}
+		}
+	}
+
+	if w.previous == nil {
+		w.previous = current
+		return nil
+	}
+
+	var changes []Change
+	change := func(kind string, state watchedCert, previousStatus string) {
+		changes = append(changes, Change{
+			Time:            now,
+			Kind:            kind,
+			Namespace:       report.Namespace,
+			Pod:             state.pod,
+			Source:          state.source,
+			Subject:         state.cert.Subject,
+			NotAfter:        state.cert.NotAfter,
+			DaysUntilExpiry: state.cert.DaysUntilExp,
+			Status:          state.status,
+			PreviousStatus:  previousStatus,
+		})
+	}
+
+	for key, cur := range current {
+		prev, existed := w.previous[key]
+		switch {
+		case !existed:
+			change(ChangeAdded, cur, "")
+		case cur.cert.SerialNumber != prev.cert.SerialNumber && cur.cert.NotAfter.After(prev.cert.NotAfter):
+			change(ChangeRenewed, cur, prev.status)
+		case cur.status != prev.status:
+			change(ChangeStatus, cur, prev.status)
+		}
+	}
+	for key, prev := range w.previous {
+		if _, exists := current[key]; !exists {
+			change(ChangeRemoved, prev, prev.status)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Source != changes[j].Source {
+			return changes[i].Source < changes[j].Source
+		}
+		return changes[i].Subject < changes[j].Subject
+	})
+
+	w.previous = current
+	return changes
+}
+
+// WriteChanges renders watch mode changes, one per line. Table format writes
+// timestamped text lines and JSON format writes one JSON object per line.
+func WriteChanges(w io.Writer, format string, changes []Change, opts Options) error {
+	for _, c := range changes {
+		if format == FormatJSON {
+			if err := json.NewEncoder(w).Encode(c); err != nil {
+				return err
+			}
+			continue
+		}
+
+		detail := fmt.Sprintf("expires %s (%d days)", c.NotAfter.Format("2006-01-02"), c.DaysUntilExpiry)
+		switch c.Kind {
+		case ChangeRenewed:
+			detail = fmt.Sprintf("renewed, now %s", detail)
+		case ChangeStatus:
+			detail = fmt.Sprintf("%s -> %s, %s", c.PreviousStatus, colorize(c.Status, opts), detail)
+		case ChangeRemoved:
+			detail = "no longer mounted"
+		}
+
+		if _, err := fmt.Fprintf(w, "%s  %-8s %s/%s  %s  %s\n",
+			c.Time.Format(time.RFC3339), c.Kind, c.Pod, c.Source, c.Subject, detail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package push
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/redact"
+	"k8s-web-service/internal/scanner"
+	"k8s-web-service/pkg/utils"
+)
+
+// certificateLabels label the metrics of each certificate found in a pod
+var certificateLabels = []string{"namespace", "pod", "source", "subject", "serial"}
+
+var (
+	expiryDesc = prometheus.NewDesc("k8s_cert_expiry_timestamp_seconds",
+		"Expiry time of each certificate found in a pod", certificateLabels, nil)
+	daysDesc = prometheus.NewDesc("k8s_cert_days_until_expiry",
+		"Days until each certificate found in a pod expires, negative once expired", certificateLabels, nil)
+	certificatesDesc = prometheus.NewDesc("k8s_cert_scan_certificates",
+		"Certificates found per namespace", []string{"namespace"}, nil)
+	warningsDesc = prometheus.NewDesc("k8s_cert_scan_warnings",
+		"Expired or expiring certificates per namespace", []string{"namespace"}, nil)
+	healthDesc = prometheus.NewDesc("k8s_cert_health_score",
+		"Certificate health score per namespace, from 0 to 100", []string{"namespace"}, nil)
+	failedNamespacesDesc = prometheus.NewDesc("k8s_cert_scan_failed_namespaces",
+		"Namespaces that could not be scanned", nil, nil)
+	scanTimestampDesc = prometheus.NewDesc("k8s_cert_scan_timestamp_seconds",
+		"Time the scan started", nil, nil)
+	scanDurationDesc = prometheus.NewDesc("k8s_cert_scan_duration_seconds",
+		"Time the scan took", nil, nil)
+	scanErrorsDesc = prometheus.NewDesc("k8s_cert_scan_errors_total",
+		"Background scans that failed as a whole", nil, nil)
+	namespaceErrorsDesc = prometheus.NewDesc("k8s_cert_scan_namespace_errors_total",
+		"Failed background scans of each namespace", []string{"namespace"}, nil)
+)
+
+// resultCollector collects the metrics of a scan result
+type resultCollector struct {
+	result *scanner.Result
+	policy redact.Policy
+}
+
+// NewResultCollector returns a collector of the metrics of a scan result.
+// The subject and serial labels are redacted as policy redacts certificate
+// identities. A certificate found twice in the same pod and source, or
+// several whose labels are the same once redacted, is reported once with
+// the soonest expiry.
+func NewResultCollector(result *scanner.Result, policy redact.Policy) prometheus.Collector {
+	return resultCollector{result: result, policy: policy}
+}
+
+// Describe sends the descriptors of the scan result metrics
+func (c resultCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{expiryDesc, daysDesc, certificatesDesc, warningsDesc, healthDesc,
+		failedNamespacesDesc, scanTimestampDesc, scanDurationDesc} {
+		ch <- desc
+	}
+}
+
+// Collect sends the scan result metrics. Days are computed when the
+// metrics are collected, so the gauge keeps counting down between scans.
+func (c resultCollector) Collect(ch chan<- prometheus.Metric) {
+	seen := make(map[[5]string]bool)
+	eachPodCertificate(c.result, func(namespace, pod, source string, cert *utils.CertificateInfo) {
+		subject, _, serial := c.policy.Identity(namespace, cert.Subject, cert.Issuer, cert.SerialNumber)
+		labels := [5]string{namespace, pod, source, subject, serial}
+		if seen[labels] {
+			return
+		}
+		seen[labels] = true
+		ch <- gauge(expiryDesc, float64(cert.NotAfter.Unix()), labels[:]...)
+		ch <- gauge(daysDesc, math.Round(time.Until(cert.NotAfter).Hours()/24*100)/100, labels[:]...)
+	})
+
+	namespaces := make(map[string]bool)
+	for _, report := range c.result.Reports {
+		if namespaces[report.Namespace] {
+			continue
+		}
+		namespaces[report.Namespace] = true
+		ch <- gauge(certificatesDesc, float64(report.TotalCertificates), report.Namespace)
+		ch <- gauge(warningsDesc, float64(report.TotalWarnings), report.Namespace)
+		ch <- gauge(healthDesc, float64(k8s.NamespaceHealthScore(report).Score), report.Namespace)
+	}
+
+	ch <- gauge(failedNamespacesDesc, float64(len(c.result.FailedNamespaces)))
+	ch <- gauge(scanTimestampDesc, float64(c.result.StartedAt.Unix()))
+	ch <- gauge(scanDurationDesc, c.result.DurationSeconds)
+}
+
+// scanErrorsCollector collects the scan failure counters of a scanner
+type scanErrorsCollector struct {
+	errors scanner.ScanErrors
+}
+
+// NewScanErrorsCollector returns a collector of the scan failure counters
+// of a scanner
+func NewScanErrorsCollector(errors scanner.ScanErrors) prometheus.Collector {
+	return scanErrorsCollector{errors: errors}
+}
+
+// Describe sends the descriptors of the scan failure counters
+func (c scanErrorsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- scanErrorsDesc
+	ch <- namespaceErrorsDesc
+}
+
+// Collect sends the scan failure counters
+func (c scanErrorsCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- counter(scanErrorsDesc, float64(c.errors.Scans))
+	for namespace, failures := range c.errors.Namespaces {
+		ch <- counter(namespaceErrorsDesc, float64(failures), namespace)
+	}
+}
+
+// gauge returns a gauge sample, or an invalid metric failing the scrape of
+// that sample if a label value is not valid UTF-8
+func gauge(desc *prometheus.Desc, value float64, labels ...string) prometheus.Metric {
+	metric, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+	if err != nil {
+		return prometheus.NewInvalidMetric(desc, err)
+	}
+	return metric
+}
+
+// counter returns a counter sample like gauge
+func counter(desc *prometheus.Desc, value float64, labels ...string) prometheus.Metric {
+	metric, err := prometheus.NewConstMetric(desc, prometheus.CounterValue, value, labels...)
+	if err != nil {
+		return prometheus.NewInvalidMetric(desc, err)
+	}
+	return metric
+}
+
+// encodeText gathers the metrics of a registry in the Prometheus text
+// format
+func encodeText(registry *prometheus.Registry) ([]byte, error) {
+	families, err := registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// eachPodCertificate calls fn with every certificate found in a pod, in
+// namespace, pod, source, and expiry order
+func eachPodCertificate(result *scanner.Result, fn func(namespace, pod, source string, cert *utils.CertificateInfo)) {
+	for _, report := range result.Reports {
+		for _, pod := range report.Pods {
+			var sources []string
+			for name := range pod.CertSources {
+				sources = append(sources, name)
+			}
+			sort.Strings(sources)
+
+			for _, name := range sources {
+				certs := append([]*utils.CertificateInfo{}, pod.CertSources[name].Certificates...)
+				sort.SliceStable(certs, func(i, j int) bool { return certs[i].NotAfter.Before(certs[j].NotAfter) })
+				for _, cert := range certs {
+					fn(report.Namespace, pod.PodName, name, cert)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+
+	"k8s-web-service/internal/auth"
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/redact"
+	"k8s-web-service/internal/scanner"
+)
+
+// defaultPushgatewayGroup is the grouping key used when a pushgateway target has no path
+const defaultPushgatewayGroup = "/job/k8s-web-service"
+
+// Pusher delivers scan results to an external destination
+type Pusher interface {
+	// Target describes the destination for logging
+	Target() string
+	Push(ctx context.Context, result *scanner.Result) error
+}
+
+// New creates a pusher for a target:
+//   - http(s)://host/path receives the result as a JSON POST
+//   - s3://bucket/prefix stores the result as prefix/scan-<timestamp>.json
+//   - pushgateway://host:port[/job/name/...] receives the metrics over HTTP;
+//     use pushgateway+https:// for TLS
+//
+// Results are redacted as security configures for responses.
+func New(target string, cfg *config.Config) (Pusher, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid push target %q", target)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	policy := redact.NewPolicy(cfg)
+
+	switch u.Scheme {
+	case "http", "https":
+		return &httpPusher{url: target, client: client, policy: policy}, nil
+	case "s3":
+		return &s3Pusher{cfg: cfg, bucket: u.Host, prefix: strings.Trim(u.Path, "/"), policy: policy}, nil
+	case "pushgateway", "pushgateway+http", "pushgateway+https":
+		scheme := "http"
+		if u.Scheme == "pushgateway+https" {
+			scheme = "https"
+		}
+		group := strings.TrimSuffix(u.Path, "/")
+		if group == "" {
+			group = defaultPushgatewayGroup
+		}
+		return &pushgatewayPusher{
+			url:    fmt.Sprintf("%s://%s/metrics%s", scheme, u.Host, group),
+			client: client,
+			policy: policy,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported push target scheme %q (supported: http, https, s3, pushgateway)", u.Scheme)
+	}
+}
+
+// httpPusher posts the result as JSON
+type httpPusher struct {
+	url    string
+	client *http.Client
+	policy redact.Policy
+}
+
+func (p *httpPusher) Target() string { return p.url }
+
+func (p *httpPusher) Push(ctx context.Context, result *scanner.Result) error {
+	body, err := p.policy.Marshal(result, "")
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	return send(ctx, p.client, http.MethodPost, p.url, "application/json", body)
+}
+
+// s3Pusher stores the result as a JSON object
+type s3Pusher struct {
+	cfg    *config.Config
+	bucket string
+	prefix string
+	policy redact.Policy
+}
+
+func (p *s3Pusher) Target() string { return fmt.Sprintf("s3://%s/%s", p.bucket, p.prefix) }
+
+func (p *s3Pusher) Push(ctx context.Context, result *scanner.Result) error {
+	data, err := p.policy.Marshal(result, "")
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	var body bytes.Buffer
+	if err := json.Indent(&body, data, "", "  "); err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	awsCfg, err := auth.LoadAWSConfig(ctx, p.cfg)
+	if err != nil {
+		return err
+	}
+
+	key := path.Join(p.prefix, fmt.Sprintf("scan-%s.json", result.StartedAt.UTC().Format("20060102T150405Z")))
+	_, err = s3.NewFromConfig(awsCfg).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(p.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body.Bytes()),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload s3://%s/%s: %w", p.bucket, key, err)
+	}
+	return nil
+}
+
+// pushgatewayPusher replaces the metrics of its grouping key in a Prometheus Pushgateway
+type pushgatewayPusher struct {
+	url    string
+	client *http.Client
+	policy redact.Policy
+}
+
+func (p *pushgatewayPusher) Target() string { return p.url }
+
+func (p *pushgatewayPusher) Push(ctx context.Context, result *scanner.Result) error {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewResultCollector(result, p.policy))
+	body, err := encodeText(registry)
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	return send(ctx, p.client, http.MethodPut, p.url, string(expfmt.NewFormat(expfmt.TypeTextPlain)), body)
+}
+
+// send issues a request and treats any non-2xx response as an error
+func send(ctx context.Context, client *http.Client, method, url, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
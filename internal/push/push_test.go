@@ -0,0 +1,224 @@
+package push
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/notify"
+	"k8s-web-service/internal/redact"
+	"k8s-web-service/internal/scanner"
+	"k8s-web-service/pkg/utils"
+)
+
+const (
+	testSubject = "CN=payments.internal,O=Example"
+	testSerial  = "4f:1a:9c"
+)
+
+// testResult is a scan result with one certificate in the payments namespace
+func testResult() *scanner.Result {
+	cert := &utils.CertificateInfo{
+		Subject:      testSubject,
+		Issuer:       "CN=Example CA",
+		SerialNumber: testSerial,
+		NotAfter:     time.Now().Add(72 * time.Hour),
+		Fingerprint:  "ab12",
+	}
+	return &scanner.Result{
+		StartedAt: time.Now(),
+		Reports: []*k8s.NamespaceExpiryReport{{
+			Namespace:         "payments",
+			TotalCertificates: 1,
+			Pods: []k8s.PodExpiryInfo{{
+				PodName: "api",
+				CertSources: map[string]*k8s.CertificateSource{
+					"tls": {Type: "secret", Name: "api-tls", Namespace: "payments", Key: "tls.crt", Certificates: []*utils.CertificateInfo{cert}},
+				},
+			}},
+		}},
+		Alerts: []notify.Alert{{Namespace: "payments", Pod: "api", Source: "tls", Subject: testSubject, SerialNumber: testSerial}},
+	}
+}
+
+func privacyConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Security.PrivacyMode = true
+	return cfg
+}
+
+// resultMetrics returns the metrics of a scan result in the text format
+func resultMetrics(t *testing.T, result *scanner.Result, policy redact.Policy) string {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewResultCollector(result, policy))
+	metrics, err := encodeText(registry)
+	if err != nil {
+		t.Fatalf("encodeText: %v", err)
+	}
+	return string(metrics)
+}
+
+func TestResultCollectorRedaction(t *testing.T) {
+	plain := resultMetrics(t, testResult(), redact.NewPolicy(&config.Config{}))
+	redacted := resultMetrics(t, testResult(), redact.NewPolicy(privacyConfig()))
+
+	if !strings.Contains(plain, testSubject) || !strings.Contains(plain, testSerial) {
+		t.Errorf("metrics lack the subject and serial without redaction:\n%s", plain)
+	}
+	for _, s := range []string{testSubject, testSerial} {
+		if strings.Contains(redacted, s) {
+			t.Errorf("metrics contain %q in privacy mode:\n%s", s, redacted)
+		}
+	}
+	if !strings.Contains(redacted, `subject="[REDACTED]"`) {
+		t.Errorf("metrics lack the redacted subject label:\n%s", redacted)
+	}
+}
+
+func TestResultCollectorDuplicateSeries(t *testing.T) {
+	result := testResult()
+	source := result.Reports[0].Pods[0].CertSources["tls"]
+	later := *source.Certificates[0]
+	later.NotAfter = later.NotAfter.Add(24 * time.Hour)
+	other := *source.Certificates[0]
+	other.Subject, other.SerialNumber, other.NotAfter = "CN=other", "77", later.NotAfter
+	// The same certificate twice, after a later one, and a second
+	// certificate whose labels only match once redacted
+	source.Certificates = []*utils.CertificateInfo{&later, source.Certificates[0], source.Certificates[0], &other}
+
+	metrics := resultMetrics(t, result, redact.NewPolicy(privacyConfig()))
+
+	if n := strings.Count(metrics, "k8s_cert_expiry_timestamp_seconds{"); n != 1 {
+		t.Errorf("expiry series = %d, want 1:\n%s", n, metrics)
+	}
+	want := strconv.FormatFloat(float64(source.Certificates[1].NotAfter.Unix()), 'g', -1, 64)
+	if !strings.Contains(metrics, `subject="[REDACTED]"} `+want+"\n") {
+		t.Errorf("expiry series does not report the soonest expiry %s:\n%s", want, metrics)
+	}
+	for _, name := range []string{"k8s_cert_expiry_timestamp_seconds", "k8s_cert_scan_certificates"} {
+		if n := strings.Count(metrics, "# TYPE "+name+" "); n != 1 {
+			t.Errorf("TYPE lines of %s = %d, want 1", name, n)
+		}
+	}
+}
+
+func TestPushRedaction(t *testing.T) {
+	bodies := make(chan []byte, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	targets := []string{server.URL + "/results", strings.Replace(server.URL, "http://", "pushgateway://", 1)}
+	for _, target := range targets {
+		t.Run(target, func(t *testing.T) {
+			p, err := New(target, privacyConfig())
+			if err != nil {
+				t.Fatalf("New(%s): %v", target, err)
+			}
+			if err := p.Push(context.Background(), testResult()); err != nil {
+				t.Fatalf("Push: %v", err)
+			}
+			body := string(<-bodies)
+			for _, s := range []string{testSubject, testSerial, "CN=Example CA"} {
+				if strings.Contains(body, s) {
+					t.Errorf("pushed payload contains %q: %s", s, body)
+				}
+			}
+		})
+	}
+}
+
+func TestNewTargets(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"https://results.example.com/scans", "https://results.example.com/scans"},
+		{"s3://scan-results/cluster-a/", "s3://scan-results/cluster-a"},
+		{"pushgateway://gateway:9091", "http://gateway:9091/metrics/job/k8s-web-service"},
+		{"pushgateway+https://gateway:9091/job/certs/cluster/a/", "https://gateway:9091/metrics/job/certs/cluster/a"},
+	}
+	for _, tt := range tests {
+		pusher, err := New(tt.target, &config.Config{})
+		if err != nil {
+			t.Errorf("New(%q) failed: %v", tt.target, err)
+			continue
+		}
+		if got := pusher.Target(); got != tt.want {
+			t.Errorf("New(%q).Target() = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+
+	for _, target := range []string{"ftp://files.example.com/scans", "results.example.com/scans", "https://"} {
+		if _, err := New(target, &config.Config{}); err == nil {
+			t.Errorf("New(%q) succeeded, want an error", target)
+		}
+	}
+}
+
+func TestPushDelivery(t *testing.T) {
+	tests := []struct {
+		scheme string
+		method string
+		path   string
+		want   string
+	}{
+		{"http", http.MethodPost, "/scans", `"namespace":"payments"`},
+		{"pushgateway", http.MethodPut, "/metrics/job/k8s-web-service", `k8s_cert_scan_certificates{namespace="payments"} 1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			var method, path, body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				method, path, body = r.Method, r.URL.Path, string(data)
+			}))
+			defer server.Close()
+
+			target := strings.Replace(server.URL, "http", tt.scheme, 1)
+			if tt.scheme == "http" {
+				target += tt.path
+			}
+			pusher, err := New(target, &config.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := pusher.Push(context.Background(), testResult()); err != nil {
+				t.Fatal(err)
+			}
+			if method != tt.method || path != tt.path {
+				t.Errorf("request = %s %s, want %s %s", method, path, tt.method, tt.path)
+			}
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("body does not contain %s:\n%s", tt.want, body)
+			}
+		})
+	}
+}
+
+func TestPushFailureStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	pusher, err := New(server.URL, &config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pusher.Push(context.Background(), testResult()); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Push = %v, want an error with the 503 status", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Package readonly defines what the service may do against the cluster and
+// AWS when read-only mode is enabled. The allow lists are deliberately small
+// and kept in one place so they can be reviewed on their own.
+package readonly
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrReadOnly is returned for requests refused in read-only mode
+var ErrReadOnly = errors.New("refused in read-only mode")
+
+// kubernetesVerbs are the Kubernetes API verbs allowed in read-only mode
+var kubernetesVerbs = map[string]bool{
+	"get":   true,
+	"list":  true,
+	"watch": true,
+}
+
+// reviewResources may be created in read-only mode because the API server
+// evaluates them and returns the result without persisting anything, by API
+// group
+var reviewResources = map[string]map[string]bool{
+	"authorization.k8s.io": {
+		"selfsubjectaccessreviews": true,
+		"selfsubjectrulesreviews":  true,
+	},
+	"authentication.k8s.io": {
+		"selfsubjectreviews": true,
+	},
+}
+
+// deniedSubresources are refused in read-only mode whatever the verb: they
+// run commands in containers, open streams to them, or forward requests to
+// pods, services, and kubelets, so even a GET can change state
+var deniedSubresources = map[string]bool{
+	"exec":        true,
+	"attach":      true,
+	"portforward": true,
+	"proxy":       true,
+}
+
+// kubeletConfigzPath is the one proxied request allowed in read-only mode:
+// the kubelet's /configz, read for kubelet certificate rotation settings
+const kubeletConfigzPath = "configz"
+
+// awsOperationPrefixes are the AWS API operation name prefixes allowed in read-only mode
+var awsOperationPrefixes = []string{"Get", "List", "Describe", "Head"}
+
+// awsOperations are individual AWS API operations allowed in read-only mode.
+// Assuming a role only issues temporary credentials.
+var awsOperations = map[string]bool{
+	"AssumeRole":                true,
+	"AssumeRoleWithWebIdentity": true,
+}
+
+// AllowKubernetesVerb reports whether a Kubernetes API verb on a resource of
+// an API group, or on one of its subresources, is allowed in read-only mode
+func AllowKubernetesVerb(verb, group, resource, subresource string) bool {
+	if deniedSubresources[subresource] {
+		return false
+	}
+	return kubernetesVerbs[verb] || (verb == "create" && subresource == "" && reviewResources[group][resource])
+}
+
+// AllowKubernetesRequest reports whether an HTTP request to the Kubernetes
+// API server is allowed in read-only mode. Paths may carry the prefix of a
+// server URL, such as a proxy's, before /api or /apis.
+func AllowKubernetesRequest(method, path string) bool {
+	segments := apiSegments(path)
+	if subresource, rest := requestSubresource(segments); deniedSubresources[subresource] {
+		return subresource == "proxy" && isKubeletConfigz(segments, rest) && (method == http.MethodGet || method == http.MethodHead)
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		return isReviewPath(segments)
+	default:
+		return false
+	}
+}
+
+// apiSegments returns the segments of a Kubernetes API path from /api or
+// /apis on, or nil when the path is outside the API, such as /version
+func apiSegments(path string) []string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if segment == "api" || segment == "apis" {
+			return segments[i:]
+		}
+	}
+	return nil
+}
+
+// requestSubresource returns the subresource of a request from the segments
+// of its API path, with the segments following it. The legacy
+// /api/v1/proxy/... and /api/v1/watch/... forms are recognized.
+func requestSubresource(segments []string) (string, []string) {
+	var rest []string
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		rest = segments[2:] // api/<version>
+	case len(segments) >= 3 && segments[0] == "apis":
+		rest = segments[3:] // apis/<group>/<version>
+	default:
+		return "", nil
+	}
+	if len(rest) > 0 && rest[0] == "proxy" {
+		return "proxy", rest[1:]
+	}
+	if len(rest) > 0 && rest[0] == "watch" {
+		rest = rest[1:]
+	}
+	if len(rest) >= 3 && rest[0] == "namespaces" {
+		rest = rest[2:] // namespaces/<namespace>/<resource>/...
+	}
+	if len(rest) < 3 {
+		return "", nil // a collection, an object, or a namespace's own subresource
+	}
+	return rest[2], rest[3:] // <resource>/<name>/<subresource>/...
+}
+
+// isKubeletConfigz reports whether the segments of a request to the proxy
+// subresource, and those following it, read a node's /configz
+func isKubeletConfigz(segments, rest []string) bool {
+	return len(segments) == 6 && segments[0] == "api" && segments[2] == "nodes" &&
+		len(rest) == 1 && rest[0] == kubeletConfigzPath
+}
+
+// isReviewPath reports whether the segments of an API path address a review
+// resource: apis/<group>/<version>/<resource>
+func isReviewPath(segments []string) bool {
+	return len(segments) == 4 && segments[0] == "apis" && reviewResources[segments[1]][segments[3]]
+}
+
+// AllowAWSOperation reports whether an AWS API operation is allowed in read-only mode
+func AllowAWSOperation(operation string) bool {
+	if awsOperations[operation] {
+		return true
+	}
+	for _, prefix := range awsOperationPrefixes {
+		if strings.HasPrefix(operation, prefix) {
+			return true
+		}
+	}
+	return false
+}
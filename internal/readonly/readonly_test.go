@@ -0,0 +1,119 @@
+package readonly
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAllowKubernetesRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   bool
+	}{
+		{"list pods", http.MethodGet, "/api/v1/namespaces/default/pods", true},
+		{"get secret", http.MethodGet, "/api/v1/namespaces/default/secrets/tls", true},
+		{"watch pods", http.MethodGet, "/api/v1/watch/namespaces/default/pods", true},
+		{"pod logs", http.MethodGet, "/api/v1/namespaces/default/pods/web/log", true},
+		{"namespace named proxy", http.MethodGet, "/api/v1/namespaces/proxy/pods", true},
+		{"pod named exec", http.MethodGet, "/api/v1/namespaces/default/pods/exec", true},
+		{"group resource", http.MethodGet, "/apis/apps/v1/namespaces/default/deployments/web", true},
+		{"head", http.MethodHead, "/api/v1/namespaces", true},
+		{"version", http.MethodGet, "/version", true},
+		{"server prefix", http.MethodGet, "/k8s/clusters/c-1/api/v1/pods", true},
+
+		{"exec upgrade", http.MethodGet, "/api/v1/namespaces/default/pods/web/exec", false},
+		{"exec", http.MethodPost, "/api/v1/namespaces/default/pods/web/exec", false},
+		{"attach", http.MethodGet, "/api/v1/namespaces/default/pods/web/attach", false},
+		{"portforward", http.MethodGet, "/api/v1/namespaces/default/pods/web/portforward", false},
+		{"pod proxy", http.MethodGet, "/api/v1/namespaces/default/pods/web/proxy/admin", false},
+		{"service proxy", http.MethodGet, "/api/v1/namespaces/default/services/https:web:443/proxy/", false},
+		{"node proxy", http.MethodGet, "/api/v1/nodes/node-1/proxy/pods", false},
+		{"node proxy run", http.MethodPost, "/api/v1/nodes/node-1/proxy/run/default/web/app", false},
+		{"legacy proxy", http.MethodGet, "/api/v1/proxy/namespaces/default/pods/web", false},
+		{"exec behind server prefix", http.MethodGet, "/k8s/clusters/c-1/api/v1/namespaces/default/pods/web/exec", false},
+		{"kubelet configz", http.MethodGet, "/api/v1/nodes/node-1/proxy/configz", true},
+		{"kubelet configz post", http.MethodPost, "/api/v1/nodes/node-1/proxy/configz", false},
+		{"pod configz", http.MethodGet, "/api/v1/namespaces/default/pods/web/proxy/configz", false},
+
+		{"access review", http.MethodPost, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", true},
+		{"rules review", http.MethodPost, "/apis/authorization.k8s.io/v1/selfsubjectrulesreviews", true},
+		{"self subject review", http.MethodPost, "/apis/authentication.k8s.io/v1/selfsubjectreviews", true},
+		{"review behind server prefix", http.MethodPost, "/k8s/clusters/c-1/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", true},
+		{"review in the wrong group", http.MethodPost, "/apis/authentication.k8s.io/v1/selfsubjectaccessreviews", false},
+		{"review-named object", http.MethodPost, "/api/v1/namespaces/default/configmaps/selfsubjectaccessreviews", false},
+		{"review-named custom resource", http.MethodPost, "/apis/example.com/v1/selfsubjectreviews", false},
+		{"review subresource", http.MethodPost, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews/x/status", false},
+
+		{"create", http.MethodPost, "/api/v1/namespaces/default/configmaps", false},
+		{"update", http.MethodPut, "/api/v1/namespaces/default/configmaps/state", false},
+		{"patch", http.MethodPatch, "/api/v1/namespaces/default/pods/web", false},
+		{"delete", http.MethodDelete, "/api/v1/namespaces/default/pods/web", false},
+		{"evict", http.MethodPost, "/api/v1/namespaces/default/pods/web/eviction", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AllowKubernetesRequest(tt.method, tt.path); got != tt.want {
+				t.Errorf("AllowKubernetesRequest(%s, %s) = %t, want %t", tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowKubernetesVerb(t *testing.T) {
+	tests := []struct {
+		verb, group, resource, subresource string
+		want                               bool
+	}{
+		{"get", "", "pods", "", true},
+		{"list", "", "secrets", "", true},
+		{"watch", "apps", "deployments", "", true},
+		{"get", "", "pods", "log", true},
+		{"create", "authorization.k8s.io", "selfsubjectaccessreviews", "", true},
+		{"create", "authentication.k8s.io", "selfsubjectreviews", "", true},
+
+		{"get", "", "pods", "exec", false},
+		{"create", "", "pods", "exec", false},
+		{"get", "", "pods", "attach", false},
+		{"get", "", "pods", "portforward", false},
+		{"get", "", "nodes", "proxy", false},
+		{"create", "authentication.k8s.io", "selfsubjectaccessreviews", "", false},
+		{"create", "", "configmaps", "", false},
+		{"update", "", "configmaps", "", false},
+		{"patch", "", "pods", "", false},
+		{"delete", "", "pods", "", false},
+		{"deletecollection", "", "pods", "", false},
+	}
+	for _, tt := range tests {
+		if got := AllowKubernetesVerb(tt.verb, tt.group, tt.resource, tt.subresource); got != tt.want {
+			t.Errorf("AllowKubernetesVerb(%s, %s, %s, %s) = %t, want %t", tt.verb, tt.group, tt.resource, tt.subresource, got, tt.want)
+		}
+	}
+}
+
+func TestAllowAWSOperation(t *testing.T) {
+	tests := []struct {
+		operation string
+		want      bool
+	}{
+		{"DescribeCluster", true},
+		{"ListCertificates", true},
+		{"GetSecretValue", true},
+		{"HeadObject", true},
+		{"AssumeRole", true},
+		{"AssumeRoleWithWebIdentity", true},
+
+		{"RenewCertificate", false},
+		{"IssueCertificate", false},
+		{"PutObject", false},
+		{"DeleteSecret", false},
+		{"UpdateClusterConfig", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := AllowAWSOperation(tt.operation); got != tt.want {
+			t.Errorf("AllowAWSOperation(%q) = %t, want %t", tt.operation, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+// Package redact removes certificate material from everything the service
+// emits: HTTP responses, exports, metrics, pushed results, and
+// notifications all go through the same Policy, so security settings hold
+// whatever the output path.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/pkg/utils"
+)
+
+// Redacted replaces removed values
+const Redacted = "[REDACTED]"
+
+// pemBlockPattern matches PEM blocks in output that is not JSON
+var pemBlockPattern = regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]+-----.*?-----END [A-Z0-9 ]+-----`)
+
+// identityKeys are the members of a certificate removed when subjects are
+// redacted. Any object with one of them reports a certificate identity.
+var identityKeys = map[string]bool{
+	"subject":       true,
+	"issuer":        true,
+	"serial_number": true,
+}
+
+// chainKeys are the members of a chain verification naming certificates of
+// the chain by subject, removed along with the certificate subjects
+var chainKeys = map[string]bool{
+	"chain":          true,
+	"missing_issuer": true,
+}
+
+// wellKnownKeySuffixes are the endings of secret key names kept when key
+// names are hidden: certificate and key files, whose names carry no secrets
+var wellKnownKeySuffixes = []string{".crt", ".pem", ".cer", ".key", ".p12", ".pfx", ".jks"}
+
+// Policy is what security configures to be removed from output
+type Policy struct {
+	// PEM removes PEM content
+	PEM bool
+	// KeyNames hides secret key names other than certificate and key file
+	// names, since an arbitrary key name may itself hold a token
+	KeyNames bool
+	// subjects removes certificate subjects everywhere
+	subjects bool
+	// sensitive removes certificate subjects of these namespaces; all
+	// namespaces if allNamespaces is set
+	sensitive     map[string]bool
+	allNamespaces bool
+}
+
+// NewPolicy returns the redaction policy configured under security
+func NewPolicy(cfg *config.Config) Policy {
+	security := cfg.Security
+	p := Policy{
+		PEM:      security.RedactPEM || security.PrivacyMode,
+		KeyNames: security.PrivacyMode,
+		subjects: security.RedactSubjects,
+	}
+	if security.PrivacyMode {
+		p.allNamespaces = len(security.SensitiveNamespaces) == 0
+		p.sensitive = make(map[string]bool)
+		for _, namespace := range security.SensitiveNamespaces {
+			p.sensitive[namespace] = true
+		}
+	}
+	return p
+}
+
+// Enabled reports whether the policy removes anything
+func (p Policy) Enabled() bool {
+	return p.PEM || p.KeyNames || p.subjects || p.allNamespaces || len(p.sensitive) > 0
+}
+
+// SubjectsOf reports whether certificate subjects, issuers, and serial
+// numbers are removed in a namespace
+func (p Policy) SubjectsOf(namespace string) bool {
+	return p.subjects || p.allNamespaces || p.sensitive[namespace]
+}
+
+// Identity returns the subject, issuer, and serial number of a certificate
+// found in namespace as they may be emitted
+func (p Policy) Identity(namespace, subject, issuer, serial string) (string, string, string) {
+	if p.SubjectsOf(namespace) {
+		return Redacted, Redacted, Redacted
+	}
+	return subject, issuer, serial
+}
+
+// Strings returns values found in namespace, such as warnings, with the
+// subjects and issuers of the certificates they mention replaced where
+// subjects are removed
+func (p Policy) Strings(namespace string, values []string, certs []*utils.CertificateInfo) []string {
+	if !p.SubjectsOf(namespace) || len(values) == 0 {
+		return values
+	}
+	identities := make(map[string]bool)
+	for _, cert := range certs {
+		if cert != nil {
+			addStrings(identities, cert.Subject)
+			addStrings(identities, cert.Issuer)
+		}
+	}
+	replacer := replacer(identities)
+	redacted := make([]string, len(values))
+	for i, value := range values {
+		redacted[i] = replacer.Replace(value)
+	}
+	return redacted
+}
+
+// KeyName returns a secret key name as it may be emitted
+func (p Policy) KeyName(name string) string {
+	if !p.KeyNames || name == "" || hasWellKnownSuffix(name) {
+		return name
+	}
+	return Redacted
+}
+
+// Text removes PEM blocks from output that is not JSON
+func (p Policy) Text(data []byte) []byte {
+	if !p.PEM {
+		return data
+	}
+	return pemBlockPattern.ReplaceAll(data, []byte(Redacted))
+}
+
+// JSON redacts an encoded JSON document found in namespace. Output that
+// does not decode as JSON is redacted as Text.
+func (p Policy) JSON(data []byte, namespace string) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return p.Text(data)
+	}
+	redacted, err := json.Marshal(p.Value(value, namespace))
+	if err != nil {
+		return p.Text(data)
+	}
+	if bytes.HasSuffix(data, []byte("\n")) {
+		redacted = append(redacted, '\n')
+	}
+	return redacted
+}
+
+// Marshal encodes value as JSON found in namespace and redacts it
+func (p Policy) Marshal(value interface{}, namespace string) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Enabled() {
+		return data, nil
+	}
+	return p.JSON(data, namespace), nil
+}
+
+// Value removes certificate material from a decoded JSON value found in
+// namespace. Objects belong to the namespace of the nearest enclosing
+// object that has one. Certificates keep their fingerprint_sha256 to
+// identify them, and are marked redacted. The subjects and issuers removed
+// are also removed from every other string, such as warnings, and so are
+// the subjects of chain verifications.
+func (p Policy) Value(value interface{}, namespace string) interface{} {
+	identities := make(map[string]bool)
+	p.collectIdentities(value, namespace, identities)
+	return p.value(value, namespace, replacer(identities))
+}
+
+// value removes certificate material from value, replacing the removed
+// identities found in strings with identities
+func (p Policy) value(value interface{}, namespace string, identities *strings.Replacer) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ns, ok := v["namespace"].(string); ok && ns != "" {
+			namespace = ns
+		}
+		subjects := p.SubjectsOf(namespace)
+		certificate := subjects && hasIdentity(v)
+		for key, member := range v {
+			switch {
+			case certificate && identityKeys[key]:
+				delete(v, key)
+			case subjects && chainKeys[key]:
+				v[key] = p.value(redactStrings(member), namespace, identities)
+			case key == "key":
+				if name, ok := member.(string); ok {
+					v[key] = p.KeyName(name)
+				}
+			case key == "keys":
+				v[key] = p.keyNames(member, namespace, identities)
+			default:
+				v[key] = p.value(member, namespace, identities)
+			}
+		}
+		if certificate {
+			v["redacted"] = true
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = p.value(item, namespace, identities)
+		}
+	case string:
+		if p.PEM && strings.Contains(v, "-----BEGIN ") {
+			return Redacted
+		}
+		return identities.Replace(v)
+	}
+	return value
+}
+
+// collectIdentities adds the subjects and issuers value reports in
+// namespaces whose subjects are removed to identities
+func (p Policy) collectIdentities(value interface{}, namespace string, identities map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ns, ok := v["namespace"].(string); ok && ns != "" {
+			namespace = ns
+		}
+		subjects := p.SubjectsOf(namespace)
+		for key, member := range v {
+			if subjects && (key == "subject" || key == "issuer" || chainKeys[key]) {
+				addStrings(identities, member)
+			}
+			p.collectIdentities(member, namespace, identities)
+		}
+	case []interface{}:
+		for _, item := range v {
+			p.collectIdentities(item, namespace, identities)
+		}
+	}
+}
+
+// keyNames hides the secret key names of a keys member: a list of names,
+// or of objects naming a key
+func (p Policy) keyNames(value interface{}, namespace string, identities *strings.Replacer) interface{} {
+	items, ok := value.([]interface{})
+	if !ok {
+		return p.value(value, namespace, identities)
+	}
+	for i, item := range items {
+		switch v := item.(type) {
+		case string:
+			items[i] = p.KeyName(v)
+		case map[string]interface{}:
+			if name, ok := v["name"].(string); ok {
+				v["name"] = p.KeyName(name)
+			}
+			items[i] = p.value(v, namespace, identities)
+		}
+	}
+	return items
+}
+
+// hasIdentity reports whether an object has a certificate identity member
+func hasIdentity(v map[string]interface{}) bool {
+	for key := range identityKeys {
+		if _, ok := v[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redactStrings replaces a string, or the strings of a list, with Redacted.
+// Other values, such as a chain of certificate objects, are kept for the
+// certificates to be redacted on their own.
+func redactStrings(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return Redacted
+	case []interface{}:
+		for i, item := range v {
+			if _, ok := item.(string); ok {
+				v[i] = Redacted
+			}
+		}
+	}
+	return value
+}
+
+// addStrings adds a non-empty string, or the non-empty strings of a list,
+// to names
+func addStrings(names map[string]bool, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		if v != "" && v != Redacted {
+			names[v] = true
+		}
+	case []interface{}:
+		for _, item := range v {
+			addStrings(names, item)
+		}
+	}
+}
+
+// replacer returns a replacer of identities with Redacted. Longer
+// identities come first, so one is not left partly replaced by another it
+// contains.
+func replacer(identities map[string]bool) *strings.Replacer {
+	names := make([]string, 0, len(identities))
+	for name := range identities {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	pairs := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		pairs = append(pairs, name, Redacted)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// hasWellKnownSuffix reports whether a key name ends in one of the
+// wellKnownKeySuffixes
+func hasWellKnownSuffix(name string) bool {
+	name = strings.ToLower(name)
+	for _, suffix := range wellKnownKeySuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
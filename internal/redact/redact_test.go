@@ -0,0 +1,149 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/pkg/utils"
+)
+
+const (
+	testPEM     = "-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIU\n-----END CERTIFICATE-----"
+	testSubject = "CN=payments.internal,O=Example"
+	testSerial  = "4f:1a:9c"
+	testKeyName = "db-password-hunter2"
+)
+
+// testPayload is a response holding a certificate, its chain verification
+// and warnings, PEM content, and secret key names in the payments and web
+// namespaces
+const testPayload = `{
+	"namespace": "payments",
+	"certificate": {"subject": "CN=payments.internal,O=Example", "issuer": "CN=Example CA", "serial_number": "4f:1a:9c", "fingerprint_sha256": "ab12"},
+	"alerts": [{"namespace": "payments", "subject": "CN=payments.internal,O=Example", "serial_number": "4f:1a:9c"}],
+	"violations": [{"namespace": "payments", "subject": "CN=payments.internal,O=Example", "reason": "weak key"}],
+	"pem": "-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIU\n-----END CERTIFICATE-----",
+	"source": {"namespace": "payments", "key": "db-password-hunter2", "certificates": [],
+		"verification": {"chain": ["CN=payments.internal,O=Example", "CN=Example Intermediate CA"], "missing_issuer": "CN=Example Root CA", "verified": false}},
+	"warnings": ["Certificate 'CN=payments.internal,O=Example' expires in 3 days (2026-10-18)", "Certificate 'CN=web.example.com' has EXPIRED on 2026-10-01"],
+	"secret": {"namespace": "payments", "keys": [{"name": "db-password-hunter2", "size": 12}, {"name": "tls.crt", "size": 900}]},
+	"truststore": {"namespace": "payments", "keys": ["db-password-hunter2", "ca.pem"]},
+	"web": {"namespace": "web", "subject": "CN=web.example.com", "fingerprint_sha256": "cd34"}
+}`
+
+func testPolicy(mutate func(*config.Config)) Policy {
+	cfg := &config.Config{}
+	mutate(cfg)
+	return NewPolicy(cfg)
+}
+
+func TestPolicyJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    Policy
+		forbidden []string
+		required  []string
+	}{
+		{
+			name:     "disabled",
+			policy:   testPolicy(func(cfg *config.Config) {}),
+			required: []string{"-----BEGIN ", testSubject, testSerial, testKeyName},
+		},
+		{
+			name:      "redact_pem",
+			policy:    testPolicy(func(cfg *config.Config) { cfg.Security.RedactPEM = true }),
+			forbidden: []string{"-----BEGIN ", "MIIB"},
+			required:  []string{testSubject, testSerial, testKeyName},
+		},
+		{
+			name:      "redact_subjects",
+			policy:    testPolicy(func(cfg *config.Config) { cfg.Security.RedactSubjects = true }),
+			forbidden: []string{"CN=", testSerial},
+			required:  []string{"-----BEGIN ", testKeyName, `"fingerprint_sha256":"ab12"`, "Certificate '[REDACTED]' expires in 3 days"},
+		},
+		{
+			name:      "privacy mode",
+			policy:    testPolicy(func(cfg *config.Config) { cfg.Security.PrivacyMode = true }),
+			forbidden: []string{"-----BEGIN ", "MIIB", "CN=", testSerial, testKeyName},
+			required:  []string{`"tls.crt"`, `"ca.pem"`, `"fingerprint_sha256":"cd34"`},
+		},
+		{
+			name: "privacy mode with sensitive namespaces",
+			policy: testPolicy(func(cfg *config.Config) {
+				cfg.Security.PrivacyMode = true
+				cfg.Security.SensitiveNamespaces = []string{"payments"}
+			}),
+			forbidden: []string{"-----BEGIN ", testSubject, testSerial, testKeyName, "CN=Example Intermediate CA", "CN=Example Root CA"},
+			required:  []string{`"subject":"CN=web.example.com"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := string(tt.policy.JSON([]byte(testPayload), "default"))
+			if !json.Valid([]byte(payload)) {
+				t.Fatalf("redacted payload is not JSON: %s", payload)
+			}
+			for _, s := range tt.forbidden {
+				if strings.Contains(payload, s) {
+					t.Errorf("payload contains %q: %s", s, payload)
+				}
+			}
+			for _, s := range tt.required {
+				if !strings.Contains(payload, s) {
+					t.Errorf("payload lacks %q: %s", s, payload)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyText(t *testing.T) {
+	csv := "namespace,pem\npayments,\"" + testPEM + "\"\n"
+	privacy := testPolicy(func(cfg *config.Config) { cfg.Security.PrivacyMode = true })
+	if got := string(privacy.Text([]byte(csv))); strings.Contains(got, "-----BEGIN ") || strings.Contains(got, "MIIB") {
+		t.Errorf("Text left PEM content in privacy mode: %s", got)
+	}
+	disabled := testPolicy(func(cfg *config.Config) {})
+	if got := string(disabled.Text([]byte(csv))); got != csv {
+		t.Errorf("Text changed output with redaction disabled: %s", got)
+	}
+	if got := string(privacy.JSON([]byte(csv), "default")); strings.Contains(got, "-----BEGIN ") {
+		t.Errorf("JSON left PEM content in output that is not JSON: %s", got)
+	}
+}
+
+func TestPolicyStrings(t *testing.T) {
+	policy := testPolicy(func(cfg *config.Config) {
+		cfg.Security.PrivacyMode = true
+		cfg.Security.SensitiveNamespaces = []string{"payments"}
+	})
+	certs := []*utils.CertificateInfo{{Subject: testSubject, Issuer: "CN=Example CA"}}
+	warnings := []string{"Certificate '" + testSubject + "' expires in 3 days (2026-10-18)"}
+	if got := policy.Strings("payments", warnings, certs); strings.Contains(got[0], "CN=") {
+		t.Errorf("Strings(payments) = %q, want the subject redacted", got)
+	}
+	if got := policy.Strings("web", warnings, certs); got[0] != warnings[0] {
+		t.Errorf("Strings(web) = %q, want it unchanged", got)
+	}
+}
+
+func TestPolicyIdentity(t *testing.T) {
+	policy := testPolicy(func(cfg *config.Config) {
+		cfg.Security.PrivacyMode = true
+		cfg.Security.SensitiveNamespaces = []string{"payments"}
+	})
+	if subject, issuer, serial := policy.Identity("payments", testSubject, "CN=Example CA", testSerial); subject != Redacted || issuer != Redacted || serial != Redacted {
+		t.Errorf("Identity(payments) = %q, %q, %q, want all redacted", subject, issuer, serial)
+	}
+	if subject, _, serial := policy.Identity("web", testSubject, "CN=Example CA", testSerial); subject != testSubject || serial != testSerial {
+		t.Errorf("Identity(web) = %q, %q, want them unchanged", subject, serial)
+	}
+	if got := policy.KeyName("tls.key"); got != "tls.key" {
+		t.Errorf("KeyName(tls.key) = %q, want it kept", got)
+	}
+	if got := policy.KeyName(testKeyName); got != Redacted {
+		t.Errorf("KeyName(%s) = %q, want %q", testKeyName, got, Redacted)
+	}
+}
@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"k8s-web-service/internal/k8s"
+)
+
+// Roles of a replica under leader election
+const (
+	RoleLeader   = "leader"
+	RoleFollower = "follower"
+)
+
+// followerSyncInterval is how often followers load the leader's results
+const followerSyncInterval = time.Minute
+
+// Role returns whether this replica leads the scanner, or "" if leader
+// election is disabled
+func (s *Scanner) Role() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.role
+}
+
+// setRole records whether this replica leads the scanner
+func (s *Scanner) setRole(role string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.role = role
+}
+
+// leaderIdentity names this replica in the Lease: the pod name when set
+// through the downward API, else the hostname, which is the pod name too
+func leaderIdentity() string {
+	if name := os.Getenv("POD_NAME"); name != "" {
+		return name
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "k8s-web-service"
+	}
+	return hostname
+}
+
+// runElected campaigns for the scanner Lease until ctx is cancelled. The
+// leader scans and notifies; followers serve the results it stores. A
+// leader that loses the Lease becomes a follower and campaigns again.
+// Leader election settings are read once.
+func (s *Scanner) runElected(ctx context.Context) {
+	cfg := s.store.Get()
+	election := cfg.Scanner.LeaderElection
+	leaseDuration, err := time.ParseDuration(election.LeaseDuration)
+	if err != nil {
+		log.Printf("Error: invalid scanner.leader_election.lease_duration %q: %v", election.LeaseDuration, err)
+		return
+	}
+	client, err := k8s.NewClient(cfg)
+	if err != nil {
+		log.Printf("Error: leader election: failed to create Kubernetes client: %v", err)
+		return
+	}
+
+	identity := leaderIdentity()
+	results := &resultStore{clientset: client.GetClientset(), namespace: election.Namespace, name: election.LeaseName + "-results"}
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: election.LeaseName, Namespace: election.Namespace},
+		Client:     client.GetClientset().CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+	log.Printf("Leader election: campaigning for lease %s/%s as %s", election.Namespace, election.LeaseName, identity)
+
+	var shards *shardCoordinator
+	if election.ShardNamespaces {
+		shards = newShardCoordinator(client.GetClientset(), election.Namespace, election.LeaseName, identity, leaseDuration)
+		go shards.heartbeat(ctx)
+		go s.runShard(ctx, shards)
+	}
+
+	s.setRole(RoleFollower)
+	go s.follow(ctx, results)
+	for ctx.Err() == nil {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			Name:            election.LeaseName,
+			LeaseDuration:   leaseDuration,
+			RenewDeadline:   leaseDuration * 2 / 3,
+			RetryPeriod:     leaseDuration / 5,
+			ReleaseOnCancel: true,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leadCtx context.Context) {
+					s.lead(leadCtx, results, shards)
+				},
+				OnStoppedLeading: func() {
+					s.setRole(RoleFollower)
+					log.Printf("Leader election: %s stopped leading the scanner", identity)
+				},
+				OnNewLeader: func(leader string) {
+					if leader != identity {
+						log.Printf("Leader election: %s leads the scanner", leader)
+					}
+				},
+			},
+		})
+	}
+}
+
+// lead runs scans, or merges the replicas' shards, as the leader until
+// leadership is lost. Alerts delivered by the previous leader are loaded
+// first so they are not delivered again.
+func (s *Scanner) lead(ctx context.Context, results *resultStore, shards *shardCoordinator) {
+	s.setRole(RoleLeader)
+	log.Printf("Leader election: leading the scanner")
+
+	if _, notified, err := results.load(ctx); err != nil {
+		log.Printf("Warning: failed to load delivered alerts, they may be delivered again: %v", err)
+	} else {
+		s.mu.Lock()
+		s.notified = notified
+		s.mu.Unlock()
+	}
+	s.runScans(ctx, results, shards)
+}
+
+// follow loads the leader's results while this replica is a follower, so
+// that every replica serves the same results
+func (s *Scanner) follow(ctx context.Context, results *resultStore) {
+	ticker := time.NewTicker(followerSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.Role() == RoleFollower {
+			result, _, err := results.load(ctx)
+			switch {
+			case err != nil:
+				log.Printf("Error: failed to load the leader's scan results: %v", err)
+			case result != nil:
+				s.mu.Lock()
+				s.last = result
+				s.lastScan = result.CompletedAt()
+				s.mu.Unlock()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,456 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/k8s"
+	"k8s-web-service/internal/lifecycle"
+	"k8s-web-service/internal/notify"
+	"k8s-web-service/internal/redact"
+)
+
+// Scanner periodically analyzes certificates in the configured namespaces
+// and forwards new expiry alerts to the notifier pipeline. Configuration
+// changes take effect from the next scan.
+type Scanner struct {
+	store     *config.Store
+	jobs      *lifecycle.Tracker
+	intervals chan time.Duration // interval changes picked up by Run
+	clients   *k8s.ClientManager
+
+	mu       sync.Mutex
+	notifier notify.Notifier
+	notified map[string]bool // alerts already delivered, keyed by AlertKey
+	lastScan time.Time
+	// lastNotified is when the notifiers last delivered a scan's new alerts,
+	// and notifyErr the error of the latest delivery, if it failed
+	lastNotified time.Time
+	notifyErr    error
+	last         *Result
+	role         string // RoleLeader or RoleFollower under leader election
+	errors       ScanErrors
+}
+
+// ScanErrors count the failures of this replica's scans since it started
+type ScanErrors struct {
+	// Scans is the number of scans that failed as a whole, such as when no
+	// Kubernetes client could be created
+	Scans uint64
+	// Namespaces is the number of failed scans of each namespace
+	Namespaces map[string]uint64
+}
+
+// New creates a scanner from the configuration, with notifiers built from
+// the notifier settings. Scheduled scans are registered with jobs, if not
+// nil, and skipped while it is draining.
+func New(store *config.Store, jobs *lifecycle.Tracker) (*Scanner, error) {
+	cfg := store.Get()
+	if _, err := cfg.ScanInterval(); err != nil {
+		return nil, err
+	}
+
+	s := &Scanner{
+		store:     store,
+		jobs:      jobs,
+		intervals: make(chan time.Duration, 1),
+		clients:   k8s.NewClientManager(),
+		notifier:  notify.FromConfig(cfg),
+		notified:  make(map[string]bool),
+		errors:    ScanErrors{Namespaces: make(map[string]uint64)},
+	}
+	store.OnChange(s.configChanged)
+	return s, nil
+}
+
+// configChanged rebuilds the notifiers and schedules an interval change
+func (s *Scanner) configChanged(prev, next *config.Config) {
+	if prev.Notifiers != next.Notifiers {
+		s.mu.Lock()
+		s.notifier = notify.FromConfig(next)
+		s.mu.Unlock()
+	}
+
+	interval, err := next.ScanInterval()
+	if err != nil || next.Scanner.Interval == prev.Scanner.Interval {
+		return
+	}
+
+	// Replace any pending change so Run always sees the latest interval
+	select {
+	case <-s.intervals:
+	default:
+	}
+	s.intervals <- interval
+}
+
+// Run scans immediately and then on every interval until ctx is cancelled.
+// With leader election enabled, only the replica holding the Lease scans.
+func (s *Scanner) Run(ctx context.Context) {
+	if s.store.Get().Scanner.LeaderElection.Enabled {
+		s.runElected(ctx)
+		return
+	}
+	s.runScans(ctx, nil, nil)
+}
+
+// runScans scans immediately and then on every interval until ctx is
+// cancelled, saving each result to results if it is not nil. With shards,
+// the results of the replicas' shards are merged instead of scanning every
+// namespace.
+func (s *Scanner) runScans(ctx context.Context, results *resultStore, shards *shardCoordinator) {
+	cfg := s.store.Get()
+	interval, _ := cfg.ScanInterval()
+	log.Printf("Scanner started: namespaces=%v interval=%s warning_days=%d notifiers=%s",
+		cfg.Scanner.Namespaces, interval, cfg.Scanner.WarningDays, s.currentNotifier().Name())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if end, ok := s.jobs.Begin("background_scan"); ok {
+			var result *Result
+			var err error
+			if shards != nil {
+				result, err = s.mergeShards(ctx, shards)
+			} else {
+				result, err = s.ScanOnce(ctx)
+			}
+			if err != nil {
+				log.Printf("Error: scan failed: %v", err)
+			}
+			s.recordErrors(result)
+			if result != nil && results != nil {
+				if err := results.save(ctx, result, s.notifiedKeys()); err != nil {
+					log.Printf("Error: failed to share scan results with other replicas: %v", err)
+				}
+			}
+			end()
+		} else {
+			log.Printf("Skipping scheduled scan: server is draining")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Scanner stopped")
+			return
+		case interval = <-s.intervals:
+			// Rescan right away so the new settings take effect immediately
+			log.Printf("Scanner interval changed to %s", interval)
+			ticker.Reset(interval)
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordErrors counts the failures of a scan, whose result is nil when it
+// failed as a whole
+func (s *Scanner) recordErrors(result *Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if result == nil {
+		s.errors.Scans++
+		return
+	}
+	for _, namespace := range result.FailedNamespaces {
+		s.errors.Namespaces[namespace]++
+	}
+}
+
+// Errors returns a copy of the scan failure counts
+func (s *Scanner) Errors() ScanErrors {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errors := ScanErrors{Scans: s.errors.Scans, Namespaces: make(map[string]uint64, len(s.errors.Namespaces))}
+	for namespace, count := range s.errors.Namespaces {
+		errors.Namespaces[namespace] = count
+	}
+	return errors
+}
+
+// currentNotifier returns the notifier built from the active configuration
+func (s *Scanner) currentNotifier() notify.Notifier {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notifier
+}
+
+// Result is the outcome of a single scan
+type Result struct {
+	StartedAt        time.Time                    `json:"started_at"`
+	DurationSeconds  float64                      `json:"duration_seconds"`
+	WarningDays      int                          `json:"warning_days"`
+	Reports          []*k8s.NamespaceExpiryReport `json:"namespaces"`
+	Alerts           []notify.Alert               `json:"alerts"`
+	FailedNamespaces []string                     `json:"failed_namespaces"`
+}
+
+// CompletedAt returns the time the scan finished
+func (r *Result) CompletedAt() time.Time {
+	return r.StartedAt.Add(time.Duration(r.DurationSeconds * float64(time.Second)))
+}
+
+// ScanOnce runs a single scan across all configured namespaces and notifies
+// about alerts that have not been delivered before. The result is returned
+// even when some namespaces failed to scan.
+func (s *Scanner) ScanOnce(ctx context.Context) (*Result, error) {
+	cfg := s.store.Get()
+	namespaces, err := s.targetNamespaces(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.scanNamespaces(ctx, cfg, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	return result, s.deliver(ctx, result)
+}
+
+// ScanOnceRemembered runs a single scan like ScanOnce, skipping the alerts
+// delivered by earlier runs. The delivered alerts and the result, redacted as
+// security configures, are kept in the <lease_name>-results ConfigMap of
+// leader election, so that each run of a CronJob notifies about an alert
+// once. When that state cannot be loaded, every current alert is delivered
+// again; when it cannot be saved, the next run may deliver them again, but
+// the scan and its delivery still succeeded.
+func (s *Scanner) ScanOnceRemembered(ctx context.Context) (*Result, error) {
+	cfg := s.store.Get()
+	client, err := s.clients.Client(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	election := cfg.Scanner.LeaderElection
+	results := &resultStore{
+		clientset: client.GetClientset(),
+		namespace: election.Namespace,
+		name:      election.LeaseName + "-results",
+		policy:    redact.NewPolicy(cfg),
+	}
+
+	if _, notified, err := results.load(ctx); err != nil {
+		log.Printf("Warning: failed to load delivered alerts, they may be delivered again: %v", err)
+	} else {
+		s.mu.Lock()
+		s.notified = notified
+		s.mu.Unlock()
+	}
+
+	result, scanErr := s.ScanOnce(ctx)
+	if result == nil {
+		return nil, scanErr
+	}
+	if err := results.save(ctx, result, s.notifiedKeys()); err != nil {
+		log.Printf("Warning: failed to save delivered alerts, the next run may deliver them again: %v", err)
+	}
+	return result, scanErr
+}
+
+// targetNamespaces returns the namespaces to scan: scanner.namespaces, or
+// every namespace of the cluster when it is "*"
+func (s *Scanner) targetNamespaces(ctx context.Context, cfg *config.Config) ([]string, error) {
+	if !cfg.ScansAllNamespaces() {
+		return cfg.Scanner.Namespaces, nil
+	}
+	client, err := s.clients.Client(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return k8s.ListNamespaceNames(ctx, client.GetClientset())
+}
+
+// scanNamespaces analyzes the certificates of namespaces, scanner.concurrency
+// at a time, without notifying. Namespaces that fail to scan are recorded in
+// the result.
+func (s *Scanner) scanNamespaces(ctx context.Context, cfg *config.Config, namespaces []string) (*Result, error) {
+	result := &Result{
+		StartedAt:        time.Now(),
+		WarningDays:      cfg.Scanner.WarningDays,
+		Reports:          []*k8s.NamespaceExpiryReport{},
+		Alerts:           []notify.Alert{},
+		FailedNamespaces: []string{},
+	}
+
+	client, err := s.clients.Client(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	reports, errs := k8s.MapNamespaces(ctx, namespaces, cfg.Scanner.Concurrency, func(ctx context.Context, namespace string) (*k8s.NamespaceExpiryReport, error) {
+		return k8s.AnalyzeNamespaceExpiry(ctx, client, namespace, cfg.Scanner.WarningDays)
+	})
+	for i, namespace := range namespaces {
+		if errs[i] != nil {
+			log.Printf("Error: scan of namespace %s failed: %v", namespace, errs[i])
+			result.FailedNamespaces = append(result.FailedNamespaces, namespace)
+			continue
+		}
+		result.Reports = append(result.Reports, reports[i])
+		result.Alerts = append(result.Alerts, AlertsFromReport(reports[i])...)
+	}
+	result.DurationSeconds = time.Since(result.StartedAt).Seconds()
+	return result, nil
+}
+
+// deliver records a complete scan result as the last one and notifies about
+// alerts that have not been delivered before. The scan counts as completed
+// whether or not the notifiers succeed.
+func (s *Scanner) deliver(ctx context.Context, result *Result) error {
+	s.mu.Lock()
+	s.last = result
+	s.lastScan = result.CompletedAt()
+	s.mu.Unlock()
+
+	newAlerts, current := s.filterNew(result.Alerts)
+	log.Printf("Scan completed: %d alerts, %d new", len(result.Alerts), len(newAlerts))
+
+	if len(newAlerts) > 0 {
+		if err := s.currentNotifier().Notify(ctx, newAlerts); err != nil {
+			// Keep the previous state so undelivered alerts are retried next scan
+			s.mu.Lock()
+			s.notifyErr = err
+			s.mu.Unlock()
+			return err
+		}
+	}
+	s.markNotified(current, result.FailedNamespaces)
+
+	if len(result.FailedNamespaces) > 0 {
+		return fmt.Errorf("scan failed for namespaces: %v", result.FailedNamespaces)
+	}
+	return nil
+}
+
+// LastScan returns the time the last scan completed
+func (s *Scanner) LastScan() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastScan
+}
+
+// LastNotification returns when the alerts of a scan were last delivered,
+// and the error of the latest delivery if it failed. A failed delivery does
+// not change LastScan.
+func (s *Scanner) LastNotification() (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastNotified, s.notifyErr
+}
+
+// LastResult returns the result of the most recent scan, or nil if no scan
+// has completed. The result must not be modified.
+func (s *Scanner) LastResult() *Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// notifiedKeys returns a copy of the keys of the delivered alerts
+func (s *Scanner) notifiedKeys() map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make(map[string]bool, len(s.notified))
+	for key := range s.notified {
+		keys[key] = true
+	}
+	return keys
+}
+
+// filterNew returns the alerts not delivered by a previous scan along with
+// the keys of all current alerts
+func (s *Scanner) filterNew(alerts []notify.Alert) ([]notify.Alert, map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := make(map[string]bool, len(alerts))
+	var newAlerts []notify.Alert
+	for _, alert := range alerts {
+		key := AlertKey(alert)
+		current[key] = true
+		if !s.notified[key] {
+			newAlerts = append(newAlerts, alert)
+		}
+	}
+	return newAlerts, current
+}
+
+// markNotified records the delivered alerts. Alerts that are no longer present
+// (e.g. the certificate was renewed) are forgotten so they are delivered again
+// if they reappear; alerts of namespaces that failed to scan are kept.
+func (s *Scanner) markNotified(current map[string]bool, failedNamespaces []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.notified {
+		for _, namespace := range failedNamespaces {
+			if strings.HasPrefix(key, namespace+"/") {
+				current[key] = true
+			}
+		}
+	}
+	s.notified = current
+	s.lastNotified = time.Now()
+	s.notifyErr = nil
+}
+
+// AlertsFromReport converts the expired and expiring certificates of a
+// report into alerts
+func AlertsFromReport(report *k8s.NamespaceExpiryReport) []notify.Alert {
+	var alerts []notify.Alert
+	for _, pod := range report.Pods {
+		var sourceNames []string
+		for name := range pod.CertSources {
+			sourceNames = append(sourceNames, name)
+		}
+		sort.Strings(sourceNames)
+
+		for _, name := range sourceNames {
+			for _, cert := range pod.CertSources[name].Certificates {
+				if !cert.IsExpired && cert.DaysUntilExp > report.WarningDays {
+					continue
+				}
+				alerts = append(alerts, notify.Alert{
+					Namespace:       report.Namespace,
+					Pod:             pod.PodName,
+					Source:          name,
+					Subject:         cert.Subject,
+					SerialNumber:    cert.SerialNumber,
+					NotAfter:        cert.NotAfter,
+					DaysUntilExpiry: cert.DaysUntilExp,
+					Expired:         cert.IsExpired,
+				})
+			}
+		}
+	}
+	// Custom resource certificates have no pod; the alert names the object
+	for _, source := range report.CustomResources {
+		for _, cert := range source.Certificates {
+			if !cert.IsExpired && cert.DaysUntilExp > report.WarningDays {
+				continue
+			}
+			alerts = append(alerts, notify.Alert{
+				Namespace:       report.Namespace,
+				Pod:             source.Name,
+				Source:          source.Key,
+				Subject:         cert.Subject,
+				SerialNumber:    cert.SerialNumber,
+				NotAfter:        cert.NotAfter,
+				DaysUntilExpiry: cert.DaysUntilExp,
+				Expired:         cert.IsExpired,
+			})
+		}
+	}
+	return alerts
+}
+
+// AlertKey identifies an alert independently of the remaining days, so an
+// expiring certificate is reported once rather than on every scan. Expiry
+// is part of the key so the transition to expired is reported as well.
+func AlertKey(a notify.Alert) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%t", a.Namespace, a.Pod, a.Source, a.SerialNumber, a.Expired)
+}
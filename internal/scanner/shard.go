@@ -0,0 +1,324 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/internal/k8s"
+)
+
+// shardLabel marks the membership Leases and shard result ConfigMaps of the
+// replicas sharing a scanner, with the leader election lease name as value
+const shardLabel = "k8s-web-service/scanner-shard"
+
+// Bounds of how long the leader waits for the replicas' shards of a round
+const (
+	shardPollInterval = 5 * time.Second
+	maxShardWait      = 5 * time.Minute
+)
+
+// shardCoordinator splits the scanned namespaces between the live replicas.
+// Each replica renews a membership Lease, scans the namespaces whose hash
+// falls on its position among the live members, and saves its shard to its
+// own ConfigMap; the leader merges the shards and notifies.
+type shardCoordinator struct {
+	clientset     kubernetes.Interface
+	namespace     string
+	group         string // the leader election lease name
+	identity      string
+	leaseDuration time.Duration
+	shard         *resultStore // this replica's shard results
+}
+
+// newShardCoordinator creates the coordinator of a replica
+func newShardCoordinator(clientset kubernetes.Interface, namespace, group, identity string, leaseDuration time.Duration) *shardCoordinator {
+	return &shardCoordinator{
+		clientset:     clientset,
+		namespace:     namespace,
+		group:         group,
+		identity:      identity,
+		leaseDuration: leaseDuration,
+		shard: &resultStore{
+			clientset: clientset,
+			namespace: namespace,
+			name:      group + "-shard-" + identity,
+			labels:    map[string]string{shardLabel: group},
+		},
+	}
+}
+
+// memberLease is the name of this replica's membership Lease
+func (c *shardCoordinator) memberLease() string {
+	return c.group + "-member-" + c.identity
+}
+
+// heartbeat renews this replica's membership Lease until ctx is cancelled,
+// then deletes it so the other replicas take over its namespaces at once
+func (c *shardCoordinator) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(c.leaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		if err := c.renew(ctx); err != nil {
+			log.Printf("Error: failed to renew scanner shard membership: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			cleanup, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := c.clientset.CoordinationV1().Leases(c.namespace).Delete(cleanup, c.memberLease(), metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				log.Printf("Warning: failed to delete scanner shard membership: %v", err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// renew creates or renews this replica's membership Lease
+func (c *shardCoordinator) renew(ctx context.Context) error {
+	leases := c.clientset.CoordinationV1().Leases(c.namespace)
+	seconds := int32(c.leaseDuration.Seconds())
+	now := metav1.NewMicroTime(time.Now())
+
+	lease, err := leases.Get(ctx, c.memberLease(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      c.memberLease(),
+				Namespace: c.namespace,
+				Labels:    map[string]string{shardLabel: c.group, "app.kubernetes.io/managed-by": "k8s-web-service"},
+			},
+			Spec: coordinationv1.LeaseSpec{HolderIdentity: &c.identity, LeaseDurationSeconds: &seconds, AcquireTime: &now, RenewTime: &now},
+		}
+		if _, err := leases.Create(ctx, lease, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create lease %s/%s: %w", c.namespace, c.memberLease(), err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get lease %s/%s: %w", c.namespace, c.memberLease(), err)
+	}
+	lease.Spec.HolderIdentity = &c.identity
+	lease.Spec.LeaseDurationSeconds = &seconds
+	lease.Spec.RenewTime = &now
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update lease %s/%s: %w", c.namespace, c.memberLease(), err)
+	}
+	return nil
+}
+
+// members returns the identities of the live replicas in order, always
+// including this one
+func (c *shardCoordinator) members(ctx context.Context) ([]string, error) {
+	leases, err := c.clientset.CoordinationV1().Leases(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: shardLabel + "=" + c.group})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scanner shard leases: %w", err)
+	}
+
+	live := map[string]bool{c.identity: true}
+	now := time.Now()
+	for _, lease := range leases.Items {
+		spec := lease.Spec
+		if spec.HolderIdentity == nil || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+			continue
+		}
+		if spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second).After(now) {
+			live[*spec.HolderIdentity] = true
+		}
+	}
+	members := make([]string, 0, len(live))
+	for identity := range live {
+		members = append(members, identity)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// shardOf returns the position of the member that scans a namespace
+func shardOf(namespace string, members int) int {
+	h := fnv.New32a()
+	h.Write([]byte(namespace))
+	return int(h.Sum32() % uint32(members))
+}
+
+// assigned returns the namespaces this replica scans among the members
+func (c *shardCoordinator) assigned(namespaces, members []string) []string {
+	position := sort.SearchStrings(members, c.identity)
+	var mine []string
+	for _, namespace := range namespaces {
+		if shardOf(namespace, len(members)) == position {
+			mine = append(mine, namespace)
+		}
+	}
+	return mine
+}
+
+// shards returns the shard results saved by the replicas
+func (c *shardCoordinator) shards(ctx context.Context) (map[string]*Result, error) {
+	configMaps, err := c.clientset.CoreV1().ConfigMaps(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: shardLabel + "=" + c.group})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scanner shard results: %w", err)
+	}
+	shards := make(map[string]*Result, len(configMaps.Items))
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		result, _, err := decodeState(configMap)
+		if err != nil {
+			log.Printf("Warning: ignoring scanner shard %s: %v", configMap.Name, err)
+			continue
+		}
+		if result != nil {
+			shards[configMap.Name] = result
+		}
+	}
+	return shards, nil
+}
+
+// runShard scans this replica's namespaces on every interval until ctx is
+// cancelled and saves them for the leader to merge
+func (s *Scanner) runShard(ctx context.Context, shards *shardCoordinator) {
+	for {
+		cfg := s.store.Get()
+		interval, _ := cfg.ScanInterval()
+		if end, ok := s.jobs.Begin("background_scan"); ok {
+			result, err := s.scanShard(ctx, shards)
+			if err != nil {
+				log.Printf("Error: shard scan failed: %v", err)
+			}
+			s.recordErrors(result)
+			end()
+		} else {
+			log.Printf("Skipping scheduled shard scan: server is draining")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// scanShard scans the namespaces assigned to this replica and saves them.
+// The result is nil when the scan failed as a whole.
+func (s *Scanner) scanShard(ctx context.Context, shards *shardCoordinator) (*Result, error) {
+	cfg := s.store.Get()
+	members, err := shards.members(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := s.targetNamespaces(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := shards.assigned(targets, members)
+	result, err := s.scanNamespaces(ctx, cfg, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Shard scan completed: %d of %d namespaces across %d replicas", len(namespaces), len(targets), len(members))
+	return result, shards.shard.save(ctx, result, nil)
+}
+
+// mergeShards combines the replicas' shards into one result and notifies
+// about its new alerts. The leader waits until every live replica has saved
+// a shard since the previous round, up to half the interval, and scans the
+// namespaces no recent shard covers itself.
+func (s *Scanner) mergeShards(ctx context.Context, shards *shardCoordinator) (*Result, error) {
+	cfg := s.store.Get()
+	interval, _ := cfg.ScanInterval()
+	started := time.Now()
+	since := started.Add(-interval)
+	wait := interval / 2
+	if wait > maxShardWait {
+		wait = maxShardWait
+	}
+
+	var fresh map[string]*Result
+	for {
+		members, err := shards.members(ctx)
+		if err != nil {
+			return nil, err
+		}
+		all, err := shards.shards(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fresh = make(map[string]*Result)
+		for name, result := range all {
+			if result.CompletedAt().After(since) {
+				fresh[name] = result
+			}
+		}
+		complete := true
+		for _, member := range members {
+			if _, ok := fresh[shards.group+"-shard-"+member]; !ok {
+				complete = false
+			}
+		}
+		if complete || time.Since(started) >= wait {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(shardPollInterval):
+		}
+	}
+
+	// The most recent report of each scanned namespace wins
+	latest := make(map[string]*k8s.NamespaceExpiryReport)
+	latestAt := make(map[string]time.Time)
+	for _, result := range fresh {
+		for _, report := range result.Reports {
+			if at := result.CompletedAt(); at.After(latestAt[report.Namespace]) {
+				latest[report.Namespace] = report
+				latestAt[report.Namespace] = at
+			}
+		}
+	}
+	targets, err := s.targetNamespaces(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, namespace := range targets {
+		if latest[namespace] == nil {
+			missing = append(missing, namespace)
+		}
+	}
+
+	merged, err := s.scanNamespaces(ctx, cfg, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		log.Printf("Scanned %d namespaces no replica's shard covered: %v", len(missing), missing)
+	}
+	for _, report := range merged.Reports {
+		latest[report.Namespace] = report
+	}
+	merged.StartedAt = started
+	merged.Reports = merged.Reports[:0]
+	merged.Alerts = merged.Alerts[:0]
+	for _, namespace := range targets {
+		if report := latest[namespace]; report != nil {
+			merged.Reports = append(merged.Reports, report)
+			merged.Alerts = append(merged.Alerts, AlertsFromReport(report)...)
+		}
+	}
+	merged.DurationSeconds = time.Since(started).Seconds()
+	log.Printf("Merged %d scanner shards", len(fresh))
+	return merged, s.deliver(ctx, merged)
+}
@@ -0,0 +1,143 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"k8s-web-service/internal/redact"
+)
+
+// resultStoreKey is the ConfigMap key of the gzipped scanner state
+const resultStoreKey = "state.json.gz"
+
+// storedState is what the leader shares with the other replicas
+type storedState struct {
+	Result *Result `json:"result"`
+	// Notified are the AlertKeys of the delivered alerts
+	Notified []string `json:"notified"`
+}
+
+// resultStore keeps the leader's last scan result and delivered alerts in a
+// ConfigMap, so that followers serve the same results and a new leader does
+// not deliver alerts again. The state is gzipped to stay well below the
+// 1 MiB ConfigMap limit.
+type resultStore struct {
+	clientset kubernetes.Interface
+	namespace string
+	name      string
+	labels    map[string]string // added to the ConfigMap when it is created
+	// policy redacts the saved result, for a store no replica serves from
+	policy redact.Policy
+}
+
+// save writes a scan result and the delivered alerts, creating the
+// ConfigMap on first use
+func (r *resultStore) save(ctx context.Context, result *Result, notified map[string]bool) error {
+	if r.policy.Enabled() && result != nil {
+		data, err := r.policy.Marshal(result, "")
+		if err != nil {
+			return fmt.Errorf("failed to redact scanner state: %w", err)
+		}
+		result = &Result{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to redact scanner state: %w", err)
+		}
+	}
+	state := storedState{Result: result, Notified: make([]string, 0, len(notified))}
+	for key := range notified {
+		state.Notified = append(state.Notified, key)
+	}
+	sort.Strings(state.Notified)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(state); err != nil {
+		return fmt.Errorf("failed to encode scanner state: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress scanner state: %w", err)
+	}
+
+	configMaps := r.clientset.CoreV1().ConfigMaps(r.namespace)
+	configMap, err := configMaps.Get(ctx, r.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      r.name,
+				Namespace: r.namespace,
+				Labels:    r.configMapLabels(),
+			},
+			BinaryData: map[string][]byte{resultStoreKey: buf.Bytes()},
+		}
+		if _, err := configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create configmap %s/%s: %w", r.namespace, r.name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get configmap %s/%s: %w", r.namespace, r.name, err)
+	}
+	configMap.BinaryData = map[string][]byte{resultStoreKey: buf.Bytes()}
+	if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update configmap %s/%s: %w", r.namespace, r.name, err)
+	}
+	return nil
+}
+
+// load reads the last result and delivered alerts saved by a leader. The
+// result is nil if no leader has saved one yet.
+func (r *resultStore) load(ctx context.Context) (*Result, map[string]bool, error) {
+	configMap, err := r.clientset.CoreV1().ConfigMaps(r.namespace).Get(ctx, r.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, make(map[string]bool), nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get configmap %s/%s: %w", r.namespace, r.name, err)
+	}
+	return decodeState(configMap)
+}
+
+// configMapLabels returns the labels of the store's ConfigMap
+func (r *resultStore) configMapLabels() map[string]string {
+	labels := map[string]string{"app.kubernetes.io/managed-by": "k8s-web-service"}
+	for key, value := range r.labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// decodeState decodes the scanner state saved in a ConfigMap
+func decodeState(configMap *corev1.ConfigMap) (*Result, map[string]bool, error) {
+	notified := make(map[string]bool)
+	data, ok := configMap.BinaryData[resultStoreKey]
+	if !ok {
+		return nil, notified, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decompress scanner state: %w", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decompress scanner state: %w", err)
+	}
+	var state storedState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode scanner state: %w", err)
+	}
+	for _, key := range state.Notified {
+		notified[key] = true
+	}
+	return state.Result, notified, nil
+}
@@ -0,0 +1,38 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"k8s-web-service/internal/config"
+	"k8s-web-service/internal/notify"
+	"k8s-web-service/internal/redact"
+)
+
+func TestResultStoreSaveRedacts(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Security.PrivacyMode = true
+	clientset := fake.NewSimpleClientset()
+	store := &resultStore{clientset: clientset, namespace: "default", name: "scanner-results", policy: redact.NewPolicy(cfg)}
+
+	result := &Result{Alerts: []notify.Alert{{Namespace: "payments", Subject: "CN=payments.internal", SerialNumber: "4f:1a:9c"}}}
+	if err := store.save(context.Background(), result, map[string]bool{"payments/pod/secret/4f:1a:9c/false": true}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	saved, notified, err := store.load(context.Background())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := saved.Alerts[0].Subject; strings.Contains(got, "CN=") {
+		t.Errorf("saved alert subject = %q, want it redacted", got)
+	}
+	if !notified["payments/pod/secret/4f:1a:9c/false"] {
+		t.Errorf("delivered alerts were not saved: %v", notified)
+	}
+	if result.Alerts[0].Subject != "CN=payments.internal" {
+		t.Errorf("save changed the result it was given")
+	}
+}
@@ -0,0 +1,190 @@
+package telemetry
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Systems whose calls are timed
+const (
+	SystemAWS        = "aws"        // AWS API calls, by service and operation
+	SystemKubernetes = "kubernetes" // Kubernetes API requests, by resource and verb
+	SystemToken      = "token"      // EKS token generation, by method
+)
+
+// buckets are the upper bounds in seconds of the latency histograms
+var buckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Call identifies a timed operation
+type Call struct {
+	System    string `json:"system"`
+	Service   string `json:"service"`
+	Operation string `json:"operation"`
+}
+
+// CallStats are the latency and error count of a timed operation since the
+// process started. The quantiles are estimated from the histogram buckets.
+type CallStats struct {
+	Call
+	Count        uint64  `json:"count"`
+	Errors       uint64  `json:"errors"`
+	TotalSeconds float64 `json:"total_seconds"`
+	MeanSeconds  float64 `json:"mean_seconds"`
+	P50Seconds   float64 `json:"p50_seconds"`
+	P95Seconds   float64 `json:"p95_seconds"`
+	MaxSeconds   float64 `json:"max_seconds"`
+}
+
+// histogram accumulates the observations of one call
+type histogram struct {
+	counts []uint64 // per bucket, with the last counting what exceeds every bound
+	count  uint64
+	errors uint64
+	sum    float64
+	max    float64
+}
+
+var (
+	mu    sync.Mutex
+	calls = make(map[Call]*histogram)
+)
+
+// Observe records the duration of a call and whether it failed
+func Observe(call Call, duration time.Duration, err error) {
+	seconds := duration.Seconds()
+	mu.Lock()
+	defer mu.Unlock()
+
+	h, ok := calls[call]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(buckets)+1)}
+		calls[call] = h
+	}
+	i := sort.SearchFloat64s(buckets, seconds)
+	h.counts[i]++
+	h.count++
+	h.sum += seconds
+	if seconds > h.max {
+		h.max = seconds
+	}
+	if err != nil {
+		h.errors++
+	}
+}
+
+// Time returns a function that records the duration of a call since Time
+// was called, for use with defer
+func Time(call Call) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		Observe(call, time.Since(start), err)
+	}
+}
+
+// Snapshot returns the statistics of every call observed so far, ordered by
+// system, service, and operation
+func Snapshot() []CallStats {
+	mu.Lock()
+	defer mu.Unlock()
+
+	stats := make([]CallStats, 0, len(calls))
+	for call, h := range calls {
+		s := CallStats{Call: call, Count: h.count, Errors: h.errors, TotalSeconds: h.sum, MaxSeconds: h.max}
+		if h.count > 0 {
+			s.MeanSeconds = h.sum / float64(h.count)
+			s.P50Seconds = h.quantile(0.5)
+			s.P95Seconds = h.quantile(0.95)
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Call.less(stats[j].Call) })
+	return stats
+}
+
+// quantile estimates a quantile by interpolating within its bucket. The
+// overflow bucket is bounded by the largest observation.
+func (h *histogram) quantile(q float64) float64 {
+	rank := q * float64(h.count)
+	var seen uint64
+	for i, n := range h.counts {
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+		lower, upper := 0.0, h.max
+		if i > 0 {
+			lower = buckets[i-1]
+		}
+		if i < len(buckets) && buckets[i] < upper {
+			upper = buckets[i]
+		}
+		return lower + (upper-lower)*(rank-float64(seen))/float64(n)
+	}
+	return h.max
+}
+
+// less orders calls by system, service, and operation
+func (c Call) less(other Call) bool {
+	if c.System != other.System {
+		return c.System < other.System
+	}
+	if c.Service != other.Service {
+		return c.Service < other.Service
+	}
+	return c.Operation < other.Operation
+}
+
+// durationDesc and errorsDesc are the metrics of the timed calls
+var (
+	durationDesc = prometheus.NewDesc("k8s_web_service_call_duration_seconds",
+		"Latency of AWS calls, Kubernetes API requests, and EKS token generation", callLabels, nil)
+	errorsDesc = prometheus.NewDesc("k8s_web_service_call_errors_total",
+		"Failed AWS calls, Kubernetes API requests, and EKS token generations", callLabels, nil)
+)
+
+// callLabels label the metrics of a timed call
+var callLabels = []string{"system", "service", "operation"}
+
+// collector collects the call latency histograms and error counts
+type collector struct{}
+
+// Collector returns a collector of the call latency histograms and error
+// counts
+func Collector() prometheus.Collector {
+	return collector{}
+}
+
+// Describe sends the descriptors of the call metrics
+func (collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- durationDesc
+	ch <- errorsDesc
+}
+
+// Collect sends the call latency histograms and error counts
+func (collector) Collect(ch chan<- prometheus.Metric) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for call, h := range calls {
+		labels := []string{call.System, call.Service, call.Operation}
+		cumulative := make(map[float64]uint64, len(buckets))
+		var count uint64
+		for i, bound := range buckets {
+			count += h.counts[i]
+			cumulative[bound] = count
+		}
+		if histogram, err := prometheus.NewConstHistogram(durationDesc, h.count, h.sum, cumulative, labels...); err == nil {
+			ch <- histogram
+		} else {
+			ch <- prometheus.NewInvalidMetric(durationDesc, err)
+		}
+		if errors, err := prometheus.NewConstMetric(errorsDesc, prometheus.CounterValue, float64(h.errors), labels...); err == nil {
+			ch <- errors
+		} else {
+			ch <- prometheus.NewInvalidMetric(errorsDesc, err)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+// Package api defines the response bodies of the web service, shared by the
+// handlers that write them and by clients that decode them. Responses that
+// carry results of the internal packages, such as the AWS, EKS, node, and
+// Helm inventories, are typed in internal/handlers instead. Error responses
+// are RFC 7807 problem details, whose extension members vary by problem
+// type, and are not defined here.
+package api
+
+// Status of successful responses
+const StatusSuccess = "success"
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"go/build"
+	"strings"
+	"testing"
+)
+
+// TestNoInternalImports keeps pkg/api importable by clients outside the
+// module, which cannot import the internal packages
+func TestNoInternalImports(t *testing.T) {
+	pkg, err := build.ImportDir(".", 0)
+	if err != nil {
+		t.Fatalf("ImportDir: %v", err)
+	}
+	for _, path := range pkg.Imports {
+		if strings.HasPrefix(path, "k8s-web-service/") && path != "k8s-web-service/pkg/utils" {
+			t.Errorf("pkg/api imports %s; copy the types it needs instead", path)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import "k8s-web-service/pkg/utils"
+
+// CertificateSource represents where a certificate comes from
+type CertificateSource struct {
+	Type         string                   `json:"type"`          // "secret", "configmap", "cluster-ca"
+	Name         string                   `json:"name"`          // resource name
+	Namespace    string                   `json:"namespace"`     // resource namespace
+	Key          string                   `json:"key,omitempty"` // key within the resource
+	Certificates []*utils.CertificateInfo `json:"certificates"`
+	// BundleSummary is set when the source holds more certificates than
+	// the bundle cap and Certificates lists only the expired and expiring
+	// ones
+	BundleSummary *utils.BundleSummary `json:"bundle_summary,omitempty"`
+	// ParseErrors locate the blocks of the source's keys that did not yield
+	// a certificate
+	ParseErrors []*utils.PEMError `json:"parse_errors,omitempty"`
+	// PrivateKey describes tls.key of a secret that also holds tls.crt
+	PrivateKey *utils.PrivateKeyInfo `json:"private_key,omitempty"`
+	// Verification is the chain of the source's leaf certificate
+	Verification *utils.ChainVerification `json:"verification,omitempty"`
+	Error        string                   `json:"error,omitempty"`
+}
+
+// InClusterCertificate is a certificate served from a TLS secret referenced
+// by an Ingress
+type InClusterCertificate struct {
+	Namespace string                   `json:"namespace"`
+	Secret    string                   `json:"secret"`
+	Ingress   string                   `json:"ingress"`
+	Hosts     []string                 `json:"hosts,omitempty"`
+	Cert      *utils.CertificateInfo   `json:"certificate,omitempty"`
+	Chain     []*utils.CertificateInfo `json:"chain,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// WebhookCABundle is the caBundle of an admission webhook calling a system
+// component
+type WebhookCABundle struct {
+	Kind          string                   `json:"kind"` // mutating or validating
+	Configuration string                   `json:"configuration"`
+	Webhook       string                   `json:"webhook"`
+	Service       string                   `json:"service"`
+	URL           string                   `json:"url,omitempty"` // set for webhooks called by URL
+	FailurePolicy string                   `json:"failure_policy,omitempty"`
+	Certificates  []*utils.CertificateInfo `json:"ca_bundle,omitempty"`
+	Error         string                   `json:"error,omitempty"`
+}
+
+// Finding is a problem or observation reported by an analyzer
+type Finding struct {
+	Analyzer string        `json:"analyzer"`
+	Severity string        `json:"severity"`
+	Message  string        `json:"message"`
+	Source   FindingSource `json:"source"`
+	Subject  string        `json:"subject"`
+}
+
+// FindingSource describes where the certificate of a finding was discovered
+type FindingSource struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod,omitempty"`
+	// Name identifies the source within the pod, e.g. "secret/tls-cert"
+	Name string `json:"name"`
+	// Type is the kind of resource holding the certificate: secret,
+	// configmap, or cluster-ca
+	Type         string `json:"type"`
+	ResourceName string `json:"resource_name"`
+	Key          string `json:"key,omitempty"`
+}
@@ -0,0 +1,100 @@
+package api
+
+import "time"
+
+// ClusterCAResponse is the response of /cluster-ca
+type ClusterCAResponse struct {
+	Status        string        `json:"status"`
+	Message       string        `json:"message"`
+	Description   string        `json:"description"`
+	CACertificate CACertificate `json:"ca_certificate"`
+	Source        string        `json:"source"`
+	Usage         string        `json:"usage"`
+	ClusterInfo   ClusterInfo   `json:"cluster_info"`
+	Notes         []string      `json:"notes"`
+}
+
+// CACertificate is the cluster CA bundle. PEMContent is empty and Redacted
+// set when security.redact_pem or security.privacy_mode is set.
+type CACertificate struct {
+	PEMContent   string   `json:"pem_content,omitempty"`
+	Length       int      `json:"length"`
+	Fingerprints []string `json:"fingerprints_sha256"`
+	Redacted     bool     `json:"redacted,omitempty"`
+}
+
+// ClusterInfo identifies the cluster of the kubeconfig
+type ClusterInfo struct {
+	Region          string `json:"region"`
+	ClusterEndpoint string `json:"cluster_endpoint"`
+	ClusterName     string `json:"cluster_name"`
+}
+
+// ClusterCAExpiryResponse is the response of /cluster-ca-expiry
+type ClusterCAExpiryResponse struct {
+	Status          string           `json:"status"`
+	Message         string           `json:"message"`
+	WarningDays     int              `json:"warning_days"`
+	AnalysisDate    string           `json:"analysis_date"`
+	AnalysisDateISO string           `json:"analysis_date_iso"`
+	Timezone        string           `json:"timezone"`
+	CertificateInfo ClusterCAReport  `json:"certificate_info"`
+	Summary         ClusterCASummary `json:"summary"`
+	Notes           []string         `json:"notes"`
+}
+
+// ClusterCAReport is the parsed cluster CA bundle with its warnings
+type ClusterCAReport struct {
+	Source       *CertificateSource  `json:"source"`
+	Warnings     []string            `json:"warnings"`
+	TotalCerts   int                 `json:"total_certs"`
+	EnhancedInfo *CertificateDetails `json:"enhanced_info"`
+}
+
+// CertificateDetails describes the first certificate of the cluster CA
+// bundle with formatted dates
+type CertificateDetails struct {
+	Subject        string         `json:"subject"`
+	Issuer         string         `json:"issuer"`
+	SerialNumber   string         `json:"serial_number"`
+	Fingerprint    string         `json:"fingerprint_sha256"`
+	IsCA           bool           `json:"is_ca"`
+	IsExpired      bool           `json:"is_expired"`
+	ValidityPeriod ValidityPeriod `json:"validity_period"`
+	ExpiryInfo     ExpiryInfo     `json:"expiry_info"`
+	DNSNames       []string       `json:"dns_names"`
+	IPAddresses    []string       `json:"ip_addresses"`
+	KeyUsage       []string       `json:"key_usage"`
+}
+
+// ValidityPeriod is the validity of a certificate in the response time zone
+type ValidityPeriod struct {
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	NotBeforeFormatted string    `json:"not_before_formatted"`
+	NotAfterFormatted  string    `json:"not_after_formatted"`
+	NotBeforeISO       string    `json:"not_before_iso"`
+	NotAfterISO        string    `json:"not_after_iso"`
+	ValidForDays       int       `json:"valid_for_days"`
+}
+
+// ExpiryInfo is the time until a certificate expires in several units;
+// negative once it has expired
+type ExpiryInfo struct {
+	DaysUntilExpiry   int    `json:"days_until_expiry"`
+	WeeksUntilExpiry  int    `json:"weeks_until_expiry"`
+	MonthsUntilExpiry int    `json:"months_until_expiry"`
+	YearsUntilExpiry  int    `json:"years_until_expiry"`
+	ExpiresOn         string `json:"expires_on"`
+	ExpiresOnWeekday  string `json:"expires_on_weekday"`
+	ExpiresOnISO      string `json:"expires_on_iso"`
+	TimeRemaining     string `json:"time_remaining"`
+}
+
+// ClusterCASummary summarizes the expiry of the cluster CA bundle
+type ClusterCASummary struct {
+	CertificatesAnalyzed int    `json:"certificates_analyzed"`
+	WarningsFound        int    `json:"warnings_found"`
+	ExpiresWithinDays    int    `json:"expires_within_days"`
+	StatusSummary        string `json:"status_summary"`
+}
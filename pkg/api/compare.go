@@ -0,0 +1,30 @@
+package api
+
+import "k8s-web-service/pkg/utils"
+
+// CompareResponse is the response of POST /compare
+type CompareResponse struct {
+	Status   string                 `json:"status"`
+	Uploaded *utils.CertificateInfo `json:"uploaded"`
+	// AllMatch is true when every target holds the uploaded certificate
+	AllMatch    bool                    `json:"all_match"`
+	Comparisons []CertificateComparison `json:"comparisons"`
+	Notes       []string                `json:"notes"`
+}
+
+// CertificateComparison compares an expected certificate, such as the one a
+// rotation should have deployed, with the certificate found at a target
+type CertificateComparison struct {
+	// Target is secret/{namespace}/{name} or service/{namespace}/{name}:{port}
+	Target   string                 `json:"target"`
+	Matches  bool                   `json:"matches"`
+	Deployed *utils.CertificateInfo `json:"deployed,omitempty"`
+	// SANsMissing are the names of the expected certificate the deployed one
+	// lacks, and SANsExtra the names only the deployed one has
+	SANsMissing []string `json:"sans_missing,omitempty"`
+	SANsExtra   []string `json:"sans_extra,omitempty"`
+	// ExpiryDeltaDays is how many days later the expected certificate
+	// expires than the deployed one; negative if it expires earlier
+	ExpiryDeltaDays int    `json:"expiry_delta_days"`
+	Error           string `json:"error,omitempty"`
+}
@@ -0,0 +1,190 @@
+package api
+
+// EffectiveConfig is the configuration in use, after merging the file,
+// environment, and flags, with credentials masked
+type EffectiveConfig struct {
+	// ReadOnly guarantees that no create, update, patch, or delete requests
+	// are sent to the cluster or AWS
+	ReadOnly   bool                  `json:"read_only"`
+	AWS        AWSConfig             `json:"aws"`
+	Clusters   map[string]ClusterAWS `json:"clusters"`
+	Kubernetes KubernetesConfig      `json:"kubernetes"`
+	Server     ServerConfig          `json:"server"`
+	Scanner    ScannerConfig         `json:"scanner"`
+	Notifiers  NotifiersConfig       `json:"notifiers"`
+	// CustomResources lists custom resource fields holding PEM certificates
+	CustomResources []CustomResource `json:"custom_resources"`
+	// Policies are certificate rules per namespace
+	Policies  []Policy        `json:"policies"`
+	Images    ImagesConfig    `json:"images"`
+	Bundles   BundlesConfig   `json:"bundles"`
+	Agent     AgentConfig     `json:"agent"`
+	Admin     AdminConfig     `json:"admin"`
+	Admission AdmissionConfig `json:"admission"`
+	Logging   LoggingConfig   `json:"logging"`
+	Security  SecurityConfig  `json:"security"`
+	// Endpoints enables or disables endpoint groups by name
+	Endpoints map[string]bool `json:"endpoints"`
+	// Limits sets the request timeout and result size per endpoint group
+	Limits map[string]EndpointLimit `json:"limits"`
+}
+
+// AWSConfig is the aws section of the configuration
+type AWSConfig struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	Region          string `json:"region"`
+	AssumeRole      struct {
+		SessionName       string            `json:"session_name"`
+		SourceIdentity    string            `json:"source_identity"`
+		SessionTags       map[string]string `json:"session_tags"`
+		TransitiveTagKeys []string          `json:"transitive_tag_keys"`
+	} `json:"assume_role"`
+	SecretsManager struct {
+		Prefixes []string `json:"prefixes"`
+	} `json:"secrets_manager"`
+	CloudFront struct {
+		DistributionIDs []string `json:"distribution_ids"`
+	} `json:"cloudfront"`
+}
+
+// ClusterAWS overrides the AWS settings of one cluster
+type ClusterAWS struct {
+	KubeconfigPath  string `json:"kubeconfig_path"`
+	Context         string `json:"context"`
+	Region          string `json:"region"`
+	Partition       string `json:"partition"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	RoleARN         string `json:"role_arn"`
+}
+
+// KubernetesConfig is the kubernetes section of the configuration
+type KubernetesConfig struct {
+	ClusterName      string `json:"cluster_name"`
+	ClusterEndpoint  string `json:"cluster_endpoint"`
+	DefaultNamespace string `json:"default_namespace"`
+	KubeconfigPath   string `json:"kubeconfig_path"`
+	FixturesDir      string `json:"fixtures_dir"`
+	Auth             string `json:"auth"`
+}
+
+// ServerConfig is the server section of the configuration
+type ServerConfig struct {
+	Port            string         `json:"port"`
+	Host            string         `json:"host"`
+	Timezone        string         `json:"timezone"`
+	Durations       DurationFormat `json:"durations"`
+	ReadTimeout     string         `json:"read_timeout"`
+	WriteTimeout    string         `json:"write_timeout"`
+	ShutdownTimeout string         `json:"shutdown_timeout"`
+	TLS             TLSConfig      `json:"tls"`
+}
+
+// TLSConfig is the TLS serving certificate and client verification of the
+// server
+type TLSConfig struct {
+	CertFile     string `json:"cert_file"`
+	KeyFile      string `json:"key_file"`
+	ClientCAFile string `json:"client_ca_file"`
+	ClientAuth   string `json:"client_auth"`
+}
+
+// DurationFormat configures human-readable durations
+type DurationFormat struct {
+	Units     []string `json:"units"`
+	Precision int      `json:"precision"`
+	Compact   bool     `json:"compact"`
+}
+
+// ScannerConfig is the scanner section of the configuration
+type ScannerConfig struct {
+	Enabled        bool     `json:"enabled"`
+	Interval       string   `json:"interval"`
+	Namespaces     []string `json:"namespaces"`
+	WarningDays    int      `json:"warning_days"`
+	Concurrency    int      `json:"concurrency"`
+	RememberAlerts bool     `json:"remember_alerts"`
+	LeaderElection struct {
+		Enabled         bool   `json:"enabled"`
+		Namespace       string `json:"namespace"`
+		LeaseName       string `json:"lease_name"`
+		LeaseDuration   string `json:"lease_duration"`
+		ShardNamespaces bool   `json:"shard_namespaces"`
+	} `json:"leader_election"`
+}
+
+// NotifiersConfig is the notifiers section of the configuration
+type NotifiersConfig struct {
+	WebhookURL      string `json:"webhook_url"`
+	SlackWebhookURL string `json:"slack_webhook_url"`
+}
+
+// CustomResource locates the certificates stored in a custom resource kind
+type CustomResource struct {
+	GVK      string `json:"gvk"`
+	JSONPath string `json:"jsonpath"`
+}
+
+// Policy is a set of certificate rules for the namespaces it names
+type Policy struct {
+	Name             string   `json:"name"`
+	Namespaces       []string `json:"namespaces"`
+	MinDaysRemaining int      `json:"min_days_remaining"`
+	AllowedIssuers   []string `json:"allowed_issuers"`
+	MaxValidityDays  int      `json:"max_validity_days"`
+}
+
+// ImagesConfig is the images section of the configuration
+type ImagesConfig struct {
+	CABundleAnalysis bool `json:"ca_bundle_analysis"`
+	MaxImages        int  `json:"max_images"`
+}
+
+// BundlesConfig is the bundles section of the configuration
+type BundlesConfig struct {
+	MaxCertificates int `json:"max_certificates"`
+}
+
+// AgentConfig is the agent section of the configuration
+type AgentConfig struct {
+	Token     string   `json:"token"`
+	ServerURL string   `json:"server_url"`
+	HostRoot  string   `json:"host_root"`
+	Paths     []string `json:"paths"`
+	Interval  string   `json:"interval"`
+}
+
+// AdminConfig is the admin section of the configuration
+type AdminConfig struct {
+	Token string `json:"token"`
+}
+
+// AdmissionConfig is the admission section of the configuration
+type AdmissionConfig struct {
+	Port       string `json:"port"`
+	CertFile   string `json:"cert_file"`
+	KeyFile    string `json:"key_file"`
+	Mode       string `json:"mode"`
+	WindowDays int    `json:"window_days"`
+}
+
+// LoggingConfig is the logging section of the configuration
+type LoggingConfig struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+// SecurityConfig is the security section of the configuration
+type SecurityConfig struct {
+	RedactPEM           bool     `json:"redact_pem"`
+	RedactSubjects      bool     `json:"redact_subjects"`
+	PrivacyMode         bool     `json:"privacy_mode"`
+	SensitiveNamespaces []string `json:"sensitive_namespaces"`
+}
+
+// EndpointLimit bounds the cost of requests to an endpoint group
+type EndpointLimit struct {
+	Timeout    string `json:"timeout"`
+	MaxResults int    `json:"max_results"`
+}
@@ -0,0 +1,227 @@
+package api
+
+import "time"
+
+// DebugResponse is the response of /debug
+type DebugResponse struct {
+	Status            string                `json:"status"`
+	AWSConfig         AWSConfigStatus       `json:"aws_config"`
+	EffectiveConfig   EffectiveConfig       `json:"effective_config"`
+	Kubeconfig        KubeconfigSource      `json:"kubeconfig"`
+	KubeconfigDetails *KubeConfigEKSDetails `json:"kubeconfig_details,omitempty"`
+	// AWSIdentity holds the error when the Kubernetes client, and with it
+	// the EKS token, could not be created
+	AWSIdentity    *ErrorStatus        `json:"aws_identity,omitempty"`
+	EKSToken       *TokenStatus        `json:"eks_token,omitempty"`
+	AWSCredentials CredentialInfo      `json:"aws_credentials"`
+	IMDS           IMDSStatus          `json:"imds"`
+	Authenticator  AuthenticatorStatus `json:"aws_iam_authenticator"`
+	Build          BuildInfo           `json:"build"`
+	Caches         CacheStatus         `json:"caches"`
+	// Calls are the latency and errors of the AWS calls, Kubernetes API
+	// requests, and EKS token generations since the process started
+	Calls []CallStats `json:"calls"`
+}
+
+// TokenStatus is the EKS token of a client created for the request; tokens
+// are generated per client, so it shows whether generation works and which
+// method succeeded
+type TokenStatus struct {
+	*TokenInfo
+	ExpiresInSeconds int    `json:"expires_in_seconds"`
+	Error            string `json:"error,omitempty"`
+}
+
+// BuildInfo is the Go version and the versions of the main dependencies the
+// binary was built with
+type BuildInfo struct {
+	GoVersion string            `json:"go_version"`
+	Version   string            `json:"version,omitempty"`
+	Modules   map[string]string `json:"modules"`
+}
+
+// CacheStatus is the age of the data the service keeps between requests.
+// Kubernetes objects are not cached: there are no informers, and every
+// request reads the API server.
+type CacheStatus struct {
+	Informers string `json:"informers"`
+	// Scanner is the result of the last background scan, served by
+	// /health-score, /policy-violations, and /graphql without a namespace
+	Scanner *CacheAge `json:"scanner,omitempty"`
+	// NodeAgents is the oldest node agent report
+	NodeAgents *CacheAge `json:"node_agents,omitempty"`
+	// ImageDigests counts the image CA bundles cached by digest, which
+	// never go stale
+	ImageDigests int `json:"image_digests"`
+}
+
+// CacheAge is when cached data was last refreshed
+type CacheAge struct {
+	UpdatedAt  time.Time `json:"updated_at"`
+	AgeSeconds int       `json:"age_seconds"`
+	Entries    int       `json:"entries,omitempty"`
+}
+
+// ErrorStatus is a failed check within a successful response
+type ErrorStatus struct {
+	Error string `json:"error"`
+}
+
+// AWSConfigStatus reports whether AWS credentials are configured, without
+// their values
+type AWSConfigStatus struct {
+	HasAccessKey     bool   `json:"has_access_key"`
+	HasSecretKey     bool   `json:"has_secret_key"`
+	Region           string `json:"region"`
+	ValidationResult string `json:"validation_result"`
+}
+
+// KubeconfigSource is the kubeconfig file in use and how it was selected
+type KubeconfigSource struct {
+	Path   string `json:"path"`
+	Source string `json:"source"`
+}
+
+// KubeConfigEKSDetails contains EKS-specific details from kubeconfig
+type KubeConfigEKSDetails struct {
+	// Cluster is the name of the clusters entry the client was created
+	// for, if any
+	Cluster         string `json:"cluster,omitempty"`
+	Context         string `json:"context,omitempty"`
+	ClusterName     string `json:"cluster_name"`
+	ClusterEndpoint string `json:"cluster_endpoint"`
+	ClusterCA       string `json:"cluster_ca"`
+	Region          string `json:"region"`
+	Partition       string `json:"partition,omitempty"`
+	RoleARN         string `json:"role_arn,omitempty"`
+}
+
+// TokenInfo describes an EKS token without revealing it
+type TokenInfo struct {
+	Source    string    `json:"source"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CredentialInfo describes the AWS credentials of the default chain without
+// revealing them
+type CredentialInfo struct {
+	// Provider is the credential provider that supplied the credentials,
+	// such as EnvConfigCredentials, SharedConfigCredentials,
+	// WebIdentityCredentials (IRSA), or EC2RoleProvider
+	Provider  string     `json:"provider,omitempty"`
+	CanExpire bool       `json:"can_expire"`
+	Expires   *time.Time `json:"expires,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// IMDSStatus reports whether the EC2 instance metadata service answers
+type IMDSStatus struct {
+	Available bool   `json:"available"`
+	Region    string `json:"region,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// AuthenticatorStatus reports whether aws-iam-authenticator is installed
+type AuthenticatorStatus struct {
+	Path    string `json:"path,omitempty"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// CallStats are the latency and error count of a timed operation since the
+// process started. The quantiles are estimated from the histogram buckets.
+type CallStats struct {
+	Call
+	Count        uint64  `json:"count"`
+	Errors       uint64  `json:"errors"`
+	TotalSeconds float64 `json:"total_seconds"`
+	MeanSeconds  float64 `json:"mean_seconds"`
+	P50Seconds   float64 `json:"p50_seconds"`
+	P95Seconds   float64 `json:"p95_seconds"`
+	MaxSeconds   float64 `json:"max_seconds"`
+}
+
+// Call identifies a timed operation
+type Call struct {
+	System    string `json:"system"`
+	Service   string `json:"service"`
+	Operation string `json:"operation"`
+}
+
+// AuthTestResponse is the response of /test-k8s-auth
+type AuthTestResponse struct {
+	Status string               `json:"status"`
+	Tests  map[string]AuthCheck `json:"tests"`
+	// Identity is the user the cluster authenticated the service as
+	Identity           string              `json:"identity,omitempty"`
+	MissingPermissions []MissingPermission `json:"missing_permissions,omitempty"`
+	// RemediationManifest is a Role and RoleBinding per namespace granting
+	// the missing permissions
+	RemediationManifest string `json:"remediation_manifest,omitempty"`
+}
+
+// RBACResponse is the JSON response of /debug/rbac
+type RBACResponse struct {
+	Status             string      `json:"status"`
+	MissingPermissions int         `json:"missing_permissions"`
+	RBAC               *RBACReport `json:"rbac"`
+	Notes              []string    `json:"notes"`
+}
+
+// AuthCheck is the result of a single authentication or permission check
+type AuthCheck struct {
+	Status     string `json:"status"`
+	Namespace  string `json:"namespace,omitempty"`
+	Permission string `json:"permission,omitempty"`
+	// Optional checks do not fail the self-test
+	Optional bool   `json:"optional,omitempty"`
+	Count    *int   `json:"count,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// MissingPermission is a permission a capability check found missing in a
+// namespace
+type MissingPermission struct {
+	Namespace string `json:"namespace"`
+	RBACPermission
+	Optional bool `json:"optional,omitempty"`
+}
+
+// RBACReport is the effective access of the service's identity
+type RBACReport struct {
+	Namespaces []string              `json:"namespaces"`
+	Matrix     []RBACMatrixRow       `json:"matrix"`
+	Rules      map[string][]RBACRule `json:"rules"`
+	// Incomplete lists the namespaces whose rules review was incomplete,
+	// with the reason; their denied cells were confirmed with access reviews
+	Incomplete map[string]string `json:"incomplete,omitempty"`
+	Errors     []string          `json:"errors,omitempty"`
+}
+
+// RBACMatrixRow is the access of the service to one permission, cluster-wide
+// or per namespace
+type RBACMatrixRow struct {
+	RBACPermission
+	ClusterAccess string            `json:"cluster,omitempty"`
+	Namespaces    map[string]string `json:"namespaces,omitempty"`
+}
+
+// RBACPermission is a Kubernetes permission the service uses
+type RBACPermission struct {
+	Group    string `json:"group,omitempty"`
+	Resource string `json:"resource"`
+	Verb     string `json:"verb"`
+	// ClusterScoped marks cluster-scoped resources, and permissions needed
+	// across all namespaces
+	ClusterScoped bool   `json:"cluster_scoped"`
+	UsedBy        string `json:"used_by"`
+}
+
+// RBACRule is a rule granted to the service in a namespace
+type RBACRule struct {
+	Verbs         []string `json:"verbs"`
+	APIGroups     []string `json:"api_groups,omitempty"`
+	Resources     []string `json:"resources,omitempty"`
+	ResourceNames []string `json:"resource_names,omitempty"`
+}
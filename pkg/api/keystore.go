@@ -0,0 +1,18 @@
+package api
+
+import (
+	"k8s-web-service/pkg/utils"
+)
+
+// KeystoreAnalysisResponse is the response of POST /analyze/keystore
+type KeystoreAnalysisResponse struct {
+	Status            string                `json:"status"`
+	Filename          string                `json:"filename,omitempty"`
+	Format            string                `json:"format"`
+	IntegrityVerified bool                  `json:"integrity_verified"`
+	WarningDays       int                   `json:"warning_days"`
+	TotalCertificates int                   `json:"total_certificates"`
+	Entries           []utils.KeystoreEntry `json:"entries"`
+	Warnings          []string              `json:"warnings"`
+	Notes             []string              `json:"notes"`
+}
@@ -0,0 +1,94 @@
+package api
+
+import "time"
+
+// ConnectResponse is the response of /connect-k8s
+type ConnectResponse struct {
+	Status           string `json:"status"`
+	Message          string `json:"message"`
+	ClusterName      string `json:"cluster_name"`
+	ClusterEndpoint  string `json:"cluster_endpoint"`
+	Region           string `json:"region"`
+	DefaultNamespace string `json:"default_namespace"`
+}
+
+// PodSummary is a pod of a /list-pods response
+type PodSummary struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Status    string    `json:"status"`
+	Node      string    `json:"node"`
+	Created   time.Time `json:"created"`
+}
+
+// ListPodsResponse is the response of /list-pods
+type ListPodsResponse struct {
+	Status     string       `json:"status"`
+	Namespace  string       `json:"namespace"`
+	Count      int          `json:"count"`
+	Pods       []PodSummary `json:"pods"`
+	Truncated  bool         `json:"truncated,omitempty"`
+	MaxResults int          `json:"max_results,omitempty"`
+}
+
+// NamespacesListPodsResponse is the response of /list-pods when the
+// namespace parameter selects several namespaces
+type NamespacesListPodsResponse struct {
+	Status           string             `json:"status"`
+	Namespaces       []string           `json:"namespaces"`
+	Count            int                `json:"count"`
+	ByNamespace      map[string]int     `json:"by_namespace"`
+	Pods             []PodSummary       `json:"pods"`
+	FailedNamespaces []NamespaceFailure `json:"failed_namespaces"`
+	Truncated        bool               `json:"truncated,omitempty"`
+	MaxResults       int                `json:"max_results,omitempty"`
+}
+
+// NamespacesResponse is the response of /namespaces
+type NamespacesResponse struct {
+	Status     string          `json:"status"`
+	Count      int             `json:"count"`
+	Namespaces []NamespaceInfo `json:"namespaces"`
+	Scanner    ScannerStatus   `json:"scanner"`
+	Truncated  bool            `json:"truncated,omitempty"`
+	MaxResults int             `json:"max_results,omitempty"`
+}
+
+// ScannerStatus is the state of the background scanner of a
+// NamespacesResponse. Only Enabled is set when the request selects another
+// cluster than the one the scanner covers.
+type ScannerStatus struct {
+	Enabled           bool       `json:"enabled"`
+	Role              string     `json:"role,omitempty"`
+	LastScan          *time.Time `json:"last_scan,omitempty"`
+	NotificationError string     `json:"notification_error,omitempty"`
+	LastNotification  *time.Time `json:"last_notification,omitempty"`
+}
+
+// NamespaceInfo is a namespace of a NamespacesResponse
+type NamespaceInfo struct {
+	Name    string            `json:"name"`
+	Phase   string            `json:"phase"`
+	Labels  map[string]string `json:"labels"`
+	Created time.Time         `json:"created"`
+	Age     string            `json:"age"`
+	AgeDays int               `json:"age_days"`
+	Scan    NamespaceScan     `json:"scan"`
+	Links   NamespaceLinks    `json:"links"`
+}
+
+// NamespaceScan is the background scan status of a namespace: not_scheduled,
+// pending, failed, warnings, or ok. The totals are set once it was scanned.
+type NamespaceScan struct {
+	Scheduled         bool   `json:"scheduled"`
+	Status            string `json:"status"`
+	TotalCertificates *int   `json:"total_certificates,omitempty"`
+	TotalWarnings     *int   `json:"total_warnings,omitempty"`
+}
+
+// NamespaceLinks are the per-namespace endpoints of a namespace
+type NamespaceLinks struct {
+	CertificateExpiry string `json:"certificate_expiry"`
+	PodCertificates   string `json:"pod_certificates"`
+	Pods              string `json:"pods"`
+}
@@ -0,0 +1,261 @@
+package api
+
+import "time"
+
+// VolumeMount represents a volume mount in a pod
+type VolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mount_path"`
+	ReadOnly  bool   `json:"read_only"`
+	Container string `json:"container"`
+}
+
+// Volume represents a volume in a pod
+type Volume struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Source string `json:"source,omitempty"`
+}
+
+// ClusterCAInfo represents cluster CA certificate information
+type ClusterCAInfo struct {
+	Description string `json:"description"`
+	Length      int    `json:"length"`
+	Source      string `json:"source"`
+}
+
+// PodCertificatesResponse is the response of /pod-certificates
+type PodCertificatesResponse struct {
+	Status          string         `json:"status"`
+	Message         string         `json:"message"`
+	TargetNamespace string         `json:"target_namespace"`
+	ClusterCAInfo   ClusterCAInfo  `json:"cluster_ca_info"`
+	Pods            []PodCertInfo  `json:"pods"`
+	ByCompute       map[string]int `json:"by_compute,omitempty"`
+	ExpiryWarnings  []string       `json:"expiry_warnings,omitempty"`
+	Truncated       bool           `json:"truncated,omitempty"`
+	Notes           []string       `json:"notes"`
+}
+
+// PodCertInfo represents certificate information for a pod with expiry details
+type PodCertInfo struct {
+	Name               string                        `json:"name"`
+	Namespace          string                        `json:"namespace"`
+	Compute            *ComputeInfo                  `json:"compute,omitempty"`
+	Runtime            *PodRuntime                   `json:"runtime"`
+	Origin             *PodOrigin                    `json:"origin"`
+	VolumeMounts       []VolumeMount                 `json:"volume_mounts"`
+	Volumes            []Volume                      `json:"volumes"`
+	CertificateSources map[string]*CertificateSource `json:"certificate_sources,omitempty"`
+	ExpiryWarnings     []string                      `json:"expiry_warnings,omitempty"`
+}
+
+// PodCertificateDetailsResponse is the response of
+// /pod-certificates/{pod-name}
+type PodCertificateDetailsResponse struct {
+	Status             string                        `json:"status"`
+	Message            string                        `json:"message"`
+	PodName            string                        `json:"pod_name"`
+	Namespace          string                        `json:"namespace"`
+	WarningDays        int                           `json:"warning_days"`
+	Compute            *ComputeInfo                  `json:"compute,omitempty"`
+	Runtime            *PodRuntime                   `json:"runtime,omitempty"`
+	Origin             *PodOrigin                    `json:"origin,omitempty"`
+	CertificateSources map[string]*CertificateSource `json:"certificate_sources"`
+	ExpiryWarnings     []string                      `json:"expiry_warnings"`
+	Findings           []Finding                     `json:"findings"`
+	Summary            PodCertificateSummary         `json:"summary"`
+}
+
+// PodCertificateSummary counts the certificates of a pod
+type PodCertificateSummary struct {
+	TotalSources      int `json:"total_sources"`
+	TotalCertificates int `json:"total_certificates"`
+	WarningsCount     int `json:"warnings_count"`
+}
+
+// CertificateExpiryResponse is the response of /certificate-expiry
+type CertificateExpiryResponse struct {
+	Status          string                   `json:"status"`
+	Message         string                   `json:"message"`
+	Namespace       string                   `json:"namespace"`
+	WarningDays     int                      `json:"warning_days"`
+	Summary         CertificateExpirySummary `json:"summary"`
+	PodExpiryInfo   []PodExpiryInfo          `json:"pod_expiry_info"`
+	CustomResources []*CertificateSource     `json:"custom_resources"`
+	AllWarnings     []string                 `json:"all_warnings"`
+	Findings        []Finding                `json:"findings"`
+	Truncated       bool                     `json:"truncated,omitempty"`
+	MaxResults      int                      `json:"max_results,omitempty"`
+	// LastScanned is set when the report comes from the last background
+	// scan, to the time it completed
+	LastScanned *time.Time `json:"last_scanned,omitempty"`
+	Notes       []string   `json:"notes"`
+}
+
+// CertificateExpirySummary counts the pods and certificates of a namespace
+type CertificateExpirySummary struct {
+	TotalPodsAnalyzed    int `json:"total_pods_analyzed"`
+	PodsWithCertificates int `json:"pods_with_certificates"`
+	TotalCertificates    int `json:"total_certificates"`
+	TotalWarnings        int `json:"total_warnings"`
+}
+
+// NamespaceFailure is a namespace whose analysis failed in a request that
+// spans several namespaces
+type NamespaceFailure struct {
+	Namespace string `json:"namespace"`
+	Error     string `json:"error"`
+}
+
+// NamespacesPodCertificatesResponse is the response of /pod-certificates
+// when the namespace parameter selects several namespaces
+type NamespacesPodCertificatesResponse struct {
+	Status           string                     `json:"status"`
+	Message          string                     `json:"message"`
+	Namespaces       []string                   `json:"namespaces"`
+	ClusterCAInfo    ClusterCAInfo              `json:"cluster_ca_info"`
+	Results          []NamespacePodCertificates `json:"results"`
+	ByCompute        map[string]int             `json:"by_compute,omitempty"`
+	FailedNamespaces []NamespaceFailure         `json:"failed_namespaces"`
+	Truncated        bool                       `json:"truncated,omitempty"`
+	Notes            []string                   `json:"notes"`
+}
+
+// NamespacePodCertificates are the pods of one namespace in a
+// NamespacesPodCertificatesResponse
+type NamespacePodCertificates struct {
+	Namespace      string        `json:"namespace"`
+	Pods           []PodCertInfo `json:"pods"`
+	ExpiryWarnings []string      `json:"expiry_warnings,omitempty"`
+	Truncated      bool          `json:"truncated,omitempty"`
+}
+
+// NamespacesExpiryResponse is the response of /certificate-expiry when the
+// namespace parameter selects several namespaces, with the report of each
+type NamespacesExpiryResponse struct {
+	Status           string                   `json:"status"`
+	Message          string                   `json:"message"`
+	Namespaces       []string                 `json:"namespaces"`
+	WarningDays      int                      `json:"warning_days"`
+	Summary          NamespacesExpirySummary  `json:"summary"`
+	Reports          []*NamespaceExpiryReport `json:"reports"`
+	FailedNamespaces []NamespaceFailure       `json:"failed_namespaces"`
+	Truncated        bool                     `json:"truncated,omitempty"`
+	MaxResults       int                      `json:"max_results,omitempty"`
+	// ScannedNamespaces are the namespaces served from the last background
+	// scan, which completed at LastScanned
+	ScannedNamespaces []string   `json:"scanned_namespaces,omitempty"`
+	LastScanned       *time.Time `json:"last_scanned,omitempty"`
+	Notes             []string   `json:"notes"`
+}
+
+// NamespacesExpirySummary totals the reports of a NamespacesExpiryResponse
+type NamespacesExpirySummary struct {
+	NamespacesAnalyzed   int `json:"namespaces_analyzed"`
+	FailedNamespaces     int `json:"failed_namespaces"`
+	TotalPodsAnalyzed    int `json:"total_pods_analyzed"`
+	PodsWithCertificates int `json:"pods_with_certificates"`
+	TotalCertificates    int `json:"total_certificates"`
+	TotalWarnings        int `json:"total_warnings"`
+}
+
+// PodExpiryInfo summarizes the certificates found in a single pod
+type PodExpiryInfo struct {
+	PodName      string                        `json:"pod_name"`
+	Compute      *ComputeInfo                  `json:"compute,omitempty"`
+	Runtime      *PodRuntime                   `json:"runtime"`
+	Origin       *PodOrigin                    `json:"origin"`
+	CertSources  map[string]*CertificateSource `json:"certificate_sources"`
+	Warnings     []string                      `json:"warnings"`
+	WarningCount int                           `json:"warning_count"`
+	CertCount    int                           `json:"certificate_count"`
+	Findings     []Finding                     `json:"findings,omitempty"`
+}
+
+// NamespaceExpiryReport is the result of a certificate expiry analysis across a namespace
+type NamespaceExpiryReport struct {
+	Namespace         string          `json:"namespace"`
+	WarningDays       int             `json:"warning_days"`
+	TotalPods         int             `json:"total_pods_analyzed"`
+	TotalCertificates int             `json:"total_certificates"`
+	TotalWarnings     int             `json:"total_warnings"`
+	Pods              []PodExpiryInfo `json:"pod_expiry_info"`
+	// CustomResources holds the certificates of the configured custom
+	// resource fields
+	CustomResources []*CertificateSource `json:"custom_resources,omitempty"`
+	Warnings        []string             `json:"all_warnings"`
+	// Findings of the registered custom analyzers
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// ComputeInfo describes where a pod runs: on Fargate, or on a managed or
+// self-managed EC2 node
+type ComputeInfo struct {
+	Type string `json:"type"`
+	// Management is how the EC2 node is managed; empty for Fargate pods or
+	// when nodes cannot be listed
+	Management string `json:"management,omitempty"`
+	Node       string `json:"node,omitempty"`
+	// NodeGroup is the managed nodegroup or Karpenter node pool of an EC2
+	// node, or the Fargate profile of a Fargate pod
+	NodeGroup string `json:"node_group,omitempty"`
+}
+
+// PodRuntime is the runtime status of a pod
+type PodRuntime struct {
+	Phase        string             `json:"phase"`
+	Ready        bool               `json:"ready"`
+	Restarts     int32              `json:"restarts"`
+	CrashLooping bool               `json:"crash_looping"`
+	Containers   []ContainerRuntime `json:"containers"`
+}
+
+// ContainerRuntime is the runtime status of a container of a pod
+type ContainerRuntime struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// ImageID is the digest of the image that runs, which differs from
+	// Image when a tag was moved
+	ImageID  string `json:"image_id,omitempty"`
+	Init     bool   `json:"init,omitempty"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	State    string `json:"state"`
+	// Reason explains a waiting or terminated state, e.g. CrashLoopBackOff
+	Reason string `json:"reason,omitempty"`
+	// LastTermination is the reason the previous instance of a restarted
+	// container stopped, e.g. Error or OOMKilled
+	LastTermination string `json:"last_termination,omitempty"`
+}
+
+// PodOrigin identifies the workload owning a pod and the Helm release or
+// Argo CD application that deployed it
+type PodOrigin struct {
+	Workload WorkloadRef `json:"workload"`
+	// ManagedBy is the app.kubernetes.io/managed-by label, e.g. Helm
+	ManagedBy string        `json:"managed_by,omitempty"`
+	Helm      *HelmOrigin   `json:"helm,omitempty"`
+	ArgoCD    *ArgoCDOrigin `json:"argocd,omitempty"`
+}
+
+// WorkloadRef identifies the top-level controller owning a pod
+type WorkloadRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// HelmOrigin is the Helm release a workload belongs to
+type HelmOrigin struct {
+	Release   string `json:"release"`
+	Namespace string `json:"namespace,omitempty"`
+	Chart     string `json:"chart,omitempty"`
+}
+
+// ArgoCDOrigin is the Argo CD application that syncs a workload
+type ArgoCDOrigin struct {
+	Application string `json:"application"`
+	// Namespace is the namespace of the application when it is outside the
+	// Argo CD control plane namespace
+	Namespace string `json:"namespace,omitempty"`
+}
@@ -0,0 +1,47 @@
+package api
+
+import "time"
+
+// ScanResponse is the response of /scan
+type ScanResponse struct {
+	Status string `json:"status"`
+	*ScanReport
+	Notes []string `json:"notes"`
+}
+
+// ScanReport is the consolidated certificate report of a namespace. Only
+// the included sections are set; a section that fails is reported under
+// Errors without failing the scan.
+type ScanReport struct {
+	Namespace   string                 `json:"namespace"`
+	WarningDays int                    `json:"warning_days"`
+	Summary     ScanSummary            `json:"summary"`
+	Pods        *NamespaceExpiryReport `json:"pods,omitempty"`
+	Secrets     []*CertificateSource   `json:"secrets,omitempty"`
+	Ingress     []InClusterCertificate `json:"ingress,omitempty"`
+	Webhooks    []WebhookCABundle      `json:"webhooks,omitempty"`
+	ClusterCA   *CertificateSource     `json:"cluster_ca,omitempty"`
+	Errors      map[string]string      `json:"errors,omitempty"`
+}
+
+// ScanSummary rolls up every section of a consolidated scan
+type ScanSummary struct {
+	Status            string         `json:"status"`
+	Sections          []string       `json:"sections"`
+	TotalCertificates int            `json:"total_certificates"`
+	BySection         map[string]int `json:"certificates_by_section"`
+	Expired           int            `json:"expired"`
+	ExpiringSoon      int            `json:"expiring_soon"`
+	FailedSections    int            `json:"failed_sections"`
+	SoonestExpiry     *ScanExpiry    `json:"soonest_expiry,omitempty"`
+}
+
+// ScanExpiry locates the certificate that expires first in a scan
+type ScanExpiry struct {
+	Section         string    `json:"section"`
+	Source          string    `json:"source"`
+	Subject         string    `json:"subject"`
+	Fingerprint     string    `json:"fingerprint_sha256"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+}
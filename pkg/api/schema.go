@@ -0,0 +1,294 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the response contracts, embedded in every
+// JSON response as schema_version. The major version changes when a field
+// is removed, renamed, or changes type; the minor version when fields are
+// added.
+const SchemaVersion = "1.8"
+
+// SchemaVersionField is the member of JSON responses holding SchemaVersion
+const SchemaVersionField = "schema_version"
+
+// Schema is a JSON Schema (draft 2020-12) of a response body
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Type is a JSON type, or a list of types for values that may be null
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Const                string             `json:"const,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Schemas are the response bodies with a published schema, by the name of
+// /schemas/{name}.json, with the endpoint they describe
+var Schemas = map[string]struct {
+	Endpoint string
+	Body     interface{}
+}{
+	"pod-certificates":              {"/pod-certificates", PodCertificatesResponse{}},
+	"pod-certificate-details":       {"/pod-certificates/{pod-name}", PodCertificateDetailsResponse{}},
+	"certificate-expiry":            {"/certificate-expiry", CertificateExpiryResponse{}},
+	"certificate-expiry-namespaces": {"/certificate-expiry?namespace=a,b", NamespacesExpiryResponse{}},
+	"pod-certificates-namespaces":   {"/pod-certificates?namespace=a,b", NamespacesPodCertificatesResponse{}},
+	"cluster-ca":                    {"/cluster-ca", ClusterCAResponse{}},
+	"cluster-ca-expiry":             {"/cluster-ca-expiry", ClusterCAExpiryResponse{}},
+	"scan":                          {"/scan", ScanResponse{}},
+	"debug":                         {"/debug", DebugResponse{}},
+	"test-k8s-auth":                 {"/test-k8s-auth", AuthTestResponse{}},
+	"debug-rbac":                    {"/debug/rbac", RBACResponse{}},
+	"keystore-analysis":             {"/analyze/keystore", KeystoreAnalysisResponse{}},
+	"compare":                       {"/compare", CompareResponse{}},
+}
+
+// SchemaNames returns the names of the published schemas in order
+func SchemaNames() []string {
+	names := make([]string, 0, len(Schemas))
+	for name := range Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResponseSchema returns the JSON Schema of a published response body, with
+// the schema_version member the server adds
+func ResponseSchema(name string) (*Schema, error) {
+	entry, ok := Schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q", name)
+	}
+	schema := schemaOf(reflect.TypeOf(entry.Body), map[reflect.Type]bool{})
+	schema.Schema = "https://json-schema.org/draft/2020-12/schema"
+	schema.ID = "/schemas/" + name + ".json"
+	schema.Title = name
+	schema.Description = fmt.Sprintf("Response of %s, schema version %s", entry.Endpoint, SchemaVersion)
+	schema.Properties[SchemaVersionField] = &Schema{Type: "string", Const: SchemaVersion}
+	schema.Required = append(schema.Required, SchemaVersionField)
+	sort.Strings(schema.Required)
+	return schema, nil
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaOf describes how encoding/json encodes values of type t. Types with
+// their own JSON encoding, and types nested in themselves, accept any value.
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t.Kind() == reflect.Pointer {
+		return nullable(schemaOf(t.Elem(), visiting))
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		// nil slices encode as null
+		return nullable(&Schema{Type: "array", Items: schemaOf(t.Elem(), visiting)})
+	case reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), visiting)}
+	case reflect.Map:
+		return nullable(&Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), visiting)})
+	case reflect.Struct:
+		if visiting[t] {
+			return &Schema{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addFields(schema, t, visiting)
+		sort.Strings(schema.Required)
+		return schema
+	}
+	return &Schema{}
+}
+
+// addFields adds the encoded fields of struct type t to schema, including
+// the fields of embedded structs without a JSON name
+func addFields(schema *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addFields(schema, embedded, visiting)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, ok := schema.Properties[name]; ok {
+			continue
+		}
+		schema.Properties[name] = schemaOf(field.Type, visiting)
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// nullable allows null in addition to the type of schema
+func nullable(schema *Schema) *Schema {
+	if typ, ok := schema.Type.(string); ok {
+		schema.Type = []string{typ, "null"}
+	}
+	return schema
+}
+
+// SchemaChange is a difference between two versions of a schema
+type SchemaChange struct {
+	Path     string `json:"path"`
+	Change   string `json:"change"`
+	Breaking bool   `json:"breaking"`
+}
+
+// String describes the change
+func (c SchemaChange) String() string {
+	if c.Breaking {
+		return fmt.Sprintf("%s: %s (breaking)", c.Path, c.Change)
+	}
+	return fmt.Sprintf("%s: %s", c.Path, c.Change)
+}
+
+// CompareSchemas lists the changes from old to new. Removed properties,
+// changed types, and properties that are no longer always present break
+// clients; added properties do not.
+func CompareSchemas(old, new *Schema) []SchemaChange {
+	var changes []SchemaChange
+	compareSchemas("$", old, new, &changes)
+	return changes
+}
+
+// compareSchemas compares the schemas at path
+func compareSchemas(path string, old, new *Schema, changes *[]SchemaChange) {
+	add := func(path, change string, breaking bool) {
+		*changes = append(*changes, SchemaChange{Path: path, Change: change, Breaking: breaking})
+	}
+	if old == nil || new == nil {
+		return
+	}
+	if oldTypes, newTypes := typeList(old.Type), typeList(new.Type); len(oldTypes) > 0 && len(newTypes) > 0 {
+		for _, typ := range oldTypes {
+			if typ != "null" && !contains(newTypes, typ) {
+				add(path, fmt.Sprintf("type changed from %s to %s", strings.Join(oldTypes, "|"), strings.Join(newTypes, "|")), true)
+				break
+			}
+		}
+		if contains(newTypes, "null") && !contains(oldTypes, "null") {
+			add(path, "may now be null", true)
+		}
+	}
+	if old.Format != new.Format && old.Format != "" {
+		add(path, fmt.Sprintf("format changed from %q to %q", old.Format, new.Format), true)
+	}
+
+	names := make([]string, 0, len(old.Properties))
+	for name := range old.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := new.Properties[name]
+		if !ok {
+			add(path+"."+name, "removed", true)
+			continue
+		}
+		compareSchemas(path+"."+name, old.Properties[name], property, changes)
+	}
+	var added []string
+	for name := range new.Properties {
+		if _, ok := old.Properties[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		add(path+"."+name, "added", false)
+	}
+	for _, name := range old.Required {
+		if _, ok := new.Properties[name]; ok && !contains(new.Required, name) {
+			add(path+"."+name, "no longer always present", true)
+		}
+	}
+
+	compareSchemas(path+"[]", old.Items, new.Items, changes)
+	compareSchemas(path+"{}", old.AdditionalProperties, new.AdditionalProperties, changes)
+}
+
+// typeList returns the types of a schema type, which is a type name or a
+// list of them; decoded schemas hold lists as []interface{}
+func typeList(typ interface{}) []string {
+	switch t := typ.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []interface{}:
+		var types []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// contains reports whether values contain value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
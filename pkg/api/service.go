@@ -0,0 +1,95 @@
+package api
+
+import "time"
+
+// RootResponse is the response of /, describing the service and its enabled
+// endpoints
+type RootResponse struct {
+	Status     string         `json:"status"`
+	Message    string         `json:"message"`
+	Version    string         `json:"version"`
+	ServerInfo ServerInfo     `json:"server_info"`
+	Endpoints  []EndpointInfo `json:"endpoints"`
+	// Clusters are the names every endpoint accepts in ?cluster=
+	Clusters    []ClusterEntry `json:"clusters"`
+	PostmanTips []string       `json:"postman_tips"`
+}
+
+// ServerInfo is the address the service listens on
+type ServerInfo struct {
+	Host    string `json:"host"`
+	Port    string `json:"port"`
+	BaseURL string `json:"base_url"`
+}
+
+// EndpointInfo describes an endpoint of a RootResponse
+type EndpointInfo struct {
+	Path             string   `json:"path"`
+	Method           string   `json:"method"`
+	Description      string   `json:"description"`
+	ExampleURL       string   `json:"example_url"`
+	Parameters       []string `json:"parameters,omitempty"`
+	ResponseIncludes []string `json:"response_includes,omitempty"`
+}
+
+// ClusterEntry is a configured cluster a request can select
+type ClusterEntry struct {
+	Name           string `json:"name"`
+	Context        string `json:"context"`
+	ExampleURL     string `json:"example_url"`
+	KubeconfigPath string `json:"kubeconfig_path,omitempty"`
+	Region         string `json:"region,omitempty"`
+}
+
+// HealthResponse is the response of /healthz and /readyz. Drain is set when
+// /readyz reports draining.
+type HealthResponse struct {
+	Status string       `json:"status"`
+	Drain  *DrainStatus `json:"drain,omitempty"`
+}
+
+// DrainStatus is the progress of draining the scan jobs before maintenance
+type DrainStatus struct {
+	Draining            bool           `json:"draining"`
+	Drained             bool           `json:"drained"`
+	StartedAt           *time.Time     `json:"started_at,omitempty"`
+	ElapsedSeconds      float64        `json:"elapsed_seconds,omitempty"`
+	ActiveJobs          int            `json:"active_jobs"`
+	ActiveByKind        map[string]int `json:"active_by_kind"`
+	CompletedSinceDrain int            `json:"completed_since_drain"`
+}
+
+// SchemasResponse is the response of /schemas/
+type SchemasResponse struct {
+	Status  string        `json:"status"`
+	Schemas []SchemaEntry `json:"schemas"`
+	Notes   []string      `json:"notes"`
+}
+
+// SchemaEntry is a published schema of a SchemasResponse
+type SchemaEntry struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	URL      string `json:"url"`
+}
+
+// ReloadResponse is the response of POST /admin/reload
+type ReloadResponse struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Changes []string `json:"changes"`
+}
+
+// DrainResponse is the response of /admin/drain
+type DrainResponse struct {
+	Status  string      `json:"status"`
+	Message string      `json:"message"`
+	Drain   DrainStatus `json:"drain"`
+}
+
+// AgentReportResponse is the response of POST /agent/report
+type AgentReportResponse struct {
+	Status string `json:"status"`
+	Node   string `json:"node"`
+	Files  int    `json:"files"`
+}
@@ -0,0 +1,292 @@
+// Package client is a Go client of the web service API. Its methods send
+// the requests of the endpoints and decode the response bodies of pkg/api;
+// error responses are returned as *Error. Requests are retried on network
+// errors and on 429, 502, 503, and 504 responses, honouring Retry-After.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s-web-service/pkg/api"
+)
+
+// Defaults of a Client
+const (
+	DefaultTimeout    = 2 * time.Minute
+	DefaultMaxRetries = 3
+	DefaultRetryWait  = time.Second
+	// maxRetryWait bounds the backoff and Retry-After delays
+	maxRetryWait = 30 * time.Second
+)
+
+// Client calls the web service API
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+	userAgent  string
+	maxRetries int
+	retryWait  time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests with httpClient instead of a client with
+// DefaultTimeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithBearerToken sends token in the Authorization header, for servers
+// behind an authenticating proxy or ingress
+func WithBearerToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithUserAgent sets the User-Agent header of requests
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// WithRetries retries failed requests up to maxRetries times, waiting wait
+// before the first retry and doubling it for each further one; 0 disables
+// retries
+func WithRetries(maxRetries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryWait = wait
+	}
+}
+
+// New creates a client of the server at baseURL, e.g. http://localhost:8080
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: expected http(s)://host:port", baseURL)
+	}
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		userAgent:  "k8s-web-service-client",
+		maxRetries: DefaultMaxRetries,
+		retryWait:  DefaultRetryWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Error is an error response of the API, an RFC 7807 problem. Type is one
+// of the stable problem type URIs, such as /problems/rbac-denied.
+type Error struct {
+	StatusCode int    `json:"status"`
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Detail     string `json:"detail"`
+	Instance   string `json:"instance"`
+	// InvalidParams lists the rejected query parameters of a 400 response
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// InvalidParam is a query parameter the server rejected
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Error describes the problem
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%s: %d %s: %s", e.Instance, e.StatusCode, e.Title, e.Detail)
+	}
+	return fmt.Sprintf("%s: %d %s", e.Instance, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// IsProblem reports whether err is an API error of a problem type
+func IsProblem(err error, problemType string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Type == problemType
+}
+
+// PodCertificatesOptions are the parameters of ListPodCertificates
+type PodCertificatesOptions struct {
+	// Namespace defaults to kubernetes.default_namespace of the server
+	Namespace string
+	// Detailed includes the certificates of each pod and their expiry
+	Detailed    bool
+	WarningDays int
+}
+
+// ListPodCertificates lists the certificate mounts of the pods of a
+// namespace (/pod-certificates)
+func (c *Client) ListPodCertificates(ctx context.Context, opts PodCertificatesOptions) (*api.PodCertificatesResponse, error) {
+	query := url.Values{}
+	setString(query, "namespace", opts.Namespace)
+	if opts.Detailed {
+		query.Set("detailed", "true")
+	}
+	setInt(query, "warning_days", opts.WarningDays)
+
+	var response api.PodCertificatesResponse
+	if err := c.get(ctx, "/pod-certificates", query, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetClusterCAExpiry analyzes the expiry of the cluster CA bundle
+// (/cluster-ca-expiry); warningDays 0 uses the server default
+func (c *Client) GetClusterCAExpiry(ctx context.Context, warningDays int) (*api.ClusterCAExpiryResponse, error) {
+	query := url.Values{}
+	setInt(query, "warning_days", warningDays)
+
+	var response api.ClusterCAExpiryResponse
+	if err := c.get(ctx, "/cluster-ca-expiry", query, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ScanOptions are the parameters of StartScan
+type ScanOptions struct {
+	// Namespace defaults to kubernetes.default_namespace of the server
+	Namespace string
+	// Include selects sections (pods, secrets, ingress, webhooks,
+	// cluster-ca); all of them if empty
+	Include     []string
+	WarningDays int
+}
+
+// StartScan runs a consolidated scan of a namespace (/scan) and returns the
+// report when it completes. The server refuses scans with 503 while it is
+// draining; the request is then retried after the Retry-After delay.
+func (c *Client) StartScan(ctx context.Context, opts ScanOptions) (*api.ScanResponse, error) {
+	query := url.Values{}
+	setString(query, "namespace", opts.Namespace)
+	setString(query, "include", strings.Join(opts.Include, ","))
+	setInt(query, "warning_days", opts.WarningDays)
+
+	var response api.ScanResponse
+	if err := c.get(ctx, "/scan", query, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// get sends a GET request, retrying failures that may be transient, and
+// decodes the response into out
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	u := *c.baseURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawQuery = query.Encode()
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.do(ctx, u.String(), out)
+		if err == nil {
+			return nil
+		}
+		if retryAfter < 0 || attempt >= c.maxRetries {
+			return err
+		}
+		delay := wait
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		delay = min(delay, maxRetryWait)
+		wait *= 2
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// do sends one request. On failure it returns how long to wait before a
+// retry: the Retry-After delay, 0 for the backoff delay, or -1 if the
+// request must not be retried.
+func (c *Client) do(ctx context.Context, rawURL string, out interface{}) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, err
+		}
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := decodeError(resp)
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return retryAfter(resp), apiErr
+		}
+		return -1, apiErr
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return -1, fmt.Errorf("failed to decode response of %s: %w", req.URL.Path, err)
+	}
+	return 0, nil
+}
+
+// decodeError reads the problem of an error response, or describes the
+// response when its body is not a problem
+func decodeError(resp *http.Response) *Error {
+	apiErr := &Error{StatusCode: resp.StatusCode, Instance: resp.Request.URL.Path}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Title == "" {
+		apiErr.Title = http.StatusText(resp.StatusCode)
+		apiErr.Detail = strings.TrimSpace(string(body))
+	}
+	apiErr.StatusCode = resp.StatusCode
+	return apiErr
+}
+
+// retryAfter returns the delay of the Retry-After header in seconds, or 0
+// if it has none
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// setString sets a query parameter unless value is empty
+func setString(query url.Values, name, value string) {
+	if value != "" {
+		query.Set(name, value)
+	}
+}
+
+// setInt sets a query parameter unless value is 0
+func setInt(query url.Values, name string, value int) {
+	if value != 0 {
+		query.Set(name, strconv.Itoa(value))
+	}
+}
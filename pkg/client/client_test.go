@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListPodCertificates(t *testing.T) {
+	var query, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","target_namespace":"payments"}`))
+	}))
+	defer server.Close()
+
+	c, err := New(server.URL, WithBearerToken("secret"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	response, err := c.ListPodCertificates(context.Background(), PodCertificatesOptions{Namespace: "payments", Detailed: true, WarningDays: 14})
+	if err != nil {
+		t.Fatalf("ListPodCertificates: %v", err)
+	}
+	if response.TargetNamespace != "payments" {
+		t.Errorf("target_namespace = %q, want payments", response.TargetNamespace)
+	}
+	if want := "detailed=true&namespace=payments&warning_days=14"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the bearer token", authorization)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+	defer server.Close()
+
+	c, err := New(server.URL, WithRetries(2, time.Millisecond))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c.StartScan(context.Background(), ScanOptions{}); err != nil {
+		t.Fatalf("StartScan: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestProblemErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"type":"/problems/rbac-denied","title":"Forbidden","status":403,"detail":"cannot list pods"}`))
+	}))
+	defer server.Close()
+
+	c, err := New(server.URL, WithRetries(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = c.GetClusterCAExpiry(context.Background(), 0)
+	if !IsProblem(err, "/problems/rbac-denied") {
+		t.Fatalf("GetClusterCAExpiry error = %v, want the rbac-denied problem", err)
+	}
+	if apiErr := err.(*Error); apiErr.StatusCode != http.StatusForbidden || apiErr.Instance != "/cluster-ca-expiry" {
+		t.Errorf("error = %+v, want status 403 of /cluster-ca-expiry", apiErr)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want no retry of a 403", attempts)
+	}
+}
+
+func TestNewRejectsInvalidBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"", "localhost:8080", "http://"} {
+		if _, err := New(baseURL); err == nil {
+			t.Errorf("New(%q) succeeded", baseURL)
+		}
+	}
+}
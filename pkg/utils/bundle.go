@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"sort"
+	"time"
+)
+
+// BundleSummary aggregates the certificates of a bundle too large to list,
+// such as a system trust store with 150+ roots
+type BundleSummary struct {
+	TotalCertificates int `json:"total_certificates"`
+	CACertificates    int `json:"ca_certificates"`
+	Expired           int `json:"expired"`
+	// Expiring counts the certificates expiring within the warning days
+	Expiring       int        `json:"expiring"`
+	EarliestExpiry *time.Time `json:"earliest_expiry,omitempty"`
+	LatestExpiry   *time.Time `json:"latest_expiry,omitempty"`
+	// Listed is the number of certificates still listed: the expired and
+	// expiring ones, soonest first, up to the cap
+	Listed  int `json:"listed"`
+	Omitted int `json:"omitted"`
+}
+
+// SummarizeCertificates summarizes certs when there are more than max of
+// them, returning the expired and expiring certificates, soonest expiry
+// first and at most max, with the summary. Fewer certificates are returned
+// as they are with a nil summary.
+func SummarizeCertificates(certs []*CertificateInfo, warningDays, max int) ([]*CertificateInfo, *BundleSummary) {
+	if max <= 0 || len(certs) <= max {
+		return certs, nil
+	}
+
+	summary := &BundleSummary{TotalCertificates: len(certs)}
+	var listed []*CertificateInfo
+	for _, cert := range certs {
+		if cert.IsCA {
+			summary.CACertificates++
+		}
+		notAfter := cert.NotAfter
+		if summary.EarliestExpiry == nil || notAfter.Before(*summary.EarliestExpiry) {
+			summary.EarliestExpiry = &notAfter
+		}
+		if summary.LatestExpiry == nil || notAfter.After(*summary.LatestExpiry) {
+			summary.LatestExpiry = &notAfter
+		}
+		switch {
+		case cert.IsExpired:
+			summary.Expired++
+		case cert.DaysUntilExp <= warningDays:
+			summary.Expiring++
+		default:
+			continue
+		}
+		listed = append(listed, cert)
+	}
+
+	sort.SliceStable(listed, func(i, j int) bool { return listed[i].NotAfter.Before(listed[j].NotAfter) })
+	if len(listed) > max {
+		listed = listed[:max]
+	}
+	summary.Listed = len(listed)
+	summary.Omitted = len(certs) - len(listed)
+	return listed, summary
+}
@@ -1,11 +1,20 @@
 package utils
 
 import (
+	"crypto"
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"strings"
 	"time"
+
+	"golang.org/x/crypto/pkcs12"
 )
 
 // CertificateInfo contains parsed certificate information
@@ -17,33 +26,58 @@ type CertificateInfo struct {
 	NotAfter     time.Time `json:"not_after"`
 	IsExpired    bool      `json:"is_expired"`
 	DaysUntilExp int       `json:"days_until_expiry"`
-	DNSNames     []string  `json:"dns_names,omitempty"`
-	IPAddresses  []string  `json:"ip_addresses,omitempty"`
-	KeyUsage     []string  `json:"key_usage,omitempty"`
-	IsCA         bool      `json:"is_ca"`
+	// TimeRemaining is the human-readable time until expiry, filled in by
+	// the endpoints that report it
+	TimeRemaining string   `json:"time_remaining,omitempty"`
+	DNSNames      []string `json:"dns_names,omitempty"`
+	IPAddresses   []string `json:"ip_addresses,omitempty"`
+	KeyUsage      []string `json:"key_usage,omitempty"`
+	IsCA          bool     `json:"is_ca"`
+	// KeyAlgorithm is RSA, ECDSA, Ed25519, or DSA, and KeySize the size of
+	// the public key in bits
+	KeyAlgorithm       string `json:"key_algorithm,omitempty"`
+	KeySize            int    `json:"key_size,omitempty"`
+	SignatureAlgorithm string `json:"signature_algorithm,omitempty"`
+	// Fingerprint is the SHA-256 digest of the DER certificate in hex, which
+	// identifies it without revealing its contents
+	Fingerprint string `json:"fingerprint_sha256"`
 }
 
-// ParseCertificate parses a PEM-encoded certificate and extracts information
-func ParseCertificate(certPEM string) (*CertificateInfo, error) {
-	// Clean up the certificate string
-	certPEM = strings.TrimSpace(certPEM)
-
-	// Decode PEM block
-	block, _ := pem.Decode([]byte(certPEM))
-	if block == nil {
-		return nil, fmt.Errorf("failed to decode PEM block")
-	}
+// Fingerprint returns the SHA-256 fingerprint of a DER certificate in hex
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
 
-	if block.Type != "CERTIFICATE" {
-		return nil, fmt.Errorf("not a certificate, found: %s", block.Type)
+// PEMFingerprints returns the SHA-256 fingerprints of the certificates in a
+// PEM bundle, skipping other blocks
+func PEMFingerprints(bundle string) []string {
+	var fingerprints []string
+	rest := []byte(bundle)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return fingerprints
+		}
+		if block.Type == "CERTIFICATE" {
+			fingerprints = append(fingerprints, Fingerprint(block.Bytes))
+		}
 	}
+}
 
-	// Parse the certificate
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+// ParseCertificate parses the first block of a PEM-encoded certificate and
+// extracts information. It fails with a *PEMError locating the problem.
+func ParseCertificate(certPEM string) (*CertificateInfo, error) {
+	certs, errs := diagnosePEM([]byte(certPEM), 1)
+	if len(certs) == 0 {
+		return nil, errs[0]
 	}
+	return NewCertificateInfo(certs[0]), nil
+}
 
+// NewCertificateInfo extracts the information of a parsed certificate
+func NewCertificateInfo(cert *x509.Certificate) *CertificateInfo {
 	// Calculate days until expiry
 	now := time.Now()
 	daysUntilExp := int(cert.NotAfter.Sub(now).Hours() / 24)
@@ -76,104 +110,90 @@ func ParseCertificate(certPEM string) (*CertificateInfo, error) {
 		keyUsage = append(keyUsage, "CRL Sign")
 	}
 
-	return &CertificateInfo{
-		Subject:      cert.Subject.String(),
-		Issuer:       cert.Issuer.String(),
-		SerialNumber: cert.SerialNumber.String(),
-		NotBefore:    cert.NotBefore,
-		NotAfter:     cert.NotAfter,
-		IsExpired:    isExpired,
-		DaysUntilExp: daysUntilExp,
-		DNSNames:     cert.DNSNames,
-		IPAddresses:  ipAddresses,
-		KeyUsage:     keyUsage,
-		IsCA:         cert.IsCA,
-	}, nil
+	info := &CertificateInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SerialNumber:       cert.SerialNumber.String(),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		IsExpired:          isExpired,
+		DaysUntilExp:       daysUntilExp,
+		DNSNames:           cert.DNSNames,
+		IPAddresses:        ipAddresses,
+		KeyUsage:           keyUsage,
+		IsCA:               cert.IsCA,
+		Fingerprint:        Fingerprint(cert.Raw),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+	}
+	info.KeyAlgorithm, info.KeySize = publicKeyInfo(cert)
+	return info
 }
 
-// ParseCertificateBundle parses multiple certificates from a bundle
+// publicKeyInfo returns the algorithm and size in bits of the public key of
+// a certificate
+func publicKeyInfo(cert *x509.Certificate) (string, int) {
+	if algorithm, size := publicKeyAlgorithm(cert.PublicKey); algorithm != "" {
+		return algorithm, size
+	}
+	return cert.PublicKeyAlgorithm.String(), 0
+}
+
+// publicKeyAlgorithm returns the algorithm and size in bits of a public key,
+// or "" for an unknown key type
+func publicKeyAlgorithm(key crypto.PublicKey) (string, int) {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	case *dsa.PublicKey:
+		return "DSA", key.P.BitLen()
+	}
+	return "", 0
+}
+
+// ParseCertificateBundle parses multiple certificates from a bundle,
+// skipping blocks that are not certificates or do not parse. It fails with
+// the *PEMError of the first such block when none parses.
 func ParseCertificateBundle(certBundle string) ([]*CertificateInfo, error) {
-	var certificates []*CertificateInfo
+	certificates, errs := ParseCertificateBundleDiagnostics(certBundle)
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("no valid certificates found in bundle: %w", errs[0])
+	}
+	return certificates, nil
+}
 
-	// Split the bundle into individual certificates
-	certBundle = strings.TrimSpace(certBundle)
+// ParseCertificateBundleDiagnostics parses the certificates of a bundle and
+// reports each block that did not yield one
+func ParseCertificateBundleDiagnostics(certBundle string) ([]*CertificateInfo, []*PEMError) {
+	certs, errs := DiagnosePEM([]byte(certBundle))
+	certificates := make([]*CertificateInfo, 0, len(certs))
+	for _, cert := range certs {
+		certificates = append(certificates, NewCertificateInfo(cert))
+	}
+	return certificates, errs
+}
 
-	// Find all certificate blocks
-	remaining := certBundle
-	for {
-		block, rest := pem.Decode([]byte(remaining))
-		if block == nil {
-			break
-		}
+// ParsePKCS12 parses the certificates of a PKCS#12 (.p12/.pfx) archive.
+// Private keys in the archive are discarded.
+func ParsePKCS12(data []byte, password string) ([]*CertificateInfo, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PKCS#12 data: %w", err)
+	}
 
+	var bundle strings.Builder
+	for _, block := range blocks {
 		if block.Type == "CERTIFICATE" {
-			cert, err := x509.ParseCertificate(block.Bytes)
-			if err != nil {
-				// Skip invalid certificates but continue processing
-				remaining = string(rest)
-				continue
-			}
-
-			// Calculate days until expiry
-			now := time.Now()
-			daysUntilExp := int(cert.NotAfter.Sub(now).Hours() / 24)
-			isExpired := now.After(cert.NotAfter)
-
-			// Extract IP addresses
-			var ipAddresses []string
-			for _, ip := range cert.IPAddresses {
-				ipAddresses = append(ipAddresses, ip.String())
-			}
-
-			// Extract key usage
-			var keyUsage []string
-			if cert.KeyUsage&x509.KeyUsageDigitalSignature != 0 {
-				keyUsage = append(keyUsage, "Digital Signature")
-			}
-			if cert.KeyUsage&x509.KeyUsageKeyEncipherment != 0 {
-				keyUsage = append(keyUsage, "Key Encipherment")
-			}
-			if cert.KeyUsage&x509.KeyUsageDataEncipherment != 0 {
-				keyUsage = append(keyUsage, "Data Encipherment")
-			}
-			if cert.KeyUsage&x509.KeyUsageKeyAgreement != 0 {
-				keyUsage = append(keyUsage, "Key Agreement")
-			}
-			if cert.KeyUsage&x509.KeyUsageCertSign != 0 {
-				keyUsage = append(keyUsage, "Certificate Sign")
-			}
-			if cert.KeyUsage&x509.KeyUsageCRLSign != 0 {
-				keyUsage = append(keyUsage, "CRL Sign")
-			}
-
-			certInfo := &CertificateInfo{
-				Subject:      cert.Subject.String(),
-				Issuer:       cert.Issuer.String(),
-				SerialNumber: cert.SerialNumber.String(),
-				NotBefore:    cert.NotBefore,
-				NotAfter:     cert.NotAfter,
-				IsExpired:    isExpired,
-				DaysUntilExp: daysUntilExp,
-				DNSNames:     cert.DNSNames,
-				IPAddresses:  ipAddresses,
-				KeyUsage:     keyUsage,
-				IsCA:         cert.IsCA,
-			}
-
-			certificates = append(certificates, certInfo)
-		}
-
-		remaining = string(rest)
-		if len(remaining) == 0 {
-			break
+			bundle.Write(pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes}))
 		}
 	}
-
-	if len(certificates) == 0 {
-		return nil, fmt.Errorf("no valid certificates found in bundle")
+	if bundle.Len() == 0 {
+		return nil, fmt.Errorf("no certificates found in PKCS#12 data")
 	}
-
-	return certificates, nil
+	return ParseCertificateBundle(bundle.String())
 }
 
 // ValidateCertificateExpiry checks if certificates are expiring soon
@@ -192,3 +212,19 @@ func ValidateCertificateExpiry(certs []*CertificateInfo, warningDays int) []stri
 
 	return warnings
 }
+
+// MatchesHostname reports whether a certificate name, which may be a
+// wildcard such as *.example.com, covers host. A wildcard matches exactly
+// one label, and the comparison ignores case and trailing dots.
+func MatchesHostname(name, host string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if name == host {
+		return true
+	}
+	if suffix, ok := strings.CutPrefix(name, "*."); ok {
+		label, rest, found := strings.Cut(host, ".")
+		return found && label != "" && rest == suffix
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+)
+
+// Trust sources a chain is verified against
+const (
+	TrustClusterCA = "cluster_ca"
+	TrustSystem    = "system"
+)
+
+// ChainVerification describes the chain built from a leaf certificate
+// through the presented intermediates to a root, and whether it validates
+// against the cluster CA or the system trust store
+type ChainVerification struct {
+	// Chain lists the subjects of the chain from the leaf on: the verified
+	// chain including its root, or the certificates found when it does not
+	// verify
+	Chain []string `json:"chain"`
+	// WrongOrder reports that the presented certificates are not ordered
+	// leaf first, each followed by its issuer
+	WrongOrder bool `json:"wrong_order"`
+	// MissingIssuer is the issuer of the last certificate found when it is
+	// neither presented nor a trusted root, usually a missing intermediate
+	MissingIssuer string `json:"missing_issuer,omitempty"`
+	Verified      bool   `json:"verified"`
+	// TrustedBy is the trust source the chain validates against
+	TrustedBy string `json:"trusted_by,omitempty"`
+	// Errors are the verification failures per trust source when the chain
+	// does not verify
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ChainVerify builds the chain of the first certificate of a PEM bundle
+// that is not a CA, using the other certificates of the bundle as
+// intermediates, and verifies it against clusterCA and the system roots.
+// It returns nil for bundles of CA certificates only, which are trust
+// bundles rather than chains.
+func ChainVerify(certPEM, clusterCA string) *ChainVerification {
+	certs, _ := diagnosePEM([]byte(certPEM), -1)
+	leafIndex := -1
+	for i, cert := range certs {
+		if !cert.IsCA {
+			leafIndex = i
+			break
+		}
+	}
+	if leafIndex < 0 {
+		return nil
+	}
+	leaf := certs[leafIndex]
+	result := &ChainVerification{Errors: make(map[string]string)}
+
+	// Follow the issuers through the presented certificates
+	chain := []*x509.Certificate{leaf}
+	used := map[int]bool{leafIndex: true}
+	for current := leaf; !selfSigned(current); {
+		issuer := -1
+		for i, candidate := range certs {
+			if !used[i] && bytes.Equal(candidate.RawSubject, current.RawIssuer) && current.CheckSignatureFrom(candidate) == nil {
+				issuer = i
+				break
+			}
+		}
+		if issuer < 0 {
+			break
+		}
+		used[issuer] = true
+		current = certs[issuer]
+		chain = append(chain, current)
+	}
+	result.WrongOrder = leafIndex != 0
+	for i, cert := range chain {
+		if i >= len(certs) || certs[i] != cert {
+			result.WrongOrder = true
+		}
+	}
+
+	intermediates := x509.NewCertPool()
+	for i, cert := range certs {
+		if i != leafIndex {
+			intermediates.AddCert(cert)
+		}
+	}
+	var verified []*x509.Certificate
+	for _, trust := range []string{TrustClusterCA, TrustSystem} {
+		roots, err := trustRoots(trust, clusterCA)
+		if err != nil {
+			result.Errors[trust] = err.Error()
+			continue
+		}
+		chains, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			result.Errors[trust] = err.Error()
+			continue
+		}
+		verified = chains[0]
+		result.Verified = true
+		result.TrustedBy = trust
+		result.Errors = nil
+		break
+	}
+
+	if verified != nil {
+		chain = verified
+	} else if last := chain[len(chain)-1]; !selfSigned(last) {
+		result.MissingIssuer = last.Issuer.String()
+	}
+	for _, cert := range chain {
+		result.Chain = append(result.Chain, cert.Subject.String())
+	}
+	return result
+}
+
+// trustRoots returns the root pool of a trust source
+func trustRoots(trust, clusterCA string) (*x509.CertPool, error) {
+	if trust == TrustSystem {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the system trust store: %w", err)
+		}
+		return roots, nil
+	}
+	if clusterCA == "" {
+		return nil, fmt.Errorf("the cluster CA is not available")
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(clusterCA)) {
+		return nil, fmt.Errorf("the cluster CA holds no PEM certificates")
+	}
+	return roots, nil
+}
+
+// selfSigned reports whether a certificate issued itself, ending a chain
+func selfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+}
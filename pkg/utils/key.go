@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// PrivateKeyInfo describes the private key stored next to a certificate.
+// None of the key material is kept.
+type PrivateKeyInfo struct {
+	// Algorithm is RSA, ECDSA, or Ed25519, and Size the key size in bits
+	Algorithm string `json:"algorithm,omitempty"`
+	Size      int    `json:"size,omitempty"`
+	// MatchesCertificate reports whether the key's public key is the one
+	// of the certificate, so that the pair can be used to serve TLS
+	MatchesCertificate bool   `json:"matches_certificate"`
+	Error              string `json:"error,omitempty"`
+}
+
+// CheckPrivateKey parses the first PEM private key of keyPEM, in PKCS#1,
+// PKCS#8, or SEC 1 form, and compares its public key with the one of the
+// first certificate of certPEM, the leaf of a tls.crt chain. A key that
+// cannot be compared is described in Error.
+func CheckPrivateKey(keyPEM, certPEM string) *PrivateKeyInfo {
+	info := &PrivateKeyInfo{}
+	key, err := parsePrivateKey([]byte(keyPEM))
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	public := key.Public()
+	info.Algorithm, info.Size = publicKeyAlgorithm(public)
+
+	certs, errs := diagnosePEM([]byte(certPEM), 1)
+	if len(certs) == 0 {
+		info.Error = fmt.Sprintf("no certificate to compare the key with: %v", errs[0])
+		return info
+	}
+	matcher, ok := public.(interface{ Equal(crypto.PublicKey) bool })
+	info.MatchesCertificate = ok && matcher.Equal(certs[0].PublicKey)
+	return info
+}
+
+// parsePrivateKey returns the first private key of PEM data
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM private key found")
+		}
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+		if block.Type == "ENCRYPTED PRIVATE KEY" || block.Headers["Proc-Type"] == "4,ENCRYPTED" {
+			return nil, fmt.Errorf("the private key is encrypted")
+		}
+
+		var key interface{}
+		var err error
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		default:
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", strings.ToLower(block.Type), err)
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+}
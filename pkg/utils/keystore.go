@@ -0,0 +1,279 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// Keystore formats
+const (
+	KeystorePKCS12 = "pkcs12"
+	KeystoreJKS    = "jks"
+)
+
+// Keystore entry types, named as in Java's KeyStore
+const (
+	KeystorePrivateKeyEntry  = "private_key"
+	KeystoreTrustedCertEntry = "trusted_certificate"
+)
+
+// jksMagic starts every JKS keystore
+const jksMagic = 0xFEEDFEED
+
+// jksDigestSalt is mixed into the integrity digest of JKS keystores
+const jksDigestSalt = "Mighty Aphrodite"
+
+// KeystoreEntry is an alias of a keystore with its certificates; the chain
+// of a private key entry starts with the key's certificate
+type KeystoreEntry struct {
+	Alias        string             `json:"alias,omitempty"`
+	Type         string             `json:"type"`
+	Certificates []*CertificateInfo `json:"certificates"`
+}
+
+// Keystore is the content of a PKCS#12 or JKS keystore. Private keys are
+// never decoded.
+type Keystore struct {
+	Format  string          `json:"format"`
+	Entries []KeystoreEntry `json:"entries"`
+	// IntegrityVerified is true when the password checked the keystore's
+	// MAC or digest. JKS certificates can be read without the password.
+	IntegrityVerified bool `json:"integrity_verified"`
+}
+
+// Certificates returns the certificates of every entry
+func (k *Keystore) Certificates() []*CertificateInfo {
+	var certs []*CertificateInfo
+	for _, entry := range k.Entries {
+		certs = append(certs, entry.Certificates...)
+	}
+	return certs
+}
+
+// DetectKeystoreFormat returns the format of keystore data from its first
+// bytes, or "" if it is neither JKS nor a DER SEQUENCE like PKCS#12
+func DetectKeystoreFormat(data []byte) string {
+	switch {
+	case len(data) >= 4 && binary.BigEndian.Uint32(data) == jksMagic:
+		return KeystoreJKS
+	case len(data) > 0 && data[0] == 0x30:
+		return KeystorePKCS12
+	}
+	return ""
+}
+
+// ParseKeystore parses the certificates of a PKCS#12 or JKS keystore in the
+// given format, or the detected one if format is empty
+func ParseKeystore(data []byte, password, format string) (*Keystore, error) {
+	if format == "" {
+		format = DetectKeystoreFormat(data)
+	}
+	switch format {
+	case KeystorePKCS12:
+		return parsePKCS12Keystore(data, password)
+	case KeystoreJKS:
+		return parseJKS(data, password)
+	case "":
+		return nil, fmt.Errorf("data is neither a PKCS#12 nor a JKS keystore")
+	}
+	return nil, fmt.Errorf("unsupported keystore format %q", format)
+}
+
+// parsePKCS12Keystore groups the certificates of a PKCS#12 archive into the
+// entries of its private keys, matched by localKeyId, and one trusted
+// certificate entry for the rest
+func parsePKCS12Keystore(data []byte, password string) (*Keystore, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PKCS#12 data: %w", err)
+	}
+
+	keyAliases := make(map[string]string)
+	for _, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			keyAliases[block.Headers["localKeyId"]] = block.Headers["friendlyName"]
+		}
+	}
+
+	keystore := &Keystore{Format: KeystorePKCS12, IntegrityVerified: true}
+	entries := make(map[string]int)
+	trusted := KeystoreEntry{Type: KeystoreTrustedCertEntry}
+	for _, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := ParseCertificate(string(pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes})))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate of PKCS#12 data: %w", err)
+		}
+		id := block.Headers["localKeyId"]
+		alias, isKey := keyAliases[id]
+		if id == "" || !isKey {
+			if trusted.Alias == "" {
+				trusted.Alias = block.Headers["friendlyName"]
+			}
+			trusted.Certificates = append(trusted.Certificates, cert)
+			continue
+		}
+		if alias == "" {
+			alias = block.Headers["friendlyName"]
+		}
+		i, ok := entries[id]
+		if !ok {
+			i = len(keystore.Entries)
+			entries[id] = i
+			keystore.Entries = append(keystore.Entries, KeystoreEntry{Alias: alias, Type: KeystorePrivateKeyEntry})
+		}
+		keystore.Entries[i].Certificates = append(keystore.Entries[i].Certificates, cert)
+	}
+	if len(trusted.Certificates) > 0 {
+		keystore.Entries = append(keystore.Entries, trusted)
+	}
+	if len(keystore.Entries) == 0 {
+		return nil, fmt.Errorf("no certificates found in PKCS#12 data")
+	}
+	return keystore, nil
+}
+
+// jksReader reads the big-endian fields of a JKS keystore
+type jksReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+// uint32 reads a 32-bit integer
+func (j *jksReader) uint32() uint32 {
+	var v uint32
+	if j.err == nil {
+		j.err = binary.Read(j.r, binary.BigEndian, &v)
+	}
+	return v
+}
+
+// bytes reads n bytes, failing rather than allocating past the end of the
+// data
+func (j *jksReader) bytes(n int) []byte {
+	if j.err != nil {
+		return nil
+	}
+	if n < 0 || n > j.r.Len() {
+		j.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := make([]byte, n)
+	_, j.err = io.ReadFull(j.r, b)
+	return b
+}
+
+// utf reads a Java modified UTF-8 string with its 16-bit length
+func (j *jksReader) utf() string {
+	var n uint16
+	if j.err == nil {
+		j.err = binary.Read(j.r, binary.BigEndian, &n)
+	}
+	return string(j.bytes(int(n)))
+}
+
+// certificate reads a certificate of an entry, typed in version 2
+// keystores
+func (j *jksReader) certificate(version uint32) ([]byte, error) {
+	if version == 2 {
+		if certType := j.utf(); j.err == nil && certType != "X.509" {
+			return nil, fmt.Errorf("unsupported certificate type %q", certType)
+		}
+	}
+	return j.bytes(int(j.uint32())), j.err
+}
+
+// parseJKS parses the certificates of a JKS keystore and, given a password,
+// checks its integrity digest
+func parseJKS(data []byte, password string) (*Keystore, error) {
+	j := &jksReader{r: bytes.NewReader(data)}
+	if j.uint32() != jksMagic {
+		return nil, fmt.Errorf("data is not a JKS keystore")
+	}
+	version := j.uint32()
+	if version != 1 && version != 2 {
+		return nil, fmt.Errorf("unsupported JKS version %d", version)
+	}
+
+	keystore := &Keystore{Format: KeystoreJKS}
+	count := j.uint32()
+	for i := uint32(0); i < count && j.err == nil; i++ {
+		tag := j.uint32()
+		entry := KeystoreEntry{Alias: j.utf()}
+		j.bytes(8) // creation time
+		var ders [][]byte
+		switch tag {
+		case 1:
+			entry.Type = KeystorePrivateKeyEntry
+			j.bytes(int(j.uint32())) // encrypted private key
+			for n := j.uint32(); n > 0 && j.err == nil; n-- {
+				der, err := j.certificate(version)
+				if err != nil {
+					return nil, fmt.Errorf("entry %q: %w", entry.Alias, err)
+				}
+				ders = append(ders, der)
+			}
+		case 2:
+			entry.Type = KeystoreTrustedCertEntry
+			der, err := j.certificate(version)
+			if err != nil {
+				return nil, fmt.Errorf("entry %q: %w", entry.Alias, err)
+			}
+			ders = append(ders, der)
+		default:
+			return nil, fmt.Errorf("unsupported JKS entry type %d; JCEKS secret keys are not supported", tag)
+		}
+		if j.err != nil {
+			break
+		}
+		for _, der := range ders {
+			cert, err := ParseCertificate(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+			if err != nil {
+				return nil, fmt.Errorf("entry %q: %w", entry.Alias, err)
+			}
+			entry.Certificates = append(entry.Certificates, cert)
+		}
+		keystore.Entries = append(keystore.Entries, entry)
+	}
+	if j.err != nil {
+		return nil, fmt.Errorf("failed to read JKS keystore: %w", j.err)
+	}
+
+	if password != "" {
+		signed := len(data) - j.r.Len()
+		digest := j.bytes(sha1.Size)
+		if j.err != nil {
+			return nil, fmt.Errorf("JKS keystore has no integrity digest: %w", j.err)
+		}
+		if subtle.ConstantTimeCompare(digest, jksDigest(data[:signed], password)) != 1 {
+			return nil, fmt.Errorf("keystore password is incorrect or the keystore was modified")
+		}
+		keystore.IntegrityVerified = true
+	}
+	if len(keystore.Entries) == 0 {
+		return nil, fmt.Errorf("no certificates found in JKS keystore")
+	}
+	return keystore, nil
+}
+
+// jksDigest computes the integrity digest of a JKS keystore: SHA-1 over the
+// UTF-16 password, a fixed salt, and the keystore
+func jksDigest(data []byte, password string) []byte {
+	h := sha1.New()
+	for _, c := range utf16.Encode([]rune(password)) {
+		h.Write([]byte{byte(c >> 8), byte(c)})
+	}
+	h.Write([]byte(jksDigestSalt))
+	h.Write(data)
+	return h.Sum(nil)
+}
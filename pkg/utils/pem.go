@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+)
+
+// Kinds of PEM parse failures
+const (
+	PEMErrorNotPEM         = "not_pem"         // no PEM block, or a block cut off or with a corrupt body
+	PEMErrorNotCertificate = "not_certificate" // a PEM block of another type, such as a private key
+	PEMErrorCorruptDER     = "corrupt_der"     // a CERTIFICATE block whose DER does not parse
+)
+
+// pemBegin starts every PEM block
+var pemBegin = []byte("-----BEGIN ")
+
+// PEMError locates the part of PEM data that did not yield a certificate
+type PEMError struct {
+	Kind string `json:"kind"`
+	// Key is the secret or configmap key holding the data, when known
+	Key string `json:"key,omitempty"`
+	// Block is the index of the PEM block from 0, or -1 when the data holds
+	// no PEM block
+	Block     int    `json:"block"`
+	BlockType string `json:"block_type,omitempty"`
+	// Offset and Line locate the start of the block: the byte offset from 0
+	// and the line from 1
+	Offset int    `json:"offset"`
+	Line   int    `json:"line"`
+	Detail string `json:"detail"`
+}
+
+// Error describes where and why the data failed to parse
+func (e *PEMError) Error() string {
+	location := fmt.Sprintf("block %d at line %d (byte %d)", e.Block, e.Line, e.Offset)
+	if e.Block < 0 {
+		location = fmt.Sprintf("line %d (byte %d)", e.Line, e.Offset)
+	}
+	if e.Key != "" {
+		location = fmt.Sprintf("key %s, %s", e.Key, location)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, e.Kind, e.Detail)
+}
+
+// DiagnosePEM parses the certificates of PEM data and reports every block
+// that did not yield one: blocks of other types, blocks that are cut off or
+// whose base64 body is corrupt, and certificates whose DER does not parse.
+// Data without any PEM block is reported as one not_pem error. Text between
+// blocks, such as the comments of a CA bundle, is ignored.
+func DiagnosePEM(data []byte) ([]*x509.Certificate, []*PEMError) {
+	return diagnosePEM(data, -1)
+}
+
+// diagnosePEM parses at most max blocks of PEM data, or all when max < 0
+func diagnosePEM(data []byte, max int) ([]*x509.Certificate, []*PEMError) {
+	var certs []*x509.Certificate
+	var errs []*PEMError
+
+	offset := 0
+	for index := 0; max < 0 || index < max; index++ {
+		start := bytes.Index(data[offset:], pemBegin)
+		if start < 0 {
+			break
+		}
+		start += offset
+		// Decode the block alone, so that a broken block is not skipped for
+		// the next one
+		end := len(data)
+		if next := bytes.Index(data[start+len(pemBegin):], pemBegin); next >= 0 {
+			end = start + len(pemBegin) + next
+		}
+		offset = end
+
+		pemErr := &PEMError{Block: index, Offset: start, Line: lineAt(data, start)}
+		block, _ := pem.Decode(data[start:end])
+		switch {
+		case block == nil:
+			pemErr.Kind = PEMErrorNotPEM
+			pemErr.BlockType = blockType(data[start:end])
+			pemErr.Detail = "the block has no matching END line or its base64 body is corrupt; it may be truncated"
+		case block.Type != "CERTIFICATE":
+			pemErr.Kind = PEMErrorNotCertificate
+			pemErr.BlockType = block.Type
+			pemErr.Detail = fmt.Sprintf("found a %s block where a CERTIFICATE was expected", block.Type)
+		default:
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				certs = append(certs, cert)
+				continue
+			}
+			pemErr.Kind = PEMErrorCorruptDER
+			pemErr.BlockType = block.Type
+			pemErr.Detail = fmt.Sprintf("the certificate DER does not parse: %v", err)
+		}
+		errs = append(errs, pemErr)
+	}
+
+	if len(certs) == 0 && len(errs) == 0 {
+		errs = append(errs, notPEMError(data))
+	}
+	return certs, errs
+}
+
+// notPEMError describes data that holds no PEM block, guessing at the
+// encoding it was stored in instead
+func notPEMError(data []byte) *PEMError {
+	start := len(data) - len(bytes.TrimLeft(data, " \t\r\n"))
+	pemErr := &PEMError{Kind: PEMErrorNotPEM, Block: -1, Offset: start, Line: lineAt(data, start)}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0:
+		pemErr.Detail = "the data is empty"
+	case trimmed[0] == 0x30:
+		pemErr.Detail = "the data looks like binary DER; a PEM CERTIFICATE block was expected"
+	case isBase64(trimmed):
+		pemErr.Detail = "the data is base64 without PEM armor; it may be base64-encoded twice"
+	default:
+		pemErr.Detail = "no -----BEGIN line found"
+	}
+	return pemErr
+}
+
+// isBase64 reports whether data decodes as standard base64, ignoring line
+// breaks
+func isBase64(data []byte) bool {
+	compact := bytes.Join(bytes.Fields(data), nil)
+	_, err := base64.StdEncoding.DecodeString(string(compact))
+	return err == nil
+}
+
+// blockType returns the type named by the BEGIN line of a PEM block
+func blockType(block []byte) string {
+	line := block[len(pemBegin):]
+	if end := bytes.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	return string(bytes.TrimSuffix(bytes.TrimSpace(line), []byte("-----")))
+}
+
+// lineAt returns the line, from 1, of a byte offset
+func lineAt(data []byte, offset int) int {
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}